@@ -0,0 +1,375 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"url-db/internal/constants"
+)
+
+// JSON-RPC error codes reported for failures inside the bridge
+const (
+	parseErrorCode    = -32700
+	internalErrorCode = -32603
+)
+
+// idempotentMethods are the JSON-RPC methods that only read, so sending one twice
+// is harmless
+var idempotentMethods = map[string]bool{
+	"initialize":               true,
+	"ping":                     true,
+	"tools/list":               true,
+	"resources/list":           true,
+	"resources/templates/list": true,
+	"resources/read":           true,
+	"prompts/list":             true,
+	"prompts/get":              true,
+}
+
+// Bridge relays newline-delimited JSON-RPC messages from a stdio MCP client to
+// url-db servers running in http or sse mode
+type Bridge struct {
+	defaultEndpoint string
+	// routes maps a composite ID's tool-name segment to the endpoint serving it
+	routes map[string]string
+	// doneEvent is the SSE event that ends a response stream ("" = read to EOF)
+	doneEvent string
+	// apiKey is sent as a bearer token to servers requiring MCP_API_KEY ("" = none)
+	apiKey string
+	// maxRetries is how often a failed request is retried (0 = never)
+	maxRetries int
+	// retryBaseDelay is the wait before the first retry, doubled for each one after
+	retryBaseDelay time.Duration
+	// idempotentTools are the tools annotated readOnlyHint or idempotentHint in the
+	// tools/list responses relayed so far
+	idempotentTools map[string]bool
+	client          *http.Client
+}
+
+// NewBridge creates a bridge that forwards to defaultEndpoint unless a request's
+// composite_id names a tool listed in routes
+func NewBridge(defaultEndpoint string, routes map[string]string) *Bridge {
+	if routes == nil {
+		routes = map[string]string{}
+	}
+	return &Bridge{
+		defaultEndpoint: defaultEndpoint,
+		routes:          routes,
+		doneEvent:       constants.DefaultSSEDoneEvent,
+		maxRetries:      constants.DefaultBridgeMaxRetries,
+		retryBaseDelay:  constants.DefaultBridgeRetryBaseDelay,
+		idempotentTools: map[string]bool{},
+		client:          &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// Run relays messages from r to the servers and writes their responses to w
+// until r is exhausted or ctx is cancelled
+func (b *Bridge) Run(ctx context.Context, r io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+
+	for scanner.Scan() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		for _, message := range b.handle(ctx, line) {
+			if _, err := w.Write(append(message, '\n')); err != nil {
+				return fmt.Errorf("failed to write response: %w", err)
+			}
+		}
+	}
+
+	return scanner.Err()
+}
+
+// handle forwards one message and returns the messages to write: any streamed
+// notifications followed by the response, or nothing for notifications
+func (b *Bridge) handle(ctx context.Context, message []byte) [][]byte {
+	if message[0] == '[' {
+		return b.handleBatch(ctx, message)
+	}
+
+	var envelope struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+		Params struct {
+			Name string `json:"name"`
+		} `json:"params"`
+	}
+	if err := json.Unmarshal(message, &envelope); err != nil {
+		return [][]byte{errorResponse(nil, parseErrorCode, constants.ErrParseError, err.Error())}
+	}
+
+	idempotent := idempotentMethods[envelope.Method] ||
+		(envelope.Method == "tools/call" && b.idempotentTools[envelope.Params.Name])
+	messages, err := b.forwardWithRetry(ctx, b.endpointFor(message), message, idempotent)
+	if err != nil {
+		if envelope.ID == nil {
+			return nil
+		}
+		return [][]byte{errorResponse(envelope.ID, internalErrorCode, "Bridge error", err.Error())}
+	}
+	if envelope.ID == nil {
+		return nil
+	}
+	if envelope.Method == "tools/list" {
+		b.learnIdempotentTools(messages)
+	}
+	return messages
+}
+
+// handleBatch forwards a JSON-RPC batch array as it is to the default endpoint, which
+// answers it with one array. Entries are not inspected, so a batch is neither routed
+// by composite ID nor retried once it may have reached the server; a failure is
+// reported with a single error.
+func (b *Bridge) handleBatch(ctx context.Context, message []byte) [][]byte {
+	messages, err := b.forwardWithRetry(ctx, b.defaultEndpoint, message, false)
+	if err != nil {
+		return [][]byte{errorResponse(nil, internalErrorCode, "Bridge error", err.Error())}
+	}
+	return messages
+}
+
+// learnIdempotentTools records the tools a tools/list response annotates as
+// read-only or idempotent, whose calls may then be retried
+func (b *Bridge) learnIdempotentTools(messages [][]byte) {
+	for _, message := range messages {
+		var response struct {
+			Result struct {
+				Tools []struct {
+					Name        string `json:"name"`
+					Annotations struct {
+						ReadOnlyHint   bool `json:"readOnlyHint"`
+						IdempotentHint bool `json:"idempotentHint"`
+					} `json:"annotations"`
+				} `json:"tools"`
+			} `json:"result"`
+		}
+		if json.Unmarshal(message, &response) != nil {
+			continue
+		}
+		for _, tool := range response.Result.Tools {
+			b.idempotentTools[tool.Name] = tool.Annotations.ReadOnlyHint || tool.Annotations.IdempotentHint
+		}
+	}
+}
+
+// forwardWithRetry forwards a message, retrying failures with exponential backoff.
+// A connection that could not be opened is retried for any message, as the server
+// never saw it. Timeouts, dropped connections and 502, 503 and 504 responses are
+// only retried for idempotent messages; other HTTP errors are never retried.
+func (b *Bridge) forwardWithRetry(ctx context.Context, endpoint string, message []byte, idempotent bool) ([][]byte, error) {
+	delay := b.retryBaseDelay
+	for attempt := 0; ; attempt++ {
+		messages, err := b.forward(ctx, endpoint, message)
+		if err == nil || attempt >= b.maxRetries || !retryable(err, idempotent) {
+			if err != nil && attempt > 0 {
+				err = fmt.Errorf("%w (after %d attempts)", err, attempt+1)
+			}
+			return messages, err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, err
+		case <-timer.C:
+		}
+		delay *= 2
+	}
+}
+
+// statusError is an HTTP response other than 200 OK
+type statusError struct {
+	endpoint string
+	status   string
+	code     int
+	body     string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("%s returned %s: %s", e.endpoint, e.status, e.body)
+}
+
+// retryable reports whether a failed forward may be retried
+func retryable(err error, idempotent bool) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var status *statusError
+	if errors.As(err, &status) {
+		switch status.code {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return idempotent
+		}
+		return false
+	}
+
+	// A failed dial means the request was never sent, such as a server still starting
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+
+	// Anything else may have reached the server, so only idempotent messages are resent
+	var netErr net.Error
+	return idempotent && (errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF))
+}
+
+// endpointFor picks the endpoint for a message from the tool-name segment of
+// params.arguments.composite_id. Only that field is decoded; anything else,
+// including a composite ID with an unrouted tool name, goes to the default.
+func (b *Bridge) endpointFor(message []byte) string {
+	var request struct {
+		Params struct {
+			Arguments struct {
+				CompositeID string `json:"composite_id"`
+			} `json:"arguments"`
+		} `json:"params"`
+	}
+	if err := json.Unmarshal(message, &request); err != nil {
+		return b.defaultEndpoint
+	}
+
+	toolName, _, found := strings.Cut(request.Params.Arguments.CompositeID, ":")
+	if !found {
+		return b.defaultEndpoint
+	}
+	if endpoint, ok := b.routes[toolName]; ok {
+		return endpoint
+	}
+	return b.defaultEndpoint
+}
+
+// forward posts a message to an endpoint and returns the JSON-RPC messages it
+// answers with. An http server sends one; in sse mode every event of the stream
+// is returned in order.
+func (b *Bridge) forward(ctx context.Context, endpoint string, message []byte) ([][]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(message))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	if b.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+b.apiKey)
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach %s: %w", endpoint, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, &statusError{endpoint: endpoint, status: resp.Status, code: resp.StatusCode, body: strings.TrimSpace(string(body))}
+	}
+
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		events, err := readSSEData(resp.Body, b.doneEvent)
+		if err != nil {
+			return nil, err
+		}
+
+		// Multi-line payloads must go back to the client on a single line
+		messages := make([][]byte, 0, len(events))
+		for _, data := range events {
+			var compacted bytes.Buffer
+			if err := json.Compact(&compacted, data); err != nil {
+				return nil, fmt.Errorf("invalid JSON in event stream: %w", err)
+			}
+			messages = append(messages, compacted.Bytes())
+		}
+		return messages, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if body = bytes.TrimSpace(body); len(body) == 0 {
+		return nil, nil
+	}
+	return [][]byte{body}, nil
+}
+
+// readSSEData returns the data of every event in an event stream, reading
+// until doneEvent arrives or the stream ends. An event may span several data
+// lines, which are joined with newlines as the SSE spec requires; a blank line
+// ends the event.
+func readSSEData(r io.Reader, doneEvent string) ([][]byte, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+
+	var events [][]byte
+	var event string
+	var data []string
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if line != "" {
+			field, value, _ := strings.Cut(line, ":")
+			value = strings.TrimPrefix(value, " ")
+			switch field {
+			case "event":
+				event = value
+			case "data":
+				data = append(data, value)
+			}
+			continue
+		}
+
+		// A blank line dispatches the event
+		if doneEvent != "" && event == doneEvent {
+			return events, nil
+		}
+		if len(data) > 0 {
+			events = append(events, []byte(strings.Join(data, "\n")))
+		}
+		event, data = "", nil
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read event stream: %w", err)
+	}
+
+	// Accept a final event whose terminating blank line was cut off
+	if len(data) > 0 && (doneEvent == "" || event != doneEvent) {
+		events = append(events, []byte(strings.Join(data, "\n")))
+	}
+	return events, nil
+}
+
+// errorResponse builds a JSON-RPC error response for failures inside the bridge
+func errorResponse(id json.RawMessage, code int, message, data string) []byte {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	response, _ := json.Marshal(map[string]interface{}{
+		"jsonrpc": constants.JSONRPCVersion,
+		"id":      id,
+		"error": map[string]interface{}{
+			"code":    code,
+			"message": message,
+			"data":    data,
+		},
+	})
+	return response
+}
@@ -0,0 +1,332 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newBackend starts a fake url-db server that answers every request with its own name
+func newBackend(t *testing.T, name string, sse bool) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID json.RawMessage `json:"id"`
+		}
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &req); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+
+		response := fmt.Sprintf(`{"jsonrpc":"2.0","id":%s,"result":{"backend":%q}}`, req.ID, name)
+		if sse {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprintf(w, "data: %s\n\n", response)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintln(w, response)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestBridgeRoutesByCompositeIDPrefix(t *testing.T) {
+	work := newBackend(t, "work", false)
+	personal := newBackend(t, "personal", true)
+	fallback := newBackend(t, "default", false)
+
+	bridge := NewBridge(fallback.URL, map[string]string{
+		"work-db":     work.URL,
+		"personal-db": personal.URL,
+	})
+
+	requests := []struct {
+		message string
+		backend string
+	}{
+		{`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"get_node","arguments":{"composite_id":"work-db:docs:1"}}}`, "work"},
+		{`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"get_node","arguments":{"composite_id":"personal-db:blog:7"}}}`, "personal"},
+		{`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"get_node","arguments":{"composite_id":"other-db:docs:1"}}}`, "default"},
+		{`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"list_domains","arguments":{}}}`, "default"},
+		{`{"jsonrpc":"2.0","id":5,"method":"tools/list"}`, "default"},
+	}
+
+	var input strings.Builder
+	for _, r := range requests {
+		input.WriteString(r.message + "\n")
+	}
+	// Notifications get no response
+	input.WriteString(`{"jsonrpc":"2.0","method":"notifications/initialized"}` + "\n")
+
+	var output bytes.Buffer
+	if err := bridge.Run(context.Background(), strings.NewReader(input.String()), &output); err != nil {
+		t.Fatalf("bridge failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	if len(lines) != len(requests) {
+		t.Fatalf("expected %d responses, got %d: %q", len(requests), len(lines), output.String())
+	}
+	for i, line := range lines {
+		var response struct {
+			ID     int `json:"id"`
+			Result struct {
+				Backend string `json:"backend"`
+			} `json:"result"`
+		}
+		if err := json.Unmarshal([]byte(line), &response); err != nil {
+			t.Fatalf("invalid response %q: %v", line, err)
+		}
+		if response.ID != i+1 || response.Result.Backend != requests[i].backend {
+			t.Errorf("request %d: expected backend %q, got id %d from %q", i+1, requests[i].backend, response.ID, response.Result.Backend)
+		}
+	}
+}
+
+func TestBridgeReportsUnreachableBackend(t *testing.T) {
+	backend := newBackend(t, "gone", false)
+	backend.Close()
+
+	var output bytes.Buffer
+	bridge := NewBridge(backend.URL, nil)
+	if err := bridge.Run(context.Background(), strings.NewReader(`{"jsonrpc":"2.0","id":9,"method":"tools/list"}`+"\n"), &output); err != nil {
+		t.Fatalf("bridge failed: %v", err)
+	}
+
+	var response struct {
+		ID    int `json:"id"`
+		Error struct {
+			Code int `json:"code"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(output.Bytes(), &response); err != nil {
+		t.Fatalf("invalid response %q: %v", output.String(), err)
+	}
+	if response.ID != 9 || response.Error.Code != internalErrorCode {
+		t.Errorf("expected an internal error for id 9, got %q", output.String())
+	}
+}
+
+func TestBridgeForwardsBatches(t *testing.T) {
+	var received string
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = string(body)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintln(w, `[{"jsonrpc":"2.0","id":1,"result":{}},{"jsonrpc":"2.0","id":2,"result":{}}]`)
+	}))
+	t.Cleanup(backend.Close)
+
+	batch := `[{"jsonrpc":"2.0","id":1,"method":"tools/list"},{"jsonrpc":"2.0","id":2,"method":"ping"}]`
+	var output bytes.Buffer
+	if err := NewBridge(backend.URL, nil).Run(context.Background(), strings.NewReader(batch+"\n"), &output); err != nil {
+		t.Fatalf("bridge failed: %v", err)
+	}
+	if received != batch {
+		t.Errorf("expected the batch to be forwarded unchanged, server got %q", received)
+	}
+	var responses []map[string]interface{}
+	if err := json.Unmarshal(output.Bytes(), &responses); err != nil || len(responses) != 2 {
+		t.Errorf("expected the response array to be relayed, got %q", output.String())
+	}
+}
+
+func TestReadSSEDataJoinsMultiLineEvents(t *testing.T) {
+	stream := ": keep-alive\n\n" +
+		"event: message\n" +
+		"data: {\"jsonrpc\":\"2.0\",\n" +
+		"data:\"id\":1,\n" +
+		"data: \"result\":{\"text\":\"first\\nsecond\"}}\n" +
+		"\n" +
+		"event: done\n" +
+		"data: {\"id\":1}\n\n" +
+		"data: {\"ignored\":true}\n\n"
+
+	events, err := readSSEData(strings.NewReader(stream), "done")
+	if err != nil {
+		t.Fatalf("failed to read event stream: %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event before done, got %d: %q", len(events), events)
+	}
+	data := events[0]
+
+	var response struct {
+		ID     int `json:"id"`
+		Result struct {
+			Text string `json:"text"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil {
+		t.Fatalf("joined payload is not valid JSON %q: %v", data, err)
+	}
+	if response.ID != 1 || response.Result.Text != "first\nsecond" {
+		t.Errorf("unexpected payload %q", data)
+	}
+}
+
+func TestBridgeForwardsMultiLineSSEResponse(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"jsonrpc\":\"2.0\",\"id\":3,\r\ndata: \"result\":{\"chunks\":2}}\r\n\r\n")
+	}))
+	defer backend.Close()
+
+	var output bytes.Buffer
+	bridge := NewBridge(backend.URL, nil)
+	if err := bridge.Run(context.Background(), strings.NewReader(`{"jsonrpc":"2.0","id":3,"method":"tools/list"}`+"\n"), &output); err != nil {
+		t.Fatalf("bridge failed: %v", err)
+	}
+
+	expected := `{"jsonrpc":"2.0","id":3,"result":{"chunks":2}}` + "\n"
+	if output.String() != expected {
+		t.Errorf("expected %q, got %q", expected, output.String())
+	}
+}
+
+func TestBridgeStopsReadingAtDoneEvent(t *testing.T) {
+	release := make(chan struct{})
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"jsonrpc\":\"2.0\",\"id\":4,\"result\":{}}\n\n")
+		fmt.Fprint(w, "event: done\ndata: {\"id\":4}\n\n")
+		w.(http.Flusher).Flush()
+
+		// Keep the stream open; the bridge must not wait for it to close
+		<-release
+	}))
+	defer backend.Close()
+	defer close(release)
+
+	var output bytes.Buffer
+	done := make(chan error, 1)
+	go func() {
+		bridge := NewBridge(backend.URL, nil)
+		done <- bridge.Run(context.Background(), strings.NewReader(`{"jsonrpc":"2.0","id":4,"method":"tools/list"}`+"\n"), &output)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("bridge failed: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("bridge kept reading after the done event")
+	}
+
+	expected := `{"jsonrpc":"2.0","id":4,"result":{}}` + "\n"
+	if output.String() != expected {
+		t.Errorf("expected %q, got %q", expected, output.String())
+	}
+}
+
+func TestBridgeForwardsStreamedNotifications(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"jsonrpc\":\"2.0\",\"method\":\"notifications/scan_item\",\"params\":{\"index\":0}}\n\n")
+		fmt.Fprint(w, "data: {\"jsonrpc\":\"2.0\",\"method\":\"notifications/scan_item\",\"params\":{\"index\":1}}\n\n")
+		fmt.Fprint(w, "data: {\"jsonrpc\":\"2.0\",\"id\":5,\"result\":{}}\n\n")
+		fmt.Fprint(w, "event: done\ndata: {\"id\":5}\n\n")
+	}))
+	defer backend.Close()
+
+	var output bytes.Buffer
+	bridge := NewBridge(backend.URL, nil)
+	if err := bridge.Run(context.Background(), strings.NewReader(`{"jsonrpc":"2.0","id":5,"method":"tools/call"}`+"\n"), &output); err != nil {
+		t.Fatalf("bridge failed: %v", err)
+	}
+
+	expected := `{"jsonrpc":"2.0","method":"notifications/scan_item","params":{"index":0}}` + "\n" +
+		`{"jsonrpc":"2.0","method":"notifications/scan_item","params":{"index":1}}` + "\n" +
+		`{"jsonrpc":"2.0","id":5,"result":{}}` + "\n"
+	if output.String() != expected {
+		t.Errorf("expected %q, got %q", expected, output.String())
+	}
+}
+
+// newFlakyBackend starts a fake url-db server that fails the first failures
+// requests with status and answers the rest, counting every request it receives
+func newFlakyBackend(t *testing.T, failures, status int, body string) (*httptest.Server, *int) {
+	t.Helper()
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts <= failures {
+			http.Error(w, "unavailable", status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(server.Close)
+	return server, &attempts
+}
+
+func TestBridgeRetriesIdempotentRequests(t *testing.T) {
+	backend, attempts := newFlakyBackend(t, 2, http.StatusServiceUnavailable, `{"jsonrpc":"2.0","id":1,"result":{"tools":[]}}`)
+
+	var output bytes.Buffer
+	bridge := NewBridge(backend.URL, nil)
+	bridge.retryBaseDelay = time.Millisecond
+	if err := bridge.Run(context.Background(), strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`+"\n"), &output); err != nil {
+		t.Fatalf("bridge failed: %v", err)
+	}
+
+	if *attempts != 3 {
+		t.Errorf("expected 3 attempts, got %d", *attempts)
+	}
+	if !strings.Contains(output.String(), `"result"`) {
+		t.Errorf("expected the third attempt's result, got %q", output.String())
+	}
+}
+
+func TestBridgeDoesNotRetryClientErrors(t *testing.T) {
+	backend, attempts := newFlakyBackend(t, 5, http.StatusUnauthorized, "")
+
+	var output bytes.Buffer
+	bridge := NewBridge(backend.URL, nil)
+	bridge.retryBaseDelay = time.Millisecond
+	if err := bridge.Run(context.Background(), strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`+"\n"), &output); err != nil {
+		t.Fatalf("bridge failed: %v", err)
+	}
+
+	if *attempts != 1 {
+		t.Errorf("expected a 401 not to be retried, got %d attempts", *attempts)
+	}
+}
+
+func TestBridgeRetriesToolCallsOnlyWhenIdempotent(t *testing.T) {
+	toolsList := `{"jsonrpc":"2.0","id":1,"result":{"tools":[` +
+		`{"name":"get_node","annotations":{"readOnlyHint":true}},` +
+		`{"name":"create_node","annotations":{"readOnlyHint":false}}]}}`
+	backend, _ := newFlakyBackend(t, 0, http.StatusOK, toolsList)
+
+	bridge := NewBridge(backend.URL, nil)
+	bridge.retryBaseDelay = time.Millisecond
+	if err := bridge.Run(context.Background(), strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"tools/list"}`+"\n"), io.Discard); err != nil {
+		t.Fatalf("bridge failed: %v", err)
+	}
+
+	cases := map[string]int{"get_node": 3, "create_node": 1}
+	for tool, expected := range cases {
+		failing, failingAttempts := newFlakyBackend(t, 5, http.StatusServiceUnavailable, "")
+		bridge.defaultEndpoint = failing.URL
+		call := `{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"` + tool + `"}}` + "\n"
+		if err := bridge.Run(context.Background(), strings.NewReader(call), io.Discard); err != nil {
+			t.Fatalf("bridge failed: %v", err)
+		}
+		if *failingAttempts != expected {
+			t.Errorf("%s: expected %d attempts, got %d", tool, expected, *failingAttempts)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"url-db/internal/constants"
+)
+
+// routeFlags collects repeated -route tool-name=endpoint flags
+type routeFlags map[string]string
+
+func (r routeFlags) String() string {
+	pairs := make([]string, 0, len(r))
+	for toolName, endpoint := range r {
+		pairs = append(pairs, toolName+"="+endpoint)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (r routeFlags) Set(value string) error {
+	toolName, endpoint, ok := strings.Cut(value, "=")
+	toolName, endpoint = strings.TrimSpace(toolName), strings.TrimSpace(endpoint)
+	if !ok || toolName == "" || endpoint == "" {
+		return fmt.Errorf("expected tool-name=endpoint, got %q", value)
+	}
+	r[toolName] = endpoint
+	return nil
+}
+
+// envInt returns the integer in the named environment variable, or fallback when unset
+func envInt(name string, fallback int) (int, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s %q: %w", name, value, err)
+	}
+	return n, nil
+}
+
+// The bridge lets stdio-only MCP clients talk to url-db servers running in
+// http or sse mode. All logging goes to stderr; stdout carries JSON-RPC only.
+func main() {
+	routes := routeFlags{}
+	defaultRetries, err := envInt(constants.EnvBridgeMaxRetries, constants.DefaultBridgeMaxRetries)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	defaultDelayMS, err := envInt(constants.EnvBridgeRetryDelay, int(constants.DefaultBridgeRetryBaseDelay/time.Millisecond))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	endpoint := flag.String("endpoint", "http://localhost:8080/mcp", "Default MCP endpoint of a url-db server in http or sse mode")
+	doneEvent := flag.String("done-event", constants.DefaultSSEDoneEvent, "SSE event that ends a response from a server in sse mode (empty = read until the stream closes)")
+	flag.Var(routes, "route", "Route composite IDs with this tool name to another endpoint, as tool-name=endpoint (repeatable)")
+	maxRetries := flag.Int("max-retries", defaultRetries, "Retries for a request that failed with a retryable error (env "+constants.EnvBridgeMaxRetries+")")
+	retryBaseDelay := flag.Duration("retry-base-delay", time.Duration(defaultDelayMS)*time.Millisecond, "Wait before the first retry, doubled for each retry after it (env "+constants.EnvBridgeRetryDelay+")")
+	flag.Parse()
+	if *maxRetries < 0 || *retryBaseDelay < 0 {
+		fmt.Fprintln(os.Stderr, "-max-retries and -retry-base-delay must not be negative")
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	bridge := NewBridge(*endpoint, routes)
+	bridge.doneEvent = *doneEvent
+	bridge.maxRetries = *maxRetries
+	bridge.retryBaseDelay = *retryBaseDelay
+	// Read from the environment rather than a flag so the key stays out of process listings
+	bridge.apiKey = os.Getenv("MCP_API_KEY")
+
+	// A read blocked on stdin cannot be interrupted, so exit on a signal
+	// without waiting for Run to notice the cancelled context
+	done := make(chan error, 1)
+	go func() {
+		done <- bridge.Run(ctx, os.Stdin, os.Stdout)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Bridge stopped: %v\n", err)
+			os.Exit(1)
+		}
+	case <-ctx.Done():
+	}
+}
@@ -6,11 +6,15 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 
 	// Clean Architecture imports
 	"url-db/internal/config"
 	"url-db/internal/constants"
 	"url-db/internal/database"
+	"url-db/internal/infrastructure/fetcher"
 	"url-db/internal/interface/mcp"
 	"url-db/internal/interface/setup"
 )
@@ -20,12 +24,19 @@ import (
 // @description     A URL management system with Clean Architecture and MCP integration.
 
 func main() {
+	os.Exit(run())
+}
+
+// run starts the server and returns the process exit code. Returning instead of
+// calling os.Exit lets deferred cleanup, like closing the database, run first.
+func run() int {
 	// Parse command line flags
 	var (
 		dbPath   = flag.String("db-path", "", "Path to the database file")
 		toolName = flag.String("tool-name", constants.DefaultServerName, "Tool name for composite keys")
 		port     = flag.String("port", "8080", "Port for HTTP server")
 		mcpMode  = flag.String("mcp-mode", "", "MCP server mode (stdio, sse, http) - if set, runs MCP server instead of HTTP")
+		readOnly = flag.Bool("read-only", false, "Only allow MCP tools that do not modify data")
 		showHelp = flag.Bool("help", false, "Show help message")
 		version  = flag.Bool("version", false, "Show version information")
 	)
@@ -41,15 +52,16 @@ func main() {
 		fmt.Println("  -tool-name string  Tool name for composite keys")
 		fmt.Println("  -port string       Port for HTTP server (default: 8080)")
 		fmt.Println("  -mcp-mode string   MCP server mode (stdio, sse, http) - if set, runs MCP server instead of HTTP")
+		fmt.Println("  -read-only         Only allow MCP tools that do not modify data (also READ_ONLY=true)")
 		fmt.Println("  -help             Show help message")
 		fmt.Println("  -version          Show version information")
-		os.Exit(0)
+		return 0
 	}
 
 	if *version {
 		fmt.Println("URL Database Server v" + constants.DefaultServerVersion)
 		fmt.Println("Clean Architecture Implementation")
-		os.Exit(0)
+		return 0
 	}
 
 	// Load configuration
@@ -65,16 +77,26 @@ func main() {
 	if *port != "" {
 		cfg.Port = *port
 	}
+	if *readOnly {
+		cfg.ReadOnly = true
+	}
 
 	// Initialize database
-	db, err := database.InitDB(cfg.DatabaseURL)
+	dbConfig := database.DefaultConfig()
+	dbConfig.URL = cfg.DatabaseURL
+	dbConfig.MaxOpenConns = cfg.DBMaxOpenConns
+	dbConfig.MaxIdleConns = cfg.DBMaxIdleConns
+	dbConfig.WALMode = cfg.DBWALMode
+	dbConfig.BusyTimeout = cfg.DBBusyTimeout
+	db, err := database.New(dbConfig)
 	if err != nil {
 		if *mcpMode == constants.MCPModeStdio {
 			// In stdio mode, write error to stderr and exit silently
 			fmt.Fprintf(os.Stderr, "Failed to initialize database: %v\n", err)
-			os.Exit(1)
+			return 1
 		} else {
-			log.Fatal("Failed to initialize database:", err)
+			log.Print("Failed to initialize database:", err)
+			return 1
 		}
 	}
 	defer func() {
@@ -83,6 +105,22 @@ func main() {
 		}
 	}()
 
+	// Bring stored domain names in line with the lowercase rule before serving
+	if cfg.LowercaseDomainNames {
+		migration, err := db.LowercaseDomainNames()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to lowercase domain names: %v\n", err)
+			return 1
+		}
+		if migration.Renamed > 0 {
+			fmt.Fprintf(os.Stderr, "Lowercased %d domain names\n", migration.Renamed)
+		}
+		for _, collision := range migration.Collisions {
+			fmt.Fprintf(os.Stderr, "Domain names differ only by case and were left unchanged: %s (merge or rename them to use %q)\n",
+				strings.Join(collision.Domains, ", "), collision.Name)
+		}
+	}
+
 	// Initialize Clean Architecture factory
 	factory := setup.NewApplicationFactory(db.DB(), db.SQLXDB(), cfg.ToolName)
 
@@ -96,9 +134,10 @@ func main() {
 			if *mcpMode == constants.MCPModeStdio {
 				// In stdio mode, write error to stderr and exit silently
 				fmt.Fprintf(os.Stderr, "Invalid MCP mode: %s. Valid modes: stdio, sse, http\n", *mcpMode)
-				os.Exit(1)
+				return 1
 			} else {
-				log.Fatalf("Invalid MCP mode: %s. Valid modes: stdio, sse, http", *mcpMode)
+				log.Printf("Invalid MCP mode: %s. Valid modes: stdio, sse, http", *mcpMode)
+				return 1
 			}
 		}
 
@@ -114,40 +153,108 @@ func main() {
 			if *mcpMode == constants.MCPModeStdio {
 				// In stdio mode, write error to stderr and exit silently
 				fmt.Fprintf(os.Stderr, "Failed to create MCP server: %v\n", err)
-				os.Exit(1)
+				return 1
 			} else {
-				log.Fatalf("Failed to create MCP server: %v", err)
+				log.Printf("Failed to create MCP server: %v", err)
+				return 1
 			}
 		}
 
+		mcpServer.SetResponseEnvelope(cfg.ResponseEnvelope)
+		mcpServer.SetDependencyTypes(cfg.DependencyTypes)
+		mcpServer.SetSoftWarnings(cfg.SoftWarnings)
+		mcpServer.SetNodeLimits(cfg.MaxNodesPerDomain, cfg.DomainMaxNodes)
+		mcpServer.SetAllowedURLSchemes(cfg.AllowedURLSchemes)
+		mcpServer.SetCompactJSON(cfg.CompactJSON)
+		mcpServer.SetEventBuffer(cfg.EventBufferSize, cfg.EventFlushInterval)
+		mcpServer.SetSSEDoneEvent(cfg.SSEDoneEvent)
+		mcpServer.SetToolTimeouts(cfg.ToolTimeout, cfg.ToolTimeouts)
+		mcpServer.SetLowercaseDomainNames(cfg.LowercaseDomainNames)
+		mcpServer.SetDomainNameUnicode(cfg.DomainNameUnicode)
+		mcpServer.SetMaxFilters(cfg.MaxFilters)
+		mcpServer.SetMaxPageSize(cfg.MaxPageSize)
+		mcpServer.SetScanDefaultOrder(cfg.ScanDefaultOrder)
+		mcpServer.SetAdminTools(cfg.AdminTools)
+		mcpServer.SetReadOnly(cfg.ReadOnly)
+		if err := mcpServer.SetAttributeTransforms(cfg.AttributeTransforms); err != nil {
+			fmt.Fprintf(os.Stderr, "Ignoring ATTRIBUTE_TRANSFORMS, using default transforms: %v\n", err)
+		}
+		if err := mcpServer.SetAttributeNameRules(cfg.AttributeNameMinLen, cfg.AttributeNamePattern); err != nil {
+			fmt.Fprintf(os.Stderr, "Ignoring ATTRIBUTE_NAME_MIN_LENGTH and ATTRIBUTE_NAME_PATTERN, using default attribute name rules: %v\n", err)
+		}
+		mcpServer.SetMaxRequestBodyBytes(cfg.MaxRequestBodyBytes)
+		mcpServer.SetRateLimit(cfg.RateLimitRPS, cfg.RateLimitBurst, cfg.RateLimitTrustXFF)
+		mcpServer.SetAPIKey(cfg.APIKey)
+		mcpServer.SetCORSOrigins(cfg.CORSAllowedOrigins)
+		if err := mcpServer.SetAccessLog(cfg.AccessLogLevel, cfg.AccessLogFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Access log disabled: %v\n", err)
+		}
+		mcpServer.StartExpirySweeper(cfg.NodeExpirySweep)
+		mcpServer.SetTitleFetcher(fetcher.NewTitleFetcher(fetcher.TitleFetcherConfig{
+			Allowlist: cfg.TitleFetchAllowlist,
+			Denylist:  cfg.TitleFetchDenylist,
+		}))
+
 		// Create MCP-aware logger for demonstration
 		mcpLogger := mcp.NewMCPLogger(mcpServer, "main")
 		mcpLogger.Infof("MCP server initialized in %s mode", *mcpMode)
 
-		// Set port for SSE/HTTP modes
+		// Set port and deliver subscribed events in SSE/HTTP modes
 		if *mcpMode == constants.MCPModeSSE || *mcpMode == constants.MCPModeHTTP {
 			mcpServer.SetPort(*port)
+			mcpServer.StartSubscriptionWorker(cfg.SubscriptionPoll, cfg.SubscriptionRetries)
+		}
+
+		// Serve until the transport stops on its own or SIGINT/SIGTERM arrives
+		sigCtx, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stopSignals()
+
+		serveErr := make(chan error, 1)
+		go func() {
+			serveErr <- mcpServer.Start(context.Background())
+		}()
+
+		var startErr error
+		select {
+		case startErr = <-serveErr:
+		case <-sigCtx.Done():
+			// A second signal falls back to the default behaviour and kills the process
+			stopSignals()
+
+			// Stop accepting connections and let in-flight requests finish
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), constants.ShutdownTimeout)
+			if err := mcpServer.Shutdown(shutdownCtx); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to shut down MCP server cleanly: %v\n", err)
+			}
+			cancel()
+		}
+
+		// Write out buffered node events before the database is closed
+		if err := mcpServer.Close(context.Background()); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to flush node events: %v\n", err)
 		}
 
-		ctx := context.Background()
-		if err := mcpServer.Start(ctx); err != nil {
+		if startErr != nil {
 			if *mcpMode == constants.MCPModeStdio {
 				// In stdio mode, write error to stderr and exit silently
-				fmt.Fprintf(os.Stderr, "Failed to start MCP server: %v\n", err)
-				os.Exit(1)
+				fmt.Fprintf(os.Stderr, "Failed to start MCP server: %v\n", startErr)
+				return 1
 			} else {
-				log.Fatal("Failed to start MCP server:", err)
+				log.Print("Failed to start MCP server:", startErr)
+				return 1
 			}
 		}
-		return
+		return 0
 	}
 
 	// Create router for HTTP mode
-	router := setup.SetupCleanRouter(factory)
+	router := setup.SetupCleanRouter(factory, cfg.MaxPageSize)
 
 	// Start HTTP server
 	log.Printf("Starting Clean Architecture HTTP server on port %s", cfg.Port)
 	if err := router.Run(":" + cfg.Port); err != nil {
-		log.Fatal("Failed to start HTTP server:", err)
+		log.Print("Failed to start HTTP server:", err)
+		return 1
 	}
+	return 0
 }
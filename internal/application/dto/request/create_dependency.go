@@ -0,0 +1,11 @@
+package request
+
+// CreateDependencyRequest represents the request for creating a node dependency
+type CreateDependencyRequest struct {
+	DependentNodeID  int    `json:"dependent_node_id" validate:"required"`
+	DependencyNodeID int    `json:"dependency_node_id" validate:"required"`
+	DependencyType   string `json:"dependency_type" validate:"required"`
+	Description      string `json:"description"`
+	CascadeDelete    bool   `json:"cascade_delete"`
+	CascadeUpdate    bool   `json:"cascade_update"`
+}
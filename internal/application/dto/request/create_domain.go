@@ -5,3 +5,9 @@ type CreateDomainRequest struct {
 	Name        string `json:"name" validate:"required,max=255"`
 	Description string `json:"description" validate:"max=1000"`
 }
+
+// UpdateDomainRequest represents the request for updating a domain. Domain names are
+// identities and cannot be changed, so only the description is updatable.
+type UpdateDomainRequest struct {
+	Description string `json:"description" validate:"max=1000"`
+}
@@ -1,9 +1,14 @@
 package request
 
+import "time"
+
 // CreateNodeRequest represents the request for creating a node
 type CreateNodeRequest struct {
-	DomainName  string `json:"domain_name" validate:"required"`
-	URL         string `json:"url" validate:"required,max=2048"`
-	Title       string `json:"title" validate:"max=255"`
-	Description string `json:"description" validate:"max=1000"`
+	DomainName  string     `json:"domain_name" validate:"required"`
+	URL         string     `json:"url" validate:"required,max=2048"`
+	Title       string     `json:"title" validate:"max=255"`
+	Description string     `json:"description" validate:"max=1000"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"` // nil = never expires
+	// AllowRelative accepts scheme-less URLs such as paths or "#fragment" identifiers
+	AllowRelative bool `json:"allow_relative,omitempty"`
 }
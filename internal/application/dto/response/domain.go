@@ -17,4 +17,5 @@ type DomainListResponse struct {
 	Page       int              `json:"page"`
 	Size       int              `json:"size"`
 	TotalPages int              `json:"total_pages"`
+	NextCursor string           `json:"next_cursor,omitempty"` // Set by cursor listing when more domains follow
 }
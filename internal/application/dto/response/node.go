@@ -4,13 +4,15 @@ import "time"
 
 // NodeResponse represents the response for node operations
 type NodeResponse struct {
-	ID          int       `json:"id"`
-	URL         string    `json:"url"`
-	DomainName  string    `json:"domain_name"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          int        `json:"id"`
+	URL         string     `json:"url"`
+	DomainName  string     `json:"domain_name"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	ArchivedAt  *time.Time `json:"archived_at,omitempty"`
 }
 
 // NodeListResponse represents the response for node list operations
@@ -24,11 +26,12 @@ type NodeListResponse struct {
 
 // NodeWithAttributes represents a node with its attributes for scanning operations
 type NodeWithAttributes struct {
-	ID          int             `json:"id"`
-	Content     string          `json:"content"`
-	Title       *string         `json:"title,omitempty"`
-	Description *string         `json:"description,omitempty"`
-	CreatedAt   time.Time       `json:"created_at"`
-	UpdatedAt   time.Time       `json:"updated_at"`
+	ID          int              `json:"id"`
+	Content     string           `json:"content"`
+	Title       *string          `json:"title,omitempty"`
+	Description *string          `json:"description,omitempty"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
 	Attributes  []AttributeValue `json:"attributes,omitempty"`
+	ContentHash string           `json:"content_hash,omitempty"` // Set when the scan asks for it
 }
@@ -13,16 +13,32 @@ import (
 type CreateAttributeUseCase struct {
 	attributeRepo repository.AttributeRepository
 	domainRepo    repository.DomainRepository
+	nameRules     entity.AttributeNameRules
 }
 
 func NewCreateAttributeUseCase(attributeRepo repository.AttributeRepository, domainRepo repository.DomainRepository) *CreateAttributeUseCase {
 	return &CreateAttributeUseCase{
 		attributeRepo: attributeRepo,
 		domainRepo:    domainRepo,
+		nameRules:     entity.DefaultAttributeNameRules(),
 	}
 }
 
+// SetNameRules sets the naming rules new attributes must follow
+func (uc *CreateAttributeUseCase) SetNameRules(rules entity.AttributeNameRules) {
+	uc.nameRules = rules
+}
+
+// ValidateName checks a new attribute name against the naming rules
+func (uc *CreateAttributeUseCase) ValidateName(name string) error {
+	return uc.nameRules.Validate(name)
+}
+
 func (uc *CreateAttributeUseCase) Execute(ctx context.Context, req *request.CreateAttributeRequest) (*response.AttributeResponse, error) {
+	if err := uc.ValidateName(req.Name); err != nil {
+		return nil, err
+	}
+
 	// Verify domain exists
 	domain, err := uc.domainRepo.GetByID(ctx, req.DomainID)
 	if err != nil {
@@ -0,0 +1,150 @@
+package dependency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"url-db/internal/application/dto/request"
+	"url-db/internal/constants"
+	"url-db/internal/domain/entity"
+	"url-db/internal/domain/repository"
+)
+
+// CreateDependencyUseCase handles the creation of a node dependency
+type CreateDependencyUseCase struct {
+	dependencyRepo repository.DependencyRepository
+	nodeRepo       repository.NodeRepository
+}
+
+// NewCreateDependencyUseCase creates a new instance of CreateDependencyUseCase
+func NewCreateDependencyUseCase(dependencyRepo repository.DependencyRepository, nodeRepo repository.NodeRepository) *CreateDependencyUseCase {
+	return &CreateDependencyUseCase{
+		dependencyRepo: dependencyRepo,
+		nodeRepo:       nodeRepo,
+	}
+}
+
+// Execute performs the dependency creation use case
+func (uc *CreateDependencyUseCase) Execute(ctx context.Context, req *request.CreateDependencyRequest) (*entity.Dependency, error) {
+	dependency, err := uc.prepare(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.dependencyRepo.Create(ctx, dependency, uc.checkCycle); err != nil {
+		return nil, err
+	}
+
+	return dependency, nil
+}
+
+// BatchDependencyResult is the outcome of one dependency of ExecuteBatch
+type BatchDependencyResult struct {
+	Dependency *entity.Dependency // The created dependency; nil when Err is set
+	Err        error
+}
+
+// ExecuteBatch creates dependencies in one transaction, checking each one as Execute
+// does. A dependency that fails a check gets its error and the others are still
+// created; the cycle check of each one includes the dependencies before it in the batch.
+func (uc *CreateDependencyUseCase) ExecuteBatch(ctx context.Context, reqs []*request.CreateDependencyRequest) ([]BatchDependencyResult, error) {
+	results := make([]BatchDependencyResult, len(reqs))
+
+	var dependencies []*entity.Dependency
+	var indexes []int
+	for i, req := range reqs {
+		dependency, err := uc.prepare(ctx, req)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		dependencies = append(dependencies, dependency)
+		indexes = append(indexes, i)
+	}
+
+	createErrors, err := uc.dependencyRepo.CreateBatch(ctx, dependencies, uc.checkCycle)
+	if err != nil {
+		return nil, err
+	}
+	for j, dependency := range dependencies {
+		if createErrors[j] != nil {
+			results[indexes[j]].Err = createErrors[j]
+			continue
+		}
+		results[indexes[j]].Dependency = dependency
+	}
+
+	return results, nil
+}
+
+// prepare builds the dependency of req after checking that both nodes exist. The
+// cycle check runs later, in the transaction that inserts the dependency.
+func (uc *CreateDependencyUseCase) prepare(ctx context.Context, req *request.CreateDependencyRequest) (*entity.Dependency, error) {
+	dependency, err := entity.NewDependency(req.DependentNodeID, req.DependencyNodeID, req.DependencyType, req.Description)
+	if err != nil {
+		return nil, err
+	}
+	dependency.SetCascade(req.CascadeDelete, req.CascadeUpdate)
+
+	// Verify both nodes exist
+	for _, nodeID := range []int{req.DependentNodeID, req.DependencyNodeID} {
+		node, err := uc.nodeRepo.GetByID(ctx, nodeID)
+		if err != nil {
+			return nil, err
+		}
+		if node == nil {
+			return nil, fmt.Errorf("%s: %d", constants.ErrNodeNotFound, nodeID)
+		}
+	}
+
+	return dependency, nil
+}
+
+// checkCycle is a repository.DependencyCheck rejecting a new dependent -> dependency
+// edge when the dependency node already reaches the dependent node in graph. The
+// walk is an iterative DFS, so deep graphs cannot exhaust the stack, and it gives
+// up after visiting constants.MaxDependencyTraversal nodes.
+func (uc *CreateDependencyUseCase) checkCycle(ctx context.Context, graph repository.DependencyGraph, dependency *entity.Dependency) error {
+	dependentID, dependencyID := dependency.DependentNodeID(), dependency.DependencyNodeID()
+
+	// previous records how each visited node was reached, to rebuild the path
+	previous := map[int]int{dependencyID: 0}
+	stack := []int{dependencyID}
+
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if current == dependentID {
+			// Walk back to the dependency node, then close the loop with the new edge
+			var reversed []int
+			for node := current; node != 0; node = previous[node] {
+				reversed = append(reversed, node)
+			}
+			cycle := []int{dependentID}
+			for i := len(reversed) - 1; i >= 0; i-- {
+				cycle = append(cycle, reversed[i])
+			}
+			return &CycleError{Path: cycle}
+		}
+
+		if len(previous) > constants.MaxDependencyTraversal {
+			return errors.New("dependency graph is too large to check for cycles")
+		}
+
+		next, err := graph.ListByDependentNodeID(ctx, current)
+		if err != nil {
+			return fmt.Errorf("failed to walk dependencies: %w", err)
+		}
+		for _, dep := range next {
+			nextID := dep.DependencyNodeID()
+			if _, seen := previous[nextID]; seen {
+				continue
+			}
+			previous[nextID] = current
+			stack = append(stack, nextID)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,21 @@
+package dependency
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CycleError reports a dependency that would close a cycle. Path lists node IDs
+// starting and ending at the new dependent node, following dependency direction.
+type CycleError struct {
+	Path []int
+}
+
+func (e *CycleError) Error() string {
+	nodes := make([]string, len(e.Path))
+	for i, id := range e.Path {
+		nodes[i] = strconv.Itoa(id)
+	}
+	return fmt.Sprintf("dependency would create a cycle: %s", strings.Join(nodes, " -> "))
+}
@@ -12,24 +12,54 @@ import (
 
 // CreateDomainUseCase handles the creation of a new domain
 type CreateDomainUseCase struct {
-	domainRepo repository.DomainRepository
+	domainRepo     repository.DomainRepository
+	lowercaseNames bool
+	unicodeMode    string // How non-ASCII characters in names are handled
 }
 
 // NewCreateDomainUseCase creates a new instance of CreateDomainUseCase
 func NewCreateDomainUseCase(repo repository.DomainRepository) *CreateDomainUseCase {
-	return &CreateDomainUseCase{domainRepo: repo}
+	return &CreateDomainUseCase{domainRepo: repo, unicodeMode: constants.DefaultDomainNameUnicode}
+}
+
+// SetLowercaseNames enables or disables lowercasing of new domain names
+func (uc *CreateDomainUseCase) SetLowercaseNames(enabled bool) {
+	uc.lowercaseNames = enabled
+}
+
+// SetUnicodeMode selects how non-ASCII characters in new domain names are handled:
+// constants.DomainNameUnicodeReject or constants.DomainNameUnicodeNFKC
+func (uc *CreateDomainUseCase) SetUnicodeMode(mode string) {
+	uc.unicodeMode = mode
+}
+
+// cleanName applies the domain name rules to a requested name
+func (uc *CreateDomainUseCase) cleanName(name string) (string, error) {
+	name, err := entity.CleanDomainName(name, uc.unicodeMode)
+	if err != nil {
+		return "", err
+	}
+	if uc.lowercaseNames {
+		name = entity.NormalizeDomainName(name)
+	}
+	return name, nil
 }
 
 // Execute performs the domain creation use case
 func (uc *CreateDomainUseCase) Execute(ctx context.Context, req *request.CreateDomainRequest) (*response.DomainResponse, error) {
+	name, err := uc.cleanName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create domain entity
-	domain, err := entity.NewDomain(req.Name, req.Description)
+	domain, err := entity.NewDomain(name, req.Description)
 	if err != nil {
 		return nil, err
 	}
 
 	// Check if domain already exists
-	exists, err := uc.domainRepo.Exists(ctx, req.Name)
+	exists, err := uc.domainRepo.Exists(ctx, name)
 	if err != nil {
 		return nil, err
 	}
@@ -51,3 +81,32 @@ func (uc *CreateDomainUseCase) Execute(ctx context.Context, req *request.CreateD
 		UpdatedAt:   domain.UpdatedAt(),
 	}, nil
 }
+
+// Ensure returns the named domain, creating it first when it does not exist; created
+// reports which happened. An existing domain is returned as it is, so its description
+// may differ from the request.
+func (uc *CreateDomainUseCase) Ensure(ctx context.Context, req *request.CreateDomainRequest) (*response.DomainResponse, bool, error) {
+	name, err := uc.cleanName(req.Name)
+	if err != nil {
+		return nil, false, err
+	}
+
+	existing, err := uc.domainRepo.GetByName(ctx, name)
+	if err != nil {
+		return nil, false, err
+	}
+	if existing != nil {
+		return &response.DomainResponse{
+			Name:        existing.Name(),
+			Description: existing.Description(),
+			CreatedAt:   existing.CreatedAt(),
+			UpdatedAt:   existing.UpdatedAt(),
+		}, false, nil
+	}
+
+	result, err := uc.Execute(ctx, req)
+	if err != nil {
+		return nil, false, err
+	}
+	return result, true, nil
+}
@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"url-db/internal/application/dto/response"
+	"url-db/internal/constants"
+	"url-db/internal/domain/repository"
+)
+
+// DeleteDomainUseCase handles the deletion of an empty domain
+type DeleteDomainUseCase struct {
+	domainRepo repository.DomainRepository
+	nodeRepo   repository.NodeRepository
+}
+
+// NewDeleteDomainUseCase creates a new instance of DeleteDomainUseCase
+func NewDeleteDomainUseCase(domainRepo repository.DomainRepository, nodeRepo repository.NodeRepository) *DeleteDomainUseCase {
+	return &DeleteDomainUseCase{
+		domainRepo: domainRepo,
+		nodeRepo:   nodeRepo,
+	}
+}
+
+// Execute deletes a domain, refusing while it still contains nodes. With dryRun the
+// checks run and the domain is returned without being deleted.
+func (uc *DeleteDomainUseCase) Execute(ctx context.Context, name string, dryRun bool) (*response.DomainResponse, error) {
+	domain, err := uc.domainRepo.GetByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if domain == nil {
+		return nil, errors.New(constants.ErrDomainNotFound)
+	}
+
+	nodeCount, err := uc.nodeRepo.CountByDomain(ctx, domain.ID())
+	if err != nil {
+		return nil, err
+	}
+
+	if nodeCount > 0 {
+		return nil, fmt.Errorf("domain '%s' still contains %d node(s); delete them before deleting the domain", name, nodeCount)
+	}
+
+	if !dryRun {
+		if err := uc.domainRepo.Delete(ctx, name); err != nil {
+			return nil, err
+		}
+	}
+
+	return &response.DomainResponse{
+		Name:        domain.Name(),
+		Description: domain.Description(),
+		CreatedAt:   domain.CreatedAt(),
+		UpdatedAt:   domain.UpdatedAt(),
+	}, nil
+}
@@ -2,18 +2,44 @@ package domain
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
 	"url-db/internal/application/dto/response"
+	"url-db/internal/constants"
 	"url-db/internal/domain/repository"
 )
 
 // ListDomainsUseCase handles the listing of domains
 type ListDomainsUseCase struct {
-	domainRepo repository.DomainRepository
+	domainRepo  repository.DomainRepository
+	maxPageSize int
 }
 
 // NewListDomainsUseCase creates a new instance of ListDomainsUseCase
 func NewListDomainsUseCase(repo repository.DomainRepository) *ListDomainsUseCase {
-	return &ListDomainsUseCase{domainRepo: repo}
+	return &ListDomainsUseCase{domainRepo: repo, maxPageSize: constants.MaxPageSize}
+}
+
+// SetMaxPageSize caps the page size of the listings; 0 restores the default
+func (uc *ListDomainsUseCase) SetMaxPageSize(maxSize int) {
+	uc.maxPageSize = maxSize
+}
+
+// clampSize applies the default and maximum page size
+func (uc *ListDomainsUseCase) clampSize(size int) int {
+	maxSize := uc.maxPageSize
+	if maxSize < 1 {
+		maxSize = constants.MaxPageSize
+	}
+	if size < 1 {
+		size = constants.DefaultPageSize
+	}
+	if size > maxSize {
+		size = maxSize
+	}
+	return size
 }
 
 // Execute performs the domain listing use case
@@ -22,12 +48,7 @@ func (uc *ListDomainsUseCase) Execute(ctx context.Context, page, size int) (*res
 	if page < 1 {
 		page = 1
 	}
-	if size < 1 {
-		size = 20
-	}
-	if size > 100 {
-		size = 100
-	}
+	size = uc.clampSize(size)
 
 	// Get domains from repository
 	domains, totalCount, err := uc.domainRepo.List(ctx, page, size)
@@ -57,3 +78,64 @@ func (uc *ListDomainsUseCase) Execute(ctx context.Context, page, size int) (*res
 		TotalPages: totalPages,
 	}, nil
 }
+
+// domainCursor is the opaque list_domains cursor: the last domain of the previous page
+type domainCursor struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	ID        int       `json:"id"`
+}
+
+func encodeDomainCursor(cursor domainCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeDomainCursor(token string) (domainCursor, error) {
+	var cursor domainCursor
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || json.Unmarshal(data, &cursor) != nil || cursor.ID <= 0 {
+		return domainCursor{}, errors.New("invalid cursor")
+	}
+	return cursor, nil
+}
+
+// ExecuteAfter lists the page of domains following cursor, oldest first. An empty
+// cursor starts at the first domain; NextCursor is empty on the last page. Unlike
+// Execute it does not count domains, so every page costs the same.
+func (uc *ListDomainsUseCase) ExecuteAfter(ctx context.Context, cursor string, size int) (*response.DomainListResponse, error) {
+	size = uc.clampSize(size)
+
+	var after domainCursor
+	if cursor != "" {
+		var err error
+		if after, err = decodeDomainCursor(cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	// One extra row tells whether another page follows
+	domains, err := uc.domainRepo.ListAfter(ctx, after.CreatedAt, after.ID, size+1)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &response.DomainListResponse{Size: size}
+	if len(domains) > size {
+		domains = domains[:size]
+		last := domains[size-1]
+		result.NextCursor = encodeDomainCursor(domainCursor{Name: last.Name(), CreatedAt: last.CreatedAt(), ID: last.ID()})
+	}
+
+	result.Domains = make([]response.DomainResponse, len(domains))
+	for i, domain := range domains {
+		result.Domains[i] = response.DomainResponse{
+			Name:        domain.Name(),
+			Description: domain.Description(),
+			CreatedAt:   domain.CreatedAt(),
+			UpdatedAt:   domain.UpdatedAt(),
+		}
+	}
+
+	return result, nil
+}
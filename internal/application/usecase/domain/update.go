@@ -0,0 +1,47 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"url-db/internal/application/dto/request"
+	"url-db/internal/application/dto/response"
+	"url-db/internal/constants"
+	"url-db/internal/domain/repository"
+)
+
+// UpdateDomainUseCase handles updating a domain's description
+type UpdateDomainUseCase struct {
+	domainRepo repository.DomainRepository
+}
+
+// NewUpdateDomainUseCase creates a new instance of UpdateDomainUseCase
+func NewUpdateDomainUseCase(repo repository.DomainRepository) *UpdateDomainUseCase {
+	return &UpdateDomainUseCase{domainRepo: repo}
+}
+
+// Execute performs the domain update use case
+func (uc *UpdateDomainUseCase) Execute(ctx context.Context, name string, req *request.UpdateDomainRequest) (*response.DomainResponse, error) {
+	domain, err := uc.domainRepo.GetByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if domain == nil {
+		return nil, errors.New(constants.ErrDomainNotFound)
+	}
+
+	if err := domain.UpdateDescription(req.Description); err != nil {
+		return nil, err
+	}
+
+	if err := uc.domainRepo.Update(ctx, domain); err != nil {
+		return nil, err
+	}
+
+	return &response.DomainResponse{
+		Name:        domain.Name(),
+		Description: domain.Description(),
+		CreatedAt:   domain.CreatedAt(),
+		UpdatedAt:   domain.UpdatedAt(),
+	}, nil
+}
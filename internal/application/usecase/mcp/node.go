@@ -35,7 +35,7 @@ func NewMCPListNodesUseCase(useCase *node.ListNodesUseCase) *MCPListNodesUseCase
 
 // Execute executes the list nodes use case with MCP-specific formatting
 func (uc *MCPListNodesUseCase) Execute(ctx context.Context, domainName string, page, size int) (interface{}, error) {
-	result, err := uc.useCase.Execute(ctx, domainName, page, size)
+	result, err := uc.useCase.Execute(ctx, domainName, page, size, false)
 	if err != nil {
 		return nil, err
 	}
@@ -3,17 +3,24 @@ package node
 import (
 	"context"
 	"errors"
+	"fmt"
+	"time"
 	"url-db/internal/application/dto/request"
 	"url-db/internal/application/dto/response"
 	"url-db/internal/constants"
 	"url-db/internal/domain/entity"
 	"url-db/internal/domain/repository"
+	"url-db/internal/domain/valueobject"
 )
 
 // CreateNodeUseCase handles the creation of a new node
 type CreateNodeUseCase struct {
 	nodeRepo   repository.NodeRepository
 	domainRepo repository.DomainRepository
+	// maxNodes caps nodes per domain (0 = unlimited); domainMaxNodes overrides it by domain name
+	maxNodes       int
+	domainMaxNodes map[string]int
+	allowedSchemes []string // URL schemes nodes may use; empty = valueobject.DefaultURLSchemes
 }
 
 // NewCreateNodeUseCase creates a new instance of CreateNodeUseCase
@@ -24,6 +31,34 @@ func NewCreateNodeUseCase(nodeRepo repository.NodeRepository, domainRepo reposit
 	}
 }
 
+// SetNodeLimits sets the default per-domain node cap and per-domain overrides (0 = unlimited)
+func (uc *CreateNodeUseCase) SetNodeLimits(maxNodes int, domainMaxNodes map[string]int) {
+	uc.maxNodes = maxNodes
+	uc.domainMaxNodes = domainMaxNodes
+}
+
+// NodeLimit returns the node cap that applies to a domain (0 = unlimited)
+func (uc *CreateNodeUseCase) NodeLimit(domainName string) int {
+	if limit, ok := uc.domainMaxNodes[domainName]; ok {
+		return limit
+	}
+	return uc.maxNodes
+}
+
+// SetAllowedSchemes sets the URL schemes nodes may use; an empty list restores the default
+func (uc *CreateNodeUseCase) SetAllowedSchemes(schemes []string) {
+	uc.allowedSchemes = schemes
+}
+
+// ValidateURL checks a node URL against the allowed schemes; relative URLs pass with allowRelative
+func (uc *CreateNodeUseCase) ValidateURL(url string, allowRelative bool) error {
+	schemes := uc.allowedSchemes
+	if len(schemes) == 0 {
+		schemes = valueobject.DefaultURLSchemes
+	}
+	return valueobject.ValidateURLWithSchemes(url, allowRelative, schemes)
+}
+
 // Execute performs the node creation use case
 func (uc *CreateNodeUseCase) Execute(ctx context.Context, req *request.CreateNodeRequest) (*response.NodeResponse, error) {
 	// Check if domain exists
@@ -36,12 +71,24 @@ func (uc *CreateNodeUseCase) Execute(ctx context.Context, req *request.CreateNod
 		return nil, errors.New(constants.ErrDomainNotFound)
 	}
 
+	// Require an absolute URL unless the caller opted into relative ones
+	if err := uc.ValidateURL(req.URL, req.AllowRelative); err != nil {
+		return nil, err
+	}
+
 	// Create node entity
 	node, err := entity.NewNode(req.URL, req.Title, req.Description, domain.ID())
 	if err != nil {
 		return nil, err
 	}
 
+	if req.ExpiresAt != nil {
+		if !req.ExpiresAt.After(time.Now()) {
+			return nil, errors.New("expiry time must be in the future")
+		}
+		node.SetExpiresAt(req.ExpiresAt)
+	}
+
 	// Check if node already exists
 	exists, err := uc.nodeRepo.Exists(ctx, req.URL, req.DomainName)
 	if err != nil {
@@ -52,6 +99,17 @@ func (uc *CreateNodeUseCase) Execute(ctx context.Context, req *request.CreateNod
 		return nil, errors.New(constants.ErrDuplicateNode)
 	}
 
+	// Enforce the domain's node cap
+	if limit := uc.NodeLimit(req.DomainName); limit > 0 {
+		count, err := uc.nodeRepo.CountByDomain(ctx, domain.ID())
+		if err != nil {
+			return nil, err
+		}
+		if count >= limit {
+			return nil, fmt.Errorf("domain '%s' has reached its node limit of %d (current count: %d)", req.DomainName, limit, count)
+		}
+	}
+
 	// Save to repository
 	if err := uc.nodeRepo.Create(ctx, node); err != nil {
 		return nil, err
@@ -66,5 +124,6 @@ func (uc *CreateNodeUseCase) Execute(ctx context.Context, req *request.CreateNod
 		Description: node.Description(),
 		CreatedAt:   node.CreatedAt(),
 		UpdatedAt:   node.UpdatedAt(),
+		ExpiresAt:   node.ExpiresAt(),
 	}, nil
 }
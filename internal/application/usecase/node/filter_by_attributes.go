@@ -2,31 +2,203 @@ package node
 
 import (
 	"context"
+	"fmt"
+	"strconv"
+	"strings"
 	"url-db/internal/application/dto/response"
+	"url-db/internal/constants"
+	"url-db/internal/domain/attribute"
 	"url-db/internal/domain/repository"
 )
 
 // FilterNodesByAttributesUseCase handles filtering nodes by attributes
 type FilterNodesByAttributesUseCase struct {
-	nodeRepo repository.NodeRepository
+	nodeRepo      repository.NodeRepository
+	domainRepo    repository.DomainRepository
+	attributeRepo repository.AttributeRepository
+	transforms    *attribute.TransformPipeline
+	maxPageSize   int
 }
 
 // NewFilterNodesByAttributesUseCase creates a new instance of FilterNodesByAttributesUseCase
-func NewFilterNodesByAttributesUseCase(repo repository.NodeRepository) *FilterNodesByAttributesUseCase {
-	return &FilterNodesByAttributesUseCase{nodeRepo: repo}
+func NewFilterNodesByAttributesUseCase(repo repository.NodeRepository, domainRepo repository.DomainRepository, attributeRepo repository.AttributeRepository) *FilterNodesByAttributesUseCase {
+	return &FilterNodesByAttributesUseCase{
+		nodeRepo:      repo,
+		domainRepo:    domainRepo,
+		attributeRepo: attributeRepo,
+		transforms:    attribute.NewDefaultTransformPipeline(),
+		maxPageSize:   constants.MaxPageSize,
+	}
 }
 
-// Execute performs the node filtering use case
-func (uc *FilterNodesByAttributesUseCase) Execute(ctx context.Context, domainName string, filters []repository.AttributeFilter, page, size int) (*response.NodeListResponse, error) {
-	// Validate pagination parameters
+// SetMaxPageSize caps the page size of Execute; 0 restores the default
+func (uc *FilterNodesByAttributesUseCase) SetMaxPageSize(maxSize int) {
+	uc.maxPageSize = maxSize
+}
+
+// SetTransforms replaces the per-type transforms applied to equals filter values; it
+// should match the transforms applied when attribute values are stored
+func (uc *FilterNodesByAttributesUseCase) SetTransforms(transforms *attribute.TransformPipeline) {
+	uc.transforms = transforms
+}
+
+// PrepareFilters validates filters and returns a copy whose equals values went through
+// the same transforms as stored values, so " Go " finds a value stored as "go"
+func (uc *FilterNodesByAttributesUseCase) PrepareFilters(ctx context.Context, domainName string, filters []repository.AttributeFilter) ([]repository.AttributeFilter, error) {
+	if err := uc.ValidateFilters(ctx, domainName, filters); err != nil {
+		return nil, err
+	}
+
+	domain, err := uc.domainRepo.GetByName(ctx, domainName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get domain: %w", err)
+	}
+	if domain == nil {
+		return nil, fmt.Errorf("domain not found: %s", domainName)
+	}
+
+	return uc.transformFilters(ctx, domain.ID(), filters)
+}
+
+// transformFilters applies the value transforms of each filter's attribute type to
+// equals filters, descending into groups. Other operators match parts of values or
+// numbers and are left as given.
+func (uc *FilterNodesByAttributesUseCase) transformFilters(ctx context.Context, domainID int, filters []repository.AttributeFilter) ([]repository.AttributeFilter, error) {
+	prepared := make([]repository.AttributeFilter, len(filters))
+	for i, filter := range filters {
+		if filter.IsGroup() {
+			nested, err := uc.transformFilters(ctx, domainID, filter.Filters)
+			if err != nil {
+				return nil, err
+			}
+			filter.Filters = nested
+			prepared[i] = filter
+			continue
+		}
+
+		if operator := strings.ToLower(filter.Operator); operator == "" || operator == "equals" {
+			attr, err := uc.attributeRepo.GetByName(ctx, domainID, filter.Name)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get attribute: %w", err)
+			}
+			if attr != nil {
+				filter.Value = uc.transforms.Apply(attribute.AttributeType(attr.Type()), filter.Value)
+			}
+		}
+		prepared[i] = filter
+	}
+
+	return prepared, nil
+}
+
+// ValidateFilters checks the logic of filter groups and the numeric filters: their
+// attribute must be of type number and their values must parse as numbers. A numeric
+// operator on another type would otherwise compare text cast to 0 and silently match
+// nothing, or everything.
+func (uc *FilterNodesByAttributesUseCase) ValidateFilters(ctx context.Context, domainName string, filters []repository.AttributeFilter) error {
+	domainID := 0
+	return uc.validateFilters(ctx, domainName, &domainID, filters)
+}
+
+// validateFilters validates filters and the filters of their groups, looking up the
+// domain ID on the first numeric filter
+func (uc *FilterNodesByAttributesUseCase) validateFilters(ctx context.Context, domainName string, domainID *int, filters []repository.AttributeFilter) error {
+	for _, filter := range filters {
+		if filter.IsGroup() {
+			switch strings.ToLower(filter.Logic) {
+			case "", repository.FilterLogicAnd, repository.FilterLogicOr:
+			default:
+				return fmt.Errorf("%w: filter group logic must be 'and' or 'or', got '%s'", repository.ErrInvalidInput, filter.Logic)
+			}
+			if err := uc.validateFilters(ctx, domainName, domainID, filter.Filters); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !repository.IsNumericFilterOperator(filter.Operator) {
+			continue
+		}
+		operator := strings.ToLower(filter.Operator)
+
+		if *domainID == 0 {
+			domain, err := uc.domainRepo.GetByName(ctx, domainName)
+			if err != nil {
+				return fmt.Errorf("failed to get domain: %w", err)
+			}
+			if domain == nil {
+				return fmt.Errorf("domain not found: %s", domainName)
+			}
+			*domainID = domain.ID()
+		}
+
+		attribute, err := uc.attributeRepo.GetByName(ctx, *domainID, filter.Name)
+		if err != nil {
+			return fmt.Errorf("failed to get attribute: %w", err)
+		}
+		if attribute == nil {
+			return fmt.Errorf("%w: attribute '%s' not found in domain '%s'", repository.ErrInvalidInput, filter.Name, domainName)
+		}
+		if attribute.Type() != "number" {
+			return fmt.Errorf("%w: operator '%s' needs a number attribute, but '%s' is of type %s",
+				repository.ErrInvalidInput, operator, filter.Name, attribute.Type())
+		}
+
+		if _, err := strconv.ParseFloat(filter.Value, 64); err != nil {
+			return fmt.Errorf("%w: value '%s' of filter '%s' is not a number", repository.ErrInvalidInput, filter.Value, filter.Name)
+		}
+		if operator == "between" {
+			if filter.ValueTo == "" {
+				return fmt.Errorf("%w: operator 'between' on '%s' needs value_to", repository.ErrInvalidInput, filter.Name)
+			}
+			if _, err := strconv.ParseFloat(filter.ValueTo, 64); err != nil {
+				return fmt.Errorf("%w: value_to '%s' of filter '%s' is not a number", repository.ErrInvalidInput, filter.ValueTo, filter.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// clampPage applies the default and maximum page size to pagination parameters; a
+// maxSize of 0 means constants.MaxPageSize
+func clampPage(page, size, maxSize int) (int, int) {
+	if maxSize < 1 {
+		maxSize = constants.MaxPageSize
+	}
 	if page < 1 {
 		page = 1
 	}
 	if size < 1 {
-		size = 20
+		size = constants.DefaultPageSize
+	}
+	if size > maxSize {
+		size = maxSize
 	}
-	if size > 100 {
-		size = 100
+	return page, size
+}
+
+// Explain validates and prepares filters as Execute does, then returns the query plans
+// of the queries Execute would run instead of running them
+func (uc *FilterNodesByAttributesUseCase) Explain(ctx context.Context, domainName string, filters []repository.AttributeFilter, page, size int) ([]repository.QueryPlan, error) {
+	page, size = clampPage(page, size, uc.maxPageSize)
+
+	filters, err := uc.PrepareFilters(ctx, domainName, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	return uc.nodeRepo.ExplainFilterByAttributes(ctx, domainName, filters, page, size)
+}
+
+// Execute performs the node filtering use case
+func (uc *FilterNodesByAttributesUseCase) Execute(ctx context.Context, domainName string, filters []repository.AttributeFilter, page, size int) (*response.NodeListResponse, error) {
+	// Validate pagination parameters
+	page, size = clampPage(page, size, uc.maxPageSize)
+
+	filters, err := uc.PrepareFilters(ctx, domainName, filters)
+	if err != nil {
+		return nil, err
 	}
 
 	// Get filtered nodes from repository
@@ -3,34 +3,44 @@ package node
 import (
 	"context"
 	"url-db/internal/application/dto/response"
+	"url-db/internal/constants"
 	"url-db/internal/domain/repository"
 )
 
 // ListNodesUseCase handles the listing of nodes
 type ListNodesUseCase struct {
-	nodeRepo repository.NodeRepository
+	nodeRepo    repository.NodeRepository
+	maxPageSize int
 }
 
 // NewListNodesUseCase creates a new instance of ListNodesUseCase
 func NewListNodesUseCase(repo repository.NodeRepository) *ListNodesUseCase {
-	return &ListNodesUseCase{nodeRepo: repo}
+	return &ListNodesUseCase{nodeRepo: repo, maxPageSize: constants.MaxPageSize}
 }
 
-// Execute performs the node listing use case
-func (uc *ListNodesUseCase) Execute(ctx context.Context, domainName string, page, size int) (*response.NodeListResponse, error) {
+// SetMaxPageSize caps the page size of the listings; 0 restores the default
+func (uc *ListNodesUseCase) SetMaxPageSize(maxSize int) {
+	uc.maxPageSize = maxSize
+}
+
+// Execute performs the node listing use case; archived nodes are listed only with includeArchived
+func (uc *ListNodesUseCase) Execute(ctx context.Context, domainName string, page, size int, includeArchived bool) (*response.NodeListResponse, error) {
+	return uc.Search(ctx, domainName, "", page, size, includeArchived)
+}
+
+// Search lists the nodes whose URL, title or description contains every word of query;
+// an empty query lists all nodes like Execute
+func (uc *ListNodesUseCase) Search(ctx context.Context, domainName, query string, page, size int, includeArchived bool) (*response.NodeListResponse, error) {
+	return uc.SearchInTimeRange(ctx, domainName, query, repository.NodeTimeRange{}, page, size, includeArchived)
+}
+
+// SearchInTimeRange is Search limited to nodes created and updated within timeRange
+func (uc *ListNodesUseCase) SearchInTimeRange(ctx context.Context, domainName, query string, timeRange repository.NodeTimeRange, page, size int, includeArchived bool) (*response.NodeListResponse, error) {
 	// Validate pagination parameters
-	if page < 1 {
-		page = 1
-	}
-	if size < 1 {
-		size = 20
-	}
-	if size > 100 {
-		size = 100
-	}
+	page, size = clampPage(page, size, uc.maxPageSize)
 
 	// Get nodes from repository
-	nodes, totalCount, err := uc.nodeRepo.List(ctx, domainName, page, size)
+	nodes, totalCount, err := uc.nodeRepo.SearchInTimeRange(ctx, domainName, query, timeRange, page, size, includeArchived)
 	if err != nil {
 		return nil, err
 	}
@@ -46,6 +56,7 @@ func (uc *ListNodesUseCase) Execute(ctx context.Context, domainName string, page
 			Description: node.Description(),
 			CreatedAt:   node.CreatedAt(),
 			UpdatedAt:   node.UpdatedAt(),
+			ArchivedAt:  node.ArchivedAt(),
 		}
 	}
 
@@ -0,0 +1,100 @@
+package node
+
+import (
+	"context"
+	"fmt"
+
+	"url-db/internal/domain/attribute"
+	"url-db/internal/domain/entity"
+)
+
+// AddValue adds one value of a tag or ordered_tag attribute to a node. Only the new
+// value is validated and only its row is written, so the node's other attributes
+// are left as they are. An ordered_tag value goes after the node's existing ones.
+// It reports false when the node already held the value once normalised.
+func (uc *SetNodeAttributesUseCase) AddValue(ctx context.Context, nodeID int, name, value string) (bool, error) {
+	domain, attr, err := uc.multiValuedAttribute(ctx, nodeID, name)
+	if err != nil {
+		return false, err
+	}
+
+	// ordered_tag values only validate with an order index; the real one is assigned on insert
+	attrType := attribute.AttributeType(attr.Type())
+	input := AttributeInput{Name: name, Value: value}
+	if attrType == attribute.TypeOrderedTag {
+		input.OrderIndex = new(int)
+	}
+	prepared, invalid, err := uc.PrepareValues(ctx, domain.Name(), map[string]attribute.AttributeType{name: attrType}, true, []AttributeInput{input})
+	if err != nil {
+		return false, err
+	}
+	if len(invalid) > 0 {
+		return false, &AttributeValidationError{Errors: invalid}
+	}
+
+	nodeAttr, err := entity.NewNodeAttribute(nodeID, attr.ID(), prepared[0].Value, nil)
+	if err != nil {
+		return false, fmt.Errorf("validation failed for attribute '%s': %w", name, err)
+	}
+	added, err := uc.nodeAttributeRepo.AddValue(ctx, nodeAttr, attrType == attribute.TypeOrderedTag)
+	if err != nil {
+		return false, fmt.Errorf("failed to add value: %w", err)
+	}
+	return added, nil
+}
+
+// RemoveValue removes one value of a tag or ordered_tag attribute from a node,
+// deleting only its row. The value is matched after the same transforms and
+// normalisation as stored values. It reports false when the node did not hold it.
+func (uc *SetNodeAttributesUseCase) RemoveValue(ctx context.Context, nodeID int, name, value string) (bool, error) {
+	_, attr, err := uc.multiValuedAttribute(ctx, nodeID, name)
+	if err != nil {
+		return false, err
+	}
+
+	removed, err := uc.nodeAttributeRepo.RemoveValue(ctx, nodeID, attr.ID(), uc.normalizeValue(attr, value))
+	if err != nil {
+		return false, fmt.Errorf("failed to remove value: %w", err)
+	}
+	return removed, nil
+}
+
+// normalizeValue applies the transforms and normalisation a value of attr gets when
+// stored, so it can be compared with stored values
+func (uc *SetNodeAttributesUseCase) normalizeValue(attr *entity.Attribute, value string) string {
+	// ordered_tag values only validate with an order index; any will do here
+	attrType := attribute.AttributeType(attr.Type())
+	var orderIndex *int
+	if attrType == attribute.TypeOrderedTag {
+		orderIndex = new(int)
+	}
+	value = uc.transforms.Apply(attrType, value)
+	if result := uc.validatorRegistry.ValidateAttribute(attrType, value, orderIndex); result.IsValid {
+		return result.NormalizedValue
+	}
+	return value
+}
+
+// multiValuedAttribute returns a node's domain and the named attribute of it,
+// refusing attribute types that hold a single value
+func (uc *SetNodeAttributesUseCase) multiValuedAttribute(ctx context.Context, nodeID int, name string) (*entity.Domain, *entity.Attribute, error) {
+	domain, err := uc.nodeRepo.GetDomainByNodeID(ctx, nodeID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get domain for node: %w", err)
+	}
+	if domain == nil {
+		return nil, nil, fmt.Errorf("node not found: %d", nodeID)
+	}
+
+	attr, err := uc.attributeRepo.GetByName(ctx, domain.ID(), name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get attribute '%s': %w", name, err)
+	}
+	if attr == nil {
+		return nil, nil, fmt.Errorf("attribute '%s' not defined in domain '%s'", name, domain.Name())
+	}
+	if !entity.IsMultiValuedAttributeType(string(attr.Type())) {
+		return nil, nil, fmt.Errorf("attribute '%s' is of type '%s', which holds a single value; use set_node_attributes", name, attr.Type())
+	}
+	return domain, attr, nil
+}
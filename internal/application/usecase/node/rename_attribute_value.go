@@ -0,0 +1,117 @@
+package node
+
+import (
+	"context"
+	"fmt"
+
+	"url-db/internal/domain/attribute"
+	"url-db/internal/domain/repository"
+	"url-db/internal/domain/service"
+)
+
+// RenameAttributeValueUseCase renames one value of an attribute on every node of a
+// domain, such as fixing a misspelt tag
+type RenameAttributeValueUseCase struct {
+	domainRepo        repository.DomainRepository
+	attributeRepo     repository.AttributeRepository
+	nodeAttributeRepo repository.NodeAttributeRepository
+	templateService   service.TemplateService
+	validatorRegistry *attribute.ValidatorRegistry
+	transforms        *attribute.TransformPipeline
+}
+
+// NewRenameAttributeValueUseCase creates a new use case for renaming attribute values
+func NewRenameAttributeValueUseCase(
+	domainRepo repository.DomainRepository,
+	attributeRepo repository.AttributeRepository,
+	nodeAttributeRepo repository.NodeAttributeRepository,
+	templateService service.TemplateService,
+) *RenameAttributeValueUseCase {
+	return &RenameAttributeValueUseCase{
+		domainRepo:        domainRepo,
+		attributeRepo:     attributeRepo,
+		nodeAttributeRepo: nodeAttributeRepo,
+		templateService:   templateService,
+		validatorRegistry: attribute.NewValidatorRegistry(),
+		transforms:        attribute.NewDefaultTransformPipeline(),
+	}
+}
+
+// SetTransforms replaces the per-type transforms applied to both values; it should
+// match the transforms applied when attribute values are stored
+func (uc *RenameAttributeValueUseCase) SetTransforms(transforms *attribute.TransformPipeline) {
+	uc.transforms = transforms
+}
+
+// AttributeValueRenameResult is the outcome of a rename. OldValue and NewValue are the
+// values as stored, after transforms and normalization.
+type AttributeValueRenameResult struct {
+	OldValue string
+	NewValue string
+	repository.AttributeValueRename
+}
+
+// Execute renames oldValue to newValue. The new value must pass the same validation
+// as set_node_attributes; with dryRun the counts are reported without writing.
+func (uc *RenameAttributeValueUseCase) Execute(ctx context.Context, domainName, attributeName, oldValue, newValue string, dryRun bool) (*AttributeValueRenameResult, error) {
+	domain, err := uc.domainRepo.GetByName(ctx, domainName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get domain: %w", err)
+	}
+	if domain == nil {
+		return nil, fmt.Errorf("domain '%s' not found", domainName)
+	}
+
+	attr, err := uc.attributeRepo.GetByName(ctx, domain.ID(), attributeName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attribute '%s': %w", attributeName, err)
+	}
+	if attr == nil {
+		return nil, fmt.Errorf("attribute '%s' not defined in domain '%s'", attributeName, domainName)
+	}
+	attrType := attribute.AttributeType(attr.Type())
+
+	// The old value is looked up the way it was stored; a value that no longer
+	// validates is looked up as given
+	oldValue = uc.transforms.Apply(attrType, oldValue)
+	if normalized, err := uc.normalize(attrType, oldValue); err == nil {
+		oldValue = normalized
+	}
+
+	newValue, err = uc.normalize(attrType, uc.transforms.Apply(attrType, newValue))
+	if err != nil {
+		return nil, fmt.Errorf("validation failed for attribute '%s': %w", attributeName, err)
+	}
+	templateValidation, err := uc.templateService.ValidateAttributeValue(ctx, domainName, attributeName, newValue)
+	if err != nil {
+		return nil, fmt.Errorf("template validation error for attribute '%s': %w", attributeName, err)
+	}
+	if !templateValidation.IsValid {
+		return nil, fmt.Errorf("template validation failed for attribute '%s': %s", attributeName, templateValidation.ErrorMessage)
+	}
+
+	if oldValue == newValue {
+		return nil, fmt.Errorf("old_value and new_value are the same once normalized: '%s'", newValue)
+	}
+
+	rename, err := uc.nodeAttributeRepo.RenameValue(ctx, attr.ID(), oldValue, newValue, dryRun)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AttributeValueRenameResult{OldValue: oldValue, NewValue: newValue, AttributeValueRename: *rename}, nil
+}
+
+// normalize validates a value for the attribute type and returns its stored form. The
+// order of ordered tags is kept by a rename, so any valid index stands in for it.
+func (uc *RenameAttributeValueUseCase) normalize(attrType attribute.AttributeType, value string) (string, error) {
+	var orderIndex *int
+	if attrType == attribute.TypeOrderedTag {
+		orderIndex = new(int)
+	}
+	result := uc.validatorRegistry.ValidateAttribute(attrType, value, orderIndex)
+	if !result.IsValid {
+		return "", fmt.Errorf("attribute validation failed: %s", result.ErrorMessage)
+	}
+	return result.NormalizedValue, nil
+}
@@ -3,6 +3,8 @@ package node
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 
 	"url-db/internal/domain/attribute"
 	"url-db/internal/domain/entity"
@@ -17,6 +19,7 @@ type SetNodeAttributesUseCase struct {
 	nodeAttributeRepo repository.NodeAttributeRepository
 	templateService   service.TemplateService
 	validatorRegistry *attribute.ValidatorRegistry
+	transforms        *attribute.TransformPipeline
 }
 
 // NewSetNodeAttributesUseCase creates a new use case for setting node attributes
@@ -32,9 +35,15 @@ func NewSetNodeAttributesUseCase(
 		nodeAttributeRepo: nodeAttributeRepo,
 		templateService:   templateService,
 		validatorRegistry: attribute.NewValidatorRegistry(),
+		transforms:        attribute.NewDefaultTransformPipeline(),
 	}
 }
 
+// SetTransforms replaces the per-type transforms applied to values before validation
+func (uc *SetNodeAttributesUseCase) SetTransforms(transforms *attribute.TransformPipeline) {
+	uc.transforms = transforms
+}
+
 // AttributeInput represents an attribute to be set
 type AttributeInput struct {
 	Name       string `json:"name"`
@@ -42,79 +51,346 @@ type AttributeInput struct {
 	OrderIndex *int   `json:"order_index,omitempty"`
 }
 
-// Execute sets attributes for a node with validation
+// AttributeValueError is a validation problem with one attribute input
+type AttributeValueError struct {
+	Name  string
+	Value string
+	Err   error
+}
+
+// AttributeValidationError is returned by Execute when any input fails validation.
+// The node's attributes are then left as they were.
+type AttributeValidationError struct {
+	Errors []AttributeValueError
+}
+
+func (e *AttributeValidationError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, invalid := range e.Errors {
+		messages = append(messages, invalid.Err.Error())
+	}
+	return fmt.Sprintf("no attributes were set, %d invalid: %s", len(e.Errors), strings.Join(messages, "; "))
+}
+
+// Unwrap returns the error of each invalid input
+func (e *AttributeValidationError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, invalid := range e.Errors {
+		errs = append(errs, invalid.Err)
+	}
+	return errs
+}
+
+// AttributeChange describes how set_node_attributes would change one attribute of a node
+type AttributeChange struct {
+	Name      string
+	OldValues []string
+	NewValues []string
+}
+
+// AttributeChangePreview is the outcome of a dry run: the attributes that would be
+// added, changed, left unchanged or removed, and the inputs that fail validation.
+// Nothing is written when Errors is non-empty, even outside a dry run.
+type AttributeChangePreview struct {
+	Added     []AttributeChange
+	Changed   []AttributeChange
+	Unchanged []AttributeChange
+	Removed   []AttributeChange
+	Errors    []AttributeValueError
+}
+
+// Execute sets attributes for a node with validation. It is all or nothing: every
+// input is validated before anything is written, and the attributes are replaced
+// in a single transaction.
 func (uc *SetNodeAttributesUseCase) Execute(ctx context.Context, nodeID int, attributes []AttributeInput) error {
+	nodeAttributes, _, invalid, err := uc.buildNodeAttributes(ctx, nodeID, attributes)
+	if err != nil {
+		return err
+	}
+	if len(invalid) > 0 {
+		return &AttributeValidationError{Errors: invalid}
+	}
+
+	// Set all attributes (this will replace existing ones)
+	err = uc.nodeAttributeRepo.SetNodeAttributes(ctx, nodeID, nodeAttributes)
+	if err != nil {
+		return fmt.Errorf("failed to set node attributes: %w", err)
+	}
+
+	return nil
+}
+
+// Preview reports what Execute would change without writing anything. Every input
+// is validated, so all validation errors are reported rather than just the first.
+func (uc *SetNodeAttributesUseCase) Preview(ctx context.Context, nodeID int, attributes []AttributeInput) (*AttributeChangePreview, error) {
+	nodeAttributes, names, invalid, err := uc.buildNodeAttributes(ctx, nodeID, attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := uc.nodeAttributeRepo.GetByNodeID(ctx, nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node attributes: %w", err)
+	}
+
+	oldByName := make(map[string][]*entity.NodeAttribute)
+	var oldNames []string
+	for _, nodeAttr := range current {
+		if _, exists := oldByName[nodeAttr.Name()]; !exists {
+			oldNames = append(oldNames, nodeAttr.Name())
+		}
+		oldByName[nodeAttr.Name()] = append(oldByName[nodeAttr.Name()], nodeAttr)
+	}
+
+	newByName := make(map[string][]*entity.NodeAttribute)
+	var newNames []string
+	for _, nodeAttr := range nodeAttributes {
+		name := names[nodeAttr.AttributeID()]
+		if _, exists := newByName[name]; !exists {
+			newNames = append(newNames, name)
+		}
+		newByName[name] = append(newByName[name], nodeAttr)
+	}
+
+	// Inputs that failed validation are not removals, only reported as errors
+	inputNames := make(map[string]bool, len(attributes))
+	for _, attrInput := range attributes {
+		inputNames[attrInput.Name] = true
+	}
+
+	preview := &AttributeChangePreview{Errors: invalid}
+	for _, name := range newNames {
+		change := AttributeChange{Name: name, NewValues: attributeValues(newByName[name])}
+		oldAttrs, existed := oldByName[name]
+		switch {
+		case !existed:
+			preview.Added = append(preview.Added, change)
+		case sameAttributeValues(oldAttrs, newByName[name]):
+			change.OldValues = attributeValues(oldAttrs)
+			preview.Unchanged = append(preview.Unchanged, change)
+		default:
+			change.OldValues = attributeValues(oldAttrs)
+			preview.Changed = append(preview.Changed, change)
+		}
+	}
+	for _, name := range oldNames {
+		if !inputNames[name] {
+			preview.Removed = append(preview.Removed, AttributeChange{Name: name, OldValues: attributeValues(oldByName[name])})
+		}
+	}
+
+	return preview, nil
+}
+
+// buildNodeAttributes transforms and validates the inputs into the node attributes
+// Execute would store. Validation problems are returned in input order rather than
+// as an error; the map gives each attribute ID's name.
+func (uc *SetNodeAttributesUseCase) buildNodeAttributes(ctx context.Context, nodeID int, attributes []AttributeInput) ([]*entity.NodeAttribute, map[int]string, []AttributeValueError, error) {
 	// Verify node exists
 	node, err := uc.nodeRepo.GetByID(ctx, nodeID)
 	if err != nil {
-		return fmt.Errorf("failed to get node: %w", err)
+		return nil, nil, nil, fmt.Errorf("failed to get node: %w", err)
 	}
 	if node == nil {
-		return fmt.Errorf("node not found: %d", nodeID)
+		return nil, nil, nil, fmt.Errorf("node not found: %d", nodeID)
 	}
 
 	// Get domain to get domain-specific attributes
 	domain, err := uc.nodeRepo.GetDomainByNodeID(ctx, nodeID)
 	if err != nil {
-		return fmt.Errorf("failed to get domain for node: %w", err)
+		return nil, nil, nil, fmt.Errorf("failed to get domain for node: %w", err)
 	}
 	if domain == nil {
-		return fmt.Errorf("domain not found for node: %d", nodeID)
+		return nil, nil, nil, fmt.Errorf("domain not found for node: %d", nodeID)
 	}
 
-	// Process and validate each attribute
-	var nodeAttributes []*entity.NodeAttribute
+	// Get the definition of every attribute named in the inputs
+	definitions := make(map[string]*entity.Attribute)
+	types := make(map[string]attribute.AttributeType)
 	for _, attrInput := range attributes {
-		// Get attribute definition from domain
+		if _, ok := definitions[attrInput.Name]; ok {
+			continue
+		}
 		attr, err := uc.attributeRepo.GetByName(ctx, domain.ID(), attrInput.Name)
 		if err != nil {
-			return fmt.Errorf("failed to get attribute '%s': %w", attrInput.Name, err)
+			return nil, nil, nil, fmt.Errorf("failed to get attribute '%s': %w", attrInput.Name, err)
 		}
-		if attr == nil {
-			return fmt.Errorf("attribute '%s' not defined in domain '%s'", attrInput.Name, domain.Name())
+		definitions[attrInput.Name] = attr
+		if attr != nil {
+			types[attrInput.Name] = attribute.AttributeType(attr.Type())
 		}
+	}
 
-		// Validate attribute value against templates (진입점 제약)
-		templateValidation, err := uc.templateService.ValidateAttributeValue(ctx, domain.Name(), attrInput.Name, attrInput.Value)
+	prepared, invalid, err := uc.PrepareValues(ctx, domain.Name(), types, true, attributes)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	var nodeAttributes []*entity.NodeAttribute
+	names := make(map[int]string, len(definitions))
+	for _, attrInput := range prepared {
+		attr := definitions[attrInput.Name]
+		names[attr.ID()] = attr.Name()
+
+		nodeAttr, err := entity.NewNodeAttribute(nodeID, attr.ID(), attrInput.Value, attrInput.OrderIndex)
 		if err != nil {
-			return fmt.Errorf("template validation error for attribute '%s': %w", attrInput.Name, err)
-		}
-
-		// Reject if template validation fails
-		if !templateValidation.IsValid {
-			return &TemplateValidationError{
-				AttributeName: attrInput.Name,
-				Value:         attrInput.Value,
-				ErrorCode:     templateValidation.ErrorCode,
-				ErrorMessage:  templateValidation.ErrorMessage,
-				AllowedValues: templateValidation.AllowedValues,
-				TemplateUsed:  templateValidation.TemplateUsed,
+			invalid = append(invalid, AttributeValueError{
+				Name:  attrInput.Name,
+				Value: attrInput.Value,
+				Err:   fmt.Errorf("validation failed for attribute '%s': %w", attrInput.Name, err),
+			})
+			continue
+		}
+		nodeAttributes = append(nodeAttributes, nodeAttr)
+	}
+
+	return nodeAttributes, names, invalid, nil
+}
+
+// PrepareValues runs attribute inputs through the transforms, deduplication,
+// templates and type validators, in that order, as Execute does before storing
+// them. Writers that do not go through Execute, such as import_domain, use it too:
+// types are given by name since the attributes may not be defined yet, and
+// templates are only checked when the domain already exists. The returned inputs
+// carry the normalised values; validation problems are returned in input order
+// rather than as an error.
+func (uc *SetNodeAttributesUseCase) PrepareValues(ctx context.Context, domainName string, types map[string]attribute.AttributeType, checkTemplates bool, attributes []AttributeInput) ([]AttributeInput, []AttributeValueError, error) {
+	// Transform values by attribute type first, so copies that only differ before
+	// the transform are deduplicated
+	var invalid []AttributeValueError
+	transformed := make([]AttributeInput, 0, len(attributes))
+	for _, attrInput := range attributes {
+		attrType, ok := types[attrInput.Name]
+		if !ok {
+			invalid = append(invalid, AttributeValueError{
+				Name:  attrInput.Name,
+				Value: attrInput.Value,
+				Err:   fmt.Errorf("attribute '%s' not defined in domain '%s'", attrInput.Name, domainName),
+			})
+			continue
+		}
+
+		attrInput.Value = uc.transforms.Apply(attrType, attrInput.Value)
+		transformed = append(transformed, attrInput)
+	}
+
+	// Process and validate each attribute
+	prepared := make([]AttributeInput, 0, len(transformed))
+	for _, attrInput := range dedupeAttributeInputs(transformed) {
+		if checkTemplates {
+			// Validate attribute value against templates (진입점 제약)
+			templateValidation, err := uc.templateService.ValidateAttributeValue(ctx, domainName, attrInput.Name, attrInput.Value)
+			if err != nil {
+				return nil, nil, fmt.Errorf("template validation error for attribute '%s': %w", attrInput.Name, err)
+			}
+
+			// Reject if template validation fails
+			if !templateValidation.IsValid {
+				invalid = append(invalid, AttributeValueError{
+					Name:  attrInput.Name,
+					Value: attrInput.Value,
+					Err: &TemplateValidationError{
+						AttributeName: attrInput.Name,
+						Value:         attrInput.Value,
+						ErrorCode:     templateValidation.ErrorCode,
+						ErrorMessage:  templateValidation.ErrorMessage,
+						AllowedValues: templateValidation.AllowedValues,
+						TemplateUsed:  templateValidation.TemplateUsed,
+					},
+				})
+				continue
 			}
 		}
 
-		// Create validated node attribute (기존 검증 유지)
-		nodeAttr, err := entity.ValidatedNodeAttribute(
-			nodeID,
-			attr.ID(),
-			attribute.AttributeType(attr.Type()),
-			attrInput.Value,
-			attrInput.OrderIndex,
-			uc.validatorRegistry,
-		)
-		if err != nil {
-			return fmt.Errorf("validation failed for attribute '%s': %w", attrInput.Name, err)
+		// Validate and normalise by type (기존 검증 유지)
+		result := uc.validatorRegistry.ValidateAttribute(types[attrInput.Name], attrInput.Value, attrInput.OrderIndex)
+		if !result.IsValid {
+			invalid = append(invalid, AttributeValueError{
+				Name:  attrInput.Name,
+				Value: attrInput.Value,
+				Err:   fmt.Errorf("validation failed for attribute '%s': attribute validation failed: %s", attrInput.Name, result.ErrorMessage),
+			})
+			continue
 		}
+		attrInput.Value = result.NormalizedValue
+		prepared = append(prepared, attrInput)
+	}
 
-		nodeAttributes = append(nodeAttributes, nodeAttr)
+	return prepared, invalid, nil
+}
+
+// sortedAttributeValues orders values the way they are read back: by order_index
+// (missing counts as 0), then by value
+func sortedAttributeValues(nodeAttributes []*entity.NodeAttribute) []*entity.NodeAttribute {
+	sorted := append([]*entity.NodeAttribute(nil), nodeAttributes...)
+	orderOf := func(nodeAttr *entity.NodeAttribute) int {
+		if nodeAttr.OrderIndex() == nil {
+			return 0
+		}
+		return *nodeAttr.OrderIndex()
 	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if orderOf(sorted[i]) != orderOf(sorted[j]) {
+			return orderOf(sorted[i]) < orderOf(sorted[j])
+		}
+		return sorted[i].Value() < sorted[j].Value()
+	})
+	return sorted
+}
 
-	// Set all attributes (this will replace existing ones)
-	err = uc.nodeAttributeRepo.SetNodeAttributes(ctx, nodeID, nodeAttributes)
-	if err != nil {
-		return fmt.Errorf("failed to set node attributes: %w", err)
+// attributeValues returns the values of one attribute in read-back order
+func attributeValues(nodeAttributes []*entity.NodeAttribute) []string {
+	values := make([]string, 0, len(nodeAttributes))
+	for _, nodeAttr := range sortedAttributeValues(nodeAttributes) {
+		values = append(values, nodeAttr.Value())
 	}
+	return values
+}
 
-	return nil
+// sameAttributeValues reports whether two sets of values of one attribute hold the
+// same values with the same order indexes
+func sameAttributeValues(a, b []*entity.NodeAttribute) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a, b = sortedAttributeValues(a), sortedAttributeValues(b)
+	for i := range a {
+		if a[i].Value() != b[i].Value() || !sameOrderIndex(a[i].OrderIndex(), b[i].OrderIndex()) {
+			return false
+		}
+	}
+	return true
+}
+
+func sameOrderIndex(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// dedupeAttributeInputs drops repeated name/value pairs, keeping the first
+// occurrence but with the lowest order_index any of the copies asked for
+func dedupeAttributeInputs(attributes []AttributeInput) []AttributeInput {
+	deduped := make([]AttributeInput, 0, len(attributes))
+	positions := make(map[[2]string]int, len(attributes))
+
+	for _, attrInput := range attributes {
+		key := [2]string{attrInput.Name, attrInput.Value}
+		position, seen := positions[key]
+		if !seen {
+			positions[key] = len(deduped)
+			deduped = append(deduped, attrInput)
+			continue
+		}
+
+		kept := &deduped[position]
+		if attrInput.OrderIndex != nil && (kept.OrderIndex == nil || *attrInput.OrderIndex < *kept.OrderIndex) {
+			kept.OrderIndex = attrInput.OrderIndex
+		}
+	}
+
+	return deduped
 }
 
 // TemplateValidationError represents a template-based validation error
@@ -0,0 +1,22 @@
+package node
+
+import (
+	"context"
+	"time"
+	"url-db/internal/domain/repository"
+)
+
+// SweepExpiredNodesUseCase deletes nodes whose expiry has passed
+type SweepExpiredNodesUseCase struct {
+	nodeRepo repository.NodeRepository
+}
+
+// NewSweepExpiredNodesUseCase creates a new instance of SweepExpiredNodesUseCase
+func NewSweepExpiredNodesUseCase(nodeRepo repository.NodeRepository) *SweepExpiredNodesUseCase {
+	return &SweepExpiredNodesUseCase{nodeRepo: nodeRepo}
+}
+
+// Execute deletes every node expired as of now and returns how many were removed
+func (uc *SweepExpiredNodesUseCase) Execute(ctx context.Context) (int, error) {
+	return uc.nodeRepo.DeleteExpired(ctx, time.Now())
+}
@@ -1,8 +1,10 @@
 package compositekey
 
 import (
+	"fmt"
 	"regexp"
 	"strings"
+	"unicode"
 )
 
 // 특수문자를 하이픈으로 변환하는 정규표현식
@@ -25,7 +27,14 @@ func NormalizeToolName(toolName string) (string, error) {
 }
 
 // NormalizeDomainName 은 도메인명을 정규화합니다.
+// ASCII가 아닌 문자는 하이픈으로 바꾸지 않고 거부합니다 (entity.ValidateDomainName 과 동일한 규칙).
 func NormalizeDomainName(domainName string) (string, error) {
+	for _, r := range domainName {
+		if r > unicode.MaxASCII && !unicode.IsSpace(r) {
+			return "", NewInvalidDomainNameError(fmt.Sprintf("도메인명에 ASCII가 아닌 문자 '%c'가 있습니다", r))
+		}
+	}
+
 	normalized := normalizeString(domainName)
 
 	if len(normalized) == 0 {
@@ -4,6 +4,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 	"url-db/internal/constants"
 )
 
@@ -12,6 +13,45 @@ type Config struct {
 	DatabaseURL          string
 	ToolName             string
 	AutoCreateAttributes bool
+	ResponseEnvelope     bool
+	TitleFetchAllowlist  []string
+	TitleFetchDenylist   []string
+	DependencyTypes      []string
+	SoftWarnings         bool
+	MaxNodesPerDomain    int
+	DomainMaxNodes       map[string]int
+	CompactJSON          bool
+	EventBufferSize      int
+	EventFlushInterval   time.Duration
+	NodeExpirySweep      time.Duration
+	SSEDoneEvent         string
+	DBMaxOpenConns       int
+	DBMaxIdleConns       int
+	DBWALMode            bool
+	DBBusyTimeout        time.Duration
+	ToolTimeout          time.Duration
+	ToolTimeouts         map[string]time.Duration
+	LowercaseDomainNames bool
+	DomainNameUnicode    string
+	MaxFilters           int
+	MaxPageSize          int
+	ScanDefaultOrder     string
+	MaxRequestBodyBytes  int64
+	AttributeTransforms  map[string][]string
+	SubscriptionPoll     time.Duration
+	SubscriptionRetries  int
+	RateLimitRPS         float64
+	RateLimitBurst       int
+	RateLimitTrustXFF    bool
+	APIKey               string
+	CORSAllowedOrigins   []string
+	AccessLogLevel       string
+	AccessLogFile        string
+	AdminTools           bool
+	ReadOnly             bool
+	AllowedURLSchemes    []string
+	AttributeNameMinLen  int
+	AttributeNamePattern string
 }
 
 func Load() *Config {
@@ -20,6 +60,45 @@ func Load() *Config {
 		DatabaseURL:          getEnv("DATABASE_URL", "file:./"+constants.DefaultDBPath),
 		ToolName:             getEnv("TOOL_NAME", constants.DefaultServerName),
 		AutoCreateAttributes: getBoolEnv("AUTO_CREATE_ATTRIBUTES", true),
+		ResponseEnvelope:     getBoolEnv("RESPONSE_ENVELOPE", false),
+		TitleFetchAllowlist:  getListEnv("TITLE_FETCH_ALLOWLIST", ""),
+		TitleFetchDenylist:   getListEnv("TITLE_FETCH_DENYLIST", ""),
+		DependencyTypes:      getListEnv("DEPENDENCY_TYPES", constants.DefaultDependencyTypes),
+		SoftWarnings:         getBoolEnv("SOFT_WARNINGS", true),
+		MaxNodesPerDomain:    getIntEnv("MAX_NODES_PER_DOMAIN", 0),
+		DomainMaxNodes:       getIntMapEnv("DOMAIN_MAX_NODES"),
+		CompactJSON:          getBoolEnv("COMPACT_JSON", true),
+		EventBufferSize:      getIntEnv("EVENT_BUFFER_SIZE", 0),
+		EventFlushInterval:   time.Duration(getIntEnv("EVENT_FLUSH_INTERVAL_MS", int(constants.DefaultEventFlushInterval/time.Millisecond))) * time.Millisecond,
+		NodeExpirySweep:      time.Duration(getIntEnv("NODE_EXPIRY_SWEEP_INTERVAL_MS", int(constants.DefaultNodeExpirySweepInterval/time.Millisecond))) * time.Millisecond,
+		SSEDoneEvent:         getEnv("SSE_DONE_EVENT", constants.DefaultSSEDoneEvent),
+		DBMaxOpenConns:       getIntEnv("DB_MAX_OPEN_CONNS", constants.DefaultDBMaxOpenConns),
+		DBMaxIdleConns:       getIntEnv("DB_MAX_IDLE_CONNS", constants.DefaultDBMaxIdleConns),
+		DBWALMode:            getBoolEnv("DB_WAL_MODE", true),
+		DBBusyTimeout:        time.Duration(getIntEnv("DB_BUSY_TIMEOUT_MS", int(constants.DefaultDBBusyTimeout/time.Millisecond))) * time.Millisecond,
+		ToolTimeout:          time.Duration(getIntEnv("TOOL_TIMEOUT_MS", int(constants.DefaultToolTimeout/time.Millisecond))) * time.Millisecond,
+		ToolTimeouts:         getMillisecondsMapEnv("TOOL_TIMEOUTS"),
+		LowercaseDomainNames: getBoolEnv("LOWERCASE_DOMAIN_NAMES", false),
+		DomainNameUnicode:    getChoiceEnv("DOMAIN_NAME_UNICODE", constants.DefaultDomainNameUnicode, constants.DomainNameUnicodeReject, constants.DomainNameUnicodeNFKC),
+		MaxFilters:           getIntEnv("MAX_FILTERS", constants.DefaultMaxFilters),
+		MaxPageSize:          getIntEnv("MAX_PAGE_SIZE", constants.MaxPageSize),
+		ScanDefaultOrder:     getChoiceEnv("SCAN_DEFAULT_ORDER", constants.DefaultScanOrder, "asc", "desc"),
+		MaxRequestBodyBytes:  int64(getIntEnv("MAX_REQUEST_BODY_BYTES", constants.DefaultMaxRequestBodyBytes)),
+		AttributeTransforms:  getListMapEnv("ATTRIBUTE_TRANSFORMS"),
+		SubscriptionPoll:     time.Duration(getIntEnv("SUBSCRIPTION_POLL_INTERVAL_MS", int(constants.DefaultSubscriptionPollInterval/time.Millisecond))) * time.Millisecond,
+		SubscriptionRetries:  getIntEnv("SUBSCRIPTION_RETRY_COUNT", constants.DefaultSubscriptionRetryCount),
+		RateLimitRPS:         getFloatEnv("RATE_LIMIT_RPS", 0),
+		RateLimitBurst:       getIntEnv("RATE_LIMIT_BURST", constants.DefaultRateLimitBurst),
+		RateLimitTrustXFF:    getBoolEnv("RATE_LIMIT_TRUST_FORWARDED_FOR", false),
+		APIKey:               getEnv("MCP_API_KEY", ""),
+		CORSAllowedOrigins:   getListEnv("CORS_ALLOWED_ORIGINS", ""),
+		AccessLogLevel:       getChoiceEnv("ACCESS_LOG_LEVEL", constants.DefaultAccessLogLevel, constants.AccessLogOff, constants.AccessLogDebug, constants.AccessLogInfo, constants.AccessLogError),
+		AccessLogFile:        getEnv("ACCESS_LOG_FILE", ""),
+		AdminTools:           getBoolEnv("ADMIN_TOOLS", false),
+		ReadOnly:             getBoolEnv("READ_ONLY", false),
+		AllowedURLSchemes:    getListEnv("ALLOWED_URL_SCHEMES", constants.DefaultAllowedURLSchemes),
+		AttributeNameMinLen:  getIntEnv("ATTRIBUTE_NAME_MIN_LENGTH", constants.DefaultAttributeNameMinLength),
+		AttributeNamePattern: getEnv("ATTRIBUTE_NAME_PATTERN", constants.DefaultAttributeNamePattern),
 	}
 }
 
@@ -46,3 +125,90 @@ func getBoolEnv(key string, defaultValue bool) bool {
 	}
 	return defaultValue
 }
+
+// getListEnv parses a comma-separated environment variable into a list
+func getListEnv(key, defaultValue string) []string {
+	var values []string
+	for _, item := range strings.Split(getEnv(key, defaultValue), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+	return values
+}
+
+// getChoiceEnv returns the lowercased value if it is one of choices, otherwise the default
+func getChoiceEnv(key, defaultValue string, choices ...string) string {
+	value := strings.ToLower(strings.TrimSpace(os.Getenv(key)))
+	for _, choice := range choices {
+		if value == choice {
+			return value
+		}
+	}
+	return defaultValue
+}
+
+func getIntEnv(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.Atoi(strings.TrimSpace(value)); err == nil && parsed >= 0 {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+// getFloatEnv returns a non-negative number such as 2.5, otherwise the default
+func getFloatEnv(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil && parsed >= 0 {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+// getMillisecondsMapEnv parses name=milliseconds pairs into durations
+func getMillisecondsMapEnv(key string) map[string]time.Duration {
+	values := make(map[string]time.Duration)
+	for name, ms := range getIntMapEnv(key) {
+		values[name] = time.Duration(ms) * time.Millisecond
+	}
+	return values
+}
+
+// getIntMapEnv parses a comma-separated list of name=number pairs, skipping malformed entries
+func getIntMapEnv(key string) map[string]int {
+	values := make(map[string]int)
+	for _, item := range getListEnv(key, "") {
+		name, number, ok := strings.Cut(item, "=")
+		if !ok {
+			continue
+		}
+		parsed, err := strconv.Atoi(strings.TrimSpace(number))
+		if err != nil || parsed < 0 {
+			continue
+		}
+		values[strings.TrimSpace(name)] = parsed
+	}
+	return values
+}
+
+// getListMapEnv parses a comma-separated list of name=a+b pairs into lists, skipping
+// malformed entries. An empty list after '=' is kept.
+func getListMapEnv(key string) map[string][]string {
+	values := make(map[string][]string)
+	for _, item := range getListEnv(key, "") {
+		name, list, ok := strings.Cut(item, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			continue
+		}
+		entries := []string{}
+		for _, entry := range strings.Split(list, "+") {
+			if entry = strings.TrimSpace(entry); entry != "" {
+				entries = append(entries, entry)
+			}
+		}
+		values[strings.TrimSpace(name)] = entries
+	}
+	return values
+}
@@ -1,5 +1,7 @@
 package constants
 
+import "time"
+
 // Server configuration constants
 const (
 	// Server metadata
@@ -27,14 +29,20 @@ const (
 
 	// Limits and validation
 	MaxDomainNameLength     = 50
+	MaxAttributeNameLength  = 100
 	MaxToolNameLength       = 50
 	MaxIDLength             = 20
 	MaxTitleLength          = 255
+	LongTitleWarningLength  = 200 // Titles above this are flagged but accepted
 	MaxDescriptionLength    = 1000
 	MaxURLLength            = 2048
 	MaxAttributeValueLength = 2048
 	MaxBatchSize            = 100
-	MaxPageSize             = 100
+	MaxRequestBatchSize     = 50    // Requests per JSON-RPC batch array, which counts once for RATE_LIMIT_RPS
+	MaxURLLookupSize        = 1000  // URLs per find_nodes_by_urls call
+	MaxInheritanceDepth     = 10    // Parent levels followed for inherited attributes
+	MaxDependencyTraversal  = 10000 // Nodes visited when checking a new dependency for cycles
+	MaxPageSize             = 100   // Default cap on page sizes, see MAX_PAGE_SIZE
 	DefaultPageSize         = 20
 
 	// Composite key format
@@ -46,7 +54,8 @@ const (
 	JSONRPCVersion     = "2.0"
 	
 	// MCP notification methods
-	MCPLogNotificationMethod = "notifications/message"
+	MCPLogNotificationMethod      = "notifications/message"
+	MCPScanItemNotificationMethod = "notifications/scan_item"
 
 	// File extensions and types
 	SQLiteExtension = ".sqlite"
@@ -65,6 +74,8 @@ const (
 	ErrDuplicateDomain      = "domain already exists"
 	ErrDuplicateNode        = "node already exists in this domain"
 	ErrDuplicateAttribute   = "attribute already exists"
+	ErrDependencyNotFound   = "dependency not found"
+	ErrDuplicateDependency  = "dependency already exists"
 	ErrInvalidURL           = "invalid URL format"
 	ErrInvalidParameters    = "invalid parameters"
 	ErrDatabaseError        = "database error"
@@ -112,6 +123,100 @@ const (
 	MinTokensPerNode        = 20    // Minimum tokens per node
 	AvgTokensPerNode        = 100   // Average tokens per node
 	ScanBatchSize           = 100   // Batch size for scanning
+	SearchIndexBatchSize    = 500   // Nodes indexed per statement by rebuild_search_index
+)
+
+// Dependency types
+const (
+	// DependencyTypeHard is the only type whose dependencies may cascade deletes or updates
+	DependencyTypeHard = "hard"
+	// DefaultDependencyTypes is the comma-separated set of allowed types when none is configured
+	DefaultDependencyTypes = "hard,soft,reference"
+)
+
+// Node URL schemes
+const (
+	// DefaultAllowedURLSchemes is the comma-separated set of schemes node URLs may use when none is configured
+	DefaultAllowedURLSchemes = "http,https"
+)
+
+// Title fetching limits
+const (
+	TitleFetchTimeout      = 5 * time.Second
+	TitleFetchMaxBytes     = 1024 * 1024 // Only the first 1MB of a page is inspected
+	TitleFetchMaxRedirects = 5
+)
+
+// Database connection pool
+const (
+	DefaultDBMaxOpenConns = 10
+	DefaultDBMaxIdleConns = 5
+	DefaultDBBusyTimeout  = 5 * time.Second // How long a connection waits on a locked database
+)
+
+// Tool execution
+const (
+	DefaultToolTimeout = 30 * time.Second // Limit for tools without a TOOL_TIMEOUTS entry
+)
+
+// HTTP/SSE transports
+const (
+	DefaultMaxRequestBodyBytes = 10 * 1024 * 1024 // Largest JSON-RPC request body accepted
+	DefaultRateLimitBurst      = 20               // Requests one client IP may send at once when RATE_LIMIT_RPS is set
+)
+
+// Health checks
+const (
+	HealthStatusOK       = "ok"
+	HealthStatusDegraded = "degraded"      // The database did not answer the check query
+	HealthCheckTimeout   = 2 * time.Second // Longest the check query may take before health is degraded
+)
+
+// Attribute filtering
+const (
+	DefaultMaxFilters = 20 // Filters one filter_nodes_by_attributes call may combine
+)
+
+// Content scanning
+const (
+	DefaultScanOrder = "asc" // scan_all_content order by created_at; ascending keeps earlier pages stable as nodes are added
+)
+
+// Server shutdown
+const (
+	ShutdownTimeout = 30 * time.Second // Time allowed for in-flight requests to drain on SIGINT/SIGTERM
+)
+
+// SSE responses
+const (
+	DefaultSSEDoneEvent  = "done" // Event sent after each response so clients can stop reading
+	SSEDoneEventDisabled = "none" // SSE_DONE_EVENT value that turns the done event off
+)
+
+// Stdio bridge retries
+const (
+	DefaultBridgeMaxRetries     = 2                      // Retries after a failed attempt, so 3 attempts in all
+	DefaultBridgeRetryBaseDelay = 200 * time.Millisecond // Wait before the first retry, doubled each retry
+)
+
+// Node expiry
+const (
+	DefaultNodeExpirySweepInterval = time.Minute
+)
+
+// Node event buffering
+const (
+	DefaultEventFlushInterval = time.Second
+	DefaultEventBufferSize    = 100 // Pending events that trigger an early flush
+)
+
+// Subscription delivery
+const (
+	DefaultSubscriptionPollInterval = 5 * time.Second
+	DefaultSubscriptionRetryCount   = 3                // Retries after a failed POST before a delivery is given up
+	DefaultSubscriptionBackoff      = time.Second      // Wait before the first retry, doubled each retry
+	DefaultSubscriptionBatchSize    = 100              // Deliveries loaded per query
+	DefaultSubscriptionTimeout      = 10 * time.Second // Time limit of each POST to a subscriber
 )
 
 // Environment variables
@@ -121,13 +226,71 @@ const (
 	EnvLogLevel             = "LOG_LEVEL"
 	EnvMCPMode              = "MCP_MODE"
 	EnvAutoCreateAttributes = "AUTO_CREATE_ATTRIBUTES"
+	EnvResponseEnvelope     = "RESPONSE_ENVELOPE"
+	EnvTitleFetchAllowlist  = "TITLE_FETCH_ALLOWLIST"
+	EnvTitleFetchDenylist   = "TITLE_FETCH_DENYLIST"
+	EnvDependencyTypes      = "DEPENDENCY_TYPES"
+	EnvSoftWarnings         = "SOFT_WARNINGS"
+	EnvMaxNodesPerDomain    = "MAX_NODES_PER_DOMAIN"
+	EnvDomainMaxNodes       = "DOMAIN_MAX_NODES"
+	EnvCompactJSON          = "COMPACT_JSON"
+	EnvEventBufferSize      = "EVENT_BUFFER_SIZE"
+	EnvEventFlushInterval   = "EVENT_FLUSH_INTERVAL_MS"
+	EnvNodeExpirySweep      = "NODE_EXPIRY_SWEEP_INTERVAL_MS"
+	EnvSSEDoneEvent         = "SSE_DONE_EVENT"
+	EnvDBMaxOpenConns       = "DB_MAX_OPEN_CONNS"
+	EnvDBMaxIdleConns       = "DB_MAX_IDLE_CONNS"
+	EnvDBWALMode            = "DB_WAL_MODE"
+	EnvDBBusyTimeout        = "DB_BUSY_TIMEOUT_MS"
+	EnvToolTimeout          = "TOOL_TIMEOUT_MS"
+	EnvToolTimeouts         = "TOOL_TIMEOUTS"
+	EnvLowercaseDomainNames = "LOWERCASE_DOMAIN_NAMES"
+	EnvDomainNameUnicode    = "DOMAIN_NAME_UNICODE"
+	EnvMaxFilters           = "MAX_FILTERS"
+	EnvMaxPageSize          = "MAX_PAGE_SIZE"
+	EnvScanDefaultOrder     = "SCAN_DEFAULT_ORDER"
+	EnvMaxRequestBodyBytes  = "MAX_REQUEST_BODY_BYTES"
+	EnvAttributeTransforms  = "ATTRIBUTE_TRANSFORMS"
+	EnvSubscriptionPoll     = "SUBSCRIPTION_POLL_INTERVAL_MS"
+	EnvSubscriptionRetries  = "SUBSCRIPTION_RETRY_COUNT"
+	EnvRateLimitRPS         = "RATE_LIMIT_RPS"
+	EnvRateLimitBurst       = "RATE_LIMIT_BURST"
+	EnvRateLimitTrustXFF    = "RATE_LIMIT_TRUST_FORWARDED_FOR"
+	EnvCORSAllowedOrigins   = "CORS_ALLOWED_ORIGINS"
+	EnvAccessLogLevel       = "ACCESS_LOG_LEVEL"
+	EnvAccessLogFile        = "ACCESS_LOG_FILE"
+	EnvAdminTools           = "ADMIN_TOOLS"
+	EnvReadOnly             = "READ_ONLY"
+	EnvAllowedURLSchemes    = "ALLOWED_URL_SCHEMES"
+	EnvBridgeMaxRetries     = "BRIDGE_MAX_RETRIES"
+	EnvBridgeRetryDelay     = "BRIDGE_RETRY_BASE_DELAY_MS"
+
+	EnvAttributeNameMinLength = "ATTRIBUTE_NAME_MIN_LENGTH"
+	EnvAttributeNamePattern   = "ATTRIBUTE_NAME_PATTERN"
 )
 
 // Resource URI schemes
 const (
-	MCPResourceScheme  = "mcp"
-	FileResourceScheme = "file"
-	HTTPResourceScheme = "http"
+	MCPResourceScheme   = "mcp"
+	FileResourceScheme  = "file"
+	HTTPResourceScheme  = "http"
+	URLDBResourceScheme = "url-db" // resources/read URIs: url-db://domain[/node-id]
+)
+
+// JSON-RPC access log levels (ACCESS_LOG_LEVEL)
+const (
+	AccessLogOff          = "off"   // No access log
+	AccessLogDebug        = "debug" // Every call, with its params
+	AccessLogInfo         = "info"  // Every call
+	AccessLogError        = "error" // Failed calls only
+	DefaultAccessLogLevel = AccessLogOff
+)
+
+// Unicode handling in new domain names (DOMAIN_NAME_UNICODE)
+const (
+	DomainNameUnicodeReject  = "reject" // Names with non-ASCII characters are refused
+	DomainNameUnicodeNFKC    = "nfkc"   // Fold full-width and accented letters to ASCII first; refuse what remains
+	DefaultDomainNameUnicode = DomainNameUnicodeReject
 )
 
 // Validation patterns
@@ -137,6 +300,13 @@ const (
 	EmailPattern      = `^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`
 )
 
+// Attribute names, unless ATTRIBUTE_NAME_MIN_LENGTH and ATTRIBUTE_NAME_PATTERN say
+// otherwise. Like domain names they may not contain spaces.
+const (
+	DefaultAttributeNamePattern   = `^[a-zA-Z0-9_-]+$`
+	DefaultAttributeNameMinLength = 1
+)
+
 // Date/Time formatting
 const (
 	DateTimeFormat    = "2006-01-02 15:04:05"
@@ -198,6 +368,7 @@ const (
 	ErrOrderIndexRequired         = "order_index is required for ordered_tag type"
 	ErrOrderIndexNonNegative      = "order_index must be non-negative"
 	ErrInvalidMarkdownSyntax      = "invalid markdown syntax: unbalanced brackets or parentheses"
+	ErrMalformedMarkdownLink      = "invalid markdown link '%s': %s"
 	ErrUnsupportedImageType       = "unsupported image type: %s. Supported types: jpeg, png, gif, webp"
 	ErrInvalidBase64Encoding      = "invalid base64 encoding"
 	ErrImageSizeExceeded          = "image size exceeds maximum limit of 10MB (actual: %.2fMB)"
@@ -1,13 +1,17 @@
 package database
 
-import "time"
+import (
+	"time"
+	"url-db/internal/constants"
+)
 
 type Config struct {
 	URL             string
 	MaxOpenConns    int
 	MaxIdleConns    int
 	ConnMaxLifetime time.Duration
-	WALMode         bool
+	WALMode         bool          // Overrides JournalMode with WAL when set
+	BusyTimeout     time.Duration // Wait on a locked database before failing (0 = fail immediately)
 	ForeignKeys     bool
 	JournalMode     string
 	Synchronous     string
@@ -16,10 +20,11 @@ type Config struct {
 func DefaultConfig() *Config {
 	return &Config{
 		URL:             "file:./app.db",
-		MaxOpenConns:    10,
-		MaxIdleConns:    5,
+		MaxOpenConns:    constants.DefaultDBMaxOpenConns,
+		MaxIdleConns:    constants.DefaultDBMaxIdleConns,
 		ConnMaxLifetime: time.Hour,
 		WALMode:         true,
+		BusyTimeout:     constants.DefaultDBBusyTimeout,
 		ForeignKeys:     true,
 		JournalMode:     "WAL",
 		Synchronous:     "NORMAL",
@@ -33,6 +38,7 @@ func TestConfig() *Config {
 		MaxIdleConns:    1,
 		ConnMaxLifetime: time.Hour,
 		WALMode:         false,
+		BusyTimeout:     constants.DefaultDBBusyTimeout,
 		ForeignKeys:     true,
 		JournalMode:     "DELETE",
 		Synchronous:     "OFF",
@@ -46,6 +52,7 @@ func ProductionConfig(dbPath string) *Config {
 		MaxIdleConns:    50,
 		ConnMaxLifetime: time.Hour,
 		WALMode:         true,
+		BusyTimeout:     constants.DefaultDBBusyTimeout,
 		ForeignKeys:     true,
 		JournalMode:     "WAL",
 		Synchronous:     "FULL",
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"os"
@@ -9,6 +10,7 @@ import (
 
 	"github.com/jmoiron/sqlx"
 	_ "github.com/mattn/go-sqlite3"
+	"url-db/internal/domain/entity"
 )
 
 // Schema file path relative to project root
@@ -43,7 +45,7 @@ func New(config *Config) (*Database, error) {
 		return nil, fmt.Errorf("failed to ensure database exists: %w", err)
 	}
 
-	db, err := sql.Open("sqlite3", config.URL)
+	db, err := sql.Open("sqlite3", connectionURL(config))
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -67,16 +69,40 @@ func New(config *Config) (*Database, error) {
 		return nil, fmt.Errorf("failed to create schema: %w", err)
 	}
 
+	if err := database.migrateSchema(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate schema: %w", err)
+	}
+
 	return database, nil
 }
 
+// connectionURL adds per-connection settings to the database URL. A PRAGMA run
+// in configureDatabase reaches only one pooled connection, so settings every
+// connection needs, like the busy timeout, go into the DSN instead.
+func connectionURL(config *Config) string {
+	separator := "?"
+	if strings.Contains(config.URL, "?") {
+		separator = "&"
+	}
+	return fmt.Sprintf("%s%s_busy_timeout=%d", config.URL, separator, config.BusyTimeout.Milliseconds())
+}
+
 func configureDatabase(db *sql.DB, config *Config) error {
 	db.SetMaxOpenConns(config.MaxOpenConns)
 	db.SetMaxIdleConns(config.MaxIdleConns)
 	db.SetConnMaxLifetime(config.ConnMaxLifetime)
 
+	// The journal mode is stored in the database file, so setting it once is enough
+	journalMode := config.JournalMode
+	if config.WALMode {
+		journalMode = "WAL"
+	} else if strings.EqualFold(journalMode, "WAL") {
+		journalMode = "DELETE"
+	}
+
 	pragmas := []string{
-		fmt.Sprintf("PRAGMA journal_mode = %s", config.JournalMode),
+		fmt.Sprintf("PRAGMA journal_mode = %s", journalMode),
 		fmt.Sprintf("PRAGMA synchronous = %s", config.Synchronous),
 	}
 
@@ -84,10 +110,6 @@ func configureDatabase(db *sql.DB, config *Config) error {
 		pragmas = append(pragmas, "PRAGMA foreign_keys = ON")
 	}
 
-	if config.WALMode {
-		pragmas = append(pragmas, "PRAGMA journal_mode = WAL")
-	}
-
 	for _, pragma := range pragmas {
 		if _, err := db.Exec(pragma); err != nil {
 			return fmt.Errorf("failed to execute pragma %s: %w", pragma, err)
@@ -111,6 +133,345 @@ func (d *Database) createSchema() error {
 	return nil
 }
 
+// columnMigrations lists columns added after their table was first released.
+// schema.sql only creates missing tables, so existing databases gain them here.
+var columnMigrations = []struct {
+	table      string
+	column     string
+	definition string
+}{
+	{"nodes", "expires_at", "DATETIME"},
+	{"nodes", "archived_at", "DATETIME"},
+	{"nodes", "version", "INTEGER NOT NULL DEFAULT 1"},
+}
+
+// indexMigrations creates indexes on migrated columns once those columns exist
+var indexMigrations = []string{
+	"CREATE INDEX IF NOT EXISTS idx_nodes_expires_at ON nodes(expires_at) WHERE expires_at IS NOT NULL",
+	"CREATE INDEX IF NOT EXISTS idx_nodes_archived_at ON nodes(archived_at) WHERE archived_at IS NOT NULL",
+}
+
+// nodeVersionTrigger bumps nodes.version whenever a node's URL, domain, title,
+// description, expiry or archive state changes, so every writer takes part in the
+// version checks of update_node. It needs the migrated version column.
+const nodeVersionTrigger = `CREATE TRIGGER IF NOT EXISTS nodes_version AFTER UPDATE OF content, domain_id, title, description, expires_at, archived_at ON nodes BEGIN
+	UPDATE nodes SET version = old.version + 1 WHERE id = new.id;
+END`
+
+// migrateSchema brings an existing database up to date with schema.sql
+func (d *Database) migrateSchema() error {
+	for _, migration := range columnMigrations {
+		exists, err := d.columnExists(migration.table, migration.column)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+
+		stmt := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", migration.table, migration.column, migration.definition)
+		if _, err := d.db.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to add column %s.%s: %w", migration.table, migration.column, err)
+		}
+		logInfo("[INFO] Added column %s.%s\n", migration.table, migration.column)
+	}
+
+	if err := d.dropNodeEventsForeignKey(); err != nil {
+		return err
+	}
+	if err := d.dropNodeSubscriptionsForeignKey(); err != nil {
+		return err
+	}
+
+	for _, stmt := range indexMigrations {
+		if _, err := d.db.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to create index: %w", err)
+		}
+	}
+
+	if _, err := d.db.Exec(nodeVersionTrigger); err != nil {
+		return fmt.Errorf("failed to create node version trigger: %w", err)
+	}
+
+	return d.createSearchIndex()
+}
+
+// searchIndexTriggers keep nodes_fts in step with nodes. Writes that bypass them,
+// such as bulk loads with the triggers dropped, need rebuild_search_index.
+var searchIndexTriggers = []string{
+	`CREATE TRIGGER IF NOT EXISTS nodes_fts_insert AFTER INSERT ON nodes BEGIN
+		INSERT INTO nodes_fts (docid, url, title, description) VALUES (new.id, new.content, new.title, new.description);
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS nodes_fts_update AFTER UPDATE OF content, title, description ON nodes BEGIN
+		UPDATE nodes_fts SET url = new.content, title = new.title, description = new.description WHERE docid = new.id;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS nodes_fts_delete AFTER DELETE ON nodes BEGIN
+		DELETE FROM nodes_fts WHERE docid = old.id;
+	END`,
+}
+
+// createSearchIndex creates the full-text index of node URLs, titles and descriptions,
+// filling it from nodes when it is new. The index is optional: SQLite builds without
+// FTS4 keep working and search falls back to LIKE.
+func (d *Database) createSearchIndex() error {
+	exists, err := d.tableExists("nodes_fts")
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		if _, err := d.db.Exec(`CREATE VIRTUAL TABLE nodes_fts USING fts4(url, title, description)`); err != nil {
+			if strings.Contains(err.Error(), "no such module") {
+				logInfo("[INFO] SQLite has no FTS4 support; node search uses LIKE\n")
+				return nil
+			}
+			return fmt.Errorf("failed to create table nodes_fts: %w", err)
+		}
+		if _, err := d.db.Exec(`INSERT INTO nodes_fts (docid, url, title, description) SELECT id, content, title, description FROM nodes`); err != nil {
+			return fmt.Errorf("failed to fill table nodes_fts: %w", err)
+		}
+		logInfo("[INFO] Created full-text index nodes_fts\n")
+	}
+
+	for _, stmt := range searchIndexTriggers {
+		if _, err := d.db.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to create search index trigger: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// nodeEventsRebuild recreates node_events without its foreign key to nodes. The
+// cascading key deleted a node's events with the node, losing deletion events.
+var nodeEventsRebuild = []string{
+	`CREATE TABLE node_events_rebuild (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		node_id INTEGER NOT NULL,
+		event_type TEXT NOT NULL,
+		event_data TEXT,
+		occurred_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		processed_at DATETIME
+	)`,
+	`INSERT INTO node_events_rebuild (id, node_id, event_type, event_data, occurred_at, processed_at)
+		SELECT id, node_id, event_type, event_data, occurred_at, processed_at FROM node_events`,
+	`DROP TABLE node_events`,
+	`ALTER TABLE node_events_rebuild RENAME TO node_events`,
+	`CREATE INDEX IF NOT EXISTS idx_events_node ON node_events(node_id)`,
+	`CREATE INDEX IF NOT EXISTS idx_events_type ON node_events(event_type)`,
+	`CREATE INDEX IF NOT EXISTS idx_events_occurred ON node_events(occurred_at)`,
+	`CREATE INDEX IF NOT EXISTS idx_events_unprocessed ON node_events(processed_at) WHERE processed_at IS NULL`,
+}
+
+// dropNodeEventsForeignKey rebuilds node_events in databases created while it still
+// referenced nodes. Running it again is a no-op.
+func (d *Database) dropNodeEventsForeignKey() error {
+	var keys int
+	if err := d.db.QueryRow(`SELECT COUNT(*) FROM pragma_foreign_key_list('node_events')`).Scan(&keys); err != nil {
+		return fmt.Errorf("failed to inspect table node_events: %w", err)
+	}
+	if keys == 0 {
+		return nil
+	}
+
+	err := d.WithTransaction(func(tx *sql.Tx) error {
+		for _, stmt := range nodeEventsRebuild {
+			if _, err := tx.Exec(stmt); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to rebuild table node_events: %w", err)
+	}
+	logInfo("[INFO] Rebuilt table node_events without its foreign key\n")
+
+	return nil
+}
+
+// nodeSubscriptionsRebuild recreates node_subscriptions without its foreign key to
+// nodes. The cascading key deleted a node's subscriptions with the node, so
+// subscriptions to deletion events were never delivered.
+var nodeSubscriptionsRebuild = []string{
+	`CREATE TABLE node_subscriptions_rebuild (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		subscriber_service TEXT NOT NULL,
+		subscriber_endpoint TEXT,
+		subscribed_node_id INTEGER NOT NULL,
+		event_types TEXT NOT NULL,
+		filter_conditions TEXT,
+		is_active BOOLEAN DEFAULT TRUE,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`,
+	`INSERT INTO node_subscriptions_rebuild (id, subscriber_service, subscriber_endpoint, subscribed_node_id, event_types, filter_conditions, is_active, created_at, updated_at)
+		SELECT id, subscriber_service, subscriber_endpoint, subscribed_node_id, event_types, filter_conditions, is_active, created_at, updated_at FROM node_subscriptions`,
+	`DROP TABLE node_subscriptions`,
+	`ALTER TABLE node_subscriptions_rebuild RENAME TO node_subscriptions`,
+	`CREATE INDEX IF NOT EXISTS idx_node_subscriptions_node ON node_subscriptions(subscribed_node_id)`,
+	`CREATE INDEX IF NOT EXISTS idx_node_subscriptions_service ON node_subscriptions(subscriber_service)`,
+}
+
+// dropNodeSubscriptionsForeignKey rebuilds node_subscriptions in databases created
+// while it still referenced nodes. Running it again is a no-op.
+func (d *Database) dropNodeSubscriptionsForeignKey() error {
+	var keys int
+	if err := d.db.QueryRow(`SELECT COUNT(*) FROM pragma_foreign_key_list('node_subscriptions')`).Scan(&keys); err != nil {
+		return fmt.Errorf("failed to inspect table node_subscriptions: %w", err)
+	}
+	if keys == 0 {
+		return nil
+	}
+
+	// subscription_deliveries cascades from node_subscriptions, so dropping the old
+	// table with foreign keys on would delete the delivery history. The pragma is
+	// per connection and ignored inside a transaction, hence the dedicated connection.
+	ctx := context.Background()
+	conn, err := d.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to rebuild table node_subscriptions: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `PRAGMA foreign_keys = OFF`); err != nil {
+		return fmt.Errorf("failed to rebuild table node_subscriptions: %w", err)
+	}
+	if d.config.ForeignKeys {
+		defer conn.ExecContext(ctx, `PRAGMA foreign_keys = ON`)
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to rebuild table node_subscriptions: %w", err)
+	}
+	for _, stmt := range nodeSubscriptionsRebuild {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to rebuild table node_subscriptions: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to rebuild table node_subscriptions: %w", err)
+	}
+	logInfo("[INFO] Rebuilt table node_subscriptions without its foreign key\n")
+
+	return nil
+}
+
+// DomainNameCollision lists stored domain names that differ only by case
+type DomainNameCollision struct {
+	Name    string   // The shared lowercase name
+	Domains []string // The stored names, in id order
+}
+
+// DomainNameMigration reports what LowercaseDomainNames changed
+type DomainNameMigration struct {
+	Renamed    int
+	Collisions []DomainNameCollision
+}
+
+// LowercaseDomainNames renames domains to their lowercase form. Nodes,
+// attributes and templates reference domains by id, so they follow the rename.
+// Domains that would end up with the same name are reported as collisions and
+// left unchanged; they must be merged or renamed by hand. Running it again is a no-op.
+func (d *Database) LowercaseDomainNames() (*DomainNameMigration, error) {
+	result := &DomainNameMigration{}
+
+	err := d.WithTransaction(func(tx *sql.Tx) error {
+		rows, err := tx.Query("SELECT id, name FROM domains ORDER BY id")
+		if err != nil {
+			return fmt.Errorf("failed to list domains: %w", err)
+		}
+
+		type storedDomain struct {
+			id   int
+			name string
+		}
+		groups := make(map[string][]storedDomain)
+		var order []string
+		for rows.Next() {
+			var domain storedDomain
+			if err := rows.Scan(&domain.id, &domain.name); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to read domain: %w", err)
+			}
+			normalized := entity.NormalizeDomainName(domain.name)
+			if _, seen := groups[normalized]; !seen {
+				order = append(order, normalized)
+			}
+			groups[normalized] = append(groups[normalized], domain)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("failed to list domains: %w", err)
+		}
+
+		for _, normalized := range order {
+			domains := groups[normalized]
+			if len(domains) > 1 {
+				collision := DomainNameCollision{Name: normalized}
+				for _, domain := range domains {
+					collision.Domains = append(collision.Domains, domain.name)
+				}
+				result.Collisions = append(result.Collisions, collision)
+				continue
+			}
+
+			if domains[0].name == normalized {
+				continue
+			}
+			if _, err := tx.Exec("UPDATE domains SET name = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?", normalized, domains[0].id); err != nil {
+				return fmt.Errorf("failed to rename domain %s: %w", domains[0].name, err)
+			}
+			result.Renamed++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// columnExists reports whether a table has the given column
+func (d *Database) columnExists(table, column string) (bool, error) {
+	rows, err := d.db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false, fmt.Errorf("failed to inspect table %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid        int
+			name       string
+			columnType string
+			notNull    int
+			defaultVal sql.NullString
+			primaryKey int
+		)
+		if err := rows.Scan(&cid, &name, &columnType, &notNull, &defaultVal, &primaryKey); err != nil {
+			return false, fmt.Errorf("failed to read table %s: %w", table, err)
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+
+	return false, rows.Err()
+}
+
+// tableExists reports whether a table, including a virtual one, exists
+func (d *Database) tableExists(table string) (bool, error) {
+	var count int
+	if err := d.db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, table).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to inspect table %s: %w", table, err)
+	}
+	return count > 0, nil
+}
+
 // loadSchemaFromFile loads schema with multiple fallback strategies
 func (d *Database) loadSchemaFromFile() (string, error) {
 	var lastErr error
@@ -262,6 +623,7 @@ CREATE TABLE IF NOT EXISTS template_attributes (
 );
 
 -- Basic indexes
+CREATE INDEX IF NOT EXISTS idx_domains_created_id ON domains(julianday(created_at), id);
 CREATE INDEX IF NOT EXISTS idx_nodes_domain ON nodes(domain_id);
 CREATE INDEX IF NOT EXISTS idx_attributes_domain ON attributes(domain_id);
 CREATE INDEX IF NOT EXISTS idx_node_attributes_node ON node_attributes(node_id);
@@ -335,7 +697,7 @@ func (d *Database) SQLXDB() *sqlx.DB {
 	return d.sqlxDB
 }
 
-// InitDB initializes the database with the given URL
+// InitDB initializes the database with the given URL and default settings
 func InitDB(url string) (*Database, error) {
 	config := DefaultConfig()
 	config.URL = url
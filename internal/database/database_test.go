@@ -0,0 +1,259 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMigrateSchemaAddsNodeExpiry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "old.sqlite")
+
+	// A database created before nodes.expires_at existed
+	old, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	_, err = old.Exec(`
+		CREATE TABLE domains (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT NOT NULL UNIQUE, description TEXT,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP, updated_at DATETIME DEFAULT CURRENT_TIMESTAMP);
+		CREATE TABLE nodes (id INTEGER PRIMARY KEY AUTOINCREMENT, content TEXT NOT NULL, domain_id INTEGER NOT NULL,
+			title TEXT, description TEXT, created_at DATETIME DEFAULT CURRENT_TIMESTAMP, updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (domain_id) REFERENCES domains(id) ON DELETE CASCADE, UNIQUE(content, domain_id));
+		INSERT INTO domains (name) VALUES ('docs');
+		INSERT INTO nodes (content, domain_id) VALUES ('https://example.com', 1);
+	`)
+	old.Close()
+	if err != nil {
+		t.Fatalf("failed to create old schema: %v", err)
+	}
+
+	config := TestConfig()
+	config.URL = path
+	db, err := New(config)
+	if err != nil {
+		t.Fatalf("failed to open and migrate database: %v", err)
+	}
+	defer db.Close()
+
+	exists, err := db.columnExists("nodes", "expires_at")
+	if err != nil || !exists {
+		t.Fatalf("expected nodes.expires_at after migration (err: %v)", err)
+	}
+	if exists, err := db.columnExists("nodes", "archived_at"); err != nil || !exists {
+		t.Fatalf("expected nodes.archived_at after migration (err: %v)", err)
+	}
+
+	// Existing nodes start at version 1, and the version trigger works on the migrated table
+	var version int
+	if _, err := db.DB().Exec("UPDATE nodes SET title = 'Example'"); err != nil {
+		t.Fatalf("failed to update migrated node: %v", err)
+	}
+	if err := db.DB().QueryRow("SELECT version FROM nodes").Scan(&version); err != nil || version != 2 {
+		t.Errorf("expected version 2 after one update, got %d (err: %v)", version, err)
+	}
+
+	var expiresAt sql.NullTime
+	if err := db.DB().QueryRow("SELECT expires_at FROM nodes WHERE content = 'https://example.com'").Scan(&expiresAt); err != nil {
+		t.Fatalf("failed to read migrated node: %v", err)
+	}
+	if expiresAt.Valid {
+		t.Errorf("existing nodes should never expire, got %v", expiresAt.Time)
+	}
+
+	// Running the migration again is a no-op
+	if err := db.migrateSchema(); err != nil {
+		t.Errorf("second migration failed: %v", err)
+	}
+}
+
+func TestMigrateSchemaKeepsEventsOfDeletedNodes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "old.sqlite")
+
+	// A database created while node_events and node_subscriptions cascaded deletes from nodes
+	old, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	_, err = old.Exec(`
+		CREATE TABLE domains (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT NOT NULL UNIQUE, description TEXT,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP, updated_at DATETIME DEFAULT CURRENT_TIMESTAMP);
+		CREATE TABLE nodes (id INTEGER PRIMARY KEY AUTOINCREMENT, content TEXT NOT NULL, domain_id INTEGER NOT NULL,
+			title TEXT, description TEXT, created_at DATETIME DEFAULT CURRENT_TIMESTAMP, updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (domain_id) REFERENCES domains(id) ON DELETE CASCADE, UNIQUE(content, domain_id));
+		CREATE TABLE node_events (id INTEGER PRIMARY KEY AUTOINCREMENT, node_id INTEGER NOT NULL, event_type TEXT NOT NULL,
+			event_data TEXT, occurred_at DATETIME DEFAULT CURRENT_TIMESTAMP, processed_at DATETIME,
+			FOREIGN KEY (node_id) REFERENCES nodes(id) ON DELETE CASCADE);
+		CREATE TABLE node_subscriptions (id INTEGER PRIMARY KEY AUTOINCREMENT, subscriber_service TEXT NOT NULL,
+			subscriber_endpoint TEXT, subscribed_node_id INTEGER NOT NULL, event_types TEXT NOT NULL, filter_conditions TEXT,
+			is_active BOOLEAN DEFAULT TRUE, created_at DATETIME DEFAULT CURRENT_TIMESTAMP, updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (subscribed_node_id) REFERENCES nodes(id) ON DELETE CASCADE);
+		CREATE TABLE subscription_deliveries (subscription_id INTEGER NOT NULL, event_id INTEGER NOT NULL,
+			attempts INTEGER NOT NULL DEFAULT 0, delivered_at DATETIME, last_error TEXT, recorded_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (subscription_id, event_id), FOREIGN KEY (subscription_id) REFERENCES node_subscriptions(id) ON DELETE CASCADE);
+		INSERT INTO domains (name) VALUES ('docs');
+		INSERT INTO nodes (content, domain_id) VALUES ('https://example.com', 1);
+		INSERT INTO node_events (node_id, event_type) VALUES (1, 'created');
+		INSERT INTO node_subscriptions (subscriber_service, subscribed_node_id, event_types) VALUES ('search', 1, '["deleted"]');
+		INSERT INTO subscription_deliveries (subscription_id, event_id, attempts) VALUES (1, 1, 1);
+	`)
+	old.Close()
+	if err != nil {
+		t.Fatalf("failed to create old schema: %v", err)
+	}
+
+	config := TestConfig()
+	config.URL = path
+	db, err := New(config)
+	if err != nil {
+		t.Fatalf("failed to open and migrate database: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := db.DB().Exec("DELETE FROM nodes WHERE id = 1"); err != nil {
+		t.Fatalf("failed to delete node: %v", err)
+	}
+	var count int
+	if err := db.DB().QueryRow("SELECT COUNT(*) FROM node_events WHERE node_id = 1").Scan(&count); err != nil {
+		t.Fatalf("failed to count events: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("expected the event to outlive its node, found %d", count)
+	}
+	if err := db.DB().QueryRow("SELECT COUNT(*) FROM node_subscriptions WHERE subscribed_node_id = 1").Scan(&count); err != nil {
+		t.Fatalf("failed to count subscriptions: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("expected the subscription to outlive its node, found %d", count)
+	}
+	if err := db.DB().QueryRow("SELECT COUNT(*) FROM subscription_deliveries WHERE subscription_id = 1").Scan(&count); err != nil {
+		t.Fatalf("failed to count deliveries: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("expected the rebuild to keep the delivery history, found %d", count)
+	}
+
+	// Running the migration again is a no-op
+	if err := db.migrateSchema(); err != nil {
+		t.Errorf("second migration failed: %v", err)
+	}
+}
+
+func TestConnectionPoolSettings(t *testing.T) {
+	config := DefaultConfig()
+	config.URL = "file:" + filepath.Join(t.TempDir(), "pool.sqlite")
+	config.MaxOpenConns = 3
+	config.BusyTimeout = 2 * time.Second
+
+	db, err := New(config)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if got := db.DB().Stats().MaxOpenConnections; got != 3 {
+		t.Errorf("expected 3 max open connections, got %d", got)
+	}
+
+	var journalMode string
+	if err := db.DB().QueryRow("PRAGMA journal_mode").Scan(&journalMode); err != nil || journalMode != "wal" {
+		t.Errorf("expected wal journal mode, got %q (err: %v)", journalMode, err)
+	}
+
+	// Every pooled connection gets the busy timeout, not just the first one
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		conn, err := db.DB().Conn(ctx)
+		if err != nil {
+			t.Fatalf("failed to get connection %d: %v", i, err)
+		}
+		defer conn.Close()
+
+		var timeout int
+		if err := conn.QueryRowContext(ctx, "PRAGMA busy_timeout").Scan(&timeout); err != nil || timeout != 2000 {
+			t.Errorf("connection %d: expected busy_timeout 2000, got %d (err: %v)", i, timeout, err)
+		}
+	}
+}
+
+func TestWALModeCanBeDisabled(t *testing.T) {
+	config := DefaultConfig()
+	config.URL = "file:" + filepath.Join(t.TempDir(), "rollback.sqlite")
+	config.WALMode = false
+
+	db, err := New(config)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	var journalMode string
+	if err := db.DB().QueryRow("PRAGMA journal_mode").Scan(&journalMode); err != nil || journalMode != "delete" {
+		t.Errorf("expected delete journal mode, got %q (err: %v)", journalMode, err)
+	}
+}
+
+func TestLowercaseDomainNamesReportsCollisions(t *testing.T) {
+	config := TestConfig()
+	config.URL = "file:" + filepath.Join(t.TempDir(), "domains.sqlite")
+	db, err := New(config)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	_, err = db.DB().Exec(`
+		INSERT INTO domains (name) VALUES ('Docs'), ('Blog'), ('docs'), ('news'), ('DOCS');
+		INSERT INTO nodes (content, domain_id) VALUES ('https://example.com/post', 2);
+	`)
+	if err != nil {
+		t.Fatalf("failed to seed domains: %v", err)
+	}
+
+	migration, err := db.LowercaseDomainNames()
+	if err != nil {
+		t.Fatalf("migration failed: %v", err)
+	}
+	if migration.Renamed != 1 {
+		t.Errorf("expected only Blog to be renamed, got %d renames", migration.Renamed)
+	}
+	if len(migration.Collisions) != 1 {
+		t.Fatalf("expected 1 collision, got %+v", migration.Collisions)
+	}
+	collision := migration.Collisions[0]
+	if collision.Name != "docs" || strings.Join(collision.Domains, ",") != "Docs,docs,DOCS" {
+		t.Errorf("unexpected collision %+v", collision)
+	}
+
+	// Colliding domains keep their names; the renamed domain keeps its nodes
+	var names []string
+	rows, err := db.DB().Query("SELECT name FROM domains ORDER BY id")
+	if err != nil {
+		t.Fatalf("failed to list domains: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatalf("failed to read domain: %v", err)
+		}
+		names = append(names, name)
+	}
+	if got := strings.Join(names, ","); got != "Docs,blog,docs,news,DOCS" {
+		t.Errorf("unexpected domain names after migration: %s", got)
+	}
+
+	var domainName string
+	if err := db.DB().QueryRow("SELECT d.name FROM nodes n JOIN domains d ON d.id = n.domain_id").Scan(&domainName); err != nil || domainName != "blog" {
+		t.Errorf("expected node to follow the renamed domain, got %q (err: %v)", domainName, err)
+	}
+
+	// Only the unresolved collision remains on a second run
+	again, err := db.LowercaseDomainNames()
+	if err != nil || again.Renamed != 0 || len(again.Collisions) != 1 {
+		t.Errorf("expected second run to rename nothing, got %+v (err: %v)", again, err)
+	}
+}
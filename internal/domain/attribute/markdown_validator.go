@@ -2,10 +2,21 @@ package attribute
 
 import (
 	"fmt"
+	"net/url"
+	"regexp"
 	"strings"
 	"url-db/internal/constants"
 )
 
+// markdownLinkPattern matches inline links and images, [text](target) and
+// ![alt](target), capturing the target. Targets may hold one level of balanced
+// parentheses, as in javascript:alert(1).
+var markdownLinkPattern = regexp.MustCompile(`!?\[[^\[\]]*\]\(((?:[^()]|\([^()]*\))*)\)`)
+
+// blockedLinkSchemes are link target schemes that run code or embed content when
+// followed; data: is allowed for images only
+var blockedLinkSchemes = map[string]bool{"javascript": true, "vbscript": true, "file": true}
+
 // MarkdownValidator implements validation for markdown attribute type
 type MarkdownValidator struct{}
 
@@ -43,6 +54,14 @@ func (v *MarkdownValidator) Validate(value string, orderIndex *int) ValidationRe
 		}
 	}
 
+	if err := v.validateMarkdownLinks(value); err != nil {
+		return ValidationResult{
+			IsValid:      false,
+			ErrorCode:    constants.ValidationErrorCode,
+			ErrorMessage: err.Error(),
+		}
+	}
+
 	// Trim whitespace but preserve formatting
 	normalizedValue := strings.TrimSpace(value)
 
@@ -81,6 +100,37 @@ func (v *MarkdownValidator) validateMarkdownSyntax(value string) bool {
 	return squareBrackets == 0 && parentheses == 0
 }
 
+// validateMarkdownLinks rejects inline links and images whose target is empty, does not
+// parse as a URL or uses a blocked scheme. Reference links and autolinks are not checked.
+func (v *MarkdownValidator) validateMarkdownLinks(value string) error {
+	for _, match := range markdownLinkPattern.FindAllStringSubmatch(value, -1) {
+		link, target := match[0], strings.TrimSpace(match[1])
+
+		// The target may be wrapped in <...> and followed by a quoted title
+		if strings.HasPrefix(target, "<") {
+			if end := strings.Index(target, ">"); end > 0 {
+				target = target[1:end]
+			}
+		} else if fields := strings.Fields(target); len(fields) > 0 {
+			target = fields[0]
+		}
+
+		if target == "" {
+			return fmt.Errorf(constants.ErrMalformedMarkdownLink, link, "the link target is empty")
+		}
+		parsed, err := url.Parse(target)
+		if err != nil {
+			return fmt.Errorf(constants.ErrMalformedMarkdownLink, link, "the link target is not a valid URL")
+		}
+		scheme := strings.ToLower(parsed.Scheme)
+		isImage := strings.HasPrefix(link, "!")
+		if blockedLinkSchemes[scheme] || (scheme == "data" && !isImage) {
+			return fmt.Errorf(constants.ErrMalformedMarkdownLink, link, fmt.Sprintf("%s: links are not allowed", scheme))
+		}
+	}
+	return nil
+}
+
 // GetType returns the attribute type
 func (v *MarkdownValidator) GetType() AttributeType {
 	return TypeMarkdown
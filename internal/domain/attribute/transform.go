@@ -0,0 +1,146 @@
+package attribute
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Transform rewrites an attribute value before it is validated and stored
+type Transform func(value string) string
+
+// Built-in transform names
+const (
+	TransformTrim             = "trim"
+	TransformLowercase        = "lowercase"
+	TransformNormalizeURL     = "normalize_url"
+	TransformSanitizeMarkdown = "sanitize_markdown"
+)
+
+// transforms maps transform names to their implementation
+var transforms = map[string]Transform{
+	TransformTrim:             strings.TrimSpace,
+	TransformLowercase:        strings.ToLower,
+	TransformNormalizeURL:     normalizeURL,
+	TransformSanitizeMarkdown: sanitizeMarkdown,
+}
+
+// DefaultTransforms returns the transforms applied when none are configured:
+// trim for every type, then URL normalization for images
+func DefaultTransforms() map[AttributeType][]string {
+	return map[AttributeType][]string{
+		TypeTag:        {TransformTrim},
+		TypeOrderedTag: {TransformTrim},
+		TypeNumber:     {TransformTrim},
+		TypeString:     {TransformTrim},
+		TypeMarkdown:   {TransformTrim},
+		TypeImage:      {TransformTrim, TransformNormalizeURL},
+	}
+}
+
+// TransformPipeline applies the configured transforms of an attribute type, in order
+type TransformPipeline struct {
+	byType map[AttributeType][]Transform
+	names  map[AttributeType][]string
+}
+
+// NewDefaultTransformPipeline creates a pipeline with DefaultTransforms
+func NewDefaultTransformPipeline() *TransformPipeline {
+	pipeline, _ := NewTransformPipeline(nil) // The defaults are always valid
+	return pipeline
+}
+
+// NewTransformPipeline creates a pipeline from DefaultTransforms, replacing the
+// transforms of every type named in overrides. An empty list disables transforms
+// for that type.
+func NewTransformPipeline(overrides map[string][]string) (*TransformPipeline, error) {
+	config := DefaultTransforms()
+	registry := NewValidatorRegistry()
+	for typeName, names := range overrides {
+		attrType := AttributeType(strings.ToLower(strings.TrimSpace(typeName)))
+		if _, err := registry.GetValidator(attrType); err != nil {
+			return nil, err
+		}
+		config[attrType] = names
+	}
+
+	pipeline := &TransformPipeline{
+		byType: make(map[AttributeType][]Transform, len(config)),
+		names:  make(map[AttributeType][]string, len(config)),
+	}
+	for attrType, names := range config {
+		for _, name := range names {
+			transform, exists := transforms[name]
+			if !exists {
+				return nil, fmt.Errorf("unknown transform '%s' for type %s, must be one of: %s, %s, %s, %s",
+					name, attrType, TransformTrim, TransformLowercase, TransformNormalizeURL, TransformSanitizeMarkdown)
+			}
+			pipeline.byType[attrType] = append(pipeline.byType[attrType], transform)
+			pipeline.names[attrType] = append(pipeline.names[attrType], name)
+		}
+	}
+
+	return pipeline, nil
+}
+
+// Apply runs the transforms of attrType over value
+func (p *TransformPipeline) Apply(attrType AttributeType, value string) string {
+	for _, transform := range p.byType[attrType] {
+		value = transform(value)
+	}
+	return value
+}
+
+// Transforms returns the names of the transforms applied to attrType, in order
+func (p *TransformPipeline) Transforms(attrType AttributeType) []string {
+	return p.names[attrType]
+}
+
+// normalizeURL lowercases the scheme and host of an HTTP(S) URL and drops its default
+// port. Other values, such as data URLs, are returned unchanged.
+func normalizeURL(value string) string {
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.Host == "" {
+		return value
+	}
+
+	scheme := strings.ToLower(parsed.Scheme)
+	if scheme != "http" && scheme != "https" {
+		return value
+	}
+	parsed.Scheme = scheme
+
+	host := strings.ToLower(parsed.Hostname())
+	port := parsed.Port()
+	if port == "" || (scheme == "http" && port == "80") || (scheme == "https" && port == "443") {
+		parsed.Host = host
+		if strings.Contains(host, ":") {
+			parsed.Host = "[" + host + "]" // IPv6 literal
+		}
+	} else {
+		parsed.Host = strings.ToLower(parsed.Host)
+	}
+
+	return parsed.String()
+}
+
+var (
+	// unsafeHTMLBlock matches elements whose content must not be rendered
+	unsafeHTMLBlock = regexp.MustCompile(`(?is)<(script|style|iframe|object|embed)\b[^>]*>.*?</(script|style|iframe|object|embed)\s*>`)
+	// unsafeHTMLTag matches stray opening or self-closing tags of those elements
+	unsafeHTMLTag = regexp.MustCompile(`(?i)</?(script|style|iframe|object|embed)\b[^>]*>`)
+	// eventHandlerAttr matches inline event handlers such as onclick="..."
+	eventHandlerAttr = regexp.MustCompile(`(?i)\s+on[a-z]+\s*=\s*("[^"]*"|'[^']*'|[^\s>]+)`)
+	// javascriptURL matches javascript: link targets
+	javascriptURL = regexp.MustCompile(`(?i)javascript\s*:`)
+)
+
+// sanitizeMarkdown removes raw HTML that runs code when the markdown is rendered:
+// script-like elements, inline event handlers and javascript: links
+func sanitizeMarkdown(value string) string {
+	value = unsafeHTMLBlock.ReplaceAllString(value, "")
+	value = unsafeHTMLTag.ReplaceAllString(value, "")
+	value = eventHandlerAttr.ReplaceAllString(value, "")
+	return javascriptURL.ReplaceAllString(value, "")
+}
@@ -0,0 +1,67 @@
+package attribute
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestTransforms(t *testing.T) {
+	tests := []struct {
+		transform string
+		input     string
+		expected  string
+	}{
+		{TransformTrim, "  go \n", "go"},
+		{TransformLowercase, "Go SQLite", "go sqlite"},
+		{TransformNormalizeURL, "HTTPS://Example.COM:443/Path/A.png?q=1", "https://example.com/Path/A.png?q=1"},
+		{TransformNormalizeURL, "http://example.com:80/a.png", "http://example.com/a.png"},
+		{TransformNormalizeURL, "http://Example.com:8080/a.png", "http://example.com:8080/a.png"},
+		{TransformNormalizeURL, "data:image/png;base64,iVBORw0KGgo=", "data:image/png;base64,iVBORw0KGgo="},
+		{TransformSanitizeMarkdown, "# Title\n<script>alert(1)</script>text", "# Title\ntext"},
+		{TransformSanitizeMarkdown, `<img src="a.png" onerror="alert(1)">`, `<img src="a.png">`},
+		{TransformSanitizeMarkdown, "[link](javascript:alert(1))", "[link](alert(1))"},
+		{TransformSanitizeMarkdown, "plain **markdown**", "plain **markdown**"},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s %q", tt.transform, tt.input), func(t *testing.T) {
+			if got := transforms[tt.transform](tt.input); got != tt.expected {
+				t.Errorf("got %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestTransformPipeline(t *testing.T) {
+	defaults := NewDefaultTransformPipeline()
+	if got := defaults.Apply(TypeString, "  Hello "); got != "Hello" {
+		t.Errorf("default string transform = %q, want trimmed only", got)
+	}
+	if got := defaults.Apply(TypeImage, " HTTP://Example.com/a.png "); got != "http://example.com/a.png" {
+		t.Errorf("default image transform = %q", got)
+	}
+
+	pipeline, err := NewTransformPipeline(map[string][]string{
+		"string":   {TransformTrim, TransformLowercase},
+		"markdown": {},
+	})
+	if err != nil {
+		t.Fatalf("failed to create pipeline: %v", err)
+	}
+	if got := pipeline.Apply(TypeString, "  Hello "); got != "hello" {
+		t.Errorf("overridden string transform = %q, want %q", got, "hello")
+	}
+	if got := pipeline.Apply(TypeMarkdown, " x "); got != " x " {
+		t.Errorf("disabled markdown transforms changed the value to %q", got)
+	}
+	if got := pipeline.Apply(TypeImage, " HTTP://Example.com/a.png "); got != "http://example.com/a.png" {
+		t.Errorf("types without overrides should keep their defaults, got %q", got)
+	}
+
+	if _, err := NewTransformPipeline(map[string][]string{"string": {"reverse"}}); err == nil {
+		t.Error("expected an error for an unknown transform")
+	}
+	if _, err := NewTransformPipeline(map[string][]string{"color": {TransformTrim}}); err == nil {
+		t.Error("expected an error for an unknown attribute type")
+	}
+}
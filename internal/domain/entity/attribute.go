@@ -2,7 +2,11 @@ package entity
 
 import (
 	"errors"
+	"fmt"
+	"regexp"
 	"time"
+	"unicode/utf8"
+	"url-db/internal/constants"
 )
 
 // Attribute represents a domain attribute that can be assigned to nodes
@@ -55,6 +59,65 @@ func NewAttribute(name, attributeType, description string, domainID int) (*Attri
 	}, nil
 }
 
+// AttributeNameRules are the naming rules for new attributes. Stored attributes are
+// loaded whatever their names, so tightening the rules never hides existing ones.
+type AttributeNameRules struct {
+	MinLength int            // Fewest characters a name may have
+	Pattern   *regexp.Regexp // What a name must match
+}
+
+var defaultAttributeNamePattern = regexp.MustCompile(constants.DefaultAttributeNamePattern)
+
+// DefaultAttributeNameRules returns the rules used when none are configured: one to
+// MaxAttributeNameLength ASCII letters, digits, hyphens and underscores
+func DefaultAttributeNameRules() AttributeNameRules {
+	return AttributeNameRules{MinLength: constants.DefaultAttributeNameMinLength, Pattern: defaultAttributeNamePattern}
+}
+
+// NewAttributeNameRules builds naming rules from a minimum length (below 1 means 1)
+// and a regular expression (empty means the default charset)
+func NewAttributeNameRules(minLength int, pattern string) (AttributeNameRules, error) {
+	rules := DefaultAttributeNameRules()
+	if minLength > constants.MaxAttributeNameLength {
+		return rules, fmt.Errorf("minimum attribute name length %d exceeds the maximum of %d", minLength, constants.MaxAttributeNameLength)
+	}
+	if minLength > 1 {
+		rules.MinLength = minLength
+	}
+	if pattern != "" {
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return DefaultAttributeNameRules(), fmt.Errorf("invalid attribute name pattern: %w", err)
+		}
+		rules.Pattern = compiled
+	}
+	return rules, nil
+}
+
+// Validate checks an attribute name against the rules
+func (r AttributeNameRules) Validate(name string) error {
+	if name == "" {
+		return errors.New("attribute name cannot be empty")
+	}
+
+	length := utf8.RuneCountInString(name)
+	if length < r.MinLength {
+		return fmt.Errorf("attribute name '%s' must be at least %d characters", name, r.MinLength)
+	}
+	if length > constants.MaxAttributeNameLength {
+		return fmt.Errorf("attribute name cannot exceed %d characters", constants.MaxAttributeNameLength)
+	}
+
+	if r.Pattern != nil && !r.Pattern.MatchString(name) {
+		if r.Pattern.String() == constants.DefaultAttributeNamePattern {
+			return fmt.Errorf("attribute name '%s' can only contain ASCII letters, digits, hyphens and underscores", name)
+		}
+		return fmt.Errorf("attribute name '%s' does not match the allowed pattern %s", name, r.Pattern)
+	}
+
+	return nil
+}
+
 // Getters - ensuring immutability from outside
 func (a *Attribute) ID() int              { return a.id }
 func (a *Attribute) Name() string         { return a.name }
@@ -98,3 +161,9 @@ func (a *Attribute) IsValid() bool {
 		a.domainID > 0 &&
 		validTypes[a.attributeType]
 }
+
+// IsMultiValuedAttributeType reports whether a node can hold several values of an
+// attribute of this type (tag and ordered_tag); other types hold a single value
+func IsMultiValuedAttributeType(attributeType string) bool {
+	return attributeType == "tag" || attributeType == "ordered_tag"
+}
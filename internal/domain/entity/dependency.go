@@ -0,0 +1,70 @@
+package entity
+
+import (
+	"errors"
+	"time"
+)
+
+// Dependency represents a directed dependency between two nodes:
+// the dependent node relies on the dependency node
+type Dependency struct {
+	id               int
+	dependentNodeID  int
+	dependencyNodeID int
+	dependencyType   string
+	description      string
+	cascadeDelete    bool
+	cascadeUpdate    bool
+	createdAt        time.Time
+}
+
+// NewDependency creates a new dependency entity with validation
+func NewDependency(dependentNodeID, dependencyNodeID int, dependencyType, description string) (*Dependency, error) {
+	if dependentNodeID <= 0 || dependencyNodeID <= 0 {
+		return nil, errors.New("node IDs must be positive")
+	}
+
+	if dependentNodeID == dependencyNodeID {
+		return nil, errors.New("a node cannot depend on itself")
+	}
+
+	if dependencyType == "" {
+		return nil, errors.New("dependency type cannot be empty")
+	}
+
+	return &Dependency{
+		dependentNodeID:  dependentNodeID,
+		dependencyNodeID: dependencyNodeID,
+		dependencyType:   dependencyType,
+		description:      description,
+		createdAt:        time.Now(),
+	}, nil
+}
+
+// Getters - ensuring immutability from outside
+func (d *Dependency) ID() int                { return d.id }
+func (d *Dependency) DependentNodeID() int   { return d.dependentNodeID }
+func (d *Dependency) DependencyNodeID() int  { return d.dependencyNodeID }
+func (d *Dependency) DependencyType() string { return d.dependencyType }
+func (d *Dependency) Description() string    { return d.description }
+func (d *Dependency) CascadeDelete() bool    { return d.cascadeDelete }
+func (d *Dependency) CascadeUpdate() bool    { return d.cascadeUpdate }
+func (d *Dependency) CreatedAt() time.Time   { return d.createdAt }
+
+// SetID is used by infrastructure layer after persistence
+func (d *Dependency) SetID(id int) {
+	if d.id == 0 { // Only allow setting ID once
+		d.id = id
+	}
+}
+
+// SetCascade sets whether deletes and updates cascade from the dependency node
+func (d *Dependency) SetCascade(cascadeDelete, cascadeUpdate bool) {
+	d.cascadeDelete = cascadeDelete
+	d.cascadeUpdate = cascadeUpdate
+}
+
+// SetCreatedAt sets the creation timestamp (for repository usage)
+func (d *Dependency) SetCreatedAt(createdAt time.Time) {
+	d.createdAt = createdAt
+}
@@ -2,8 +2,16 @@ package entity
 
 import (
 	"errors"
+	"fmt"
+	"regexp"
+	"strings"
 	"time"
+	"unicode"
 	"url-db/internal/constants"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
 )
 
 // Domain represents a domain entity in the business domain
@@ -38,6 +46,66 @@ func NewDomain(name, description string) (*Domain, error) {
 	}, nil
 }
 
+// NormalizeDomainName applies the lowercase domain name rule: surrounding
+// whitespace is dropped and letters are lowercased. Names stored before the
+// rule was enforced can be brought in line with Database.LowercaseDomainNames.
+func NormalizeDomainName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+var domainNameRegex = regexp.MustCompile(constants.DomainNamePattern)
+
+// FoldDomainName drops surrounding whitespace and, in the nfkc unicode mode, folds
+// compatibility characters to their plain form (full-width "ｄｏｃｓ" becomes "docs")
+// and strips accents ("café" becomes "cafe"). Characters without an ASCII form, such
+// as Hangul, are left as they are for ValidateDomainName to refuse.
+func FoldDomainName(name, unicodeMode string) string {
+	name = strings.TrimSpace(name)
+	if unicodeMode != constants.DomainNameUnicodeNFKC {
+		return name
+	}
+
+	stripAccents := transform.Chain(norm.NFKD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	if folded, _, err := transform.String(stripAccents, name); err == nil {
+		name = folded
+	}
+	return strings.TrimSpace(norm.NFKC.String(name))
+}
+
+// ValidateDomainName checks a domain name as it will be stored: 1-255 ASCII letters,
+// digits, hyphens and underscores
+func ValidateDomainName(name string) error {
+	if name == "" {
+		return errors.New("domain name cannot be empty")
+	}
+
+	for _, r := range name {
+		if r > unicode.MaxASCII {
+			return fmt.Errorf("domain name '%s' contains the non-ASCII character '%c'; use ASCII letters, digits, hyphens and underscores", name, r)
+		}
+	}
+
+	if len(name) > constants.MaxDomainNameLength {
+		return errors.New("domain name cannot exceed 255 characters")
+	}
+
+	if !domainNameRegex.MatchString(name) {
+		return fmt.Errorf("domain name '%s' can only contain ASCII letters, digits, hyphens and underscores", name)
+	}
+
+	return nil
+}
+
+// CleanDomainName prepares a requested domain name for storage: FoldDomainName,
+// then ValidateDomainName
+func CleanDomainName(name, unicodeMode string) (string, error) {
+	name = FoldDomainName(name, unicodeMode)
+	if err := ValidateDomainName(name); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
 // Getters - immutable from outside
 func (d *Domain) ID() int              { return d.id }
 func (d *Domain) Name() string         { return d.name }
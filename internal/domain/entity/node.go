@@ -14,6 +14,9 @@ type Node struct {
 	description string
 	createdAt   time.Time
 	updatedAt   time.Time
+	expiresAt   *time.Time // nil = never expires
+	archivedAt  *time.Time // nil = not archived
+	version     int        // Bumped by every change; 0 = not loaded from storage
 }
 
 // NewNode creates a new node entity with validation
@@ -50,18 +53,52 @@ func NewNode(url, title, description string, domainID int) (*Node, error) {
 }
 
 // Getters - immutable from outside
-func (n *Node) ID() int              { return n.id }
-func (n *Node) Content() string      { return n.content }
-func (n *Node) URL() string          { return n.content } // Alias for content
-func (n *Node) DomainID() int        { return n.domainID }
-func (n *Node) Title() string        { return n.title }
-func (n *Node) Description() string  { return n.description }
-func (n *Node) CreatedAt() time.Time { return n.createdAt }
-func (n *Node) UpdatedAt() time.Time { return n.updatedAt }
+func (n *Node) ID() int               { return n.id }
+func (n *Node) Content() string       { return n.content }
+func (n *Node) URL() string           { return n.content } // Alias for content
+func (n *Node) DomainID() int         { return n.domainID }
+func (n *Node) Title() string         { return n.title }
+func (n *Node) Description() string   { return n.description }
+func (n *Node) CreatedAt() time.Time  { return n.createdAt }
+func (n *Node) UpdatedAt() time.Time  { return n.updatedAt }
+func (n *Node) ExpiresAt() *time.Time { return n.expiresAt }
+func (n *Node) ArchivedAt() *time.Time { return n.archivedAt }
+func (n *Node) Version() int           { return n.version }
 
 // Setters for internal use (e.g., by repository)
 func (n *Node) SetID(id int) { n.id = id }
 
+// SetVersion sets the node's version as stored (for repository usage)
+func (n *Node) SetVersion(version int) { n.version = version }
+
+// SetExpiresAt sets when the node expires; nil means it never does
+func (n *Node) SetExpiresAt(expiresAt *time.Time) {
+	if expiresAt != nil {
+		utc := expiresAt.UTC()
+		expiresAt = &utc
+	}
+	n.expiresAt = expiresAt
+}
+
+// SetArchivedAt sets when the node was archived; nil means it is not archived
+func (n *Node) SetArchivedAt(archivedAt *time.Time) {
+	if archivedAt != nil {
+		utc := archivedAt.UTC()
+		archivedAt = &utc
+	}
+	n.archivedAt = archivedAt
+}
+
+// IsArchived reports whether the node is archived
+func (n *Node) IsArchived() bool {
+	return n.archivedAt != nil
+}
+
+// IsExpired reports whether the node has expired at the given time
+func (n *Node) IsExpired(now time.Time) bool {
+	return n.expiresAt != nil && !n.expiresAt.After(now)
+}
+
 // Business logic methods
 func (n *Node) UpdateTitle(title string) error {
 	if len(title) > 255 {
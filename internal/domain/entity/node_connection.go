@@ -0,0 +1,56 @@
+package entity
+
+import (
+	"errors"
+	"time"
+)
+
+// NodeConnection represents a directed, typed link between two nodes
+// (e.g. 'parent', 'child', 'related', 'next', 'prev')
+type NodeConnection struct {
+	id               int
+	sourceNodeID     int
+	targetNodeID     int
+	relationshipType string
+	description      string
+	createdAt        time.Time
+}
+
+// NewNodeConnection creates a new node connection entity with validation
+func NewNodeConnection(sourceNodeID, targetNodeID int, relationshipType, description string) (*NodeConnection, error) {
+	if sourceNodeID <= 0 || targetNodeID <= 0 {
+		return nil, errors.New("node IDs must be positive")
+	}
+
+	if relationshipType == "" {
+		return nil, errors.New("relationship type cannot be empty")
+	}
+
+	return &NodeConnection{
+		sourceNodeID:     sourceNodeID,
+		targetNodeID:     targetNodeID,
+		relationshipType: relationshipType,
+		description:      description,
+		createdAt:        time.Now(),
+	}, nil
+}
+
+// Getters - ensuring immutability from outside
+func (c *NodeConnection) ID() int                  { return c.id }
+func (c *NodeConnection) SourceNodeID() int        { return c.sourceNodeID }
+func (c *NodeConnection) TargetNodeID() int        { return c.targetNodeID }
+func (c *NodeConnection) RelationshipType() string { return c.relationshipType }
+func (c *NodeConnection) Description() string      { return c.description }
+func (c *NodeConnection) CreatedAt() time.Time     { return c.createdAt }
+
+// SetID is used by infrastructure layer after persistence
+func (c *NodeConnection) SetID(id int) {
+	if c.id == 0 { // Only allow setting ID once
+		c.id = id
+	}
+}
+
+// SetCreatedAt sets the creation timestamp (for repository usage)
+func (c *NodeConnection) SetCreatedAt(createdAt time.Time) {
+	c.createdAt = createdAt
+}
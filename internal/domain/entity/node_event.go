@@ -0,0 +1,67 @@
+package entity
+
+import (
+	"errors"
+	"time"
+)
+
+// Node event types
+const (
+	NodeEventCreated = "created"
+	NodeEventUpdated = "updated"
+	NodeEventDeleted = "deleted"
+)
+
+// NodeEvent records a change to a node in the node event log
+type NodeEvent struct {
+	id          int
+	nodeID      int
+	eventType   string
+	eventData   string
+	occurredAt  time.Time
+	processedAt *time.Time
+}
+
+// NewNodeEvent creates a new node event; eventData is optional JSON detail
+func NewNodeEvent(nodeID int, eventType, eventData string) (*NodeEvent, error) {
+	if nodeID <= 0 {
+		return nil, errors.New("node ID must be positive")
+	}
+
+	if eventType == "" {
+		return nil, errors.New("event type cannot be empty")
+	}
+
+	return &NodeEvent{
+		nodeID:     nodeID,
+		eventType:  eventType,
+		eventData:  eventData,
+		occurredAt: time.Now(),
+	}, nil
+}
+
+// Getters - ensuring immutability from outside
+func (e *NodeEvent) ID() int                 { return e.id }
+func (e *NodeEvent) NodeID() int             { return e.nodeID }
+func (e *NodeEvent) EventType() string       { return e.eventType }
+func (e *NodeEvent) EventData() string       { return e.eventData }
+func (e *NodeEvent) OccurredAt() time.Time   { return e.occurredAt }
+func (e *NodeEvent) ProcessedAt() *time.Time { return e.processedAt }
+
+// IsProcessed reports whether a consumer has marked the event processed
+func (e *NodeEvent) IsProcessed() bool {
+	return e.processedAt != nil
+}
+
+// SetID is used by infrastructure layer after persistence
+func (e *NodeEvent) SetID(id int) {
+	if e.id == 0 { // Only allow setting ID once
+		e.id = id
+	}
+}
+
+// SetTimestamps is used by infrastructure layer when loading from persistence
+func (e *NodeEvent) SetTimestamps(occurredAt time.Time, processedAt *time.Time) {
+	e.occurredAt = occurredAt
+	e.processedAt = processedAt
+}
@@ -0,0 +1,64 @@
+package entity
+
+import (
+	"errors"
+	"time"
+)
+
+// NodeSubscription registers an external service for change events of one node
+type NodeSubscription struct {
+	id                 int
+	subscriberService  string
+	subscriberEndpoint string
+	nodeID             int
+	eventTypes         []string
+	isActive           bool
+	createdAt          time.Time
+}
+
+// NewNodeSubscription creates a new active subscription; endpoint is optional, and
+// subscriptions without one are never delivered
+func NewNodeSubscription(subscriberService, subscriberEndpoint string, nodeID int, eventTypes []string) (*NodeSubscription, error) {
+	if subscriberService == "" {
+		return nil, errors.New("subscriber service cannot be empty")
+	}
+
+	if nodeID <= 0 {
+		return nil, errors.New("node ID must be positive")
+	}
+
+	if len(eventTypes) == 0 {
+		return nil, errors.New("at least one event type is required")
+	}
+
+	return &NodeSubscription{
+		subscriberService:  subscriberService,
+		subscriberEndpoint: subscriberEndpoint,
+		nodeID:             nodeID,
+		eventTypes:         eventTypes,
+		isActive:           true,
+		createdAt:          time.Now(),
+	}, nil
+}
+
+// Getters - ensuring immutability from outside
+func (s *NodeSubscription) ID() int                    { return s.id }
+func (s *NodeSubscription) SubscriberService() string  { return s.subscriberService }
+func (s *NodeSubscription) SubscriberEndpoint() string { return s.subscriberEndpoint }
+func (s *NodeSubscription) NodeID() int                { return s.nodeID }
+func (s *NodeSubscription) EventTypes() []string       { return s.eventTypes }
+func (s *NodeSubscription) IsActive() bool             { return s.isActive }
+func (s *NodeSubscription) CreatedAt() time.Time       { return s.createdAt }
+
+// SetID is used by infrastructure layer after persistence
+func (s *NodeSubscription) SetID(id int) {
+	if s.id == 0 { // Only allow setting ID once
+		s.id = id
+	}
+}
+
+// SetState is used by infrastructure layer when loading from persistence
+func (s *NodeSubscription) SetState(isActive bool, createdAt time.Time) {
+	s.isActive = isActive
+	s.createdAt = createdAt
+}
@@ -5,12 +5,24 @@ import (
 	"url-db/internal/domain/entity"
 )
 
+// DomainAttribute is an attribute definition together with its domain's name
+type DomainAttribute struct {
+	DomainName  string
+	Name        string
+	Type        string
+	Description string
+	ValueCount  int // Values of the attribute held by the domain's nodes
+}
+
 // AttributeRepository defines the interface for attribute data access
 type AttributeRepository interface {
 	Create(ctx context.Context, attribute *entity.Attribute) error
 	GetByID(ctx context.Context, id int) (*entity.Attribute, error)
 	GetByName(ctx context.Context, domainID int, name string) (*entity.Attribute, error)
 	ListByDomainID(ctx context.Context, domainID int) ([]*entity.Attribute, error)
+	// ListAcrossDomains lists the attribute definitions of every domain, ordered by
+	// attribute name, then domain name
+	ListAcrossDomains(ctx context.Context) ([]DomainAttribute, error)
 	Update(ctx context.Context, attribute *entity.Attribute) error
 	Delete(ctx context.Context, id int) error
 }
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"url-db/internal/domain/entity"
+)
+
+// DependencyEdge is a dependency with the domains of both endpoints and the title and
+// URL of the related node, the endpoint at the far end from the listed node
+type DependencyEdge struct {
+	Dependency       *entity.Dependency
+	DependentDomain  string
+	DependencyDomain string
+	RelatedTitle     string
+	RelatedURL       string
+}
+
+// DependencyGraph reads the active dependencies of nodes
+type DependencyGraph interface {
+	// ListByDependentNodeID retrieves active dependencies of a node (what it depends on)
+	ListByDependentNodeID(ctx context.Context, nodeID int) ([]*entity.Dependency, error)
+}
+
+// DependencyCheck vets a dependency just before it is inserted. It runs inside the
+// inserting transaction once the write lock is held, so graph shows every committed
+// dependency and those inserted before it in the same call, and no concurrent
+// insert can slip in between the check and the insert.
+type DependencyCheck func(ctx context.Context, graph DependencyGraph, dependency *entity.Dependency) error
+
+// DependencyRepository defines the contract for node dependency persistence
+type DependencyRepository interface {
+	// Create persists a dependency that passes check (nil = no check) and sets its ID
+	Create(ctx context.Context, dependency *entity.Dependency, check DependencyCheck) error
+	// CreateBatch persists several dependencies in one transaction and sets their IDs.
+	// It returns one error slot per dependency (nil on success); a failed dependency,
+	// such as a duplicate or one rejected by check, does not stop the others.
+	CreateBatch(ctx context.Context, dependencies []*entity.Dependency, check DependencyCheck) ([]error, error)
+	// ListByDependentNodeID retrieves active dependencies of a node (what it depends on)
+	ListByDependentNodeID(ctx context.Context, nodeID int) ([]*entity.Dependency, error)
+	// ListByDependencyNodeID retrieves active dependents of a node (what depends on it)
+	ListByDependencyNodeID(ctx context.Context, nodeID int) ([]*entity.Dependency, error)
+	// ListEdgesByDependentNodeID is ListByDependentNodeID with endpoints resolved in the
+	// same query; the related node of each edge is its dependency
+	ListEdgesByDependentNodeID(ctx context.Context, nodeID int) ([]DependencyEdge, error)
+	// ListEdgesByDependencyNodeID is ListByDependencyNodeID with endpoints resolved in the
+	// same query; the related node of each edge is its dependent
+	ListEdgesByDependencyNodeID(ctx context.Context, nodeID int) ([]DependencyEdge, error)
+	// Delete removes a dependency by ID
+	Delete(ctx context.Context, id int) error
+	// ListByDomain retrieves active dependencies whose endpoints both belong to a domain
+	ListByDomain(ctx context.Context, domainID int, page, size int) ([]*entity.Dependency, int, error)
+}
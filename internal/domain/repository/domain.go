@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 	"url-db/internal/domain/entity"
 )
 
@@ -19,6 +20,10 @@ type DomainRepository interface {
 	// List retrieves all domains with optional pagination
 	List(ctx context.Context, page, size int) ([]*entity.Domain, int, error)
 
+	// ListAfter retrieves up to size domains ordered by (created_at, id) that come after
+	// the given position; an id of 0 starts from the first domain
+	ListAfter(ctx context.Context, createdAt time.Time, id int, size int) ([]*entity.Domain, error)
+
 	// Update updates an existing domain
 	Update(ctx context.Context, domain *entity.Domain) error
 
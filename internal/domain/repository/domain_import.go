@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+// ImportConflict decides what an import or a domain merge does with a URL that already
+// exists in the domain written to
+type ImportConflict string
+
+const (
+	ImportConflictSkip      ImportConflict = "skip"      // Keep the existing node and its attributes
+	ImportConflictOverwrite ImportConflict = "overwrite" // Replace title, description and attributes
+	ImportConflictFail      ImportConflict = "fail"      // Abort the whole import
+)
+
+// DomainImport is a domain dump to restore, as produced by export_domain
+type DomainImport struct {
+	Name        string
+	Description string
+	Attributes  []ImportAttribute
+	Nodes       []ImportNode
+	MaxNodes    int // Node cap of the domain after the import (0 = unlimited)
+}
+
+// ImportAttribute is an attribute definition of a domain dump
+type ImportAttribute struct {
+	Name        string
+	Type        string
+	Description string
+}
+
+// ImportNode is a node of a domain dump with its attribute values
+type ImportNode struct {
+	URL         string
+	Title       string
+	Description string
+	CreatedAt   time.Time // Zero means the time of the import
+	UpdatedAt   time.Time
+	Attributes  []ImportAttributeValue
+}
+
+// ImportAttributeValue is one attribute value of an imported node
+type ImportAttributeValue struct {
+	Name       string
+	Value      string
+	OrderIndex *int
+}
+
+// DomainImportResult counts what an import created, updated and skipped
+type DomainImportResult struct {
+	DomainCreated          bool
+	AttributesCreated      int
+	AttributesSkipped      int // Already defined with the same type
+	NodesCreated           int
+	NodesUpdated           int
+	NodesSkipped           int
+	AttributeValuesCreated int
+}
+
+// DomainImportRepository restores domain dumps
+type DomainImportRepository interface {
+	// Import creates the domain if missing and inserts its attribute definitions, nodes
+	// and attribute values in one transaction; any error leaves the database unchanged
+	Import(ctx context.Context, data *DomainImport, conflict ImportConflict) (*DomainImportResult, error)
+}
@@ -0,0 +1,54 @@
+package repository
+
+import "context"
+
+// DomainMerge is a request to fold one domain into another and delete it
+type DomainMerge struct {
+	SourceDomainID            int
+	TargetDomainID            int
+	Conflict                  ImportConflict // What to do with a source URL the target already holds
+	DropConflictingAttributes bool           // Drop values of attributes whose type differs in the target, instead of aborting
+	MaxNodes                  int            // Node cap of the target domain (0 = unlimited)
+	DryRun                    bool           // Report what the merge would do and roll it back
+}
+
+// AttributeTypeConflict is an attribute both domains define with different types
+type AttributeTypeConflict struct {
+	Name       string
+	SourceType string
+	TargetType string
+	Values     int // Values of the source attribute, dropped by the merge
+}
+
+// RemovedMergeNode is a source node deleted by a merge because the target already
+// held its URL
+type RemovedMergeNode struct {
+	ID       int
+	URL      string
+	KeptByID int // Target node holding the URL, which took over its links
+}
+
+// DomainMergeResult counts what a domain merge moved, merged and dropped
+type DomainMergeResult struct {
+	NodesMoved       int
+	NodesSkipped     int // URLs the target already held, kept as they were
+	NodesOverwritten int // URLs the target already held, given the source node's title, description and attributes
+	AttributesMoved  int // Source attributes the target did not define, moved over
+	AttributesMerged int // Source attributes the target defines with the same type, whose values now use the target's
+	TemplatesMoved   int
+	TemplatesDropped int // Source templates whose name the target already uses
+	Conflicts        []AttributeTypeConflict
+	LinksRepointed   int   // Connections and dependencies of removed nodes moved onto the target node keeping their URL
+	LinksDropped     int   // Links of removed nodes the target node already had, or that joined the two nodes
+	UpdatedNodeIDs   []int // Nodes now in the target whose domain or content changed
+	RemovedNodes     []RemovedMergeNode
+}
+
+// DomainMergeRepository merges domains
+type DomainMergeRepository interface {
+	// Merge moves the source domain's nodes, attribute definitions and templates into
+	// the target and deletes the source, all in one transaction. Attribute type
+	// conflicts abort it with ErrAttributeTypeConflict, still returning the result
+	// with the conflicts, unless DropConflictingAttributes is set.
+	Merge(ctx context.Context, merge *DomainMerge) (*DomainMergeResult, error)
+}
@@ -18,4 +18,12 @@ var (
 
 	// ErrConcurrencyConflict is returned when a concurrency conflict occurs
 	ErrConcurrencyConflict = errors.New("concurrency conflict")
+
+	// ErrIncompatibleAttributes is returned when a node to move uses attributes the
+	// target domain does not define
+	ErrIncompatibleAttributes = errors.New("attributes not defined in the target domain")
+
+	// ErrAttributeTypeConflict is returned when two domains define an attribute of the
+	// same name with different types
+	ErrAttributeTypeConflict = errors.New("attribute type conflict")
 )
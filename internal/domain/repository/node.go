@@ -2,6 +2,9 @@ package repository
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"time"
 	"url-db/internal/domain/entity"
 )
 
@@ -10,21 +13,46 @@ type NodeRepository interface {
 	// Create creates a new node
 	Create(ctx context.Context, node *entity.Node) error
 
+	// CreateBatch creates several nodes in one transaction and sets their IDs. It returns
+	// one error slot per node (nil on success); when atomic is set, the first failure rolls
+	// the whole batch back and is also returned as the error.
+	CreateBatch(ctx context.Context, nodes []*entity.Node, atomic bool) ([]error, error)
+
 	// GetByID retrieves a node by its ID
 	GetByID(ctx context.Context, id int) (*entity.Node, error)
 
 	// GetByURL retrieves a node by its URL and domain
 	GetByURL(ctx context.Context, url, domainName string) (*entity.Node, error)
 
-	// List retrieves nodes by domain with optional pagination
-	List(ctx context.Context, domainName string, page, size int) ([]*entity.Node, int, error)
+	// GetByURLs retrieves the nodes in a domain matching any of the given URLs
+	GetByURLs(ctx context.Context, urls []string, domainName string) ([]*entity.Node, error)
+
+	// List retrieves nodes by domain with optional pagination. Archived nodes are
+	// left out unless includeArchived is set.
+	List(ctx context.Context, domainName string, page, size int, includeArchived bool) ([]*entity.Node, int, error)
+
+	// Search lists a domain's nodes whose URL, title or description contains every
+	// word of query, like List otherwise
+	Search(ctx context.Context, domainName, query string, page, size int, includeArchived bool) ([]*entity.Node, int, error)
+
+	// SearchInTimeRange is Search limited to nodes created and updated within timeRange
+	SearchInTimeRange(ctx context.Context, domainName, query string, timeRange NodeTimeRange, page, size int, includeArchived bool) ([]*entity.Node, int, error)
 
-	// Update updates an existing node
+	// GlobalSearch ranks the active nodes of a domain, or of every domain when domainName
+	// is empty, where each word of query appears in the URL, title, description or an
+	// attribute value. Title matches rank above URL matches, which rank above the rest.
+	GlobalSearch(ctx context.Context, domainName, query string, page, size int) ([]SearchHit, int, error)
+
+	// Update updates an existing node. A node loaded from storage carries its version,
+	// and is refused with ErrConcurrencyConflict once the stored node has changed.
 	Update(ctx context.Context, node *entity.Node) error
 
 	// Delete deletes a node by its ID
 	Delete(ctx context.Context, id int) error
 
+	// SetArchivedAt archives a node at archivedAt, or restores it when archivedAt is nil
+	SetArchivedAt(ctx context.Context, id int, archivedAt *time.Time) error
+
 	// Exists checks if a node exists by URL and domain
 	Exists(ctx context.Context, url, domainName string) (bool, error)
 
@@ -37,16 +65,115 @@ type NodeRepository interface {
 	// FilterByAttributes retrieves nodes by domain with attribute filters
 	FilterByAttributes(ctx context.Context, domainName string, filters []AttributeFilter, page, size int) ([]*entity.Node, int, error)
 
+	// ExplainFilterByAttributes returns the SQLite query plans of the queries
+	// FilterByAttributes would run for the same arguments, without running them
+	ExplainFilterByAttributes(ctx context.Context, domainName string, filters []AttributeFilter, page, size int) ([]QueryPlan, error)
+
+	// CountAttributeValues counts nodes per distinct value of each named attribute among
+	// the domain's nodes matching filters, most common values first, keyed by attribute name
+	CountAttributeValues(ctx context.Context, domainName string, attributeNames []string, filters []AttributeFilter) (map[string][]AttributeValueCount, error)
+
 	// CountByDomain counts nodes in a domain
 	CountByDomain(ctx context.Context, domainID int) (int, error)
 
 	// GetByDomainFromCursor retrieves nodes starting from a cursor position
 	GetByDomainFromCursor(ctx context.Context, domainID int, lastNodeID int, limit int) ([]*entity.Node, error)
+
+	// GetByDomainOrdered retrieves a page of a domain's nodes ordered by created_at, ties
+	// broken by id in the same direction, so the order is total and repeatable
+	GetByDomainOrdered(ctx context.Context, domainID int, order SortOrder, offset, limit int) ([]*entity.Node, error)
+
+	// DeleteExpired deletes nodes whose expiry is at or before now and returns how many.
+	// Expired nodes are already hidden from every read above; this reclaims their rows.
+	DeleteExpired(ctx context.Context, now time.Time) (int, error)
+}
+
+// SortOrder is the direction of an ordering
+type SortOrder string
+
+const (
+	SortAscending  SortOrder = "asc"
+	SortDescending SortOrder = "desc"
+)
+
+// ParseSortOrder parses "asc" or "desc", case-insensitively
+func ParseSortOrder(value string) (SortOrder, error) {
+	switch order := SortOrder(strings.ToLower(value)); order {
+	case SortAscending, SortDescending:
+		return order, nil
+	default:
+		return "", fmt.Errorf("%w: sort order must be 'asc' or 'desc', got '%s'", ErrInvalidInput, value)
+	}
 }
 
-// AttributeFilter represents a filter condition for node attributes
+// AttributeFilter represents a filter condition for node attributes. A filter with
+// nested Filters is a group instead: it matches when its filters, combined by Logic,
+// match. A list of filters is ANDed.
 type AttributeFilter struct {
 	Name     string // Attribute name
-	Value    string // Attribute value
-	Operator string // Comparison operator: "equals", "contains", "starts_with", "ends_with"
+	Value    string // Attribute value; the lower bound for "between"
+	ValueTo  string // Upper bound for "between"
+	Operator string // Comparison operator: "equals", "contains", "starts_with", "ends_with", "gt", "gte", "lt", "lte", "between"
+
+	Logic   string            // How a group combines its filters: "and" (default) or "or"
+	Filters []AttributeFilter // Filters of a group
+}
+
+// Filter group logic
+const (
+	FilterLogicAnd = "and"
+	FilterLogicOr  = "or"
+)
+
+// IsGroup reports whether the filter is a group of nested filters
+func (f AttributeFilter) IsGroup() bool {
+	return len(f.Filters) > 0
+}
+
+// IsNumericFilterOperator reports whether operator compares values as numbers,
+// which only makes sense for number attributes
+func IsNumericFilterOperator(operator string) bool {
+	switch strings.ToLower(operator) {
+	case "gt", "gte", "lt", "lte", "between":
+		return true
+	default:
+		return false
+	}
+}
+
+// QueryPlan is the EXPLAIN QUERY PLAN output for one query
+type QueryPlan struct {
+	Name  string // What the query is for, such as "page" or "count"
+	Query string
+	Rows  []QueryPlanRow
+}
+
+// QueryPlanRow is one step of a query plan; Parent is the ID of the step it belongs to
+type QueryPlanRow struct {
+	ID     int
+	Parent int
+	Detail string
+}
+
+// NodeTimeRange limits nodes to those created or updated within a window. Every bound
+// is optional; After bounds are inclusive and Before bounds exclusive.
+type NodeTimeRange struct {
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	UpdatedAfter  *time.Time
+	UpdatedBefore *time.Time
+}
+
+// SearchHit is one node found by GlobalSearch, with the name of its domain and its
+// rank; a higher Score is a better match
+type SearchHit struct {
+	Node       *entity.Node
+	DomainName string
+	Score      int
+}
+
+// AttributeValueCount is the number of nodes holding one attribute value
+type AttributeValueCount struct {
+	Value string
+	Count int
 }
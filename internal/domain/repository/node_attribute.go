@@ -13,6 +13,13 @@ type NodeAttributeRepository interface {
 	// GetByNodeID retrieves all attributes for a specific node
 	GetByNodeID(ctx context.Context, nodeID int) ([]*entity.NodeAttribute, error)
 
+	// GetByNodeIDs retrieves attributes for several nodes in one query, keyed by node ID
+	GetByNodeIDs(ctx context.Context, nodeIDs []int) (map[int][]*entity.NodeAttribute, error)
+
+	// CountByNodeIDs counts attributes for several nodes in one query, keyed by node ID;
+	// nodes without attributes are absent from the map
+	CountByNodeIDs(ctx context.Context, nodeIDs []int) (map[int]int, error)
+
 	// GetByNodeAndAttribute retrieves a specific attribute for a node
 	GetByNodeAndAttribute(ctx context.Context, nodeID int, attributeID int) (*entity.NodeAttribute, error)
 
@@ -22,12 +29,77 @@ type NodeAttributeRepository interface {
 	// Delete deletes a node attribute
 	Delete(ctx context.Context, nodeID int, attributeID int) error
 
-	// DeleteAllByNode deletes all attributes for a node
-	DeleteAllByNode(ctx context.Context, nodeID int) error
+	// DeleteAllByNode deletes all attributes for a node and returns how many were removed
+	DeleteAllByNode(ctx context.Context, nodeID int) (int, error)
+
+	// DeleteDuplicates removes repeated (attribute, value) rows from a node, keeping the
+	// one with the lowest order_index, and returns how many were removed
+	DeleteDuplicates(ctx context.Context, nodeID int) (int, error)
+
+	// AddValue inserts one value of an attribute on a node, leaving its other rows
+	// alone, unless the node already holds the value; with appendOrder the value gets
+	// the order_index after the node's highest for the attribute. It reports whether
+	// a row was inserted.
+	AddValue(ctx context.Context, nodeAttribute *entity.NodeAttribute, appendOrder bool) (bool, error)
+
+	// RemoveValue deletes one value of an attribute from a node, leaving its other
+	// rows alone, and reports whether the node held it
+	RemoveValue(ctx context.Context, nodeID, attributeID int, value string) (bool, error)
 
 	// SetNodeAttributes sets multiple attributes for a node (replaces existing ones)
 	SetNodeAttributes(ctx context.Context, nodeID int, attributes []*entity.NodeAttribute) error
 
+	// SearchNodeIDsByValue retrieves IDs of nodes in a domain having any attribute value
+	// containing term (case-insensitive), paginated, with the total number of matching nodes
+	SearchNodeIDsByValue(ctx context.Context, domainID int, term string, page, size int) ([]int, int, error)
+
+	// FindByNameAndValue retrieves nodes in any domain holding an exact attribute value,
+	// ordered by domain name and node ID
+	FindByNameAndValue(ctx context.Context, attributeName, value string, page, size int) ([]AttributeValueMatch, error)
+
+	// CountByNameAndValuePerDomain counts nodes holding an exact attribute value, keyed by domain name
+	CountByNameAndValuePerDomain(ctx context.Context, attributeName, value string) (map[string]int, error)
+
+	// CountValues retrieves up to limit distinct values of an attribute with the number of
+	// nodes holding each, most used first; prefix, if set, keeps values starting with it
+	// (case-insensitive)
+	CountValues(ctx context.Context, attributeID int, prefix string, limit int) ([]AttributeValueCount, error)
+
+	// GetUsageStats retrieves every attribute of a domain with the number of nodes holding
+	// it and its number of distinct values, most used first; unused attributes count zero
+	GetUsageStats(ctx context.Context, domainID int) ([]AttributeUsage, error)
+
+	// RenameValue changes oldValue of an attribute to newValue on every node in one
+	// transaction. A node already holding newValue keeps that row and loses the old one.
+	// With dryRun the counts are reported and nothing is written.
+	RenameValue(ctx context.Context, attributeID int, oldValue, newValue string, dryRun bool) (*AttributeValueRename, error)
+
+	// CountByAttributeID counts the values of an attribute across all nodes
+	CountByAttributeID(ctx context.Context, attributeID int) (int, error)
+
 	// GetNodesWithAttribute retrieves nodes that have a specific attribute with optional value filter
 	GetNodesWithAttribute(ctx context.Context, attributeID int, value *string) ([]int, error)
 }
+
+// AttributeValueRename reports the rows a value rename changed
+type AttributeValueRename struct {
+	Renamed int // Rows whose value was changed
+	Merged  int // Rows dropped because their node already held the new value
+}
+
+// AttributeUsage summarises how one attribute is used across a domain's nodes
+type AttributeUsage struct {
+	AttributeID    int
+	Name           string
+	Type           string
+	NodeCount      int // Nodes holding at least one value
+	DistinctValues int // Distinct values across those nodes
+}
+
+// AttributeValueMatch identifies a node holding a given attribute value
+type AttributeValueMatch struct {
+	NodeID     int
+	DomainName string
+	URL        string
+	Title      string
+}
@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+	"url-db/internal/domain/entity"
+)
+
+// NodeConnectionRepository defines the contract for reading node connections
+type NodeConnectionRepository interface {
+	// ListParentIDs returns the IDs of a node's parents, ordered by ID. A parent is either
+	// the target of a 'parent' connection from the node or the source of a 'child'
+	// connection to it.
+	ListParentIDs(ctx context.Context, nodeID int) ([]int, error)
+
+	// ListByNodeID retrieves all connections where the node is the source or the target
+	ListByNodeID(ctx context.Context, nodeID int) ([]*entity.NodeConnection, error)
+}
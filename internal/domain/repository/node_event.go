@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"url-db/internal/domain/entity"
+)
+
+// NodeEventRepository defines the contract for the node event log
+type NodeEventRepository interface {
+	// CreateBatch stores events in one transaction and returns how many were written.
+	// Events are kept even when their node no longer exists.
+	CreateBatch(ctx context.Context, events []*entity.NodeEvent) (int, error)
+
+	// ListByNode retrieves a node's events newest first, paginated, with the total count
+	ListByNode(ctx context.Context, nodeID int, page, size int) ([]*entity.NodeEvent, int, error)
+
+	// ListPending retrieves up to limit unprocessed events, oldest first
+	ListPending(ctx context.Context, limit int) ([]*entity.NodeEvent, error)
+
+	// MarkProcessed records when an event was processed and returns the event.
+	// An event that is already processed keeps its original time. Returns ErrNotFound
+	// for an unknown event.
+	MarkProcessed(ctx context.Context, id int, processedAt time.Time) (*entity.NodeEvent, error)
+
+	// Stats counts events by processing status and by type
+	Stats(ctx context.Context) (*NodeEventStats, error)
+}
+
+// NodeEventStats summarizes the node event log
+type NodeEventStats struct {
+	Total     int
+	Pending   int
+	Processed int
+	ByType    map[string]int
+}
@@ -0,0 +1,29 @@
+package repository
+
+import "context"
+
+// NodeMove is a request to move nodes to another domain
+type NodeMove struct {
+	NodeIDs          []int
+	TargetDomainID   int
+	MaxNodes         int  // Node cap of the target domain (0 = unlimited); nodes past it stay where they are
+	DropIncompatible bool // Drop values of attributes the target does not define, instead of keeping the node
+}
+
+// NodeMoveResult reports the move of one node to another domain
+type NodeMoveResult struct {
+	NodeID            int
+	SourceDomainID    int
+	Err               error    // Why the node was not moved; nil when it was
+	DroppedAttributes []string // Attributes whose values were dropped, with no matching definition in the target
+}
+
+// NodeMoveRepository moves nodes between domains
+type NodeMoveRepository interface {
+	// Move moves the nodes to the target domain in one transaction, returning one result
+	// per node in order. Attribute values follow the target attribute of the same name
+	// and type; values of other attributes are dropped, or keep the node where it is
+	// without DropIncompatible. A node that cannot move, such as one whose URL is taken
+	// in the target, is reported in its result without aborting the others.
+	Move(ctx context.Context, move *NodeMove) ([]NodeMoveResult, error)
+}
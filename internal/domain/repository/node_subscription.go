@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+	"time"
+	"url-db/internal/domain/entity"
+)
+
+// NodeSubscriptionRepository defines the contract for node subscriptions and the
+// delivery of node events to them
+type NodeSubscriptionRepository interface {
+	// Create stores a new subscription
+	Create(ctx context.Context, subscription *entity.NodeSubscription) error
+
+	// GetByID retrieves a subscription, or nil when it does not exist
+	GetByID(ctx context.Context, id int) (*entity.NodeSubscription, error)
+
+	// ListByNode retrieves a node's subscriptions in id order
+	ListByNode(ctx context.Context, nodeID int) ([]*entity.NodeSubscription, error)
+
+	// Delete deletes a subscription and its delivery records
+	Delete(ctx context.Context, id int) error
+
+	// ListPendingDeliveries retrieves up to limit events not yet delivered to, or given
+	// up on by, an active subscription with an endpoint: events of the subscribed node
+	// and types that occurred after the subscription was created, oldest first
+	ListPendingDeliveries(ctx context.Context, limit int) ([]*SubscriptionDelivery, error)
+
+	// RecordDelivery stores the outcome of delivering an event to a subscription;
+	// a nil deliveredAt records that delivery was given up after attempts tries
+	RecordDelivery(ctx context.Context, subscriptionID, eventID, attempts int, deliveredAt *time.Time, lastError string) error
+}
+
+// SubscriptionDelivery pairs a subscription with an event it should receive
+type SubscriptionDelivery struct {
+	Subscription *entity.NodeSubscription
+	Event        *entity.NodeEvent
+}
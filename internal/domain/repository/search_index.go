@@ -0,0 +1,16 @@
+package repository
+
+import "context"
+
+// SearchIndexRepository maintains the full-text index of node URLs, titles and
+// descriptions that node search uses when the database supports it
+type SearchIndexRepository interface {
+	// Available reports whether the database has the index (SQLite with FTS4)
+	Available(ctx context.Context) (bool, error)
+
+	// Rebuild replaces the index entries of a domain's nodes (domainID 0 = all domains)
+	// with their current URL, title and description and drops entries of nodes that no
+	// longer exist. It returns how many nodes were indexed; on error or cancellation
+	// the index is left as it was.
+	Rebuild(ctx context.Context, domainID int) (int, error)
+}
@@ -0,0 +1,26 @@
+package repository
+
+import "context"
+
+// StorageStats describes the size of the database
+type StorageStats struct {
+	RowCounts map[string]int // Rows per table, keyed by table name
+	PageSize  int            // Bytes per database page
+	PageCount int            // Pages in the database file, including free pages
+	FreePages int            // Unused pages that VACUUM would release
+}
+
+// SizeBytes returns the size of the database file, excluding any WAL file
+func (s *StorageStats) SizeBytes() int64 {
+	return int64(s.PageSize) * int64(s.PageCount)
+}
+
+// StorageRepository reports database size for capacity planning
+type StorageRepository interface {
+	// GetStats counts the rows of the main tables and reads the page counts of the database
+	GetStats(ctx context.Context) (*StorageStats, error)
+	// Ping runs a trivial query to check that the database answers
+	Ping(ctx context.Context) error
+	// DatabasePath returns the file of the main database ("" for an in-memory database)
+	DatabasePath(ctx context.Context) (string, error)
+}
@@ -97,6 +97,7 @@ type TemplateAttributeRepository interface {
 	// Search and filter operations
 	FindTemplateAttributesByValue(ctx context.Context, value string) ([]*entity.TemplateAttribute, error)
 	GetTemplateAttributeUsageStats(ctx context.Context, domainName string) (map[string]int, error)
+	CountByAttributeID(ctx context.Context, attributeID int) (int, error)
 }
 
 // TemplateAttributeValue represents an attribute value to be set on a template
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+
+	"url-db/internal/domain/entity"
+)
+
+// hashedAttribute is the part of a node attribute covered by the content hash
+type hashedAttribute struct {
+	Name       string `json:"name"`
+	Value      string `json:"value"`
+	OrderIndex *int   `json:"order_index"`
+}
+
+// hashedNode is the part of a node covered by the content hash
+type hashedNode struct {
+	URL         string            `json:"url"`
+	Title       string            `json:"title"`
+	Description string            `json:"description"`
+	Attributes  []hashedAttribute `json:"attributes"`
+}
+
+// NodeContentHash returns a hex SHA-256 of a node's URL, title, description and
+// attributes. Attributes are sorted first, so the hash only changes when the content
+// does, which lets sync clients skip unchanged nodes.
+func NodeContentHash(node *entity.Node, attributes []*entity.NodeAttribute) string {
+	hashed := hashedNode{
+		URL:         node.URL(),
+		Title:       node.Title(),
+		Description: node.Description(),
+		Attributes:  make([]hashedAttribute, 0, len(attributes)),
+	}
+	for _, attr := range attributes {
+		hashed.Attributes = append(hashed.Attributes, hashedAttribute{
+			Name:       attr.Name(),
+			Value:      attr.Value(),
+			OrderIndex: attr.OrderIndex(),
+		})
+	}
+	sort.Slice(hashed.Attributes, func(i, j int) bool {
+		a, b := hashed.Attributes[i], hashed.Attributes[j]
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		if orderIndexOf(a.OrderIndex) != orderIndexOf(b.OrderIndex) {
+			return orderIndexOf(a.OrderIndex) < orderIndexOf(b.OrderIndex)
+		}
+		return a.Value < b.Value
+	})
+
+	// Marshalling plain structs cannot fail
+	data, _ := json.Marshal(hashed)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// orderIndexOf treats a missing order index as 0
+func orderIndexOf(orderIndex *int) int {
+	if orderIndex == nil {
+		return 0
+	}
+	return *orderIndex
+}
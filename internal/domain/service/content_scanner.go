@@ -12,9 +12,10 @@ import (
 
 // ContentScanner provides token-based pagination for scanning domain content
 type ContentScanner struct {
-	nodeRepo      repository.NodeRepository
-	attributeRepo repository.NodeAttributeRepository
-	domainRepo    repository.DomainRepository
+	nodeRepo       repository.NodeRepository
+	attributeRepo  repository.NodeAttributeRepository
+	domainRepo     repository.DomainRepository
+	definitionRepo repository.AttributeRepository
 }
 
 // NewContentScanner creates a new ContentScanner instance
@@ -22,11 +23,13 @@ func NewContentScanner(
 	nodeRepo repository.NodeRepository,
 	attributeRepo repository.NodeAttributeRepository,
 	domainRepo repository.DomainRepository,
+	definitionRepo repository.AttributeRepository,
 ) *ContentScanner {
 	return &ContentScanner{
-		nodeRepo:      nodeRepo,
-		attributeRepo: attributeRepo,
-		domainRepo:    domainRepo,
+		nodeRepo:       nodeRepo,
+		attributeRepo:  attributeRepo,
+		domainRepo:     domainRepo,
+		definitionRepo: definitionRepo,
 	}
 }
 
@@ -37,6 +40,11 @@ type ScanRequest struct {
 	Page               int    `json:"page"`               // Page number (1-based)
 	IncludeAttributes  bool   `json:"include_attributes"`
 	CompressAttributes bool   `json:"compress_attributes"` // Remove duplicate attribute values
+	IncludeContentHash bool   `json:"include_content_hash"` // Add each item's content hash for incremental sync
+	// Order of nodes by created_at, ties broken by id; empty means ascending, which keeps
+	// earlier pages unchanged as nodes are added. Deleting nodes between page requests
+	// still shifts later page boundaries.
+	Order repository.SortOrder `json:"order"`
 }
 
 // ScanResponse represents the response from content scanning
@@ -51,6 +59,7 @@ type PaginationInfo struct {
 	CurrentPage   int  `json:"current_page"`
 	TotalPages    int  `json:"total_pages"`
 	CurrentTokens int  `json:"current_tokens"`
+	NodesPerPage  int  `json:"nodes_per_page"` // Estimated from the token budget
 	HasMore       bool `json:"has_more"`
 	HasPrevious   bool `json:"has_previous"`
 }
@@ -63,6 +72,14 @@ type ScanMetadata struct {
 	EstimatedPages     int                    `json:"estimated_pages"`
 	AttributeSummary   *AttributeSummary      `json:"attribute_summary,omitempty"`
 	CompressedOutput   bool                   `json:"compressed_output"`
+	Attributes         []AttributeDefinition  `json:"attributes"` // The domain's attribute definitions, so values can be read by meaning
+}
+
+// AttributeDefinition describes one attribute of the scanned domain
+type AttributeDefinition struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Description string `json:"description"`
 }
 
 // AttributeSummary contains compressed attribute information
@@ -83,24 +100,24 @@ type PageInfo struct {
 	EndIndex         int `json:"end_index"`
 }
 
+// ScanItemFunc receives each scanned item as soon as it is built; an error aborts the scan
+type ScanItemFunc func(item response.NodeWithAttributes) error
+
 // ScanAllContent performs page-based scanning of domain content with token optimization
 func (cs *ContentScanner) ScanAllContent(ctx context.Context, req ScanRequest) (*ScanResponse, error) {
+	return cs.ScanAllContentStream(ctx, req, nil)
+}
+
+// ScanAllContentStream is ScanAllContent that also passes every item of the page to
+// onItem while the page is built, so callers can forward items before the scan ends
+func (cs *ContentScanner) ScanAllContentStream(ctx context.Context, req ScanRequest, onItem ScanItemFunc) (*ScanResponse, error) {
 	// Validate domain exists
-	domain, err := cs.domainRepo.GetByName(ctx, req.DomainName)
+	domain, err := cs.getDomain(ctx, req.DomainName)
 	if err != nil {
-		return nil, fmt.Errorf("domain not found: %w", err)
+		return nil, err
 	}
 
-	// Set default values
-	if req.MaxTokensPerPage <= 0 {
-		req.MaxTokensPerPage = constants.DefaultMaxTokensPerPage
-	}
-	if req.MaxTokensPerPage > constants.MaxTokensPerPage {
-		req.MaxTokensPerPage = constants.MaxTokensPerPage
-	}
-	if req.Page <= 0 {
-		req.Page = 1
-	}
+	req = withScanDefaults(req)
 
 	// Get total node count
 	totalNodes, err := cs.nodeRepo.CountByDomain(ctx, domain.ID())
@@ -108,6 +125,11 @@ func (cs *ContentScanner) ScanAllContent(ctx context.Context, req ScanRequest) (
 		return nil, fmt.Errorf("failed to count nodes: %w", err)
 	}
 
+	definitions, err := cs.attributeDefinitions(ctx, domain.ID())
+	if err != nil {
+		return nil, err
+	}
+
 	if totalNodes == 0 {
 		return &ScanResponse{
 			Items: []response.NodeWithAttributes{},
@@ -124,30 +146,23 @@ func (cs *ContentScanner) ScanAllContent(ctx context.Context, req ScanRequest) (
 				EstimatedTokens:  0,
 				EstimatedPages:   1,
 				CompressedOutput: req.CompressAttributes,
+				Attributes:       definitions,
 			},
 		}, nil
 	}
 
 	// Calculate page information based on estimated nodes per page
-	avgTokensPerNode := constants.AvgTokensPerNode
-	if req.IncludeAttributes {
-		avgTokensPerNode = int(float64(avgTokensPerNode) * 1.5)
-	}
-	estimatedNodesPerPage := req.MaxTokensPerPage / avgTokensPerNode
-	if estimatedNodesPerPage < 1 {
-		estimatedNodesPerPage = 1
-	}
-
-	pageInfo := cs.calculatePageInfo(req.Page, estimatedNodesPerPage, totalNodes)
+	avgTokensPerNode := averageNodeTokens(req)
+	pageInfo := cs.calculatePageInfo(req.Page, nodesPerPage(req), totalNodes)
 
 	// Fetch nodes for the current page
-	nodes, err := cs.fetchNodesForPage(ctx, domain.ID(), pageInfo)
+	nodes, err := cs.fetchNodesForPage(ctx, domain.ID(), req.Order, pageInfo)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch nodes: %w", err)
 	}
 
 	// Build response with token optimization
-	result, actualTokens, attributesSummary, err := cs.buildOptimizedResponse(ctx, nodes, req)
+	result, actualTokens, attributesSummary, err := cs.buildOptimizedResponse(ctx, nodes, req, onItem)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build response: %w", err)
 	}
@@ -162,6 +177,7 @@ func (cs *ContentScanner) ScanAllContent(ctx context.Context, req ScanRequest) (
 			CurrentPage:   req.Page,
 			TotalPages:    pageInfo.TotalPages,
 			CurrentTokens: actualTokens,
+			NodesPerPage:  pageInfo.NodesPerPage,
 			HasMore:       req.Page < pageInfo.TotalPages,
 			HasPrevious:   req.Page > 1,
 		},
@@ -172,12 +188,146 @@ func (cs *ContentScanner) ScanAllContent(ctx context.Context, req ScanRequest) (
 			EstimatedPages:     estimatedPages,
 			AttributeSummary:   attributesSummary,
 			CompressedOutput:   req.CompressAttributes,
+			Attributes:         definitions,
 		},
 	}
 
 	return response, nil
 }
 
+// withScanDefaults fills in the defaults of a scan request and caps its token budget
+func withScanDefaults(req ScanRequest) ScanRequest {
+	if req.MaxTokensPerPage <= 0 {
+		req.MaxTokensPerPage = constants.DefaultMaxTokensPerPage
+	}
+	if req.MaxTokensPerPage > constants.MaxTokensPerPage {
+		req.MaxTokensPerPage = constants.MaxTokensPerPage
+	}
+	if req.Page <= 0 {
+		req.Page = 1
+	}
+	if req.Order == "" {
+		req.Order = repository.SortAscending
+	}
+	return req
+}
+
+// averageNodeTokens is the token count a scan assumes per node when sizing pages
+func averageNodeTokens(req ScanRequest) int {
+	avgTokensPerNode := constants.AvgTokensPerNode
+	if req.IncludeAttributes {
+		avgTokensPerNode = int(float64(avgTokensPerNode) * 1.5)
+	}
+	return avgTokensPerNode
+}
+
+// nodesPerPage is the number of nodes a scan puts on each page for the request's budget
+func nodesPerPage(req ScanRequest) int {
+	perPage := req.MaxTokensPerPage / averageNodeTokens(req)
+	if perPage < 1 {
+		perPage = 1
+	}
+	return perPage
+}
+
+// ScanEstimate is the size a full scan of a domain would have
+type ScanEstimate struct {
+	TotalNodes       int `json:"total_nodes"`
+	EstimatedTokens  int `json:"estimated_tokens"`    // Node count times the per-node estimate pages are sized with
+	TotalPages       int `json:"total_pages"`         // Pages ScanAllContent splits the domain into
+	NodesPerPage     int `json:"nodes_per_page"`
+	MaxTokensPerPage int `json:"max_tokens_per_page"` // Budget after defaults and caps
+}
+
+// EstimateScanSize reports how many nodes, tokens and pages scanning the domain with
+// req would produce, from the node count alone: no node or attribute is read. Tokens
+// use the per-node average scan pages are sized with, the same figure as a scan's
+// estimated_tokens metadata, so they can differ from the sum of current_tokens over
+// all pages; req.Page is ignored.
+func (cs *ContentScanner) EstimateScanSize(ctx context.Context, req ScanRequest) (*ScanEstimate, error) {
+	domain, err := cs.getDomain(ctx, req.DomainName)
+	if err != nil {
+		return nil, err
+	}
+	req = withScanDefaults(req)
+
+	totalNodes, err := cs.nodeRepo.CountByDomain(ctx, domain.ID())
+	if err != nil {
+		return nil, fmt.Errorf("failed to count nodes: %w", err)
+	}
+
+	return &ScanEstimate{
+		TotalNodes:       totalNodes,
+		EstimatedTokens:  totalNodes * averageNodeTokens(req),
+		TotalPages:       cs.calculatePageInfo(1, nodesPerPage(req), totalNodes).TotalPages,
+		NodesPerPage:     nodesPerPage(req),
+		MaxTokensPerPage: req.MaxTokensPerPage,
+	}, nil
+}
+
+// getDomain looks up the domain to scan; GetByName returns no domain and no error
+// for an unknown name
+func (cs *ContentScanner) getDomain(ctx context.Context, domainName string) (*entity.Domain, error) {
+	domain, err := cs.domainRepo.GetByName(ctx, domainName)
+	if err != nil {
+		return nil, fmt.Errorf("domain not found: %w", err)
+	}
+	if domain == nil {
+		return nil, fmt.Errorf("domain not found: %s", domainName)
+	}
+	return domain, nil
+}
+
+// attributeDefinitions lists the attribute definitions of a domain for scan metadata
+func (cs *ContentScanner) attributeDefinitions(ctx context.Context, domainID int) ([]AttributeDefinition, error) {
+	attributes, err := cs.definitionRepo.ListByDomainID(ctx, domainID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list attributes: %w", err)
+	}
+
+	definitions := make([]AttributeDefinition, 0, len(attributes))
+	for _, attr := range attributes {
+		definitions = append(definitions, AttributeDefinition{
+			Name:        attr.Name(),
+			Type:        attr.Type(),
+			Description: attr.Description(),
+		})
+	}
+	return definitions, nil
+}
+
+// ExportDomain passes every node of the domain with its full attributes to onItem in
+// id order, reading nodes in batches, and returns the number of nodes exported
+func (cs *ContentScanner) ExportDomain(ctx context.Context, domainName string, onItem ScanItemFunc) (int, error) {
+	domain, err := cs.getDomain(ctx, domainName)
+	if err != nil {
+		return 0, err
+	}
+
+	req := ScanRequest{DomainName: domainName, IncludeAttributes: true}
+	exported := 0
+	lastNodeID := 0
+	for {
+		nodes, err := cs.nodeRepo.GetByDomainFromCursor(ctx, domain.ID(), lastNodeID, constants.ScanBatchSize)
+		if err != nil {
+			return exported, fmt.Errorf("failed to fetch nodes: %w", err)
+		}
+		if len(nodes) == 0 {
+			return exported, nil
+		}
+
+		if _, _, _, err := cs.buildOptimizedResponse(ctx, nodes, req, onItem); err != nil {
+			return exported, fmt.Errorf("failed to build response: %w", err)
+		}
+		exported += len(nodes)
+		lastNodeID = nodes[len(nodes)-1].ID()
+
+		if len(nodes) < constants.ScanBatchSize {
+			return exported, nil
+		}
+	}
+}
+
 // calculatePageInfo calculates page boundaries and metadata
 func (cs *ContentScanner) calculatePageInfo(currentPage, nodesPerPage, totalNodes int) PageInfo {
 	totalPages := (totalNodes + nodesPerPage - 1) / nodesPerPage // Ceiling division
@@ -202,29 +352,16 @@ func (cs *ContentScanner) calculatePageInfo(currentPage, nodesPerPage, totalNode
 }
 
 // fetchNodesForPage fetches nodes for a specific page
-func (cs *ContentScanner) fetchNodesForPage(ctx context.Context, domainID int, pageInfo PageInfo) ([]*entity.Node, error) {
-	// For now, we'll use the existing cursor-based method and slice the results
-	// This is not optimal for large datasets but works for the current implementation
-	allNodes, err := cs.nodeRepo.GetByDomainFromCursor(ctx, domainID, 0, pageInfo.TotalNodes)
-	if err != nil {
-		return nil, err
-	}
-
-	// Slice the nodes for the current page
-	if pageInfo.StartIndex >= len(allNodes) {
+func (cs *ContentScanner) fetchNodesForPage(ctx context.Context, domainID int, order repository.SortOrder, pageInfo PageInfo) ([]*entity.Node, error) {
+	if pageInfo.StartIndex >= pageInfo.EndIndex {
 		return []*entity.Node{}, nil
 	}
 
-	endIdx := pageInfo.EndIndex
-	if endIdx > len(allNodes) {
-		endIdx = len(allNodes)
-	}
-
-	return allNodes[pageInfo.StartIndex:endIdx], nil
+	return cs.nodeRepo.GetByDomainOrdered(ctx, domainID, order, pageInfo.StartIndex, pageInfo.EndIndex-pageInfo.StartIndex)
 }
 
 // buildOptimizedResponse builds the response with token optimization and attribute compression
-func (cs *ContentScanner) buildOptimizedResponse(ctx context.Context, nodes []*entity.Node, req ScanRequest) ([]response.NodeWithAttributes, int, *AttributeSummary, error) {
+func (cs *ContentScanner) buildOptimizedResponse(ctx context.Context, nodes []*entity.Node, req ScanRequest, onItem ScanItemFunc) ([]response.NodeWithAttributes, int, *AttributeSummary, error) {
 	result := make([]response.NodeWithAttributes, 0, len(nodes))
 	totalTokens := 0
 	var attributeSummary *AttributeSummary
@@ -238,9 +375,9 @@ func (cs *ContentScanner) buildOptimizedResponse(ctx context.Context, nodes []*e
 		}
 	}
 
-	// First pass: collect all attributes for compression analysis
+	// First pass: collect all attributes for compression analysis and content hashes
 	allAttributes := make(map[int][]*entity.NodeAttribute)
-	if req.IncludeAttributes {
+	if req.IncludeAttributes || req.IncludeContentHash {
 		for _, node := range nodes {
 			attributes, err := cs.attributeRepo.GetByNodeID(ctx, node.ID())
 			if err != nil {
@@ -250,7 +387,7 @@ func (cs *ContentScanner) buildOptimizedResponse(ctx context.Context, nodes []*e
 		}
 
 		// Analyze attributes for compression if requested
-		if req.CompressAttributes {
+		if req.IncludeAttributes && req.CompressAttributes {
 			cs.analyzeAttributesForCompression(allAttributes, attributeSummary)
 		}
 	}
@@ -290,11 +427,22 @@ func (cs *ContentScanner) buildOptimizedResponse(ctx context.Context, nodes []*e
 			}
 		}
 
+		// Hash the full node, independent of compression and include_attributes
+		if req.IncludeContentHash {
+			nodeResp.ContentHash = NodeContentHash(node, allAttributes[node.ID()])
+		}
+
 		// Estimate tokens for this node
 		nodeTokens := cs.estimateNodeTokens(nodeResp, req.IncludeAttributes)
 		totalTokens += nodeTokens
 
 		result = append(result, nodeResp)
+
+		if onItem != nil {
+			if err := onItem(nodeResp); err != nil {
+				return nil, 0, nil, fmt.Errorf("failed to emit node %d: %w", node.ID(), err)
+			}
+		}
 	}
 
 	return result, totalTokens, attributeSummary, nil
@@ -2,6 +2,8 @@ package service_test
 
 import (
 	"context"
+	"fmt"
+	"sort"
 	"strings"
 	"testing"
 	"time"
@@ -41,33 +43,112 @@ func (m *mockNodeRepository) GetByDomainFromCursor(ctx context.Context, domainID
 	return result, nil
 }
 
+func (m *mockNodeRepository) GetByDomainOrdered(ctx context.Context, domainID int, order repository.SortOrder, offset, limit int) ([]*entity.Node, error) {
+	var result []*entity.Node
+	for _, node := range m.nodes {
+		if node.DomainID() == domainID {
+			result = append(result, node)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		less := result[i].CreatedAt().Before(result[j].CreatedAt()) ||
+			(result[i].CreatedAt().Equal(result[j].CreatedAt()) && result[i].ID() < result[j].ID())
+		if order == repository.SortDescending {
+			return !less
+		}
+		return less
+	})
+	if offset >= len(result) {
+		return nil, nil
+	}
+	result = result[offset:]
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
 // Implement other required methods (stub implementations)
 func (m *mockNodeRepository) Create(ctx context.Context, node *entity.Node) error { return nil }
+func (m *mockNodeRepository) CreateBatch(ctx context.Context, nodes []*entity.Node, atomic bool) ([]error, error) { return make([]error, len(nodes)), nil }
 func (m *mockNodeRepository) GetByID(ctx context.Context, id int) (*entity.Node, error) { return nil, nil }
 func (m *mockNodeRepository) GetByURL(ctx context.Context, url, domainName string) (*entity.Node, error) { return nil, nil }
-func (m *mockNodeRepository) List(ctx context.Context, domainName string, page, size int) ([]*entity.Node, int, error) { return nil, 0, nil }
+func (m *mockNodeRepository) GetByURLs(ctx context.Context, urls []string, domainName string) ([]*entity.Node, error) { return nil, nil }
+func (m *mockNodeRepository) List(ctx context.Context, domainName string, page, size int, includeArchived bool) ([]*entity.Node, int, error) { return nil, 0, nil }
 func (m *mockNodeRepository) Update(ctx context.Context, node *entity.Node) error { return nil }
+func (m *mockNodeRepository) Search(ctx context.Context, domainName, query string, page, size int, includeArchived bool) ([]*entity.Node, int, error) { return nil, 0, nil }
+func (m *mockNodeRepository) SearchInTimeRange(ctx context.Context, domainName, query string, timeRange repository.NodeTimeRange, page, size int, includeArchived bool) ([]*entity.Node, int, error) { return nil, 0, nil }
+func (m *mockNodeRepository) GlobalSearch(ctx context.Context, domainName, query string, page, size int) ([]repository.SearchHit, int, error) { return nil, 0, nil }
 func (m *mockNodeRepository) Delete(ctx context.Context, id int) error { return nil }
+func (m *mockNodeRepository) SetArchivedAt(ctx context.Context, id int, archivedAt *time.Time) error { return nil }
 func (m *mockNodeRepository) Exists(ctx context.Context, url, domainName string) (bool, error) { return false, nil }
 func (m *mockNodeRepository) GetBatch(ctx context.Context, ids []int) ([]*entity.Node, error) { return nil, nil }
 func (m *mockNodeRepository) GetDomainByNodeID(ctx context.Context, nodeID int) (*entity.Domain, error) { return nil, nil }
 func (m *mockNodeRepository) FilterByAttributes(ctx context.Context, domainName string, filters []repository.AttributeFilter, page, size int) ([]*entity.Node, int, error) { return nil, 0, nil }
+func (m *mockNodeRepository) ExplainFilterByAttributes(ctx context.Context, domainName string, filters []repository.AttributeFilter, page, size int) ([]repository.QueryPlan, error) { return nil, nil }
+func (m *mockNodeRepository) CountAttributeValues(ctx context.Context, domainName string, attributeNames []string, filters []repository.AttributeFilter) (map[string][]repository.AttributeValueCount, error) { return nil, nil }
+func (m *mockNodeRepository) DeleteExpired(ctx context.Context, now time.Time) (int, error) { return 0, nil }
 
 type mockNodeAttributeRepository struct {
 	attributes map[int][]*entity.NodeAttribute
+	calls      int // GetByNodeID calls
 }
 
 func (m *mockNodeAttributeRepository) GetByNodeID(ctx context.Context, nodeID int) ([]*entity.NodeAttribute, error) {
+	m.calls++
 	return m.attributes[nodeID], nil
 }
 
+func (m *mockNodeAttributeRepository) FindByNameAndValue(ctx context.Context, attributeName, value string, page, size int) ([]repository.AttributeValueMatch, error) {
+	return nil, nil
+}
+
+func (m *mockNodeAttributeRepository) CountByNameAndValuePerDomain(ctx context.Context, attributeName, value string) (map[string]int, error) {
+	return nil, nil
+}
+
+func (m *mockNodeAttributeRepository) SearchNodeIDsByValue(ctx context.Context, domainID int, term string, page, size int) ([]int, int, error) {
+	return nil, 0, nil
+}
+
+func (m *mockNodeAttributeRepository) CountValues(ctx context.Context, attributeID int, prefix string, limit int) ([]repository.AttributeValueCount, error) {
+	return nil, nil
+}
+
+func (m *mockNodeAttributeRepository) GetUsageStats(ctx context.Context, domainID int) ([]repository.AttributeUsage, error) {
+	return nil, nil
+}
+
+func (m *mockNodeAttributeRepository) CountByNodeIDs(ctx context.Context, nodeIDs []int) (map[int]int, error) {
+	result := make(map[int]int)
+	for _, nodeID := range nodeIDs {
+		if n := len(m.attributes[nodeID]); n > 0 {
+			result[nodeID] = n
+		}
+	}
+	return result, nil
+}
+
+func (m *mockNodeAttributeRepository) GetByNodeIDs(ctx context.Context, nodeIDs []int) (map[int][]*entity.NodeAttribute, error) {
+	result := make(map[int][]*entity.NodeAttribute)
+	for _, nodeID := range nodeIDs {
+		result[nodeID] = m.attributes[nodeID]
+	}
+	return result, nil
+}
+
 // Implement other required methods (stub implementations)
 func (m *mockNodeAttributeRepository) Create(ctx context.Context, nodeAttribute *entity.NodeAttribute) error { return nil }
 func (m *mockNodeAttributeRepository) GetByNodeAndAttribute(ctx context.Context, nodeID int, attributeID int) (*entity.NodeAttribute, error) { return nil, nil }
 func (m *mockNodeAttributeRepository) Update(ctx context.Context, nodeAttribute *entity.NodeAttribute) error { return nil }
 func (m *mockNodeAttributeRepository) Delete(ctx context.Context, nodeID int, attributeID int) error { return nil }
-func (m *mockNodeAttributeRepository) DeleteAllByNode(ctx context.Context, nodeID int) error { return nil }
+func (m *mockNodeAttributeRepository) DeleteAllByNode(ctx context.Context, nodeID int) (int, error) { return 0, nil }
+func (m *mockNodeAttributeRepository) DeleteDuplicates(ctx context.Context, nodeID int) (int, error) { return 0, nil }
+func (m *mockNodeAttributeRepository) AddValue(ctx context.Context, nodeAttribute *entity.NodeAttribute, appendOrder bool) (bool, error) { return false, nil }
+func (m *mockNodeAttributeRepository) RemoveValue(ctx context.Context, nodeID, attributeID int, value string) (bool, error) { return false, nil }
 func (m *mockNodeAttributeRepository) SetNodeAttributes(ctx context.Context, nodeID int, attributes []*entity.NodeAttribute) error { return nil }
+func (m *mockNodeAttributeRepository) RenameValue(ctx context.Context, attributeID int, oldValue, newValue string, dryRun bool) (*repository.AttributeValueRename, error) { return &repository.AttributeValueRename{}, nil }
+func (m *mockNodeAttributeRepository) CountByAttributeID(ctx context.Context, attributeID int) (int, error) { return 0, nil }
 func (m *mockNodeAttributeRepository) GetNodesWithAttribute(ctx context.Context, attributeID int, value *string) ([]int, error) { return nil, nil }
 
 type mockDomainRepository struct {
@@ -82,10 +163,33 @@ func (m *mockDomainRepository) GetByName(ctx context.Context, name string) (*ent
 func (m *mockDomainRepository) Create(ctx context.Context, domain *entity.Domain) error { return nil }
 func (m *mockDomainRepository) GetByID(ctx context.Context, id int) (*entity.Domain, error) { return nil, nil }
 func (m *mockDomainRepository) List(ctx context.Context, page, size int) ([]*entity.Domain, int, error) { return nil, 0, nil }
+func (m *mockDomainRepository) ListAfter(ctx context.Context, createdAt time.Time, id int, size int) ([]*entity.Domain, error) { return nil, nil }
 func (m *mockDomainRepository) Update(ctx context.Context, domain *entity.Domain) error { return nil }
 func (m *mockDomainRepository) Delete(ctx context.Context, name string) error { return nil }
 func (m *mockDomainRepository) Exists(ctx context.Context, name string) (bool, error) { return false, nil }
 
+type mockAttributeRepository struct {
+	attributes []*entity.Attribute
+}
+
+func (m *mockAttributeRepository) ListByDomainID(ctx context.Context, domainID int) ([]*entity.Attribute, error) {
+	var result []*entity.Attribute
+	for _, attr := range m.attributes {
+		if attr.DomainID() == domainID {
+			result = append(result, attr)
+		}
+	}
+	return result, nil
+}
+
+// Implement other required methods (stub implementations)
+func (m *mockAttributeRepository) Create(ctx context.Context, attribute *entity.Attribute) error { return nil }
+func (m *mockAttributeRepository) GetByID(ctx context.Context, id int) (*entity.Attribute, error) { return nil, nil }
+func (m *mockAttributeRepository) GetByName(ctx context.Context, domainID int, name string) (*entity.Attribute, error) { return nil, nil }
+func (m *mockAttributeRepository) ListAcrossDomains(ctx context.Context) ([]repository.DomainAttribute, error) { return nil, nil }
+func (m *mockAttributeRepository) Update(ctx context.Context, attribute *entity.Attribute) error { return nil }
+func (m *mockAttributeRepository) Delete(ctx context.Context, id int) error { return nil }
+
 func TestContentScanner_ScanAllContent(t *testing.T) {
 	// Create test domain
 	domain, _ := entity.NewDomain("test", "Test domain")
@@ -114,7 +218,7 @@ func TestContentScanner_ScanAllContent(t *testing.T) {
 	}
 
 	// Create content scanner
-	scanner := service.NewContentScanner(nodeRepo, nodeAttrRepo, domainRepo)
+	scanner := service.NewContentScanner(nodeRepo, nodeAttrRepo, domainRepo, &mockAttributeRepository{})
 
 	// Test scan request (first page)
 	req := service.ScanRequest{
@@ -257,7 +361,7 @@ func TestContentScanner_ScanAllContent_WithCompression(t *testing.T) {
 		domain: domain,
 	}
 
-	scanner := service.NewContentScanner(nodeRepo, nodeAttrRepo, domainRepo)
+	scanner := service.NewContentScanner(nodeRepo, nodeAttrRepo, domainRepo, &mockAttributeRepository{})
 
 	// Test with compression enabled
 	req := service.ScanRequest{
@@ -298,4 +402,100 @@ func TestContentScanner_ScanAllContent_WithCompression(t *testing.T) {
 // Helper function
 func stringPtr(s string) *string {
 	return &s
-}
\ No newline at end of file
+}
+func TestContentScanner_ScanAllContent_AttributeDefinitions(t *testing.T) {
+	domain, _ := entity.NewDomain("test", "Test domain")
+	domain.SetID(1)
+
+	node, _ := entity.NewNode("https://example.com/1", "Title 1", "Description 1", 1)
+	node.SetID(1)
+	node.SetTimestamps(time.Now(), time.Now())
+
+	stars, _ := entity.NewAttribute("stars", "number", "GitHub stars", 1)
+	tag, _ := entity.NewAttribute("tag", "tag", "Topic tags", 1)
+	other, _ := entity.NewAttribute("other", "string", "Attribute of another domain", 2)
+
+	scanner := service.NewContentScanner(
+		&mockNodeRepository{nodes: []*entity.Node{node}},
+		&mockNodeAttributeRepository{attributes: make(map[int][]*entity.NodeAttribute)},
+		&mockDomainRepository{domain: domain},
+		&mockAttributeRepository{attributes: []*entity.Attribute{stars, tag, other}},
+	)
+
+	result, err := scanner.ScanAllContent(context.Background(), service.ScanRequest{DomainName: "test", Page: 1})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	expected := []service.AttributeDefinition{
+		{Name: "stars", Type: "number", Description: "GitHub stars"},
+		{Name: "tag", Type: "tag", Description: "Topic tags"},
+	}
+	if len(result.Metadata.Attributes) != len(expected) {
+		t.Fatalf("Expected %d attribute definitions, got %v", len(expected), result.Metadata.Attributes)
+	}
+	for i, definition := range expected {
+		if result.Metadata.Attributes[i] != definition {
+			t.Errorf("Expected attribute definition %v, got %v", definition, result.Metadata.Attributes[i])
+		}
+	}
+}
+
+func TestContentScanner_EstimateScanSize(t *testing.T) {
+	domain, _ := entity.NewDomain("test", "Test domain")
+	domain.SetID(1)
+
+	// More nodes than one scan batch, with descriptions of varying length
+	var nodes []*entity.Node
+	for i := 1; i <= 150; i++ {
+		node, _ := entity.NewNode(fmt.Sprintf("https://example.com/%d", i), fmt.Sprintf("Title %d", i), strings.Repeat("description ", i%40), 1)
+		node.SetID(i)
+		node.SetTimestamps(time.Now(), time.Now())
+		nodes = append(nodes, node)
+	}
+
+	scanner := service.NewContentScanner(
+		&mockNodeRepository{nodes: nodes},
+		&mockNodeAttributeRepository{attributes: make(map[int][]*entity.NodeAttribute)},
+		&mockDomainRepository{domain: domain},
+		&mockAttributeRepository{},
+	)
+
+	req := service.ScanRequest{DomainName: "test", MaxTokensPerPage: 1000}
+	estimate, err := scanner.EstimateScanSize(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if estimate.TotalNodes != 150 || estimate.NodesPerPage != 10 || estimate.TotalPages != 15 || estimate.MaxTokensPerPage != 1000 {
+		t.Errorf("Unexpected estimate: %+v", estimate)
+	}
+
+	// The estimate matches what scanning every page reports
+	for page := 1; ; page++ {
+		req.Page = page
+		result, err := scanner.ScanAllContent(context.Background(), req)
+		if err != nil {
+			t.Fatalf("Expected no error scanning page %d, got: %v", page, err)
+		}
+		if result.Pagination.TotalPages != estimate.TotalPages || result.Metadata.EstimatedTokens != estimate.EstimatedTokens {
+			t.Errorf("Page %d reports %d pages and %d tokens, estimate %+v", page, result.Pagination.TotalPages, result.Metadata.EstimatedTokens, estimate)
+		}
+		if !result.Pagination.HasMore {
+			break
+		}
+	}
+
+	// The estimate reads no attributes
+	attributes := &mockNodeAttributeRepository{attributes: make(map[int][]*entity.NodeAttribute)}
+	scanner = service.NewContentScanner(&mockNodeRepository{nodes: nodes}, attributes, &mockDomainRepository{domain: domain}, &mockAttributeRepository{})
+	req.IncludeAttributes = true
+	if _, err := scanner.EstimateScanSize(context.Background(), req); err != nil || attributes.calls != 0 {
+		t.Errorf("Expected an estimate without attribute reads, got %d reads and error %v", attributes.calls, err)
+	}
+
+	// An unknown domain is an error rather than a nil dereference
+	scanner = service.NewContentScanner(&mockNodeRepository{}, attributes, &mockDomainRepository{}, &mockAttributeRepository{})
+	if _, err := scanner.EstimateScanSize(context.Background(), service.ScanRequest{DomainName: "nope"}); err == nil || !strings.Contains(err.Error(), "domain not found") {
+		t.Errorf("Expected domain not found, got %v", err)
+	}
+}
@@ -3,7 +3,6 @@ package service
 import (
 	"context"
 	"errors"
-	"regexp"
 	"url-db/internal/domain/entity"
 	"url-db/internal/domain/repository"
 )
@@ -16,30 +15,23 @@ type DomainService interface {
 }
 
 type domainService struct {
-	domainRepo repository.DomainRepository
+	domainRepo  repository.DomainRepository
+	unicodeMode string
 }
 
-// NewDomainService creates a new domain service
-func NewDomainService(domainRepo repository.DomainRepository) DomainService {
+// NewDomainService creates a new domain service. Names are folded with
+// entity.FoldDomainName in unicodeMode before validation; LOWERCASE_DOMAIN_NAMES
+// is applied by the create domain use case only.
+func NewDomainService(domainRepo repository.DomainRepository, unicodeMode string) DomainService {
 	return &domainService{
-		domainRepo: domainRepo,
+		domainRepo:  domainRepo,
+		unicodeMode: unicodeMode,
 	}
 }
 
-var domainNameRegex = regexp.MustCompile(`^[a-zA-Z0-9-]+$`)
-
 // ValidateDomainName validates domain name according to business rules
 func (s *domainService) ValidateDomainName(name string) error {
-	if len(name) == 0 {
-		return errors.New("domain name is required")
-	}
-	if len(name) > 255 {
-		return errors.New("domain name cannot exceed 255 characters")
-	}
-	if !domainNameRegex.MatchString(name) {
-		return errors.New("domain name can only contain alphanumeric characters and hyphens")
-	}
-	return nil
+	return entity.ValidateDomainName(name)
 }
 
 // ValidateDescription validates domain description according to business rules
@@ -52,6 +44,8 @@ func (s *domainService) ValidateDescription(description string) error {
 
 // CreateDomain creates a new domain with business validation
 func (s *domainService) CreateDomain(ctx context.Context, name, description string) (*entity.Domain, error) {
+	name = entity.FoldDomainName(name, s.unicodeMode)
+
 	// Validate input
 	if err := s.ValidateDomainName(name); err != nil {
 		return nil, err
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"url-db/internal/constants"
+)
+
+// NodeWarnings reports soft issues with a node's URL and title. These inputs
+// are valid and are still stored, but often indicate a mistake worth flagging.
+func NodeWarnings(urlStr, title string) []string {
+	warnings := []string{}
+
+	if parsed, err := url.Parse(urlStr); err == nil {
+		host := strings.ToLower(parsed.Hostname())
+		if ip := net.ParseIP(host); ip != nil {
+			if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsUnspecified() {
+				warnings = append(warnings, fmt.Sprintf("URL points to a private or local address (%s)", host))
+			}
+		} else if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+			warnings = append(warnings, "URL points to localhost")
+		} else if host != "" && !strings.Contains(host, ".") {
+			warnings = append(warnings, fmt.Sprintf("URL host '%s' has no top-level domain", host))
+		}
+	}
+
+	if len(title) > constants.LongTitleWarningLength {
+		warnings = append(warnings, fmt.Sprintf("title is unusually long (%d characters)", len(title)))
+	}
+
+	return warnings
+}
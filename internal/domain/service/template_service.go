@@ -48,6 +48,7 @@ type TemplateService interface {
 
 	// Template-based attribute value validation
 	ValidateAttributeValue(ctx context.Context, domainName, attributeName, value string) (*AttributeValidationResult, error)
+	AllowedAttributeValues(ctx context.Context, domainName, attributeName string) ([]string, error)
 }
 
 type templateService struct {
@@ -543,29 +544,9 @@ func (s *templateService) ValidateAttributeValue(ctx context.Context, domainName
 		return nil, fmt.Errorf("attribute not found: %w", err)
 	}
 
-	// Find active templates for this domain that define constraints for this attribute
-	templates, _, err := s.templateRepo.ListActive(ctx, domainName, 1, 100) // Get all active templates
+	applicableTemplate, validationMethod, allowedValues, err := s.findAttributeConstraints(ctx, domainName, attributeName)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get templates: %w", err)
-	}
-
-	var applicableTemplate *entity.Template
-	var allowedValues []string
-	var validationMethod string
-
-	// Check each template to see if it defines constraints for this attribute
-	for _, template := range templates {
-		if !template.IsActive() {
-			continue
-		}
-
-		templateData := template.TemplateData()
-		constraints, found := s.extractAttributeConstraints(templateData, attributeName)
-		if found {
-			applicableTemplate = template
-			validationMethod, allowedValues = s.parseConstraints(constraints)
-			break
-		}
+		return nil, err
 	}
 
 	// If no template defines constraints for this attribute, validation passes
@@ -585,6 +566,45 @@ func (s *templateService) ValidateAttributeValue(ctx context.Context, domainName
 	return result, nil
 }
 
+// AllowedAttributeValues returns the values the domain's active templates restrict
+// the attribute to, or nil when no template lists its values
+func (s *templateService) AllowedAttributeValues(ctx context.Context, domainName, attributeName string) ([]string, error) {
+	template, method, values, err := s.findAttributeConstraints(ctx, domainName, attributeName)
+	if err != nil || template == nil {
+		return nil, err
+	}
+	switch method {
+	case constants.ValidationMethodAllowedValues, constants.ValidationMethodEnum, constants.ValidationMethodSingleValue:
+		return values, nil
+	}
+	return nil, nil
+}
+
+// findAttributeConstraints returns the first active template of the domain that
+// defines constraints for the attribute, with the parsed constraints
+func (s *templateService) findAttributeConstraints(ctx context.Context, domainName, attributeName string) (*entity.Template, string, []string, error) {
+	// Find active templates for this domain that define constraints for this attribute
+	templates, _, err := s.templateRepo.ListActive(ctx, domainName, 1, 100) // Get all active templates
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("failed to get templates: %w", err)
+	}
+
+	// Check each template to see if it defines constraints for this attribute
+	for _, template := range templates {
+		if !template.IsActive() {
+			continue
+		}
+
+		constraints, found := s.extractAttributeConstraints(template.TemplateData(), attributeName)
+		if found {
+			method, values := s.parseConstraints(constraints)
+			return template, method, values, nil
+		}
+	}
+
+	return nil, "", nil, nil
+}
+
 // extractAttributeConstraints extracts constraints for a specific attribute from template data
 func (s *templateService) extractAttributeConstraints(templateData, attributeName string) (interface{}, bool) {
 	var data map[string]interface{}
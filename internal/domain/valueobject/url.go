@@ -2,10 +2,15 @@ package valueobject
 
 import (
 	"errors"
+	"fmt"
 	"net/url"
 	"strings"
+	"url-db/internal/constants"
 )
 
+// DefaultURLSchemes are the schemes node URLs may use unless configured otherwise
+var DefaultURLSchemes = strings.Split(constants.DefaultAllowedURLSchemes, ",")
+
 // URL represents a URL value object
 type URL struct {
 	value string
@@ -21,20 +26,16 @@ func NewURL(urlString string) (*URL, error) {
 		return nil, errors.New("URL cannot exceed 2048 characters")
 	}
 
+	if err := ValidateURL(urlString, false); err != nil {
+		return nil, err
+	}
+
 	// Parse and validate URL
 	parsedURL, err := url.Parse(urlString)
 	if err != nil {
 		return nil, errors.New("invalid URL format")
 	}
 
-	if parsedURL.Scheme == "" {
-		return nil, errors.New("URL must have a scheme (http:// or https://)")
-	}
-
-	if parsedURL.Host == "" {
-		return nil, errors.New("URL must have a host")
-	}
-
 	// Normalize URL
 	normalizedURL := normalizeURL(parsedURL)
 
@@ -43,6 +44,54 @@ func NewURL(urlString string) (*URL, error) {
 	}, nil
 }
 
+// ValidateURL checks that urlString is an absolute URL with one of DefaultURLSchemes
+// and a host. With allowRelative, scheme-less references such as paths or "#section"
+// identifiers are accepted as long as they contain no whitespace.
+func ValidateURL(urlString string, allowRelative bool) error {
+	return ValidateURLWithSchemes(urlString, allowRelative, DefaultURLSchemes)
+}
+
+// ValidateURLWithSchemes is ValidateURL with the allowed schemes given, compared
+// case-insensitively. URLs of schemes without an authority, such as mailto:, need
+// no host once their scheme is allowed.
+func ValidateURLWithSchemes(urlString string, allowRelative bool, allowedSchemes []string) error {
+	parsedURL, err := url.Parse(urlString)
+	if err != nil {
+		return errors.New("invalid URL format")
+	}
+
+	if allowRelative && parsedURL.Scheme == "" {
+		if strings.ContainsAny(urlString, " \t\r\n") {
+			return errors.New("invalid URL format: relative URL cannot contain whitespace")
+		}
+		return nil
+	}
+
+	if parsedURL.Scheme == "" {
+		return errors.New("URL must have a scheme (http:// or https://)")
+	}
+
+	if !schemeAllowed(parsedURL.Scheme, allowedSchemes) {
+		return fmt.Errorf("URL scheme '%s' is not allowed (allowed: %s)", strings.ToLower(parsedURL.Scheme), strings.Join(allowedSchemes, ", "))
+	}
+
+	if parsedURL.Host == "" && parsedURL.Opaque == "" {
+		return errors.New("URL must have a host")
+	}
+
+	return nil
+}
+
+// schemeAllowed reports whether scheme is one of allowedSchemes, ignoring case
+func schemeAllowed(scheme string, allowedSchemes []string) bool {
+	for _, allowed := range allowedSchemes {
+		if strings.EqualFold(scheme, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
 // Value returns the URL string
 func (u *URL) Value() string {
 	return u.value
@@ -73,3 +122,49 @@ func normalizeURL(u *url.URL) string {
 
 	return u.String()
 }
+
+// trackingParams are query parameters that identify a click rather than a resource
+var trackingParams = map[string]bool{
+	"fbclid":  true,
+	"gclid":   true,
+	"msclkid": true,
+	"mc_cid":  true,
+	"mc_eid":  true,
+}
+
+// ComparisonKey returns the form of a URL used to detect duplicates: scheme and host
+// lowercased, default ports, trailing slashes and the fragment removed, and tracking
+// parameters (utm_* and common click IDs) dropped with the rest sorted. URLs with the
+// same key point at the same resource for most sites; the key is not a valid URL to
+// store. Unparseable or relative URLs are only trimmed and lowercased.
+func ComparisonKey(urlString string) string {
+	parsedURL, err := url.Parse(strings.TrimSpace(urlString))
+	if err != nil || parsedURL.Host == "" {
+		return strings.ToLower(strings.TrimSpace(urlString))
+	}
+
+	parsedURL.Scheme = strings.ToLower(parsedURL.Scheme)
+	host := strings.ToLower(parsedURL.Host)
+	if (parsedURL.Scheme == "http" && strings.HasSuffix(host, ":80")) ||
+		(parsedURL.Scheme == "https" && strings.HasSuffix(host, ":443")) {
+		host = host[:strings.LastIndex(host, ":")]
+	}
+	parsedURL.Host = host
+	parsedURL.Path = strings.TrimRight(parsedURL.Path, "/")
+	parsedURL.RawPath = ""
+	parsedURL.Fragment = ""
+	parsedURL.RawFragment = ""
+
+	query := parsedURL.Query()
+	for name := range query {
+		lowerName := strings.ToLower(name)
+		if strings.HasPrefix(lowerName, "utm_") || trackingParams[lowerName] {
+			query.Del(name)
+		}
+	}
+	// Encode sorts by name, so parameter order does not matter
+	parsedURL.RawQuery = query.Encode()
+	parsedURL.ForceQuery = false
+
+	return parsedURL.String()
+}
@@ -0,0 +1,244 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"url-db/internal/constants"
+	"url-db/internal/domain/repository"
+	"url-db/internal/infrastructure/fetcher"
+)
+
+// DeliveryConfig configures a DeliveryWorker
+type DeliveryConfig struct {
+	PollInterval time.Duration // Time between polls for undelivered events
+	RetryCount   int           // Retries after a failed POST before the delivery is given up
+	BackoffBase  time.Duration // Wait before the first retry, doubled on each further retry
+	BatchSize    int           // Deliveries handled per poll
+	Client       *http.Client  // Client used to POST payloads; if nil, a client with a timeout that only reaches public addresses
+	Recorder     Recorder      // Flushed before each poll so buffered events are delivered, if set
+}
+
+// DeliveryPayload is the JSON body POSTed to a subscriber endpoint
+type DeliveryPayload struct {
+	SubscriptionID    int           `json:"subscription_id"`
+	SubscriberService string        `json:"subscriber_service"`
+	Event             DeliveryEvent `json:"event"`
+}
+
+// DeliveryEvent is the event part of a DeliveryPayload
+type DeliveryEvent struct {
+	ID         int             `json:"id"`
+	NodeID     int             `json:"node_id"`
+	EventType  string          `json:"event_type"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data,omitempty"` // The event's JSON detail, if any
+}
+
+// DeliveryResult summarizes one DeliverPending run
+type DeliveryResult struct {
+	Delivered int
+	Failed    int
+}
+
+// DeliveryWorker POSTs node events to the endpoints of matching subscriptions.
+// Each subscription receives an event at most once: successful deliveries and
+// deliveries that ran out of retries are both recorded and never attempted again.
+type DeliveryWorker struct {
+	repo repository.NodeSubscriptionRepository
+	cfg  DeliveryConfig
+
+	runMu    sync.Mutex // One DeliverPending run at a time
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewDeliveryWorker creates a delivery worker; call Start to begin polling
+func NewDeliveryWorker(repo repository.NodeSubscriptionRepository, cfg DeliveryConfig) *DeliveryWorker {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = constants.DefaultSubscriptionPollInterval
+	}
+	if cfg.RetryCount < 0 {
+		cfg.RetryCount = 0
+	}
+	if cfg.BackoffBase <= 0 {
+		cfg.BackoffBase = constants.DefaultSubscriptionBackoff
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = constants.DefaultSubscriptionBatchSize
+	}
+	if cfg.Client == nil {
+		// Endpoints come from MCP clients, so internal hosts are off limits like title fetches
+		cfg.Client = fetcher.NewPublicClient(constants.DefaultSubscriptionTimeout)
+	}
+
+	return &DeliveryWorker{
+		repo: repo,
+		cfg:  cfg,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+}
+
+// Start runs DeliverPending every poll interval until Stop is called
+func (w *DeliveryWorker) Start() {
+	go func() {
+		defer close(w.done)
+		ticker := time.NewTicker(w.cfg.PollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := w.DeliverPending(context.Background()); err != nil {
+					fmt.Fprintf(os.Stderr, "Failed to deliver node events: %v\n", err)
+				}
+			case <-w.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends the poll loop started by Start and waits for the current run to finish
+func (w *DeliveryWorker) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.stop)
+		<-w.done
+	})
+}
+
+// DeliverPending sends every undelivered event to its matching subscriptions,
+// one batch at a time, until none are left or the worker is stopped
+func (w *DeliveryWorker) DeliverPending(ctx context.Context) (*DeliveryResult, error) {
+	w.runMu.Lock()
+	defer w.runMu.Unlock()
+
+	if w.cfg.Recorder != nil {
+		if err := w.cfg.Recorder.Flush(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &DeliveryResult{}
+	for {
+		deliveries, err := w.repo.ListPendingDeliveries(ctx, w.cfg.BatchSize)
+		if err != nil {
+			return result, err
+		}
+		if len(deliveries) == 0 {
+			return result, nil
+		}
+
+		for _, delivery := range deliveries {
+			attempts, deliverErr := w.deliver(ctx, delivery)
+			if deliverErr != nil && w.stopping(ctx) {
+				// Retries were cut short; leave the delivery pending for the next run
+				return result, nil
+			}
+
+			var deliveredAt *time.Time
+			lastError := ""
+			if deliverErr == nil {
+				now := time.Now()
+				deliveredAt = &now
+				result.Delivered++
+			} else {
+				lastError = deliverErr.Error()
+				result.Failed++
+			}
+
+			if err := w.repo.RecordDelivery(ctx, delivery.Subscription.ID(), delivery.Event.ID(), attempts, deliveredAt, lastError); err != nil {
+				return result, err
+			}
+		}
+
+		if w.stopping(ctx) {
+			return result, nil
+		}
+	}
+}
+
+// stopping reports whether the worker is stopping or ctx is done
+func (w *DeliveryWorker) stopping(ctx context.Context) bool {
+	select {
+	case <-w.stop:
+		return true
+	case <-ctx.Done():
+		return true
+	default:
+		return false
+	}
+}
+
+// deliver POSTs one event, retrying with exponential backoff. It returns the
+// number of attempts made and the last error, or nil once a 2xx is received.
+func (w *DeliveryWorker) deliver(ctx context.Context, delivery *repository.SubscriptionDelivery) (int, error) {
+	event := delivery.Event
+	var data json.RawMessage
+	if json.Valid([]byte(event.EventData())) {
+		data = json.RawMessage(event.EventData())
+	}
+
+	body, err := json.Marshal(DeliveryPayload{
+		SubscriptionID:    delivery.Subscription.ID(),
+		SubscriberService: delivery.Subscription.SubscriberService(),
+		Event: DeliveryEvent{
+			ID:         event.ID(),
+			NodeID:     event.NodeID(),
+			EventType:  event.EventType(),
+			OccurredAt: event.OccurredAt(),
+			Data:       data,
+		},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode delivery payload: %w", err)
+	}
+
+	backoff := w.cfg.BackoffBase
+	attempts := 0
+	for {
+		attempts++
+		err = w.post(ctx, delivery.Subscription.SubscriberEndpoint(), body)
+		if err == nil || attempts > w.cfg.RetryCount {
+			return attempts, err
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-w.stop:
+			return attempts, err
+		case <-ctx.Done():
+			return attempts, err
+		}
+		backoff *= 2
+	}
+}
+
+func (w *DeliveryWorker) post(ctx context.Context, endpoint string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.cfg.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post event: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("subscriber endpoint returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"url-db/internal/constants"
+	"url-db/internal/domain/entity"
+	"url-db/internal/domain/repository"
+)
+
+// Recorder writes node events to the node event log
+type Recorder interface {
+	// Record stores an event, either immediately or on the next flush
+	Record(ctx context.Context, event *entity.NodeEvent) error
+	// Pending returns the number of events recorded but not yet written
+	Pending() int
+	// Flush writes pending events now, so readers of the event log see them
+	Flush(ctx context.Context) error
+	// Close writes any pending events and stops background work
+	Close(ctx context.Context) error
+}
+
+// DirectRecorder writes every event synchronously as it is recorded
+type DirectRecorder struct {
+	repo repository.NodeEventRepository
+}
+
+// NewDirectRecorder creates a recorder that writes each event immediately
+func NewDirectRecorder(repo repository.NodeEventRepository) *DirectRecorder {
+	return &DirectRecorder{repo: repo}
+}
+
+func (r *DirectRecorder) Record(ctx context.Context, event *entity.NodeEvent) error {
+	_, err := r.repo.CreateBatch(ctx, []*entity.NodeEvent{event})
+	return err
+}
+
+func (r *DirectRecorder) Pending() int { return 0 }
+
+func (r *DirectRecorder) Flush(ctx context.Context) error { return nil }
+
+func (r *DirectRecorder) Close(ctx context.Context) error { return nil }
+
+// BufferConfig configures when a BufferedRecorder flushes
+type BufferConfig struct {
+	FlushInterval time.Duration // Flush at least this often
+	MaxSize       int           // Flush early once this many events are pending
+}
+
+// BufferedRecorder keeps events in memory and writes them in batches, either on
+// a timer or once MaxSize events are pending. Close flushes whatever is left, but
+// events still in the buffer when the process crashes are lost.
+type BufferedRecorder struct {
+	repo     repository.NodeEventRepository
+	interval time.Duration
+	maxSize  int
+
+	mu      sync.Mutex
+	buffer  []*entity.NodeEvent
+	closed  bool
+	flushMu sync.Mutex // Keeps batches in recording order
+
+	trigger   chan struct{}
+	done      chan struct{}
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+}
+
+// NewBufferedRecorder creates a buffered recorder and starts its flush loop
+func NewBufferedRecorder(repo repository.NodeEventRepository, cfg BufferConfig) *BufferedRecorder {
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = constants.DefaultEventFlushInterval
+	}
+	if cfg.MaxSize <= 0 {
+		cfg.MaxSize = constants.DefaultEventBufferSize
+	}
+
+	r := &BufferedRecorder{
+		repo:     repo,
+		interval: cfg.FlushInterval,
+		maxSize:  cfg.MaxSize,
+		trigger:  make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+
+	r.wg.Add(1)
+	go r.run()
+
+	return r
+}
+
+func (r *BufferedRecorder) run() {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-r.trigger:
+		case <-r.done:
+			return
+		}
+
+		if err := r.Flush(context.Background()); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to flush node events: %v\n", err)
+		}
+	}
+}
+
+// Record adds an event to the buffer. Once the recorder is closed, events are
+// written through directly so nothing recorded during shutdown is dropped.
+func (r *BufferedRecorder) Record(ctx context.Context, event *entity.NodeEvent) error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		_, err := r.repo.CreateBatch(ctx, []*entity.NodeEvent{event})
+		return err
+	}
+	r.buffer = append(r.buffer, event)
+	full := len(r.buffer) >= r.maxSize
+	r.mu.Unlock()
+
+	if full {
+		select {
+		case r.trigger <- struct{}{}:
+		default: // A flush is already pending
+		}
+	}
+
+	return nil
+}
+
+// Pending returns the current buffer depth
+func (r *BufferedRecorder) Pending() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.buffer)
+}
+
+// Flush writes all buffered events in one batch. A failed batch is dropped and
+// its error returned.
+func (r *BufferedRecorder) Flush(ctx context.Context) error {
+	r.flushMu.Lock()
+	defer r.flushMu.Unlock()
+
+	r.mu.Lock()
+	batch := r.buffer
+	r.buffer = nil
+	r.mu.Unlock()
+
+	if len(batch) == 0 {
+		return nil
+	}
+
+	if _, err := r.repo.CreateBatch(ctx, batch); err != nil {
+		return fmt.Errorf("failed to write %d buffered event(s): %w", len(batch), err)
+	}
+
+	return nil
+}
+
+// Close stops the flush loop and writes the remaining buffer
+func (r *BufferedRecorder) Close(ctx context.Context) error {
+	r.closeOnce.Do(func() {
+		r.mu.Lock()
+		r.closed = true
+		r.mu.Unlock()
+
+		close(r.done)
+		r.wg.Wait()
+	})
+
+	return r.Flush(ctx)
+}
@@ -0,0 +1,211 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+
+	"url-db/internal/constants"
+)
+
+// titlePattern matches the contents of the first HTML <title> element
+var titlePattern = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+
+// ErrHostNotAllowed is returned when a URL's host is blocked by the allow/deny lists
+var ErrHostNotAllowed = errors.New("host not allowed for title fetching")
+
+// TitleFetcherConfig configures the guards applied when fetching titles
+type TitleFetcherConfig struct {
+	Timeout   time.Duration
+	MaxBytes  int64
+	Allowlist []string // If non-empty, only these hosts may be fetched
+	Denylist  []string // Hosts that may never be fetched
+}
+
+// TitleFetcher retrieves page titles over HTTP with SSRF protections.
+// The allowlist only narrows the hosts that may be fetched: every host, listed
+// or not, must resolve to a public address, so loopback, private and link-local
+// targets are refused.
+type TitleFetcher struct {
+	client    *http.Client
+	maxBytes  int64
+	allowlist map[string]bool
+	denylist  map[string]bool
+	// allowPrivate skips the address check so tests can fetch from local servers
+	allowPrivate bool
+}
+
+// NewTitleFetcher creates a title fetcher with the given guards
+func NewTitleFetcher(cfg TitleFetcherConfig) *TitleFetcher {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = constants.TitleFetchTimeout
+	}
+	if cfg.MaxBytes <= 0 {
+		cfg.MaxBytes = constants.TitleFetchMaxBytes
+	}
+
+	f := &TitleFetcher{
+		maxBytes:  cfg.MaxBytes,
+		allowlist: toHostSet(cfg.Allowlist),
+		denylist:  toHostSet(cfg.Denylist),
+	}
+
+	dialer := &net.Dialer{
+		Timeout: cfg.Timeout,
+		Control: f.checkDialAddress,
+	}
+	f.client = &http.Client{
+		Timeout: cfg.Timeout,
+		Transport: &http.Transport{
+			DialContext: dialer.DialContext,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= constants.TitleFetchMaxRedirects {
+				return fmt.Errorf("stopped after %d redirects", len(via))
+			}
+			return f.checkURL(req.URL)
+		},
+	}
+
+	return f
+}
+
+// FetchTitle fetches rawURL and returns the text of its HTML <title> element
+func (f *TitleFetcher) FetchTitle(ctx context.Context, rawURL string) (string, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL: %w", err)
+	}
+	if err := f.checkURL(parsed); err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, parsed.String(), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Accept", "text/html")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch URL: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, f.maxBytes))
+	if err != nil {
+		return "", fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	match := titlePattern.FindSubmatch(body)
+	if match == nil {
+		return "", fmt.Errorf("no <title> element found")
+	}
+
+	title := strings.Join(strings.Fields(html.UnescapeString(string(match[1]))), " ")
+	if title == "" {
+		return "", fmt.Errorf("empty <title> element")
+	}
+
+	// Keep within the node title limit, cutting at the last rune boundary that fits
+	if len(title) > constants.MaxTitleLength {
+		cut := 0
+		for i := range title {
+			if i > constants.MaxTitleLength {
+				break
+			}
+			cut = i
+		}
+		title = strings.TrimSpace(title[:cut])
+	}
+
+	return title, nil
+}
+
+// checkURL validates the scheme and host of a URL against the allow/deny lists
+func (f *TitleFetcher) checkURL(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+
+	host := strings.ToLower(u.Hostname())
+	if host == "" {
+		return fmt.Errorf("URL must have a host")
+	}
+	if f.denylist[host] {
+		return fmt.Errorf("%w: %s", ErrHostNotAllowed, host)
+	}
+	if len(f.allowlist) > 0 && !f.allowlist[host] {
+		return fmt.Errorf("%w: %s", ErrHostNotAllowed, host)
+	}
+
+	return nil
+}
+
+// checkDialAddress refuses connections to non-public addresses
+func (f *TitleFetcher) checkDialAddress(network, address string, conn syscall.RawConn) error {
+	if f.allowPrivate {
+		return nil
+	}
+	return CheckPublicAddress(network, address, conn)
+}
+
+// CheckPublicAddress is a net.Dialer Control function that refuses connections to
+// loopback, private, link-local, unspecified and multicast addresses. It runs on
+// the resolved address, so host names that resolve to internal targets are caught.
+func CheckPublicAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("%w: unresolved address %s", ErrHostNotAllowed, host)
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsUnspecified() || ip.IsMulticast() {
+		return fmt.Errorf("%w: %s is not a public address", ErrHostNotAllowed, ip)
+	}
+
+	return nil
+}
+
+// NewPublicClient creates an HTTP client that only connects to public addresses
+func NewPublicClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: CheckPublicAddress,
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext: dialer.DialContext,
+		},
+	}
+}
+
+// toHostSet normalizes a list of host names into a lookup set
+func toHostSet(hosts []string) map[string]bool {
+	set := make(map[string]bool, len(hosts))
+	for _, host := range hosts {
+		host = strings.ToLower(strings.TrimSpace(host))
+		if host != "" {
+			set[host] = true
+		}
+	}
+	return set
+}
@@ -0,0 +1,114 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"url-db/internal/constants"
+)
+
+func newTestServer(t *testing.T, body string) (*httptest.Server, string) {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(server.Close)
+
+	u, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("failed to parse server URL: %v", err)
+	}
+	return server, u.Hostname()
+}
+
+func TestFetchTitle(t *testing.T) {
+	server, host := newTestServer(t, "<html><head><title>\n  Hello &amp; Welcome\n</title></head></html>")
+
+	f := NewTitleFetcher(TitleFetcherConfig{Allowlist: []string{host}})
+	f.allowPrivate = true
+	title, err := f.FetchTitle(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if title != "Hello & Welcome" {
+		t.Errorf("title = %q, want %q", title, "Hello & Welcome")
+	}
+}
+
+func TestFetchTitleGuards(t *testing.T) {
+	server, host := newTestServer(t, "<title>Secret</title>")
+
+	tests := []struct {
+		name    string
+		config  TitleFetcherConfig
+		url     string
+		blocked bool
+	}{
+		{"loopback refused without allowlist", TitleFetcherConfig{}, server.URL, true},
+		{"allowlisted loopback refused", TitleFetcherConfig{Allowlist: []string{host}}, server.URL, true},
+		{"denylisted host refused", TitleFetcherConfig{Allowlist: []string{host}, Denylist: []string{host}}, server.URL, true},
+		{"host outside allowlist refused", TitleFetcherConfig{Allowlist: []string{"example.com"}}, server.URL, true},
+		{"unsupported scheme", TitleFetcherConfig{}, "file:///etc/passwd", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewTitleFetcher(tt.config).FetchTitle(context.Background(), tt.url)
+			if err == nil {
+				t.Fatalf("expected error")
+			}
+			if tt.blocked && !errors.Is(err, ErrHostNotAllowed) {
+				t.Errorf("expected ErrHostNotAllowed, got %v", err)
+			}
+		})
+	}
+}
+
+func TestFetchTitleSizeLimit(t *testing.T) {
+	server, host := newTestServer(t, strings.Repeat(" ", 2048)+"<title>Too far</title>")
+
+	f := NewTitleFetcher(TitleFetcherConfig{Allowlist: []string{host}, MaxBytes: 1024})
+	f.allowPrivate = true
+	if _, err := f.FetchTitle(context.Background(), server.URL); err == nil {
+		t.Errorf("expected title beyond the size limit to be ignored")
+	}
+}
+
+func TestFetchTitleTruncatesLongTitles(t *testing.T) {
+	server, host := newTestServer(t, "<title>"+strings.Repeat("é", 300)+"</title>")
+
+	f := NewTitleFetcher(TitleFetcherConfig{Allowlist: []string{host}})
+	f.allowPrivate = true
+	title, err := f.FetchTitle(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(title) > constants.MaxTitleLength {
+		t.Errorf("title length %d exceeds %d", len(title), constants.MaxTitleLength)
+	}
+
+	// A title filling the whole size limit is cut in one pass
+	server, host = newTestServer(t, "<title>"+strings.Repeat("é", (constants.TitleFetchMaxBytes-32)/2)+"</title>")
+	f = NewTitleFetcher(TitleFetcherConfig{Allowlist: []string{host}})
+	f.allowPrivate = true
+	start := time.Now()
+	title, err = f.FetchTitle(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := strings.Repeat("é", constants.MaxTitleLength/2); title != want {
+		t.Errorf("got a %d byte title, want %d bytes of whole runes", len(title), len(want))
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("truncating a 1MB title took %v", elapsed)
+	}
+}
@@ -0,0 +1,66 @@
+package mapper
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+	"url-db/internal/domain/entity"
+)
+
+// DependencyDBModel represents a node_dependencies row joined with its type name
+type DependencyDBModel struct {
+	ID               int            `db:"id"`
+	DependentNodeID  int            `db:"dependent_node_id"`
+	DependencyNodeID int            `db:"dependency_node_id"`
+	TypeName         string         `db:"type_name"`
+	Metadata         sql.NullString `db:"metadata"`
+	CreatedAt        time.Time      `db:"created_at"`
+}
+
+// dependencyMetadata is the JSON stored in node_dependencies.metadata
+type dependencyMetadata struct {
+	Description   string `json:"description,omitempty"`
+	CascadeDelete bool   `json:"cascade_delete,omitempty"`
+	CascadeUpdate bool   `json:"cascade_update,omitempty"`
+}
+
+// ToDependencyEntity converts a database model to domain entity
+func ToDependencyEntity(dbModel *DependencyDBModel) *entity.Dependency {
+	if dbModel == nil {
+		return nil
+	}
+
+	var metadata dependencyMetadata
+	if dbModel.Metadata.Valid && dbModel.Metadata.String != "" {
+		_ = json.Unmarshal([]byte(dbModel.Metadata.String), &metadata)
+	}
+
+	dependency, err := entity.NewDependency(
+		dbModel.DependentNodeID,
+		dbModel.DependencyNodeID,
+		dbModel.TypeName,
+		metadata.Description,
+	)
+	if err != nil {
+		return nil
+	}
+
+	dependency.SetID(dbModel.ID)
+	dependency.SetCascade(metadata.CascadeDelete, metadata.CascadeUpdate)
+	dependency.SetCreatedAt(dbModel.CreatedAt)
+
+	return dependency
+}
+
+// ToDependencyMetadata encodes the metadata column for a dependency
+func ToDependencyMetadata(dependency *entity.Dependency) string {
+	data, err := json.Marshal(dependencyMetadata{
+		Description:   dependency.Description(),
+		CascadeDelete: dependency.CascadeDelete(),
+		CascadeUpdate: dependency.CascadeUpdate(),
+	})
+	if err != nil {
+		return "{}"
+	}
+	return string(data)
+}
@@ -7,13 +7,16 @@ import (
 
 // DatabaseNode represents the node as stored in database (raw SQL row)
 type DatabaseNode struct {
-	ID          int       `db:"id"`
-	Content     string    `db:"content"` // This is the URL field
-	DomainID    int       `db:"domain_id"`
-	Title       string    `db:"title"`
-	Description string    `db:"description"`
-	CreatedAt   time.Time `db:"created_at"`
-	UpdatedAt   time.Time `db:"updated_at"`
+	ID          int        `db:"id"`
+	Content     string     `db:"content"` // This is the URL field
+	DomainID    int        `db:"domain_id"`
+	Title       string     `db:"title"`
+	Description string     `db:"description"`
+	CreatedAt   time.Time  `db:"created_at"`
+	UpdatedAt   time.Time  `db:"updated_at"`
+	ExpiresAt   *time.Time `db:"expires_at"`
+	ArchivedAt  *time.Time `db:"archived_at"`
+	Version     int        `db:"version"`
 }
 
 // ToNodeEntity converts a database row to a node entity
@@ -30,6 +33,9 @@ func ToNodeEntity(dbRow *DatabaseNode) *entity.Node {
 	// Set database-specific fields
 	node.SetID(dbRow.ID)
 	node.SetTimestamps(dbRow.CreatedAt, dbRow.UpdatedAt)
+	node.SetExpiresAt(dbRow.ExpiresAt)
+	node.SetArchivedAt(dbRow.ArchivedAt)
+	node.SetVersion(dbRow.Version)
 
 	return node
 }
@@ -48,5 +54,8 @@ func FromNodeEntity(node *entity.Node) *DatabaseNode {
 		Description: node.Description(),
 		CreatedAt:   node.CreatedAt(),
 		UpdatedAt:   node.UpdatedAt(),
+		ExpiresAt:   node.ExpiresAt(),
+		ArchivedAt:  node.ArchivedAt(),
+		Version:     node.Version(),
 	}
 }
@@ -139,6 +139,33 @@ func (r *attributeRepository) ListByDomainID(ctx context.Context, domainID int)
 	return attributes, rows.Err()
 }
 
+func (r *attributeRepository) ListAcrossDomains(ctx context.Context) ([]repository.DomainAttribute, error) {
+	query := `
+		SELECT d.name, a.name, a.type, COALESCE(a.description, ''),
+			(SELECT COUNT(*) FROM node_attributes na WHERE na.attribute_id = a.id)
+		FROM attributes a
+		INNER JOIN domains d ON a.domain_id = d.id
+		ORDER BY a.name, d.name
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attributes []repository.DomainAttribute
+	for rows.Next() {
+		var attr repository.DomainAttribute
+		if err := rows.Scan(&attr.DomainName, &attr.Name, &attr.Type, &attr.Description, &attr.ValueCount); err != nil {
+			return nil, err
+		}
+		attributes = append(attributes, attr)
+	}
+
+	return attributes, rows.Err()
+}
+
 func (r *attributeRepository) Update(ctx context.Context, attribute *entity.Attribute) error {
 	query := `
 		UPDATE attributes 
@@ -0,0 +1,303 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"url-db/internal/constants"
+	"url-db/internal/domain/entity"
+	"url-db/internal/domain/repository"
+	"url-db/internal/infrastructure/persistence/sqlite/mapper"
+)
+
+type dependencyRepository struct {
+	db *sql.DB
+}
+
+// NewDependencyRepository creates a new dependency repository
+func NewDependencyRepository(db *sql.DB) repository.DependencyRepository {
+	return &dependencyRepository{db: db}
+}
+
+// dependencySelect selects dependency rows joined with their type name
+const dependencySelect = `
+	SELECT nd.id, nd.dependent_node_id, nd.dependency_node_id, dt.type_name, nd.metadata, nd.created_at
+	FROM node_dependencies nd
+	JOIN dependency_types dt ON nd.dependency_type_id = dt.id
+`
+
+// dependencyQueryer runs queries on the database or inside a transaction
+type dependencyQueryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// txDependencyGraph reads dependencies inside a transaction, seeing its own inserts
+type txDependencyGraph struct {
+	tx *sql.Tx
+}
+
+func (g txDependencyGraph) ListByDependentNodeID(ctx context.Context, nodeID int) ([]*entity.Dependency, error) {
+	return listDependenciesByNode(ctx, g.tx, "nd.dependent_node_id", nodeID)
+}
+
+func (r *dependencyRepository) Create(ctx context.Context, dependency *entity.Dependency, check repository.DependencyCheck) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := r.createInTx(ctx, tx, dependency, check); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+func (r *dependencyRepository) CreateBatch(ctx context.Context, dependencies []*entity.Dependency, check repository.DependencyCheck) ([]error, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// A failed statement only undoes itself, so later dependencies still go in
+	createErrors := make([]error, len(dependencies))
+	for i, dependency := range dependencies {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		createErrors[i] = r.createInTx(ctx, tx, dependency, check)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return createErrors, nil
+}
+
+// createInTx inserts a dependency unless an active one of the same type joins the
+// same nodes or check rejects it, and sets its ID
+func (r *dependencyRepository) createInTx(ctx context.Context, tx *sql.Tx, dependency *entity.Dependency, check repository.DependencyCheck) error {
+	// Custom types from DEPENDENCY_TYPES are registered on first use. As the first
+	// statement is a write, it takes the write lock before check reads the graph, so
+	// concurrent inserts run one after the other and each sees the edges before it.
+	_, err := tx.ExecContext(ctx,
+		`INSERT OR IGNORE INTO dependency_types (type_name, category, description) VALUES (?, 'custom', 'Custom dependency type')`,
+		dependency.DependencyType())
+	if err != nil {
+		return fmt.Errorf("failed to register dependency type: %w", err)
+	}
+
+	var typeID int
+	err = tx.QueryRowContext(ctx, `SELECT id FROM dependency_types WHERE type_name = ?`, dependency.DependencyType()).Scan(&typeID)
+	if err != nil {
+		return fmt.Errorf("failed to get dependency type: %w", err)
+	}
+
+	if check != nil {
+		if err := check(ctx, txDependencyGraph{tx: tx}, dependency); err != nil {
+			return err
+		}
+	}
+
+	var exists int
+	err = tx.QueryRowContext(ctx, `
+		SELECT 1 FROM node_dependencies
+		WHERE dependent_node_id = ? AND dependency_node_id = ? AND dependency_type_id = ? AND is_active = 1
+		LIMIT 1`,
+		dependency.DependentNodeID(), dependency.DependencyNodeID(), typeID).Scan(&exists)
+	if err == nil {
+		return errors.New(constants.ErrDuplicateDependency)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to check existing dependency: %w", err)
+	}
+
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO node_dependencies (dependent_node_id, dependency_node_id, dependency_type_id, metadata, created_at)
+		VALUES (?, ?, ?, ?, ?)`,
+		dependency.DependentNodeID(),
+		dependency.DependencyNodeID(),
+		typeID,
+		mapper.ToDependencyMetadata(dependency),
+		dependency.CreatedAt(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert dependency: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get dependency ID: %w", err)
+	}
+
+	dependency.SetID(int(id))
+	return nil
+}
+
+func (r *dependencyRepository) ListByDependentNodeID(ctx context.Context, nodeID int) ([]*entity.Dependency, error) {
+	return listDependenciesByNode(ctx, r.db, "nd.dependent_node_id", nodeID)
+}
+
+func (r *dependencyRepository) ListByDependencyNodeID(ctx context.Context, nodeID int) ([]*entity.Dependency, error) {
+	return listDependenciesByNode(ctx, r.db, "nd.dependency_node_id", nodeID)
+}
+
+// listDependenciesByNode retrieves active dependencies whose given endpoint column matches a node
+func listDependenciesByNode(ctx context.Context, q dependencyQueryer, column string, nodeID int) ([]*entity.Dependency, error) {
+	query := dependencySelect + ` WHERE nd.is_active = 1 AND ` + column + ` = ? ORDER BY nd.id`
+
+	rows, err := q.QueryContext(ctx, query, nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query dependencies: %w", err)
+	}
+	defer rows.Close()
+
+	return scanDependencies(rows)
+}
+
+func (r *dependencyRepository) ListEdgesByDependentNodeID(ctx context.Context, nodeID int) ([]repository.DependencyEdge, error) {
+	return r.listEdgesByNode(ctx, "nd.dependent_node_id", "dependency", nodeID)
+}
+
+func (r *dependencyRepository) ListEdgesByDependencyNodeID(ctx context.Context, nodeID int) ([]repository.DependencyEdge, error) {
+	return r.listEdgesByNode(ctx, "nd.dependency_node_id", "dependent", nodeID)
+}
+
+// listEdgesByNode is listDependenciesByNode joined with both endpoint nodes and their domains, so
+// no edge needs a lookup of its own. related names the endpoint alias whose title and
+// URL are returned.
+func (r *dependencyRepository) listEdgesByNode(ctx context.Context, column, related string, nodeID int) ([]repository.DependencyEdge, error) {
+	query := `
+		SELECT nd.id, nd.dependent_node_id, nd.dependency_node_id, dt.type_name, nd.metadata, nd.created_at,
+		       dependent_domain.name, dependency_domain.name,
+		       COALESCE(` + related + `.title, ''), ` + related + `.content
+		FROM node_dependencies nd
+		JOIN dependency_types dt ON nd.dependency_type_id = dt.id
+		JOIN nodes dependent ON nd.dependent_node_id = dependent.id
+		JOIN domains dependent_domain ON dependent.domain_id = dependent_domain.id
+		JOIN nodes dependency ON nd.dependency_node_id = dependency.id
+		JOIN domains dependency_domain ON dependency.domain_id = dependency_domain.id
+		WHERE nd.is_active = 1 AND ` + column + ` = ?
+		ORDER BY nd.id`
+
+	rows, err := r.db.QueryContext(ctx, query, nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query dependencies: %w", err)
+	}
+	defer rows.Close()
+
+	var edges []repository.DependencyEdge
+	for rows.Next() {
+		var dbModel mapper.DependencyDBModel
+		var edge repository.DependencyEdge
+		err := rows.Scan(
+			&dbModel.ID,
+			&dbModel.DependentNodeID,
+			&dbModel.DependencyNodeID,
+			&dbModel.TypeName,
+			&dbModel.Metadata,
+			&dbModel.CreatedAt,
+			&edge.DependentDomain,
+			&edge.DependencyDomain,
+			&edge.RelatedTitle,
+			&edge.RelatedURL,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan dependency: %w", err)
+		}
+
+		if edge.Dependency = mapper.ToDependencyEntity(&dbModel); edge.Dependency != nil {
+			edges = append(edges, edge)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate dependencies: %w", err)
+	}
+
+	return edges, nil
+}
+
+func (r *dependencyRepository) Delete(ctx context.Context, id int) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM node_dependencies WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete dependency: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New(constants.ErrDependencyNotFound)
+	}
+
+	return nil
+}
+
+func (r *dependencyRepository) ListByDomain(ctx context.Context, domainID int, page, size int) ([]*entity.Dependency, int, error) {
+	// Both endpoints must belong to the domain
+	domainFilter := `
+		JOIN nodes dependent ON nd.dependent_node_id = dependent.id
+		JOIN nodes dependency ON nd.dependency_node_id = dependency.id
+		WHERE nd.is_active = 1 AND dependent.domain_id = ? AND dependency.domain_id = ?
+	`
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM node_dependencies nd ` + domainFilter
+	if err := r.db.QueryRowContext(ctx, countQuery, domainID, domainID).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count dependencies: %w", err)
+	}
+
+	offset := (page - 1) * size
+	query := dependencySelect + domainFilter + ` ORDER BY nd.id LIMIT ? OFFSET ?`
+
+	rows, err := r.db.QueryContext(ctx, query, domainID, domainID, size, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query dependencies: %w", err)
+	}
+	defer rows.Close()
+
+	dependencies, err := scanDependencies(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return dependencies, total, nil
+}
+
+// scanDependencies converts dependency rows to entities
+func scanDependencies(rows *sql.Rows) ([]*entity.Dependency, error) {
+	var dependencies []*entity.Dependency
+	for rows.Next() {
+		var dbModel mapper.DependencyDBModel
+		err := rows.Scan(
+			&dbModel.ID,
+			&dbModel.DependentNodeID,
+			&dbModel.DependencyNodeID,
+			&dbModel.TypeName,
+			&dbModel.Metadata,
+			&dbModel.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan dependency: %w", err)
+		}
+
+		if dependency := mapper.ToDependencyEntity(&dbModel); dependency != nil {
+			dependencies = append(dependencies, dependency)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate dependencies: %w", err)
+	}
+
+	return dependencies, nil
+}
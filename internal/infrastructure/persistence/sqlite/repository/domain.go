@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"time"
 	"url-db/internal/constants"
 	"url-db/internal/domain/entity"
 	"url-db/internal/domain/repository"
@@ -120,6 +121,50 @@ func (r *domainRepository) List(ctx context.Context, page, size int) ([]*entity.
 	return domains, totalCount, nil
 }
 
+// ListAfter pages through domains by keyset so each page costs the same regardless
+// of position. created_at is compared through julianday() because rows written at
+// different times store it in different text formats; idx_domains_created_id covers it.
+func (r *domainRepository) ListAfter(ctx context.Context, createdAt time.Time, id int, size int) ([]*entity.Domain, error) {
+	query := `SELECT id, name, description, created_at, updated_at FROM domains`
+	var args []interface{}
+	if id > 0 {
+		// The leading >= lets SQLite seek the index instead of scanning from the start
+		after := createdAt.UTC().Format("2006-01-02 15:04:05.000000000")
+		query += ` WHERE julianday(created_at) >= julianday(?) AND (julianday(created_at), id) > (julianday(?), ?)`
+		args = append(args, after, after, id)
+	}
+	query += ` ORDER BY julianday(created_at), id LIMIT ?`
+	args = append(args, size)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var domains []*entity.Domain
+	for rows.Next() {
+		var dbRow mapper.DatabaseDomain
+		err := rows.Scan(
+			&dbRow.ID,
+			&dbRow.Name,
+			&dbRow.Description,
+			&dbRow.CreatedAt,
+			&dbRow.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		domain := mapper.ToDomainEntity(&dbRow)
+		if domain != nil {
+			domains = append(domains, domain)
+		}
+	}
+
+	return domains, rows.Err()
+}
+
 func (r *domainRepository) Update(ctx context.Context, domain *entity.Domain) error {
 	dbModel := mapper.FromDomainEntity(domain)
 
@@ -0,0 +1,232 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+	"url-db/internal/domain/entity"
+	"url-db/internal/domain/repository"
+	"url-db/internal/infrastructure/persistence/sqlite/mapper"
+)
+
+type domainImportRepository struct {
+	db *sql.DB
+}
+
+// NewDomainImportRepository creates a new SQLite-based domain import repository
+func NewDomainImportRepository(db *sql.DB) repository.DomainImportRepository {
+	return &domainImportRepository{db: db}
+}
+
+func (r *domainImportRepository) Import(ctx context.Context, data *repository.DomainImport, conflict repository.ImportConflict) (*repository.DomainImportResult, error) {
+	switch conflict {
+	case repository.ImportConflictSkip, repository.ImportConflictOverwrite, repository.ImportConflictFail:
+	default:
+		return nil, fmt.Errorf("invalid conflict mode: %s", conflict)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result := &repository.DomainImportResult{}
+
+	domainID, err := r.ensureDomain(ctx, tx, data, result)
+	if err != nil {
+		return nil, err
+	}
+
+	attributeIDs, err := r.ensureAttributes(ctx, tx, domainID, data.Attributes, result)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range data.Nodes {
+		if err := r.importNode(ctx, tx, domainID, item, attributeIDs, conflict, result); err != nil {
+			return nil, err
+		}
+	}
+
+	if data.MaxNodes > 0 {
+		var count int
+		err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM nodes n WHERE n.domain_id = ? AND `+activeNodeCondition,
+			domainID, activeAt()).Scan(&count)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count nodes: %w", err)
+		}
+		if count > data.MaxNodes {
+			return nil, fmt.Errorf("import would put %d nodes in domain '%s', above its limit of %d", count, data.Name, data.MaxNodes)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return result, nil
+}
+
+// ensureDomain returns the ID of the domain, creating it if missing
+func (r *domainImportRepository) ensureDomain(ctx context.Context, tx *sql.Tx, data *repository.DomainImport, result *repository.DomainImportResult) (int, error) {
+	var domainID int
+	err := tx.QueryRowContext(ctx, `SELECT id FROM domains WHERE name = ?`, data.Name).Scan(&domainID)
+	if err == nil {
+		return domainID, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, fmt.Errorf("failed to get domain: %w", err)
+	}
+
+	domain, err := entity.NewDomain(data.Name, data.Description)
+	if err != nil {
+		return 0, fmt.Errorf("invalid domain: %w", err)
+	}
+	dbModel := mapper.FromDomainEntity(domain)
+
+	res, err := tx.ExecContext(ctx, `INSERT INTO domains (name, description, created_at, updated_at) VALUES (?, ?, ?, ?)`,
+		dbModel.Name, dbModel.Description, dbModel.CreatedAt, dbModel.UpdatedAt)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create domain: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get domain ID: %w", err)
+	}
+
+	result.DomainCreated = true
+	return int(id), nil
+}
+
+// ensureAttributes creates missing attribute definitions and returns the IDs of all
+// attributes of the domain by name. An existing attribute of another type is an error,
+// since its values could not be imported as they are.
+func (r *domainImportRepository) ensureAttributes(ctx context.Context, tx *sql.Tx, domainID int, attributes []repository.ImportAttribute, result *repository.DomainImportResult) (map[string]int, error) {
+	type existingAttribute struct {
+		id            int
+		attributeType string
+	}
+
+	rows, err := tx.QueryContext(ctx, `SELECT id, name, type FROM attributes WHERE domain_id = ?`, domainID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list attributes: %w", err)
+	}
+	existing := make(map[string]existingAttribute)
+	for rows.Next() {
+		var attr existingAttribute
+		var name string
+		if err := rows.Scan(&attr.id, &name, &attr.attributeType); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan attribute: %w", err)
+		}
+		existing[name] = attr
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list attributes: %w", err)
+	}
+
+	for _, item := range attributes {
+		if attr, ok := existing[item.Name]; ok {
+			if attr.attributeType != item.Type {
+				return nil, fmt.Errorf("attribute '%s' already exists with type %s, not %s", item.Name, attr.attributeType, item.Type)
+			}
+			result.AttributesSkipped++
+			continue
+		}
+
+		attribute, err := entity.NewAttribute(item.Name, item.Type, item.Description, domainID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid attribute '%s': %w", item.Name, err)
+		}
+		res, err := tx.ExecContext(ctx, `INSERT INTO attributes (domain_id, name, type, description, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)`,
+			domainID, attribute.Name(), attribute.Type(), attribute.Description(), attribute.CreatedAt(), attribute.UpdatedAt())
+		if err != nil {
+			return nil, fmt.Errorf("failed to create attribute '%s': %w", item.Name, err)
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get attribute ID: %w", err)
+		}
+		existing[item.Name] = existingAttribute{id: int(id), attributeType: item.Type}
+		result.AttributesCreated++
+	}
+
+	ids := make(map[string]int, len(existing))
+	for name, attr := range existing {
+		ids[name] = attr.id
+	}
+	return ids, nil
+}
+
+// importNode inserts one node with its attribute values, resolving an existing URL
+// according to conflict
+func (r *domainImportRepository) importNode(ctx context.Context, tx *sql.Tx, domainID int, item repository.ImportNode, attributeIDs map[string]int, conflict repository.ImportConflict, result *repository.DomainImportResult) error {
+	node, err := entity.NewNode(item.URL, item.Title, item.Description, domainID)
+	if err != nil {
+		return fmt.Errorf("invalid node '%s': %w", item.URL, err)
+	}
+	if !item.CreatedAt.IsZero() {
+		updatedAt := item.UpdatedAt
+		if updatedAt.IsZero() {
+			updatedAt = item.CreatedAt
+		}
+		node.SetTimestamps(item.CreatedAt, updatedAt)
+	}
+	dbModel := mapper.FromNodeEntity(node)
+
+	if err := purgeExpiredURL(ctx, tx, dbModel.Content, domainID); err != nil {
+		return err
+	}
+
+	var nodeID int
+	err = tx.QueryRowContext(ctx, `SELECT id FROM nodes WHERE content = ? AND domain_id = ?`, dbModel.Content, domainID).Scan(&nodeID)
+	switch {
+	case err == sql.ErrNoRows:
+		res, err := tx.ExecContext(ctx, `INSERT INTO nodes (content, domain_id, title, description, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)`,
+			dbModel.Content, domainID, dbModel.Title, dbModel.Description, dbModel.CreatedAt, dbModel.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to create node '%s': %w", item.URL, err)
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to get node ID: %w", err)
+		}
+		nodeID = int(id)
+		result.NodesCreated++
+	case err != nil:
+		return fmt.Errorf("failed to look up node '%s': %w", item.URL, err)
+	case conflict == repository.ImportConflictSkip:
+		result.NodesSkipped++
+		return nil
+	case conflict == repository.ImportConflictFail:
+		return fmt.Errorf("node '%s' already exists in domain: %w", item.URL, repository.ErrDuplicateKey)
+	default:
+		_, err := tx.ExecContext(ctx, `UPDATE nodes SET title = ?, description = ?, updated_at = ? WHERE id = ?`,
+			dbModel.Title, dbModel.Description, time.Now(), nodeID)
+		if err != nil {
+			return fmt.Errorf("failed to update node '%s': %w", item.URL, err)
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM node_attributes WHERE node_id = ?`, nodeID); err != nil {
+			return fmt.Errorf("failed to delete attributes of node '%s': %w", item.URL, err)
+		}
+		result.NodesUpdated++
+	}
+
+	for _, value := range item.Attributes {
+		attributeID, ok := attributeIDs[value.Name]
+		if !ok {
+			return fmt.Errorf("node '%s' uses attribute '%s', which is not defined in the domain", item.URL, value.Name)
+		}
+		_, err := tx.ExecContext(ctx, `INSERT INTO node_attributes (node_id, attribute_id, value, order_index, created_at) VALUES (?, ?, ?, ?, ?)`,
+			nodeID, attributeID, value.Value, value.OrderIndex, time.Now())
+		if err != nil {
+			return fmt.Errorf("failed to set attribute '%s' of node '%s': %w", value.Name, item.URL, err)
+		}
+		result.AttributeValuesCreated++
+	}
+
+	return nil
+}
@@ -0,0 +1,283 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+	"url-db/internal/domain/repository"
+)
+
+type domainMergeRepository struct {
+	db *sql.DB
+}
+
+// NewDomainMergeRepository creates a new SQLite-based domain merge repository
+func NewDomainMergeRepository(db *sql.DB) repository.DomainMergeRepository {
+	return &domainMergeRepository{db: db}
+}
+
+func (r *domainMergeRepository) Merge(ctx context.Context, merge *repository.DomainMerge) (*repository.DomainMergeResult, error) {
+	switch merge.Conflict {
+	case repository.ImportConflictSkip, repository.ImportConflictOverwrite, repository.ImportConflictFail:
+	default:
+		return nil, fmt.Errorf("invalid conflict mode: %s", merge.Conflict)
+	}
+	if merge.SourceDomainID == merge.TargetDomainID {
+		return nil, fmt.Errorf("cannot merge a domain into itself")
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result := &repository.DomainMergeResult{}
+
+	// Attributes go first, so node values already point at the target's definitions
+	// when nodes move
+	if err := r.mergeAttributes(ctx, tx, merge, result); err != nil {
+		return result, err
+	}
+
+	if err := r.mergeNodes(ctx, tx, merge, result); err != nil {
+		return nil, err
+	}
+
+	if merge.MaxNodes > 0 {
+		var count int
+		err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM nodes n WHERE n.domain_id = ? AND `+activeNodeCondition,
+			merge.TargetDomainID, activeAt()).Scan(&count)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count nodes: %w", err)
+		}
+		if count > merge.MaxNodes {
+			return nil, fmt.Errorf("merge would put %d nodes in the target domain, above its limit of %d", count, merge.MaxNodes)
+		}
+	}
+
+	// Templates keep their name, so one the target already uses is dropped
+	res, err := tx.ExecContext(ctx, `
+		UPDATE templates SET domain_id = ?, updated_at = ?
+		WHERE domain_id = ? AND name NOT IN (SELECT name FROM templates WHERE domain_id = ?)`,
+		merge.TargetDomainID, time.Now(), merge.SourceDomainID, merge.TargetDomainID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to move templates: %w", err)
+	}
+	moved, err := res.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to move templates: %w", err)
+	}
+	result.TemplatesMoved = int(moved)
+	err = tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM templates WHERE domain_id = ?`, merge.SourceDomainID).Scan(&result.TemplatesDropped)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count templates: %w", err)
+	}
+
+	// Whatever is left, such as skipped nodes and conflicting attributes, goes with the source
+	if _, err := tx.ExecContext(ctx, `DELETE FROM domains WHERE id = ?`, merge.SourceDomainID); err != nil {
+		return nil, fmt.Errorf("failed to delete source domain: %w", err)
+	}
+
+	if merge.DryRun {
+		return result, nil
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return result, nil
+}
+
+// mergeAttributes moves source attribute definitions the target lacks and points
+// values of those it shares at the target's. Type conflicts are all collected
+// before any write, and abort the merge unless their values may be dropped.
+func (r *domainMergeRepository) mergeAttributes(ctx context.Context, tx *sql.Tx, merge *repository.DomainMerge, result *repository.DomainMergeResult) error {
+	sourceAttributes, err := listMoveAttributes(ctx, tx, merge.SourceDomainID)
+	if err != nil {
+		return err
+	}
+	targetAttributes, err := listMoveAttributes(ctx, tx, merge.TargetDomainID)
+	if err != nil {
+		return err
+	}
+	targetByName := make(map[string]moveAttribute, len(targetAttributes))
+	for _, attr := range targetAttributes {
+		targetByName[attr.name] = attr
+	}
+
+	var conflicting []moveAttribute
+	for _, attr := range sourceAttributes {
+		target, ok := targetByName[attr.name]
+		if !ok || target.attributeType == attr.attributeType {
+			continue
+		}
+		conflict := repository.AttributeTypeConflict{Name: attr.name, SourceType: attr.attributeType, TargetType: target.attributeType}
+		err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM node_attributes WHERE attribute_id = ?`, attr.id).Scan(&conflict.Values)
+		if err != nil {
+			return fmt.Errorf("failed to count values of attribute '%s': %w", attr.name, err)
+		}
+		result.Conflicts = append(result.Conflicts, conflict)
+		conflicting = append(conflicting, attr)
+	}
+	if len(result.Conflicts) > 0 && !merge.DropConflictingAttributes {
+		names := make([]string, len(result.Conflicts))
+		for i, conflict := range result.Conflicts {
+			names[i] = fmt.Sprintf("%s (%s in source, %s in target)", conflict.Name, conflict.SourceType, conflict.TargetType)
+		}
+		return fmt.Errorf("%w: %s", repository.ErrAttributeTypeConflict, strings.Join(names, ", "))
+	}
+
+	for _, attr := range conflicting {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM node_attributes WHERE attribute_id = ?`, attr.id); err != nil {
+			return fmt.Errorf("failed to drop values of attribute '%s': %w", attr.name, err)
+		}
+	}
+
+	for _, attr := range sourceAttributes {
+		target, ok := targetByName[attr.name]
+		switch {
+		case !ok:
+			if _, err := tx.ExecContext(ctx, `UPDATE attributes SET domain_id = ?, updated_at = ? WHERE id = ?`,
+				merge.TargetDomainID, time.Now(), attr.id); err != nil {
+				return fmt.Errorf("failed to move attribute '%s': %w", attr.name, err)
+			}
+			result.AttributesMoved++
+		case target.attributeType == attr.attributeType:
+			for _, table := range []string{"node_attributes", "template_attributes"} {
+				if _, err := tx.ExecContext(ctx, `UPDATE `+table+` SET attribute_id = ? WHERE attribute_id = ?`, target.id, attr.id); err != nil {
+					return fmt.Errorf("failed to merge attribute '%s': %w", attr.name, err)
+				}
+			}
+			result.AttributesMerged++
+		}
+	}
+
+	return nil
+}
+
+// mergeNodes moves the source's live nodes into the target, resolving URLs the
+// target already holds according to the merge's conflict mode
+func (r *domainMergeRepository) mergeNodes(ctx context.Context, tx *sql.Tx, merge *repository.DomainMerge, result *repository.DomainMergeResult) error {
+	type sourceNode struct {
+		id          int
+		url         string
+		title       sql.NullString
+		description sql.NullString
+	}
+
+	rows, err := tx.QueryContext(ctx, `SELECT n.id, n.content, n.title, n.description FROM nodes n WHERE n.domain_id = ? AND `+activeNodeCondition+` ORDER BY n.id`,
+		merge.SourceDomainID, activeAt())
+	if err != nil {
+		return fmt.Errorf("failed to list nodes: %w", err)
+	}
+	var nodes []sourceNode
+	for rows.Next() {
+		var node sourceNode
+		if err := rows.Scan(&node.id, &node.url, &node.title, &node.description); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan node: %w", err)
+		}
+		nodes = append(nodes, node)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	for _, node := range nodes {
+		if err := purgeExpiredURL(ctx, tx, node.url, merge.TargetDomainID); err != nil {
+			return err
+		}
+
+		// No expiry filter: the purge above removed any expired holder of the URL
+		var targetID int
+		err := tx.QueryRowContext(ctx, `SELECT id FROM nodes WHERE content = ? AND domain_id = ?`, node.url, merge.TargetDomainID).Scan(&targetID)
+		switch {
+		case err == sql.ErrNoRows:
+			if _, err := tx.ExecContext(ctx, `UPDATE nodes SET domain_id = ?, updated_at = ? WHERE id = ?`,
+				merge.TargetDomainID, time.Now(), node.id); err != nil {
+				return fmt.Errorf("failed to move node %d: %w", node.id, err)
+			}
+			result.NodesMoved++
+			result.UpdatedNodeIDs = append(result.UpdatedNodeIDs, node.id)
+		case err != nil:
+			return fmt.Errorf("failed to look up node '%s': %w", node.url, err)
+		case merge.Conflict == repository.ImportConflictFail:
+			return fmt.Errorf("URL '%s' exists in both domains: %w", node.url, repository.ErrDuplicateKey)
+		case merge.Conflict == repository.ImportConflictSkip:
+			if err := repointLinks(ctx, tx, node.id, targetID, result); err != nil {
+				return err
+			}
+			result.NodesSkipped++
+			result.RemovedNodes = append(result.RemovedNodes, repository.RemovedMergeNode{ID: node.id, URL: node.url, KeptByID: targetID})
+		default:
+			if err := repointLinks(ctx, tx, node.id, targetID, result); err != nil {
+				return err
+			}
+			// The target node keeps its ID, so its dependencies and connections survive
+			if _, err := tx.ExecContext(ctx, `UPDATE nodes SET title = ?, description = ?, updated_at = ? WHERE id = ?`,
+				node.title, node.description, time.Now(), targetID); err != nil {
+				return fmt.Errorf("failed to update node '%s': %w", node.url, err)
+			}
+			if _, err := tx.ExecContext(ctx, `DELETE FROM node_attributes WHERE node_id = ?`, targetID); err != nil {
+				return fmt.Errorf("failed to delete attributes of node '%s': %w", node.url, err)
+			}
+			if _, err := tx.ExecContext(ctx, `UPDATE node_attributes SET node_id = ? WHERE node_id = ?`, targetID, node.id); err != nil {
+				return fmt.Errorf("failed to copy attributes of node '%s': %w", node.url, err)
+			}
+			result.NodesOverwritten++
+			result.UpdatedNodeIDs = append(result.UpdatedNodeIDs, targetID)
+			result.RemovedNodes = append(result.RemovedNodes, repository.RemovedMergeNode{ID: node.id, URL: node.url, KeptByID: targetID})
+		}
+	}
+
+	return nil
+}
+
+// nodeLinkTables are the tables linking two nodes, with their two node columns
+var nodeLinkTables = []struct{ table, from, to string }{
+	{"node_connections", "source_node_id", "target_node_id"},
+	{"node_dependencies", "dependent_node_id", "dependency_node_id"},
+}
+
+// repointLinks moves the connections and dependencies of a source node the merge
+// removes onto the target node keeping its URL. Links between the two nodes, and
+// those the target node already has, are dropped with the source node.
+func repointLinks(ctx context.Context, tx *sql.Tx, sourceID, targetID int, result *repository.DomainMergeResult) error {
+	for _, t := range nodeLinkTables {
+		res, err := tx.ExecContext(ctx, `DELETE FROM `+t.table+` WHERE (`+t.from+` = ? AND `+t.to+` = ?) OR (`+t.from+` = ? AND `+t.to+` = ?)`,
+			sourceID, targetID, targetID, sourceID)
+		if err != nil {
+			return fmt.Errorf("failed to drop links of node %d: %w", sourceID, err)
+		}
+		dropped, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to drop links of node %d: %w", sourceID, err)
+		}
+		result.LinksDropped += int(dropped)
+
+		// OR IGNORE leaves a link the target node already has on the source node
+		for _, column := range []string{t.from, t.to} {
+			res, err := tx.ExecContext(ctx, `UPDATE OR IGNORE `+t.table+` SET `+column+` = ? WHERE `+column+` = ?`, targetID, sourceID)
+			if err != nil {
+				return fmt.Errorf("failed to repoint links of node %d: %w", sourceID, err)
+			}
+			repointed, err := res.RowsAffected()
+			if err != nil {
+				return fmt.Errorf("failed to repoint links of node %d: %w", sourceID, err)
+			}
+			result.LinksRepointed += int(repointed)
+		}
+
+		var left int
+		err = tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM `+t.table+` WHERE `+t.from+` = ? OR `+t.to+` = ?`, sourceID, sourceID).Scan(&left)
+		if err != nil {
+			return fmt.Errorf("failed to count links of node %d: %w", sourceID, err)
+		}
+		result.LinksDropped += left
+	}
+	return nil
+}
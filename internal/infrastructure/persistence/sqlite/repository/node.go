@@ -4,13 +4,24 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"strings"
+	"time"
 	"url-db/internal/constants"
 	"url-db/internal/domain/entity"
 	"url-db/internal/domain/repository"
 	"url-db/internal/infrastructure/persistence/sqlite/mapper"
 )
 
+// activeNodeCondition excludes expired nodes; bind the current time from activeAt()
+const activeNodeCondition = "(n.expires_at IS NULL OR n.expires_at > ?)"
+
+// activeAt returns the time expiry is checked against. Expiry times are stored in
+// UTC, so comparing their text form orders them correctly.
+func activeAt() time.Time {
+	return time.Now().UTC()
+}
+
 type nodeRepository struct {
 	db *sql.DB
 }
@@ -20,10 +31,32 @@ func NewNodeRepository(db *sql.DB) repository.NodeRepository {
 	return &nodeRepository{db: db}
 }
 
+// purgeExpiredURLQuery deletes an expired node occupying a URL in a domain
+const purgeExpiredURLQuery = `DELETE FROM nodes WHERE content = ? AND domain_id = ? AND expires_at IS NOT NULL AND expires_at <= ?`
+
+// nodeExecer runs statements on the database or inside a transaction
+type nodeExecer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// purgeExpiredURL deletes an expired node occupying the URL in the domain. An
+// expired node still holds its URL until the sweeper runs, so a new holder
+// replaces it.
+func purgeExpiredURL(ctx context.Context, db nodeExecer, url string, domainID int) error {
+	if _, err := db.ExecContext(ctx, purgeExpiredURLQuery, url, domainID, activeAt()); err != nil {
+		return fmt.Errorf("failed to purge expired node: %w", err)
+	}
+	return nil
+}
+
 func (r *nodeRepository) Create(ctx context.Context, node *entity.Node) error {
 	dbModel := mapper.FromNodeEntity(node)
 
-	query := `INSERT INTO nodes (content, domain_id, title, description, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)`
+	if err := purgeExpiredURL(ctx, r.db, dbModel.Content, dbModel.DomainID); err != nil {
+		return err
+	}
+
+	query := `INSERT INTO nodes (content, domain_id, title, description, created_at, updated_at, expires_at) VALUES (?, ?, ?, ?, ?, ?, ?)`
 	result, err := r.db.ExecContext(ctx, query,
 		dbModel.Content,
 		dbModel.DomainID,
@@ -31,6 +64,7 @@ func (r *nodeRepository) Create(ctx context.Context, node *entity.Node) error {
 		dbModel.Description,
 		dbModel.CreatedAt,
 		dbModel.UpdatedAt,
+		dbModel.ExpiresAt,
 	)
 	if err != nil {
 		return err
@@ -46,11 +80,79 @@ func (r *nodeRepository) Create(ctx context.Context, node *entity.Node) error {
 	return nil
 }
 
+func (r *nodeRepository) CreateBatch(ctx context.Context, nodes []*entity.Node, atomic bool) ([]error, error) {
+	itemErrors := make([]error, len(nodes))
+	if len(nodes) == 0 {
+		return itemErrors, nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	purgeStmt, err := tx.PrepareContext(ctx, purgeExpiredURLQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare expired node purge: %w", err)
+	}
+	defer purgeStmt.Close()
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO nodes (content, domain_id, title, description, created_at, updated_at, expires_at) VALUES (?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare insert: %w", err)
+	}
+	defer stmt.Close()
+
+	now := activeAt()
+
+	// IDs are only assigned once the transaction commits
+	ids := make([]int64, len(nodes))
+	for i, node := range nodes {
+		dbModel := mapper.FromNodeEntity(node)
+		_, err := purgeStmt.ExecContext(ctx, dbModel.Content, dbModel.DomainID, now)
+		var result sql.Result
+		if err == nil {
+			result, err = stmt.ExecContext(ctx,
+				dbModel.Content,
+				dbModel.DomainID,
+				dbModel.Title,
+				dbModel.Description,
+				dbModel.CreatedAt,
+				dbModel.UpdatedAt,
+				dbModel.ExpiresAt,
+			)
+		}
+		if err == nil {
+			ids[i], err = result.LastInsertId()
+		}
+		if err != nil {
+			itemErrors[i] = err
+			if atomic {
+				return itemErrors, fmt.Errorf("failed to create node %d: %w", i, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	for i, node := range nodes {
+		if itemErrors[i] == nil {
+			node.SetID(int(ids[i]))
+		}
+	}
+
+	return itemErrors, nil
+}
+
 func (r *nodeRepository) GetByID(ctx context.Context, id int) (*entity.Node, error) {
 	var dbRow mapper.DatabaseNode
 
-	query := `SELECT id, content, domain_id, title, description, created_at, updated_at FROM nodes WHERE id = ?`
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	query := `SELECT n.id, n.content, n.domain_id, n.title, n.description, n.created_at, n.updated_at, n.expires_at, n.archived_at, n.version
+			  FROM nodes n WHERE n.id = ? AND ` + activeNodeCondition
+	err := r.db.QueryRowContext(ctx, query, id, activeAt()).Scan(
 		&dbRow.ID,
 		&dbRow.Content,
 		&dbRow.DomainID,
@@ -58,6 +160,9 @@ func (r *nodeRepository) GetByID(ctx context.Context, id int) (*entity.Node, err
 		&dbRow.Description,
 		&dbRow.CreatedAt,
 		&dbRow.UpdatedAt,
+		&dbRow.ExpiresAt,
+		&dbRow.ArchivedAt,
+		&dbRow.Version,
 	)
 
 	if err == sql.ErrNoRows {
@@ -73,11 +178,11 @@ func (r *nodeRepository) GetByID(ctx context.Context, id int) (*entity.Node, err
 func (r *nodeRepository) GetByURL(ctx context.Context, url, domainName string) (*entity.Node, error) {
 	var dbRow mapper.DatabaseNode
 
-	query := `SELECT n.id, n.content, n.domain_id, n.title, n.description, n.created_at, n.updated_at 
+	query := `SELECT n.id, n.content, n.domain_id, n.title, n.description, n.created_at, n.updated_at, n.expires_at, n.archived_at, n.version
 			  FROM nodes n 
 			  JOIN domains d ON n.domain_id = d.id 
-			  WHERE n.content = ? AND d.name = ?`
-	err := r.db.QueryRowContext(ctx, query, url, domainName).Scan(
+			  WHERE n.content = ? AND d.name = ? AND ` + activeNodeCondition
+	err := r.db.QueryRowContext(ctx, query, url, domainName, activeAt()).Scan(
 		&dbRow.ID,
 		&dbRow.Content,
 		&dbRow.DomainID,
@@ -85,6 +190,9 @@ func (r *nodeRepository) GetByURL(ctx context.Context, url, domainName string) (
 		&dbRow.Description,
 		&dbRow.CreatedAt,
 		&dbRow.UpdatedAt,
+		&dbRow.ExpiresAt,
+		&dbRow.ArchivedAt,
+		&dbRow.Version,
 	)
 
 	if err == sql.ErrNoRows {
@@ -97,11 +205,253 @@ func (r *nodeRepository) GetByURL(ctx context.Context, url, domainName string) (
 	return mapper.ToNodeEntity(&dbRow), nil
 }
 
-func (r *nodeRepository) List(ctx context.Context, domainName string, page, size int) ([]*entity.Node, int, error) {
+func (r *nodeRepository) GetByURLs(ctx context.Context, urls []string, domainName string) ([]*entity.Node, error) {
+	if len(urls) == 0 {
+		return []*entity.Node{}, nil
+	}
+
+	// Build query with placeholders
+	placeholders := make([]string, len(urls))
+	args := make([]interface{}, 0, len(urls)+2)
+	args = append(args, domainName, activeAt())
+	for i, url := range urls {
+		placeholders[i] = "?"
+		args = append(args, url)
+	}
+
+	query := `SELECT n.id, n.content, n.domain_id, n.title, n.description, n.created_at, n.updated_at, n.expires_at, n.archived_at, n.version
+			  FROM nodes n
+			  JOIN domains d ON n.domain_id = d.id
+			  WHERE d.name = ? AND ` + activeNodeCondition + ` AND n.content IN (` + strings.Join(placeholders, ",") + `)`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var nodes []*entity.Node
+	for rows.Next() {
+		var dbRow mapper.DatabaseNode
+		err := rows.Scan(
+			&dbRow.ID,
+			&dbRow.Content,
+			&dbRow.DomainID,
+			&dbRow.Title,
+			&dbRow.Description,
+			&dbRow.CreatedAt,
+			&dbRow.UpdatedAt,
+			&dbRow.ExpiresAt,
+			&dbRow.ArchivedAt,
+			&dbRow.Version,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if node := mapper.ToNodeEntity(&dbRow); node != nil {
+			nodes = append(nodes, node)
+		}
+	}
+
+	return nodes, rows.Err()
+}
+
+func (r *nodeRepository) List(ctx context.Context, domainName string, page, size int, includeArchived bool) ([]*entity.Node, int, error) {
+	return r.listWhere(ctx, domainName, "", nil, page, size, includeArchived)
+}
+
+// Search lists a domain's nodes whose URL, title or description matches every word of
+// query, through the nodes_fts index when the database has one and LIKE otherwise
+func (r *nodeRepository) Search(ctx context.Context, domainName, query string, page, size int, includeArchived bool) ([]*entity.Node, int, error) {
+	return r.SearchInTimeRange(ctx, domainName, query, repository.NodeTimeRange{}, page, size, includeArchived)
+}
+
+// SearchInTimeRange is Search limited to nodes created and updated within timeRange
+func (r *nodeRepository) SearchInTimeRange(ctx context.Context, domainName, query string, timeRange repository.NodeTimeRange, page, size int, includeArchived bool) ([]*entity.Node, int, error) {
+	conditions, args := timeRangeConditions(timeRange)
+
+	words := strings.Fields(query)
+	if len(words) == 0 {
+		return r.listWhere(ctx, domainName, strings.Join(conditions, " AND "), args, page, size, includeArchived)
+	}
+
+	indexed, err := searchIndexExists(ctx, r.db)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if indexed {
+		// Quote each word so characters like ':' and '-' are not read as FTS operators
+		phrases := make([]string, len(words))
+		for i, word := range words {
+			phrases[i] = `"` + strings.ReplaceAll(word, `"`, `""`) + `"`
+		}
+		conditions = append(conditions, "n.id IN (SELECT docid FROM nodes_fts WHERE nodes_fts MATCH ?)")
+		args = append(args, strings.Join(phrases, " "))
+		return r.listWhere(ctx, domainName, strings.Join(conditions, " AND "), args, page, size, includeArchived)
+	}
+
+	// Match each word literally inside LIKE
+	escaper := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	for _, word := range words {
+		pattern := "%" + escaper.Replace(word) + "%"
+		conditions = append(conditions, `(n.content LIKE ? ESCAPE '\' OR n.title LIKE ? ESCAPE '\' OR n.description LIKE ? ESCAPE '\')`)
+		args = append(args, pattern, pattern, pattern)
+	}
+	return r.listWhere(ctx, domainName, strings.Join(conditions, " AND "), args, page, size, includeArchived)
+}
+
+// timeRangeConditions returns the conditions and arguments limiting nodes to timeRange.
+// Timestamps are stored with the offset of the writer's time zone, so they are compared
+// through julianday(), which converts both sides to UTC.
+func timeRangeConditions(timeRange repository.NodeTimeRange) ([]string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	for _, bound := range []struct {
+		column   string
+		operator string
+		at       *time.Time
+	}{
+		{"n.created_at", ">=", timeRange.CreatedAfter},
+		{"n.created_at", "<", timeRange.CreatedBefore},
+		{"n.updated_at", ">=", timeRange.UpdatedAfter},
+		{"n.updated_at", "<", timeRange.UpdatedBefore},
+	} {
+		if bound.at == nil {
+			continue
+		}
+		conditions = append(conditions, fmt.Sprintf("julianday(%s) %s julianday(?)", bound.column, bound.operator))
+		args = append(args, bound.at.UTC().Format("2006-01-02 15:04:05.999999999"))
+	}
+	return conditions, args
+}
+
+// Global search weights per word: a word in the title counts most, then the URL,
+// then the description or an attribute value
+const (
+	searchTitleWeight       = 4
+	searchURLWeight         = 2
+	searchDescriptionWeight = 1
+	searchAttributeWeight   = 1
+)
+
+// GlobalSearch matches each word against the nodes_fts index when the database has
+// one and LIKE otherwise, and against attribute values with LIKE. The score counts
+// substring hits per field, so it ranks the same either way.
+func (r *nodeRepository) GlobalSearch(ctx context.Context, domainName, query string, page, size int) ([]repository.SearchHit, int, error) {
+	words := strings.Fields(query)
+	if len(words) == 0 {
+		return nil, 0, fmt.Errorf("%w: search query is empty", repository.ErrInvalidInput)
+	}
+
+	indexed, err := searchIndexExists(ctx, r.db)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	attributeMatch := `EXISTS (SELECT 1 FROM node_attributes na WHERE na.node_id = n.id AND na.value LIKE ? ESCAPE '\')`
+	escaper := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+	where := activeNodeCondition + " AND n.archived_at IS NULL"
+	whereArgs := []interface{}{activeAt()}
+	if domainName != "" {
+		where += " AND d.name = ?"
+		whereArgs = append(whereArgs, domainName)
+	}
+
+	scores := make([]string, len(words))
+	var scoreArgs []interface{}
+	for i, word := range words {
+		pattern := "%" + escaper.Replace(word) + "%"
+
+		if indexed {
+			// Quote the word so characters like ':' and '-' are not read as FTS operators
+			where += " AND (n.id IN (SELECT docid FROM nodes_fts WHERE nodes_fts MATCH ?) OR " + attributeMatch + ")"
+			whereArgs = append(whereArgs, `"`+strings.ReplaceAll(word, `"`, `""`)+`"`, pattern)
+		} else {
+			where += ` AND (n.content LIKE ? ESCAPE '\' OR n.title LIKE ? ESCAPE '\' OR n.description LIKE ? ESCAPE '\' OR ` + attributeMatch + ")"
+			whereArgs = append(whereArgs, pattern, pattern, pattern, pattern)
+		}
+
+		scores[i] = fmt.Sprintf(`(IFNULL(n.title, '') LIKE ? ESCAPE '\') * %d + (n.content LIKE ? ESCAPE '\') * %d + (IFNULL(n.description, '') LIKE ? ESCAPE '\') * %d + %s * %d`,
+			searchTitleWeight, searchURLWeight, searchDescriptionWeight, attributeMatch, searchAttributeWeight)
+		scoreArgs = append(scoreArgs, pattern, pattern, pattern, pattern)
+	}
+
+	var totalCount int
+	countQuery := `SELECT COUNT(*) FROM nodes n JOIN domains d ON n.domain_id = d.id WHERE ` + where
+	if err := r.db.QueryRowContext(ctx, countQuery, whereArgs...).Scan(&totalCount); err != nil {
+		return nil, 0, fmt.Errorf("failed to count search results: %w", err)
+	}
+
+	offset := (page - 1) * size
+	selectQuery := `SELECT n.id, n.content, n.domain_id, n.title, n.description, n.created_at, n.updated_at, n.expires_at, n.archived_at, n.version,
+			  d.name, ` + strings.Join(scores, " + ") + ` AS score
+			  FROM nodes n
+			  JOIN domains d ON n.domain_id = d.id
+			  WHERE ` + where + `
+			  ORDER BY score DESC, n.created_at DESC, n.id DESC
+			  LIMIT ? OFFSET ?`
+	args := append(append(scoreArgs, whereArgs...), size, offset)
+	rows, err := r.db.QueryContext(ctx, selectQuery, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search nodes: %w", err)
+	}
+	defer rows.Close()
+
+	var hits []repository.SearchHit
+	for rows.Next() {
+		var dbRow mapper.DatabaseNode
+		var hit repository.SearchHit
+		err := rows.Scan(
+			&dbRow.ID,
+			&dbRow.Content,
+			&dbRow.DomainID,
+			&dbRow.Title,
+			&dbRow.Description,
+			&dbRow.CreatedAt,
+			&dbRow.UpdatedAt,
+			&dbRow.ExpiresAt,
+			&dbRow.ArchivedAt,
+			&dbRow.Version,
+			&hit.DomainName,
+			&hit.Score,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan search result: %w", err)
+		}
+
+		if hit.Node = mapper.ToNodeEntity(&dbRow); hit.Node != nil {
+			hits = append(hits, hit)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to iterate search results: %w", err)
+	}
+
+	return hits, totalCount, nil
+}
+
+// listWhere pages through a domain's active nodes, newest first, that also satisfy
+// condition (bound to args) unless it is empty
+func (r *nodeRepository) listWhere(ctx context.Context, domainName, condition string, args []interface{}, page, size int, includeArchived bool) ([]*entity.Node, int, error) {
 	// Get total count
+	now := activeAt()
+
+	where := "d.name = ? AND " + activeNodeCondition
+	whereArgs := append([]interface{}{domainName, now}, args...)
+	if condition != "" {
+		where += " AND " + condition
+	}
+	if !includeArchived {
+		where += " AND n.archived_at IS NULL"
+	}
+
 	var totalCount int
-	countQuery := `SELECT COUNT(*) FROM nodes n JOIN domains d ON n.domain_id = d.id WHERE d.name = ?`
-	err := r.db.QueryRowContext(ctx, countQuery, domainName).Scan(&totalCount)
+	countQuery := `SELECT COUNT(*) FROM nodes n JOIN domains d ON n.domain_id = d.id WHERE ` + where
+	err := r.db.QueryRowContext(ctx, countQuery, whereArgs...).Scan(&totalCount)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -110,13 +460,13 @@ func (r *nodeRepository) List(ctx context.Context, domainName string, page, size
 	offset := (page - 1) * size
 
 	// Get nodes with pagination
-	query := `SELECT n.id, n.content, n.domain_id, n.title, n.description, n.created_at, n.updated_at 
+	query := `SELECT n.id, n.content, n.domain_id, n.title, n.description, n.created_at, n.updated_at, n.expires_at, n.archived_at, n.version
 			  FROM nodes n 
 			  JOIN domains d ON n.domain_id = d.id 
-			  WHERE d.name = ? 
+			  WHERE ` + where + `
 			  ORDER BY n.created_at DESC 
 			  LIMIT ? OFFSET ?`
-	rows, err := r.db.QueryContext(ctx, query, domainName, size, offset)
+	rows, err := r.db.QueryContext(ctx, query, append(whereArgs, size, offset)...)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -133,6 +483,9 @@ func (r *nodeRepository) List(ctx context.Context, domainName string, page, size
 			&dbRow.Description,
 			&dbRow.CreatedAt,
 			&dbRow.UpdatedAt,
+			&dbRow.ExpiresAt,
+			&dbRow.ArchivedAt,
+			&dbRow.Version,
 		)
 		if err != nil {
 			return nil, 0, err
@@ -147,15 +500,20 @@ func (r *nodeRepository) List(ctx context.Context, domainName string, page, size
 	return nodes, totalCount, nil
 }
 
+// Update saves the node's title and description. A node loaded from storage is only
+// saved while the stored row is still at the version it was loaded with; the
+// nodes_version trigger then bumps the version, which the node is given.
 func (r *nodeRepository) Update(ctx context.Context, node *entity.Node) error {
 	dbModel := mapper.FromNodeEntity(node)
 
-	query := `UPDATE nodes SET title = ?, description = ?, updated_at = ? WHERE id = ?`
+	query := `UPDATE nodes SET title = ?, description = ?, updated_at = ? WHERE id = ? AND (? = 0 OR version = ?)`
 	result, err := r.db.ExecContext(ctx, query,
 		dbModel.Title,
 		dbModel.Description,
 		dbModel.UpdatedAt,
 		dbModel.ID,
+		dbModel.Version,
+		dbModel.Version,
 	)
 	if err != nil {
 		return err
@@ -167,9 +525,20 @@ func (r *nodeRepository) Update(ctx context.Context, node *entity.Node) error {
 	}
 
 	if rowsAffected == 0 {
-		return errors.New(constants.ErrNodeNotFound)
+		var current int
+		err := r.db.QueryRowContext(ctx, `SELECT version FROM nodes WHERE id = ?`, dbModel.ID).Scan(&current)
+		if err == sql.ErrNoRows {
+			return errors.New(constants.ErrNodeNotFound)
+		}
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("node %d is at version %d, not %d: %w", dbModel.ID, current, dbModel.Version, repository.ErrConcurrencyConflict)
 	}
 
+	if dbModel.Version > 0 {
+		node.SetVersion(dbModel.Version + 1)
+	}
 	return nil
 }
 
@@ -192,10 +561,35 @@ func (r *nodeRepository) Delete(ctx context.Context, id int) error {
 	return nil
 }
 
+// SetArchivedAt archives a node at archivedAt, or restores it when archivedAt is nil
+func (r *nodeRepository) SetArchivedAt(ctx context.Context, id int, archivedAt *time.Time) error {
+	var value interface{}
+	if archivedAt != nil {
+		value = archivedAt.UTC()
+	}
+
+	query := `UPDATE nodes SET archived_at = ? WHERE id = ?`
+	result, err := r.db.ExecContext(ctx, query, value, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return errors.New(constants.ErrNodeNotFound)
+	}
+
+	return nil
+}
+
 func (r *nodeRepository) Exists(ctx context.Context, url, domainName string) (bool, error) {
 	var exists int
-	query := `SELECT 1 FROM nodes n JOIN domains d ON n.domain_id = d.id WHERE n.content = ? AND d.name = ? LIMIT 1`
-	err := r.db.QueryRowContext(ctx, query, url, domainName).Scan(&exists)
+	query := `SELECT 1 FROM nodes n JOIN domains d ON n.domain_id = d.id WHERE n.content = ? AND d.name = ? AND ` + activeNodeCondition + ` LIMIT 1`
+	err := r.db.QueryRowContext(ctx, query, url, domainName, activeAt()).Scan(&exists)
 
 	if err == sql.ErrNoRows {
 		return false, nil
@@ -218,13 +612,14 @@ func (r *nodeRepository) GetBatch(ctx context.Context, ids []int) ([]*entity.Nod
 		placeholders[i] = "?"
 	}
 
-	query := `SELECT id, content, domain_id, title, description, created_at, updated_at FROM nodes WHERE id IN (` +
-		strings.Join(placeholders, ",") + `)`
+	query := `SELECT n.id, n.content, n.domain_id, n.title, n.description, n.created_at, n.updated_at, n.expires_at, n.archived_at, n.version
+			  FROM nodes n WHERE ` + activeNodeCondition + ` AND n.id IN (` + strings.Join(placeholders, ",") + `)`
 
 	// Convert ids to interface slice
-	args := make([]interface{}, len(ids))
-	for i, id := range ids {
-		args[i] = id
+	args := make([]interface{}, 0, len(ids)+1)
+	args = append(args, activeAt())
+	for _, id := range ids {
+		args = append(args, id)
 	}
 
 	rows, err := r.db.QueryContext(ctx, query, args...)
@@ -244,6 +639,9 @@ func (r *nodeRepository) GetBatch(ctx context.Context, ids []int) ([]*entity.Nod
 			&dbRow.Description,
 			&dbRow.CreatedAt,
 			&dbRow.UpdatedAt,
+			&dbRow.ExpiresAt,
+			&dbRow.ArchivedAt,
+			&dbRow.Version,
 		)
 		if err != nil {
 			return nil, err
@@ -289,90 +687,21 @@ func (r *nodeRepository) GetDomainByNodeID(ctx context.Context, nodeID int) (*en
 // FilterByAttributes retrieves nodes by domain with attribute filters
 func (r *nodeRepository) FilterByAttributes(ctx context.Context, domainName string, filters []repository.AttributeFilter, page, size int) ([]*entity.Node, int, error) {
 	if len(filters) == 0 {
-		// No filters, return regular list
-		return r.List(ctx, domainName, page, size)
+		// No filters, return regular list; filtering does not look at archiving
+		return r.List(ctx, domainName, page, size, true)
 	}
 
-	// Build the SQL query with JOIN for each filter
-	var joins []string
-	var conditions []string
-	var args []interface{}
-	argIndex := 1
-
-	// Add domain condition
-	conditions = append(conditions, "d.name = ?")
-	args = append(args, domainName)
-	argIndex++
-
-	// Add a JOIN and condition for each filter
-	for i, filter := range filters {
-		joinAlias := "na" + string(rune('0'+i))
-		attrAlias := "a" + string(rune('0'+i))
-
-		joins = append(joins,
-			"INNER JOIN node_attributes "+joinAlias+" ON n.id = "+joinAlias+".node_id")
-		joins = append(joins,
-			"INNER JOIN attributes "+attrAlias+" ON "+joinAlias+".attribute_id = "+attrAlias+".id")
-
-		// Add attribute name condition
-		conditions = append(conditions, attrAlias+".name = ?")
-		args = append(args, filter.Name)
-		argIndex++
-
-		// Add value condition based on operator
-		switch strings.ToLower(filter.Operator) {
-		case "equals", "":
-			conditions = append(conditions, joinAlias+".value = ?")
-			args = append(args, filter.Value)
-		case "contains":
-			conditions = append(conditions, joinAlias+".value LIKE ?")
-			args = append(args, "%"+filter.Value+"%")
-		case "starts_with":
-			conditions = append(conditions, joinAlias+".value LIKE ?")
-			args = append(args, filter.Value+"%")
-		case "ends_with":
-			conditions = append(conditions, joinAlias+".value LIKE ?")
-			args = append(args, "%"+filter.Value)
-		default:
-			// Default to equals for invalid operators
-			conditions = append(conditions, joinAlias+".value = ?")
-			args = append(args, filter.Value)
-		}
-		argIndex++
-	}
-
-	// Build the complete query
-	baseQuery := `
-		SELECT DISTINCT n.id, n.content, n.domain_id, n.title, n.description, n.created_at, n.updated_at
-		FROM nodes n
-		INNER JOIN domains d ON n.domain_id = d.id
-		` + strings.Join(joins, " ") + `
-		WHERE ` + strings.Join(conditions, " AND ") + `
-		ORDER BY n.created_at DESC
-	`
-
-	// Count query for total
-	countQuery := `
-		SELECT COUNT(DISTINCT n.id)
-		FROM nodes n
-		INNER JOIN domains d ON n.domain_id = d.id
-		` + strings.Join(joins, " ") + `
-		WHERE ` + strings.Join(conditions, " AND ")
+	queries := filterByAttributesQueries(domainName, filters, page, size)
 
 	// Get total count
 	var total int
-	err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total)
+	err := r.db.QueryRowContext(ctx, queries.count, queries.countArgs...).Scan(&total)
 	if err != nil {
 		return nil, 0, err
 	}
 
-	// Add pagination
-	offset := (page - 1) * size
-	limitQuery := baseQuery + " LIMIT ? OFFSET ?"
-	args = append(args, size, offset)
-
 	// Execute the main query
-	rows, err := r.db.QueryContext(ctx, limitQuery, args...)
+	rows, err := r.db.QueryContext(ctx, queries.page, queries.pageArgs...)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -389,6 +718,9 @@ func (r *nodeRepository) FilterByAttributes(ctx context.Context, domainName stri
 			&dbRow.Description,
 			&dbRow.CreatedAt,
 			&dbRow.UpdatedAt,
+			&dbRow.ExpiresAt,
+			&dbRow.ArchivedAt,
+			&dbRow.Version,
 		)
 		if err != nil {
 			return nil, 0, err
@@ -407,12 +739,219 @@ func (r *nodeRepository) FilterByAttributes(ctx context.Context, domainName stri
 	return nodes, total, nil
 }
 
+// filterQueries are the queries FilterByAttributes runs: a page of nodes and their total
+type filterQueries struct {
+	page      string
+	pageArgs  []interface{}
+	count     string
+	countArgs []interface{}
+}
+
+// filterByAttributesQueries builds the page and count queries for the domain's nodes
+// matching filters
+func filterByAttributesQueries(domainName string, filters []repository.AttributeFilter, page, size int) filterQueries {
+	filterCondition, filterArgs := attributeFilterCondition(filters)
+
+	// Domain condition first, then the filters
+	conditions := []string{"d.name = ?", activeNodeCondition}
+	if filterCondition != "" {
+		conditions = append(conditions, filterCondition)
+	}
+	args := append([]interface{}{domainName, activeAt()}, filterArgs...)
+
+	// Build the complete query, with pagination
+	pageQuery := `
+		SELECT DISTINCT n.id, n.content, n.domain_id, n.title, n.description, n.created_at, n.updated_at, n.expires_at, n.archived_at, n.version
+		FROM nodes n
+		INNER JOIN domains d ON n.domain_id = d.id
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		ORDER BY n.created_at DESC
+		LIMIT ? OFFSET ?`
+	offset := (page - 1) * size
+	pageArgs := append(append([]interface{}{}, args...), size, offset)
+
+	// Count query for total
+	countQuery := `
+		SELECT COUNT(DISTINCT n.id)
+		FROM nodes n
+		INNER JOIN domains d ON n.domain_id = d.id
+		WHERE ` + strings.Join(conditions, " AND ")
+
+	return filterQueries{page: pageQuery, pageArgs: pageArgs, count: countQuery, countArgs: args}
+}
+
+// ExplainFilterByAttributes returns the query plans of the queries FilterByAttributes
+// runs for the same arguments. Without filters FilterByAttributes lists the domain's
+// nodes, but the plans are of the filter queries.
+func (r *nodeRepository) ExplainFilterByAttributes(ctx context.Context, domainName string, filters []repository.AttributeFilter, page, size int) ([]repository.QueryPlan, error) {
+	queries := filterByAttributesQueries(domainName, filters, page, size)
+
+	plans := []repository.QueryPlan{
+		{Name: "page", Query: strings.TrimSpace(queries.page)},
+		{Name: "count", Query: strings.TrimSpace(queries.count)},
+	}
+	argsByPlan := [][]interface{}{queries.pageArgs, queries.countArgs}
+
+	for i := range plans {
+		rows, err := r.db.QueryContext(ctx, "EXPLAIN QUERY PLAN "+plans[i].Query, argsByPlan[i]...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to explain %s query: %w", plans[i].Name, err)
+		}
+
+		for rows.Next() {
+			var row repository.QueryPlanRow
+			var notUsed int
+			if err := rows.Scan(&row.ID, &row.Parent, &notUsed, &row.Detail); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to read %s query plan: %w", plans[i].Name, err)
+			}
+			plans[i].Rows = append(plans[i].Rows, row)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read %s query plan: %w", plans[i].Name, err)
+		}
+	}
+
+	return plans, nil
+}
+
+// numericFilterComparisons maps numeric filter operators to their SQL comparison
+var numericFilterComparisons = map[string]string{"gt": ">", "gte": ">=", "lt": "<", "lte": "<="}
+
+// attributeFilterCondition builds the WHERE condition of a filter list on nodes n,
+// ANDing the filters; it is empty without filters. Groups are built recursively.
+func attributeFilterCondition(filters []repository.AttributeFilter) (string, []interface{}) {
+	return attributeFilterGroupCondition(filters, repository.FilterLogicAnd)
+}
+
+// attributeFilterGroupCondition combines the conditions of filters with logic
+func attributeFilterGroupCondition(filters []repository.AttributeFilter, logic string) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	for _, filter := range filters {
+		var condition string
+		var filterArgs []interface{}
+		if filter.IsGroup() {
+			condition, filterArgs = attributeFilterGroupCondition(filter.Filters, filter.Logic)
+		} else {
+			condition, filterArgs = attributeFilterValueCondition(filter)
+		}
+		if condition == "" {
+			continue
+		}
+		conditions = append(conditions, condition)
+		args = append(args, filterArgs...)
+	}
+
+	if len(conditions) == 0 {
+		return "", nil
+	}
+
+	separator := " AND "
+	if strings.ToLower(logic) == repository.FilterLogicOr {
+		separator = " OR "
+	}
+	return "(" + strings.Join(conditions, separator) + ")", args
+}
+
+// attributeFilterValueCondition matches nodes holding a value of the filter's
+// attribute that satisfies its operator. Numeric operators compare the stored text
+// as REAL.
+func attributeFilterValueCondition(filter repository.AttributeFilter) (string, []interface{}) {
+	args := []interface{}{filter.Name}
+
+	var valueCondition string
+	switch strings.ToLower(filter.Operator) {
+	case "equals", "":
+		valueCondition = "fna.value = ?"
+		args = append(args, filter.Value)
+	case "contains":
+		valueCondition = "fna.value LIKE ?"
+		args = append(args, "%"+filter.Value+"%")
+	case "starts_with":
+		valueCondition = "fna.value LIKE ?"
+		args = append(args, filter.Value+"%")
+	case "ends_with":
+		valueCondition = "fna.value LIKE ?"
+		args = append(args, "%"+filter.Value)
+	case "gt", "gte", "lt", "lte":
+		comparison := numericFilterComparisons[strings.ToLower(filter.Operator)]
+		valueCondition = "CAST(fna.value AS REAL) " + comparison + " CAST(? AS REAL)"
+		args = append(args, filter.Value)
+	case "between":
+		valueCondition = "CAST(fna.value AS REAL) BETWEEN CAST(? AS REAL) AND CAST(? AS REAL)"
+		args = append(args, filter.Value, filter.ValueTo)
+	default:
+		// Default to equals for invalid operators
+		valueCondition = "fna.value = ?"
+		args = append(args, filter.Value)
+	}
+
+	return `EXISTS (
+			SELECT 1 FROM node_attributes fna
+			INNER JOIN attributes fa ON fna.attribute_id = fa.id
+			WHERE fna.node_id = n.id AND fa.name = ? AND ` + valueCondition + `
+		)`, args
+}
+
+// CountAttributeValues counts nodes per distinct value of each named attribute
+// among the domain's nodes matching filters
+func (r *nodeRepository) CountAttributeValues(ctx context.Context, domainName string, attributeNames []string, filters []repository.AttributeFilter) (map[string][]repository.AttributeValueCount, error) {
+	counts := make(map[string][]repository.AttributeValueCount)
+	if len(attributeNames) == 0 {
+		return counts, nil
+	}
+
+	filterCondition, filterArgs := attributeFilterCondition(filters)
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(attributeNames)), ",")
+	conditions := []string{"d.name = ?", activeNodeCondition, "ca.name IN (" + placeholders + ")"}
+	if filterCondition != "" {
+		conditions = append(conditions, filterCondition)
+	}
+	args := []interface{}{domainName, activeAt()}
+	for _, name := range attributeNames {
+		args = append(args, name)
+	}
+	args = append(args, filterArgs...)
+
+	query := `
+		SELECT ca.name, cna.value, COUNT(DISTINCT n.id) AS node_count
+		FROM nodes n
+		INNER JOIN domains d ON n.domain_id = d.id
+		INNER JOIN node_attributes cna ON n.id = cna.node_id
+		INNER JOIN attributes ca ON cna.attribute_id = ca.id
+		WHERE ` + strings.Join(conditions, " AND ") + `
+		GROUP BY ca.name, cna.value
+		ORDER BY ca.name, node_count DESC, cna.value
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		var valueCount repository.AttributeValueCount
+		if err := rows.Scan(&name, &valueCount.Value, &valueCount.Count); err != nil {
+			return nil, err
+		}
+		counts[name] = append(counts[name], valueCount)
+	}
+
+	return counts, rows.Err()
+}
+
 // CountByDomain counts nodes in a domain
 func (r *nodeRepository) CountByDomain(ctx context.Context, domainID int) (int, error) {
-	query := `SELECT COUNT(*) FROM nodes WHERE domain_id = ?`
+	query := `SELECT COUNT(*) FROM nodes n WHERE n.domain_id = ? AND ` + activeNodeCondition
 	
 	var count int
-	err := r.db.QueryRowContext(ctx, query, domainID).Scan(&count)
+	err := r.db.QueryRowContext(ctx, query, domainID, activeAt()).Scan(&count)
 	if err != nil {
 		return 0, err
 	}
@@ -420,17 +959,66 @@ func (r *nodeRepository) CountByDomain(ctx context.Context, domainID int) (int,
 	return count, nil
 }
 
+// GetByDomainOrdered retrieves a page of a domain's nodes ordered by created_at and id
+func (r *nodeRepository) GetByDomainOrdered(ctx context.Context, domainID int, order repository.SortOrder, offset, limit int) ([]*entity.Node, error) {
+	direction := "ASC"
+	if order == repository.SortDescending {
+		direction = "DESC"
+	}
+
+	query := `
+		SELECT n.id, n.content, n.domain_id, n.title, n.description, n.created_at, n.updated_at, n.expires_at, n.archived_at, n.version
+		FROM nodes n
+		WHERE n.domain_id = ? AND ` + activeNodeCondition + `
+		ORDER BY n.created_at ` + direction + `, n.id ` + direction + `
+		LIMIT ? OFFSET ?
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, domainID, activeAt(), limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var nodes []*entity.Node
+	for rows.Next() {
+		var dbRow mapper.DatabaseNode
+		err := rows.Scan(
+			&dbRow.ID,
+			&dbRow.Content,
+			&dbRow.DomainID,
+			&dbRow.Title,
+			&dbRow.Description,
+			&dbRow.CreatedAt,
+			&dbRow.UpdatedAt,
+			&dbRow.ExpiresAt,
+			&dbRow.ArchivedAt,
+			&dbRow.Version,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		node := mapper.ToNodeEntity(&dbRow)
+		if node != nil {
+			nodes = append(nodes, node)
+		}
+	}
+
+	return nodes, rows.Err()
+}
+
 // GetByDomainFromCursor retrieves nodes starting from a cursor position
 func (r *nodeRepository) GetByDomainFromCursor(ctx context.Context, domainID int, lastNodeID int, limit int) ([]*entity.Node, error) {
 	query := `
-		SELECT id, content, domain_id, title, description, created_at, updated_at
-		FROM nodes
-		WHERE domain_id = ? AND id > ?
-		ORDER BY id ASC
+		SELECT n.id, n.content, n.domain_id, n.title, n.description, n.created_at, n.updated_at, n.expires_at, n.archived_at, n.version
+		FROM nodes n
+		WHERE n.domain_id = ? AND n.id > ? AND ` + activeNodeCondition + `
+		ORDER BY n.id ASC
 		LIMIT ?
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, domainID, lastNodeID, limit)
+	rows, err := r.db.QueryContext(ctx, query, domainID, lastNodeID, activeAt(), limit)
 	if err != nil {
 		return nil, err
 	}
@@ -447,6 +1035,9 @@ func (r *nodeRepository) GetByDomainFromCursor(ctx context.Context, domainID int
 			&dbRow.Description,
 			&dbRow.CreatedAt,
 			&dbRow.UpdatedAt,
+			&dbRow.ExpiresAt,
+			&dbRow.ArchivedAt,
+			&dbRow.Version,
 		)
 		if err != nil {
 			return nil, err
@@ -464,3 +1055,19 @@ func (r *nodeRepository) GetByDomainFromCursor(ctx context.Context, domainID int
 
 	return nodes, nil
 }
+
+// DeleteExpired removes nodes that expired at or before now
+func (r *nodeRepository) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	query := `DELETE FROM nodes WHERE expires_at IS NOT NULL AND expires_at <= ?`
+	result, err := r.db.ExecContext(ctx, query, now.UTC())
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(rowsAffected), nil
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/jmoiron/sqlx"
 	"url-db/internal/domain/entity"
@@ -97,6 +98,298 @@ func (r *sqliteNodeAttributeRepository) GetByNodeID(ctx context.Context, nodeID
 	return attributes, nil
 }
 
+// FindByNameAndValue retrieves nodes across domains holding an exact attribute value
+func (r *sqliteNodeAttributeRepository) FindByNameAndValue(ctx context.Context, attributeName, value string, page, size int) ([]repository.AttributeValueMatch, error) {
+	query := `
+		SELECT DISTINCT n.id, d.name, n.content, COALESCE(n.title, '')
+		FROM node_attributes na
+		JOIN attributes a ON na.attribute_id = a.id
+		JOIN nodes n ON na.node_id = n.id
+		JOIN domains d ON n.domain_id = d.id
+		WHERE a.name = ? AND na.value = ? AND `+activeNodeCondition+`
+		ORDER BY d.name, n.id
+		LIMIT ? OFFSET ?
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, attributeName, value, activeAt(), size, (page-1)*size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query attribute value: %w", err)
+	}
+	defer rows.Close()
+
+	var matches []repository.AttributeValueMatch
+	for rows.Next() {
+		var match repository.AttributeValueMatch
+		if err := rows.Scan(&match.NodeID, &match.DomainName, &match.URL, &match.Title); err != nil {
+			return nil, fmt.Errorf("failed to scan attribute value match: %w", err)
+		}
+		matches = append(matches, match)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate attribute value matches: %w", err)
+	}
+
+	return matches, nil
+}
+
+// CountByNameAndValuePerDomain counts nodes holding an exact attribute value per domain
+func (r *sqliteNodeAttributeRepository) CountByNameAndValuePerDomain(ctx context.Context, attributeName, value string) (map[string]int, error) {
+	query := `
+		SELECT d.name, COUNT(DISTINCT na.node_id)
+		FROM node_attributes na
+		JOIN attributes a ON na.attribute_id = a.id
+		JOIN domains d ON a.domain_id = d.id
+		JOIN nodes n ON na.node_id = n.id
+		WHERE a.name = ? AND na.value = ? AND `+activeNodeCondition+`
+		GROUP BY d.name
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, attributeName, value, activeAt())
+	if err != nil {
+		return nil, fmt.Errorf("failed to count attribute value: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var domainName string
+		var count int
+		if err := rows.Scan(&domainName, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan attribute value count: %w", err)
+		}
+		counts[domainName] = count
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate attribute value counts: %w", err)
+	}
+
+	return counts, nil
+}
+
+// CountValues retrieves the distinct values of an attribute ranked by use
+func (r *sqliteNodeAttributeRepository) CountValues(ctx context.Context, attributeID int, prefix string, limit int) ([]repository.AttributeValueCount, error) {
+	// Match the prefix literally inside LIKE
+	escaper := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	pattern := escaper.Replace(prefix) + "%"
+
+	query := `
+		SELECT na.value, COUNT(DISTINCT na.node_id) AS node_count
+		FROM node_attributes na
+		JOIN nodes n ON na.node_id = n.id
+		WHERE na.attribute_id = ? AND na.value LIKE ? ESCAPE '\' AND `+activeNodeCondition+`
+		GROUP BY na.value
+		ORDER BY node_count DESC, na.value
+		LIMIT ?
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, attributeID, pattern, activeAt(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count attribute values: %w", err)
+	}
+	defer rows.Close()
+
+	counts := []repository.AttributeValueCount{}
+	for rows.Next() {
+		var count repository.AttributeValueCount
+		if err := rows.Scan(&count.Value, &count.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan attribute value count: %w", err)
+		}
+		counts = append(counts, count)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate attribute value counts: %w", err)
+	}
+
+	return counts, nil
+}
+
+// GetUsageStats retrieves node and distinct value counts for every attribute of a domain
+func (r *sqliteNodeAttributeRepository) GetUsageStats(ctx context.Context, domainID int) ([]repository.AttributeUsage, error) {
+	// Values of expired nodes are joined away inside the LEFT JOIN so unused
+	// attributes are still listed
+	query := `
+		SELECT a.id, a.name, a.type,
+		       COUNT(DISTINCT na.node_id) AS node_count,
+		       COUNT(DISTINCT na.value) AS distinct_values
+		FROM attributes a
+		LEFT JOIN (node_attributes na JOIN nodes n ON na.node_id = n.id AND ` + activeNodeCondition + `)
+		       ON na.attribute_id = a.id
+		WHERE a.domain_id = ?
+		GROUP BY a.id, a.name, a.type
+		ORDER BY node_count DESC, a.name
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, activeAt(), domainID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attribute usage stats: %w", err)
+	}
+	defer rows.Close()
+
+	stats := []repository.AttributeUsage{}
+	for rows.Next() {
+		var usage repository.AttributeUsage
+		if err := rows.Scan(&usage.AttributeID, &usage.Name, &usage.Type, &usage.NodeCount, &usage.DistinctValues); err != nil {
+			return nil, fmt.Errorf("failed to scan attribute usage stats: %w", err)
+		}
+		stats = append(stats, usage)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate attribute usage stats: %w", err)
+	}
+
+	return stats, nil
+}
+
+// SearchNodeIDsByValue retrieves IDs of nodes with any attribute value containing term
+func (r *sqliteNodeAttributeRepository) SearchNodeIDsByValue(ctx context.Context, domainID int, term string, page, size int) ([]int, int, error) {
+	// Match the term literally inside LIKE
+	escaper := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	pattern := "%" + escaper.Replace(term) + "%"
+
+	matchFilter := `
+		FROM node_attributes na
+		JOIN attributes a ON na.attribute_id = a.id
+		JOIN nodes n ON na.node_id = n.id
+		WHERE a.domain_id = ? AND na.value LIKE ? ESCAPE '\' AND ` + activeNodeCondition
+
+	now := activeAt()
+	var total int
+	countQuery := `SELECT COUNT(DISTINCT na.node_id) ` + matchFilter
+	if err := r.db.QueryRowContext(ctx, countQuery, domainID, pattern, now).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count matching nodes: %w", err)
+	}
+
+	offset := (page - 1) * size
+	query := `SELECT DISTINCT na.node_id ` + matchFilter + ` ORDER BY na.node_id LIMIT ? OFFSET ?`
+
+	rows, err := r.db.QueryContext(ctx, query, domainID, pattern, now, size, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search attribute values: %w", err)
+	}
+	defer rows.Close()
+
+	var nodeIDs []int
+	for rows.Next() {
+		var nodeID int
+		if err := rows.Scan(&nodeID); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan node ID: %w", err)
+		}
+		nodeIDs = append(nodeIDs, nodeID)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to iterate matching nodes: %w", err)
+	}
+
+	return nodeIDs, total, nil
+}
+
+// GetByNodeIDs retrieves attributes for several nodes with a single joined query
+func (r *sqliteNodeAttributeRepository) GetByNodeIDs(ctx context.Context, nodeIDs []int) (map[int][]*entity.NodeAttribute, error) {
+	result := make(map[int][]*entity.NodeAttribute)
+	if len(nodeIDs) == 0 {
+		return result, nil
+	}
+
+	// Build query with placeholders
+	placeholders := make([]string, len(nodeIDs))
+	args := make([]interface{}, len(nodeIDs))
+	for i, id := range nodeIDs {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+
+	query := `
+		SELECT na.id, na.node_id, na.attribute_id, na.value, na.order_index, na.created_at,
+		       a.name, a.type
+		FROM node_attributes na
+		JOIN attributes a ON na.attribute_id = a.id
+		WHERE na.node_id IN (` + strings.Join(placeholders, ",") + `)
+		ORDER BY na.node_id, a.name, COALESCE(na.order_index, 0)
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query node attributes: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		model := &mapper.NodeAttributeModel{}
+		var attrName, attrType string
+		err := rows.Scan(
+			&model.ID,
+			&model.NodeID,
+			&model.AttributeID,
+			&model.Value,
+			&model.OrderIndex,
+			&model.CreatedAt,
+			&attrName,
+			&attrType,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan node attribute: %w", err)
+		}
+
+		attribute := mapper.MapNodeAttributeModelToEntity(model)
+		attribute.SetName(attrName)
+		attribute.SetAttributeType(&attrType)
+		result[model.NodeID] = append(result[model.NodeID], attribute)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate node attributes: %w", err)
+	}
+
+	return result, nil
+}
+
+// CountByNodeIDs counts attributes for several nodes in one query, keyed by node ID
+func (r *sqliteNodeAttributeRepository) CountByNodeIDs(ctx context.Context, nodeIDs []int) (map[int]int, error) {
+	result := make(map[int]int)
+	if len(nodeIDs) == 0 {
+		return result, nil
+	}
+
+	placeholders := make([]string, len(nodeIDs))
+	args := make([]interface{}, len(nodeIDs))
+	for i, id := range nodeIDs {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+
+	query := `
+		SELECT node_id, COUNT(*)
+		FROM node_attributes
+		WHERE node_id IN (` + strings.Join(placeholders, ",") + `)
+		GROUP BY node_id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count node attributes: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var nodeID, count int
+		if err := rows.Scan(&nodeID, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan node attribute count: %w", err)
+		}
+		result[nodeID] = count
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate node attribute counts: %w", err)
+	}
+
+	return result, nil
+}
+
 // GetByNodeAndAttribute retrieves a specific attribute for a node
 func (r *sqliteNodeAttributeRepository) GetByNodeAndAttribute(ctx context.Context, nodeID int, attributeID int) (*entity.NodeAttribute, error) {
 	query := `
@@ -168,16 +461,156 @@ func (r *sqliteNodeAttributeRepository) Delete(ctx context.Context, nodeID int,
 	return nil
 }
 
-// DeleteAllByNode deletes all attributes for a node
-func (r *sqliteNodeAttributeRepository) DeleteAllByNode(ctx context.Context, nodeID int) error {
+// DeleteAllByNode deletes all attributes for a node and returns how many were removed
+func (r *sqliteNodeAttributeRepository) DeleteAllByNode(ctx context.Context, nodeID int) (int, error) {
 	query := `DELETE FROM node_attributes WHERE node_id = ?`
 
-	_, err := r.db.ExecContext(ctx, query, nodeID)
+	result, err := r.db.ExecContext(ctx, query, nodeID)
 	if err != nil {
-		return fmt.Errorf("failed to delete node attributes: %w", err)
+		return 0, fmt.Errorf("failed to delete node attributes: %w", err)
 	}
 
-	return nil
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return int(rowsAffected), nil
+}
+
+// renameMergeCondition matches rows holding the old value whose node already holds
+// the new value of the same attribute
+const renameMergeCondition = `attribute_id = ? AND value = ? AND EXISTS (
+	SELECT 1 FROM node_attributes existing
+	WHERE existing.node_id = node_attributes.node_id
+	  AND existing.attribute_id = node_attributes.attribute_id
+	  AND existing.value = ?
+)`
+
+// RenameValue changes an attribute value on every node. Rows that would duplicate a
+// value the node already holds are deleted first, then the rest are updated in one statement.
+func (r *sqliteNodeAttributeRepository) RenameValue(ctx context.Context, attributeID int, oldValue, newValue string, dryRun bool) (*repository.AttributeValueRename, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var total int
+	err = tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM node_attributes WHERE attribute_id = ? AND value = ?`,
+		attributeID, oldValue).Scan(&total)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count attribute values: %w", err)
+	}
+
+	rename := &repository.AttributeValueRename{}
+	err = tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM node_attributes WHERE `+renameMergeCondition,
+		attributeID, oldValue, newValue).Scan(&rename.Merged)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count merged attribute values: %w", err)
+	}
+	rename.Renamed = total - rename.Merged
+
+	if dryRun || total == 0 {
+		return rename, nil
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM node_attributes WHERE `+renameMergeCondition,
+		attributeID, oldValue, newValue)
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge attribute values: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE node_attributes SET value = ? WHERE attribute_id = ? AND value = ?`,
+		newValue, attributeID, oldValue)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rename attribute values: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return rename, nil
+}
+
+// DeleteDuplicates removes repeated (attribute, value) rows from a node. The row with
+// the lowest order_index survives; unordered rows and ties keep the oldest.
+func (r *sqliteNodeAttributeRepository) DeleteDuplicates(ctx context.Context, nodeID int) (int, error) {
+	query := `
+		DELETE FROM node_attributes
+		WHERE node_id = ? AND id IN (
+			SELECT id FROM (
+				SELECT id, ROW_NUMBER() OVER (
+					PARTITION BY attribute_id, value
+					ORDER BY order_index IS NULL, order_index, id
+				) AS position
+				FROM node_attributes
+				WHERE node_id = ?
+			)
+			WHERE position > 1
+		)
+	`
+
+	result, err := r.db.ExecContext(ctx, query, nodeID, nodeID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete duplicate node attributes: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return int(rowsAffected), nil
+}
+
+// AddValue inserts a node attribute unless the node already holds its value. The
+// duplicate check and the order_index are part of the insert, so concurrent adds
+// cannot lose each other's values.
+func (r *sqliteNodeAttributeRepository) AddValue(ctx context.Context, nodeAttribute *entity.NodeAttribute, appendOrder bool) (bool, error) {
+	query := `
+		INSERT INTO node_attributes (node_id, attribute_id, value, order_index, created_at)
+		SELECT ?, ?, ?,
+			CASE WHEN ? THEN (
+				SELECT MAX(COALESCE(MAX(order_index) + 1, 0), 0) FROM node_attributes WHERE node_id = ? AND attribute_id = ?
+			) ELSE ? END,
+			?
+		WHERE NOT EXISTS (
+			SELECT 1 FROM node_attributes WHERE node_id = ? AND attribute_id = ? AND value = ?
+		)
+	`
+
+	nodeID, attributeID, value := nodeAttribute.NodeID(), nodeAttribute.AttributeID(), nodeAttribute.Value()
+	result, err := r.db.ExecContext(ctx, query,
+		nodeID, attributeID, value,
+		appendOrder, nodeID, attributeID, nodeAttribute.OrderIndex(),
+		nodeAttribute.CreatedAt(),
+		nodeID, attributeID, value,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to add node attribute value: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected > 0, nil
+}
+
+// RemoveValue deletes every row of one attribute value from a node
+func (r *sqliteNodeAttributeRepository) RemoveValue(ctx context.Context, nodeID, attributeID int, value string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM node_attributes WHERE node_id = ? AND attribute_id = ? AND value = ?`,
+		nodeID, attributeID, value)
+	if err != nil {
+		return false, fmt.Errorf("failed to remove node attribute value: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected > 0, nil
 }
 
 // SetNodeAttributes sets multiple attributes for a node (replaces existing ones)
@@ -209,7 +642,7 @@ func (r *sqliteNodeAttributeRepository) SetNodeAttributes(ctx context.Context, n
 			attr.CreatedAt(),
 		)
 		if err != nil {
-			return fmt.Errorf("failed to insert node attribute: %w", err)
+			return fmt.Errorf("failed to insert value '%s' of attribute %d, no attributes were set: %w", attr.Value(), attr.AttributeID(), err)
 		}
 	}
 
@@ -220,6 +653,16 @@ func (r *sqliteNodeAttributeRepository) SetNodeAttributes(ctx context.Context, n
 	return nil
 }
 
+// CountByAttributeID counts node attribute values for an attribute
+func (r *sqliteNodeAttributeRepository) CountByAttributeID(ctx context.Context, attributeID int) (int, error) {
+	query := `SELECT COUNT(*) FROM node_attributes WHERE attribute_id = ?`
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, attributeID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count node attributes by attribute ID: %w", err)
+	}
+	return count, nil
+}
+
 // GetNodesWithAttribute retrieves nodes that have a specific attribute with optional value filter
 func (r *sqliteNodeAttributeRepository) GetNodesWithAttribute(ctx context.Context, attributeID int, value *string) ([]int, error) {
 	var query string
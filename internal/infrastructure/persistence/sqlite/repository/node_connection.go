@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+	"url-db/internal/domain/entity"
+	"url-db/internal/domain/repository"
+)
+
+type nodeConnectionRepository struct {
+	db *sql.DB
+}
+
+// NewNodeConnectionRepository creates a new node connection repository
+func NewNodeConnectionRepository(db *sql.DB) repository.NodeConnectionRepository {
+	return &nodeConnectionRepository{db: db}
+}
+
+func (r *nodeConnectionRepository) ListParentIDs(ctx context.Context, nodeID int) ([]int, error) {
+	query := `
+		SELECT target_node_id FROM node_connections
+		WHERE source_node_id = ? AND relationship_type = 'parent'
+		UNION
+		SELECT source_node_id FROM node_connections
+		WHERE target_node_id = ? AND relationship_type = 'child'
+		ORDER BY 1
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, nodeID, nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query parent connections: %w", err)
+	}
+	defer rows.Close()
+
+	var parentIDs []int
+	for rows.Next() {
+		var parentID int
+		if err := rows.Scan(&parentID); err != nil {
+			return nil, fmt.Errorf("failed to scan parent ID: %w", err)
+		}
+		parentIDs = append(parentIDs, parentID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate parent connections: %w", err)
+	}
+
+	return parentIDs, nil
+}
+
+func (r *nodeConnectionRepository) ListByNodeID(ctx context.Context, nodeID int) ([]*entity.NodeConnection, error) {
+	query := `
+		SELECT id, source_node_id, target_node_id, relationship_type, description, created_at
+		FROM node_connections
+		WHERE source_node_id = ? OR target_node_id = ?
+		ORDER BY id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, nodeID, nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query node connections: %w", err)
+	}
+	defer rows.Close()
+
+	var connections []*entity.NodeConnection
+	for rows.Next() {
+		var (
+			id, sourceID, targetID int
+			relationshipType       string
+			description            sql.NullString
+			createdAt              time.Time
+		)
+		if err := rows.Scan(&id, &sourceID, &targetID, &relationshipType, &description, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan node connection: %w", err)
+		}
+
+		connection, err := entity.NewNodeConnection(sourceID, targetID, relationshipType, description.String)
+		if err != nil {
+			continue // Skip rows that violate entity invariants
+		}
+		connection.SetID(id)
+		connection.SetCreatedAt(createdAt)
+		connections = append(connections, connection)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate node connections: %w", err)
+	}
+
+	return connections, nil
+}
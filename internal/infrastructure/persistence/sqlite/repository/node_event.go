@@ -0,0 +1,213 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+	"url-db/internal/domain/entity"
+	"url-db/internal/domain/repository"
+)
+
+type nodeEventRepository struct {
+	db *sql.DB
+}
+
+// NewNodeEventRepository creates a new node event repository
+func NewNodeEventRepository(db *sql.DB) repository.NodeEventRepository {
+	return &nodeEventRepository{db: db}
+}
+
+func (r *nodeEventRepository) CreateBatch(ctx context.Context, events []*entity.NodeEvent) (int, error) {
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// node_events has no foreign key, so a buffered event that outlives its node is
+	// still written and the node's history stays complete
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO node_events (node_id, event_type, event_data, occurred_at)
+		VALUES (?, ?, ?, ?)
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare insert: %w", err)
+	}
+	defer stmt.Close()
+
+	ids := make([]int64, len(events))
+	written := 0
+	for i, event := range events {
+		var eventData interface{}
+		if event.EventData() != "" {
+			eventData = event.EventData()
+		}
+
+		result, err := stmt.ExecContext(ctx, event.NodeID(), event.EventType(), eventData, event.OccurredAt())
+		if err != nil {
+			return 0, fmt.Errorf("failed to insert node event: %w", err)
+		}
+
+		if ids[i], err = result.LastInsertId(); err != nil {
+			return 0, fmt.Errorf("failed to get event ID: %w", err)
+		}
+		written++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	for i, event := range events {
+		if ids[i] != 0 {
+			event.SetID(int(ids[i]))
+		}
+	}
+
+	return written, nil
+}
+
+// nodeEventColumns is the column list scanned by scanNodeEvent
+const nodeEventColumns = `id, node_id, event_type, event_data, occurred_at, processed_at`
+
+// scanNodeEvent reads one row selected with nodeEventColumns
+func scanNodeEvent(scanner interface{ Scan(dest ...interface{}) error }) (*entity.NodeEvent, error) {
+	var (
+		id, nodeID  int
+		eventType   string
+		eventData   sql.NullString
+		occurredAt  time.Time
+		processedAt sql.NullTime
+	)
+	if err := scanner.Scan(&id, &nodeID, &eventType, &eventData, &occurredAt, &processedAt); err != nil {
+		return nil, err
+	}
+
+	event, err := entity.NewNodeEvent(nodeID, eventType, eventData.String)
+	if err != nil {
+		return nil, fmt.Errorf("invalid node event %d: %w", id, err)
+	}
+	event.SetID(id)
+	if processedAt.Valid {
+		event.SetTimestamps(occurredAt, &processedAt.Time)
+	} else {
+		event.SetTimestamps(occurredAt, nil)
+	}
+	return event, nil
+}
+
+// queryNodeEvents runs a query selecting nodeEventColumns and collects the events
+func (r *nodeEventRepository) queryNodeEvents(ctx context.Context, query string, args ...interface{}) ([]*entity.NodeEvent, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query node events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []*entity.NodeEvent{}
+	for rows.Next() {
+		event, err := scanNodeEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan node event: %w", err)
+		}
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate node events: %w", err)
+	}
+
+	return events, nil
+}
+
+func (r *nodeEventRepository) ListByNode(ctx context.Context, nodeID int, page, size int) ([]*entity.NodeEvent, int, error) {
+	var total int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM node_events WHERE node_id = ?`, nodeID).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count node events: %w", err)
+	}
+
+	offset := (page - 1) * size
+	events, err := r.queryNodeEvents(ctx, `
+		SELECT `+nodeEventColumns+`
+		FROM node_events
+		WHERE node_id = ?
+		ORDER BY id DESC
+		LIMIT ? OFFSET ?
+	`, nodeID, size, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return events, total, nil
+}
+
+func (r *nodeEventRepository) ListPending(ctx context.Context, limit int) ([]*entity.NodeEvent, error) {
+	return r.queryNodeEvents(ctx, `
+		SELECT `+nodeEventColumns+`
+		FROM node_events
+		WHERE processed_at IS NULL
+		ORDER BY id
+		LIMIT ?
+	`, limit)
+}
+
+func (r *nodeEventRepository) MarkProcessed(ctx context.Context, id int, processedAt time.Time) (*entity.NodeEvent, error) {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE node_events SET processed_at = ? WHERE id = ? AND processed_at IS NULL
+	`, processedAt, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark node event processed: %w", err)
+	}
+
+	row := r.db.QueryRowContext(ctx, `SELECT `+nodeEventColumns+` FROM node_events WHERE id = ?`, id)
+	event, err := scanNodeEvent(row)
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node event: %w", err)
+	}
+
+	return event, nil
+}
+
+func (r *nodeEventRepository) Stats(ctx context.Context) (*repository.NodeEventStats, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT event_type, processed_at IS NOT NULL, COUNT(*)
+		FROM node_events
+		GROUP BY event_type, processed_at IS NOT NULL
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count node events: %w", err)
+	}
+	defer rows.Close()
+
+	stats := &repository.NodeEventStats{ByType: make(map[string]int)}
+	for rows.Next() {
+		var eventType string
+		var processed bool
+		var count int
+		if err := rows.Scan(&eventType, &processed, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan node event count: %w", err)
+		}
+
+		stats.Total += count
+		stats.ByType[eventType] += count
+		if processed {
+			stats.Processed += count
+		} else {
+			stats.Pending += count
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate node event counts: %w", err)
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"url-db/internal/domain/repository"
+)
+
+type nodeMoveRepository struct {
+	db *sql.DB
+}
+
+// NewNodeMoveRepository creates a new SQLite-based node move repository
+func NewNodeMoveRepository(db *sql.DB) repository.NodeMoveRepository {
+	return &nodeMoveRepository{db: db}
+}
+
+// moveAttribute is an attribute definition as the move compares it
+type moveAttribute struct {
+	id            int
+	name          string
+	attributeType string
+}
+
+func (r *nodeMoveRepository) Move(ctx context.Context, move *repository.NodeMove) ([]repository.NodeMoveResult, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	targetAttributes, err := listMoveAttributes(ctx, tx, move.TargetDomainID)
+	if err != nil {
+		return nil, err
+	}
+	targetByName := make(map[string]moveAttribute, len(targetAttributes))
+	for _, attr := range targetAttributes {
+		targetByName[attr.name] = attr
+	}
+
+	results := make([]repository.NodeMoveResult, len(move.NodeIDs))
+	for i, nodeID := range move.NodeIDs {
+		results[i], err = r.moveNode(ctx, tx, nodeID, move, targetByName)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return results, nil
+}
+
+// moveNode moves one node. Reasons the node cannot move go into its result; the
+// returned error is reserved for database failures, which abort the whole move.
+// Every check runs before the first write, so a node that fails is left untouched.
+func (r *nodeMoveRepository) moveNode(ctx context.Context, tx *sql.Tx, nodeID int, move *repository.NodeMove, targetByName map[string]moveAttribute) (repository.NodeMoveResult, error) {
+	result := repository.NodeMoveResult{NodeID: nodeID}
+	targetDomainID := move.TargetDomainID
+
+	var url string
+	err := tx.QueryRowContext(ctx, `SELECT n.content, n.domain_id FROM nodes n WHERE n.id = ? AND `+activeNodeCondition,
+		nodeID, activeAt()).Scan(&url, &result.SourceDomainID)
+	if err == sql.ErrNoRows {
+		result.Err = fmt.Errorf("node %d: %w", nodeID, repository.ErrNotFound)
+		return result, nil
+	}
+	if err != nil {
+		return result, fmt.Errorf("failed to get node %d: %w", nodeID, err)
+	}
+	if result.SourceDomainID == targetDomainID {
+		result.Err = fmt.Errorf("node %d is already in the target domain", nodeID)
+		return result, nil
+	}
+
+	if err := purgeExpiredURL(ctx, tx, url, targetDomainID); err != nil {
+		return result, err
+	}
+	// No expiry filter: the purge above removed any expired holder of the URL
+	var taken bool
+	err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM nodes WHERE content = ? AND domain_id = ?)`,
+		url, targetDomainID).Scan(&taken)
+	if err != nil {
+		return result, fmt.Errorf("failed to check target domain for '%s': %w", url, err)
+	}
+	if taken {
+		result.Err = fmt.Errorf("URL '%s' already exists in the target domain: %w", url, repository.ErrDuplicateKey)
+		return result, nil
+	}
+
+	if move.MaxNodes > 0 {
+		var count int
+		err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM nodes n WHERE n.domain_id = ? AND `+activeNodeCondition,
+			targetDomainID, activeAt()).Scan(&count)
+		if err != nil {
+			return result, fmt.Errorf("failed to count nodes: %w", err)
+		}
+		if count >= move.MaxNodes {
+			result.Err = fmt.Errorf("target domain has reached its limit of %d nodes", move.MaxNodes)
+			return result, nil
+		}
+	}
+
+	// Map each attribute the node uses to its counterpart in the target
+	rows, err := tx.QueryContext(ctx, `
+		SELECT DISTINCT a.id, a.name, a.type
+		FROM node_attributes na
+		INNER JOIN attributes a ON na.attribute_id = a.id
+		WHERE na.node_id = ?`, nodeID)
+	if err != nil {
+		return result, fmt.Errorf("failed to list attributes of node %d: %w", nodeID, err)
+	}
+	repoint := make(map[int]int)
+	var dropped []moveAttribute
+	for rows.Next() {
+		var attr moveAttribute
+		if err := rows.Scan(&attr.id, &attr.name, &attr.attributeType); err != nil {
+			rows.Close()
+			return result, fmt.Errorf("failed to scan attribute: %w", err)
+		}
+		if target, ok := targetByName[attr.name]; ok && target.attributeType == attr.attributeType {
+			repoint[attr.id] = target.id
+		} else {
+			dropped = append(dropped, attr)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return result, fmt.Errorf("failed to list attributes of node %d: %w", nodeID, err)
+	}
+
+	sort.Slice(dropped, func(i, j int) bool { return dropped[i].name < dropped[j].name })
+	for _, attr := range dropped {
+		result.DroppedAttributes = append(result.DroppedAttributes, attr.name)
+	}
+	if len(dropped) > 0 && !move.DropIncompatible {
+		result.Err = fmt.Errorf("%w: %s", repository.ErrIncompatibleAttributes, strings.Join(result.DroppedAttributes, ", "))
+		result.DroppedAttributes = nil
+		return result, nil
+	}
+
+	for _, attr := range dropped {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM node_attributes WHERE node_id = ? AND attribute_id = ?`, nodeID, attr.id); err != nil {
+			return result, fmt.Errorf("failed to drop attribute '%s' of node %d: %w", attr.name, nodeID, err)
+		}
+	}
+	for sourceID, targetID := range repoint {
+		if _, err := tx.ExecContext(ctx, `UPDATE node_attributes SET attribute_id = ? WHERE node_id = ? AND attribute_id = ?`, targetID, nodeID, sourceID); err != nil {
+			return result, fmt.Errorf("failed to re-point attributes of node %d: %w", nodeID, err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE nodes SET domain_id = ?, updated_at = ? WHERE id = ?`, targetDomainID, time.Now(), nodeID); err != nil {
+		return result, fmt.Errorf("failed to move node %d: %w", nodeID, err)
+	}
+
+	return result, nil
+}
+
+// listMoveAttributes lists the attribute definitions of a domain
+func listMoveAttributes(ctx context.Context, tx *sql.Tx, domainID int) ([]moveAttribute, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT id, name, type FROM attributes WHERE domain_id = ?`, domainID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list attributes: %w", err)
+	}
+	defer rows.Close()
+
+	var attributes []moveAttribute
+	for rows.Next() {
+		var attr moveAttribute
+		if err := rows.Scan(&attr.id, &attr.name, &attr.attributeType); err != nil {
+			return nil, fmt.Errorf("failed to scan attribute: %w", err)
+		}
+		attributes = append(attributes, attr)
+	}
+	return attributes, rows.Err()
+}
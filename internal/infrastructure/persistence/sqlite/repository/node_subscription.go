@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+	"url-db/internal/domain/entity"
+	"url-db/internal/domain/repository"
+)
+
+type nodeSubscriptionRepository struct {
+	db *sql.DB
+}
+
+// NewNodeSubscriptionRepository creates a new node subscription repository
+func NewNodeSubscriptionRepository(db *sql.DB) repository.NodeSubscriptionRepository {
+	return &nodeSubscriptionRepository{db: db}
+}
+
+// nodeSubscriptionColumns is the column list read by scanNodeSubscription
+const nodeSubscriptionColumns = `s.id, s.subscriber_service, s.subscriber_endpoint, s.subscribed_node_id,
+	s.event_types, s.is_active, s.created_at`
+
+// nodeSubscriptionRow holds the raw columns of a subscription row
+type nodeSubscriptionRow struct {
+	id         int
+	service    string
+	endpoint   sql.NullString
+	nodeID     int
+	eventTypes string
+	isActive   bool
+	createdAt  time.Time
+}
+
+func (row *nodeSubscriptionRow) fields() []interface{} {
+	return []interface{}{&row.id, &row.service, &row.endpoint, &row.nodeID, &row.eventTypes, &row.isActive, &row.createdAt}
+}
+
+func (row *nodeSubscriptionRow) toEntity() (*entity.NodeSubscription, error) {
+	var eventTypes []string
+	if err := json.Unmarshal([]byte(row.eventTypes), &eventTypes); err != nil {
+		return nil, fmt.Errorf("invalid event types of subscription %d: %w", row.id, err)
+	}
+
+	subscription, err := entity.NewNodeSubscription(row.service, row.endpoint.String, row.nodeID, eventTypes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid subscription %d: %w", row.id, err)
+	}
+	subscription.SetID(row.id)
+	subscription.SetState(row.isActive, row.createdAt)
+	return subscription, nil
+}
+
+func (r *nodeSubscriptionRepository) Create(ctx context.Context, subscription *entity.NodeSubscription) error {
+	eventTypes, err := json.Marshal(subscription.EventTypes())
+	if err != nil {
+		return fmt.Errorf("failed to encode event types: %w", err)
+	}
+
+	var endpoint interface{}
+	if subscription.SubscriberEndpoint() != "" {
+		endpoint = subscription.SubscriberEndpoint()
+	}
+
+	// created_at is written from Go so it compares exactly with event occurred_at
+	result, err := r.db.ExecContext(ctx, `
+		INSERT INTO node_subscriptions (subscriber_service, subscriber_endpoint, subscribed_node_id, event_types, is_active, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, subscription.SubscriberService(), endpoint, subscription.NodeID(), string(eventTypes), subscription.IsActive(), subscription.CreatedAt())
+	if err != nil {
+		return fmt.Errorf("failed to create subscription: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("failed to get subscription ID: %w", err)
+	}
+	subscription.SetID(int(id))
+
+	return nil
+}
+
+func (r *nodeSubscriptionRepository) GetByID(ctx context.Context, id int) (*entity.NodeSubscription, error) {
+	row := nodeSubscriptionRow{}
+	err := r.db.QueryRowContext(ctx, `SELECT `+nodeSubscriptionColumns+` FROM node_subscriptions s WHERE s.id = ?`, id).Scan(row.fields()...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscription: %w", err)
+	}
+
+	return row.toEntity()
+}
+
+func (r *nodeSubscriptionRepository) ListByNode(ctx context.Context, nodeID int) ([]*entity.NodeSubscription, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+nodeSubscriptionColumns+`
+		FROM node_subscriptions s
+		WHERE s.subscribed_node_id = ?
+		ORDER BY s.id
+	`, nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	subscriptions := []*entity.NodeSubscription{}
+	for rows.Next() {
+		row := nodeSubscriptionRow{}
+		if err := rows.Scan(row.fields()...); err != nil {
+			return nil, fmt.Errorf("failed to scan subscription: %w", err)
+		}
+		subscription, err := row.toEntity()
+		if err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate subscriptions: %w", err)
+	}
+
+	return subscriptions, nil
+}
+
+func (r *nodeSubscriptionRepository) Delete(ctx context.Context, id int) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM node_subscriptions WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete subscription: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if affected == 0 {
+		return repository.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *nodeSubscriptionRepository) ListPendingDeliveries(ctx context.Context, limit int) ([]*repository.SubscriptionDelivery, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+nodeSubscriptionColumns+`,
+		       e.id, e.node_id, e.event_type, e.event_data, e.occurred_at, e.processed_at
+		FROM node_subscriptions s
+		JOIN node_events e ON e.node_id = s.subscribed_node_id
+		WHERE s.is_active = 1
+		  AND COALESCE(s.subscriber_endpoint, '') != ''
+		  AND EXISTS (SELECT 1 FROM json_each(s.event_types) WHERE json_each.value = e.event_type)
+		  AND julianday(e.occurred_at) >= julianday(s.created_at)
+		  AND NOT EXISTS (
+		      SELECT 1 FROM subscription_deliveries d
+		      WHERE d.subscription_id = s.id AND d.event_id = e.id
+		  )
+		ORDER BY e.id, s.id
+		LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pending deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []*repository.SubscriptionDelivery{}
+	for rows.Next() {
+		row := nodeSubscriptionRow{}
+		var (
+			eventID, eventNodeID int
+			eventType            string
+			eventData            sql.NullString
+			occurredAt           time.Time
+			processedAt          sql.NullTime
+		)
+		fields := append(row.fields(), &eventID, &eventNodeID, &eventType, &eventData, &occurredAt, &processedAt)
+		if err := rows.Scan(fields...); err != nil {
+			return nil, fmt.Errorf("failed to scan pending delivery: %w", err)
+		}
+
+		subscription, err := row.toEntity()
+		if err != nil {
+			return nil, err
+		}
+		event, err := entity.NewNodeEvent(eventNodeID, eventType, eventData.String)
+		if err != nil {
+			return nil, fmt.Errorf("invalid node event %d: %w", eventID, err)
+		}
+		event.SetID(eventID)
+		if processedAt.Valid {
+			event.SetTimestamps(occurredAt, &processedAt.Time)
+		} else {
+			event.SetTimestamps(occurredAt, nil)
+		}
+
+		deliveries = append(deliveries, &repository.SubscriptionDelivery{Subscription: subscription, Event: event})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate pending deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+func (r *nodeSubscriptionRepository) RecordDelivery(ctx context.Context, subscriptionID, eventID, attempts int, deliveredAt *time.Time, lastError string) error {
+	var delivered, errorText interface{}
+	if deliveredAt != nil {
+		delivered = *deliveredAt
+	}
+	if lastError != "" {
+		errorText = lastError
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT OR REPLACE INTO subscription_deliveries (subscription_id, event_id, attempts, delivered_at, last_error)
+		VALUES (?, ?, ?, ?, ?)
+	`, subscriptionID, eventID, attempts, delivered, errorText)
+	if err != nil {
+		return fmt.Errorf("failed to record delivery: %w", err)
+	}
+
+	return nil
+}
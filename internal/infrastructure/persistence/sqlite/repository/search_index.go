@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"url-db/internal/constants"
+	"url-db/internal/domain/repository"
+)
+
+type searchIndexRepository struct {
+	db *sql.DB
+}
+
+// NewSearchIndexRepository creates a new SQLite-based search index repository
+func NewSearchIndexRepository(db *sql.DB) repository.SearchIndexRepository {
+	return &searchIndexRepository{db: db}
+}
+
+// searchIndexExists reports whether the database has the nodes_fts index; databases
+// opened by an SQLite without FTS4 have none
+func searchIndexExists(ctx context.Context, db *sql.DB) (bool, error) {
+	var count int
+	err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'nodes_fts'`).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to look up search index: %w", err)
+	}
+	return count > 0, nil
+}
+
+func (r *searchIndexRepository) Available(ctx context.Context) (bool, error) {
+	return searchIndexExists(ctx, r.db)
+}
+
+func (r *searchIndexRepository) Rebuild(ctx context.Context, domainID int) (int, error) {
+	available, err := r.Available(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if !available {
+		return 0, errors.New("search index is not available: SQLite was built without FTS4")
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Entries of deleted nodes have no domain left, so they are dropped in either scope
+	clear := `DELETE FROM nodes_fts`
+	clearArgs := []interface{}{}
+	if domainID > 0 {
+		clear = `DELETE FROM nodes_fts WHERE docid IN (SELECT id FROM nodes WHERE domain_id = ?) OR docid NOT IN (SELECT id FROM nodes)`
+		clearArgs = append(clearArgs, domainID)
+	}
+	if _, err := tx.ExecContext(ctx, clear, clearArgs...); err != nil {
+		return 0, fmt.Errorf("failed to clear search index: %w", err)
+	}
+
+	// Index in id order a batch at a time, checking for cancellation in between
+	batchEnd := `SELECT MAX(id) FROM (
+		SELECT id FROM nodes WHERE id > ? AND (? = 0 OR domain_id = ?) ORDER BY id LIMIT ?
+	)`
+	fill := `INSERT INTO nodes_fts (docid, url, title, description)
+		SELECT id, content, title, description FROM nodes
+		WHERE id > ? AND id <= ? AND (? = 0 OR domain_id = ?)`
+	indexed, lastID := 0, int64(0)
+	for {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+
+		var endID sql.NullInt64
+		if err := tx.QueryRowContext(ctx, batchEnd, lastID, domainID, domainID, constants.SearchIndexBatchSize).Scan(&endID); err != nil {
+			return 0, fmt.Errorf("failed to read nodes to index: %w", err)
+		}
+		if !endID.Valid {
+			break
+		}
+
+		result, err := tx.ExecContext(ctx, fill, lastID, endID.Int64, domainID, domainID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to index nodes: %w", err)
+		}
+		count, err := result.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("failed to index nodes: %w", err)
+		}
+		indexed += int(count)
+		lastID = endID.Int64
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return indexed, nil
+}
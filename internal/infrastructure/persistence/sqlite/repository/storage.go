@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"url-db/internal/domain/repository"
+)
+
+// storageStatsTables are the tables whose rows GetStats counts
+var storageStatsTables = []string{
+	"domains",
+	"nodes",
+	"attributes",
+	"node_attributes",
+	"templates",
+	"node_dependencies",
+	"node_subscriptions",
+	"node_events",
+}
+
+type storageRepository struct {
+	db *sql.DB
+}
+
+// NewStorageRepository creates a new SQLite-based storage repository
+func NewStorageRepository(db *sql.DB) repository.StorageRepository {
+	return &storageRepository{db: db}
+}
+
+func (r *storageRepository) GetStats(ctx context.Context) (*repository.StorageStats, error) {
+	stats := &repository.StorageStats{RowCounts: make(map[string]int, len(storageStatsTables))}
+
+	for _, table := range storageStatsTables {
+		var count int
+		if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM "+table).Scan(&count); err != nil {
+			return nil, fmt.Errorf("failed to count rows of %s: %w", table, err)
+		}
+		stats.RowCounts[table] = count
+	}
+
+	pragmas := []struct {
+		name  string
+		value *int
+	}{
+		{"page_size", &stats.PageSize},
+		{"page_count", &stats.PageCount},
+		{"freelist_count", &stats.FreePages},
+	}
+	for _, pragma := range pragmas {
+		if err := r.db.QueryRowContext(ctx, "PRAGMA "+pragma.name).Scan(pragma.value); err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", pragma.name, err)
+		}
+	}
+
+	return stats, nil
+}
+
+func (r *storageRepository) Ping(ctx context.Context) error {
+	var one int
+	if err := r.db.QueryRowContext(ctx, "SELECT 1").Scan(&one); err != nil {
+		return fmt.Errorf("failed to query database: %w", err)
+	}
+	return nil
+}
+
+func (r *storageRepository) DatabasePath(ctx context.Context) (string, error) {
+	rows, err := r.db.QueryContext(ctx, "PRAGMA database_list")
+	if err != nil {
+		return "", fmt.Errorf("failed to list databases: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var seq int
+		var name, file string
+		if err := rows.Scan(&seq, &name, &file); err != nil {
+			return "", fmt.Errorf("failed to scan database: %w", err)
+		}
+		if name == "main" {
+			return file, nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return "", fmt.Errorf("failed to list databases: %w", err)
+	}
+	return "", nil
+}
@@ -0,0 +1,74 @@
+package render
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+
+	"url-db/internal/constants"
+)
+
+// markdown renders CommonMark with GitHub tables, strikethrough and task lists. It
+// keeps goldmark's safe defaults: raw HTML is left out of the output and links with
+// dangerous schemes such as javascript: lose their target.
+var markdown = goldmark.New(goldmark.WithExtensions(extension.Table, extension.Strikethrough, extension.TaskList))
+
+// Markdown renders a markdown attribute value as HTML that is safe to embed in a page
+func Markdown(source string) (string, error) {
+	var buf bytes.Buffer
+	if err := markdown.Convert([]byte(source), &buf); err != nil {
+		return "", fmt.Errorf("failed to render markdown: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// ImageInfo describes an image attribute value for display
+type ImageInfo struct {
+	Source    string // "data" for a data URL, "url" for an HTTP(S) URL
+	URL       string // Normalised URL; empty for data URLs, which are used as stored
+	MimeType  string // From the data URL or the URL's file extension; "" if unknown
+	SizeBytes int    // Decoded size of a data URL; 0 for HTTP(S) URLs
+}
+
+// Image describes an image attribute value, which is a base64 data URL or an HTTP(S) URL
+func Image(value string) (*ImageInfo, error) {
+	if strings.HasPrefix(value, constants.DataImagePrefix) {
+		header, data, ok := strings.Cut(value, constants.Base64Separator)
+		if !ok {
+			return nil, fmt.Errorf("data URL must use base64 encoding")
+		}
+		decoded, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s", constants.ErrInvalidBase64Encoding)
+		}
+		return &ImageInfo{
+			Source:    "data",
+			MimeType:  strings.TrimPrefix(header, "data:"),
+			SizeBytes: len(decoded),
+		}, nil
+	}
+
+	parsed, err := url.Parse(value)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("image must be either data URL (data:image/...) or HTTP(S) URL")
+	}
+
+	// Only image types are reported, so a page URL like /photo.html gives none
+	mimeType := mime.TypeByExtension(strings.ToLower(path.Ext(parsed.Path)))
+	if !strings.HasPrefix(mimeType, "image/") {
+		mimeType = ""
+	}
+
+	return &ImageInfo{
+		Source:   "url",
+		URL:      parsed.String(),
+		MimeType: mimeType,
+	}, nil
+}
@@ -56,7 +56,7 @@ func (h *AttributeHandler) CreateAttribute(c *gin.Context) {
 	c.JSON(http.StatusCreated, response)
 }
 
-// ListAttributes handles GET /api/domains/{domain_id}/attributes
+// ListAttributes handles GET /api/domains/{domain_id}/attributes. Responses carry an ETag for conditional requests.
 func (h *AttributeHandler) ListAttributes(c *gin.Context) {
 	domainIDStr := c.Param("domain_id")
 
@@ -72,5 +72,5 @@ func (h *AttributeHandler) ListAttributes(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, response)
+	writeJSON(c.Writer, c.Request, response)
 }
@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"url-db/internal/application/dto/request"
 	"url-db/internal/application/usecase/domain"
+	"url-db/internal/constants"
 )
 
 // DomainHandler handles HTTP requests for domain operations
@@ -46,7 +47,7 @@ func (h *DomainHandler) CreateDomain(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
-// ListDomains handles GET /domains
+// ListDomains handles GET /domains. Responses carry an ETag for conditional requests.
 func (h *DomainHandler) ListDomains(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -61,7 +62,7 @@ func (h *DomainHandler) ListDomains(w http.ResponseWriter, r *http.Request) {
 
 	size, _ := strconv.Atoi(r.URL.Query().Get("size"))
 	if size <= 0 {
-		size = 20
+		size = constants.DefaultPageSize
 	}
 
 	response, err := h.listUseCase.Execute(r.Context(), page, size)
@@ -70,6 +71,5 @@ func (h *DomainHandler) ListDomains(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	writeJSON(w, r, response)
 }
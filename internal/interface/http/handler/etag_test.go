@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWriteJSONConditional(t *testing.T) {
+	get := func(v interface{}, ifNoneMatch string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/domains", nil)
+		if ifNoneMatch != "" {
+			r.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		writeJSON(w, r, v)
+		return w
+	}
+
+	first := get(map[string]string{"title": "a"}, "")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" || first.Body.String() != "{\"title\":\"a\"}\n" {
+		t.Fatalf("unexpected first response: %d %q %q", first.Code, etag, first.Body.String())
+	}
+
+	for _, ifNoneMatch := range []string{etag, "W/" + etag, `"other", ` + etag, "*"} {
+		if w := get(map[string]string{"title": "a"}, ifNoneMatch); w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+			t.Errorf("If-None-Match %s: got %d with %d byte body, want 304 and no body", ifNoneMatch, w.Code, w.Body.Len())
+		}
+	}
+
+	// A changed response gets a new ETag and a full body
+	changed := get(map[string]string{"title": "b"}, etag)
+	if changed.Code != http.StatusOK || changed.Header().Get("ETag") == etag {
+		t.Errorf("changed response: got %d with ETag %s", changed.Code, changed.Header().Get("ETag"))
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"url-db/internal/application/dto/request"
 	"url-db/internal/application/usecase/node"
+	"url-db/internal/constants"
 )
 
 // NodeHandler handles HTTP requests for node operations
@@ -46,7 +47,7 @@ func (h *NodeHandler) CreateNode(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
-// ListNodes handles GET /domains/{domainName}/nodes
+// ListNodes handles GET /domains/{domainName}/nodes. Responses carry an ETag for conditional requests.
 func (h *NodeHandler) ListNodes(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -69,15 +70,14 @@ func (h *NodeHandler) ListNodes(w http.ResponseWriter, r *http.Request) {
 
 	size, _ := strconv.Atoi(r.URL.Query().Get("size"))
 	if size <= 0 {
-		size = 20
+		size = constants.DefaultPageSize
 	}
 
-	response, err := h.listUseCase.Execute(r.Context(), domainName, page, size)
+	response, err := h.listUseCase.Execute(r.Context(), domainName, page, size, false)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	writeJSON(w, r, response)
 }
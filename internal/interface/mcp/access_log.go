@@ -0,0 +1,101 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// accessLogRank orders log levels so entries below the configured level are skipped
+var accessLogRank = map[LogLevel]int{
+	LogLevelDebug: 0,
+	LogLevelInfo:  1,
+	LogLevelError: 2,
+}
+
+// AccessLogger writes one JSON line per JSON-RPC call with its method, tool name,
+// duration and error. Failed calls are logged at error level and others at info
+// level; at debug level entries also carry the request params.
+type AccessLogger struct {
+	mu    sync.Mutex
+	out   io.Writer
+	level LogLevel
+}
+
+// AccessLogEntry is one line of the access log
+type AccessLogEntry struct {
+	Time       string          `json:"time"`
+	Level      LogLevel        `json:"level"`
+	Method     string          `json:"method"`
+	Tool       string          `json:"tool,omitempty"`
+	ID         interface{}     `json:"id,omitempty"`
+	DurationMS float64         `json:"duration_ms"`
+	Error      *RPCError       `json:"error,omitempty"`
+	Params     json.RawMessage `json:"params,omitempty"`
+}
+
+// NewAccessLogger creates an access logger writing entries at level or above to out.
+// Levels are LogLevelDebug, LogLevelInfo and LogLevelError.
+func NewAccessLogger(out io.Writer, level LogLevel) *AccessLogger {
+	return &AccessLogger{out: out, level: level}
+}
+
+// Wrap returns next with each call logged. A nil logger returns next unchanged.
+func (l *AccessLogger) Wrap(next RequestHandler) RequestHandler {
+	if l == nil {
+		return next
+	}
+
+	return func(ctx context.Context, req *JSONRPCRequest) *JSONRPCResponse {
+		start := time.Now()
+		resp := next(ctx, req)
+		l.log(req, resp, time.Since(start))
+		return resp
+	}
+}
+
+// log writes the entry for one call when its level is enabled
+func (l *AccessLogger) log(req *JSONRPCRequest, resp *JSONRPCResponse, duration time.Duration) {
+	entry := AccessLogEntry{
+		Time:       time.Now().UTC().Format(time.RFC3339Nano),
+		Level:      LogLevelInfo,
+		Method:     req.Method,
+		Tool:       accessLogToolName(req),
+		ID:         req.ID,
+		DurationMS: float64(duration.Microseconds()) / 1000,
+	}
+	if resp != nil && resp.Error != nil {
+		entry.Level = LogLevelError
+		entry.Error = &RPCError{Code: resp.Error.Code, Message: resp.Error.Message, Data: resp.Error.Data}
+	}
+	if accessLogRank[entry.Level] < accessLogRank[l.level] {
+		return
+	}
+	if l.level == LogLevelDebug {
+		entry.Params = req.Params
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.out.Write(append(line, '\n'))
+}
+
+// accessLogToolName returns the tool a tools/call request names, or "" for other methods
+func accessLogToolName(req *JSONRPCRequest) string {
+	if req.Method != "tools/call" {
+		return ""
+	}
+	var params struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		return ""
+	}
+	return params.Name
+}
@@ -0,0 +1,56 @@
+package mcp
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// APIKeyAuth requires HTTP and SSE requests to carry a configured API key as
+// 'Authorization: Bearer <key>'
+type APIKeyAuth struct {
+	keyHash [sha256.Size]byte
+}
+
+// NewAPIKeyAuth creates an API key check; an empty key returns nil, which lets every
+// request through
+func NewAPIKeyAuth(key string) *APIKeyAuth {
+	if key == "" {
+		return nil
+	}
+	return &APIKeyAuth{keyHash: sha256.Sum256([]byte(key))}
+}
+
+// Allow reports whether r carries the configured key. Keys are compared by their
+// hashes in constant time, so neither the key nor its length leaks through timing.
+func (a *APIKeyAuth) Allow(r *http.Request) bool {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return false
+	}
+	tokenHash := sha256.Sum256([]byte(strings.TrimSpace(token)))
+	return subtle.ConstantTimeCompare(tokenHash[:], a.keyHash[:]) == 1
+}
+
+// Wrap returns next guarded by a. Requests without the key get HTTP 401 with a
+// JSON-RPC error body; CORS preflights pass, as browsers send them without
+// credentials. A nil check returns next unchanged.
+func (a *APIKeyAuth) Wrap(next http.Handler) http.Handler {
+	if a == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions || a.Allow(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("WWW-Authenticate", `Bearer realm="mcp"`)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		NewHTTPResponseWriter(w).WriteError(nil, Unauthorized, "Unauthorized",
+			"missing or invalid API key; send 'Authorization: Bearer <key>'")
+	})
+}
@@ -0,0 +1,109 @@
+package mcp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// templateCompositeIDSegment marks a template composite ID, 'tool-name:domain:template:id'
+const templateCompositeIDSegment = "template"
+
+// parsedCompositeID is a node composite ID, 'tool-name:domain:id', or a template
+// composite ID, 'tool-name:domain:template:id'
+type parsedCompositeID struct {
+	ToolName   string
+	DomainName string
+	ID         int
+	Template   bool // A template ID rather than a node ID
+}
+
+// splitCompositeID parses a node or template composite ID. It splits from the right,
+// so the tool name is everything before the domain and may itself contain colons.
+// 'a:b:template:1' is read as a template of domain 'b'; a node of a domain named
+// 'template' is only recognised when the tool name has no colon.
+func splitCompositeID(compositeID string) (parsedCompositeID, error) {
+	invalidFormat := fmt.Errorf("invalid composite_id format, expected 'tool-name:domain:id' or 'tool-name:domain:template:id'")
+
+	rest, idPart, ok := cutLastSegment(compositeID)
+	if !ok {
+		return parsedCompositeID{}, invalidFormat
+	}
+	id, err := strconv.Atoi(idPart)
+	if err != nil || id <= 0 {
+		return parsedCompositeID{}, fmt.Errorf("invalid ID in composite_id: '%s' is not a positive integer", idPart)
+	}
+
+	toolName, domainName, ok := cutLastSegment(rest)
+	if !ok {
+		return parsedCompositeID{}, invalidFormat
+	}
+
+	if domainName == templateCompositeIDSegment {
+		if templateToolName, templateDomainName, ok := cutLastSegment(toolName); ok {
+			return parsedCompositeID{ToolName: templateToolName, DomainName: templateDomainName, ID: id, Template: true}, nil
+		}
+	}
+
+	return parsedCompositeID{ToolName: toolName, DomainName: domainName, ID: id}, nil
+}
+
+// cutLastSegment splits value around its last colon, reporting false when there is
+// none or either side is empty
+func cutLastSegment(value string) (string, string, bool) {
+	i := strings.LastIndex(value, ":")
+	if i <= 0 || i == len(value)-1 {
+		return "", "", false
+	}
+	return value[:i], value[i+1:], true
+}
+
+// nodeCompositeID builds a node composite ID using this server's configured tool name
+func (h *MCPToolHandler) nodeCompositeID(domainName string, nodeID int) string {
+	return fmt.Sprintf("%s:%s:%d", h.toolName, domainName, nodeID)
+}
+
+// templateCompositeID builds a template composite ID using this server's configured tool name
+func (h *MCPToolHandler) templateCompositeID(domainName string, templateID int) string {
+	return fmt.Sprintf("%s:%s:%s:%d", h.toolName, domainName, templateCompositeIDSegment, templateID)
+}
+
+// parseCompositeID returns the node ID of a node composite ID. IDs minted by a
+// server with another tool name are refused rather than resolved by node ID.
+func (h *MCPToolHandler) parseCompositeID(compositeID string) (int, error) {
+	parsed, err := splitCompositeID(compositeID)
+	if err != nil {
+		return 0, err
+	}
+	if parsed.Template {
+		return 0, fmt.Errorf("composite_id '%s' names a template, expected a node ('tool-name:domain:id')", compositeID)
+	}
+	if err := h.checkCompositeIDToolName(parsed.ToolName); err != nil {
+		return 0, err
+	}
+	return parsed.ID, nil
+}
+
+// parseTemplateCompositeID parses a template composite ID minted by this server
+func (h *MCPToolHandler) parseTemplateCompositeID(compositeID string) (parsedCompositeID, error) {
+	parsed, err := splitCompositeID(compositeID)
+	if err != nil {
+		return parsedCompositeID{}, err
+	}
+	if !parsed.Template {
+		return parsedCompositeID{}, fmt.Errorf("composite_id '%s' names a node, expected a template ('tool-name:domain:template:id')", compositeID)
+	}
+	if err := h.checkCompositeIDToolName(parsed.ToolName); err != nil {
+		return parsedCompositeID{}, err
+	}
+	return parsed, nil
+}
+
+// checkCompositeIDToolName refuses composite IDs minted by a server configured with
+// another tool name, which could otherwise resolve to an unrelated node or template
+func (h *MCPToolHandler) checkCompositeIDToolName(toolName string) error {
+	if toolName != h.toolName {
+		return fmt.Errorf("composite_id belongs to tool '%s', but this server's tool name is '%s'", toolName, h.toolName)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package mcp
+
+import (
+	"net/http"
+	"strings"
+)
+
+// CORSPolicy limits the browser origins allowed to call /mcp in http and sse mode
+type CORSPolicy struct {
+	origins map[string]bool // Allowed origins, lowercased
+}
+
+// NewCORSPolicy creates a policy allowing the listed origins, such as
+// https://app.example.com. An empty list or one holding "*" returns nil, which
+// allows any origin.
+func NewCORSPolicy(origins []string) *CORSPolicy {
+	allowed := map[string]bool{}
+	for _, origin := range origins {
+		origin = strings.ToLower(strings.TrimRight(strings.TrimSpace(origin), "/"))
+		if origin == "*" {
+			return nil
+		}
+		if origin != "" {
+			allowed[origin] = true
+		}
+	}
+	if len(allowed) == 0 {
+		return nil
+	}
+	return &CORSPolicy{origins: allowed}
+}
+
+// SetHeaders sets the CORS headers for r and reports whether its origin is allowed.
+// A nil policy answers any origin with '*'; otherwise an allowed Origin is echoed
+// and other origins get no CORS headers, so browsers refuse the response.
+func (p *CORSPolicy) SetHeaders(w http.ResponseWriter, r *http.Request, allowHeaders string) bool {
+	if p == nil {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+	} else {
+		// The answer depends on Origin, so caches must not share it across origins
+		w.Header().Add("Vary", "Origin")
+		origin := r.Header.Get("Origin")
+		if !p.origins[strings.ToLower(origin)] {
+			return false
+		}
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+	}
+
+	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", allowHeaders)
+	return true
+}
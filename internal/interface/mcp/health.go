@@ -0,0 +1,64 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"url-db/internal/constants"
+)
+
+// HealthReport is what /health in SSE and HTTP modes and the get_health tool return
+type HealthReport struct {
+	Status        string `json:"status"` // HealthStatusOK, or HealthStatusDegraded when the database check fails
+	Mode          string `json:"mode,omitempty"`
+	Server        string `json:"server"`
+	ToolName      string `json:"tool_name"`
+	UptimeSeconds int64  `json:"uptime_seconds"`
+	Database      string `json:"database"`        // "ok" or "error"
+	Error         string `json:"error,omitempty"` // Why the database check failed
+}
+
+// HealthFunc checks the server's health for the /health endpoint
+type HealthFunc func(ctx context.Context) HealthReport
+
+// health checks that the database answers a query within HealthCheckTimeout
+func (h *MCPToolHandler) health(ctx context.Context) HealthReport {
+	report := HealthReport{
+		Status:        constants.HealthStatusOK,
+		Server:        constants.MCPServerName,
+		ToolName:      h.toolName,
+		UptimeSeconds: int64(time.Since(h.startedAt).Seconds()),
+		Database:      "ok",
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, constants.HealthCheckTimeout)
+	defer cancel()
+	if err := h.dependencies.StorageRepo.Ping(ctx); err != nil {
+		report.Status = constants.HealthStatusDegraded
+		report.Database = "error"
+		report.Error = err.Error()
+	}
+	return report
+}
+
+// writeHealth answers a /health request with the report of check, or a static ok
+// report without one. A degraded server answers 503 so load balancers take it out
+// of rotation.
+func writeHealth(w http.ResponseWriter, r *http.Request, check HealthFunc, mode string) {
+	report := HealthReport{Status: constants.HealthStatusOK, Server: constants.MCPServerName}
+	if check != nil {
+		report = check(r.Context())
+	}
+	report.Mode = mode
+
+	status := http.StatusOK
+	if report.Status != constants.HealthStatusOK {
+		status = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,67 @@
+package mcp
+
+import "fmt"
+
+// Pagination is the "pagination" object every list, scan and filter tool puts in its
+// structured content, so clients can page through any tool the same way
+type Pagination struct {
+	Page        int    `json:"page"`        // 1-based; 0 with cursor pagination
+	Size        int    `json:"size"`        // Items per page
+	TotalCount  int    `json:"total_count"` // 0 with cursor pagination, which does not count
+	TotalPages  int    `json:"total_pages"` // 0 with cursor pagination
+	HasMore     bool   `json:"has_more"`
+	HasPrevious bool   `json:"has_previous"`
+	NextCursor  string `json:"next_cursor,omitempty"` // Cursor of the next page, for cursor pagination
+}
+
+// newPagination builds the pagination of a page-numbered listing
+func newPagination(page, size, totalCount int) Pagination {
+	totalPages := 0
+	if size > 0 {
+		totalPages = (totalCount + size - 1) / size
+	}
+
+	return Pagination{
+		Page:        page,
+		Size:        size,
+		TotalCount:  totalCount,
+		TotalPages:  totalPages,
+		HasMore:     page < totalPages,
+		HasPrevious: page > 1,
+	}
+}
+
+// pageSizeCappedText tells clients reading only text content that the requested page
+// size was lowered to the server's maximum; it is "" when the size was not capped
+func pageSizeCappedText(requested, size int) string {
+	if requested <= size {
+		return ""
+	}
+	return fmt.Sprintf("Page size capped at %d (requested %d)", size, requested)
+}
+
+// newCursorPagination builds the pagination of a cursor listing; nextCursor is empty
+// on the last page
+func newCursorPagination(cursor string, size int, nextCursor string) Pagination {
+	return Pagination{
+		Size:        size,
+		HasMore:     nextCursor != "",
+		HasPrevious: cursor != "",
+		NextCursor:  nextCursor,
+	}
+}
+
+// paginationSchema describes Pagination in tool output schemas
+var paginationSchema = map[string]interface{}{
+	"type": "object",
+	"properties": map[string]interface{}{
+		"page":         map[string]interface{}{"type": "integer"},
+		"size":         map[string]interface{}{"type": "integer"},
+		"total_count":  map[string]interface{}{"type": "integer"},
+		"total_pages":  map[string]interface{}{"type": "integer"},
+		"has_more":     map[string]interface{}{"type": "boolean"},
+		"has_previous": map[string]interface{}{"type": "boolean"},
+		"next_cursor":  map[string]interface{}{"type": "string"},
+	},
+	"required": []string{"page", "size", "total_count", "total_pages", "has_more", "has_previous"},
+}
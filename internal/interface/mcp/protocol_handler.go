@@ -3,25 +3,249 @@ package mcp
 import (
 	"context"
 	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
 
 	"url-db/internal/constants"
+	"url-db/internal/domain/attribute"
+	"url-db/internal/domain/entity"
+	"url-db/internal/domain/repository"
+	"url-db/internal/infrastructure/events"
+	"url-db/internal/infrastructure/fetcher"
 	"url-db/internal/interface/setup"
 )
 
 // MCPProtocolHandler handles MCP JSON-RPC 2.0 protocol logic
 type MCPProtocolHandler struct {
-	factory     *setup.ApplicationFactory
-	toolHandler *MCPToolHandler
-	mode        string
+	factory          *setup.ApplicationFactory
+	toolHandler      *MCPToolHandler
+	mode             string
+	responseEnvelope bool                   // Attach server metadata to tool results
+	stopSweeper      func()                 // Stops the expired node sweeper, if running
+	deliveryWorker   *events.DeliveryWorker // Posts events to subscribers, if running
+	adminTools       bool                   // Expose the tools in adminToolNames
+	readOnly         bool                   // Expose only the tools in readOnlyToolNames
+	authRequired     bool                   // HTTP/SSE requests need the API key, checked by the transport
+
+	defaultToolTimeout time.Duration            // Limit for tools without their own entry (0 = none)
+	toolTimeouts       map[string]time.Duration // Per-tool limits by tool name
 }
 
 // NewMCPProtocolHandler creates a new protocol handler
 func NewMCPProtocolHandler(factory *setup.ApplicationFactory, mode string) *MCPProtocolHandler {
 	return &MCPProtocolHandler{
-		factory:     factory,
-		toolHandler: NewMCPToolHandler(factory),
-		mode:        mode,
+		factory:            factory,
+		toolHandler:        NewMCPToolHandler(factory),
+		mode:               mode,
+		defaultToolTimeout: constants.DefaultToolTimeout,
+	}
+}
+
+// SetResponseEnvelope enables or disables the server metadata envelope on tool results
+func (h *MCPProtocolHandler) SetResponseEnvelope(enabled bool) {
+	h.responseEnvelope = enabled
+}
+
+// SetTitleFetcher replaces the fetcher used by create_node's fetch_title option
+func (h *MCPProtocolHandler) SetTitleFetcher(titleFetcher *fetcher.TitleFetcher) {
+	h.toolHandler.titleFetcher = titleFetcher
+}
+
+// SetDependencyTypes sets the dependency types accepted by create_dependency
+func (h *MCPProtocolHandler) SetDependencyTypes(types []string) {
+	if len(types) > 0 {
+		h.toolHandler.dependencyTypes = types
+	}
+}
+
+// SetAllowedURLSchemes sets the URL schemes nodes may use; an empty list keeps http and https
+func (h *MCPProtocolHandler) SetAllowedURLSchemes(schemes []string) {
+	h.toolHandler.dependencies.CreateNodeUC.SetAllowedSchemes(schemes)
+}
+
+// Health checks that the server and its database are healthy
+func (h *MCPProtocolHandler) Health(ctx context.Context) HealthReport {
+	return h.toolHandler.health(ctx)
+}
+
+// SetSoftWarnings enables or disables soft validation warnings on create/update results
+func (h *MCPProtocolHandler) SetSoftWarnings(enabled bool) {
+	h.toolHandler.softWarnings = enabled
+}
+
+// SetCompactJSON selects compact (true) or indented (false) JSON inside text content
+func (h *MCPProtocolHandler) SetCompactJSON(enabled bool) {
+	h.toolHandler.compactJSON = enabled
+}
+
+// SetNodeLimits sets the default per-domain node cap and per-domain overrides (0 = unlimited)
+func (h *MCPProtocolHandler) SetNodeLimits(maxNodes int, domainMaxNodes map[string]int) {
+	h.toolHandler.dependencies.CreateNodeUC.SetNodeLimits(maxNodes, domainMaxNodes)
+}
+
+// SetLowercaseDomainNames enforces lowercase domain names on creation and in
+// domain_name arguments
+func (h *MCPProtocolHandler) SetLowercaseDomainNames(enabled bool) {
+	h.toolHandler.lowercaseDomainNames = enabled
+	h.toolHandler.dependencies.CreateDomainUC.SetLowercaseNames(enabled)
+}
+
+// SetDomainNameUnicode selects how non-ASCII characters in domain names are handled:
+// constants.DomainNameUnicodeReject refuses them, constants.DomainNameUnicodeNFKC folds
+// full-width and accented letters to ASCII first
+func (h *MCPProtocolHandler) SetDomainNameUnicode(mode string) {
+	h.toolHandler.domainNameUnicode = mode
+	h.toolHandler.dependencies.CreateDomainUC.SetUnicodeMode(mode)
+}
+
+// SetMaxFilters caps the filters one filter_nodes_by_attributes call accepts (0 = unlimited)
+func (h *MCPProtocolHandler) SetMaxFilters(maxFilters int) {
+	h.toolHandler.maxFilters = maxFilters
+}
+
+// SetMaxPageSize caps the page size of paginated tools, list_domains, list_nodes and
+// filter_nodes_by_attributes among them; values below 1 restore the default
+func (h *MCPProtocolHandler) SetMaxPageSize(maxSize int) {
+	if maxSize < 1 {
+		maxSize = constants.MaxPageSize
+	}
+	deps := h.toolHandler.dependencies
+	h.toolHandler.maxPageSize = maxSize
+	deps.ListDomainsUC.SetMaxPageSize(maxSize)
+	deps.ListNodesUC.SetMaxPageSize(maxSize)
+	deps.FilterNodesUC.SetMaxPageSize(maxSize)
+}
+
+// SetAdminTools exposes or hides the admin tools, such as explain_filter
+func (h *MCPProtocolHandler) SetAdminTools(enabled bool) {
+	h.adminTools = enabled
+}
+
+// SetReadOnly limits tools/list and tools/call to tools annotated readOnlyHint
+func (h *MCPProtocolHandler) SetReadOnly(enabled bool) {
+	h.readOnly = enabled
+}
+
+// SetAuthRequired records whether the transport requires an API key, for
+// get_server_capabilities; the check itself is done by the transport
+func (h *MCPProtocolHandler) SetAuthRequired(required bool) {
+	h.authRequired = required
+}
+
+// SetScanDefaultOrder sets the created_at order of scan_all_content calls that give none
+// ("asc" or "desc"); other values are ignored
+func (h *MCPProtocolHandler) SetScanDefaultOrder(order string) {
+	if parsed, err := repository.ParseSortOrder(order); err == nil {
+		h.toolHandler.scanDefaultOrder = parsed
+	}
+}
+
+// SetAttributeTransforms replaces the default value transforms of the attribute types
+// named in overrides (type -> transform names, applied in order) for stored values and
+// equals filters alike. On an unknown type or transform the defaults stay in place.
+func (h *MCPProtocolHandler) SetAttributeTransforms(overrides map[string][]string) error {
+	pipeline, err := attribute.NewTransformPipeline(overrides)
+	if err != nil {
+		return err
+	}
+	h.toolHandler.dependencies.SetNodeAttributesUC.SetTransforms(pipeline)
+	h.toolHandler.dependencies.RenameAttributeValueUC.SetTransforms(pipeline)
+	h.toolHandler.dependencies.FilterNodesUC.SetTransforms(pipeline)
+	return nil
+}
+
+// SetAttributeNameRules sets the minimum length and the pattern new attribute names
+// must follow. On an invalid pattern or length the default rules stay in place.
+func (h *MCPProtocolHandler) SetAttributeNameRules(minLength int, pattern string) error {
+	rules, err := entity.NewAttributeNameRules(minLength, pattern)
+	if err != nil {
+		return err
+	}
+	h.toolHandler.dependencies.CreateAttributeUC.SetNameRules(rules)
+	return nil
+}
+
+// SetToolTimeouts sets the default tool time limit and per-tool overrides (0 = no limit)
+func (h *MCPProtocolHandler) SetToolTimeouts(defaultTimeout time.Duration, toolTimeouts map[string]time.Duration) {
+	h.defaultToolTimeout = defaultTimeout
+	h.toolTimeouts = toolTimeouts
+}
+
+// SetEventBuffer switches node event recording to an in-memory buffer flushed every
+// flushInterval or once size events are pending. A size of 0 keeps synchronous writes.
+func (h *MCPProtocolHandler) SetEventBuffer(size int, flushInterval time.Duration) {
+	if size <= 0 {
+		return
+	}
+	deps := h.toolHandler.dependencies
+	deps.EventRecorder = events.NewBufferedRecorder(deps.NodeEventRepo, events.BufferConfig{
+		FlushInterval: flushInterval,
+		MaxSize:       size,
+	})
+}
+
+// StartExpirySweeper deletes expired nodes every interval until Close is called.
+// Expired nodes are hidden from reads either way; the sweeper reclaims their rows.
+func (h *MCPProtocolHandler) StartExpirySweeper(interval time.Duration) {
+	if interval <= 0 || h.stopSweeper != nil {
+		return
+	}
+
+	sweepUC := h.toolHandler.dependencies.SweepExpiredNodesUC
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := sweepUC.Execute(context.Background()); err != nil {
+					fmt.Fprintf(os.Stderr, "Failed to delete expired nodes: %v\n", err)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	h.stopSweeper = func() {
+		close(stop)
+		<-done
+	}
+}
+
+// StartSubscriptionWorker delivers node events to subscriber endpoints every interval
+// until Close is called. Failed POSTs are retried retries times with exponential backoff.
+func (h *MCPProtocolHandler) StartSubscriptionWorker(interval time.Duration, retries int) {
+	if interval <= 0 || h.deliveryWorker != nil {
+		return
 	}
+
+	deps := h.toolHandler.dependencies
+	h.deliveryWorker = events.NewDeliveryWorker(deps.NodeSubscriptionRepo, events.DeliveryConfig{
+		PollInterval: interval,
+		RetryCount:   retries,
+		Recorder:     deps.EventRecorder,
+	})
+	h.deliveryWorker.Start()
+}
+
+// Close stops the background workers and flushes buffered node events
+func (h *MCPProtocolHandler) Close(ctx context.Context) error {
+	if h.stopSweeper != nil {
+		h.stopSweeper()
+		h.stopSweeper = nil
+	}
+	if h.deliveryWorker != nil {
+		h.deliveryWorker.Stop()
+		h.deliveryWorker = nil
+	}
+	return h.toolHandler.dependencies.EventRecorder.Close(ctx)
 }
 
 // HandleRequest processes a JSON-RPC request and returns a response
@@ -40,9 +264,9 @@ func (h *MCPProtocolHandler) HandleRequest(ctx context.Context, req *JSONRPCRequ
 	case "tools/call":
 		return h.handleToolCall(ctx, req)
 	case "resources/list":
-		return h.handleResourcesList(req)
+		return h.handleResourcesList(ctx, req)
 	case "resources/read":
-		return h.handleResourceRead(req)
+		return h.handleResourceRead(ctx, req)
 	case "notifications/initialized":
 		// Client notification that initialization is complete
 		// No response needed for notifications
@@ -85,6 +309,14 @@ func (h *MCPProtocolHandler) handleInitialize(req *JSONRPCRequest) *JSONRPCRespo
 				"subscribe":   true,
 				"listChanged": true,
 			},
+			// Composite IDs embed the tool name, so clients talking to several
+			// instances can use it to detect IDs sent to the wrong server
+			"experimental": map[string]interface{}{
+				"compositeId": map[string]interface{}{
+					"toolName": h.factory.ToolName(),
+					"format":   "tool-name:domain:id",
+				},
+			},
 		},
 		"serverInfo": map[string]interface{}{
 			"name":    constants.MCPServerName,
@@ -97,11 +329,10 @@ func (h *MCPProtocolHandler) handleInitialize(req *JSONRPCRequest) *JSONRPCRespo
 
 // handleToolsList returns available MCP tools with standard format
 func (h *MCPProtocolHandler) handleToolsList(req *JSONRPCRequest) *JSONRPCResponse {
-	toolDefs := GetToolDefinitions()
-	tools := make([]map[string]interface{}, len(toolDefs))
-	
-	for i, def := range toolDefs {
-		tools[i] = def.ToMap()
+	toolDefs := h.visibleToolDefinitions()
+	tools := make([]map[string]interface{}, 0, len(toolDefs))
+	for _, def := range toolDefs {
+		tools = append(tools, def.ToMap())
 	}
 
 	result := map[string]interface{}{
@@ -112,37 +343,114 @@ func (h *MCPProtocolHandler) handleToolsList(req *JSONRPCRequest) *JSONRPCRespon
 }
 
 
+// visibleToolDefinitions returns the tools this server advertises and accepts, leaving
+// out admin tools unless enabled and mutating tools in read-only mode
+func (h *MCPProtocolHandler) visibleToolDefinitions() []ToolDefinition {
+	var visible []ToolDefinition
+	for _, def := range GetToolDefinitions() {
+		if adminToolNames[def.Name] && !h.adminTools {
+			continue
+		}
+		if h.readOnly && !readOnlyToolNames[def.Name] {
+			continue
+		}
+		visible = append(visible, def)
+	}
+	return visible
+}
+
 // handleGetServerInfo returns server information
 func (h *MCPProtocolHandler) handleGetServerInfo(req *JSONRPCRequest) *JSONRPCResponse {
+	eventBufferDepth := h.toolHandler.dependencies.EventRecorder.Pending()
+
 	result := map[string]interface{}{
 		"content": []map[string]interface{}{
 			{
 				"type": "text",
-				"text": fmt.Sprintf("Server: %s v%s\nMode: %s\nProtocol: MCP %s",
+				"text": fmt.Sprintf("Server: %s v%s\nMode: %s\nProtocol: MCP %s\nEvent buffer depth: %d",
 					constants.MCPServerName,
 					constants.DefaultServerVersion,
 					h.mode,
 					constants.MCPProtocolVersion,
+					eventBufferDepth,
 				),
 			},
 		},
+		"structuredContent": map[string]interface{}{
+			"name":             constants.MCPServerName,
+			"version":          constants.DefaultServerVersion,
+			"mode":             h.mode,
+			"protocol_version": constants.MCPProtocolVersion,
+			"read_only":        h.readOnly,
+			"metrics": map[string]interface{}{
+				"event_buffer_depth": eventBufferDepth,
+			},
+		},
 	}
 
 	return h.createSuccessResponse(req.ID, result)
 }
 
-// handleResourcesList returns available resources (placeholder)
-func (h *MCPProtocolHandler) handleResourcesList(req *JSONRPCRequest) *JSONRPCResponse {
-	result := map[string]interface{}{
-		"resources": []interface{}{},
+// handleGetServerCapabilities reports the configuration-dependent features of this
+// server, so a client can plan around what the instance it talks to supports
+func (h *MCPProtocolHandler) handleGetServerCapabilities(ctx context.Context) (interface{}, error) {
+	deps := h.toolHandler.dependencies
+
+	databasePath, err := deps.StorageRepo.DatabasePath(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database path: %w", err)
 	}
+	fullTextSearch, err := deps.SearchIndexRepo.Available(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check search index: %w", err)
+	}
+	_, eventBuffer := deps.EventRecorder.(*events.BufferedRecorder)
 
-	return h.createSuccessResponse(req.ID, result)
-}
+	subsystems := map[string]bool{
+		"full_text_search":    fullTextSearch,
+		"admin_tools":         h.adminTools,
+		"subscription_worker": h.deliveryWorker != nil,
+		"expiry_sweeper":      h.stopSweeper != nil,
+		"event_buffer":        eventBuffer,
+		"title_fetcher":       h.toolHandler.titleFetcher != nil,
+		"response_envelope":   h.responseEnvelope,
+	}
+	toolCount := len(h.visibleToolDefinitions())
+
+	lines := []string{
+		fmt.Sprintf("Mode: %s", h.mode),
+		fmt.Sprintf("Read-only: %t", h.readOnly),
+		fmt.Sprintf("Auth required: %t", h.authRequired),
+		fmt.Sprintf("Tools: %d", toolCount),
+		fmt.Sprintf("Database: %s", databasePath),
+	}
+	names := make([]string, 0, len(subsystems))
+	for name := range subsystems {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		state := "disabled"
+		if subsystems[name] {
+			state = "enabled"
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s", name, state))
+	}
+
+	content := []map[string]interface{}{
+		createTextContent(strings.Join(lines, "\n")),
+	}
+
+	structuredContent := map[string]interface{}{
+		"mode":          h.mode,
+		"read_only":     h.readOnly,
+		"auth_required": h.authRequired,
+		"tool_count":    toolCount,
+		"database_path": databasePath,
+		"subsystems":    subsystems,
+	}
 
-// handleResourceRead reads a resource (placeholder)
-func (h *MCPProtocolHandler) handleResourceRead(req *JSONRPCRequest) *JSONRPCResponse {
-	return h.createErrorResponse(req.ID, MethodNotFound, "Resource reading not implemented", nil)
+	return createMCPResponse(content, structuredContent), nil
 }
 
 // createSuccessResponse creates a successful JSON-RPC response
@@ -0,0 +1,204 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"url-db/internal/constants"
+)
+
+// Resource URIs
+//
+// Every domain is exposed as a resource and every node can be read through one:
+//
+//	url-db://<domain>            the domain and the first page of its nodes
+//	url-db://<domain>/<node-id>  a single node
+//
+// <domain> is the domain name and <node-id> the numeric node ID, i.e. the last
+// part of the node's composite ID. A node is only found under its own domain.
+// Both resources are returned as JSON documents.
+
+const resourceMimeType = "application/json"
+
+// resourceURI identifies a domain, or a node when nodeID is non-zero
+type resourceURI struct {
+	domainName string
+	nodeID     int
+}
+
+// parseResourceURI parses url-db://domain and url-db://domain/node-id
+func parseResourceURI(uri string) (resourceURI, error) {
+	prefix := constants.URLDBResourceScheme + "://"
+	rest, ok := strings.CutPrefix(uri, prefix)
+	if !ok {
+		return resourceURI{}, fmt.Errorf("resource URI must start with %s", prefix)
+	}
+
+	domainName, nodePart, hasNode := strings.Cut(rest, "/")
+	if domainName == "" {
+		return resourceURI{}, fmt.Errorf("resource URI is missing the domain name")
+	}
+	if !hasNode {
+		return resourceURI{domainName: domainName}, nil
+	}
+
+	nodeID, err := strconv.Atoi(nodePart)
+	if err != nil || nodeID <= 0 {
+		return resourceURI{}, fmt.Errorf("invalid node ID %q, expected url-db://domain/node-id", nodePart)
+	}
+	return resourceURI{domainName: domainName, nodeID: nodeID}, nil
+}
+
+// domainResourceURI builds the resource URI of a domain
+func domainResourceURI(domainName string) string {
+	return fmt.Sprintf("%s://%s", constants.URLDBResourceScheme, domainName)
+}
+
+// nodeResourceURI builds the resource URI of a node
+func nodeResourceURI(domainName string, nodeID int) string {
+	return fmt.Sprintf("%s://%s/%d", constants.URLDBResourceScheme, domainName, nodeID)
+}
+
+// handleResourcesList exposes each domain as a resource
+func (h *MCPProtocolHandler) handleResourcesList(ctx context.Context, req *JSONRPCRequest) *JSONRPCResponse {
+	domainRepo := h.toolHandler.dependencies.DomainRepo
+
+	resources := []map[string]interface{}{}
+	for page := 1; ; page++ {
+		domains, total, err := domainRepo.List(ctx, page, constants.MaxPageSize)
+		if err != nil {
+			return h.createErrorResponse(req.ID, InternalError, "Failed to list resources", err.Error())
+		}
+		for _, domain := range domains {
+			resources = append(resources, map[string]interface{}{
+				"uri":         domainResourceURI(domain.Name()),
+				"name":        domain.Name(),
+				"description": domain.Description(),
+				"mimeType":    resourceMimeType,
+			})
+		}
+		if len(domains) == 0 || len(resources) >= total {
+			break
+		}
+	}
+
+	return h.createSuccessResponse(req.ID, map[string]interface{}{
+		"resources": resources,
+	})
+}
+
+// handleResourceRead returns a domain or node resource by URI
+func (h *MCPProtocolHandler) handleResourceRead(ctx context.Context, req *JSONRPCRequest) *JSONRPCResponse {
+	var params struct {
+		URI string `json:"uri"`
+	}
+	if err := json.Unmarshal(req.Params, &params); err != nil || params.URI == "" {
+		return h.createErrorResponse(req.ID, InvalidParams, "Invalid resource read parameters", "missing 'uri'")
+	}
+
+	uri, err := parseResourceURI(params.URI)
+	if err != nil {
+		return h.createErrorResponse(req.ID, InvalidParams, "Invalid resource URI", err.Error())
+	}
+
+	var document map[string]interface{}
+	if uri.nodeID == 0 {
+		document, err = h.readDomainResource(ctx, uri.domainName)
+	} else {
+		document, err = h.readNodeResource(ctx, uri.domainName, uri.nodeID)
+	}
+	if err != nil {
+		return h.createErrorResponse(req.ID, InternalError, "Failed to read resource", err.Error())
+	}
+	if document == nil {
+		return h.createErrorResponse(req.ID, ResourceNotFound, "Resource not found", params.URI)
+	}
+
+	text, err := json.Marshal(document)
+	if err != nil {
+		return h.createErrorResponse(req.ID, InternalError, "Failed to read resource", err.Error())
+	}
+
+	return h.createSuccessResponse(req.ID, map[string]interface{}{
+		"contents": []map[string]interface{}{
+			{
+				"uri":      params.URI,
+				"mimeType": resourceMimeType,
+				"text":     string(text),
+			},
+		},
+	})
+}
+
+// readDomainResource describes a domain and links its first page of nodes.
+// It returns nil when the domain does not exist.
+func (h *MCPProtocolHandler) readDomainResource(ctx context.Context, domainName string) (map[string]interface{}, error) {
+	deps := h.toolHandler.dependencies
+
+	domain, err := deps.DomainRepo.GetByName(ctx, domainName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get domain: %w", err)
+	}
+	if domain == nil {
+		return nil, nil
+	}
+
+	nodes, total, err := deps.NodeRepo.List(ctx, domainName, 1, constants.MaxPageSize, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	nodeLinks := make([]map[string]interface{}, len(nodes))
+	for i, node := range nodes {
+		nodeLinks[i] = map[string]interface{}{
+			"uri":   nodeResourceURI(domainName, node.ID()),
+			"url":   node.URL(),
+			"title": node.Title(),
+		}
+	}
+
+	return map[string]interface{}{
+		"name":        domain.Name(),
+		"description": domain.Description(),
+		"nodes":       nodeLinks,
+		"total_count": total,
+	}, nil
+}
+
+// readNodeResource returns a node of the given domain, or nil when there is none
+func (h *MCPProtocolHandler) readNodeResource(ctx context.Context, domainName string, nodeID int) (map[string]interface{}, error) {
+	deps := h.toolHandler.dependencies
+
+	domain, err := deps.DomainRepo.GetByName(ctx, domainName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get domain: %w", err)
+	}
+	if domain == nil {
+		return nil, nil
+	}
+
+	node, err := deps.NodeRepo.GetByID(ctx, nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node: %w", err)
+	}
+	if node == nil || node.DomainID() != domain.ID() {
+		return nil, nil
+	}
+
+	document := map[string]interface{}{
+		"composite_id": h.toolHandler.nodeCompositeID(domainName, node.ID()),
+		"url":          node.URL(),
+		"title":        node.Title(),
+		"description":  node.Description(),
+		"created_at":   node.CreatedAt().Format(time.RFC3339),
+		"updated_at":   node.UpdatedAt().Format(time.RFC3339),
+	}
+	if expiresAt := node.ExpiresAt(); expiresAt != nil {
+		document["expires_at"] = expiresAt.Format(time.RFC3339)
+	}
+	return document, nil
+}
@@ -0,0 +1,1144 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"url-db/internal/application/dto/response"
+	"url-db/internal/constants"
+	"url-db/internal/database"
+	"url-db/internal/domain/entity"
+	"url-db/internal/domain/service"
+	"url-db/internal/interface/setup"
+)
+
+// newTestProtocolHandler creates a protocol handler backed by an in-memory database
+func newTestProtocolHandler(t testing.TB) *MCPProtocolHandler {
+	t.Helper()
+
+	h, _ := newTestProtocolHandlerWithDB(t)
+	return h
+}
+
+// newTestProtocolHandlerWithDB also returns the database for seeding rows no tool can create
+func newTestProtocolHandlerWithDB(t testing.TB) (*MCPProtocolHandler, *database.Database) {
+	t.Helper()
+
+	db, err := database.New(database.TestConfig())
+	if err != nil {
+		t.Fatalf("failed to create test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	factory := setup.NewApplicationFactory(db.DB(), db.SQLXDB(), "test-tool")
+	return NewMCPProtocolHandler(factory, constants.MCPModeStdio), db
+}
+
+// callTool invokes a tool through tools/call and returns the raw response
+func callTool(t testing.TB, h *MCPProtocolHandler, name string, args map[string]interface{}) *JSONRPCResponse {
+	t.Helper()
+
+	params, err := json.Marshal(map[string]interface{}{
+		"name":      name,
+		"arguments": args,
+	})
+	if err != nil {
+		t.Fatalf("failed to marshal params: %v", err)
+	}
+
+	return h.HandleRequest(context.Background(), &JSONRPCRequest{
+		JSONRPC: constants.JSONRPCVersion,
+		ID:      1,
+		Method:  "tools/call",
+		Params:  params,
+	})
+}
+
+// structuredContent extracts the structured content of a successful tool response
+func structuredContent(t testing.TB, resp *JSONRPCResponse) map[string]interface{} {
+	t.Helper()
+
+	if resp.Error != nil {
+		t.Fatalf("unexpected error: %s (%v)", resp.Error.Message, resp.Error.Data)
+	}
+	result, ok := resp.Result.(map[string]interface{})
+	if !ok {
+		t.Fatalf("unexpected result type %T", resp.Result)
+	}
+	structured, ok := result["structuredContent"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("structuredContent missing from result: %v", result)
+	}
+	return structured
+}
+
+func TestResponseEnvelope(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+
+	// Disabled by default
+	structured := structuredContent(t, callTool(t, h, "list_domains", nil))
+	if _, ok := structured["_server"]; ok {
+		t.Fatalf("envelope should be absent by default")
+	}
+
+	h.SetResponseEnvelope(true)
+	structured = structuredContent(t, callTool(t, h, "list_domains", nil))
+
+	envelope, ok := structured["_server"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("envelope missing when enabled: %v", structured)
+	}
+	if envelope["name"] != constants.MCPServerName {
+		t.Errorf("name = %v, want %s", envelope["name"], constants.MCPServerName)
+	}
+	if envelope["version"] != constants.DefaultServerVersion {
+		t.Errorf("version = %v, want %s", envelope["version"], constants.DefaultServerVersion)
+	}
+	if envelope["tool_name"] != "test-tool" {
+		t.Errorf("tool_name = %v, want test-tool", envelope["tool_name"])
+	}
+}
+
+func TestInitializeReportsToolName(t *testing.T) {
+	h := newTestProtocolHandler(t)
+
+	resp := h.HandleRequest(context.Background(), &JSONRPCRequest{
+		JSONRPC: constants.JSONRPCVersion,
+		ID:      1,
+		Method:  "initialize",
+	})
+	if resp.Error != nil {
+		t.Fatalf("unexpected error: %s", resp.Error.Message)
+	}
+
+	result := resp.Result.(map[string]interface{})
+	capabilities := result["capabilities"].(map[string]interface{})
+	experimental, ok := capabilities["experimental"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("experimental capabilities missing: %v", capabilities)
+	}
+	compositeID, ok := experimental["compositeId"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("compositeId capability missing: %v", experimental)
+	}
+	if compositeID["toolName"] != "test-tool" {
+		t.Errorf("toolName = %v, want test-tool", compositeID["toolName"])
+	}
+
+	// Composite IDs handed out by tools must use the same tool name
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	node := structuredContent(t, callTool(t, h, "create_node", map[string]interface{}{
+		"domain_name": "docs",
+		"url":         "https://example.com",
+	}))
+	if node["composite_id"] != "test-tool:docs:1" {
+		t.Errorf("composite_id = %v, want test-tool:docs:1", node["composite_id"])
+	}
+}
+
+func TestHTTPTransportShutdownDrainsRequests(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to find a free port: %v", err)
+	}
+	port := strconv.Itoa(listener.Addr().(*net.TCPAddr).Port)
+	listener.Close()
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	transport := NewHTTPTransport(&TransportConfig{Mode: constants.MCPModeHTTP, Port: port})
+	transport.SetRequestHandler(func(ctx context.Context, req *JSONRPCRequest) *JSONRPCResponse {
+		close(entered)
+		<-release
+		return &JSONRPCResponse{JSONRPC: constants.JSONRPCVersion, ID: req.ID, Result: "ok"}
+	})
+
+	startErr := make(chan error, 1)
+	go func() { startErr <- transport.Start(context.Background()) }()
+
+	// Send one request and hold it in the handler
+	requestDone := make(chan int, 1)
+	go func() {
+		for i := 0; i < 50; i++ {
+			resp, err := http.Post("http://127.0.0.1:"+port+"/mcp", "application/json",
+				strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"ping"}`))
+			if err == nil {
+				resp.Body.Close()
+				requestDone <- resp.StatusCode
+				return
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		requestDone <- 0
+	}()
+	<-entered
+
+	shutdownErr := make(chan error, 1)
+	go func() { shutdownErr <- transport.Shutdown(context.Background()) }()
+
+	select {
+	case err := <-shutdownErr:
+		t.Fatalf("shutdown returned before the in-flight request finished: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(release)
+	if status := <-requestDone; status != http.StatusOK {
+		t.Errorf("in-flight request status = %d, want 200", status)
+	}
+	if err := <-shutdownErr; err != nil {
+		t.Errorf("shutdown failed: %v", err)
+	}
+	if err := <-startErr; err != nil {
+		t.Errorf("Start should return nil after shutdown, got %v", err)
+	}
+}
+
+// postSSE sends one request to an SSE transport's endpoint and returns the raw event stream
+func postSSE(transport *SSETransport, body string) string {
+	recorder := httptest.NewRecorder()
+	transport.handleSSEEndpoint(recorder, httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body)))
+	return recorder.Body.String()
+}
+
+func TestSSETransportSendsDoneEvent(t *testing.T) {
+	handler := func(ctx context.Context, req *JSONRPCRequest) *JSONRPCResponse {
+		if req.ID == nil {
+			return nil
+		}
+		return &JSONRPCResponse{JSONRPC: constants.JSONRPCVersion, ID: req.ID, Result: "ok"}
+	}
+
+	transport := NewSSETransport(&TransportConfig{Mode: constants.MCPModeSSE, DoneEvent: constants.DefaultSSEDoneEvent})
+	transport.SetRequestHandler(handler)
+
+	expected := "data: {\"jsonrpc\":\"2.0\",\"id\":1,\"result\":\"ok\"}\n\n" +
+		"event: done\ndata: {\"id\":1}\n\n"
+	if got := postSSE(transport, `{"jsonrpc":"2.0","id":1,"method":"ping"}`); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+
+	// Notifications have no response but still end the stream explicitly
+	if got := postSSE(transport, `{"jsonrpc":"2.0","method":"notifications/initialized"}`); got != "event: done\ndata: {\"id\":null}\n\n" {
+		t.Errorf("unexpected notification stream %q", got)
+	}
+
+	transport.SetDoneEvent("")
+	if got := postSSE(transport, `{"jsonrpc":"2.0","id":2,"method":"ping"}`); strings.Contains(got, "event:") {
+		t.Errorf("expected no done event when disabled, got %q", got)
+	}
+}
+
+func TestScanAllContentStreamsOverSSE(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": "https://example.com/a"})
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": "https://example.com/b"})
+
+	transport := NewSSETransport(&TransportConfig{Mode: constants.MCPModeSSE, DoneEvent: constants.DefaultSSEDoneEvent})
+	transport.SetRequestHandler(h.HandleRequest)
+
+	stream := postSSE(transport, `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"scan_all_content","arguments":{"domain_name":"docs","stream":true}}}`)
+	frames := strings.Split(strings.TrimSuffix(stream, "\n\n"), "\n\n")
+	if len(frames) != 4 {
+		t.Fatalf("expected 2 items, the response and done, got %d frames: %q", len(frames), stream)
+	}
+
+	for i, url := range []string{"https://example.com/a", "https://example.com/b"} {
+		var notification struct {
+			Method string `json:"method"`
+			Params struct {
+				Index int `json:"index"`
+				Item  struct {
+					Content string `json:"content"`
+				} `json:"item"`
+			} `json:"params"`
+		}
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(frames[i], "data: ")), &notification); err != nil {
+			t.Fatalf("invalid item frame %q: %v", frames[i], err)
+		}
+		if notification.Method != constants.MCPScanItemNotificationMethod || notification.Params.Index != i || notification.Params.Item.Content != url {
+			t.Errorf("unexpected item frame %q", frames[i])
+		}
+	}
+
+	var resp struct {
+		ID     int `json:"id"`
+		Result struct {
+			Result map[string]interface{} `json:"result"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(frames[2], "data: ")), &resp); err != nil {
+		t.Fatalf("invalid response frame %q: %v", frames[2], err)
+	}
+	if resp.ID != 1 || resp.Result.Result["streamed_items"] != float64(2) || resp.Result.Result["items"] != nil {
+		t.Errorf("unexpected completion frame %q", frames[2])
+	}
+	if !strings.HasPrefix(frames[3], "event: done") {
+		t.Errorf("expected the done event last, got %q", frames[3])
+	}
+
+	// Without an SSE stream the flag falls back to the batched response
+	result := callTool(t, h, "scan_all_content", map[string]interface{}{"domain_name": "docs", "stream": true}).Result.(map[string]interface{})["result"].(map[string]interface{})
+	if items, ok := result["items"].([]response.NodeWithAttributes); !ok || len(items) != 2 {
+		t.Errorf("expected 2 batched items, got %v", result["items"])
+	}
+}
+
+func TestScanAllContentResultOverStdio(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	h.SetResponseEnvelope(true)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": "https://example.com/a"})
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": "https://example.com/b"})
+
+	var out strings.Builder
+	stdio := NewStdioTransport(&TransportConfig{Mode: constants.MCPModeStdio, Writer: &out,
+		Reader: strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"scan_all_content","arguments":{"domain_name":"docs"}}}` + "\n")})
+	stdio.SetRequestHandler(h.HandleRequest)
+	if err := stdio.Start(context.Background()); err != nil {
+		t.Fatalf("stdio transport failed: %v", err)
+	}
+
+	// The structured scan output reaches the client next to the text summary
+	var resp struct {
+		Result struct {
+			Content []map[string]interface{} `json:"content"`
+			Result  struct {
+				Items      []map[string]interface{} `json:"items"`
+				Pagination map[string]interface{}   `json:"pagination"`
+				Metadata   map[string]interface{}   `json:"metadata"`
+			} `json:"result"`
+		} `json:"result"`
+		Error *RPCError `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(out.String()), &resp); err != nil || resp.Error != nil {
+		t.Fatalf("unexpected response %q", out.String())
+	}
+	scan := resp.Result.Result
+	if len(resp.Result.Content) != 1 || len(scan.Items) != 2 || scan.Pagination == nil || scan.Metadata == nil {
+		t.Fatalf("expected the text summary and result with items, pagination and metadata, got %q", out.String())
+	}
+	if scan.Items[0]["content"] != "https://example.com/a" {
+		t.Errorf("unexpected first item %v", scan.Items[0])
+	}
+}
+
+func TestToolTimeouts(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	h.SetToolTimeouts(20*time.Millisecond, map[string]time.Duration{"scan_all_content": time.Second})
+
+	req := &JSONRPCRequest{JSONRPC: constants.JSONRPCVersion, ID: 1, Method: "tools/call"}
+	ok := &JSONRPCResponse{JSONRPC: constants.JSONRPCVersion, ID: 1, Result: "ok"}
+
+	// slowHandler takes 100ms and ignores its context
+	slowHandler := func(ctx context.Context, req *JSONRPCRequest) *JSONRPCResponse {
+		time.Sleep(100 * time.Millisecond)
+		return ok
+	}
+
+	start := time.Now()
+	resp := h.runWithToolTimeout(context.Background(), req, "get_node", slowHandler)
+	if resp.Error == nil || resp.Error.Code != ToolTimeout {
+		t.Fatalf("expected a timeout error, got %+v", resp)
+	}
+	if data := resp.Error.Data.(string); !strings.Contains(data, "get_node") || !strings.Contains(data, "20ms") {
+		t.Errorf("timeout error should name the tool and limit, got %q", data)
+	}
+	if elapsed := time.Since(start); elapsed >= 100*time.Millisecond {
+		t.Errorf("timeout response waited for the handler (%v)", elapsed)
+	}
+
+	// The per-tool entry gives scan_all_content more time than the default
+	if resp := h.runWithToolTimeout(context.Background(), req, "scan_all_content", slowHandler); resp != ok {
+		t.Errorf("expected scan_all_content to finish within its own limit, got %+v", resp)
+	}
+
+	// A handler that gives up when its context expires still reports a timeout
+	ctxHandler := func(ctx context.Context, req *JSONRPCRequest) *JSONRPCResponse {
+		<-ctx.Done()
+		return h.createErrorResponse(req.ID, InternalError, "Tool execution failed", ctx.Err().Error())
+	}
+	if resp := h.runWithToolTimeout(context.Background(), req, "get_node", ctxHandler); resp.Error == nil || resp.Error.Code != ToolTimeout {
+		t.Errorf("expected a timeout error, got %+v", resp)
+	}
+
+	// A panicking handler becomes an error response instead of crashing the server
+	panicHandler := func(ctx context.Context, req *JSONRPCRequest) *JSONRPCResponse {
+		var domain *entity.Domain
+		return &JSONRPCResponse{Result: domain.Name()}
+	}
+	if resp := h.runWithToolTimeout(context.Background(), req, "get_node", panicHandler); resp.Error == nil || resp.Error.Code != InternalError || !strings.Contains(resp.Error.Data.(string), "get_node panicked") {
+		t.Errorf("expected an internal error for the panic, got %+v", resp)
+	}
+
+	// A limit of 0 disables the timeout
+	h.SetToolTimeouts(0, nil)
+	if resp := h.runWithToolTimeout(context.Background(), req, "get_node", slowHandler); resp != ok {
+		t.Errorf("expected no timeout when disabled, got %+v", resp)
+	}
+}
+
+func TestLowercaseDomainNames(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	h.SetLowercaseDomainNames(true)
+
+	created := structuredContent(t, callTool(t, h, "create_domain", map[string]interface{}{
+		"name":        "Docs",
+		"description": "Documentation",
+	}))
+	if created["name"] != "docs" {
+		t.Fatalf("expected the domain to be stored as docs, got %v", created["name"])
+	}
+
+	// Any casing of the name finds the same domain
+	found := structuredContent(t, callTool(t, h, "get_domain", map[string]interface{}{"domain_name": "DOCS"}))
+	if found["name"] != "docs" {
+		t.Errorf("expected DOCS to resolve to docs, got %v", found["name"])
+	}
+
+	// A name differing only by case is a duplicate
+	if resp := callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Again"}); resp.Error == nil {
+		t.Error("expected a duplicate domain error")
+	}
+
+	// Every argument naming a domain follows the same rule, not just domain_name
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "blog", "description": "Blog"})
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "Docs", "url": "https://example.com/a"})
+	diff := structuredContent(t, callTool(t, h, "diff_domain_schemas", map[string]interface{}{"domain_a": "DOCS", "domain_b": "Blog"}))
+	if diff["domain_a"] != "docs" || diff["domain_b"] != "blog" {
+		t.Errorf("expected the diff to resolve both domains, got %v", diff)
+	}
+	moved := structuredContent(t, callTool(t, h, "move_node", map[string]interface{}{"composite_id": "test-tool:docs:1", "target_domain_name": "BLOG"}))
+	if moved["composite_id"] != "test-tool:blog:1" {
+		t.Errorf("expected BLOG to resolve to blog, got %v", moved)
+	}
+	moved = structuredContent(t, callTool(t, h, "move_nodes", map[string]interface{}{"composite_ids": []interface{}{"test-tool:blog:1"}, "target_domain_name": " Docs "}))
+	if moved["target_domain_name"] != "docs" {
+		t.Errorf("expected Docs to resolve to docs, got %v", moved)
+	}
+}
+
+func TestDomainNameUnicode(t *testing.T) {
+	tests := []struct {
+		mode     string
+		input    string
+		expected string // Empty when the name is refused
+	}{
+		{constants.DomainNameUnicodeReject, "  docs\t\n", "docs"},
+		{constants.DomainNameUnicodeReject, "\u3000my_docs\u3000", "my_docs"},
+		{constants.DomainNameUnicodeReject, "ｄｏｃｓ", ""},
+		{constants.DomainNameUnicodeReject, "café", ""},
+		{constants.DomainNameUnicodeReject, "my docs", ""},
+		{constants.DomainNameUnicodeNFKC, " ｄｏｃｓ－２ ", "docs-2"},
+		{constants.DomainNameUnicodeNFKC, "café", "cafe"},
+		{constants.DomainNameUnicodeNFKC, "문서", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode+"/"+tt.input, func(t *testing.T) {
+			h := newTestProtocolHandler(t)
+			h.SetDomainNameUnicode(tt.mode)
+
+			// The entity rule, the domain service and create_domain agree
+			cleaned, entityErr := entity.CleanDomainName(tt.input, tt.mode)
+			svc := service.NewDomainService(h.toolHandler.dependencies.DomainRepo, tt.mode)
+			created, serviceErr := svc.CreateDomain(context.Background(), tt.input, "")
+			resp := callTool(t, h, "create_domain", map[string]interface{}{"name": tt.input, "description": "Docs"})
+
+			if tt.expected == "" {
+				if entityErr == nil || serviceErr == nil || resp.Error == nil {
+					t.Fatalf("expected every layer to refuse %q: entity=%v service=%v mcp=%v", tt.input, entityErr, serviceErr, resp.Error)
+				}
+				return
+			}
+			if entityErr != nil || serviceErr != nil {
+				t.Fatalf("expected %q to be accepted: entity=%v service=%v", tt.input, entityErr, serviceErr)
+			}
+			if cleaned != tt.expected || created.Name() != tt.expected {
+				t.Errorf("entity=%q service=%q, want %q", cleaned, created.Name(), tt.expected)
+			}
+
+			// The service already stored the name, so create_domain reports a duplicate
+			if resp.Error == nil || !strings.Contains(fmt.Sprint(resp.Error.Data), "exist") {
+				t.Errorf("expected create_domain to clean %q to the existing %q, got %+v", tt.input, tt.expected, resp)
+			}
+			found := structuredContent(t, callTool(t, h, "get_domain", map[string]interface{}{"domain_name": tt.input}))
+			if found["name"] != tt.expected {
+				t.Errorf("get_domain(%q) found %v, want %s", tt.input, found["name"], tt.expected)
+			}
+		})
+	}
+}
+
+func TestFilterNodesByAttributesMaxFilters(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	structuredContent(t, callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"}))
+
+	filters := func(n int) []interface{} {
+		list := make([]interface{}, n)
+		for i := range list {
+			list[i] = map[string]interface{}{"name": "tag", "value": fmt.Sprintf("v%d", i)}
+		}
+		return list
+	}
+
+	// Exactly the limit is accepted
+	if resp := callTool(t, h, "filter_nodes_by_attributes", map[string]interface{}{
+		"domain_name": "docs",
+		"filters":     filters(constants.DefaultMaxFilters),
+	}); resp.Error != nil {
+		t.Fatalf("expected %d filters to be accepted: %v", constants.DefaultMaxFilters, resp.Error.Data)
+	}
+
+	// One more is refused with a clear error
+	resp := callTool(t, h, "filter_nodes_by_attributes", map[string]interface{}{
+		"domain_name": "docs",
+		"filters":     filters(constants.DefaultMaxFilters + 1),
+	})
+	if resp.Error == nil {
+		t.Fatal("expected an error for too many filters")
+	}
+	if data := fmt.Sprint(resp.Error.Data); !strings.Contains(data, "too many filters: got 21, at most 20") {
+		t.Errorf("unexpected error: %s", data)
+	}
+
+	// 0 removes the cap
+	h.SetMaxFilters(0)
+	if resp := callTool(t, h, "filter_nodes_by_attributes", map[string]interface{}{
+		"domain_name": "docs",
+		"filters":     filters(constants.DefaultMaxFilters + 1),
+	}); resp.Error != nil {
+		t.Errorf("expected no cap when disabled: %v", resp.Error.Data)
+	}
+}
+
+func TestResources(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	structuredContent(t, callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"}))
+	structuredContent(t, callTool(t, h, "create_domain", map[string]interface{}{"name": "blog", "description": "Blog"}))
+	node := structuredContent(t, callTool(t, h, "create_node", map[string]interface{}{
+		"domain_name": "docs",
+		"url":         "https://example.com/guide",
+		"title":       "Guide",
+	}))
+	nodeURI := "url-db://docs/" + strconv.Itoa(node["id"].(int))
+
+	request := func(method string, params interface{}) *JSONRPCResponse {
+		raw, _ := json.Marshal(params)
+		return h.HandleRequest(context.Background(), &JSONRPCRequest{JSONRPC: constants.JSONRPCVersion, ID: 1, Method: method, Params: raw})
+	}
+
+	list := request("resources/list", map[string]interface{}{})
+	if list.Error != nil {
+		t.Fatalf("resources/list failed: %+v", list.Error)
+	}
+	resources := list.Result.(map[string]interface{})["resources"].([]map[string]interface{})
+	uris := map[string]bool{}
+	for _, resource := range resources {
+		uris[resource["uri"].(string)] = true
+	}
+	if len(resources) != 2 || !uris["url-db://docs"] || !uris["url-db://blog"] {
+		t.Errorf("expected a resource per domain, got %v", resources)
+	}
+
+	read := request("resources/read", map[string]interface{}{"uri": nodeURI})
+	if read.Error != nil {
+		t.Fatalf("resources/read failed: %+v", read.Error)
+	}
+	contents := read.Result.(map[string]interface{})["contents"].([]map[string]interface{})
+	var document map[string]interface{}
+	if err := json.Unmarshal([]byte(contents[0]["text"].(string)), &document); err != nil {
+		t.Fatalf("node resource is not JSON: %v", err)
+	}
+	if document["url"] != "https://example.com/guide" || document["title"] != "Guide" {
+		t.Errorf("unexpected node resource %v", document)
+	}
+
+	// The domain resource links its nodes
+	read = request("resources/read", map[string]interface{}{"uri": "url-db://docs"})
+	if read.Error != nil || !strings.Contains(read.Result.(map[string]interface{})["contents"].([]map[string]interface{})[0]["text"].(string), nodeURI) {
+		t.Errorf("expected the domain resource to link %s, got %+v", nodeURI, read)
+	}
+
+	// A node is only found under its own domain
+	wrongDomain := strings.Replace(nodeURI, "docs", "blog", 1)
+	if resp := request("resources/read", map[string]interface{}{"uri": wrongDomain}); resp.Error == nil || resp.Error.Code != ResourceNotFound {
+		t.Errorf("expected resource not found for %s, got %+v", wrongDomain, resp)
+	}
+
+	for _, uri := range []string{"https://example.com", "url-db://", "url-db://docs/abc"} {
+		if resp := request("resources/read", map[string]interface{}{"uri": uri}); resp.Error == nil || resp.Error.Code != InvalidParams {
+			t.Errorf("expected invalid params for %q, got %+v", uri, resp)
+		}
+	}
+}
+
+// countingReader records how many bytes were read from it
+type countingReader struct {
+	r    io.Reader
+	read int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.read += int64(n)
+	return n, err
+}
+
+func TestHTTPTransportRequestBodyLimit(t *testing.T) {
+	const limit = 64 * 1024
+
+	handled := 0
+	transport := NewHTTPTransport(&TransportConfig{Mode: constants.MCPModeHTTP, MaxBodyBytes: limit})
+	transport.SetRequestHandler(func(ctx context.Context, req *JSONRPCRequest) *JSONRPCResponse {
+		handled++
+		return &JSONRPCResponse{JSONRPC: constants.JSONRPCVersion, ID: req.ID, Result: "ok"}
+	})
+
+	// A create_nodes_batch import of about 4MB
+	var doc strings.Builder
+	doc.WriteString(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"create_nodes_batch","arguments":{"domain_name":"docs","nodes":[`)
+	for i := 0; i < 50000; i++ {
+		if i > 0 {
+			doc.WriteString(",")
+		}
+		fmt.Fprintf(&doc, `{"url":"https://example.com/page/%d","title":"Page %d"}`, i, i)
+	}
+	doc.WriteString(`]}}}`)
+
+	body := &countingReader{r: strings.NewReader(doc.String())}
+	recorder := httptest.NewRecorder()
+	transport.handleHTTPEndpoint(recorder, httptest.NewRequest(http.MethodPost, "/mcp", body))
+
+	if recorder.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, want 413", recorder.Code)
+	}
+	if contentType := recorder.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", contentType)
+	}
+	var resp JSONRPCResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &resp); err != nil || resp.Error == nil || resp.Error.Code != InvalidRequest {
+		t.Errorf("expected a JSON-RPC invalid request error, got %q", recorder.Body.String())
+	}
+	if handled != 0 {
+		t.Error("an oversized request must not reach the handler")
+	}
+	// Reading stopped at the limit instead of buffering the whole document
+	if body.read > 2*limit {
+		t.Errorf("read %d bytes of a %d byte body with a %d byte limit", body.read, doc.Len(), limit)
+	}
+
+	// Requests under the limit are served as before
+	recorder = httptest.NewRecorder()
+	transport.handleHTTPEndpoint(recorder, httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(`{"jsonrpc":"2.0","id":2,"method":"ping"}`)))
+	if recorder.Code != http.StatusOK || handled != 1 {
+		t.Errorf("small request: status %d, handled %d times", recorder.Code, handled)
+	}
+}
+
+func TestHTTPTransportRateLimit(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to find a free port: %v", err)
+	}
+	port := strconv.Itoa(listener.Addr().(*net.TCPAddr).Port)
+	listener.Close()
+
+	// Two requests at once, then one every 1000 seconds
+	transport := NewHTTPTransport(&TransportConfig{Mode: constants.MCPModeHTTP, Port: port,
+		RateLimiter: NewRateLimiter(0.001, 2, true)})
+	transport.SetRequestHandler(func(ctx context.Context, req *JSONRPCRequest) *JSONRPCResponse {
+		return &JSONRPCResponse{JSONRPC: constants.JSONRPCVersion, ID: req.ID, Result: "ok"}
+	})
+	go transport.Start(context.Background())
+	defer transport.Shutdown(context.Background())
+
+	send := func(path, forwardedFor string) *http.Response {
+		t.Helper()
+		for i := 0; i < 50; i++ {
+			req, _ := http.NewRequest(http.MethodPost, "http://127.0.0.1:"+port+path,
+				strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"ping"}`))
+			if forwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", forwardedFor)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err == nil {
+				return resp
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		t.Fatalf("server did not start")
+		return nil
+	}
+
+	for i := 0; i < 2; i++ {
+		resp := send("/mcp", "203.0.113.1")
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("request %d within the burst: status %d", i+1, resp.StatusCode)
+		}
+	}
+
+	resp := send("/mcp", "10.0.0.9, 203.0.113.1")
+	var rpcResp JSONRPCResponse
+	json.NewDecoder(resp.Body).Decode(&rpcResp)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("request over the burst: status %d, want 429", resp.StatusCode)
+	}
+	if rpcResp.Error == nil || rpcResp.Error.Code != RateLimited {
+		t.Errorf("expected a JSON-RPC error with code %d, got %+v", RateLimited, rpcResp.Error)
+	}
+	if resp.Header.Get("Retry-After") == "" {
+		t.Error("expected a Retry-After header")
+	}
+
+	// Health checks are not limited and other clients have their own bucket
+	resp = send("/health", "203.0.113.1")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("health check status %d, want 200", resp.StatusCode)
+	}
+	resp = send("/mcp", "203.0.113.2")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("another client's request: status %d, want 200", resp.StatusCode)
+	}
+}
+
+func TestHTTPTransportAPIKey(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to find a free port: %v", err)
+	}
+	port := strconv.Itoa(listener.Addr().(*net.TCPAddr).Port)
+	listener.Close()
+
+	transport := NewHTTPTransport(&TransportConfig{Mode: constants.MCPModeHTTP, Port: port, Auth: NewAPIKeyAuth("s3cret")})
+	transport.SetRequestHandler(func(ctx context.Context, req *JSONRPCRequest) *JSONRPCResponse {
+		return &JSONRPCResponse{JSONRPC: constants.JSONRPCVersion, ID: req.ID, Result: "ok"}
+	})
+	go transport.Start(context.Background())
+	defer transport.Shutdown(context.Background())
+
+	send := func(path, authorization string) *http.Response {
+		t.Helper()
+		for i := 0; i < 50; i++ {
+			req, _ := http.NewRequest(http.MethodPost, "http://127.0.0.1:"+port+path,
+				strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"ping"}`))
+			if authorization != "" {
+				req.Header.Set("Authorization", authorization)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err == nil {
+				return resp
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		t.Fatalf("server did not start")
+		return nil
+	}
+
+	for _, authorization := range []string{"", "Bearer wrong", "Bearer s3cret-longer", "Basic s3cret", "s3cret"} {
+		resp := send("/mcp", authorization)
+		var rpcResp JSONRPCResponse
+		json.NewDecoder(resp.Body).Decode(&rpcResp)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("Authorization %q: status %d, want 401", authorization, resp.StatusCode)
+		}
+		if rpcResp.Error == nil || rpcResp.Error.Code != Unauthorized {
+			t.Errorf("Authorization %q: expected a JSON-RPC error with code %d, got %+v", authorization, Unauthorized, rpcResp.Error)
+		}
+	}
+
+	resp := send("/mcp", "bearer s3cret")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("request with the key: status %d, want 200", resp.StatusCode)
+	}
+
+	// Health checks need no key
+	resp = send("/health", "")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("health check status %d, want 200", resp.StatusCode)
+	}
+
+	if NewAPIKeyAuth("") != nil {
+		t.Error("an empty key should disable the check")
+	}
+}
+
+func TestTransportCORSOrigins(t *testing.T) {
+	handler := func(ctx context.Context, req *JSONRPCRequest) *JSONRPCResponse {
+		return &JSONRPCResponse{JSONRPC: constants.JSONRPCVersion, ID: req.ID, Result: "ok"}
+	}
+	httpTransport := NewHTTPTransport(&TransportConfig{Mode: constants.MCPModeHTTP})
+	httpTransport.SetRequestHandler(handler)
+	sseTransport := NewSSETransport(&TransportConfig{Mode: constants.MCPModeSSE})
+	sseTransport.SetRequestHandler(handler)
+
+	serve := func(endpoint http.HandlerFunc, method, origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/mcp", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"ping"}`))
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		recorder := httptest.NewRecorder()
+		endpoint(recorder, req)
+		return recorder
+	}
+
+	// Any origin by default
+	if got := serve(httpTransport.handleHTTPEndpoint, http.MethodPost, "https://evil.example").Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("default Access-Control-Allow-Origin = %q, want *", got)
+	}
+
+	policy := NewCORSPolicy([]string{"https://app.example.com/", " http://localhost:3000"})
+	httpTransport.SetCORSPolicy(policy)
+	sseTransport.SetCORSPolicy(policy)
+	for name, endpoint := range map[string]http.HandlerFunc{"http": httpTransport.handleHTTPEndpoint, "sse": sseTransport.handleSSEEndpoint} {
+		recorder := serve(endpoint, http.MethodOptions, "https://APP.example.com")
+		if recorder.Code != http.StatusOK || recorder.Header().Get("Access-Control-Allow-Origin") != "https://APP.example.com" ||
+			recorder.Header().Get("Access-Control-Allow-Methods") != "POST, OPTIONS" {
+			t.Errorf("%s: allowed preflight got status %d, headers %v", name, recorder.Code, recorder.Header())
+		}
+
+		recorder = serve(endpoint, http.MethodOptions, "https://evil.example")
+		if recorder.Code != http.StatusForbidden || recorder.Header().Get("Access-Control-Allow-Origin") != "" ||
+			recorder.Header().Get("Access-Control-Allow-Methods") != "" {
+			t.Errorf("%s: refused preflight got status %d, headers %v", name, recorder.Code, recorder.Header())
+		}
+
+		// The call itself still runs; the browser drops the response without the header
+		recorder = serve(endpoint, http.MethodPost, "https://evil.example")
+		if recorder.Header().Get("Access-Control-Allow-Origin") != "" || recorder.Header().Get("Vary") != "Origin" {
+			t.Errorf("%s: refused origin got headers %v", name, recorder.Header())
+		}
+		if got := serve(endpoint, http.MethodPost, "http://localhost:3000").Header().Get("Access-Control-Allow-Origin"); got != "http://localhost:3000" {
+			t.Errorf("%s: allowed origin got Access-Control-Allow-Origin %q", name, got)
+		}
+	}
+
+	if NewCORSPolicy([]string{"https://app.example.com", "*"}) != nil || NewCORSPolicy(nil) != nil {
+		t.Error("an empty list or '*' should allow any origin")
+	}
+}
+
+func TestAccessLog(t *testing.T) {
+	h := newTestProtocolHandler(t)
+
+	calls := []*JSONRPCRequest{
+		{JSONRPC: constants.JSONRPCVersion, ID: 1, Method: "tools/list"},
+		{JSONRPC: constants.JSONRPCVersion, ID: 2, Method: "tools/call", Params: json.RawMessage(`{"name":"list_domains","arguments":{}}`)},
+		{JSONRPC: constants.JSONRPCVersion, ID: 3, Method: "tools/call", Params: json.RawMessage(`{"name":"get_node","arguments":{"composite_id":"test-tool:missing:1"}}`)},
+	}
+	logCalls := func(level LogLevel) []AccessLogEntry {
+		var out strings.Builder
+		handler := NewAccessLogger(&out, level).Wrap(h.HandleRequest)
+		for _, req := range calls {
+			handler(context.Background(), req)
+		}
+
+		var entries []AccessLogEntry
+		for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+			if line == "" {
+				continue
+			}
+			var entry AccessLogEntry
+			if err := json.Unmarshal([]byte(line), &entry); err != nil {
+				t.Fatalf("invalid log line %q: %v", line, err)
+			}
+			entries = append(entries, entry)
+		}
+		return entries
+	}
+
+	entries := logCalls(LogLevelInfo)
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries at info level, got %+v", entries)
+	}
+	if entries[0].Method != "tools/list" || entries[0].Tool != "" || entries[1].Tool != "list_domains" || entries[1].Error != nil {
+		t.Errorf("unexpected entries: %+v", entries[:2])
+	}
+	if entries[2].Tool != "get_node" || entries[2].Level != LogLevelError || entries[2].Error == nil {
+		t.Errorf("expected the failed get_node call at error level, got %+v", entries[2])
+	}
+	if entries[1].Params != nil {
+		t.Error("params are only logged at debug level")
+	}
+
+	if entries = logCalls(LogLevelError); len(entries) != 1 || entries[0].Tool != "get_node" {
+		t.Errorf("expected only the failed call at error level, got %+v", entries)
+	}
+	if entries = logCalls(LogLevelDebug); len(entries) != 3 || !strings.Contains(string(entries[2].Params), "test-tool:missing:1") {
+		t.Errorf("expected params at debug level, got %+v", entries)
+	}
+}
+
+func TestAccessLogStaysOnStderrInStdioMode(t *testing.T) {
+	db, err := database.New(database.TestConfig())
+	if err != nil {
+		t.Fatalf("failed to create test database: %v", err)
+	}
+	defer db.Close()
+
+	server, err := NewMCPServer(setup.NewApplicationFactory(db.DB(), db.SQLXDB(), "test-tool"), constants.MCPModeStdio)
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	path := t.TempDir() + "/access.log"
+	if err := server.SetAccessLog(constants.AccessLogInfo, path); err != nil {
+		t.Fatalf("failed to set access log: %v", err)
+	}
+	defer server.Close(context.Background())
+
+	if server.accessLog == nil || server.accessLog.out != os.Stderr {
+		t.Error("stdio mode must log to stderr")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("stdio mode must not open the log file, stat returned %v", err)
+	}
+}
+
+func TestJSONRPCBatch(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	transport := NewHTTPTransport(&TransportConfig{Mode: constants.MCPModeHTTP})
+	transport.SetRequestHandler(h.HandleRequest)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		transport.handleHTTPEndpoint(recorder, httptest.NewRequest(http.MethodPost, "/mcp", strings.NewReader(body)))
+		return recorder
+	}
+
+	// Responses come back in order; notifications get none and invalid entries get an error
+	recorder := post(`[
+		{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"create_domain","arguments":{"name":"docs","description":"Docs"}}},
+		{"jsonrpc":"2.0","method":"notifications/initialized"},
+		{"jsonrpc":"2.0","id":"two","method":"tools/call","params":{"name":"list_domains","arguments":{}}},
+		5
+	]`)
+	var responses []JSONRPCResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &responses); err != nil {
+		t.Fatalf("expected a response array, got %q", recorder.Body.String())
+	}
+	if len(responses) != 3 {
+		t.Fatalf("expected 3 responses, got %d: %s", len(responses), recorder.Body.String())
+	}
+	if responses[0].ID != float64(1) || responses[0].Error != nil || responses[1].ID != "two" || responses[1].Error != nil {
+		t.Errorf("unexpected responses: %s", recorder.Body.String())
+	}
+	if !strings.Contains(recorder.Body.String(), `"name":"docs"`) {
+		t.Error("list_domains in the batch should see the domain created before it")
+	}
+	if responses[2].ID != nil || responses[2].Error == nil || responses[2].Error.Code != InvalidRequest {
+		t.Errorf("expected an invalid request error for a non-object entry, got %+v", responses[2])
+	}
+
+	// A batch of notifications gets no response; an empty batch is a single error
+	if recorder = post(`[{"jsonrpc":"2.0","method":"notifications/initialized"}]`); recorder.Body.Len() != 0 {
+		t.Errorf("expected no response to a batch of notifications, got %q", recorder.Body.String())
+	}
+	var resp JSONRPCResponse
+	if err := json.Unmarshal(post(`[]`).Body.Bytes(), &resp); err != nil || resp.Error == nil || resp.Error.Code != InvalidRequest {
+		t.Errorf("expected an invalid request error for an empty batch, got %+v", resp)
+	}
+
+	// A batch above the size limit is refused without running any of it
+	entries := make([]string, constants.MaxRequestBatchSize+1)
+	for i := range entries {
+		entries[i] = fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":"tools/call","params":{"name":"create_domain","arguments":{"name":"d%d","description":"D"}}}`, i, i)
+	}
+	resp = JSONRPCResponse{}
+	if err := json.Unmarshal(post("["+strings.Join(entries, ",")+"]").Body.Bytes(), &resp); err != nil || resp.Error == nil || resp.Error.Code != InvalidRequest {
+		t.Errorf("expected an invalid request error for an oversized batch, got %+v", resp)
+	}
+	if domains := structuredContent(t, callTool(t, h, "list_domains", map[string]interface{}{}))["domains"]; strings.Contains(fmt.Sprint(domains), "d0") {
+		t.Errorf("expected the oversized batch to create nothing, got %v", domains)
+	}
+
+	// stdio reads batches and single requests from the same stream
+	var out strings.Builder
+	stdio := NewStdioTransport(&TransportConfig{Mode: constants.MCPModeStdio, Writer: &out,
+		Reader: strings.NewReader(`[{"jsonrpc":"2.0","id":1,"method":"tools/list"},{"jsonrpc":"2.0","id":2,"method":"tools/list"}]` + "\n" +
+			`{"jsonrpc":"2.0","id":3,"method":"tools/list"}` + "\n")})
+	stdio.SetRequestHandler(h.HandleRequest)
+	if err := stdio.Start(context.Background()); err != nil {
+		t.Fatalf("stdio transport failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "[") || !strings.HasPrefix(lines[1], "{") {
+		t.Errorf("expected a batch response then a single response, got %d lines", len(lines))
+	}
+}
+
+func TestToolsListMatchesDispatch(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	h.SetAdminTools(true)
+
+	resp := h.HandleRequest(context.Background(), &JSONRPCRequest{JSONRPC: constants.JSONRPCVersion, ID: 1, Method: "tools/list"})
+	listed := map[string]map[string]interface{}{}
+	for _, tool := range resp.Result.(map[string]interface{})["tools"].([]map[string]interface{}) {
+		listed[tool["name"].(string)] = tool
+	}
+
+	// Every defined tool is listed with its schemas and reaches a handler
+	for _, def := range GetToolDefinitions() {
+		tool, ok := listed[def.Name]
+		if !ok {
+			t.Errorf("%s is defined but not listed", def.Name)
+			continue
+		}
+		if _, ok := tool["outputSchema"]; def.OutputSchema != nil && !ok {
+			t.Errorf("%s is listed without its output schema", def.Name)
+		}
+		if _, ok := tool["annotations"]; def.Annotations != nil && !ok {
+			t.Errorf("%s is listed without its annotations", def.Name)
+		}
+		if resp := callTool(t, h, def.Name, map[string]interface{}{}); resp.Error != nil && resp.Error.Code == MethodNotFound {
+			t.Errorf("%s is listed but not dispatched", def.Name)
+		}
+	}
+	if len(listed) != len(GetToolDefinitions()) {
+		t.Errorf("expected %d listed tools, got %d", len(GetToolDefinitions()), len(listed))
+	}
+
+	if resp := callTool(t, h, "no_such_tool", map[string]interface{}{}); resp.Error == nil || resp.Error.Code != MethodNotFound {
+		t.Errorf("expected an unknown tool to be refused, got %+v", resp)
+	}
+}
+
+func TestReadOnlyMode(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	h.SetReadOnly(true)
+
+	resp := h.HandleRequest(context.Background(), &JSONRPCRequest{JSONRPC: constants.JSONRPCVersion, ID: 1, Method: "tools/list"})
+	listed := map[string]bool{}
+	for _, tool := range resp.Result.(map[string]interface{})["tools"].([]map[string]interface{}) {
+		listed[tool["name"].(string)] = true
+	}
+	if !listed["list_nodes"] || listed["create_node"] || listed["delete_node"] {
+		t.Errorf("read-only tools/list should keep list_nodes and drop create_node and delete_node, got %v", listed)
+	}
+
+	for _, name := range []string{"create_node", "delete_domain", "set_node_attributes"} {
+		if resp := callTool(t, h, name, map[string]interface{}{"domain_name": "docs", "url": "https://example.com/a"}); resp.Error == nil || resp.Error.Code != ReadOnlyMode {
+			t.Errorf("%s: expected a read-only error with code %d, got %+v", name, ReadOnlyMode, resp.Error)
+		}
+	}
+	if resp := callTool(t, h, "list_domains", map[string]interface{}{}); resp.Error != nil {
+		t.Errorf("list_domains should be callable in read-only mode: %+v", resp.Error)
+	}
+
+	h.SetReadOnly(false)
+	if resp := callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": "https://example.com/a"}); resp.Error != nil {
+		t.Errorf("create_node should be callable after leaving read-only mode: %+v", resp.Error)
+	}
+}
+
+func TestGetServerCapabilities(t *testing.T) {
+	h := newTestProtocolHandler(t)
+
+	capabilities := structuredContent(t, callTool(t, h, "get_server_capabilities", map[string]interface{}{}))
+	subsystems := capabilities["subsystems"].(map[string]bool)
+	if capabilities["mode"] != constants.MCPModeStdio || capabilities["read_only"] != false || capabilities["auth_required"] != false {
+		t.Errorf("unexpected capabilities: %v", capabilities)
+	}
+	if !subsystems["full_text_search"] || subsystems["admin_tools"] || subsystems["subscription_worker"] {
+		t.Errorf("unexpected subsystems: %v", subsystems)
+	}
+	fullCount := capabilities["tool_count"].(int)
+	if fullCount != len(h.visibleToolDefinitions()) {
+		t.Errorf("tool_count = %d, want the %d tools of tools/list", fullCount, len(h.visibleToolDefinitions()))
+	}
+
+	h.SetReadOnly(true)
+	h.SetAdminTools(true)
+	h.SetAuthRequired(true)
+	capabilities = structuredContent(t, callTool(t, h, "get_server_capabilities", map[string]interface{}{}))
+	if capabilities["read_only"] != true || capabilities["auth_required"] != true || !capabilities["subsystems"].(map[string]bool)["admin_tools"] {
+		t.Errorf("capabilities do not reflect the configuration: %v", capabilities)
+	}
+	if count := capabilities["tool_count"].(int); count >= fullCount {
+		t.Errorf("read-only tool_count = %d, want fewer than %d", count, fullCount)
+	}
+}
+
+func TestTemplateToolsOverStdio(t *testing.T) {
+	h := newTestProtocolHandler(t)
+
+	var out strings.Builder
+	stdio := NewStdioTransport(&TransportConfig{Mode: constants.MCPModeStdio, Writer: &out,
+		Reader: strings.NewReader(strings.Join([]string{
+			`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"create_domain","arguments":{"name":"docs","description":"Docs"}}}`,
+			`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"create_template","arguments":{"name":"landing","domain_name":"docs","template_data":"{\"type\":\"layout\",\"version\":\"1.0\"}"}}}`,
+			`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"get_template","arguments":{"composite_id":"test-tool:docs:template:1"}}}`,
+		}, "\n") + "\n")})
+	stdio.SetRequestHandler(h.HandleRequest)
+	if err := stdio.Start(context.Background()); err != nil {
+		t.Fatalf("stdio transport failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 responses, got %q", out.String())
+	}
+	for _, line := range lines {
+		var resp JSONRPCResponse
+		if err := json.Unmarshal([]byte(line), &resp); err != nil || resp.Error != nil {
+			t.Fatalf("unexpected response %s", line)
+		}
+	}
+	if !strings.Contains(lines[2], "Name: landing") {
+		t.Errorf("get_template should return the created template, got %s", lines[2])
+	}
+}
+
+func TestHealth(t *testing.T) {
+	h, db := newTestProtocolHandlerWithDB(t)
+	transport := NewHTTPTransport(&TransportConfig{Mode: constants.MCPModeHTTP, Health: h.Health})
+	check := func() (int, HealthReport) {
+		recorder := httptest.NewRecorder()
+		transport.handleHealthCheck(recorder, httptest.NewRequest(http.MethodGet, "/health", nil))
+		var report HealthReport
+		if err := json.Unmarshal(recorder.Body.Bytes(), &report); err != nil {
+			t.Fatalf("invalid health response %q", recorder.Body.String())
+		}
+		return recorder.Code, report
+	}
+
+	code, report := check()
+	if code != http.StatusOK || report.Status != constants.HealthStatusOK || report.Database != "ok" ||
+		report.Mode != "http" || report.ToolName != "test-tool" || report.Error != "" {
+		t.Errorf("unexpected healthy report %d %+v", code, report)
+	}
+	result := structuredContent(t, callTool(t, h, "get_health", map[string]interface{}{}))
+	if result["status"] != constants.HealthStatusOK || result["tool_name"] != "test-tool" {
+		t.Errorf("unexpected get_health result %v", result)
+	}
+
+	// A database that cannot answer degrades health with the error
+	db.Close()
+	if code, report = check(); code != http.StatusServiceUnavailable || report.Status != constants.HealthStatusDegraded ||
+		report.Database != "error" || !strings.Contains(report.Error, "closed") {
+		t.Errorf("unexpected degraded report %d %+v", code, report)
+	}
+	result = structuredContent(t, callTool(t, h, "get_health", map[string]interface{}{}))
+	if result["status"] != constants.HealthStatusDegraded || result["error"] == nil {
+		t.Errorf("unexpected degraded get_health result %v", result)
+	}
+}
@@ -3,11 +3,154 @@ package mcp
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"url-db/internal/constants"
+	"url-db/internal/domain/repository"
 )
 
-// handleToolCall executes a tool call
+// adminToolNames are the tools for operators that are only listed and callable when
+// admin tools are enabled (ADMIN_TOOLS)
+var adminToolNames = map[string]bool{
+	"explain_filter":       true,
+	"rebuild_search_index": true,
+}
+
+// definedToolNames are the tools of GetToolDefinitions. tools/call refuses any other
+// name, so a tool is callable exactly when tools/list can advertise it.
+var definedToolNames = func() map[string]bool {
+	names := map[string]bool{}
+	for _, def := range GetToolDefinitions() {
+		names[def.Name] = true
+	}
+	return names
+}()
+
+// readOnlyToolNames are the tools annotated readOnlyHint, the only ones listed and
+// callable in read-only mode (READ_ONLY). Tools without annotations count as mutating.
+var readOnlyToolNames = func() map[string]bool {
+	names := map[string]bool{}
+	for _, def := range GetToolDefinitions() {
+		if def.Annotations != nil && def.Annotations.ReadOnlyHint != nil && *def.Annotations.ReadOnlyHint {
+			names[def.Name] = true
+		}
+	}
+	return names
+}()
+
+// handleToolCall executes a tool call within the tool's time limit
 func (h *MCPProtocolHandler) handleToolCall(ctx context.Context, req *JSONRPCRequest) *JSONRPCResponse {
+	var params struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		return h.createErrorResponse(req.ID, InvalidParams, "Invalid tool call parameters", err.Error())
+	}
+	if !definedToolNames[params.Name] {
+		return h.createErrorResponse(req.ID, MethodNotFound, fmt.Sprintf("Tool not found: %s", params.Name), nil)
+	}
+	if adminToolNames[params.Name] && !h.adminTools {
+		return h.createErrorResponse(req.ID, MethodNotFound, fmt.Sprintf("Tool not found: %s", params.Name),
+			"admin tools are disabled; set ADMIN_TOOLS=true to enable them")
+	}
+	if h.readOnly && !readOnlyToolNames[params.Name] {
+		return h.createErrorResponse(req.ID, ReadOnlyMode, fmt.Sprintf("Tool %s modifies data and the server is read-only", params.Name),
+			"only tools annotated readOnlyHint can be called; restart the server without READ_ONLY or -read-only to modify data")
+	}
+
+	return h.runWithToolTimeout(ctx, req, params.Name, h.dispatchToolCall)
+}
+
+// toolTimeout returns the time limit for a tool: its own entry in the per-tool
+// map, otherwise the default (0 = no limit)
+func (h *MCPProtocolHandler) toolTimeout(toolName string) time.Duration {
+	if timeout, ok := h.toolTimeouts[toolName]; ok {
+		return timeout
+	}
+	return h.defaultToolTimeout
+}
+
+// runWithToolTimeout runs call under the tool's time limit. Handlers stop when
+// their repository calls see the cancelled context; the response does not wait
+// for one that ignores it.
+func (h *MCPProtocolHandler) runWithToolTimeout(ctx context.Context, req *JSONRPCRequest, toolName string, call func(context.Context, *JSONRPCRequest) *JSONRPCResponse) *JSONRPCResponse {
+	timeout := h.toolTimeout(toolName)
+	if timeout <= 0 {
+		return call(ctx, req)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// An abandoned handler must not write to the stream after the timeout response
+	if stream, ok := streamWriterFrom(ctx); ok {
+		guarded := &guardedStreamWriter{stream: stream}
+		defer guarded.close()
+		ctx = withStreamWriter(ctx, guarded)
+	}
+
+	done := make(chan *JSONRPCResponse, 1)
+	go func() {
+		// Outside the request goroutine a panic would take the whole server down
+		defer func() {
+			if r := recover(); r != nil {
+				fmt.Fprintf(os.Stderr, "Tool %s panicked: %v\n", toolName, r)
+				done <- h.createErrorResponse(req.ID, InternalError, "Tool execution failed", fmt.Sprintf("%s panicked: %v", toolName, r))
+			}
+		}()
+		done <- call(ctx, req)
+	}()
+
+	select {
+	case resp := <-done:
+		// A handler that failed because its context ran out still timed out
+		if resp != nil && resp.Error != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return h.toolTimeoutResponse(req.ID, toolName, timeout)
+		}
+		return resp
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return h.toolTimeoutResponse(req.ID, toolName, timeout)
+		}
+		return h.createErrorResponse(req.ID, InternalError, "Tool execution failed", ctx.Err().Error())
+	}
+}
+
+// toolTimeoutResponse reports a tool that exceeded its time limit
+func (h *MCPProtocolHandler) toolTimeoutResponse(id interface{}, toolName string, timeout time.Duration) *JSONRPCResponse {
+	return h.createErrorResponse(id, ToolTimeout, "Tool execution timed out",
+		fmt.Sprintf("%s exceeded its time limit of %s", toolName, timeout))
+}
+
+// guardedStreamWriter drops writes once closed, so a handler still running after
+// its timeout cannot interleave frames with the response
+type guardedStreamWriter struct {
+	mu     sync.Mutex
+	stream StreamWriter
+	closed bool
+}
+
+func (w *guardedStreamWriter) WriteMessage(message interface{}) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return context.DeadlineExceeded
+	}
+	return w.stream.WriteMessage(message)
+}
+
+func (w *guardedStreamWriter) close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+}
+
+// dispatchToolCall routes a tool call to its handler
+func (h *MCPProtocolHandler) dispatchToolCall(ctx context.Context, req *JSONRPCRequest) *JSONRPCResponse {
 	var params struct {
 		Name      string                 `json:"name"`
 		Arguments map[string]interface{} `json:"arguments"`
@@ -19,37 +162,98 @@ func (h *MCPProtocolHandler) handleToolCall(ctx context.Context, req *JSONRPCReq
 
 	// Use tool name directly without namespace
 	toolName := params.Name
+	h.toolHandler.normalizeDomainArguments(params.Arguments)
 
 	var result interface{}
 	var err error
 
 	switch toolName {
 	case "get_server_info":
-		return h.handleGetServerInfo(req)
+		resp := h.handleGetServerInfo(req)
+		resp.Result = h.applyResponseEnvelope(resp.Result)
+		return resp
+	case "get_server_capabilities":
+		result, err = h.handleGetServerCapabilities(ctx)
+	case "get_health":
+		result, err = h.toolHandler.handleGetHealth(ctx, params.Arguments)
+	case "get_storage_stats":
+		result, err = h.toolHandler.handleGetStorageStats(ctx, params.Arguments)
+	case "rebuild_search_index":
+		result, err = h.toolHandler.handleRebuildSearchIndex(ctx, params.Arguments)
 	case "list_domains":
 		result, err = h.toolHandler.handleListDomains(ctx, params.Arguments)
 	case "create_domain":
 		result, err = h.toolHandler.handleCreateDomain(ctx, params.Arguments)
+	case "update_domain":
+		result, err = h.toolHandler.handleUpdateDomain(ctx, params.Arguments)
+	case "delete_domain":
+		result, err = h.toolHandler.handleDeleteDomain(ctx, params.Arguments)
+	case "get_domain":
+		result, err = h.toolHandler.handleGetDomain(ctx, params.Arguments)
+	case "get_domain_stats":
+		result, err = h.toolHandler.handleGetDomainStats(ctx, params.Arguments)
+	case "export_domain":
+		result, err = h.toolHandler.handleExportDomain(ctx, params.Arguments)
+	case "merge_domains":
+		result, err = h.toolHandler.handleMergeDomains(ctx, params.Arguments)
+	case "import_domain":
+		result, err = h.toolHandler.handleImportDomain(ctx, params.Arguments)
 	case "list_nodes":
 		result, err = h.toolHandler.handleListNodes(ctx, params.Arguments)
 	case "create_node":
 		result, err = h.toolHandler.handleCreateNode(ctx, params.Arguments)
+	case "create_nodes_batch":
+		result, err = h.toolHandler.handleCreateNodesBatch(ctx, params.Arguments)
 	case "get_node":
 		result, err = h.toolHandler.handleGetNode(ctx, params.Arguments)
 	case "update_node":
 		result, err = h.toolHandler.handleUpdateNode(ctx, params.Arguments)
 	case "delete_node":
 		result, err = h.toolHandler.handleDeleteNode(ctx, params.Arguments)
+	case "archive_node":
+		result, err = h.toolHandler.handleArchiveNode(ctx, params.Arguments)
+	case "restore_node":
+		result, err = h.toolHandler.handleRestoreNode(ctx, params.Arguments)
+	case "move_node":
+		result, err = h.toolHandler.handleMoveNode(ctx, params.Arguments)
+	case "move_nodes":
+		result, err = h.toolHandler.handleMoveNodes(ctx, params.Arguments)
+	case "find_duplicate_nodes":
+		result, err = h.toolHandler.handleFindDuplicateNodes(ctx, params.Arguments)
 	case "find_node_by_url":
 		result, err = h.toolHandler.handleFindNodeByURL(ctx, params.Arguments)
+	case "find_nodes_by_urls":
+		result, err = h.toolHandler.handleFindNodesByURLs(ctx, params.Arguments)
+	case "estimate_scan_size":
+		result, err = h.toolHandler.handleEstimateScanSize(ctx, params.Arguments)
 	case "scan_all_content":
 		result, err = h.toolHandler.handleScanAllContent(ctx, params.Arguments)
 	case "get_node_attributes":
 		result, err = h.toolHandler.handleGetNodeAttributes(ctx, params.Arguments)
 	case "set_node_attributes":
 		result, err = h.toolHandler.handleSetNodeAttributes(ctx, params.Arguments)
+	case "clear_node_attributes":
+		result, err = h.toolHandler.handleClearNodeAttributes(ctx, params.Arguments)
+	case "copy_node_attributes":
+		result, err = h.toolHandler.handleCopyNodeAttributes(ctx, params.Arguments)
+	case "add_node_tag":
+		result, err = h.toolHandler.handleAddNodeTag(ctx, params.Arguments)
+	case "remove_node_tag":
+		result, err = h.toolHandler.handleRemoveNodeTag(ctx, params.Arguments)
+	case "render_node_attribute":
+		result, err = h.toolHandler.handleRenderNodeAttribute(ctx, params.Arguments)
+	case "dedupe_node_attributes":
+		result, err = h.toolHandler.handleDedupeNodeAttributes(ctx, params.Arguments)
+	case "get_all_attributes":
+		result, err = h.toolHandler.handleGetAllAttributes(ctx, params.Arguments)
 	case "list_domain_attributes":
 		result, err = h.toolHandler.handleListDomainAttributes(ctx, params.Arguments)
+	case "get_domain_schema":
+		result, err = h.toolHandler.handleGetDomainSchema(ctx, params.Arguments)
+	case "list_all_attributes_across_domains":
+		result, err = h.toolHandler.handleListAllAttributesAcrossDomains(ctx, params.Arguments)
+	case "diff_domain_schemas":
+		result, err = h.toolHandler.handleDiffDomainSchemas(ctx, params.Arguments)
 	case "create_domain_attribute":
 		result, err = h.toolHandler.handleCreateDomainAttribute(ctx, params.Arguments)
 	case "get_domain_attribute":
@@ -60,14 +264,36 @@ func (h *MCPProtocolHandler) handleToolCall(ctx context.Context, req *JSONRPCReq
 		result, err = h.toolHandler.handleDeleteDomainAttribute(ctx, params.Arguments)
 	case "create_dependency":
 		result, err = h.toolHandler.handleCreateDependency(ctx, params.Arguments)
+	case "create_dependencies":
+		result, err = h.toolHandler.handleCreateDependencies(ctx, params.Arguments)
 	case "list_node_dependencies":
 		result, err = h.toolHandler.handleListNodeDependencies(ctx, params.Arguments)
 	case "list_node_dependents":
 		result, err = h.toolHandler.handleListNodeDependents(ctx, params.Arguments)
 	case "delete_dependency":
 		result, err = h.toolHandler.handleDeleteDependency(ctx, params.Arguments)
+	case "list_domain_dependencies":
+		result, err = h.toolHandler.handleListDomainDependencies(ctx, params.Arguments)
 	case "filter_nodes_by_attributes":
 		result, err = h.toolHandler.handleFilterNodesByAttributes(ctx, params.Arguments)
+	case "explain_filter":
+		result, err = h.toolHandler.handleExplainFilter(ctx, params.Arguments)
+	case "get_node_full":
+		result, err = h.toolHandler.handleGetNodeFull(ctx, params.Arguments)
+	case "global_search":
+		result, err = h.toolHandler.handleGlobalSearch(ctx, params.Arguments)
+	case "search_attribute_values":
+		result, err = h.toolHandler.handleSearchAttributeValues(ctx, params.Arguments)
+	case "find_nodes_by_attribute_value":
+		result, err = h.toolHandler.handleFindNodesByAttributeValue(ctx, params.Arguments)
+	case "suggest_attribute_values":
+		result, err = h.toolHandler.handleSuggestAttributeValues(ctx, params.Arguments)
+	case "get_attribute_statistics":
+		result, err = h.toolHandler.handleGetAttributeStatistics(ctx, params.Arguments)
+	case "rename_attribute_value":
+		result, err = h.toolHandler.handleRenameAttributeValue(ctx, params.Arguments)
+	case "get_facets":
+		result, err = h.toolHandler.handleGetFacets(ctx, params.Arguments)
 	case "get_node_with_attributes":
 		result, err = h.toolHandler.handleGetNodeWithAttributes(ctx, params.Arguments)
 	case "list_templates":
@@ -86,14 +312,59 @@ func (h *MCPProtocolHandler) handleToolCall(ctx context.Context, req *JSONRPCReq
 		result, err = h.toolHandler.handleGenerateTemplateScaffold(ctx, params.Arguments)
 	case "validate_template":
 		result, err = h.toolHandler.handleValidateTemplate(ctx, params.Arguments)
+	case "get_node_events":
+		result, err = h.toolHandler.handleGetNodeEvents(ctx, params.Arguments)
+	case "get_pending_events":
+		result, err = h.toolHandler.handleGetPendingEvents(ctx, params.Arguments)
+	case "process_event":
+		result, err = h.toolHandler.handleProcessEvent(ctx, params.Arguments)
+	case "get_event_stats":
+		result, err = h.toolHandler.handleGetEventStats(ctx, params.Arguments)
+	case "create_subscription":
+		result, err = h.toolHandler.handleCreateSubscription(ctx, params.Arguments)
+	case "list_subscriptions":
+		result, err = h.toolHandler.handleListSubscriptions(ctx, params.Arguments)
+	case "delete_subscription":
+		result, err = h.toolHandler.handleDeleteSubscription(ctx, params.Arguments)
 	default:
 		return h.createErrorResponse(req.ID, MethodNotFound, fmt.Sprintf("Tool not found: %s", params.Name), nil)
 	}
 
 	// Handle the response
+	if errors.Is(err, repository.ErrConcurrencyConflict) {
+		return h.createErrorResponse(req.ID, VersionConflict, "Version conflict", err.Error())
+	}
 	if err != nil {
 		return h.createErrorResponse(req.ID, InternalError, "Tool execution failed", err.Error())
 	}
 
-	return h.createSuccessResponse(req.ID, result)
+	return h.createSuccessResponse(req.ID, h.applyResponseEnvelope(result))
+}
+
+// applyResponseEnvelope adds server name, version and tool name to the structured
+// content of a tool result when the response envelope is enabled. This lets clients
+// tell which instance produced a result when several servers sit behind one bridge.
+func (h *MCPProtocolHandler) applyResponseEnvelope(result interface{}) interface{} {
+	if !h.responseEnvelope {
+		return result
+	}
+
+	response, ok := result.(map[string]interface{})
+	if !ok {
+		return result
+	}
+
+	structuredContent, ok := response["structuredContent"].(map[string]interface{})
+	if !ok {
+		structuredContent = map[string]interface{}{}
+		response["structuredContent"] = structuredContent
+	}
+
+	structuredContent["_server"] = map[string]interface{}{
+		"name":      constants.MCPServerName,
+		"version":   constants.DefaultServerVersion,
+		"tool_name": h.factory.ToolName(),
+	}
+
+	return response
 }
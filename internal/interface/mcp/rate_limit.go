@@ -0,0 +1,125 @@
+package mcp
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimiter limits requests per client IP with a token bucket: each IP may
+// send burst requests at once, refilled at rps requests per second
+type RateLimiter struct {
+	rps               float64
+	burst             float64
+	trustForwardedFor bool // Take the client IP from X-Forwarded-For (behind a gateway)
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter creates a per-IP rate limiter. A burst below 1 is raised to 1.
+func NewRateLimiter(rps float64, burst int, trustForwardedFor bool) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		rps:               rps,
+		burst:             float64(burst),
+		trustForwardedFor: trustForwardedFor,
+		buckets:           make(map[string]*tokenBucket),
+	}
+}
+
+// Allow takes a token from ip's bucket. When the bucket is empty it returns false
+// and how long until the next token is available.
+func (l *RateLimiter) Allow(ip string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.sweep(now)
+
+	bucket, exists := l.buckets[ip]
+	if !exists {
+		bucket = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[ip] = bucket
+	}
+
+	bucket.tokens = math.Min(l.burst, bucket.tokens+now.Sub(bucket.last).Seconds()*l.rps)
+	bucket.last = now
+
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		return true, 0
+	}
+	wait := time.Duration((1 - bucket.tokens) / l.rps * float64(time.Second))
+	return false, wait
+}
+
+// sweep drops buckets that have refilled completely, at most once per refill period,
+// so the map only holds recently active clients
+func (l *RateLimiter) sweep(now time.Time) {
+	fullAfter := time.Duration(l.burst / l.rps * float64(time.Second))
+	if now.Sub(l.lastSweep) < fullAfter {
+		return
+	}
+	l.lastSweep = now
+
+	for ip, bucket := range l.buckets {
+		if now.Sub(bucket.last) >= fullAfter {
+			delete(l.buckets, ip)
+		}
+	}
+}
+
+// Wrap returns next limited by l. Requests over the limit get HTTP 429 with a
+// JSON-RPC error body. A nil limiter returns next unchanged.
+func (l *RateLimiter) Wrap(next http.Handler) http.Handler {
+	if l == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		allowed, wait := l.Allow(l.clientIP(r))
+		if allowed {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		retryAfter := int(math.Ceil(wait.Seconds()))
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusTooManyRequests)
+		NewHTTPResponseWriter(w).WriteError(nil, RateLimited, "Rate limit exceeded",
+			map[string]interface{}{"retry_after_seconds": retryAfter})
+	})
+}
+
+// clientIP returns the address requests are limited by: the peer address, or with
+// trustForwardedFor the last X-Forwarded-For entry, which the gateway appended
+func (l *RateLimiter) clientIP(r *http.Request) string {
+	if l.trustForwardedFor {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			entries := strings.Split(forwarded, ",")
+			if ip := strings.TrimSpace(entries[len(entries)-1]); ip != "" {
+				return ip
+			}
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -6,8 +6,10 @@ import (
 	"io"
 	"os"
 	"strconv"
+	"time"
 
 	"url-db/internal/constants"
+	"url-db/internal/infrastructure/fetcher"
 	"url-db/internal/interface/setup"
 )
 
@@ -20,7 +22,14 @@ type MCPServer struct {
 	transportFactory *TransportFactory
 	mode             string
 	port             string
-	logEnabled       bool      // Whether to send log notifications
+	sseDoneEvent     string        // SSE event sent after each response ("" = none)
+	maxBodyBytes     int64         // HTTP/SSE request body limit (0 = unlimited)
+	rateLimiter      *RateLimiter  // HTTP/SSE per-IP rate limit (nil = unlimited)
+	auth             *APIKeyAuth   // HTTP/SSE API key check (nil = open)
+	cors             *CORSPolicy   // HTTP/SSE allowed browser origins (nil = any)
+	accessLog        *AccessLogger // JSON-RPC call log (nil = off)
+	accessLogFile    *os.File      // File the access log writes to, closed by Close
+	logEnabled       bool          // Whether to send log notifications
 }
 
 // NewMCPServer creates a new MCP server instance with transport abstraction
@@ -38,6 +47,8 @@ func NewMCPServer(factory *setup.ApplicationFactory, mode string) (*MCPServer, e
 		transportFactory: transportFactory,
 		mode:             mode,
 		port:             strconv.Itoa(constants.DefaultPort),
+		sseDoneEvent:     constants.DefaultSSEDoneEvent,
+		maxBodyBytes:     constants.DefaultMaxRequestBodyBytes,
 		logEnabled:       true, // Enable structured logging by default
 	}
 
@@ -57,6 +68,170 @@ func (s *MCPServer) SetPort(port string) {
 	}
 }
 
+// SetSSEDoneEvent sets the event sent after each SSE response ("none" = no event)
+func (s *MCPServer) SetSSEDoneEvent(name string) {
+	if name == constants.SSEDoneEventDisabled {
+		name = ""
+	}
+	s.sseDoneEvent = name
+	if sseTransport, ok := s.transport.(*SSETransport); ok {
+		sseTransport.SetDoneEvent(name)
+	}
+}
+
+// SetMaxRequestBodyBytes limits HTTP and SSE request bodies (0 = unlimited)
+func (s *MCPServer) SetMaxRequestBodyBytes(maxBytes int64) {
+	s.maxBodyBytes = maxBytes
+	switch transport := s.transport.(type) {
+	case *HTTPTransport:
+		transport.SetMaxBodyBytes(maxBytes)
+	case *SSETransport:
+		transport.SetMaxBodyBytes(maxBytes)
+	}
+}
+
+// SetRateLimit limits HTTP and SSE /mcp requests to rps per second per client IP,
+// allowing bursts of burst requests (rps <= 0 = unlimited). With trustForwardedFor
+// the client IP is taken from X-Forwarded-For, for use behind a gateway.
+func (s *MCPServer) SetRateLimit(rps float64, burst int, trustForwardedFor bool) {
+	s.rateLimiter = nil
+	if rps > 0 {
+		s.rateLimiter = NewRateLimiter(rps, burst, trustForwardedFor)
+	}
+	switch transport := s.transport.(type) {
+	case *HTTPTransport:
+		transport.SetRateLimiter(s.rateLimiter)
+	case *SSETransport:
+		transport.SetRateLimiter(s.rateLimiter)
+	}
+}
+
+// SetAPIKey requires HTTP and SSE /mcp requests to send 'Authorization: Bearer <key>'
+// ("" = no check). /health stays open and stdio mode, being local, is never checked.
+func (s *MCPServer) SetAPIKey(key string) {
+	s.auth = NewAPIKeyAuth(key)
+	s.protocolHandler.SetAuthRequired(s.auth != nil)
+	switch transport := s.transport.(type) {
+	case *HTTPTransport:
+		transport.SetAuth(s.auth)
+	case *SSETransport:
+		transport.SetAuth(s.auth)
+	}
+}
+
+// SetCORSOrigins limits the browser origins allowed to call HTTP and SSE /mcp; an
+// empty list, or one holding "*", allows any origin
+func (s *MCPServer) SetCORSOrigins(origins []string) {
+	s.cors = NewCORSPolicy(origins)
+	switch transport := s.transport.(type) {
+	case *HTTPTransport:
+		transport.SetCORSPolicy(s.cors)
+	case *SSETransport:
+		transport.SetCORSPolicy(s.cors)
+	}
+}
+
+// SetResponseEnvelope enables or disables server metadata on tool results
+func (s *MCPServer) SetResponseEnvelope(enabled bool) {
+	s.protocolHandler.SetResponseEnvelope(enabled)
+}
+
+// SetTitleFetcher configures how create_node fetches page titles
+func (s *MCPServer) SetTitleFetcher(titleFetcher *fetcher.TitleFetcher) {
+	s.protocolHandler.SetTitleFetcher(titleFetcher)
+}
+
+// SetDependencyTypes sets the dependency types accepted by create_dependency
+func (s *MCPServer) SetDependencyTypes(types []string) {
+	s.protocolHandler.SetDependencyTypes(types)
+}
+
+// SetAllowedURLSchemes sets the URL schemes nodes may use; an empty list keeps http and https
+func (s *MCPServer) SetAllowedURLSchemes(schemes []string) {
+	s.protocolHandler.SetAllowedURLSchemes(schemes)
+}
+
+// SetSoftWarnings enables or disables soft validation warnings on create/update results
+func (s *MCPServer) SetSoftWarnings(enabled bool) {
+	s.protocolHandler.SetSoftWarnings(enabled)
+}
+
+// SetCompactJSON selects compact (true) or indented (false) JSON inside text content
+func (s *MCPServer) SetCompactJSON(enabled bool) {
+	s.protocolHandler.SetCompactJSON(enabled)
+}
+
+// SetNodeLimits sets the default per-domain node cap and per-domain overrides (0 = unlimited)
+func (s *MCPServer) SetNodeLimits(maxNodes int, domainMaxNodes map[string]int) {
+	s.protocolHandler.SetNodeLimits(maxNodes, domainMaxNodes)
+}
+
+// SetLowercaseDomainNames enforces lowercase domain names on creation and in
+// domain_name arguments
+func (s *MCPServer) SetLowercaseDomainNames(enabled bool) {
+	s.protocolHandler.SetLowercaseDomainNames(enabled)
+}
+
+// SetDomainNameUnicode selects how non-ASCII characters in domain names are handled
+func (s *MCPServer) SetDomainNameUnicode(mode string) {
+	s.protocolHandler.SetDomainNameUnicode(mode)
+}
+
+// SetMaxFilters caps the filters one filter_nodes_by_attributes call accepts (0 = unlimited)
+func (s *MCPServer) SetMaxFilters(maxFilters int) {
+	s.protocolHandler.SetMaxFilters(maxFilters)
+}
+
+// SetMaxPageSize caps the page size of paginated tools (values below 1 = default)
+func (s *MCPServer) SetMaxPageSize(maxSize int) {
+	s.protocolHandler.SetMaxPageSize(maxSize)
+}
+
+// SetAdminTools exposes or hides the admin tools, such as explain_filter
+func (s *MCPServer) SetAdminTools(enabled bool) {
+	s.protocolHandler.SetAdminTools(enabled)
+}
+
+// SetReadOnly allows only tools annotated readOnlyHint to be listed and called
+func (s *MCPServer) SetReadOnly(enabled bool) {
+	s.protocolHandler.SetReadOnly(enabled)
+}
+
+// SetScanDefaultOrder sets the created_at order of scan_all_content calls that give none
+func (s *MCPServer) SetScanDefaultOrder(order string) {
+	s.protocolHandler.SetScanDefaultOrder(order)
+}
+
+// SetAttributeTransforms replaces the value transforms of the attribute types in overrides
+func (s *MCPServer) SetAttributeTransforms(overrides map[string][]string) error {
+	return s.protocolHandler.SetAttributeTransforms(overrides)
+}
+
+// SetAttributeNameRules sets the minimum length and the pattern new attribute names must follow
+func (s *MCPServer) SetAttributeNameRules(minLength int, pattern string) error {
+	return s.protocolHandler.SetAttributeNameRules(minLength, pattern)
+}
+
+// SetToolTimeouts sets the default tool time limit and per-tool overrides (0 = no limit)
+func (s *MCPServer) SetToolTimeouts(defaultTimeout time.Duration, toolTimeouts map[string]time.Duration) {
+	s.protocolHandler.SetToolTimeouts(defaultTimeout, toolTimeouts)
+}
+
+// SetEventBuffer enables buffered node event recording (size 0 = synchronous writes)
+func (s *MCPServer) SetEventBuffer(size int, flushInterval time.Duration) {
+	s.protocolHandler.SetEventBuffer(size, flushInterval)
+}
+
+// StartExpirySweeper periodically deletes expired nodes (interval 0 = never)
+func (s *MCPServer) StartExpirySweeper(interval time.Duration) {
+	s.protocolHandler.StartExpirySweeper(interval)
+}
+
+// StartSubscriptionWorker periodically posts node events to subscribers (interval 0 = never)
+func (s *MCPServer) StartSubscriptionWorker(interval time.Duration, retries int) {
+	s.protocolHandler.StartSubscriptionWorker(interval, retries)
+}
+
 // SetIOStreams sets custom input/output streams (useful for testing)
 func (s *MCPServer) SetIOStreams(reader io.Reader, writer io.Writer) error {
 	if s.mode != constants.MCPModeStdio {
@@ -77,7 +252,7 @@ func (s *MCPServer) SetIOStreams(reader io.Reader, writer io.Writer) error {
 	}
 
 	s.transport = transport
-	s.transport.SetRequestHandler(s.protocolHandler.HandleRequest)
+	s.transport.SetRequestHandler(s.requestHandler())
 	return nil
 }
 
@@ -88,8 +263,8 @@ func (s *MCPServer) Start(ctx context.Context) error {
 	}
 
 	// Don't log in stdio mode as it interferes with JSON-RPC communication
-	if s.mode != "stdio" {
-		fmt.Printf("Starting MCP server in %s mode\n", s.mode)
+	if s.mode != constants.MCPModeStdio {
+		fmt.Fprintf(os.Stderr, "Starting MCP server in %s mode\n", s.mode)
 	}
 	return s.transport.Start(ctx)
 }
@@ -101,12 +276,71 @@ func (s *MCPServer) Stop() error {
 	}
 
 	// Don't log in stdio mode as it interferes with JSON-RPC communication
-	if s.mode != "stdio" {
-		fmt.Printf("Stopping MCP server (%s mode)\n", s.mode)
+	if s.mode != constants.MCPModeStdio {
+		fmt.Fprintf(os.Stderr, "Stopping MCP server (%s mode)\n", s.mode)
 	}
 	return s.transport.Stop()
 }
 
+// Shutdown stops accepting new requests and drains in-flight ones until ctx is done.
+// Start returns once the transport has stopped serving.
+func (s *MCPServer) Shutdown(ctx context.Context) error {
+	if s.transport == nil {
+		return nil
+	}
+
+	// Don't log in stdio mode as it interferes with JSON-RPC communication
+	if s.mode != constants.MCPModeStdio {
+		fmt.Fprintf(os.Stderr, "Shutting down MCP server (%s mode)\n", s.mode)
+	}
+	return s.transport.Shutdown(ctx)
+}
+
+// Close stops background work and flushes buffered node events; call it once the
+// server has stopped serving
+func (s *MCPServer) Close(ctx context.Context) error {
+	err := s.protocolHandler.Close(ctx)
+	if s.accessLogFile != nil {
+		s.accessLogFile.Close()
+		s.accessLogFile = nil
+	}
+	return err
+}
+
+// requestHandler returns the protocol handler wrapped in the access log, if enabled
+func (s *MCPServer) requestHandler() RequestHandler {
+	return s.accessLog.Wrap(s.protocolHandler.HandleRequest)
+}
+
+// SetAccessLog logs each JSON-RPC call at level ("debug", "info" or "error"; "off"
+// disables it) to the file at path, appending, or to stderr when path is empty. In
+// stdio mode the log always goes to stderr, since stdout carries the protocol.
+func (s *MCPServer) SetAccessLog(level, path string) error {
+	if s.accessLogFile != nil {
+		s.accessLogFile.Close()
+		s.accessLogFile = nil
+	}
+	s.accessLog = nil
+
+	if level != constants.AccessLogOff {
+		var out io.Writer = os.Stderr
+		if path != "" && s.mode != constants.MCPModeStdio {
+			file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+			if err != nil {
+				return fmt.Errorf("failed to open access log: %w", err)
+			}
+			s.accessLogFile = file
+			out = file
+		}
+		s.accessLog = NewAccessLogger(out, LogLevel(level))
+	}
+
+	if s.transport != nil {
+		s.transport.SetRequestHandler(s.requestHandler())
+	}
+	return nil
+}
+
 // GetMode returns the current transport mode
 func (s *MCPServer) GetMode() string {
 	return s.mode
@@ -139,17 +373,23 @@ func (s *MCPServer) SwitchMode(newMode string) error {
 		return fmt.Errorf("failed to initialize new transport: %w", err)
 	}
 
-	fmt.Printf("Switched to %s mode\n", newMode)
+	fmt.Fprintf(os.Stderr, "Switched to %s mode\n", newMode)
 	return nil
 }
 
 // initializeTransport creates and configures the transport based on current mode
 func (s *MCPServer) initializeTransport() error {
 	config := &TransportConfig{
-		Mode:   s.mode,
-		Port:   s.port,
-		Reader: os.Stdin,  // Default for stdio
-		Writer: os.Stdout, // Default for stdio
+		Mode:         s.mode,
+		Port:         s.port,
+		Reader:       os.Stdin,  // Default for stdio
+		Writer:       os.Stdout, // Default for stdio
+		DoneEvent:    s.sseDoneEvent,
+		MaxBodyBytes: s.maxBodyBytes,
+		RateLimiter:  s.rateLimiter,
+		Auth:         s.auth,
+		CORS:         s.cors,
+		Health:       s.protocolHandler.Health,
 	}
 
 	transport, err := s.transportFactory.CreateTransport(config)
@@ -158,7 +398,7 @@ func (s *MCPServer) initializeTransport() error {
 	}
 
 	// Set the request handler
-	transport.SetRequestHandler(s.protocolHandler.HandleRequest)
+	transport.SetRequestHandler(s.requestHandler())
 	transport.SetPort(s.port)
 
 	s.transport = transport
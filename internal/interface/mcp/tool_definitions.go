@@ -59,6 +59,106 @@ func GetToolDefinitions() []ToolDefinition {
 			},
 		},
 
+		{
+			Name:        "get_server_capabilities",
+			Description: stringPtr("Report the features this server instance has enabled: mode, read-only, whether an API key is required, the number of callable tools, the database file and optional subsystems such as full-text search"),
+			InputSchema: InputSchema{
+				Type:       "object",
+				Properties: map[string]map[string]interface{}{},
+			},
+			OutputSchema: &OutputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"mode":          {"type": "string", "description": "Transport mode: stdio, sse or http"},
+					"read_only":     {"type": "boolean"},
+					"auth_required": {"type": "boolean", "description": "Whether HTTP and SSE requests need 'Authorization: Bearer <key>'"},
+					"tool_count":    {"type": "integer", "description": "Tools listed by tools/list on this instance"},
+					"database_path": {"type": "string", "description": "Database file, empty for an in-memory database"},
+					"subsystems":    {"type": "object", "description": "Enabled state of full_text_search, admin_tools, subscription_worker, expiry_sweeper, event_buffer, title_fetcher and response_envelope"},
+				},
+				Required: []string{"mode", "read_only", "auth_required", "tool_count", "database_path", "subsystems"},
+			},
+			Annotations: &ToolAnnotations{
+				ReadOnlyHint:  boolPtr(true),
+				OpenWorldHint: boolPtr(false),
+			},
+		},
+
+		{
+			Name:        "get_health",
+			Description: stringPtr("Check that the server and its database are healthy, the same report as /health in SSE and HTTP modes"),
+			InputSchema: InputSchema{
+				Type:       "object",
+				Properties: map[string]map[string]interface{}{},
+			},
+			OutputSchema: &OutputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"status":         {"type": "string", "enum": []string{"ok", "degraded"}, "description": "degraded when the database does not answer a query"},
+					"server":         {"type": "string"},
+					"tool_name":      {"type": "string", "description": "Tool name used in composite IDs"},
+					"uptime_seconds": {"type": "integer"},
+					"database":       {"type": "string", "enum": []string{"ok", "error"}},
+					"error":          {"type": "string", "description": "Why the database check failed"},
+				},
+				Required: []string{"status", "server", "tool_name", "uptime_seconds", "database"},
+			},
+			Annotations: &ToolAnnotations{
+				ReadOnlyHint:  boolPtr(true),
+				OpenWorldHint: boolPtr(false),
+			},
+		},
+
+		{
+			Name:        "get_storage_stats",
+			Description: stringPtr("Get row counts per table and the database size, for monitoring growth and capacity planning"),
+			InputSchema: InputSchema{
+				Type:       "object",
+				Properties: map[string]map[string]interface{}{},
+			},
+			OutputSchema: &OutputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"row_counts": {"type": "object", "description": "Rows of domains, nodes, attributes, node_attributes, templates, dependencies, subscriptions and events"},
+					"size_bytes": {"type": "integer", "description": "Size of the database file (page_size * page_count), excluding any WAL file"},
+					"page_size":  {"type": "integer"},
+					"page_count": {"type": "integer"},
+					"free_pages": {"type": "integer", "description": "Unused pages that VACUUM would release"},
+					"free_bytes": {"type": "integer"},
+				},
+				Required: []string{"row_counts", "size_bytes", "page_size", "page_count", "free_pages", "free_bytes"},
+			},
+			Annotations: &ToolAnnotations{
+				ReadOnlyHint:  boolPtr(true),
+				OpenWorldHint: boolPtr(false),
+			},
+		},
+
+		{
+			Name:        "rebuild_search_index",
+			Description: stringPtr("Rebuild the full-text search index from the nodes table, for one domain or all, after writes that bypassed it such as bulk loads (admin tool, requires ADMIN_TOOLS=true)"),
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"domain_name": {"type": "string", "description": "Domain to reindex; omit to reindex every domain"},
+				},
+			},
+			OutputSchema: &OutputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"domain_name":   {"type": "string", "description": "The reindexed domain; absent when every domain was reindexed"},
+					"indexed_count": {"type": "integer", "description": "Nodes written to the index"},
+				},
+				Required: []string{"indexed_count"},
+			},
+			Annotations: &ToolAnnotations{
+				ReadOnlyHint:    boolPtr(false),
+				DestructiveHint: boolPtr(false),
+				IdempotentHint:  boolPtr(true),
+				OpenWorldHint:   boolPtr(false),
+			},
+		},
+
 		// Domain Management
 		{
 			Name:        "list_domains",
@@ -66,8 +166,9 @@ func GetToolDefinitions() []ToolDefinition {
 			InputSchema: InputSchema{
 				Type: "object",
 				Properties: map[string]map[string]interface{}{
-					"page": {"type": "integer", "default": 1},
-					"size": {"type": "integer", "default": 20},
+					"page":   {"type": "integer", "default": 1},
+					"size":   {"type": "integer", "default": 20},
+					"cursor": {"type": "string", "description": "Cursor pagination instead of page: pass \"\" for the first page, then each next_cursor. Domains come oldest first"},
 				},
 			},
 			OutputSchema: &OutputSchema{
@@ -87,6 +188,8 @@ func GetToolDefinitions() []ToolDefinition {
 					"total_count": {"type": "integer"},
 					"page":        {"type": "integer"},
 					"total_pages": {"type": "integer"},
+					"next_cursor": {"type": "string", "description": "Cursor of the next page; absent on the last page"},
+					"pagination":  paginationSchema,
 				},
 			},
 			Annotations: &ToolAnnotations{
@@ -101,8 +204,9 @@ func GetToolDefinitions() []ToolDefinition {
 			InputSchema: InputSchema{
 				Type: "object",
 				Properties: map[string]map[string]interface{}{
-					"name":        {"type": "string", "description": "Domain name"},
-					"description": {"type": "string", "description": "Domain description"},
+					"name":          {"type": "string", "description": "Domain name: ASCII letters, digits, hyphens and underscores; surrounding whitespace is trimmed"},
+					"description":   {"type": "string", "description": "Domain description"},
+					"if_not_exists": {"type": "boolean", "description": "Return the existing domain instead of an error when it already exists", "default": false},
 				},
 				Required: []string{"name", "description"},
 			},
@@ -112,11 +216,182 @@ func GetToolDefinitions() []ToolDefinition {
 					"name":        {"type": "string"},
 					"description": {"type": "string"},
 					"created_at":  {"type": "string", "format": "date-time"},
+					"created":     {"type": "boolean", "description": "False when if_not_exists returned an existing domain"},
 				},
 				Required: []string{"name", "description", "created_at"},
 			},
 		},
 
+		{
+			Name:        "update_domain",
+			Description: stringPtr("Update a domain's description (requires: domain must exist via create_domain). Domains cannot be renamed; create a new domain instead"),
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"domain_name": {"type": "string", "description": "Domain name"},
+					"description": {"type": "string", "description": "New domain description"},
+				},
+				Required: []string{"domain_name", "description"},
+			},
+			OutputSchema: &OutputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"name":        {"type": "string"},
+					"description": {"type": "string"},
+					"created_at":  {"type": "string", "format": "date-time"},
+					"updated_at":  {"type": "string", "format": "date-time"},
+				},
+				Required: []string{"name", "description", "updated_at"},
+			},
+			Annotations: &ToolAnnotations{
+				IdempotentHint: boolPtr(true),
+				OpenWorldHint:  boolPtr(false),
+			},
+		},
+
+		{
+			Name:        "delete_domain",
+			Description: stringPtr("Delete an empty domain and its attribute definitions (requires: domain must exist and contain no nodes; delete nodes first via delete_node)"),
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"domain_name": {"type": "string", "description": "Domain name to delete"},
+					"dry_run":     {"type": "boolean", "default": false, "description": "Only check the deletion and report the attribute definitions and templates it would remove, without deleting"},
+				},
+				Required: []string{"domain_name"},
+			},
+			Annotations: &ToolAnnotations{
+				DestructiveHint: boolPtr(true),
+				OpenWorldHint:   boolPtr(false),
+			},
+		},
+
+		{
+			Name:        "get_domain",
+			Description: stringPtr("Get a domain's description, timestamps and node count (requires: domain must exist via create_domain)"),
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"domain_name": {"type": "string", "description": "Domain name"},
+				},
+				Required: []string{"domain_name"},
+			},
+			OutputSchema: &OutputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"name":        {"type": "string"},
+					"description": {"type": "string"},
+					"node_count":  {"type": "integer"},
+					"created_at":  {"type": "string", "format": "date-time"},
+					"updated_at":  {"type": "string", "format": "date-time"},
+				},
+				Required: []string{"name", "description", "node_count", "created_at", "updated_at"},
+			},
+			Annotations: &ToolAnnotations{
+				ReadOnlyHint:  boolPtr(true),
+				OpenWorldHint: boolPtr(false),
+			},
+		},
+
+		{
+			Name:        "get_domain_stats",
+			Description: stringPtr("Get node count and node cap usage for a domain (requires: domain must exist via create_domain)"),
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"domain_name": {"type": "string", "description": "Domain name"},
+				},
+				Required: []string{"domain_name"},
+			},
+			OutputSchema: &OutputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"domain_name": {"type": "string"},
+					"node_count":  {"type": "integer"},
+					"max_nodes":   {"type": "integer", "description": "Node cap for the domain; 0 means unlimited"},
+					"remaining":   {"type": []string{"integer", "null"}, "description": "Nodes that can still be created; null when unlimited"},
+				},
+				Required: []string{"domain_name", "node_count", "max_nodes"},
+			},
+			Annotations: &ToolAnnotations{
+				ReadOnlyHint:  boolPtr(true),
+				OpenWorldHint: boolPtr(false),
+			},
+		},
+
+		{
+			Name:        "export_domain",
+			Description: stringPtr("Export a whole domain as one document: domain metadata, attribute definitions, and every URL with its attributes (requires: domain must exist via create_domain)"),
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"domain_name": {"type": "string", "description": "Domain name to export"},
+					"format":      {"type": "string", "enum": []string{"json", "ndjson"}, "description": "json returns the export as structured content; ndjson returns one record per line tagged by \"record\" (domain, then attributes, then nodes) for line-by-line processing", "default": "json"},
+				},
+				Required: []string{"domain_name"},
+			},
+			Annotations: &ToolAnnotations{
+				ReadOnlyHint:  boolPtr(true),
+				OpenWorldHint: boolPtr(false),
+			},
+		},
+
+		{
+			Name:        "import_domain",
+			Description: stringPtr("Restore a domain from an export_domain dump in one transaction, creating the domain and its attribute definitions if missing. Attribute values are validated as by set_node_attributes"),
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"data":        {"type": []string{"string", "object"}, "description": "The export: json text, the structured content export_domain returned, or ndjson text"},
+					"format":      {"type": "string", "enum": []string{"json", "ndjson"}, "description": "Format of data", "default": "json"},
+					"domain_name": {"type": "string", "description": "Import into this domain instead of the one named in the export"},
+					"conflict":    {"type": "string", "enum": []string{"skip", "overwrite", "fail"}, "description": "When a URL already exists: keep it (skip), replace its title, description and attributes (overwrite), or abort the import (fail)", "default": "fail"},
+				},
+				Required: []string{"data"},
+			},
+			Annotations: &ToolAnnotations{
+				DestructiveHint: boolPtr(true),
+				OpenWorldHint:   boolPtr(false),
+			},
+		},
+
+		{
+			Name:        "merge_domains",
+			Description: stringPtr("Move every URL, attribute definition and template of one domain into another and delete the emptied source domain, all in one transaction (requires: both domains must exist via create_domain)"),
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"source_domain_name":          {"type": "string", "description": "Domain to merge and delete"},
+					"target_domain_name":          {"type": "string", "description": "Domain that receives the source's URLs"},
+					"conflict":                    {"type": "string", "enum": []string{"skip", "overwrite", "fail"}, "description": "When a URL is in both domains: keep the target's (skip), give it the source's title, description and attributes (overwrite), or abort the merge (fail)", "default": "fail"},
+					"drop_conflicting_attributes": {"type": "boolean", "description": "Drop values of attributes both domains define with different types, instead of aborting", "default": false},
+					"dry_run":                     {"type": "boolean", "description": "Report what the merge would do without changing anything", "default": false},
+				},
+				Required: []string{"source_domain_name", "target_domain_name"},
+			},
+			OutputSchema: &OutputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"source_domain_name":  {"type": "string"},
+					"target_domain_name":  {"type": "string"},
+					"conflict":            {"type": "string"},
+					"dry_run":             {"type": "boolean"},
+					"nodes":               {"type": "object", "description": "Counts of URLs moved, skipped and overwritten"},
+					"links":               {"type": "object", "description": "Counts of connections and dependencies of skipped or overwritten source nodes repointed to the target node, and dropped as duplicates"},
+					"attributes":          {"type": "object", "description": "Counts of attribute definitions moved (new to the target) and merged (same name and type)"},
+					"templates":           {"type": "object", "description": "Counts of templates moved and dropped because the target uses their name"},
+					"attribute_conflicts": {"type": "array", "description": "Attributes defined with different types: name, source_type, target_type, dropped_values"},
+				},
+				Required: []string{"source_domain_name", "target_domain_name", "nodes", "attributes", "templates", "attribute_conflicts"},
+			},
+			Annotations: &ToolAnnotations{
+				ReadOnlyHint:    boolPtr(false),
+				DestructiveHint: boolPtr(true),
+				IdempotentHint:  boolPtr(false),
+				OpenWorldHint:   boolPtr(false),
+			},
+		},
+
 		// Node Management
 		{
 			Name:        "list_nodes",
@@ -124,10 +399,23 @@ func GetToolDefinitions() []ToolDefinition {
 			InputSchema: InputSchema{
 				Type: "object",
 				Properties: map[string]map[string]interface{}{
-					"domain_name": {"type": "string", "description": "Domain name to list nodes from"},
-					"page":        {"type": "integer", "default": 1},
-					"size":        {"type": "integer", "default": 20},
-					"search":      {"type": "string", "description": "Search query"},
+					"domain_name":      {"type": "string", "description": "Domain name to list nodes from"},
+					"page":             {"type": "integer", "default": 1},
+					"size":             {"type": "integer", "default": 20},
+					"search":           {"type": "string", "description": "Only list nodes whose URL, title or description contains every word"},
+					"include_archived": {"type": "boolean", "default": false, "description": "Also list nodes archived via archive_node; they carry archived_at"},
+					"created_after":    {"type": "string", "format": "date-time", "description": "Only nodes created at or after this RFC3339 time"},
+					"created_before":   {"type": "string", "format": "date-time", "description": "Only nodes created before this RFC3339 time"},
+					"updated_after":    {"type": "string", "format": "date-time", "description": "Only nodes updated at or after this RFC3339 time, e.g. to process what changed since yesterday"},
+					"updated_before":   {"type": "string", "format": "date-time", "description": "Only nodes updated before this RFC3339 time"},
+					"fields": {
+						"type":        "array",
+						"description": "Fields to include (composite_id and attribute_count are always included); omit for all fields",
+						"items": map[string]interface{}{
+							"type": "string",
+							"enum": []string{"composite_id", "attribute_count", "id", "url", "title", "description", "created_at"},
+						},
+					},
 				},
 				Required: []string{"domain_name"},
 			},
@@ -143,18 +431,49 @@ func GetToolDefinitions() []ToolDefinition {
 			InputSchema: InputSchema{
 				Type: "object",
 				Properties: map[string]map[string]interface{}{
-					"domain_name": {"type": "string", "description": "Domain name"},
-					"url":         {"type": "string", "description": "URL to store"},
-					"title":       {"type": "string", "description": "Node title"},
-					"description": {"type": "string", "description": "Node description"},
+					"domain_name":    {"type": "string", "description": "Domain name"},
+					"url":            {"type": "string", "description": "Absolute URL to store (scheme and host required)"},
+					"title":          {"type": "string", "description": "Node title"},
+					"description":    {"type": "string", "description": "Node description"},
+					"fetch_title":    {"type": "boolean", "default": false, "description": "Fetch the page and use its HTML <title> when no title is given"},
+					"expires_in":     {"type": "integer", "minimum": 1, "description": "Seconds until the node expires and is removed; omit to keep it forever"},
+					"expires_at":     {"type": "string", "format": "date-time", "description": "RFC 3339 time at which the node expires (alternative to expires_in)"},
+					"allow_relative": {"type": "boolean", "default": false, "description": "Also accept scheme-less URLs such as paths or #fragment identifiers"},
 				},
 				Required: []string{"domain_name", "url"},
 			},
 		},
 
+		{
+			Name:        "create_nodes_batch",
+			Description: stringPtr("Add many URLs to a domain in one call, reporting success or failure per item (requires: domain must exist via create_domain)"),
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"domain_name": {"type": "string", "description": "Domain name"},
+					"nodes": {
+						"type":        "array",
+						"description": "URLs to create (max 100)",
+						"items": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"url":         map[string]interface{}{"type": "string", "description": "URL to store"},
+								"title":       map[string]interface{}{"type": "string", "description": "Node title"},
+								"description": map[string]interface{}{"type": "string", "description": "Node description"},
+							},
+							"required": []string{"url"},
+						},
+					},
+					"atomic":         {"type": "boolean", "default": false, "description": "Create nothing if any item fails"},
+					"allow_relative": {"type": "boolean", "default": false, "description": "Also accept scheme-less URLs such as paths or #fragment identifiers"},
+				},
+				Required: []string{"domain_name", "nodes"},
+			},
+		},
+
 		{
 			Name:        "get_node",
-			Description: stringPtr("Get URL details (requires: node must exist via create_node; returns composite_id from create_node)"),
+			Description: stringPtr("Get URL details, including the version to pass to update_node as expected_version (requires: node must exist via create_node; returns composite_id from create_node)"),
 			InputSchema: InputSchema{
 				Type: "object",
 				Properties: map[string]map[string]interface{}{
@@ -170,13 +489,14 @@ func GetToolDefinitions() []ToolDefinition {
 
 		{
 			Name:        "update_node",
-			Description: stringPtr("Update URL title or description (requires: node must exist via create_node; use composite_id from create_node)"),
+			Description: stringPtr("Update URL title or description; with expected_version the update is refused with a conflict if the node changed since it was read (requires: node must exist via create_node; use composite_id from create_node)"),
 			InputSchema: InputSchema{
 				Type: "object",
 				Properties: map[string]map[string]interface{}{
-					"composite_id": {"type": "string", "description": "Composite ID (format: tool:domain:id)"},
-					"title":        {"type": "string", "description": "New title"},
-					"description":  {"type": "string", "description": "New description"},
+					"composite_id":     {"type": "string", "description": "Composite ID (format: tool:domain:id)"},
+					"title":            {"type": "string", "description": "New title"},
+					"description":      {"type": "string", "description": "New description"},
+					"expected_version": {"type": "integer", "minimum": 1, "description": "Only update if the node is still at this version, as returned by get_node or a previous update"},
 				},
 				Required: []string{"composite_id"},
 			},
@@ -189,15 +509,22 @@ func GetToolDefinitions() []ToolDefinition {
 				Type: "object",
 				Properties: map[string]map[string]interface{}{
 					"composite_id": {"type": "string", "description": "Composite ID (format: tool:domain:id)"},
+					"dry_run":      {"type": "boolean", "default": false, "description": "Only report the node and the attributes, dependencies and subscriptions it would remove, without deleting"},
 				},
 				Required: []string{"composite_id"},
 			},
 			OutputSchema: &OutputSchema{
 				Type: "object",
 				Properties: map[string]map[string]interface{}{
-					"deleted":      {"type": "boolean"},
-					"composite_id": {"type": "string"},
-					"message":      {"type": "string"},
+					"deleted":            {"type": "boolean"},
+					"dry_run":            {"type": "boolean"},
+					"composite_id":       {"type": "string"},
+					"url":                {"type": "string"},
+					"title":              {"type": "string"},
+					"attribute_count":    {"type": "integer", "description": "Attribute values removed with the node"},
+					"dependency_count":   {"type": "integer", "description": "Dependencies of the node removed with it"},
+					"dependent_count":    {"type": "integer", "description": "Dependencies on the node removed with it"},
+					"subscription_count": {"type": "integer", "description": "Subscriptions to the node removed with it"},
 				},
 				Required: []string{"deleted", "composite_id"},
 			},
@@ -210,35 +537,883 @@ func GetToolDefinitions() []ToolDefinition {
 		},
 
 		{
-			Name:        "find_node_by_url",
-			Description: stringPtr("Search by exact URL (requires: domain must exist via create_domain; returns composite_id if found)"),
+			Name:        "archive_node",
+			Description: stringPtr("Archive a URL without deleting it: list_nodes leaves it out unless include_archived is set, and restore_node brings it back (requires: node must exist via create_node)"),
 			InputSchema: InputSchema{
 				Type: "object",
 				Properties: map[string]map[string]interface{}{
-					"domain_name": {"type": "string", "description": "Domain name"},
-					"url":         {"type": "string", "description": "URL to find"},
+					"composite_id": {"type": "string", "description": "Composite ID (format: tool:domain:id)"},
 				},
-				Required: []string{"domain_name", "url"},
+				Required: []string{"composite_id"},
+			},
+			OutputSchema: &OutputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"composite_id": {"type": "string"},
+					"archived":     {"type": "boolean"},
+					"archived_at":  {"type": "string", "format": "date-time"},
+				},
+				Required: []string{"composite_id", "archived"},
 			},
 			Annotations: &ToolAnnotations{
-				ReadOnlyHint:  boolPtr(true),
-				OpenWorldHint: boolPtr(false),
+				ReadOnlyHint:    boolPtr(false),
+				DestructiveHint: boolPtr(false),
+				IdempotentHint:  boolPtr(true),
+				OpenWorldHint:   boolPtr(false),
 			},
 		},
 
 		{
-			Name:        "scan_all_content",
-			Description: stringPtr("Retrieve all URLs and their content from a domain using page-based navigation with token optimization for AI processing"),
+			Name:        "restore_node",
+			Description: stringPtr("Restore a URL archived via archive_node so list_nodes shows it again (requires: node must exist via create_node)"),
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"composite_id": {"type": "string", "description": "Composite ID (format: tool:domain:id)"},
+				},
+				Required: []string{"composite_id"},
+			},
+			OutputSchema: &OutputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"composite_id": {"type": "string"},
+					"archived":     {"type": "boolean"},
+				},
+				Required: []string{"composite_id", "archived"},
+			},
+			Annotations: &ToolAnnotations{
+				ReadOnlyHint:    boolPtr(false),
+				DestructiveHint: boolPtr(false),
+				IdempotentHint:  boolPtr(true),
+				OpenWorldHint:   boolPtr(false),
+			},
+		},
+
+		{
+			Name:        "move_node",
+			Description: stringPtr("Move a URL to another domain and return its new composite_id (requires: node must exist via create_node; target domain must exist via create_domain). Attribute values follow the target attribute of the same name and type"),
 			InputSchema: InputSchema{
 				Type: "object",
 				Properties: map[string]map[string]interface{}{
-					"domain_name":         {"type": "string", "description": "Domain name to scan"},
-					"max_tokens_per_page": {"type": "integer", "description": "Maximum tokens per page (recommended: 6000-10000)", "default": 8000},
-					"page":                {"type": "integer", "description": "Page number (1-based)", "default": 1},
-					"include_attributes":  {"type": "boolean", "description": "Include node attributes in response", "default": true},
-					"compress_attributes": {"type": "boolean", "description": "Remove duplicate attribute values for AI context compression", "default": false},
+					"composite_id":                 {"type": "string", "description": "Composite ID (format: tool:domain:id)"},
+					"target_domain_name":           {"type": "string", "description": "Domain to move the node to"},
+					"drop_incompatible_attributes": {"type": "boolean", "default": false, "description": "Drop values of attributes the target domain does not define with the same type; otherwise the move fails"},
+				},
+				Required: []string{"composite_id", "target_domain_name"},
+			},
+			OutputSchema: &OutputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"composite_id":          {"type": "string", "description": "New composite ID in the target domain"},
+					"previous_composite_id": {"type": "string"},
+					"domain_name":           {"type": "string"},
+					"dropped_attributes":    {"type": "array", "items": map[string]interface{}{"type": "string"}},
+				},
+				Required: []string{"composite_id", "previous_composite_id", "domain_name", "dropped_attributes"},
+			},
+			Annotations: &ToolAnnotations{
+				ReadOnlyHint:    boolPtr(false),
+				DestructiveHint: boolPtr(true),
+				OpenWorldHint:   boolPtr(false),
+			},
+		},
+
+		{
+			Name:        "move_nodes",
+			Description: stringPtr("Move many URLs to another domain in one transaction, reporting success or failure per node (requires: target domain must exist via create_domain; use composite_ids from create_node). Like move_node with drop_incompatible_attributes, dropped attributes are reported per node"),
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"composite_ids": {
+						"type":        "array",
+						"description": "Composite IDs of the nodes to move (max 100)",
+						"items":       map[string]interface{}{"type": "string"},
+					},
+					"target_domain_name": {"type": "string", "description": "Domain to move the nodes to"},
+				},
+				Required: []string{"composite_ids", "target_domain_name"},
+			},
+			Annotations: &ToolAnnotations{
+				ReadOnlyHint:    boolPtr(false),
+				DestructiveHint: boolPtr(true),
+				OpenWorldHint:   boolPtr(false),
+			},
+		},
+
+		{
+			Name:        "find_duplicate_nodes",
+			Description: stringPtr("Find clusters of URLs in a domain that point at the same resource once normalized: host lowercased, trailing slashes, fragments and tracking parameters (utm_*, fbclid, gclid) removed (requires: domain must exist via create_domain). Use the composite IDs with delete_node to clean up"),
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"domain_name": {"type": "string", "description": "Domain to check"},
+					"page":        {"type": "integer", "default": 1, "description": "Page of clusters"},
+					"size":        {"type": "integer", "default": 20, "description": "Clusters per page (max 100)"},
+				},
+				Required: []string{"domain_name"},
+			},
+			OutputSchema: &OutputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"domain_name": {"type": "string"},
+					"clusters": {
+						"type": "array",
+						"items": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"normalized_url": map[string]interface{}{"type": "string"},
+								"count":          map[string]interface{}{"type": "integer"},
+								"composite_ids":  map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+								"nodes":          map[string]interface{}{"type": "array", "description": "Members oldest first, with composite_id, url, title and created_at"},
+							},
+						},
+					},
+					"cluster_count":        {"type": "integer"},
+					"duplicate_node_count": {"type": "integer", "description": "Nodes in all clusters"},
+					"pagination":           paginationSchema,
+				},
+				Required: []string{"domain_name", "clusters", "cluster_count", "duplicate_node_count", "pagination"},
+			},
+			Annotations: &ToolAnnotations{
+				ReadOnlyHint:  boolPtr(true),
+				OpenWorldHint: boolPtr(false),
+			},
+		},
+
+		{
+			Name:        "find_node_by_url",
+			Description: stringPtr("Search by exact URL (requires: domain must exist via create_domain; returns composite_id if found)"),
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"domain_name":      {"type": "string", "description": "Domain name"},
+					"url":              {"type": "string", "description": "URL to find"},
+					"include_archived": {"type": "boolean", "default": false, "description": "Also find the node when it is archived, e.g. to restore it with restore_node instead of creating a duplicate"},
+				},
+				Required: []string{"domain_name", "url"},
+			},
+			Annotations: &ToolAnnotations{
+				ReadOnlyHint:  boolPtr(true),
+				OpenWorldHint: boolPtr(false),
+			},
+		},
+
+		{
+			Name:        "find_nodes_by_urls",
+			Description: stringPtr("Check many URLs at once (requires: domain must exist via create_domain; returns composite_id per found URL, in input order)"),
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"domain_name": {"type": "string", "description": "Domain name"},
+					"urls": {
+						"type":        "array",
+						"description": "URLs to look up (max 1000)",
+						"items":       map[string]interface{}{"type": "string"},
+					},
+				},
+				Required: []string{"domain_name", "urls"},
+			},
+			OutputSchema: &OutputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"domain_name": {"type": "string"},
+					"results": {
+						"type": "array",
+						"items": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"url":          map[string]interface{}{"type": "string"},
+								"found":        map[string]interface{}{"type": "boolean"},
+								"composite_id": map[string]interface{}{"type": "string"},
+							},
+						},
+					},
+					"found_count": {"type": "integer"},
+				},
+			},
+			Annotations: &ToolAnnotations{
+				ReadOnlyHint:  boolPtr(true),
+				OpenWorldHint: boolPtr(false),
+			},
+		},
+
+		{
+			Name:        "scan_all_content",
+			Description: stringPtr("Retrieve all URLs and their content from a domain using page-based navigation with token optimization for AI processing"),
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"domain_name":          {"type": "string", "description": "Domain name to scan"},
+					"max_tokens_per_page":  {"type": "integer", "description": "Maximum tokens per page (recommended: 6000-10000)", "default": 8000},
+					"page":                 {"type": "integer", "description": "Page number (1-based)", "default": 1},
+					"include_attributes":   {"type": "boolean", "description": "Include node attributes in response", "default": true},
+					"compress_attributes":  {"type": "boolean", "description": "Remove duplicate attribute values for AI context compression", "default": false},
+					"include_content_hash": {"type": "boolean", "description": "Add a content_hash to each item (SHA-256 of URL, title, description and attributes) so sync clients can skip unchanged nodes", "default": false},
+					"stream":               {"type": "boolean", "description": "In sse mode, send each item as a notifications/scan_item message before the response, which then omits items. Ignored in stdio and http modes", "default": false},
+					"order":                {"type": "string", "description": "Node order by created_at, ties broken by id; defaults to the server's SCAN_DEFAULT_ORDER (asc). Deleting nodes between page requests shifts later page boundaries", "enum": []string{"asc", "desc"}},
+				},
+				Required: []string{"domain_name"},
+			},
+			Annotations: &ToolAnnotations{
+				ReadOnlyHint:  boolPtr(true),
+				OpenWorldHint: boolPtr(false),
+			},
+		},
+
+		{
+			Name:        "estimate_scan_size",
+			Description: stringPtr("Estimate how many nodes, tokens and pages a full scan_all_content of a domain would return, from the node count alone without reading any content"),
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"domain_name":         {"type": "string", "description": "Domain name to estimate"},
+					"max_tokens_per_page": {"type": "integer", "description": "Token budget per page, as passed to scan_all_content (at most 5000)", "default": 3000},
+					"include_attributes":  {"type": "boolean", "description": "Count node attributes, as scan_all_content's include_attributes", "default": true},
+					"compress_attributes": {"type": "boolean", "description": "Accepted as scan_all_content's compress_attributes; the per-node estimate does not depend on it", "default": false},
+				},
+				Required: []string{"domain_name"},
+			},
+			OutputSchema: &OutputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"domain_name":         {"type": "string"},
+					"total_nodes":         {"type": "integer"},
+					"estimated_tokens":    {"type": "integer", "description": "Node count times the per-node average scan_all_content sizes pages with, as its estimated_tokens metadata"},
+					"total_pages":         {"type": "integer", "description": "Pages scan_all_content splits the domain into"},
+					"nodes_per_page":      {"type": "integer"},
+					"max_tokens_per_page": {"type": "integer", "description": "Token budget used, after the default and cap"},
+				},
+				Required: []string{"domain_name", "total_nodes", "estimated_tokens", "total_pages", "nodes_per_page", "max_tokens_per_page"},
+			},
+			Annotations: &ToolAnnotations{
+				ReadOnlyHint:  boolPtr(true),
+				OpenWorldHint: boolPtr(false),
+			},
+		},
+
+		// Attribute Management
+		{
+			Name:        "get_node_attributes",
+			Description: stringPtr("Get URL tags and attributes (requires: node must exist via create_node; attributes defined via create_domain_attribute)"),
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"composite_id": {"type": "string", "description": "Composite ID (format: tool:domain:id)"},
+					"include_inherited": {
+						"type":        "boolean",
+						"description": "Also include attributes inherited from parent nodes (via parent/child connections) that the node does not set itself",
+						"default":     false,
+					},
+					"format": {
+						"type":        "string",
+						"enum":        []string{"list", "map"},
+						"description": "list returns an array of {name, type, value, order_index}; map returns {name: value}, where tag and ordered_tag attributes map to an array of values (ordered_tag sorted by order_index) and other types to their single value",
+						"default":     "list",
+					},
+					"page": {"type": "integer", "description": "Page of attribute values, ordered by name then order_index (default: all values in one response)"},
+					"size": {"type": "integer", "description": "Attribute values per page when paginating (default 20, max 100)"},
+				},
+				Required: []string{"composite_id"},
+			},
+			Annotations: &ToolAnnotations{
+				ReadOnlyHint:  boolPtr(true),
+				OpenWorldHint: boolPtr(false),
+			},
+		},
+
+		{
+			Name:        "set_node_attributes",
+			Description: stringPtr("Add or update URL tags; all or nothing, so one invalid attribute leaves the node unchanged (requires: node must exist via create_node; attributes should be defined via create_domain_attribute unless auto_create_attributes=true)"),
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"composite_id": {"type": "string", "description": "Composite ID (format: tool:domain:id)"},
+					"attributes": {
+						"type":        "array",
+						"description": "Array of attributes to set",
+						"items": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"name":        map[string]interface{}{"type": "string", "description": "Attribute name"},
+								"value":       map[string]interface{}{"type": "string", "description": "Attribute value"},
+								"order_index": map[string]interface{}{"type": "integer", "description": "Order index (for ordered_tag type)"},
+							},
+							"required": []string{"name", "value"},
+						},
+					},
+					"auto_create_attributes": {"type": "boolean", "default": true, "description": "Automatically create attributes if they don't exist"},
+					"dry_run":                {"type": "boolean", "default": false, "description": "Only report which attributes would be added, changed (old → new), left unchanged or removed, and which values fail validation, without writing"},
+				},
+				Required: []string{"composite_id", "attributes"},
+			},
+		},
+
+		{
+			Name:        "clear_node_attributes",
+			Description: stringPtr("Remove every attribute from a node so it can be retagged from scratch (requires: node must exist via create_node)"),
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"composite_id": {"type": "string", "description": "Composite ID (format: tool:domain:id)"},
+				},
+				Required: []string{"composite_id"},
+			},
+			OutputSchema: &OutputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"composite_id":  {"type": "string"},
+					"removed_count": {"type": "integer"},
+				},
+				Required: []string{"composite_id", "removed_count"},
+			},
+			Annotations: &ToolAnnotations{
+				ReadOnlyHint:    boolPtr(false),
+				DestructiveHint: boolPtr(true),
+				IdempotentHint:  boolPtr(true),
+				OpenWorldHint:   boolPtr(false),
+			},
+		},
+
+		{
+			Name:        "copy_node_attributes",
+			Description: stringPtr("Copy a node's attributes onto another node of the same domain, merged with or replacing the target's attributes (requires: both nodes must exist via create_node in one domain)"),
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"source_composite_id": {"type": "string", "description": "Node to copy attributes from (format: tool:domain:id)"},
+					"target_composite_id": {"type": "string", "description": "Node to copy attributes to, in the same domain"},
+					"mode": {
+						"type":        "string",
+						"enum":        []string{"merge", "replace"},
+						"description": "merge keeps the target's attributes and adds the source's, keeping the target's value of single-valued attributes it already has; replace makes the target's attributes a copy of the source's",
+						"default":     "merge",
+					},
+				},
+				Required: []string{"source_composite_id", "target_composite_id"},
+			},
+			OutputSchema: &OutputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"source_composite_id": {"type": "string"},
+					"target_composite_id": {"type": "string"},
+					"mode":                {"type": "string"},
+					"copied_count":        {"type": "integer"},
+					"skipped":             {"type": "array", "description": "Single-valued attributes the target kept its own value for (merge mode)"},
+				},
+				Required: []string{"source_composite_id", "target_composite_id", "mode", "copied_count", "skipped"},
+			},
+			Annotations: &ToolAnnotations{
+				ReadOnlyHint:    boolPtr(false),
+				DestructiveHint: boolPtr(true),
+				IdempotentHint:  boolPtr(true),
+				OpenWorldHint:   boolPtr(false),
+			},
+		},
+
+		{
+			Name:        "add_node_tag",
+			Description: stringPtr("Add one value of a tag or ordered_tag attribute to a node, keeping its other attributes; ordered tags go last (requires: node must exist via create_node; attribute via create_domain_attribute, or pass type to create it)"),
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"composite_id":   {"type": "string", "description": "Composite ID (format: tool:domain:id)"},
+					"attribute_name": {"type": "string", "description": "Tag or ordered_tag attribute to add the value to"},
+					"value":          {"type": "string", "description": "Value to add"},
+					"type": {
+						"type":        "string",
+						"enum":        []string{"tag", "ordered_tag"},
+						"description": "Creates the attribute with this type if the domain does not define it yet",
+					},
+				},
+				Required: []string{"composite_id", "attribute_name", "value"},
+			},
+			OutputSchema: &OutputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"composite_id":      {"type": "string"},
+					"attribute_name":    {"type": "string"},
+					"added":             {"type": "boolean", "description": "False when the node already had the value"},
+					"attribute_created": {"type": "boolean"},
+					"values":            {"type": "array", "description": "The node's values of the attribute after the change"},
+				},
+				Required: []string{"composite_id", "attribute_name", "added", "attribute_created", "values"},
+			},
+			Annotations: &ToolAnnotations{
+				ReadOnlyHint:    boolPtr(false),
+				DestructiveHint: boolPtr(false),
+				IdempotentHint:  boolPtr(true),
+				OpenWorldHint:   boolPtr(false),
+			},
+		},
+
+		{
+			Name:        "remove_node_tag",
+			Description: stringPtr("Remove one value of a tag or ordered_tag attribute from a node, keeping its other attributes (requires: node must exist via create_node)"),
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"composite_id":   {"type": "string", "description": "Composite ID (format: tool:domain:id)"},
+					"attribute_name": {"type": "string", "description": "Tag or ordered_tag attribute to remove the value from"},
+					"value":          {"type": "string", "description": "Value to remove, matched after the attribute's normalisation"},
+				},
+				Required: []string{"composite_id", "attribute_name", "value"},
+			},
+			OutputSchema: &OutputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"composite_id":   {"type": "string"},
+					"attribute_name": {"type": "string"},
+					"removed":        {"type": "boolean", "description": "False when the node did not have the value"},
+					"values":         {"type": "array", "description": "The node's values of the attribute after the change"},
+				},
+				Required: []string{"composite_id", "attribute_name", "removed", "values"},
+			},
+			Annotations: &ToolAnnotations{
+				ReadOnlyHint:    boolPtr(false),
+				DestructiveHint: boolPtr(true),
+				IdempotentHint:  boolPtr(true),
+				OpenWorldHint:   boolPtr(false),
+			},
+		},
+
+		{
+			Name:        "render_node_attribute",
+			Description: stringPtr("Render one attribute of a node for display: markdown values come with sanitized HTML (raw HTML and script links removed), image values with their resolved URL, mime type and size; other types are returned as stored (requires: node must exist via create_node)"),
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"composite_id":   {"type": "string", "description": "Composite ID (format: tool:domain:id)"},
+					"attribute_name": {"type": "string", "description": "Attribute to render"},
+				},
+				Required: []string{"composite_id", "attribute_name"},
+			},
+			OutputSchema: &OutputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"composite_id":   {"type": "string"},
+					"attribute_name": {"type": "string"},
+					"type":           {"type": "string", "description": "The attribute's type"},
+					"value":          {"type": "string", "description": "The stored value; tag and ordered_tag attributes return values instead"},
+					"values":         {"type": "array", "description": "The stored values of a tag or ordered_tag attribute"},
+					"html":           {"type": "string", "description": "Sanitized HTML rendering of a markdown value"},
+					"image":          {"type": "object", "description": "For image values: source (data or url), url (empty for data URLs), mime_type (empty if unknown) and size_bytes (data URLs only)"},
+				},
+				Required: []string{"composite_id", "attribute_name", "type"},
+			},
+			Annotations: &ToolAnnotations{
+				ReadOnlyHint:    boolPtr(true),
+				DestructiveHint: boolPtr(false),
+				IdempotentHint:  boolPtr(true),
+				OpenWorldHint:   boolPtr(false),
+			},
+		},
+
+		{
+			Name:        "dedupe_node_attributes",
+			Description: stringPtr("Remove repeated attribute values (same attribute and value) from a node, keeping the lowest order_index for ordered tags (requires: node must exist via create_node)"),
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"composite_id": {"type": "string", "description": "Composite ID (format: tool:domain:id)"},
+				},
+				Required: []string{"composite_id"},
+			},
+			OutputSchema: &OutputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"composite_id":  {"type": "string"},
+					"removed_count": {"type": "integer"},
+				},
+				Required: []string{"composite_id", "removed_count"},
+			},
+			Annotations: &ToolAnnotations{
+				ReadOnlyHint:    boolPtr(false),
+				DestructiveHint: boolPtr(false),
+				IdempotentHint:  boolPtr(true),
+				OpenWorldHint:   boolPtr(false),
+			},
+		},
+
+		{
+			Name:        "get_all_attributes",
+			Description: stringPtr("Export every (composite_id, attribute_name, value, order_index) tuple in a domain, paginated by node (requires: domain must exist via create_domain)"),
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"domain_name": {"type": "string", "description": "Domain to export attributes from"},
+					"page":        {"type": "integer", "default": 1, "description": "Page number (by node)"},
+					"size":        {"type": "integer", "default": 20, "description": "Nodes per page (max 100)"},
+				},
+				Required: []string{"domain_name"},
+			},
+			OutputSchema: &OutputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"domain_name": {"type": "string"},
+					"attributes": {
+						"type": "array",
+						"items": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"composite_id":   map[string]interface{}{"type": "string"},
+								"attribute_name": map[string]interface{}{"type": "string"},
+								"value":          map[string]interface{}{"type": "string"},
+								"order_index":    map[string]interface{}{"type": []string{"integer", "null"}},
+							},
+						},
+					},
+					"page":        {"type": "integer"},
+					"size":        {"type": "integer"},
+					"total_nodes": {"type": "integer"},
+					"total_pages": {"type": "integer"},
+					"has_more":    {"type": "boolean"},
+					"pagination":  paginationSchema,
+				},
+			},
+			Annotations: &ToolAnnotations{
+				ReadOnlyHint:  boolPtr(true),
+				OpenWorldHint: boolPtr(false),
+			},
+		},
+
+		// Domain Attribute Schema
+		{
+			Name:        "list_domain_attributes",
+			Description: stringPtr("Get available tag types for domain (requires: domain must exist via create_domain)"),
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"domain_name": {"type": "string", "description": "The domain to list attributes for"},
+				},
+				Required: []string{"domain_name"},
+			},
+			Annotations: &ToolAnnotations{
+				ReadOnlyHint:  boolPtr(true),
+				OpenWorldHint: boolPtr(false),
+			},
+		},
+
+		{
+			Name:        "get_domain_schema",
+			Description: stringPtr("Get the full attribute schema of a domain as structured JSON (requires: domain must exist via create_domain)"),
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"domain_name": {"type": "string", "description": "The domain to describe"},
+				},
+				Required: []string{"domain_name"},
+			},
+			OutputSchema: &OutputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"domain_name": {"type": "string"},
+					"attributes": {
+						"type": "array",
+						"items": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"name":           map[string]interface{}{"type": "string"},
+								"type":           map[string]interface{}{"type": "string"},
+								"description":    map[string]interface{}{"type": "string"},
+								"allowed_values": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}, "description": "Values an active template restricts the attribute to; absent when none does"},
+							},
+						},
+					},
+				},
+				Required: []string{"domain_name", "attributes"},
+			},
+			Annotations: &ToolAnnotations{
+				ReadOnlyHint:  boolPtr(true),
+				OpenWorldHint: boolPtr(false),
+			},
+		},
+
+		{
+			Name:        "list_all_attributes_across_domains",
+			Description: stringPtr("List every attribute definition in every domain, grouped by attribute name, showing which domains define each name and flagging names defined with different types. Use to standardize tagging conventions across domains"),
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"mismatches_only": {"type": "boolean", "description": "Only list attribute names defined with more than one type", "default": false},
+				},
+			},
+			OutputSchema: &OutputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"attributes":      {"type": "array", "description": "One entry per attribute name: name, types, type_mismatch, domain_count and domains (domain_name, type, description, value_count)"},
+					"type_mismatches": {"type": "array", "items": map[string]interface{}{"type": "string"}, "description": "Names defined with different types in different domains"},
+					"domain_count":    {"type": "integer", "description": "Domains defining at least one attribute"},
+				},
+				Required: []string{"attributes", "type_mismatches", "domain_count"},
+			},
+			Annotations: &ToolAnnotations{
+				ReadOnlyHint:  boolPtr(true),
+				OpenWorldHint: boolPtr(false),
+			},
+		},
+
+		{
+			Name:        "diff_domain_schemas",
+			Description: stringPtr("Compare the attribute schemas of two domains: attributes only in A, only in B, and in both with a differing type or description (requires: both domains must exist via create_domain). Use before cloning or merging taxonomies"),
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"domain_a": {"type": "string", "description": "First domain"},
+					"domain_b": {"type": "string", "description": "Second domain"},
+				},
+				Required: []string{"domain_a", "domain_b"},
+			},
+			OutputSchema: &OutputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"domain_a":  {"type": "string"},
+					"domain_b":  {"type": "string"},
+					"only_in_a": {"type": "array", "description": "Attributes (name, type, description) defined only in domain_a"},
+					"only_in_b": {"type": "array", "description": "Attributes (name, type, description) defined only in domain_b"},
+					"changed":   {"type": "array", "description": "Attributes in both whose definitions differ: name, a, b and differences (\"type\", \"description\")"},
+					"identical": {"type": "array", "items": map[string]interface{}{"type": "string"}, "description": "Names of attributes defined the same way in both"},
+				},
+				Required: []string{"domain_a", "domain_b", "only_in_a", "only_in_b", "changed", "identical"},
+			},
+			Annotations: &ToolAnnotations{
+				ReadOnlyHint:  boolPtr(true),
+				OpenWorldHint: boolPtr(false),
+			},
+		},
+
+		{
+			Name:        "create_domain_attribute",
+			Description: stringPtr("Define new tag type for domain (requires: domain must exist via create_domain; enables attributes for set_node_attributes)"),
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"domain_name": {"type": "string", "description": "The domain to add attribute to"},
+					"name":        {"type": "string", "description": "Attribute name"},
+					"type": {
+						"type":        "string",
+						"description": "One of: tag, ordered_tag, number, string, markdown, image",
+						"enum":        []string{"tag", "ordered_tag", "number", "string", "markdown", "image"},
+					},
+					"description": {"type": "string", "description": "Human-readable description"},
+				},
+				Required: []string{"domain_name", "name", "type"},
+			},
+		},
+
+		{
+			Name:        "get_domain_attribute",
+			Description: stringPtr("Get details of a specific domain attribute (requires: attribute must exist via create_domain_attribute)"),
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"domain_name":    {"type": "string", "description": "The domain name"},
+					"attribute_name": {"type": "string", "description": "The attribute name to get"},
+				},
+				Required: []string{"domain_name", "attribute_name"},
+			},
+			Annotations: &ToolAnnotations{
+				ReadOnlyHint:  boolPtr(true),
+				OpenWorldHint: boolPtr(false),
+			},
+		},
+
+		{
+			Name:        "update_domain_attribute",
+			Description: stringPtr("Update domain attribute description (requires: attribute must exist via create_domain_attribute)"),
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"domain_name":    {"type": "string", "description": "The domain name"},
+					"attribute_name": {"type": "string", "description": "The attribute name to update"},
+					"description":    {"type": "string", "description": "New description for the attribute"},
+				},
+				Required: []string{"domain_name", "attribute_name"},
+			},
+		},
+
+		{
+			Name:        "delete_domain_attribute",
+			Description: stringPtr("Remove domain attribute definition (requires: attribute must exist via create_domain_attribute; removes all values from nodes)"),
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"domain_name":    {"type": "string", "description": "The domain name"},
+					"attribute_name": {"type": "string", "description": "The attribute name to delete"},
+					"dry_run":        {"type": "boolean", "default": false, "description": "Only report the node and template values it would remove, without deleting"},
+				},
+				Required: []string{"domain_name", "attribute_name"},
+			},
+			Annotations: &ToolAnnotations{
+				ReadOnlyHint:    boolPtr(false),
+				DestructiveHint: boolPtr(true),
+				IdempotentHint:  boolPtr(true),
+				OpenWorldHint:   boolPtr(false),
+			},
+		},
+
+		// Dependency Management
+		{
+			Name:        "create_dependency",
+			Description: stringPtr("Create dependency relationship between nodes (requires: both nodes must exist via create_node; use composite_ids from create_node). Rejects dependencies that would create a cycle"),
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"dependent_node_id":  {"type": "string", "description": "Composite ID of the dependent node (format: tool:domain:id)"},
+					"dependency_node_id": {"type": "string", "description": "Composite ID of the dependency node (format: tool:domain:id)"},
+					"dependency_type": {
+						"type":        "string",
+						"description": "Type of dependency: hard, soft, reference by default, or any type configured via DEPENDENCY_TYPES",
+					},
+					"cascade_delete": {"type": "boolean", "default": false, "description": "Whether to cascade delete (hard dependencies only)"},
+					"cascade_update": {"type": "boolean", "default": false, "description": "Whether to cascade update (hard dependencies only)"},
+					"description":    {"type": "string", "description": "Optional description of the dependency"},
+				},
+				Required: []string{"dependent_node_id", "dependency_node_id", "dependency_type"},
+			},
+		},
+
+		{
+			Name:        "create_dependencies",
+			Description: stringPtr("Create many dependency relationships in one transaction, reporting success or failure per edge (requires: nodes must exist via create_node). Each edge gets the same checks as create_dependency, including cycles through earlier edges of the batch; a failed edge does not stop the others"),
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"dependencies": {
+						"type":        "array",
+						"description": "Dependencies to create (max 100)",
+						"items": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"dependent_node_id":  map[string]interface{}{"type": "string", "description": "Composite ID of the dependent node (format: tool:domain:id)"},
+								"dependency_node_id": map[string]interface{}{"type": "string", "description": "Composite ID of the dependency node (format: tool:domain:id)"},
+								"dependency_type":    map[string]interface{}{"type": "string", "description": "Type of dependency: hard, soft, reference by default, or any type configured via DEPENDENCY_TYPES"},
+								"cascade_delete":     map[string]interface{}{"type": "boolean", "default": false, "description": "Whether to cascade delete (hard dependencies only)"},
+								"cascade_update":     map[string]interface{}{"type": "boolean", "default": false, "description": "Whether to cascade update (hard dependencies only)"},
+								"description":        map[string]interface{}{"type": "string", "description": "Optional description of the dependency"},
+							},
+							"required": []string{"dependent_node_id", "dependency_node_id", "dependency_type"},
+						},
+					},
+				},
+				Required: []string{"dependencies"},
+			},
+		},
+
+		{
+			Name:        "list_node_dependencies",
+			Description: stringPtr("List what a node depends on (requires: node must exist via create_node; dependencies created via create_dependency)"),
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"composite_id": {"type": "string", "description": "Composite ID of the node (format: tool:domain:id)"},
+				},
+				Required: []string{"composite_id"},
+			},
+			OutputSchema: dependencyListOutputSchema(),
+			Annotations: &ToolAnnotations{
+				ReadOnlyHint:  boolPtr(true),
+				OpenWorldHint: boolPtr(false),
+			},
+		},
+
+		{
+			Name:        "list_node_dependents",
+			Description: stringPtr("List what depends on a node (requires: node must exist via create_node; dependencies created via create_dependency)"),
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"composite_id": {"type": "string", "description": "Composite ID of the node (format: tool:domain:id)"},
+				},
+				Required: []string{"composite_id"},
+			},
+			OutputSchema: dependencyListOutputSchema(),
+			Annotations: &ToolAnnotations{
+				ReadOnlyHint:  boolPtr(true),
+				OpenWorldHint: boolPtr(false),
+			},
+		},
+
+		{
+			Name:        "delete_dependency",
+			Description: stringPtr("Remove dependency relationship (requires: dependency must exist via create_dependency; use dependency_id from list_node_dependencies)"),
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"dependency_id": {"type": "integer", "description": "ID of the dependency relationship to delete"},
+				},
+				Required: []string{"dependency_id"},
+			},
+			Annotations: &ToolAnnotations{
+				ReadOnlyHint:    boolPtr(false),
+				DestructiveHint: boolPtr(true),
+				IdempotentHint:  boolPtr(true),
+				OpenWorldHint:   boolPtr(false),
+			},
+		},
+
+		{
+			Name:        "list_domain_dependencies",
+			Description: stringPtr("List all dependency edges among nodes in a domain, for dependency-graph views (requires: domain must exist via create_domain)"),
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"domain_name": {"type": "string", "description": "Domain whose dependency graph to list"},
+					"page":        {"type": "integer", "default": 1},
+					"size":        {"type": "integer", "default": 20, "description": "Edges per page (max 100)"},
+				},
+				Required: []string{"domain_name"},
+			},
+			OutputSchema: &OutputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"domain_name": {"type": "string"},
+					"dependencies": {
+						"type": "array",
+						"items": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"id":                 map[string]interface{}{"type": "integer"},
+								"dependent_node_id":  map[string]interface{}{"type": "string"},
+								"dependency_node_id": map[string]interface{}{"type": "string"},
+								"dependency_type":    map[string]interface{}{"type": "string"},
+								"description":        map[string]interface{}{"type": "string"},
+							},
+						},
+					},
+					"total_count": {"type": "integer"},
+					"page":        {"type": "integer"},
+					"total_pages": {"type": "integer"},
+					"pagination":  paginationSchema,
+				},
+			},
+			Annotations: &ToolAnnotations{
+				ReadOnlyHint:  boolPtr(true),
+				OpenWorldHint: boolPtr(false),
+			},
+		},
+
+		// Filtering and Queries
+		{
+			Name:        "filter_nodes_by_attributes",
+			Description: stringPtr("Filter nodes by attribute values (requires: domain must exist via create_domain; attributes defined via create_domain_attribute)"),
+			InputSchema: filterNodesInputSchema(),
+			Annotations: &ToolAnnotations{
+				ReadOnlyHint:  boolPtr(true),
+				OpenWorldHint: boolPtr(false),
+			},
+		},
+
+		{
+			Name:        "explain_filter",
+			Description: stringPtr("Show the SQLite query plan of a filter_nodes_by_attributes call without running it, to see whether indexes are used (admin tool, requires ADMIN_TOOLS=true)"),
+			InputSchema: filterNodesInputSchema(),
+			OutputSchema: &OutputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"domain_name": {"type": "string"},
+					"plans":       {"type": "array", "description": "The page and count queries, each with name, query and plan rows of id, parent and detail"},
 				},
-				Required: []string{"domain_name"},
+				Required: []string{"domain_name", "plans"},
 			},
 			Annotations: &ToolAnnotations{
 				ReadOnlyHint:  boolPtr(true),
@@ -246,14 +1421,17 @@ func GetToolDefinitions() []ToolDefinition {
 			},
 		},
 
-		// Attribute Management
 		{
-			Name:        "get_node_attributes",
-			Description: stringPtr("Get URL tags and attributes (requires: node must exist via create_node; attributes defined via create_domain_attribute)"),
+			Name:        "get_node_full",
+			Description: stringPtr("Get a URL with its attributes, dependencies, dependents and connections in one call (requires: node must exist via create_node)"),
 			InputSchema: InputSchema{
 				Type: "object",
 				Properties: map[string]map[string]interface{}{
-					"composite_id": {"type": "string", "description": "Composite ID (format: tool:domain:id)"},
+					"composite_id":         {"type": "string", "description": "Composite ID (format: tool:domain:id)"},
+					"include_attributes":   {"type": "boolean", "default": true, "description": "Include attribute values"},
+					"include_dependencies": {"type": "boolean", "default": true, "description": "Include what the node depends on"},
+					"include_dependents":   {"type": "boolean", "default": true, "description": "Include what depends on the node"},
+					"include_connections":  {"type": "boolean", "default": true, "description": "Include node connections in both directions"},
 				},
 				Required: []string{"composite_id"},
 			},
@@ -264,41 +1442,28 @@ func GetToolDefinitions() []ToolDefinition {
 		},
 
 		{
-			Name:        "set_node_attributes",
-			Description: stringPtr("Add or update URL tags (requires: node must exist via create_node; attributes should be defined via create_domain_attribute unless auto_create_attributes=true)"),
+			Name:        "global_search",
+			Description: stringPtr("Search URLs, titles, descriptions and attribute values in one domain or across all domains, best matches first, with the fields each result matched in"),
 			InputSchema: InputSchema{
 				Type: "object",
 				Properties: map[string]map[string]interface{}{
-					"composite_id": {"type": "string", "description": "Composite ID (format: tool:domain:id)"},
-					"attributes": {
-						"type":        "array",
-						"description": "Array of attributes to set",
-						"items": map[string]interface{}{
-							"type": "object",
-							"properties": map[string]interface{}{
-								"name":        map[string]interface{}{"type": "string", "description": "Attribute name"},
-								"value":       map[string]interface{}{"type": "string", "description": "Attribute value"},
-								"order_index": map[string]interface{}{"type": "integer", "description": "Order index (for ordered_tag type)"},
-							},
-							"required": []string{"name", "value"},
-						},
-					},
-					"auto_create_attributes": {"type": "boolean", "default": true, "description": "Automatically create attributes if they don't exist"},
+					"query":       {"type": "string", "description": "Words to look for; every word must appear in some field of a node"},
+					"domain_name": {"type": "string", "description": "Only search this domain (default: all domains)"},
+					"page":        {"type": "integer", "default": 1},
+					"size":        {"type": "integer", "default": 20},
 				},
-				Required: []string{"composite_id", "attributes"},
+				Required: []string{"query"},
 			},
-		},
-
-		// Domain Attribute Schema
-		{
-			Name:        "list_domain_attributes",
-			Description: stringPtr("Get available tag types for domain (requires: domain must exist via create_domain)"),
-			InputSchema: InputSchema{
+			OutputSchema: &OutputSchema{
 				Type: "object",
 				Properties: map[string]map[string]interface{}{
-					"domain_name": {"type": "string", "description": "The domain to list attributes for"},
+					"query":       {"type": "string"},
+					"domain_name": {"type": "string", "description": "Searched domain, empty for all domains"},
+					"results":     {"type": "array", "description": "Nodes with composite_id, domain_name, url, title, description, score, matched_fields and attribute_matches, best first"},
+					"total_count": {"type": "integer"},
+					"pagination":  paginationSchema,
 				},
-				Required: []string{"domain_name"},
+				Required: []string{"query", "domain_name", "results", "total_count", "pagination"},
 			},
 			Annotations: &ToolAnnotations{
 				ReadOnlyHint:  boolPtr(true),
@@ -307,34 +1472,36 @@ func GetToolDefinitions() []ToolDefinition {
 		},
 
 		{
-			Name:        "create_domain_attribute",
-			Description: stringPtr("Define new tag type for domain (requires: domain must exist via create_domain; enables attributes for set_node_attributes)"),
+			Name:        "search_attribute_values",
+			Description: stringPtr("Find nodes whose attribute values contain a term, in any attribute (requires: domain must exist via create_domain)"),
 			InputSchema: InputSchema{
 				Type: "object",
 				Properties: map[string]map[string]interface{}{
-					"domain_name": {"type": "string", "description": "The domain to add attribute to"},
-					"name":        {"type": "string", "description": "Attribute name"},
-					"type": {
-						"type":        "string",
-						"description": "One of: tag, ordered_tag, number, string, markdown, image",
-						"enum":        []string{"tag", "ordered_tag", "number", "string", "markdown", "image"},
-					},
-					"description": {"type": "string", "description": "Human-readable description"},
+					"domain_name": {"type": "string", "description": "Domain name to search in"},
+					"query":       {"type": "string", "description": "Text to look for in attribute values (case-insensitive substring)"},
+					"page":        {"type": "integer", "default": 1},
+					"size":        {"type": "integer", "default": 20},
 				},
-				Required: []string{"domain_name", "name", "type"},
+				Required: []string{"domain_name", "query"},
+			},
+			Annotations: &ToolAnnotations{
+				ReadOnlyHint:  boolPtr(true),
+				OpenWorldHint: boolPtr(false),
 			},
 		},
 
 		{
-			Name:        "get_domain_attribute",
-			Description: stringPtr("Get details of a specific domain attribute (requires: attribute must exist via create_domain_attribute)"),
+			Name:        "find_nodes_by_attribute_value",
+			Description: stringPtr("Find nodes holding an exact attribute value across all domains, with per-domain counts for faceting"),
 			InputSchema: InputSchema{
 				Type: "object",
 				Properties: map[string]map[string]interface{}{
-					"domain_name":    {"type": "string", "description": "The domain name"},
-					"attribute_name": {"type": "string", "description": "The attribute name to get"},
+					"attribute_name": {"type": "string", "description": "Attribute name (matched in every domain that defines it)"},
+					"value":          {"type": "string", "description": "Exact attribute value"},
+					"page":           {"type": "integer", "default": 1},
+					"size":           {"type": "integer", "default": 20},
 				},
-				Required: []string{"domain_name", "attribute_name"},
+				Required: []string{"attribute_name", "value"},
 			},
 			Annotations: &ToolAnnotations{
 				ReadOnlyHint:  boolPtr(true),
@@ -343,69 +1510,54 @@ func GetToolDefinitions() []ToolDefinition {
 		},
 
 		{
-			Name:        "update_domain_attribute",
-			Description: stringPtr("Update domain attribute description (requires: attribute must exist via create_domain_attribute)"),
+			Name:        "suggest_attribute_values",
+			Description: stringPtr("List the existing values of an attribute in a domain, most used first, so tags reuse the domain's vocabulary instead of inventing synonyms (requires: attribute must exist via create_domain_attribute)"),
 			InputSchema: InputSchema{
 				Type: "object",
 				Properties: map[string]map[string]interface{}{
-					"domain_name":    {"type": "string", "description": "The domain name"},
-					"attribute_name": {"type": "string", "description": "The attribute name to update"},
-					"description":    {"type": "string", "description": "New description for the attribute"},
+					"domain_name":    {"type": "string", "description": "Domain name"},
+					"attribute_name": {"type": "string", "description": "Attribute name"},
+					"prefix":         {"type": "string", "description": "Only values starting with this text (case-insensitive)"},
+					"limit":          {"type": "integer", "default": 20, "maximum": 100, "description": "Maximum number of values to return"},
 				},
 				Required: []string{"domain_name", "attribute_name"},
 			},
-		},
-
-		{
-			Name:        "delete_domain_attribute",
-			Description: stringPtr("Remove domain attribute definition (requires: attribute must exist via create_domain_attribute; removes all values from nodes)"),
-			InputSchema: InputSchema{
+			OutputSchema: &OutputSchema{
 				Type: "object",
 				Properties: map[string]map[string]interface{}{
-					"domain_name":    {"type": "string", "description": "The domain name"},
-					"attribute_name": {"type": "string", "description": "The attribute name to delete"},
+					"domain_name":    {"type": "string"},
+					"attribute_name": {"type": "string"},
+					"attribute_type": {"type": "string"},
+					"prefix":         {"type": "string"},
+					"values":         {"type": "array", "description": "Values with value and node_count, most used first"},
 				},
-				Required: []string{"domain_name", "attribute_name"},
+				Required: []string{"domain_name", "attribute_name", "attribute_type", "prefix", "values"},
 			},
 			Annotations: &ToolAnnotations{
-				ReadOnlyHint:    boolPtr(false),
-				DestructiveHint: boolPtr(true),
-				IdempotentHint:  boolPtr(true),
-				OpenWorldHint:   boolPtr(false),
+				ReadOnlyHint:  boolPtr(true),
+				OpenWorldHint: boolPtr(false),
 			},
 		},
 
-		// Dependency Management
 		{
-			Name:        "create_dependency",
-			Description: stringPtr("Create dependency relationship between nodes (requires: both nodes must exist via create_node; use composite_ids from create_node)"),
+			Name:        "get_attribute_statistics",
+			Description: stringPtr("Summarise how each attribute of a domain is used: nodes holding it, distinct values and most used values; use to learn a domain's tagging schema before filtering"),
 			InputSchema: InputSchema{
 				Type: "object",
 				Properties: map[string]map[string]interface{}{
-					"dependent_node_id":  {"type": "string", "description": "Composite ID of the dependent node (format: tool:domain:id)"},
-					"dependency_node_id": {"type": "string", "description": "Composite ID of the dependency node (format: tool:domain:id)"},
-					"dependency_type": {
-						"type":        "string",
-						"description": "Type of dependency",
-						"enum":        []string{"hard", "soft", "reference"},
-					},
-					"cascade_delete": {"type": "boolean", "default": false, "description": "Whether to cascade delete"},
-					"cascade_update": {"type": "boolean", "default": false, "description": "Whether to cascade update"},
-					"description":    {"type": "string", "description": "Optional description of the dependency"},
+					"domain_name": {"type": "string", "description": "Domain name"},
+					"top_values":  {"type": "integer", "default": 5, "minimum": 0, "maximum": 100, "description": "Most used values to return per attribute; 0 for none"},
 				},
-				Required: []string{"dependent_node_id", "dependency_node_id", "dependency_type"},
+				Required: []string{"domain_name"},
 			},
-		},
-
-		{
-			Name:        "list_node_dependencies",
-			Description: stringPtr("List what a node depends on (requires: node must exist via create_node; dependencies created via create_dependency)"),
-			InputSchema: InputSchema{
+			OutputSchema: &OutputSchema{
 				Type: "object",
 				Properties: map[string]map[string]interface{}{
-					"composite_id": {"type": "string", "description": "Composite ID of the node (format: tool:domain:id)"},
+					"domain_name": {"type": "string"},
+					"node_count":  {"type": "integer", "description": "Nodes in the domain"},
+					"attributes":  {"type": "array", "description": "Attributes with name, type, node_count, distinct_values and top_values, most used first"},
 				},
-				Required: []string{"composite_id"},
+				Required: []string{"domain_name", "node_count", "attributes"},
 			},
 			Annotations: &ToolAnnotations{
 				ReadOnlyHint:  boolPtr(true),
@@ -414,64 +1566,71 @@ func GetToolDefinitions() []ToolDefinition {
 		},
 
 		{
-			Name:        "list_node_dependents",
-			Description: stringPtr("List what depends on a node (requires: node must exist via create_node; dependencies created via create_dependency)"),
+			Name:        "rename_attribute_value",
+			Description: stringPtr("Rename one value of an attribute on every node of a domain, e.g. fixing a misspelt tag; nodes already holding the new value keep one copy (requires: attribute must exist via create_domain_attribute)"),
 			InputSchema: InputSchema{
 				Type: "object",
 				Properties: map[string]map[string]interface{}{
-					"composite_id": {"type": "string", "description": "Composite ID of the node (format: tool:domain:id)"},
+					"domain_name":    {"type": "string", "description": "Domain name"},
+					"attribute_name": {"type": "string", "description": "Attribute name"},
+					"old_value":      {"type": "string", "description": "Value to replace"},
+					"new_value":      {"type": "string", "description": "Replacement value; validated like set_node_attributes values"},
+					"dry_run":        {"type": "boolean", "default": false, "description": "Only report how many values would change, without writing"},
 				},
-				Required: []string{"composite_id"},
-			},
-			Annotations: &ToolAnnotations{
-				ReadOnlyHint:  boolPtr(true),
-				OpenWorldHint: boolPtr(false),
+				Required: []string{"domain_name", "attribute_name", "old_value", "new_value"},
 			},
-		},
-
-		{
-			Name:        "delete_dependency",
-			Description: stringPtr("Remove dependency relationship (requires: dependency must exist via create_dependency; use dependency_id from list_node_dependencies)"),
-			InputSchema: InputSchema{
+			OutputSchema: &OutputSchema{
 				Type: "object",
 				Properties: map[string]map[string]interface{}{
-					"dependency_id": {"type": "integer", "description": "ID of the dependency relationship to delete"},
+					"domain_name":    {"type": "string"},
+					"attribute_name": {"type": "string"},
+					"old_value":      {"type": "string", "description": "Old value as stored, after transforms"},
+					"new_value":      {"type": "string", "description": "New value as stored, after transforms"},
+					"renamed_count":  {"type": "integer", "description": "Values changed in place"},
+					"merged_count":   {"type": "integer", "description": "Old values dropped from nodes that already held the new value"},
+					"changed_count":  {"type": "integer", "description": "renamed_count + merged_count"},
+					"dry_run":        {"type": "boolean"},
 				},
-				Required: []string{"dependency_id"},
+				Required: []string{"domain_name", "attribute_name", "old_value", "new_value", "renamed_count", "merged_count", "changed_count", "dry_run"},
 			},
 			Annotations: &ToolAnnotations{
 				ReadOnlyHint:    boolPtr(false),
-				DestructiveHint: boolPtr(true),
+				DestructiveHint: boolPtr(false),
 				IdempotentHint:  boolPtr(true),
 				OpenWorldHint:   boolPtr(false),
 			},
 		},
 
-		// Filtering and Queries
 		{
-			Name:        "filter_nodes_by_attributes",
-			Description: stringPtr("Filter nodes by attribute values (requires: domain must exist via create_domain; attributes defined via create_domain_attribute)"),
+			Name:        "get_facets",
+			Description: stringPtr("Count nodes per distinct value of each given attribute in a domain, optionally within a filtered subset, for faceted navigation (requires: domain must exist via create_domain)"),
 			InputSchema: InputSchema{
 				Type: "object",
 				Properties: map[string]map[string]interface{}{
-					"domain_name": {"type": "string", "description": "Domain name to filter nodes from"},
+					"domain_name": {"type": "string", "description": "Domain name"},
+					"attributes": {
+						"type":        "array",
+						"description": "Attribute names to facet on",
+						"items":       map[string]interface{}{"type": "string"},
+					},
 					"filters": {
 						"type":        "array",
-						"description": "Array of attribute filters",
+						"description": "Base filter in the filter_nodes_by_attributes format; facets count only matching nodes",
 						"items": map[string]interface{}{
 							"type": "object",
 							"properties": map[string]interface{}{
 								"name":     map[string]interface{}{"type": "string", "description": "Attribute name"},
-								"value":    map[string]interface{}{"type": "string", "description": "Attribute value"},
-								"operator": map[string]interface{}{"type": "string", "description": "Comparison operator", "enum": []string{"equals", "contains", "starts_with", "ends_with"}, "default": "equals"},
+								"value":    map[string]interface{}{"type": "string", "description": "Attribute value; the lower bound for between"},
+								"value_to": map[string]interface{}{"type": "string", "description": "Upper bound for between"},
+								"operator": map[string]interface{}{"type": "string", "description": "Comparison operator; gt, gte, lt, lte and between compare numbers and need a number attribute", "enum": []string{"equals", "contains", "starts_with", "ends_with", "gt", "gte", "lt", "lte", "between"}, "default": "equals"},
+								"logic":    map[string]interface{}{"type": "string", "description": "Makes the filter a group: how its nested filters combine", "enum": []string{"and", "or"}, "default": "and"},
+								"filters":  map[string]interface{}{"type": "array", "description": "Nested filters of a group, in this same format", "items": map[string]interface{}{"type": "object"}},
 							},
-							"required": []string{"name", "value"},
 						},
 					},
-					"page": {"type": "integer", "default": 1},
-					"size": {"type": "integer", "default": 20},
+					"limit": {"type": "integer", "default": 20, "description": "Most common values returned per attribute (max 100)"},
 				},
-				Required: []string{"domain_name", "filters"},
+				Required: []string{"domain_name", "attributes"},
 			},
 			Annotations: &ToolAnnotations{
 				ReadOnlyHint:  boolPtr(true),
@@ -486,6 +1645,12 @@ func GetToolDefinitions() []ToolDefinition {
 				Type: "object",
 				Properties: map[string]map[string]interface{}{
 					"composite_id": {"type": "string", "description": "Composite ID (format: tool:domain:id)"},
+					"format": {
+						"type":        "string",
+						"enum":        []string{"list", "map"},
+						"description": "list returns an array of {name, type, value, order_index}; map returns {name: value}, where tag and ordered_tag attributes map to an array of values (ordered_tag sorted by order_index) and other types to their single value",
+						"default":     "list",
+					},
 				},
 				Required: []string{"composite_id"},
 			},
@@ -633,6 +1798,180 @@ func GetToolDefinitions() []ToolDefinition {
 				OpenWorldHint: boolPtr(false),
 			},
 		},
+
+		{
+			Name:        "get_node_events",
+			Description: stringPtr("List a node's change events (created, updated, deleted), newest first; works for deleted nodes too"),
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"composite_id": {"type": "string", "description": "Composite ID of the node (tool-name:domain:id)"},
+					"page":         {"type": "integer", "default": 1},
+					"size":         {"type": "integer", "default": 20, "maximum": 100},
+				},
+				Required: []string{"composite_id"},
+			},
+			OutputSchema: &OutputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"composite_id": {"type": "string"},
+					"events":       {"type": "array", "description": "Events with id, node_id, event_type, occurred_at, processed_at (null while pending) and data"},
+					"total_count":  {"type": "integer"},
+					"pagination":   paginationSchema,
+				},
+				Required: []string{"composite_id", "events", "total_count", "pagination"},
+			},
+			Annotations: &ToolAnnotations{
+				ReadOnlyHint:  boolPtr(true),
+				OpenWorldHint: boolPtr(false),
+			},
+		},
+
+		{
+			Name:        "get_pending_events",
+			Description: stringPtr("List node events not yet marked processed, oldest first (for sync workers; mark each done via process_event)"),
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"limit": {"type": "integer", "default": 20, "maximum": 100, "description": "Maximum number of events to return"},
+				},
+			},
+			OutputSchema: &OutputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"events": {"type": "array", "description": "Events with id, node_id, event_type, occurred_at, processed_at and data"},
+					"count":  {"type": "integer"},
+				},
+				Required: []string{"events", "count"},
+			},
+			Annotations: &ToolAnnotations{
+				ReadOnlyHint:  boolPtr(true),
+				OpenWorldHint: boolPtr(false),
+			},
+		},
+
+		{
+			Name:        "process_event",
+			Description: stringPtr("Mark a node event processed so get_pending_events stops returning it (requires: event ID from get_pending_events)"),
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"event_id": {"type": "integer", "description": "ID of the event to mark processed"},
+				},
+				Required: []string{"event_id"},
+			},
+			OutputSchema: &OutputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"id":           {"type": "integer"},
+					"node_id":      {"type": "integer"},
+					"event_type":   {"type": "string"},
+					"occurred_at":  {"type": "string", "format": "date-time"},
+					"processed_at": {"type": "string", "format": "date-time", "description": "Kept from the first call when the event was already processed"},
+					"data":         {"type": "object"},
+				},
+				Required: []string{"id", "node_id", "event_type", "occurred_at", "processed_at"},
+			},
+			Annotations: &ToolAnnotations{
+				IdempotentHint: boolPtr(true),
+				OpenWorldHint:  boolPtr(false),
+			},
+		},
+
+		{
+			Name:        "get_event_stats",
+			Description: stringPtr("Count node events by processing status and by event type"),
+			InputSchema: InputSchema{
+				Type:       "object",
+				Properties: map[string]map[string]interface{}{},
+			},
+			OutputSchema: &OutputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"total_count":     {"type": "integer"},
+					"pending_count":   {"type": "integer"},
+					"processed_count": {"type": "integer"},
+					"by_type":         {"type": "object", "description": "Event count keyed by event type"},
+				},
+				Required: []string{"total_count", "pending_count", "processed_count", "by_type"},
+			},
+			Annotations: &ToolAnnotations{
+				ReadOnlyHint:  boolPtr(true),
+				OpenWorldHint: boolPtr(false),
+			},
+		},
+
+		{
+			Name:        "create_subscription",
+			Description: stringPtr("Subscribe an external service to a node's events; in SSE/HTTP modes each new event is POSTed as JSON to the endpoint, with retries (requires: composite_id from list_nodes)"),
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"composite_id":        {"type": "string", "description": "Node to subscribe to (format: tool-name:domain:id)"},
+					"subscriber_service":  {"type": "string", "description": "Name of the subscribing service"},
+					"subscriber_endpoint": {"type": "string", "format": "uri", "description": "http or https URL that receives the events; must resolve to a public address"},
+					"event_types":         {"type": "array", "items": map[string]interface{}{"type": "string", "enum": []string{"created", "updated", "deleted"}}, "default": []string{"updated"}, "description": "Event types to deliver; only events after the subscription is created are delivered"},
+				},
+				Required: []string{"composite_id", "subscriber_service", "subscriber_endpoint"},
+			},
+			OutputSchema: &OutputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"id":                  {"type": "integer"},
+					"composite_id":        {"type": "string"},
+					"subscriber_service":  {"type": "string"},
+					"subscriber_endpoint": {"type": "string"},
+					"event_types":         {"type": "array", "items": map[string]interface{}{"type": "string"}},
+					"is_active":           {"type": "boolean"},
+					"created_at":          {"type": "string", "format": "date-time"},
+				},
+				Required: []string{"id", "composite_id", "subscriber_service", "subscriber_endpoint", "event_types", "is_active", "created_at"},
+			},
+			Annotations: &ToolAnnotations{
+				OpenWorldHint: boolPtr(true),
+			},
+		},
+
+		{
+			Name:        "list_subscriptions",
+			Description: stringPtr("List the event subscriptions of a node (requires: composite_id from list_nodes)"),
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"composite_id": {"type": "string", "description": "Node whose subscriptions to list (format: tool-name:domain:id)"},
+				},
+				Required: []string{"composite_id"},
+			},
+			OutputSchema: &OutputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"composite_id":  {"type": "string"},
+					"subscriptions": {"type": "array", "description": "Subscriptions with id, subscriber_service, subscriber_endpoint, event_types, is_active and created_at"},
+					"count":         {"type": "integer"},
+				},
+				Required: []string{"composite_id", "subscriptions", "count"},
+			},
+			Annotations: &ToolAnnotations{
+				ReadOnlyHint:  boolPtr(true),
+				OpenWorldHint: boolPtr(false),
+			},
+		},
+
+		{
+			Name:        "delete_subscription",
+			Description: stringPtr("Delete an event subscription so no further events are delivered to it (requires: subscription ID from list_subscriptions)"),
+			InputSchema: InputSchema{
+				Type: "object",
+				Properties: map[string]map[string]interface{}{
+					"subscription_id": {"type": "integer", "description": "ID of the subscription to delete"},
+				},
+				Required: []string{"subscription_id"},
+			},
+			Annotations: &ToolAnnotations{
+				DestructiveHint: boolPtr(true),
+				OpenWorldHint:   boolPtr(false),
+			},
+		},
 	}
 }
 
@@ -683,4 +2022,69 @@ func (t ToolDefinition) ToMap() map[string]interface{} {
 	}
 	
 	return result
-}
\ No newline at end of file
+}
+
+// filterNodesInputSchema is the input of filter_nodes_by_attributes, which explain_filter shares
+func filterNodesInputSchema() InputSchema {
+	return InputSchema{
+		Type: "object",
+		Properties: map[string]map[string]interface{}{
+			"domain_name": {"type": "string", "description": "Domain name to filter nodes from"},
+			"filters": {
+				"type":        "array",
+				"description": "Array of attribute filters, ANDed; each is a condition (name, value, operator) or a group (logic, filters). At most 20 filters in all unless the server sets MAX_FILTERS",
+				"items": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"name":     map[string]interface{}{"type": "string", "description": "Attribute name"},
+						"value":    map[string]interface{}{"type": "string", "description": "Attribute value; the lower bound for between"},
+						"value_to": map[string]interface{}{"type": "string", "description": "Upper bound for between"},
+						"operator": map[string]interface{}{"type": "string", "description": "Comparison operator; gt, gte, lt, lte and between compare numbers and need a number attribute", "enum": []string{"equals", "contains", "starts_with", "ends_with", "gt", "gte", "lt", "lte", "between"}, "default": "equals"},
+						"logic":    map[string]interface{}{"type": "string", "description": "Makes the filter a group: how its nested filters combine", "enum": []string{"and", "or"}, "default": "and"},
+						"filters":  map[string]interface{}{"type": "array", "description": "Nested filters of a group, in this same format", "items": map[string]interface{}{"type": "object"}},
+					},
+				},
+			},
+			"logic": {"type": "string", "description": "How the top-level filters combine", "enum": []string{"and", "or"}, "default": "and"},
+			"page":  {"type": "integer", "default": 1},
+			"size":  {"type": "integer", "default": 20},
+		},
+		Required: []string{"domain_name", "filters"},
+	}
+}
+
+// dependencyListOutputSchema is the output of list_node_dependencies and list_node_dependents
+func dependencyListOutputSchema() *OutputSchema {
+	return &OutputSchema{
+		Type: "object",
+		Properties: map[string]map[string]interface{}{
+			"composite_id": {"type": "string"},
+			"dependencies": {
+				"type": "array",
+				"items": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"dependency_id":      map[string]interface{}{"type": "integer"},
+						"dependent_node_id":  map[string]interface{}{"type": "string"},
+						"dependency_node_id": map[string]interface{}{"type": "string"},
+						"dependency_type":    map[string]interface{}{"type": "string"},
+						"cascade_delete":     map[string]interface{}{"type": "boolean"},
+						"cascade_update":     map[string]interface{}{"type": "boolean"},
+						"description":        map[string]interface{}{"type": "string"},
+						"created_at":         map[string]interface{}{"type": "string"},
+						"related_node": map[string]interface{}{
+							"type":        "object",
+							"description": "The node at the other end of the edge",
+							"properties": map[string]interface{}{
+								"composite_id": map[string]interface{}{"type": "string"},
+								"title":        map[string]interface{}{"type": "string"},
+								"url":          map[string]interface{}{"type": "string"},
+							},
+						},
+					},
+				},
+			},
+			"total_count": {"type": "integer"},
+		},
+	}
+}
@@ -1,33 +1,143 @@
 package mcp
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/url"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"url-db/internal/application/dto/request"
+	"url-db/internal/application/dto/response"
+	dependencyUseCase "url-db/internal/application/usecase/dependency"
 	nodeUseCase "url-db/internal/application/usecase/node"
 	"url-db/internal/constants"
+	"url-db/internal/domain/attribute"
 	"url-db/internal/domain/entity"
 	"url-db/internal/domain/repository"
 	"url-db/internal/domain/service"
+	"url-db/internal/domain/valueobject"
+	"url-db/internal/infrastructure/fetcher"
+	"url-db/internal/infrastructure/render"
 	"url-db/internal/interface/setup"
 )
 
 // MCPToolHandler handles all MCP tool implementations
 type MCPToolHandler struct {
-	dependencies *setup.CleanDependencies
+	dependencies    *setup.CleanDependencies
+	toolName        string
+	titleFetcher    *fetcher.TitleFetcher
+	// dependencyTypes lists the dependency_type values create_dependency accepts
+	dependencyTypes []string
+	// softWarnings adds non-fatal input warnings to create/update results
+	softWarnings bool
+	// compactJSON strips indentation from JSON embedded in text content
+	compactJSON bool
+	// lowercaseDomainNames lowercases domain_name arguments before lookups
+	lowercaseDomainNames bool
+	// domainNameUnicode is how non-ASCII characters in domain names are handled
+	domainNameUnicode string
+	// maxFilters caps the filters of filter_nodes_by_attributes (0 = unlimited)
+	maxFilters int
+	// maxPageSize caps the size and limit arguments of paginated tools
+	maxPageSize int
+	// scanDefaultOrder orders scan_all_content when the call gives no order
+	scanDefaultOrder repository.SortOrder
+	// startedAt is when the handler was created, for the uptime in health reports
+	startedAt time.Time
 }
 
 // NewMCPToolHandler creates a new tool handler
 func NewMCPToolHandler(factory *setup.ApplicationFactory) *MCPToolHandler {
 	return &MCPToolHandler{
-		dependencies: factory.CreateCleanArchitectureDependencies(),
+		dependencies:      factory.CreateCleanArchitectureDependencies(),
+		toolName:          factory.ToolName(),
+		titleFetcher:      fetcher.NewTitleFetcher(fetcher.TitleFetcherConfig{}),
+		dependencyTypes:   strings.Split(constants.DefaultDependencyTypes, ","),
+		softWarnings:      true,
+		compactJSON:       true,
+		domainNameUnicode: constants.DefaultDomainNameUnicode,
+		maxFilters:        constants.DefaultMaxFilters,
+		maxPageSize:       constants.MaxPageSize,
+		scanDefaultOrder:  repository.SortOrder(constants.DefaultScanOrder),
+		startedAt:         time.Now(),
 	}
 }
 
+// domainNameArguments are the tool arguments that name an existing domain
+var domainNameArguments = []string{"domain_name", "source_domain_name", "target_domain_name", "domain_a", "domain_b"}
+
+// normalizeDomainArguments applies the domain name rules to every argument that
+// names a domain, so lookups match the names create_domain stores
+func (h *MCPToolHandler) normalizeDomainArguments(args map[string]interface{}) {
+	for _, key := range domainNameArguments {
+		if name, ok := args[key].(string); ok {
+			args[key] = h.canonicalDomainName(name)
+		}
+	}
+}
+
+// canonicalDomainName trims and folds a domain name the way create_domain does,
+// and lowercases it when the lowercase rule is on. It does not validate the name.
+func (h *MCPToolHandler) canonicalDomainName(name string) string {
+	name = entity.FoldDomainName(name, h.domainNameUnicode)
+	if h.lowercaseDomainNames {
+		name = entity.NormalizeDomainName(name)
+	}
+	return name
+}
+
+// recordNodeEvent adds an entry to the node event log. The node change has already
+// been saved, so a recording failure is not reported to the caller.
+func (h *MCPToolHandler) recordNodeEvent(ctx context.Context, nodeID int, eventType string, data map[string]interface{}) {
+	eventData, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+	event, err := entity.NewNodeEvent(nodeID, eventType, string(eventData))
+	if err != nil {
+		return
+	}
+	_ = h.dependencies.EventRecorder.Record(ctx, event)
+}
+
+// formatJSONText formats JSON embedded in text content: compact when compactJSON is
+// set, indented otherwise. Text that is not valid JSON is returned unchanged.
+func (h *MCPToolHandler) formatJSONText(data string) string {
+	var buf bytes.Buffer
+	var err error
+	if h.compactJSON {
+		err = json.Compact(&buf, []byte(data))
+	} else {
+		err = json.Indent(&buf, []byte(data), "", "  ")
+	}
+	if err != nil {
+		return data
+	}
+	return buf.String()
+}
+
+// addNodeWarnings attaches soft validation warnings for a node to a tool result
+func (h *MCPToolHandler) addNodeWarnings(content []map[string]interface{}, structuredContent map[string]interface{}, url, title string) []map[string]interface{} {
+	if !h.softWarnings {
+		return content
+	}
+
+	warnings := service.NodeWarnings(url, title)
+	if len(warnings) == 0 {
+		return content
+	}
+
+	structuredContent["warnings"] = warnings
+	return append(content, createTextContent("Warnings:\n- "+strings.Join(warnings, "\n- ")))
+}
+
 // Helper functions for MCP response formatting
 
 // createMCPResponse creates a standardized MCP tool response with optional structured content
@@ -62,12 +172,21 @@ func (h *MCPToolHandler) handleListDomains(ctx context.Context, args map[string]
 		page = int(p)
 	}
 
-	size := 20
+	size := constants.DefaultPageSize
 	if s, ok := args["size"].(float64); ok {
 		size = int(s)
 	}
 
-	result, err := h.dependencies.ListDomainsUC.Execute(ctx, page, size)
+	// A cursor (empty to start) switches to keyset pagination, oldest domain first
+	cursor, useCursor := args["cursor"].(string)
+
+	var result *response.DomainListResponse
+	var err error
+	if useCursor {
+		result, err = h.dependencies.ListDomainsUC.ExecuteAfter(ctx, cursor, size)
+	} else {
+		result, err = h.dependencies.ListDomainsUC.Execute(ctx, page, size)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to list domains: %w", err)
 	}
@@ -91,13 +210,25 @@ func (h *MCPToolHandler) handleListDomains(ctx context.Context, args map[string]
 	if len(content) == 0 {
 		content = append(content, createTextContent("No domains found"))
 	}
+	if note := pageSizeCappedText(size, result.Size); note != "" {
+		content = append(content, createTextContent(note))
+	}
 
 	// Create structured content for machine-readable access
 	structuredContent := map[string]interface{}{
-		"domains":     structuredDomains,
-		"total_count": result.TotalCount,
-		"page":        result.Page,
-		"total_pages": result.TotalPages,
+		"domains": structuredDomains,
+	}
+	if useCursor {
+		if result.NextCursor != "" {
+			structuredContent["next_cursor"] = result.NextCursor
+			content = append(content, createTextContent("More domains available; pass next_cursor as cursor"))
+		}
+		structuredContent["pagination"] = newCursorPagination(cursor, result.Size, result.NextCursor)
+	} else {
+		structuredContent["total_count"] = result.TotalCount
+		structuredContent["page"] = result.Page
+		structuredContent["total_pages"] = result.TotalPages
+		structuredContent["pagination"] = newPagination(result.Page, result.Size, result.TotalCount)
 	}
 
 	return createMCPResponse(content, structuredContent), nil
@@ -116,352 +247,3639 @@ func (h *MCPToolHandler) handleCreateDomain(ctx context.Context, args map[string
 		return nil, fmt.Errorf("missing or invalid 'description' parameter")
 	}
 
+	ifNotExists, _ := args["if_not_exists"].(bool)
+
 	// Create request DTO
 	createReq := &request.CreateDomainRequest{
 		Name:        name,
 		Description: description,
 	}
 
-	// Execute use case
-	result, err := h.dependencies.CreateDomainUC.Execute(ctx, createReq)
+	// Execute use case; with if_not_exists an existing domain is returned instead of an error
+	var result *response.DomainResponse
+	var err error
+	created := true
+	if ifNotExists {
+		result, created, err = h.dependencies.CreateDomainUC.Ensure(ctx, createReq)
+	} else {
+		result, err = h.dependencies.CreateDomainUC.Execute(ctx, createReq)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to create domain: %w", err)
 	}
 
 	// Convert to MCP response format
+	status := "Successfully created domain"
+	if !created {
+		status = "Domain already exists"
+	}
 	content := []map[string]interface{}{
-		createTextContent(fmt.Sprintf("Successfully created domain: %s\nDescription: %s\nCreated: %s",
-			result.Name, result.Description, result.CreatedAt.Format("2006-01-02 15:04:05"))),
+		createTextContent(fmt.Sprintf("%s: %s\nDescription: %s\nCreated: %s",
+			status, result.Name, result.Description, result.CreatedAt.Format("2006-01-02 15:04:05"))),
 	}
 
 	structuredContent := map[string]interface{}{
 		"name":        result.Name,
 		"description": result.Description,
 		"created_at":  result.CreatedAt.Format(time.RFC3339),
+		"created":     created,
 	}
 
 	return createMCPResponse(content, structuredContent), nil
 }
 
-// Node Management Tools
-
-// handleListNodes implements the list_nodes tool
-func (h *MCPToolHandler) handleListNodes(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+// handleUpdateDomain implements the update_domain tool
+func (h *MCPToolHandler) handleUpdateDomain(ctx context.Context, args map[string]interface{}) (interface{}, error) {
 	// Parse arguments
 	domainName, ok := args["domain_name"].(string)
 	if !ok || domainName == "" {
 		return nil, fmt.Errorf("missing or invalid 'domain_name' parameter")
 	}
 
-	// Optional parameters with defaults
-	page := 1
-	if p, ok := args["page"].(float64); ok {
-		page = int(p)
-	}
-
-	size := 20
-	if s, ok := args["size"].(float64); ok {
-		size = int(s)
+	// Domain names are identities (composite IDs embed them), so renaming is refused
+	// outright rather than silently ignored
+	for _, key := range []string{"name", "new_name"} {
+		if newName, ok := args[key].(string); ok && newName != "" && newName != domainName {
+			return nil, fmt.Errorf("domains cannot be renamed; create a new domain with create_domain instead")
+		}
 	}
 
-	search := ""
-	if s, ok := args["search"].(string); ok {
-		search = s
+	description, ok := args["description"].(string)
+	if !ok {
+		return nil, fmt.Errorf("missing or invalid 'description' parameter")
 	}
-	_ = search // TODO: Implement search functionality
 
-	// Execute use case
-	result, err := h.dependencies.ListNodesUC.Execute(ctx, domainName, page, size)
+	result, err := h.dependencies.UpdateDomainUC.Execute(ctx, domainName, &request.UpdateDomainRequest{
+		Description: description,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to list nodes: %w", err)
-	}
-
-	// Convert to MCP response format
-	content := []map[string]interface{}{}
-	structuredNodes := []map[string]interface{}{}
-	
-	for _, node := range result.Nodes {
-		content = append(content, createTextContent(
-			fmt.Sprintf("Node ID: %d\nURL: %s\nTitle: %s\nDescription: %s\nCreated: %s",
-				node.ID, node.URL, node.Title, node.Description, node.CreatedAt.Format("2006-01-02 15:04:05"))))
-		
-		structuredNodes = append(structuredNodes, map[string]interface{}{
-			"id":          node.ID,
-			"url":         node.URL,
-			"title":       node.Title,
-			"description": node.Description,
-			"created_at":  node.CreatedAt.Format(time.RFC3339),
-		})
+		return nil, fmt.Errorf("failed to update domain: %w", err)
 	}
 
-	if len(content) == 0 {
-		content = append(content, createTextContent(fmt.Sprintf("No nodes found in domain '%s'", domainName)))
+	content := []map[string]interface{}{
+		createTextContent(fmt.Sprintf("Successfully updated domain: %s\nDescription: %s\nUpdated: %s",
+			result.Name, result.Description, result.UpdatedAt.Format("2006-01-02 15:04:05"))),
 	}
 
-	// Create structured content for machine-readable access
 	structuredContent := map[string]interface{}{
-		"domain_name": domainName,
-		"nodes":       structuredNodes,
-		"total_count": result.TotalCount,
-		"page":        result.Page,
-		"total_pages": result.TotalPages,
+		"name":        result.Name,
+		"description": result.Description,
+		"created_at":  result.CreatedAt.Format(time.RFC3339),
+		"updated_at":  result.UpdatedAt.Format(time.RFC3339),
 	}
 
 	return createMCPResponse(content, structuredContent), nil
 }
 
-// handleCreateNode implements the create_node tool
-func (h *MCPToolHandler) handleCreateNode(ctx context.Context, args map[string]interface{}) (interface{}, error) {
-	// Parse required arguments
+// handleDeleteDomain implements the delete_domain tool
+func (h *MCPToolHandler) handleDeleteDomain(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	// Parse domain_name argument
 	domainName, ok := args["domain_name"].(string)
 	if !ok || domainName == "" {
 		return nil, fmt.Errorf("missing or invalid 'domain_name' parameter")
 	}
 
-	url, ok := args["url"].(string)
-	if !ok || url == "" {
-		return nil, fmt.Errorf("missing or invalid 'url' parameter")
-	}
+	dryRun, _ := args["dry_run"].(bool)
 
-	// Optional parameters
-	title := ""
-	if t, ok := args["title"].(string); ok {
-		title = t
+	// Count what goes with the domain before it is deleted
+	domain, err := h.dependencies.DomainRepo.GetByName(ctx, domainName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get domain: %w", err)
 	}
-
-	description := ""
-	if d, ok := args["description"].(string); ok {
-		description = d
+	if domain == nil {
+		return nil, fmt.Errorf("domain '%s' not found", domainName)
 	}
-
-	// Create request DTO
-	createReq := &request.CreateNodeRequest{
-		DomainName:  domainName,
-		URL:         url,
-		Title:       title,
-		Description: description,
+	attributes, err := h.dependencies.AttributeRepo.ListByDomainID(ctx, domain.ID())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list domain attributes: %w", err)
+	}
+	_, templateCount, err := h.dependencies.TemplateRepo.List(ctx, domainName, 1, 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count templates: %w", err)
 	}
 
-	// Execute use case
-	result, err := h.dependencies.CreateNodeUC.Execute(ctx, createReq)
+	result, err := h.dependencies.DeleteDomainUC.Execute(ctx, domainName, dryRun)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create node: %w", err)
+		return nil, fmt.Errorf("failed to delete domain: %w", err)
 	}
 
-	// Convert to MCP response format with composite ID for easy reference
-	compositeID := fmt.Sprintf("%s:%s:%d", constants.DefaultServerName, domainName, result.ID)
+	text := fmt.Sprintf("Successfully deleted domain: %s", result.Name)
+	if dryRun {
+		text = fmt.Sprintf("Dry run: deleting domain %s would remove %d attribute definition(s) and %d template(s); nothing was deleted",
+			result.Name, len(attributes), templateCount)
+	}
 
 	content := []map[string]interface{}{
-		createTextContent(fmt.Sprintf("Successfully created node in domain '%s'\nComposite ID: %s\nURL: %s\nTitle: %s\nDescription: %s\nCreated: %s",
-			domainName, compositeID, result.URL, result.Title, result.Description, result.CreatedAt.Format("2006-01-02 15:04:05"))),
+		createTextContent(text),
 	}
 
 	structuredContent := map[string]interface{}{
-		"composite_id": compositeID,
-		"domain_name":  domainName,
-		"id":           result.ID,
-		"url":          result.URL,
-		"title":        result.Title,
-		"description":  result.Description,
-		"created_at":   result.CreatedAt.Format(time.RFC3339),
+		"name":            result.Name,
+		"description":     result.Description,
+		"deleted":         !dryRun,
+		"dry_run":         dryRun,
+		"attribute_count": len(attributes),
+		"template_count":  templateCount,
 	}
 
 	return createMCPResponse(content, structuredContent), nil
 }
 
-// Additional Node Management Tools
-
-// handleGetNode implements the get_node tool
-func (h *MCPToolHandler) handleGetNode(ctx context.Context, args map[string]interface{}) (interface{}, error) {
-	// Parse composite_id argument
-	compositeID, ok := args["composite_id"].(string)
-	if !ok || compositeID == "" {
-		return nil, fmt.Errorf("missing or invalid 'composite_id' parameter")
-	}
-
-	// Parse composite ID to extract node ID
-	// composite_id format: "tool-name:domain:id"
-	parts := strings.Split(compositeID, ":")
-	if len(parts) != 3 {
-		return nil, fmt.Errorf("invalid composite_id format, expected 'tool-name:domain:id'")
+// handleGetDomain implements the get_domain tool
+func (h *MCPToolHandler) handleGetDomain(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	// Parse domain_name argument
+	domainName, ok := args["domain_name"].(string)
+	if !ok || domainName == "" {
+		return nil, fmt.Errorf("missing or invalid 'domain_name' parameter")
 	}
 
-	nodeIDStr := parts[2]
-	nodeID, err := strconv.Atoi(nodeIDStr)
+	domain, err := h.dependencies.DomainRepo.GetByName(ctx, domainName)
 	if err != nil {
-		return nil, fmt.Errorf("invalid node ID in composite_id: %v", err)
+		return nil, fmt.Errorf("failed to get domain: %w", err)
+	}
+	if domain == nil {
+		return nil, fmt.Errorf("domain '%s' not found", domainName)
 	}
 
-	// Get node from repository
-	node, err := h.dependencies.NodeRepo.GetByID(ctx, nodeID)
+	nodeCount, err := h.dependencies.NodeRepo.CountByDomain(ctx, domain.ID())
 	if err != nil {
-		return nil, fmt.Errorf("failed to get node: %w", err)
+		return nil, fmt.Errorf("failed to count nodes: %w", err)
 	}
 
-	// Convert to MCP response format
 	content := []map[string]interface{}{
-		createTextContent(fmt.Sprintf("Node ID: %d\nComposite ID: %s\nURL: %s\nTitle: %s\nDescription: %s\nCreated: %s\nUpdated: %s",
-			node.ID(), compositeID, node.URL(), node.Title(), node.Description(),
-			node.CreatedAt().Format("2006-01-02 15:04:05"),
-			node.UpdatedAt().Format("2006-01-02 15:04:05"))),
+		createTextContent(fmt.Sprintf("Domain: %s\nDescription: %s\nNodes: %d\nCreated: %s\nUpdated: %s",
+			domain.Name(), domain.Description(), nodeCount,
+			domain.CreatedAt().Format("2006-01-02 15:04:05"),
+			domain.UpdatedAt().Format("2006-01-02 15:04:05"))),
 	}
 
 	structuredContent := map[string]interface{}{
-		"composite_id": compositeID,
-		"id":           node.ID(),
-		"url":          node.URL(),
-		"title":        node.Title(),
-		"description":  node.Description(),
-		"created_at":   node.CreatedAt().Format(time.RFC3339),
-		"updated_at":   node.UpdatedAt().Format(time.RFC3339),
+		"name":        domain.Name(),
+		"description": domain.Description(),
+		"node_count":  nodeCount,
+		"created_at":  domain.CreatedAt().Format(time.RFC3339),
+		"updated_at":  domain.UpdatedAt().Format(time.RFC3339),
 	}
 
 	return createMCPResponse(content, structuredContent), nil
 }
 
-// handleUpdateNode implements the update_node tool
-func (h *MCPToolHandler) handleUpdateNode(ctx context.Context, args map[string]interface{}) (interface{}, error) {
-	// Parse composite_id argument
-	compositeID, ok := args["composite_id"].(string)
-	if !ok || compositeID == "" {
-		return nil, fmt.Errorf("missing or invalid 'composite_id' parameter")
-	}
-
-	// Parse composite ID to extract node ID
-	parts := strings.Split(compositeID, ":")
-	if len(parts) != 3 {
-		return nil, fmt.Errorf("invalid composite_id format, expected 'tool-name:domain:id'")
+// handleGetDomainStats implements the get_domain_stats tool
+func (h *MCPToolHandler) handleGetDomainStats(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	// Parse domain_name argument
+	domainName, ok := args["domain_name"].(string)
+	if !ok || domainName == "" {
+		return nil, fmt.Errorf("missing or invalid 'domain_name' parameter")
 	}
 
-	nodeIDStr := parts[2]
-	nodeID, err := strconv.Atoi(nodeIDStr)
+	domain, err := h.dependencies.DomainRepo.GetByName(ctx, domainName)
 	if err != nil {
-		return nil, fmt.Errorf("invalid node ID in composite_id: %v", err)
+		return nil, fmt.Errorf("failed to get domain: %w", err)
+	}
+	if domain == nil {
+		return nil, fmt.Errorf("domain '%s' not found", domainName)
 	}
 
-	// Get existing node
-	node, err := h.dependencies.NodeRepo.GetByID(ctx, nodeID)
+	nodeCount, err := h.dependencies.NodeRepo.CountByDomain(ctx, domain.ID())
 	if err != nil {
-		return nil, fmt.Errorf("failed to get node: %w", err)
+		return nil, fmt.Errorf("failed to count nodes: %w", err)
 	}
 
-	// Update fields if provided
-	updated := false
-	if title, ok := args["title"].(string); ok {
-		if err := node.UpdateTitle(title); err != nil {
-			return nil, fmt.Errorf("failed to update title: %w", err)
-		}
-		updated = true
+	maxNodes := h.dependencies.CreateNodeUC.NodeLimit(domainName)
+
+	structuredContent := map[string]interface{}{
+		"domain_name": domainName,
+		"node_count":  nodeCount,
+		"max_nodes":   maxNodes,
+		"remaining":   nil,
 	}
 
-	if description, ok := args["description"].(string); ok {
-		if err := node.UpdateDescription(description); err != nil {
-			return nil, fmt.Errorf("failed to update description: %w", err)
+	usage := fmt.Sprintf("%d (no limit)", nodeCount)
+	if maxNodes > 0 {
+		remaining := maxNodes - nodeCount
+		if remaining < 0 {
+			remaining = 0
 		}
-		updated = true
+		structuredContent["remaining"] = remaining
+		usage = fmt.Sprintf("%d / %d (%d remaining)", nodeCount, maxNodes, remaining)
 	}
 
-	if !updated {
-		return nil, fmt.Errorf("at least one field (title or description) must be provided for update")
+	content := []map[string]interface{}{
+		createTextContent(fmt.Sprintf("Domain: %s\nNodes: %s", domainName, usage)),
 	}
 
-	// Save updated node
-	if err := h.dependencies.NodeRepo.Update(ctx, node); err != nil {
-		return nil, fmt.Errorf("failed to update node: %w", err)
-	}
+	return createMCPResponse(content, structuredContent), nil
+}
 
-	// Convert to MCP response format
-	return map[string]interface{}{
-		"content": []map[string]interface{}{
-			{
-				"type": "text",
-				"text": fmt.Sprintf("Successfully updated node:\nID: %d\nURL: %s\nTitle: %s\nDescription: %s\nUpdated: %s",
-					node.ID(), node.URL(), node.Title(), node.Description(),
-					node.UpdatedAt().Format("2006-01-02 15:04:05")),
-			},
-		},
-	}, nil
+// exportNodeRecord is one node line of an ndjson export
+type exportNodeRecord struct {
+	Record string `json:"record"`
+	response.NodeWithAttributes
 }
 
-// handleDeleteNode implements the delete_node tool
-func (h *MCPToolHandler) handleDeleteNode(ctx context.Context, args map[string]interface{}) (interface{}, error) {
-	// Parse composite_id argument
-	compositeID, ok := args["composite_id"].(string)
-	if !ok || compositeID == "" {
-		return nil, fmt.Errorf("missing or invalid 'composite_id' parameter")
+// handleExportDomain implements the export_domain tool
+func (h *MCPToolHandler) handleExportDomain(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	// Parse arguments
+	domainName, ok := args["domain_name"].(string)
+	if !ok || domainName == "" {
+		return nil, fmt.Errorf("missing or invalid 'domain_name' parameter")
 	}
 
-	// Parse composite ID to extract node ID
-	parts := strings.Split(compositeID, ":")
-	if len(parts) != 3 {
-		return nil, fmt.Errorf("invalid composite_id format, expected 'tool-name:domain:id'")
+	format := "json"
+	if f, ok := args["format"].(string); ok && f != "" {
+		format = f
+	}
+	if format != "json" && format != "ndjson" {
+		return nil, fmt.Errorf("invalid 'format' parameter: %s (expected json or ndjson)", format)
 	}
 
-	nodeIDStr := parts[2]
-	nodeID, err := strconv.Atoi(nodeIDStr)
+	domain, err := h.dependencies.DomainRepo.GetByName(ctx, domainName)
 	if err != nil {
-		return nil, fmt.Errorf("invalid node ID in composite_id: %v", err)
+		return nil, fmt.Errorf("failed to get domain: %w", err)
+	}
+	if domain == nil {
+		return nil, fmt.Errorf("domain '%s' not found", domainName)
 	}
 
-	// Get node before deleting (for confirmation message)
-	node, err := h.dependencies.NodeRepo.GetByID(ctx, nodeID)
+	attributes, err := h.dependencies.AttributeRepo.ListByDomainID(ctx, domain.ID())
 	if err != nil {
-		return nil, fmt.Errorf("failed to get node: %w", err)
+		return nil, fmt.Errorf("failed to list domain attributes: %w", err)
 	}
 
-	// Delete the node
-	if err := h.dependencies.NodeRepo.Delete(ctx, nodeID); err != nil {
-		return nil, fmt.Errorf("failed to delete node: %w", err)
+	domainInfo := map[string]interface{}{
+		"name":        domain.Name(),
+		"description": domain.Description(),
+		"created_at":  domain.CreatedAt(),
+		"updated_at":  domain.UpdatedAt(),
 	}
-
-	// Convert to MCP response format
-	return map[string]interface{}{
-		"content": []map[string]interface{}{
-			{
-				"type": "text",
-				"text": fmt.Sprintf("Successfully deleted node:\nID: %d\nURL: %s\nTitle: %s",
-					node.ID(), node.URL(), node.Title()),
-			},
-		},
-	}, nil
-}
-
-// handleFindNodeByURL implements the find_node_by_url tool
-func (h *MCPToolHandler) handleFindNodeByURL(ctx context.Context, args map[string]interface{}) (interface{}, error) {
-	// Parse arguments
-	domainName, ok := args["domain_name"].(string)
-	if !ok || domainName == "" {
-		return nil, fmt.Errorf("missing or invalid 'domain_name' parameter")
+	definitions := []map[string]interface{}{}
+	for _, attr := range attributes {
+		definitions = append(definitions, map[string]interface{}{
+			"name":        attr.Name(),
+			"type":        attr.Type(),
+			"description": attr.Description(),
+		})
 	}
 
-	url, ok := args["url"].(string)
-	if !ok || url == "" {
-		return nil, fmt.Errorf("missing or invalid 'url' parameter")
-	}
+	contentScanner := service.NewContentScanner(
+		h.dependencies.NodeRepo,
+		h.dependencies.NodeAttributeRepo,
+		h.dependencies.DomainRepo,
+		h.dependencies.AttributeRepo,
+	)
+
+	if format == "ndjson" {
+		// One JSON record per line, tagged by "record": the domain, then its attribute
+		// definitions, then its nodes
+		var buf bytes.Buffer
+		encoder := json.NewEncoder(&buf)
+		domainRecord := map[string]interface{}{"record": "domain"}
+		for key, value := range domainInfo {
+			domainRecord[key] = value
+		}
+		if err := encoder.Encode(domainRecord); err != nil {
+			return nil, fmt.Errorf("failed to encode domain: %w", err)
+		}
+		for _, definition := range definitions {
+			record := map[string]interface{}{"record": "attribute"}
+			for key, value := range definition {
+				record[key] = value
+			}
+			if err := encoder.Encode(record); err != nil {
+				return nil, fmt.Errorf("failed to encode attribute: %w", err)
+			}
+		}
+
+		nodeCount, err := contentScanner.ExportDomain(ctx, domainName, func(item response.NodeWithAttributes) error {
+			return encoder.Encode(exportNodeRecord{Record: "node", NodeWithAttributes: item})
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to export domain: %w", err)
+		}
+
+		content := []map[string]interface{}{createTextContent(buf.String())}
+		structuredContent := map[string]interface{}{
+			"domain_name":     domainName,
+			"format":          format,
+			"attribute_count": len(definitions),
+			"node_count":      nodeCount,
+		}
+		return createMCPResponse(content, structuredContent), nil
+	}
+
+	nodes := []response.NodeWithAttributes{}
+	nodeCount, err := contentScanner.ExportDomain(ctx, domainName, func(item response.NodeWithAttributes) error {
+		nodes = append(nodes, item)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to export domain: %w", err)
+	}
+
+	structuredContent := map[string]interface{}{
+		"domain":     domainInfo,
+		"attributes": definitions,
+		"nodes":      nodes,
+		"node_count": nodeCount,
+	}
+
+	document, err := json.Marshal(structuredContent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode export: %w", err)
+	}
+
+	content := []map[string]interface{}{
+		createTextContent(h.formatJSONText(string(document))),
+	}
+
+	return createMCPResponse(content, structuredContent), nil
+}
+
+// exportDocument is the json export_domain format, as read back by import_domain
+type exportDocument struct {
+	Domain struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	} `json:"domain"`
+	Attributes []exportAttribute             `json:"attributes"`
+	Nodes      []response.NodeWithAttributes `json:"nodes"`
+}
+
+// exportAttribute is an attribute definition of an export
+type exportAttribute struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Description string `json:"description"`
+}
+
+// parseExportDocument reads a json or ndjson export_domain dump
+func parseExportDocument(data, format string) (*exportDocument, error) {
+	document := &exportDocument{}
+	if format == "json" {
+		if err := json.Unmarshal([]byte(data), document); err != nil {
+			return nil, fmt.Errorf("invalid json export: %w", err)
+		}
+		return document, nil
+	}
+
+	for i, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		var record struct {
+			Record string `json:"record"`
+		}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			return nil, fmt.Errorf("invalid ndjson export at line %d: %w", i+1, err)
+		}
+
+		var err error
+		switch record.Record {
+		case "domain":
+			err = json.Unmarshal([]byte(line), &document.Domain)
+		case "attribute":
+			var attribute exportAttribute
+			err = json.Unmarshal([]byte(line), &attribute)
+			document.Attributes = append(document.Attributes, attribute)
+		case "node":
+			var node response.NodeWithAttributes
+			err = json.Unmarshal([]byte(line), &node)
+			document.Nodes = append(document.Nodes, node)
+		default:
+			err = fmt.Errorf("unknown record type '%s'", record.Record)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid ndjson export at line %d: %w", i+1, err)
+		}
+	}
+	return document, nil
+}
+
+// handleImportDomain implements the import_domain tool
+func (h *MCPToolHandler) handleImportDomain(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	// Parse arguments; a json export may also be passed as the object export_domain returned
+	var data string
+	switch raw := args["data"].(type) {
+	case string:
+		data = raw
+	case map[string]interface{}:
+		encoded, err := json.Marshal(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid 'data' parameter: %w", err)
+		}
+		data = string(encoded)
+	}
+	if strings.TrimSpace(data) == "" {
+		return nil, fmt.Errorf("missing or invalid 'data' parameter")
+	}
+
+	format := "json"
+	if f, ok := args["format"].(string); ok && f != "" {
+		format = f
+	}
+	if format != "json" && format != "ndjson" {
+		return nil, fmt.Errorf("invalid 'format' parameter: %s (expected json or ndjson)", format)
+	}
+
+	conflict := repository.ImportConflictFail
+	if c, ok := args["conflict"].(string); ok && c != "" {
+		conflict = repository.ImportConflict(c)
+	}
+	switch conflict {
+	case repository.ImportConflictSkip, repository.ImportConflictOverwrite, repository.ImportConflictFail:
+	default:
+		return nil, fmt.Errorf("invalid 'conflict' parameter: %s (expected skip, overwrite or fail)", conflict)
+	}
+
+	document, err := parseExportDocument(data, format)
+	if err != nil {
+		return nil, err
+	}
+
+	// domain_name restores the dump under another name
+	domainName, _ := args["domain_name"].(string)
+	if domainName == "" {
+		domainName = h.canonicalDomainName(document.Domain.Name)
+	}
+	if domainName == "" {
+		return nil, fmt.Errorf("the export names no domain; pass 'domain_name'")
+	}
+	if err := entity.ValidateDomainName(domainName); err != nil {
+		return nil, err
+	}
+
+	dump := &repository.DomainImport{
+		Name:        domainName,
+		Description: document.Domain.Description,
+		Attributes:  make([]repository.ImportAttribute, len(document.Attributes)),
+		Nodes:       make([]repository.ImportNode, len(document.Nodes)),
+		MaxNodes:    h.dependencies.CreateNodeUC.NodeLimit(domainName),
+	}
+	// Values are checked against the types they will be stored under: those already
+	// defined in the domain, then those the export defines
+	existing, err := h.dependencies.DomainRepo.GetByName(ctx, domainName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get domain: %w", err)
+	}
+	types := make(map[string]attribute.AttributeType)
+	if existing != nil {
+		defined, err := h.dependencies.AttributeRepo.ListByDomainID(ctx, existing.ID())
+		if err != nil {
+			return nil, fmt.Errorf("failed to list domain attributes: %w", err)
+		}
+		for _, attr := range defined {
+			types[attr.Name()] = attribute.AttributeType(attr.Type())
+		}
+	}
+	for i, attr := range document.Attributes {
+		if err := h.dependencies.CreateAttributeUC.ValidateName(attr.Name); err != nil {
+			return nil, fmt.Errorf("invalid attribute in export: %w", err)
+		}
+		dump.Attributes[i] = repository.ImportAttribute{Name: attr.Name, Type: attr.Type, Description: attr.Description}
+		if _, ok := types[attr.Name]; !ok {
+			types[attr.Name] = attribute.AttributeType(attr.Type)
+		}
+	}
+	var invalidValues []string
+	for i, node := range document.Nodes {
+		// Imported URLs obey the scheme allowlist too; relative ones may come from allow_relative
+		if err := h.dependencies.CreateNodeUC.ValidateURL(node.Content, true); err != nil {
+			return nil, fmt.Errorf("invalid URL '%s' in export: %w", node.Content, err)
+		}
+		item := repository.ImportNode{
+			URL:       node.Content,
+			CreatedAt: node.CreatedAt,
+			UpdatedAt: node.UpdatedAt,
+		}
+		if node.Title != nil {
+			item.Title = *node.Title
+		}
+		if node.Description != nil {
+			item.Description = *node.Description
+		}
+
+		// Values get the transforms, deduplication and validation of set_node_attributes
+		inputs := make([]nodeUseCase.AttributeInput, len(node.Attributes))
+		for j, attr := range node.Attributes {
+			inputs[j] = nodeUseCase.AttributeInput{Name: attr.Name, Value: attr.Value, OrderIndex: attr.OrderIndex}
+		}
+		prepared, invalid, err := h.dependencies.SetNodeAttributesUC.PrepareValues(ctx, domainName, types, existing != nil, inputs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to validate attributes of '%s': %w", node.Content, err)
+		}
+		for _, value := range invalid {
+			invalidValues = append(invalidValues, fmt.Sprintf("node '%s': %v", node.Content, value.Err))
+		}
+		for _, attr := range prepared {
+			item.Attributes = append(item.Attributes, repository.ImportAttributeValue{Name: attr.Name, Value: attr.Value, OrderIndex: attr.OrderIndex})
+		}
+		dump.Nodes[i] = item
+	}
+	if len(invalidValues) > 0 {
+		return nil, fmt.Errorf("nothing was imported, %d invalid attribute value(s): %s", len(invalidValues), strings.Join(invalidValues, "; "))
+	}
+
+	result, err := h.dependencies.DomainImportRepo.Import(ctx, dump, conflict)
+	if err != nil {
+		return nil, fmt.Errorf("failed to import domain: %w", err)
+	}
+
+	domainStatus := "existing"
+	if result.DomainCreated {
+		domainStatus = "new"
+	}
+	content := []map[string]interface{}{
+		createTextContent(fmt.Sprintf("Imported into %s domain '%s'\nNodes: %d created, %d updated, %d skipped\nAttributes: %d created, %d already defined\nAttribute values: %d created",
+			domainStatus, domainName, result.NodesCreated, result.NodesUpdated, result.NodesSkipped,
+			result.AttributesCreated, result.AttributesSkipped, result.AttributeValuesCreated)),
+	}
+
+	structuredContent := map[string]interface{}{
+		"domain_name":    domainName,
+		"domain_created": result.DomainCreated,
+		"conflict":       string(conflict),
+		"nodes": map[string]interface{}{
+			"created": result.NodesCreated,
+			"updated": result.NodesUpdated,
+			"skipped": result.NodesSkipped,
+		},
+		"attributes": map[string]interface{}{
+			"created": result.AttributesCreated,
+			"skipped": result.AttributesSkipped,
+		},
+		"attribute_values_created": result.AttributeValuesCreated,
+	}
+
+	return createMCPResponse(content, structuredContent), nil
+}
+
+// handleMergeDomains implements the merge_domains tool
+func (h *MCPToolHandler) handleMergeDomains(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	sourceName, ok := args["source_domain_name"].(string)
+	if !ok || sourceName == "" {
+		return nil, fmt.Errorf("missing or invalid 'source_domain_name' parameter")
+	}
+	targetName, ok := args["target_domain_name"].(string)
+	if !ok || targetName == "" {
+		return nil, fmt.Errorf("missing or invalid 'target_domain_name' parameter")
+	}
+	if sourceName == targetName {
+		return nil, fmt.Errorf("cannot merge domain '%s' into itself", sourceName)
+	}
+
+	conflict := repository.ImportConflictFail
+	if c, ok := args["conflict"].(string); ok && c != "" {
+		conflict = repository.ImportConflict(c)
+	}
+	switch conflict {
+	case repository.ImportConflictSkip, repository.ImportConflictOverwrite, repository.ImportConflictFail:
+	default:
+		return nil, fmt.Errorf("invalid 'conflict' parameter: %s (expected skip, overwrite or fail)", conflict)
+	}
+	dropConflicting, _ := args["drop_conflicting_attributes"].(bool)
+	dryRun, _ := args["dry_run"].(bool)
+
+	domains := make([]*entity.Domain, 2)
+	for i, name := range []string{sourceName, targetName} {
+		domain, err := h.dependencies.DomainRepo.GetByName(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get domain: %w", err)
+		}
+		if domain == nil {
+			return nil, fmt.Errorf("domain '%s' not found", name)
+		}
+		domains[i] = domain
+	}
+
+	result, err := h.dependencies.DomainMergeRepo.Merge(ctx, &repository.DomainMerge{
+		SourceDomainID:            domains[0].ID(),
+		TargetDomainID:            domains[1].ID(),
+		Conflict:                  conflict,
+		DropConflictingAttributes: dropConflicting,
+		MaxNodes:                  h.dependencies.CreateNodeUC.NodeLimit(targetName),
+		DryRun:                    dryRun,
+	})
+	if errors.Is(err, repository.ErrAttributeTypeConflict) {
+		return nil, fmt.Errorf("merge aborted, nothing was changed: %w (set drop_conflicting_attributes to drop their values)", err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("merge aborted, nothing was changed: %w", err)
+	}
+
+	if !dryRun {
+		for _, nodeID := range result.UpdatedNodeIDs {
+			h.recordNodeEvent(ctx, nodeID, entity.NodeEventUpdated, map[string]interface{}{"fields": []string{"domain"}, "domain_name": targetName})
+		}
+		for _, node := range result.RemovedNodes {
+			h.recordNodeEvent(ctx, node.ID, entity.NodeEventDeleted, map[string]interface{}{"url": node.URL, "merged_into": node.KeptByID})
+		}
+	}
+
+	conflicts := make([]map[string]interface{}, len(result.Conflicts))
+	conflictLines := make([]string, len(result.Conflicts))
+	for i, c := range result.Conflicts {
+		conflicts[i] = map[string]interface{}{
+			"name":           c.Name,
+			"source_type":    c.SourceType,
+			"target_type":    c.TargetType,
+			"dropped_values": c.Values,
+		}
+		conflictLines[i] = fmt.Sprintf("- %s: %s in source, %s in target (%d value(s) dropped)", c.Name, c.SourceType, c.TargetType, c.Values)
+	}
+
+	text := fmt.Sprintf("Merged domain '%s' into '%s' and deleted '%s'", sourceName, targetName, sourceName)
+	if dryRun {
+		text = fmt.Sprintf("Dry run: merging domain '%s' into '%s' would do the following; nothing was changed", sourceName, targetName)
+	}
+	text += fmt.Sprintf("\nNodes: %d moved, %d skipped, %d overwritten\nLinks of removed nodes: %d repointed, %d dropped\nAttributes: %d moved, %d merged\nTemplates: %d moved, %d dropped",
+		result.NodesMoved, result.NodesSkipped, result.NodesOverwritten, result.LinksRepointed, result.LinksDropped,
+		result.AttributesMoved, result.AttributesMerged, result.TemplatesMoved, result.TemplatesDropped)
+	if len(conflictLines) > 0 {
+		text += "\nAttribute type conflicts:\n" + strings.Join(conflictLines, "\n")
+	}
+
+	content := []map[string]interface{}{
+		createTextContent(text),
+	}
+
+	structuredContent := map[string]interface{}{
+		"source_domain_name": sourceName,
+		"target_domain_name": targetName,
+		"conflict":           string(conflict),
+		"dry_run":            dryRun,
+		"nodes": map[string]interface{}{
+			"moved":       result.NodesMoved,
+			"skipped":     result.NodesSkipped,
+			"overwritten": result.NodesOverwritten,
+		},
+		"links": map[string]interface{}{
+			"repointed": result.LinksRepointed,
+			"dropped":   result.LinksDropped,
+		},
+		"attributes": map[string]interface{}{
+			"moved":  result.AttributesMoved,
+			"merged": result.AttributesMerged,
+		},
+		"templates": map[string]interface{}{
+			"moved":   result.TemplatesMoved,
+			"dropped": result.TemplatesDropped,
+		},
+		"attribute_conflicts": conflicts,
+	}
+
+	return createMCPResponse(content, structuredContent), nil
+}
+
+// Node Management Tools
+
+// handleListNodes implements the list_nodes tool
+func (h *MCPToolHandler) handleListNodes(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	// Parse arguments
+	domainName, ok := args["domain_name"].(string)
+	if !ok || domainName == "" {
+		return nil, fmt.Errorf("missing or invalid 'domain_name' parameter")
+	}
+
+	// Optional parameters with defaults
+	page := 1
+	if p, ok := args["page"].(float64); ok {
+		page = int(p)
+	}
+
+	size := constants.DefaultPageSize
+	if s, ok := args["size"].(float64); ok {
+		size = int(s)
+	}
+
+	search := ""
+	if s, ok := args["search"].(string); ok {
+		search = s
+	}
+
+	includeArchived, _ := args["include_archived"].(bool)
+
+	timeRange, err := parseNodeTimeRange(args)
+	if err != nil {
+		return nil, err
+	}
+
+	// Optional field projection (composite_id is always included)
+	fields, err := parseNodeFields(args)
+	if err != nil {
+		return nil, err
+	}
+
+	// Execute use case
+	result, err := h.dependencies.ListNodesUC.SearchInTimeRange(ctx, domainName, search, timeRange, page, size, includeArchived)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	// Count attributes for the whole page in one query
+	nodeIDs := make([]int, len(result.Nodes))
+	for i, node := range result.Nodes {
+		nodeIDs[i] = node.ID
+	}
+	attributeCounts, err := h.dependencies.NodeAttributeRepo.CountByNodeIDs(ctx, nodeIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count node attributes: %w", err)
+	}
+
+	// Convert to MCP response format
+	content := []map[string]interface{}{}
+	structuredNodes := []map[string]interface{}{}
+	
+	for _, node := range result.Nodes {
+		compositeID := h.nodeCompositeID(domainName, node.ID)
+
+		if fields == nil {
+			content = append(content, createTextContent(
+				fmt.Sprintf("Node ID: %d\nURL: %s\nTitle: %s\nDescription: %s\nCreated: %s",
+					node.ID, node.URL, node.Title, node.Description, node.CreatedAt.Format("2006-01-02 15:04:05"))))
+		} else {
+			lines := []string{fmt.Sprintf("Composite ID: %s", compositeID)}
+			for _, field := range fields {
+				lines = append(lines, fmt.Sprintf("%s: %v", field, nodeFieldValue(node, field)))
+			}
+			content = append(content, createTextContent(strings.Join(lines, "\n")))
+		}
+
+		structuredNode := map[string]interface{}{
+			"composite_id":    compositeID,
+			"attribute_count": attributeCounts[node.ID],
+		}
+		for _, field := range fieldsOrDefault(fields) {
+			structuredNode[field] = nodeFieldValue(node, field)
+		}
+		if node.ArchivedAt != nil {
+			structuredNode["archived_at"] = node.ArchivedAt.Format(time.RFC3339)
+		}
+		structuredNodes = append(structuredNodes, structuredNode)
+	}
+
+	if len(content) == 0 {
+		content = append(content, createTextContent(fmt.Sprintf("No nodes found in domain '%s'", domainName)))
+	}
+	if note := pageSizeCappedText(size, result.Size); note != "" {
+		content = append(content, createTextContent(note))
+	}
+
+	// Create structured content for machine-readable access
+	structuredContent := map[string]interface{}{
+		"domain_name": domainName,
+		"nodes":       structuredNodes,
+		"total_count": result.TotalCount,
+		"page":        result.Page,
+		"total_pages": result.TotalPages,
+		"pagination":  newPagination(result.Page, result.Size, result.TotalCount),
+	}
+
+	return createMCPResponse(content, structuredContent), nil
+}
+
+// parseNodeTimeRange reads the optional RFC3339 created_after, created_before,
+// updated_after and updated_before arguments of list_nodes
+func parseNodeTimeRange(args map[string]interface{}) (repository.NodeTimeRange, error) {
+	var timeRange repository.NodeTimeRange
+	bounds := []struct {
+		name string
+		at   **time.Time
+	}{
+		{"created_after", &timeRange.CreatedAfter},
+		{"created_before", &timeRange.CreatedBefore},
+		{"updated_after", &timeRange.UpdatedAfter},
+		{"updated_before", &timeRange.UpdatedBefore},
+	}
+	for _, bound := range bounds {
+		raw, exists := args[bound.name]
+		if !exists || raw == nil {
+			continue
+		}
+		value, ok := raw.(string)
+		if !ok {
+			return timeRange, fmt.Errorf("invalid '%s' parameter: expected an RFC3339 timestamp string", bound.name)
+		}
+		at, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return timeRange, fmt.Errorf("invalid '%s' parameter: '%s' is not an RFC3339 timestamp such as 2024-01-02T15:04:05Z", bound.name, value)
+		}
+		*bound.at = &at
+	}
+
+	if after, before := timeRange.CreatedAfter, timeRange.CreatedBefore; after != nil && before != nil && !after.Before(*before) {
+		return timeRange, fmt.Errorf("invalid time range: created_after must be before created_before")
+	}
+	if after, before := timeRange.UpdatedAfter, timeRange.UpdatedBefore; after != nil && before != nil && !after.Before(*before) {
+		return timeRange, fmt.Errorf("invalid time range: updated_after must be before updated_before")
+	}
+	return timeRange, nil
+}
+
+// listableNodeFields are the node fields list_nodes can project
+var listableNodeFields = []string{"id", "url", "title", "description", "created_at"}
+
+// parseNodeFields reads the optional 'fields' argument; nil means all fields
+func parseNodeFields(args map[string]interface{}) ([]string, error) {
+	raw, ok := args["fields"].([]interface{})
+	if !ok {
+		return nil, nil
+	}
+
+	fields := []string{}
+	for _, item := range raw {
+		field, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid 'fields' parameter: entries must be strings")
+		}
+		if field == "composite_id" || field == "attribute_count" {
+			continue // always included
+		}
+
+		known := false
+		for _, f := range listableNodeFields {
+			if f == field {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return nil, fmt.Errorf("invalid field '%s', must be one of: composite_id, attribute_count, %s", field, strings.Join(listableNodeFields, ", "))
+		}
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
+
+// fieldsOrDefault returns the requested fields, or every listable field when none were requested
+func fieldsOrDefault(fields []string) []string {
+	if fields == nil {
+		return listableNodeFields
+	}
+	return fields
+}
+
+// nodeFieldValue returns the value of a listable node field
+func nodeFieldValue(node response.NodeResponse, field string) interface{} {
+	switch field {
+	case "id":
+		return node.ID
+	case "url":
+		return node.URL
+	case "title":
+		return node.Title
+	case "description":
+		return node.Description
+	case "created_at":
+		return node.CreatedAt.Format(time.RFC3339)
+	}
+	return nil
+}
+
+// handleCreateNode implements the create_node tool
+func (h *MCPToolHandler) handleCreateNode(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	// Parse required arguments
+	domainName, ok := args["domain_name"].(string)
+	if !ok || domainName == "" {
+		return nil, fmt.Errorf("missing or invalid 'domain_name' parameter")
+	}
+
+	url, ok := args["url"].(string)
+	if !ok || url == "" {
+		return nil, fmt.Errorf("missing or invalid 'url' parameter")
+	}
+
+	// Optional parameters
+	title := ""
+	if t, ok := args["title"].(string); ok {
+		title = t
+	}
+
+	description := ""
+	if d, ok := args["description"].(string); ok {
+		description = d
+	}
+
+	expiresAt, err := parseNodeExpiry(args)
+	if err != nil {
+		return nil, err
+	}
+
+	// Optionally derive the title from the page itself. A failed fetch never
+	// blocks node creation; the reason is reported alongside the result.
+	titleFetchError := ""
+	if fetchTitle, ok := args["fetch_title"].(bool); ok && fetchTitle && title == "" {
+		fetched, err := h.titleFetcher.FetchTitle(ctx, url)
+		if err != nil {
+			titleFetchError = err.Error()
+		} else {
+			title = fetched
+		}
+	}
+
+	allowRelative, _ := args["allow_relative"].(bool)
+
+	// Create request DTO
+	createReq := &request.CreateNodeRequest{
+		DomainName:    domainName,
+		URL:           url,
+		Title:         title,
+		Description:   description,
+		ExpiresAt:     expiresAt,
+		AllowRelative: allowRelative,
+	}
+
+	// Execute use case
+	result, err := h.dependencies.CreateNodeUC.Execute(ctx, createReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create node: %w", err)
+	}
+
+	h.recordNodeEvent(ctx, result.ID, entity.NodeEventCreated, map[string]interface{}{"url": result.URL})
+
+	// Convert to MCP response format with composite ID for easy reference
+	compositeID := h.nodeCompositeID(domainName, result.ID)
+
+	content := []map[string]interface{}{
+		createTextContent(fmt.Sprintf("Successfully created node in domain '%s'\nComposite ID: %s\nURL: %s\nTitle: %s\nDescription: %s\nCreated: %s",
+			domainName, compositeID, result.URL, result.Title, result.Description, result.CreatedAt.Format("2006-01-02 15:04:05"))),
+	}
+
+	structuredContent := map[string]interface{}{
+		"composite_id": compositeID,
+		"domain_name":  domainName,
+		"id":           result.ID,
+		"url":          result.URL,
+		"title":        result.Title,
+		"description":  result.Description,
+		"created_at":   result.CreatedAt.Format(time.RFC3339),
+	}
+
+	if result.ExpiresAt != nil {
+		content = append(content, createTextContent("Expires: "+result.ExpiresAt.Format(time.RFC3339)))
+		structuredContent["expires_at"] = result.ExpiresAt.Format(time.RFC3339)
+	}
+
+	if titleFetchError != "" {
+		content = append(content, createTextContent("Title could not be fetched: "+titleFetchError))
+		structuredContent["title_fetch_error"] = titleFetchError
+	}
+
+	content = h.addNodeWarnings(content, structuredContent, result.URL, result.Title)
+
+	return createMCPResponse(content, structuredContent), nil
+}
+
+// parseNodeExpiry reads create_node's optional expires_in (seconds) or expires_at
+// (RFC 3339) argument. It returns nil when neither is given.
+func parseNodeExpiry(args map[string]interface{}) (*time.Time, error) {
+	expiresIn, hasExpiresIn := args["expires_in"]
+	expiresAtArg, hasExpiresAt := args["expires_at"]
+
+	switch {
+	case hasExpiresIn && hasExpiresAt:
+		return nil, fmt.Errorf("use either 'expires_in' or 'expires_at', not both")
+	case hasExpiresIn:
+		seconds, ok := expiresIn.(float64)
+		if !ok || seconds < 1 {
+			return nil, fmt.Errorf("invalid 'expires_in' parameter: must be a positive number of seconds")
+		}
+		expiresAt := time.Now().Add(time.Duration(seconds) * time.Second)
+		return &expiresAt, nil
+	case hasExpiresAt:
+		value, ok := expiresAtArg.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid 'expires_at' parameter: must be an RFC 3339 time")
+		}
+		expiresAt, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid 'expires_at' parameter: %v", err)
+		}
+		return &expiresAt, nil
+	}
+
+	return nil, nil
+}
+
+// Additional Node Management Tools
+
+// handleCreateNodesBatch implements the create_nodes_batch tool
+func (h *MCPToolHandler) handleCreateNodesBatch(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	// Parse arguments
+	domainName, ok := args["domain_name"].(string)
+	if !ok || domainName == "" {
+		return nil, fmt.Errorf("missing or invalid 'domain_name' parameter")
+	}
+
+	rawNodes, ok := args["nodes"].([]interface{})
+	if !ok || len(rawNodes) == 0 {
+		return nil, fmt.Errorf("missing or invalid 'nodes' parameter")
+	}
+	if len(rawNodes) > constants.MaxBatchSize {
+		return nil, fmt.Errorf("too many nodes: %d (max %d)", len(rawNodes), constants.MaxBatchSize)
+	}
+
+	atomic, _ := args["atomic"].(bool)
+	allowRelative, _ := args["allow_relative"].(bool)
+
+	domain, err := h.dependencies.DomainRepo.GetByName(ctx, domainName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get domain: %w", err)
+	}
+	if domain == nil {
+		return nil, fmt.Errorf("domain '%s' not found", domainName)
+	}
+
+	urls := make([]string, len(rawNodes))
+	for i, raw := range rawNodes {
+		if item, ok := raw.(map[string]interface{}); ok {
+			urls[i], _ = item["url"].(string)
+		}
+	}
+
+	existing, err := h.dependencies.NodeRepo.GetByURLs(ctx, urls, domainName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check existing nodes: %w", err)
+	}
+	seen := make(map[string]bool, len(urls))
+	for _, node := range existing {
+		seen[node.URL()] = true
+	}
+
+	// Respect the domain's node cap
+	remaining := -1
+	limit := h.dependencies.CreateNodeUC.NodeLimit(domainName)
+	if limit > 0 {
+		count, err := h.dependencies.NodeRepo.CountByDomain(ctx, domain.ID())
+		if err != nil {
+			return nil, fmt.Errorf("failed to count nodes: %w", err)
+		}
+		remaining = limit - count
+	}
+
+	// Validate every item up front; only valid ones reach the database
+	itemErrors := make([]error, len(rawNodes))
+	var nodes []*entity.Node
+	var nodeIndexes []int
+	for i, raw := range rawNodes {
+		item, ok := raw.(map[string]interface{})
+		if !ok || urls[i] == "" {
+			itemErrors[i] = errors.New("missing or invalid 'url'")
+			continue
+		}
+		if seen[urls[i]] {
+			itemErrors[i] = errors.New(constants.ErrDuplicateNode)
+			continue
+		}
+		if err := h.dependencies.CreateNodeUC.ValidateURL(urls[i], allowRelative); err != nil {
+			itemErrors[i] = err
+			continue
+		}
+
+		title, _ := item["title"].(string)
+		description, _ := item["description"].(string)
+		node, err := entity.NewNode(urls[i], title, description, domain.ID())
+		if err != nil {
+			itemErrors[i] = err
+			continue
+		}
+
+		if remaining == 0 {
+			itemErrors[i] = fmt.Errorf("domain '%s' has reached its node limit of %d", domainName, limit)
+			continue
+		}
+		if remaining > 0 {
+			remaining--
+		}
+
+		seen[urls[i]] = true
+		nodes = append(nodes, node)
+		nodeIndexes = append(nodeIndexes, i)
+	}
+
+	if atomic {
+		for i, itemErr := range itemErrors {
+			if itemErr != nil {
+				return nil, fmt.Errorf("batch aborted, nothing was created: item %d (%s): %v", i, urls[i], itemErr)
+			}
+		}
+	}
+
+	createErrors, err := h.dependencies.NodeRepo.CreateBatch(ctx, nodes, atomic)
+	if err != nil {
+		return nil, fmt.Errorf("batch aborted, nothing was created: %w", err)
+	}
+	for j, createErr := range createErrors {
+		itemErrors[nodeIndexes[j]] = createErr
+	}
+
+	// Report results in input order
+	nodesByIndex := make(map[int]*entity.Node, len(nodes))
+	for j, node := range nodes {
+		nodesByIndex[nodeIndexes[j]] = node
+	}
+
+	results := make([]map[string]interface{}, len(rawNodes))
+	lines := make([]string, len(rawNodes))
+	created := 0
+	for i := range rawNodes {
+		if itemErrors[i] != nil {
+			results[i] = map[string]interface{}{"index": i, "url": urls[i], "success": false, "error": itemErrors[i].Error()}
+			lines[i] = fmt.Sprintf("%d. %s: failed (%v)", i, urls[i], itemErrors[i])
+			continue
+		}
+
+		created++
+		h.recordNodeEvent(ctx, nodesByIndex[i].ID(), entity.NodeEventCreated, map[string]interface{}{"url": urls[i]})
+		compositeID := h.nodeCompositeID(domainName, nodesByIndex[i].ID())
+		results[i] = map[string]interface{}{"index": i, "url": urls[i], "success": true, "composite_id": compositeID}
+		lines[i] = fmt.Sprintf("%d. %s: %s", i, urls[i], compositeID)
+	}
+
+	content := []map[string]interface{}{
+		createTextContent(fmt.Sprintf("Created %d of %d node(s) in domain '%s'\n%s",
+			created, len(rawNodes), domainName, strings.Join(lines, "\n"))),
+	}
+
+	structuredContent := map[string]interface{}{
+		"domain_name":   domainName,
+		"results":       results,
+		"created_count": created,
+		"failed_count":  len(rawNodes) - created,
+	}
+
+	return createMCPResponse(content, structuredContent), nil
+}
+
+// handleGetNode implements the get_node tool
+func (h *MCPToolHandler) handleGetNode(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	// Parse composite_id argument
+	compositeID, ok := args["composite_id"].(string)
+	if !ok || compositeID == "" {
+		return nil, fmt.Errorf("missing or invalid 'composite_id' parameter")
+	}
+
+	// Parse composite ID to extract node ID
+	// composite_id format: "tool-name:domain:id"
+	nodeID, err := h.parseCompositeID(compositeID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get node from repository
+	node, err := h.dependencies.NodeRepo.GetByID(ctx, nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node: %w", err)
+	}
+	if node == nil {
+		return nil, fmt.Errorf("node not found: %s", compositeID)
+	}
+
+	// Convert to MCP response format
+	content := []map[string]interface{}{
+		createTextContent(fmt.Sprintf("Node ID: %d\nComposite ID: %s\nURL: %s\nTitle: %s\nDescription: %s\nCreated: %s\nUpdated: %s\nVersion: %d",
+			node.ID(), compositeID, node.URL(), node.Title(), node.Description(),
+			node.CreatedAt().Format("2006-01-02 15:04:05"),
+			node.UpdatedAt().Format("2006-01-02 15:04:05"), node.Version())),
+	}
+
+	structuredContent := map[string]interface{}{
+		"composite_id": compositeID,
+		"id":           node.ID(),
+		"url":          node.URL(),
+		"title":        node.Title(),
+		"description":  node.Description(),
+		"created_at":   node.CreatedAt().Format(time.RFC3339),
+		"updated_at":   node.UpdatedAt().Format(time.RFC3339),
+		"version":      node.Version(),
+	}
+
+	if expiresAt := node.ExpiresAt(); expiresAt != nil {
+		ttl := int(time.Until(*expiresAt).Seconds())
+		content = append(content, createTextContent(fmt.Sprintf("Expires: %s (in %ds)", expiresAt.Format(time.RFC3339), ttl)))
+		structuredContent["expires_at"] = expiresAt.Format(time.RFC3339)
+		structuredContent["ttl_seconds"] = ttl
+	}
+
+	if archivedAt := node.ArchivedAt(); archivedAt != nil {
+		content = append(content, createTextContent("Archived: "+archivedAt.Format(time.RFC3339)))
+		structuredContent["archived_at"] = archivedAt.Format(time.RFC3339)
+	}
+
+	return createMCPResponse(content, structuredContent), nil
+}
+
+// handleUpdateNode implements the update_node tool
+func (h *MCPToolHandler) handleUpdateNode(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	// Parse composite_id argument
+	compositeID, ok := args["composite_id"].(string)
+	if !ok || compositeID == "" {
+		return nil, fmt.Errorf("missing or invalid 'composite_id' parameter")
+	}
+
+	// Parse composite ID to extract node ID
+	nodeID, err := h.parseCompositeID(compositeID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get existing node
+	node, err := h.dependencies.NodeRepo.GetByID(ctx, nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node: %w", err)
+	}
+	if node == nil {
+		return nil, fmt.Errorf("node not found: %s", compositeID)
+	}
+
+	// A stale expected_version fails here; a change after this read fails in Update
+	if raw, ok := args["expected_version"]; ok {
+		expected, ok := raw.(float64)
+		if !ok || expected < 1 || expected != float64(int(expected)) {
+			return nil, fmt.Errorf("invalid 'expected_version' parameter: must be a positive integer")
+		}
+		if int(expected) != node.Version() {
+			return nil, fmt.Errorf("node %s is at version %d, not %d; read it again and retry: %w",
+				compositeID, node.Version(), int(expected), repository.ErrConcurrencyConflict)
+		}
+	}
+
+	// Update fields if provided
+	var changed []string
+	if title, ok := args["title"].(string); ok {
+		if err := node.UpdateTitle(title); err != nil {
+			return nil, fmt.Errorf("failed to update title: %w", err)
+		}
+		changed = append(changed, "title")
+	}
+
+	if description, ok := args["description"].(string); ok {
+		if err := node.UpdateDescription(description); err != nil {
+			return nil, fmt.Errorf("failed to update description: %w", err)
+		}
+		changed = append(changed, "description")
+	}
+
+	if len(changed) == 0 {
+		return nil, fmt.Errorf("at least one field (title or description) must be provided for update")
+	}
+
+	// Save updated node
+	if err := h.dependencies.NodeRepo.Update(ctx, node); err != nil {
+		if errors.Is(err, repository.ErrConcurrencyConflict) {
+			return nil, fmt.Errorf("node %s changed while it was being updated; read it again and retry: %w", compositeID, err)
+		}
+		return nil, fmt.Errorf("failed to update node: %w", err)
+	}
+
+	h.recordNodeEvent(ctx, node.ID(), entity.NodeEventUpdated, map[string]interface{}{"fields": changed})
+
+	// Convert to MCP response format
+	content := []map[string]interface{}{
+		createTextContent(fmt.Sprintf("Successfully updated node:\nID: %d\nURL: %s\nTitle: %s\nDescription: %s\nUpdated: %s\nVersion: %d",
+			node.ID(), node.URL(), node.Title(), node.Description(),
+			node.UpdatedAt().Format("2006-01-02 15:04:05"), node.Version())),
+	}
+
+	structuredContent := map[string]interface{}{
+		"composite_id": compositeID,
+		"id":           node.ID(),
+		"url":          node.URL(),
+		"title":        node.Title(),
+		"description":  node.Description(),
+		"updated_at":   node.UpdatedAt().Format(time.RFC3339),
+		"version":      node.Version(),
+	}
+
+	content = h.addNodeWarnings(content, structuredContent, node.URL(), node.Title())
+
+	return createMCPResponse(content, structuredContent), nil
+}
+
+// handleDeleteNode implements the delete_node tool
+func (h *MCPToolHandler) handleDeleteNode(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	// Parse composite_id argument
+	compositeID, ok := args["composite_id"].(string)
+	if !ok || compositeID == "" {
+		return nil, fmt.Errorf("missing or invalid 'composite_id' parameter")
+	}
+
+	// Parse composite ID to extract node ID
+	nodeID, err := h.parseCompositeID(compositeID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get node before deleting (for confirmation message)
+	node, err := h.dependencies.NodeRepo.GetByID(ctx, nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node: %w", err)
+	}
+	if node == nil {
+		return nil, fmt.Errorf("node not found: %s", compositeID)
+	}
+
+	dryRun, _ := args["dry_run"].(bool)
+
+	// Count the rows deleted along with the node
+	attributeCounts, err := h.dependencies.NodeAttributeRepo.CountByNodeIDs(ctx, []int{nodeID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to count node attributes: %w", err)
+	}
+	dependencies, err := h.dependencies.DependencyRepo.ListByDependentNodeID(ctx, nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dependencies: %w", err)
+	}
+	dependents, err := h.dependencies.DependencyRepo.ListByDependencyNodeID(ctx, nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dependents: %w", err)
+	}
+	subscriptions, err := h.dependencies.NodeSubscriptionRepo.ListByNode(ctx, nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list subscriptions: %w", err)
+	}
+
+	header := "Successfully deleted node:"
+	if dryRun {
+		header = "Dry run, nothing was deleted. Deleting this node would remove:"
+	} else {
+		if err := h.dependencies.NodeRepo.Delete(ctx, nodeID); err != nil {
+			return nil, fmt.Errorf("failed to delete node: %w", err)
+		}
+		h.recordNodeEvent(ctx, nodeID, entity.NodeEventDeleted, map[string]interface{}{"url": node.URL()})
+	}
+
+	content := []map[string]interface{}{
+		createTextContent(fmt.Sprintf("%s\nID: %d\nURL: %s\nTitle: %s\nAttributes: %d\nDependencies: %d\nDependents: %d\nSubscriptions: %d",
+			header, node.ID(), node.URL(), node.Title(), attributeCounts[nodeID],
+			len(dependencies), len(dependents), len(subscriptions))),
+	}
+
+	structuredContent := map[string]interface{}{
+		"deleted":            !dryRun,
+		"dry_run":            dryRun,
+		"composite_id":       compositeID,
+		"url":                node.URL(),
+		"title":              node.Title(),
+		"attribute_count":    attributeCounts[nodeID],
+		"dependency_count":   len(dependencies),
+		"dependent_count":    len(dependents),
+		"subscription_count": len(subscriptions),
+	}
+
+	return createMCPResponse(content, structuredContent), nil
+}
+
+// handleArchiveNode implements the archive_node tool. Archiving an archived node
+// keeps its original archive time.
+func (h *MCPToolHandler) handleArchiveNode(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	return h.setNodeArchived(ctx, args, true)
+}
+
+// handleRestoreNode implements the restore_node tool. Restoring a node that is not
+// archived changes nothing.
+func (h *MCPToolHandler) handleRestoreNode(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	return h.setNodeArchived(ctx, args, false)
+}
+
+// setNodeArchived archives or restores the node named by args' composite_id
+func (h *MCPToolHandler) setNodeArchived(ctx context.Context, args map[string]interface{}, archive bool) (interface{}, error) {
+	compositeID, ok := args["composite_id"].(string)
+	if !ok || compositeID == "" {
+		return nil, fmt.Errorf("missing or invalid 'composite_id' parameter")
+	}
+
+	nodeID, err := h.parseCompositeID(compositeID)
+	if err != nil {
+		return nil, err
+	}
+
+	node, err := h.dependencies.NodeRepo.GetByID(ctx, nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node: %w", err)
+	}
+	if node == nil {
+		return nil, fmt.Errorf("node not found: %s", compositeID)
+	}
+
+	if node.IsArchived() != archive {
+		var archivedAt *time.Time
+		if archive {
+			now := time.Now()
+			archivedAt = &now
+		}
+		if err := h.dependencies.NodeRepo.SetArchivedAt(ctx, nodeID, archivedAt); err != nil {
+			return nil, fmt.Errorf("failed to update node: %w", err)
+		}
+		node.SetArchivedAt(archivedAt)
+		h.recordNodeEvent(ctx, nodeID, entity.NodeEventUpdated, map[string]interface{}{"fields": []string{"archived_at"}})
+	}
+
+	structuredContent := map[string]interface{}{
+		"composite_id": compositeID,
+		"archived":     node.IsArchived(),
+	}
+
+	var content []map[string]interface{}
+	if archivedAt := node.ArchivedAt(); archivedAt != nil {
+		structuredContent["archived_at"] = archivedAt.Format(time.RFC3339)
+		content = append(content, createTextContent(fmt.Sprintf("Archived node %s (%s) at %s", compositeID, node.URL(), archivedAt.Format(time.RFC3339))))
+	} else {
+		content = append(content, createTextContent(fmt.Sprintf("Node %s (%s) is not archived", compositeID, node.URL())))
+	}
+
+	return createMCPResponse(content, structuredContent), nil
+}
+
+// handleMoveNode implements the move_node tool
+func (h *MCPToolHandler) handleMoveNode(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	// Parse arguments
+	compositeID, ok := args["composite_id"].(string)
+	if !ok || compositeID == "" {
+		return nil, fmt.Errorf("missing or invalid 'composite_id' parameter")
+	}
+
+	nodeID, err := h.parseCompositeID(compositeID)
+	if err != nil {
+		return nil, err
+	}
+
+	targetDomainName, ok := args["target_domain_name"].(string)
+	if !ok || targetDomainName == "" {
+		return nil, fmt.Errorf("missing or invalid 'target_domain_name' parameter")
+	}
+
+	dropIncompatible, _ := args["drop_incompatible_attributes"].(bool)
+
+	targetDomain, err := h.dependencies.DomainRepo.GetByName(ctx, targetDomainName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get domain: %w", err)
+	}
+	if targetDomain == nil {
+		return nil, fmt.Errorf("domain not found: %s", targetDomainName)
+	}
+
+	results, err := h.dependencies.NodeMoveRepo.Move(ctx, &repository.NodeMove{
+		NodeIDs:          []int{nodeID},
+		TargetDomainID:   targetDomain.ID(),
+		MaxNodes:         h.dependencies.CreateNodeUC.NodeLimit(targetDomainName),
+		DropIncompatible: dropIncompatible,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to move node: %w", err)
+	}
+	result := results[0]
+	if errors.Is(result.Err, repository.ErrIncompatibleAttributes) {
+		return nil, fmt.Errorf("failed to move node: %w (set drop_incompatible_attributes to drop their values)", result.Err)
+	}
+	if result.Err != nil {
+		return nil, fmt.Errorf("failed to move node: %w", result.Err)
+	}
+
+	dropped := result.DroppedAttributes
+	if dropped == nil {
+		dropped = []string{}
+	}
+	h.recordNodeEvent(ctx, nodeID, entity.NodeEventUpdated, map[string]interface{}{"fields": []string{"domain"}, "domain_name": targetDomainName})
+
+	// Composite IDs embed the domain, so the node has a new one
+	newCompositeID := h.nodeCompositeID(targetDomainName, nodeID)
+	text := fmt.Sprintf("Moved node %s to domain '%s'\nNew composite ID: %s", compositeID, targetDomainName, newCompositeID)
+	if len(dropped) > 0 {
+		text += fmt.Sprintf("\nDropped attributes: %s", strings.Join(dropped, ", "))
+	}
+
+	content := []map[string]interface{}{createTextContent(text)}
+	structuredContent := map[string]interface{}{
+		"composite_id":          newCompositeID,
+		"previous_composite_id": compositeID,
+		"domain_name":           targetDomainName,
+		"dropped_attributes":    dropped,
+	}
+
+	return createMCPResponse(content, structuredContent), nil
+}
+
+// handleMoveNodes implements the move_nodes tool
+func (h *MCPToolHandler) handleMoveNodes(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	// Parse arguments
+	rawIDs, ok := args["composite_ids"].([]interface{})
+	if !ok || len(rawIDs) == 0 {
+		return nil, fmt.Errorf("missing or invalid 'composite_ids' parameter")
+	}
+	if len(rawIDs) > constants.MaxBatchSize {
+		return nil, fmt.Errorf("too many nodes: %d (max %d)", len(rawIDs), constants.MaxBatchSize)
+	}
+
+	targetDomainName, ok := args["target_domain_name"].(string)
+	if !ok || targetDomainName == "" {
+		return nil, fmt.Errorf("missing or invalid 'target_domain_name' parameter")
+	}
+
+	targetDomain, err := h.dependencies.DomainRepo.GetByName(ctx, targetDomainName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get domain: %w", err)
+	}
+	if targetDomain == nil {
+		return nil, fmt.Errorf("domain not found: %s", targetDomainName)
+	}
+
+	// Malformed IDs fail on their own; the rest move together
+	compositeIDs := make([]string, len(rawIDs))
+	itemErrors := make([]error, len(rawIDs))
+	var nodeIDs []int
+	var nodeIndexes []int
+	for i, raw := range rawIDs {
+		compositeID, _ := raw.(string)
+		compositeIDs[i] = compositeID
+
+		nodeID, err := h.parseCompositeID(compositeID)
+		if err != nil {
+			itemErrors[i] = err
+			continue
+		}
+		nodeIDs = append(nodeIDs, nodeID)
+		nodeIndexes = append(nodeIndexes, i)
+	}
+
+	moveResults, err := h.dependencies.NodeMoveRepo.Move(ctx, &repository.NodeMove{
+		NodeIDs:          nodeIDs,
+		TargetDomainID:   targetDomain.ID(),
+		MaxNodes:         h.dependencies.CreateNodeUC.NodeLimit(targetDomainName),
+		DropIncompatible: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("move aborted, nothing was moved: %w", err)
+	}
+	moved := make(map[int]repository.NodeMoveResult, len(moveResults))
+	for j, result := range moveResults {
+		if result.Err != nil {
+			itemErrors[nodeIndexes[j]] = result.Err
+			continue
+		}
+		moved[nodeIndexes[j]] = result
+	}
+
+	// Report results in input order
+	results := make([]map[string]interface{}, len(rawIDs))
+	lines := make([]string, len(rawIDs))
+	movedCount := 0
+	for i := range rawIDs {
+		if itemErrors[i] != nil {
+			results[i] = map[string]interface{}{"index": i, "composite_id": compositeIDs[i], "success": false, "error": itemErrors[i].Error()}
+			lines[i] = fmt.Sprintf("%d. %s: failed (%v)", i, compositeIDs[i], itemErrors[i])
+			continue
+		}
+
+		movedCount++
+		result := moved[i]
+		dropped := result.DroppedAttributes
+		if dropped == nil {
+			dropped = []string{}
+		}
+		h.recordNodeEvent(ctx, result.NodeID, entity.NodeEventUpdated, map[string]interface{}{"fields": []string{"domain"}, "domain_name": targetDomainName})
+		newCompositeID := h.nodeCompositeID(targetDomainName, result.NodeID)
+		results[i] = map[string]interface{}{
+			"index":              i,
+			"composite_id":       compositeIDs[i],
+			"success":            true,
+			"new_composite_id":   newCompositeID,
+			"dropped_attributes": dropped,
+		}
+		lines[i] = fmt.Sprintf("%d. %s: %s", i, compositeIDs[i], newCompositeID)
+		if len(dropped) > 0 {
+			lines[i] += fmt.Sprintf(" (dropped: %s)", strings.Join(dropped, ", "))
+		}
+	}
+
+	content := []map[string]interface{}{
+		createTextContent(fmt.Sprintf("Moved %d of %d node(s) to domain '%s'\n%s",
+			movedCount, len(rawIDs), targetDomainName, strings.Join(lines, "\n"))),
+	}
+
+	structuredContent := map[string]interface{}{
+		"target_domain_name": targetDomainName,
+		"results":            results,
+		"moved_count":        movedCount,
+		"failed_count":       len(rawIDs) - movedCount,
+	}
+
+	return createMCPResponse(content, structuredContent), nil
+}
+
+// handleFindDuplicateNodes implements the find_duplicate_nodes tool
+func (h *MCPToolHandler) handleFindDuplicateNodes(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	domainName, ok := args["domain_name"].(string)
+	if !ok || domainName == "" {
+		return nil, fmt.Errorf("missing or invalid 'domain_name' parameter")
+	}
+
+	page := 1
+	if p, ok := args["page"].(float64); ok && p >= 1 {
+		page = int(p)
+	}
+	size := constants.DefaultPageSize
+	if s, ok := args["size"].(float64); ok && s >= 1 {
+		size = int(s)
+	}
+	if size > h.maxPageSize {
+		size = h.maxPageSize
+	}
+
+	domain, err := h.dependencies.DomainRepo.GetByName(ctx, domainName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get domain: %w", err)
+	}
+	if domain == nil {
+		return nil, fmt.Errorf("domain not found: %s", domainName)
+	}
+
+	// Group every node by its comparison key, reading nodes in id order
+	groups := make(map[string][]*entity.Node)
+	var keys []string
+	lastNodeID := 0
+	for {
+		nodes, err := h.dependencies.NodeRepo.GetByDomainFromCursor(ctx, domain.ID(), lastNodeID, constants.ScanBatchSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list nodes: %w", err)
+		}
+		for _, node := range nodes {
+			key := valueobject.ComparisonKey(node.URL())
+			if _, ok := groups[key]; !ok {
+				keys = append(keys, key)
+			}
+			groups[key] = append(groups[key], node)
+			lastNodeID = node.ID()
+		}
+		if len(nodes) < constants.ScanBatchSize {
+			break
+		}
+	}
+
+	// Largest clusters first, then by key
+	var clusterKeys []string
+	duplicateNodes := 0
+	for _, key := range keys {
+		if len(groups[key]) > 1 {
+			clusterKeys = append(clusterKeys, key)
+			duplicateNodes += len(groups[key])
+		}
+	}
+	sort.SliceStable(clusterKeys, func(i, j int) bool {
+		if len(groups[clusterKeys[i]]) != len(groups[clusterKeys[j]]) {
+			return len(groups[clusterKeys[i]]) > len(groups[clusterKeys[j]])
+		}
+		return clusterKeys[i] < clusterKeys[j]
+	})
+
+	start := (page - 1) * size
+	if start > len(clusterKeys) {
+		start = len(clusterKeys)
+	}
+	end := start + size
+	if end > len(clusterKeys) {
+		end = len(clusterKeys)
+	}
+
+	clusters := make([]map[string]interface{}, 0, end-start)
+	lines := []string{fmt.Sprintf("Found %d duplicate cluster(s) covering %d node(s) in domain '%s'", len(clusterKeys), duplicateNodes, domainName)}
+	for _, key := range clusterKeys[start:end] {
+		// Members stay in id order, oldest first
+		members := make([]map[string]interface{}, len(groups[key]))
+		compositeIDs := make([]string, len(groups[key]))
+		for i, node := range groups[key] {
+			compositeIDs[i] = h.nodeCompositeID(domainName, node.ID())
+			members[i] = map[string]interface{}{
+				"composite_id": compositeIDs[i],
+				"url":          node.URL(),
+				"title":        node.Title(),
+				"created_at":   node.CreatedAt(),
+			}
+		}
+		clusters = append(clusters, map[string]interface{}{
+			"normalized_url": key,
+			"count":          len(members),
+			"composite_ids":  compositeIDs,
+			"nodes":          members,
+		})
+		lines = append(lines, fmt.Sprintf("- %s (%d): %s", key, len(members), strings.Join(compositeIDs, ", ")))
+	}
+
+	content := []map[string]interface{}{createTextContent(strings.Join(lines, "\n"))}
+	structuredContent := map[string]interface{}{
+		"domain_name":          domainName,
+		"clusters":             clusters,
+		"cluster_count":        len(clusterKeys),
+		"duplicate_node_count": duplicateNodes,
+		"pagination":           newPagination(page, size, len(clusterKeys)),
+	}
+
+	return createMCPResponse(content, structuredContent), nil
+}
+
+// handleFindNodeByURL implements the find_node_by_url tool
+func (h *MCPToolHandler) handleFindNodeByURL(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	// Parse arguments
+	domainName, ok := args["domain_name"].(string)
+	if !ok || domainName == "" {
+		return nil, fmt.Errorf("missing or invalid 'domain_name' parameter")
+	}
+
+	url, ok := args["url"].(string)
+	if !ok || url == "" {
+		return nil, fmt.Errorf("missing or invalid 'url' parameter")
+	}
+
+	includeArchived, _ := args["include_archived"].(bool)
+
+	// Find node by URL
+	node, err := h.dependencies.NodeRepo.GetByURL(ctx, url, domainName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find node: %w", err)
+	}
+	if node == nil {
+		return nil, fmt.Errorf("node not found: %s", url)
+	}
+	// An archived node still holds its URL, so say so rather than invite a duplicate
+	if node.IsArchived() && !includeArchived {
+		return nil, fmt.Errorf("node not found: %s is archived; pass include_archived to find it and restore_node to restore it", url)
+	}
+
+	compositeID := h.nodeCompositeID(domainName, node.ID())
+	content := []map[string]interface{}{
+		createTextContent(fmt.Sprintf("Found node:\nComposite ID: %s\nID: %d\nURL: %s\nTitle: %s\nDescription: %s\nCreated: %s",
+			compositeID, node.ID(), node.URL(), node.Title(), node.Description(),
+			node.CreatedAt().Format("2006-01-02 15:04:05"))),
+	}
+	structuredContent := map[string]interface{}{
+		"composite_id": compositeID,
+		"url":          node.URL(),
+		"title":        node.Title(),
+		"description":  node.Description(),
+		"created_at":   node.CreatedAt().Format(time.RFC3339),
+	}
+
+	if archivedAt := node.ArchivedAt(); archivedAt != nil {
+		content = append(content, createTextContent("Archived: "+archivedAt.Format(time.RFC3339)))
+		structuredContent["archived_at"] = archivedAt.Format(time.RFC3339)
+	}
+
+	return createMCPResponse(content, structuredContent), nil
+}
+
+// handleFindNodesByURLs implements the find_nodes_by_urls tool
+func (h *MCPToolHandler) handleFindNodesByURLs(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	// Parse arguments
+	domainName, ok := args["domain_name"].(string)
+	if !ok || domainName == "" {
+		return nil, fmt.Errorf("missing or invalid 'domain_name' parameter")
+	}
+
+	rawURLs, ok := args["urls"].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("missing or invalid 'urls' parameter")
+	}
+	if len(rawURLs) > constants.MaxURLLookupSize {
+		return nil, fmt.Errorf("too many urls: %d (max %d)", len(rawURLs), constants.MaxURLLookupSize)
+	}
+
+	urls := make([]string, len(rawURLs))
+	for i, raw := range rawURLs {
+		url, ok := raw.(string)
+		if !ok || url == "" {
+			return nil, fmt.Errorf("invalid url at index %d", i)
+		}
+		urls[i] = url
+	}
+
+	// Look all URLs up in a single query
+	nodes, err := h.dependencies.NodeRepo.GetByURLs(ctx, urls, domainName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find nodes: %w", err)
+	}
+
+	nodesByURL := make(map[string]*entity.Node, len(nodes))
+	for _, node := range nodes {
+		nodesByURL[node.URL()] = node
+	}
+
+	// Report results in input order
+	results := make([]map[string]interface{}, len(urls))
+	lines := make([]string, len(urls))
+	found := 0
+	for i, url := range urls {
+		node, ok := nodesByURL[url]
+		if !ok {
+			results[i] = map[string]interface{}{"url": url, "found": false}
+			lines[i] = fmt.Sprintf("%s: not found", url)
+			continue
+		}
+
+		found++
+		compositeID := h.nodeCompositeID(domainName, node.ID())
+		results[i] = map[string]interface{}{"url": url, "found": true, "composite_id": compositeID}
+		lines[i] = fmt.Sprintf("%s: %s", url, compositeID)
+	}
+
+	content := []map[string]interface{}{
+		createTextContent(fmt.Sprintf("Found %d of %d URL(s) in domain '%s'\n%s",
+			found, len(urls), domainName, strings.Join(lines, "\n"))),
+	}
+
+	structuredContent := map[string]interface{}{
+		"domain_name": domainName,
+		"results":     results,
+		"found_count": found,
+	}
+
+	return createMCPResponse(content, structuredContent), nil
+}
+
+// Attribute Management Tools
+
+// handleGetNodeAttributes implements the get_node_attributes tool
+func (h *MCPToolHandler) handleGetNodeAttributes(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	// Parse composite_id argument
+	compositeID, ok := args["composite_id"].(string)
+	if !ok || compositeID == "" {
+		return nil, fmt.Errorf("missing or invalid 'composite_id' parameter")
+	}
+
+	// Parse composite ID to extract node ID
+	nodeID, err := h.parseCompositeID(compositeID)
+	if err != nil {
+		return nil, err
+	}
+
+	includeInherited, _ := args["include_inherited"].(bool)
+
+	format, err := parseAttributeFormat(args)
+	if err != nil {
+		return nil, err
+	}
+
+	// Pagination is optional; without page or size every attribute is returned
+	_, hasPage := args["page"]
+	_, hasSize := args["size"]
+	paginated := hasPage || hasSize
+
+	page := 1
+	if p, ok := args["page"].(float64); ok && p >= 1 {
+		page = int(p)
+	}
+
+	size := constants.DefaultPageSize
+	if s, ok := args["size"].(float64); ok && s >= 1 {
+		size = int(s)
+	}
+	if size > h.maxPageSize {
+		size = h.maxPageSize
+	}
+
+	// Get node to ensure it exists
+	node, err := h.dependencies.NodeRepo.GetByID(ctx, nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node: %w", err)
+	}
+	if node == nil {
+		return nil, fmt.Errorf("node not found: %s", compositeID)
+	}
+
+	// Get node attributes from database
+	nodeAttributes, err := h.dependencies.NodeAttributeRepo.GetByNodeID(ctx, nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node attributes: %w", err)
+	}
+
+	attributes := make([]map[string]interface{}, 0, len(nodeAttributes))
+	ownNames := make(map[string]bool)
+	for _, nodeAttr := range nodeAttributes {
+		// Get attribute definition to show name and type
+		attr, err := h.dependencies.AttributeRepo.GetByID(ctx, nodeAttr.AttributeID())
+		if err != nil || attr == nil {
+			continue // Skip if attribute definition not found
+		}
+
+		ownNames[attr.Name()] = true
+		attributes = append(attributes, map[string]interface{}{
+			"name":        attr.Name(),
+			"type":        attr.Type(),
+			"value":       nodeAttr.Value(),
+			"order_index": nodeAttr.OrderIndex(),
+			"inherited":   false,
+		})
+	}
+
+	if includeInherited {
+		inherited, err := h.collectInheritedAttributes(ctx, nodeID, ownNames)
+		if err != nil {
+			return nil, err
+		}
+		attributes = append(attributes, inherited...)
+	}
+
+	// A page is taken from all attributes, own and inherited, ordered by name and
+	// then order_index
+	totalCount := len(attributes)
+	if paginated {
+		sort.SliceStable(attributes, func(i, j int) bool {
+			if attributes[i]["name"] != attributes[j]["name"] {
+				return attributes[i]["name"].(string) < attributes[j]["name"].(string)
+			}
+			a, _ := attributes[i]["order_index"].(*int)
+			b, _ := attributes[j]["order_index"].(*int)
+			return a != nil && (b == nil || *a < *b)
+		})
+
+		start := (page - 1) * size
+		if start > totalCount {
+			start = totalCount
+		}
+		end := start + size
+		if end > totalCount {
+			end = totalCount
+		}
+		attributes = attributes[start:end]
+	}
+
+	// Build attributes display
+	attributeTexts := make([]string, len(attributes))
+	for i, attr := range attributes {
+		attributeTexts[i] = fmt.Sprintf("• %s (%s): %s", attr["name"], attr["type"], attr["value"])
+		if inheritedFrom, ok := attr["inherited_from"]; ok {
+			attributeTexts[i] += fmt.Sprintf(" [inherited from %s]", inheritedFrom)
+		} else if orderIndex, _ := attr["order_index"].(*int); orderIndex != nil {
+			attributeTexts[i] += fmt.Sprintf(" [order: %d]", *orderIndex)
+		}
+	}
+
+	var text string
+	if totalCount == 0 {
+		text = fmt.Sprintf("No attributes found for node: %s\nURL: %s", node.Title(), node.URL())
+	} else if paginated {
+		text = fmt.Sprintf("Attributes for node: %s\nURL: %s\nPage %d of %d (%d attribute value(s))\n\n%s",
+			node.Title(), node.URL(), page, (totalCount+size-1)/size, totalCount, strings.Join(attributeTexts, "\n"))
+	} else {
+		text = fmt.Sprintf("Attributes for node: %s\nURL: %s\n\n%s",
+			node.Title(), node.URL(), strings.Join(attributeTexts, "\n"))
+	}
+
+	content := []map[string]interface{}{
+		createTextContent(text),
+	}
+
+	structuredContent := map[string]interface{}{
+		"composite_id": compositeID,
+		"attributes":   formatAttributes(attributes, format),
+		"total_count":  totalCount,
+	}
+	if paginated {
+		structuredContent["pagination"] = newPagination(page, size, totalCount)
+	}
+
+	return createMCPResponse(content, structuredContent), nil
+}
+
+// parseAttributeFormat reads the optional 'format' argument of tools returning node
+// attributes: "list" (default) or "map"
+func parseAttributeFormat(args map[string]interface{}) (string, error) {
+	format, _ := args["format"].(string)
+	switch format {
+	case "":
+		return "list", nil
+	case "list", "map":
+		return format, nil
+	default:
+		return "", fmt.Errorf("invalid 'format' parameter: %s (expected list or map)", format)
+	}
+}
+
+// formatAttributes returns node attributes (maps with name, type, value and order_index)
+// in the requested format. The map format keys values by attribute name: tag and
+// ordered_tag attributes map to an array of values (ordered_tag by order_index), other
+// types to their single value. If a single-valued attribute somehow holds several
+// values, the first one wins.
+func formatAttributes(attributes []map[string]interface{}, format string) interface{} {
+	if format != "map" {
+		return attributes
+	}
+
+	ordered := make([]map[string]interface{}, len(attributes))
+	copy(ordered, attributes)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		a, _ := ordered[i]["order_index"].(*int)
+		b, _ := ordered[j]["order_index"].(*int)
+		return a != nil && (b == nil || *a < *b)
+	})
+
+	result := make(map[string]interface{})
+	for _, attr := range ordered {
+		name, _ := attr["name"].(string)
+		attributeType, _ := attr["type"].(string)
+		value, _ := attr["value"].(string)
+
+		if entity.IsMultiValuedAttributeType(attributeType) {
+			values, _ := result[name].([]string)
+			result[name] = append(values, value)
+		} else if _, exists := result[name]; !exists {
+			result[name] = value
+		}
+	}
+	return result
+}
+
+// collectInheritedAttributes walks parent connections breadth-first and returns the
+// attributes of ancestors whose names are not already set closer to the node. The child
+// always overrides its parents; among ancestors the nearest one wins, and at equal depth
+// the parent with the lowest ID wins. Visited nodes are skipped, so connection cycles
+// terminate, and the walk stops after constants.MaxInheritanceDepth levels.
+func (h *MCPToolHandler) collectInheritedAttributes(ctx context.Context, nodeID int, ownNames map[string]bool) ([]map[string]interface{}, error) {
+	claimed := make(map[string]int, len(ownNames))
+	for name := range ownNames {
+		claimed[name] = nodeID
+	}
+
+	visited := map[int]bool{nodeID: true}
+	level := []int{nodeID}
+	var inherited []map[string]interface{}
+
+	for depth := 0; depth < constants.MaxInheritanceDepth && len(level) > 0; depth++ {
+		var next []int
+		for _, childID := range level {
+			parentIDs, err := h.dependencies.NodeConnectionRepo.ListParentIDs(ctx, childID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get parent nodes: %w", err)
+			}
+			for _, parentID := range parentIDs {
+				if visited[parentID] {
+					continue
+				}
+				visited[parentID] = true
+				next = append(next, parentID)
+			}
+		}
+
+		for _, parentID := range next {
+			parentAttributes, err := h.dependencies.NodeAttributeRepo.GetByNodeID(ctx, parentID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get parent node attributes: %w", err)
+			}
+			if len(parentAttributes) == 0 {
+				continue
+			}
+
+			domain, err := h.dependencies.NodeRepo.GetDomainByNodeID(ctx, parentID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get parent domain: %w", err)
+			}
+			parentCompositeID := h.nodeCompositeID(domain.Name(), parentID)
+
+			for _, nodeAttr := range parentAttributes {
+				// Claim a name for the first ancestor that sets it; its other values
+				// (e.g. further tags) are still inherited together
+				if owner, ok := claimed[nodeAttr.Name()]; ok && owner != parentID {
+					continue
+				}
+				claimed[nodeAttr.Name()] = parentID
+
+				attrType := ""
+				if nodeAttr.AttributeType() != nil {
+					attrType = *nodeAttr.AttributeType()
+				}
+				inherited = append(inherited, map[string]interface{}{
+					"name":           nodeAttr.Name(),
+					"type":           attrType,
+					"value":          nodeAttr.Value(),
+					"order_index":    nodeAttr.OrderIndex(),
+					"inherited":      true,
+					"inherited_from": parentCompositeID,
+				})
+			}
+		}
+
+		level = next
+	}
+
+	return inherited, nil
+}
+
+// handleSetNodeAttributes implements the set_node_attributes tool
+func (h *MCPToolHandler) handleSetNodeAttributes(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	// Parse composite_id argument
+	compositeID, ok := args["composite_id"].(string)
+	if !ok || compositeID == "" {
+		return nil, fmt.Errorf("missing or invalid 'composite_id' parameter")
+	}
+
+	// Parse attributes argument
+	attributesRaw, ok := args["attributes"]
+	if !ok {
+		return nil, fmt.Errorf("missing 'attributes' parameter")
+	}
+
+	attributes, ok := attributesRaw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid 'attributes' parameter, expected array")
+	}
+
+	// Parse composite ID to extract node ID
+	nodeID, err := h.parseCompositeID(compositeID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get node to ensure it exists
+	node, err := h.dependencies.NodeRepo.GetByID(ctx, nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node: %w", err)
+	}
+
+	// Convert attributes to use case input
+	var attributeInputs []nodeUseCase.AttributeInput
+	for _, attr := range attributes {
+		attrMap, ok := attr.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid attribute format")
+		}
+
+		name, ok := attrMap["name"].(string)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("attribute must have a valid 'name'")
+		}
+
+		value, ok := attrMap["value"].(string)
+		if !ok || value == "" {
+			return nil, fmt.Errorf("attribute must have a valid 'value'")
+		}
+
+		var orderIndex *int
+		if orderIndexRaw, exists := attrMap["order_index"]; exists && orderIndexRaw != nil {
+			if orderIndexFloat, ok := orderIndexRaw.(float64); ok {
+				orderIndexInt := int(orderIndexFloat)
+				orderIndex = &orderIndexInt
+			}
+		}
+
+		attributeInputs = append(attributeInputs, nodeUseCase.AttributeInput{
+			Name:       name,
+			Value:      value,
+			OrderIndex: orderIndex,
+		})
+	}
+
+	if dryRun, _ := args["dry_run"].(bool); dryRun {
+		preview, err := h.dependencies.SetNodeAttributesUC.Preview(ctx, nodeID, attributeInputs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to preview node attributes: %w", err)
+		}
+		return attributeChangePreviewResponse(compositeID, preview), nil
+	}
+
+	// Execute the use case
+	err = h.dependencies.SetNodeAttributesUC.Execute(ctx, nodeID, attributeInputs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set node attributes: %w", err)
+	}
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": fmt.Sprintf("Successfully set %d attributes for node: %s\nURL: %s",
+					len(attributes), node.Title(), node.URL()),
+			},
+		},
+	}, nil
+}
+
+// attributeChangePreviewResponse formats the result of a set_node_attributes dry run
+func attributeChangePreviewResponse(compositeID string, preview *nodeUseCase.AttributeChangePreview) map[string]interface{} {
+	changes := func(list []nodeUseCase.AttributeChange, withOld, withNew bool) []map[string]interface{} {
+		structured := []map[string]interface{}{}
+		for _, change := range list {
+			item := map[string]interface{}{"name": change.Name}
+			if withOld {
+				item["old_values"] = change.OldValues
+			}
+			if withNew {
+				item["new_values"] = change.NewValues
+			}
+			structured = append(structured, item)
+		}
+		return structured
+	}
+
+	lines := []string{fmt.Sprintf("Dry run for %s: %d added, %d changed, %d unchanged, %d removed, %d invalid",
+		compositeID, len(preview.Added), len(preview.Changed), len(preview.Unchanged), len(preview.Removed), len(preview.Errors))}
+	for _, change := range preview.Added {
+		lines = append(lines, fmt.Sprintf("+ %s: %s", change.Name, strings.Join(change.NewValues, ", ")))
+	}
+	for _, change := range preview.Changed {
+		lines = append(lines, fmt.Sprintf("~ %s: %s → %s", change.Name, strings.Join(change.OldValues, ", "), strings.Join(change.NewValues, ", ")))
+	}
+	for _, change := range preview.Removed {
+		lines = append(lines, fmt.Sprintf("- %s: %s", change.Name, strings.Join(change.OldValues, ", ")))
+	}
+
+	errorList := []map[string]interface{}{}
+	for _, invalid := range preview.Errors {
+		lines = append(lines, fmt.Sprintf("! %s: %v", invalid.Name, invalid.Err))
+		errorList = append(errorList, map[string]interface{}{
+			"name":  invalid.Name,
+			"value": invalid.Value,
+			"error": invalid.Err.Error(),
+		})
+	}
+
+	structuredContent := map[string]interface{}{
+		"composite_id": compositeID,
+		"dry_run":      true,
+		"valid":        len(preview.Errors) == 0,
+		"added":        changes(preview.Added, false, true),
+		"changed":      changes(preview.Changed, true, true),
+		"unchanged":    changes(preview.Unchanged, true, false),
+		"removed":      changes(preview.Removed, true, false),
+		"errors":       errorList,
+	}
+
+	return createMCPResponse([]map[string]interface{}{createTextContent(strings.Join(lines, "\n"))}, structuredContent)
+}
+
+// handleClearNodeAttributes implements the clear_node_attributes tool
+func (h *MCPToolHandler) handleClearNodeAttributes(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	// Parse composite_id argument
+	compositeID, ok := args["composite_id"].(string)
+	if !ok || compositeID == "" {
+		return nil, fmt.Errorf("missing or invalid 'composite_id' parameter")
+	}
+
+	nodeID, err := h.parseCompositeID(compositeID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid composite_id: %w", err)
+	}
+
+	// Verify node exists
+	node, err := h.dependencies.NodeRepo.GetByID(ctx, nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node: %w", err)
+	}
+	if node == nil {
+		return nil, fmt.Errorf("node not found: %s", compositeID)
+	}
+
+	removed, err := h.dependencies.NodeAttributeRepo.DeleteAllByNode(ctx, nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to clear node attributes: %w", err)
+	}
+
+	content := []map[string]interface{}{
+		createTextContent(fmt.Sprintf("Cleared %d attribute(s) from node: %s\nURL: %s",
+			removed, compositeID, node.URL())),
+	}
+
+	structuredContent := map[string]interface{}{
+		"composite_id":  compositeID,
+		"removed_count": removed,
+	}
+
+	return createMCPResponse(content, structuredContent), nil
+}
+
+// handleCopyNodeAttributes implements the copy_node_attributes tool
+func (h *MCPToolHandler) handleCopyNodeAttributes(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	// Parse arguments
+	sourceCompositeID, ok := args["source_composite_id"].(string)
+	if !ok || sourceCompositeID == "" {
+		return nil, fmt.Errorf("missing or invalid 'source_composite_id' parameter")
+	}
+
+	targetCompositeID, ok := args["target_composite_id"].(string)
+	if !ok || targetCompositeID == "" {
+		return nil, fmt.Errorf("missing or invalid 'target_composite_id' parameter")
+	}
+
+	mode, _ := args["mode"].(string)
+	switch mode {
+	case "":
+		mode = "merge"
+	case "merge", "replace":
+	default:
+		return nil, fmt.Errorf("invalid 'mode' parameter: %s (expected merge or replace)", mode)
+	}
+
+	sourceNodeID, err := h.parseCompositeID(sourceCompositeID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid source_composite_id: %w", err)
+	}
+	targetNodeID, err := h.parseCompositeID(targetCompositeID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid target_composite_id: %w", err)
+	}
+	if sourceNodeID == targetNodeID {
+		return nil, fmt.Errorf("source and target are the same node: %s", targetCompositeID)
+	}
+
+	// Attribute definitions belong to a domain, so both nodes must share one
+	domains := make([]*entity.Domain, 2)
+	for i, nodeID := range []int{sourceNodeID, targetNodeID} {
+		node, err := h.dependencies.NodeRepo.GetByID(ctx, nodeID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get node: %w", err)
+		}
+		if node == nil {
+			return nil, fmt.Errorf("node not found: %s", []string{sourceCompositeID, targetCompositeID}[i])
+		}
+		if domains[i], err = h.dependencies.NodeRepo.GetDomainByNodeID(ctx, nodeID); err != nil {
+			return nil, fmt.Errorf("failed to get domain for node: %w", err)
+		}
+	}
+	if domains[0].ID() != domains[1].ID() {
+		return nil, fmt.Errorf("source node is in domain '%s' but target node is in domain '%s'; attributes can only be copied within a domain",
+			domains[0].Name(), domains[1].Name())
+	}
+
+	sourceAttributes, err := h.dependencies.NodeAttributeRepo.GetByNodeID(ctx, sourceNodeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get source node attributes: %w", err)
+	}
+
+	// In merge mode the target keeps its attributes; a single-valued attribute it
+	// already has keeps the target's value
+	var inputs []nodeUseCase.AttributeInput
+	targetSingleValued := make(map[string]bool)
+	if mode == "merge" {
+		targetAttributes, err := h.dependencies.NodeAttributeRepo.GetByNodeID(ctx, targetNodeID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get target node attributes: %w", err)
+		}
+		for _, nodeAttr := range targetAttributes {
+			inputs = append(inputs, nodeUseCase.AttributeInput{Name: nodeAttr.Name(), Value: nodeAttr.Value(), OrderIndex: nodeAttr.OrderIndex()})
+			if nodeAttr.AttributeType() != nil && !entity.IsMultiValuedAttributeType(*nodeAttr.AttributeType()) {
+				targetSingleValued[nodeAttr.Name()] = true
+			}
+		}
+	}
+
+	copied := 0
+	skipped := []string{}
+	for _, nodeAttr := range sourceAttributes {
+		if targetSingleValued[nodeAttr.Name()] {
+			skipped = append(skipped, nodeAttr.Name())
+			continue
+		}
+		inputs = append(inputs, nodeUseCase.AttributeInput{Name: nodeAttr.Name(), Value: nodeAttr.Value(), OrderIndex: nodeAttr.OrderIndex()})
+		copied++
+	}
+
+	if err := h.dependencies.SetNodeAttributesUC.Execute(ctx, targetNodeID, inputs); err != nil {
+		return nil, fmt.Errorf("failed to set node attributes: %w", err)
+	}
+
+	text := fmt.Sprintf("Copied %d attribute value(s) from %s to %s (%s)", copied, sourceCompositeID, targetCompositeID, mode)
+	if len(skipped) > 0 {
+		text += fmt.Sprintf("\nKept the target's own value for: %s", strings.Join(skipped, ", "))
+	}
+
+	content := []map[string]interface{}{
+		createTextContent(text),
+	}
+
+	structuredContent := map[string]interface{}{
+		"source_composite_id": sourceCompositeID,
+		"target_composite_id": targetCompositeID,
+		"mode":                mode,
+		"copied_count":        copied,
+		"skipped":             skipped,
+	}
+
+	return createMCPResponse(content, structuredContent), nil
+}
+
+// parseNodeTagArguments parses the arguments shared by add_node_tag and remove_node_tag
+func (h *MCPToolHandler) parseNodeTagArguments(args map[string]interface{}) (string, int, string, string, error) {
+	compositeID, ok := args["composite_id"].(string)
+	if !ok || compositeID == "" {
+		return "", 0, "", "", fmt.Errorf("missing or invalid 'composite_id' parameter")
+	}
+
+	attributeName, ok := args["attribute_name"].(string)
+	if !ok || attributeName == "" {
+		return "", 0, "", "", fmt.Errorf("missing or invalid 'attribute_name' parameter")
+	}
+
+	value, ok := args["value"].(string)
+	if !ok || value == "" {
+		return "", 0, "", "", fmt.Errorf("missing or invalid 'value' parameter")
+	}
+
+	nodeID, err := h.parseCompositeID(compositeID)
+	if err != nil {
+		return "", 0, "", "", err
+	}
+
+	return compositeID, nodeID, attributeName, value, nil
+}
+
+// nodeAttributeValues returns a node's values of one attribute in read-back order
+func (h *MCPToolHandler) nodeAttributeValues(ctx context.Context, nodeID int, attributeName string) ([]string, error) {
+	nodeAttributes, err := h.dependencies.NodeAttributeRepo.GetByNodeID(ctx, nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node attributes: %w", err)
+	}
+
+	values := []string{}
+	for _, nodeAttr := range nodeAttributes {
+		if nodeAttr.Name() == attributeName {
+			values = append(values, nodeAttr.Value())
+		}
+	}
+	return values, nil
+}
+
+// handleAddNodeTag implements the add_node_tag tool
+func (h *MCPToolHandler) handleAddNodeTag(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	compositeID, nodeID, attributeName, value, err := h.parseNodeTagArguments(args)
+	if err != nil {
+		return nil, err
+	}
+	attrType, _ := args["type"].(string)
+
+	node, err := h.dependencies.NodeRepo.GetByID(ctx, nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node: %w", err)
+	}
+	if node == nil {
+		return nil, fmt.Errorf("node not found: %s", compositeID)
+	}
+	domain, err := h.dependencies.NodeRepo.GetDomainByNodeID(ctx, nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get domain for node: %w", err)
+	}
+
+	// With a type, a missing attribute definition is created first
+	attr, err := h.dependencies.AttributeRepo.GetByName(ctx, domain.ID(), attributeName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attribute: %w", err)
+	}
+	attributeCreated := false
+	switch {
+	case attr == nil && attrType != "":
+		if !entity.IsMultiValuedAttributeType(attrType) {
+			return nil, fmt.Errorf("invalid 'type' parameter: %s (expected tag or ordered_tag)", attrType)
+		}
+		if _, err := h.dependencies.CreateAttributeUC.Execute(ctx, &request.CreateAttributeRequest{
+			DomainID: domain.ID(),
+			Name:     attributeName,
+			Type:     attrType,
+		}); err != nil {
+			return nil, fmt.Errorf("failed to create domain attribute: %w", err)
+		}
+		attributeCreated = true
+	case attr != nil && attrType != "" && string(attr.Type()) != attrType:
+		return nil, fmt.Errorf("attribute '%s' already exists in domain '%s' with type '%s'", attributeName, domain.Name(), attr.Type())
+	}
+
+	added, err := h.dependencies.SetNodeAttributesUC.AddValue(ctx, nodeID, attributeName, value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add tag: %w", err)
+	}
+
+	values, err := h.nodeAttributeValues(ctx, nodeID, attributeName)
+	if err != nil {
+		return nil, err
+	}
+
+	text := fmt.Sprintf("Added %s '%s' to %s", attributeName, value, compositeID)
+	if !added {
+		text = fmt.Sprintf("%s already has %s '%s'", compositeID, attributeName, value)
+	}
+	if attributeCreated {
+		text += fmt.Sprintf(" (created %s attribute '%s')", attrType, attributeName)
+	}
+	text += fmt.Sprintf("\n%s: %s", attributeName, strings.Join(values, ", "))
+
+	content := []map[string]interface{}{
+		createTextContent(text),
+	}
+
+	structuredContent := map[string]interface{}{
+		"composite_id":      compositeID,
+		"attribute_name":    attributeName,
+		"added":             added,
+		"attribute_created": attributeCreated,
+		"values":            values,
+	}
+
+	return createMCPResponse(content, structuredContent), nil
+}
+
+// handleRemoveNodeTag implements the remove_node_tag tool
+func (h *MCPToolHandler) handleRemoveNodeTag(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	compositeID, nodeID, attributeName, value, err := h.parseNodeTagArguments(args)
+	if err != nil {
+		return nil, err
+	}
+
+	node, err := h.dependencies.NodeRepo.GetByID(ctx, nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node: %w", err)
+	}
+	if node == nil {
+		return nil, fmt.Errorf("node not found: %s", compositeID)
+	}
+
+	removed, err := h.dependencies.SetNodeAttributesUC.RemoveValue(ctx, nodeID, attributeName, value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove tag: %w", err)
+	}
+
+	values, err := h.nodeAttributeValues(ctx, nodeID, attributeName)
+	if err != nil {
+		return nil, err
+	}
+
+	text := fmt.Sprintf("Removed %s '%s' from %s", attributeName, value, compositeID)
+	if !removed {
+		text = fmt.Sprintf("%s does not have %s '%s'", compositeID, attributeName, value)
+	}
+	text += fmt.Sprintf("\n%s: %s", attributeName, strings.Join(values, ", "))
+
+	content := []map[string]interface{}{
+		createTextContent(text),
+	}
+
+	structuredContent := map[string]interface{}{
+		"composite_id":   compositeID,
+		"attribute_name": attributeName,
+		"removed":        removed,
+		"values":         values,
+	}
+
+	return createMCPResponse(content, structuredContent), nil
+}
+
+// handleRenderNodeAttribute implements the render_node_attribute tool. Markdown values
+// come with sanitized HTML and image values with their resolved URL and mime type;
+// other types are returned as stored.
+func (h *MCPToolHandler) handleRenderNodeAttribute(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	compositeID, ok := args["composite_id"].(string)
+	if !ok || compositeID == "" {
+		return nil, fmt.Errorf("missing or invalid 'composite_id' parameter")
+	}
+
+	attributeName, ok := args["attribute_name"].(string)
+	if !ok || attributeName == "" {
+		return nil, fmt.Errorf("missing or invalid 'attribute_name' parameter")
+	}
+
+	nodeID, err := h.parseCompositeID(compositeID)
+	if err != nil {
+		return nil, err
+	}
+
+	domain, err := h.dependencies.NodeRepo.GetDomainByNodeID(ctx, nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get domain for node: %w", err)
+	}
+	if domain == nil {
+		return nil, fmt.Errorf("node not found: %s", compositeID)
+	}
+
+	attr, err := h.dependencies.AttributeRepo.GetByName(ctx, domain.ID(), attributeName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attribute: %w", err)
+	}
+	if attr == nil {
+		return nil, fmt.Errorf("attribute '%s' not defined in domain '%s'", attributeName, domain.Name())
+	}
+
+	values, err := h.nodeAttributeValues(ctx, nodeID, attributeName)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("%s has no value for attribute '%s'", compositeID, attributeName)
+	}
+
+	structuredContent := map[string]interface{}{
+		"composite_id":   compositeID,
+		"attribute_name": attributeName,
+		"type":           string(attr.Type()),
+	}
+	var text string
+
+	switch string(attr.Type()) {
+	case "markdown":
+		html, err := render.Markdown(values[0])
+		if err != nil {
+			return nil, err
+		}
+		structuredContent["value"] = values[0]
+		structuredContent["html"] = html
+		text = fmt.Sprintf("%s of %s rendered as HTML:\n%s", attributeName, compositeID, html)
+	case "image":
+		info, err := render.Image(values[0])
+		if err != nil {
+			return nil, fmt.Errorf("failed to read image value: %w", err)
+		}
+		structuredContent["value"] = values[0]
+		structuredContent["image"] = map[string]interface{}{
+			"source":     info.Source,
+			"url":        info.URL,
+			"mime_type":  info.MimeType,
+			"size_bytes": info.SizeBytes,
+		}
+		mimeType := info.MimeType
+		if mimeType == "" {
+			mimeType = "unknown type"
+		}
+		if info.Source == "data" {
+			text = fmt.Sprintf("%s of %s is an embedded %s image (%d bytes)", attributeName, compositeID, mimeType, info.SizeBytes)
+		} else {
+			text = fmt.Sprintf("%s of %s is an image at %s (%s)", attributeName, compositeID, info.URL, mimeType)
+		}
+	default:
+		if entity.IsMultiValuedAttributeType(string(attr.Type())) {
+			structuredContent["values"] = values
+		} else {
+			structuredContent["value"] = values[0]
+		}
+		text = fmt.Sprintf("%s of %s (%s, no rendering): %s", attributeName, compositeID, attr.Type(), strings.Join(values, ", "))
+	}
+
+	content := []map[string]interface{}{
+		createTextContent(text),
+	}
+
+	return createMCPResponse(content, structuredContent), nil
+}
+
+// handleDedupeNodeAttributes implements the dedupe_node_attributes tool
+func (h *MCPToolHandler) handleDedupeNodeAttributes(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	// Parse composite_id argument
+	compositeID, ok := args["composite_id"].(string)
+	if !ok || compositeID == "" {
+		return nil, fmt.Errorf("missing or invalid 'composite_id' parameter")
+	}
+
+	nodeID, err := h.parseCompositeID(compositeID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid composite_id: %w", err)
+	}
+
+	// Verify node exists
+	node, err := h.dependencies.NodeRepo.GetByID(ctx, nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node: %w", err)
+	}
+	if node == nil {
+		return nil, fmt.Errorf("node not found: %s", compositeID)
+	}
+
+	removed, err := h.dependencies.NodeAttributeRepo.DeleteDuplicates(ctx, nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dedupe node attributes: %w", err)
+	}
+
+	content := []map[string]interface{}{
+		createTextContent(fmt.Sprintf("Removed %d duplicate attribute value(s) from node: %s\nURL: %s",
+			removed, compositeID, node.URL())),
+	}
+
+	structuredContent := map[string]interface{}{
+		"composite_id":  compositeID,
+		"removed_count": removed,
+	}
+
+	return createMCPResponse(content, structuredContent), nil
+}
+
+// handleGetAllAttributes implements the get_all_attributes tool
+func (h *MCPToolHandler) handleGetAllAttributes(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	// Parse domain_name argument
+	domainName, ok := args["domain_name"].(string)
+	if !ok || domainName == "" {
+		return nil, fmt.Errorf("missing or invalid 'domain_name' parameter")
+	}
+
+	// Pagination is by node: each page covers up to 'size' nodes and all their attributes
+	page := 1
+	if p, ok := args["page"].(float64); ok && p >= 1 {
+		page = int(p)
+	}
+
+	size := constants.DefaultPageSize
+	if s, ok := args["size"].(float64); ok && s >= 1 {
+		size = int(s)
+	}
+	if size > h.maxPageSize {
+		size = h.maxPageSize
+	}
+
+	domain, err := h.dependencies.DomainRepo.GetByName(ctx, domainName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get domain: %w", err)
+	}
+	if domain == nil {
+		return nil, fmt.Errorf("domain '%s' not found", domainName)
+	}
+
+	nodes, totalNodes, err := h.dependencies.NodeRepo.List(ctx, domainName, page, size, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	// Stop early if the client gave up while the node page was loading
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	nodeIDs := make([]int, len(nodes))
+	for i, node := range nodes {
+		nodeIDs[i] = node.ID()
+	}
+
+	attributesByNode, err := h.dependencies.NodeAttributeRepo.GetByNodeIDs(ctx, nodeIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node attributes: %w", err)
+	}
+
+	tuples := []map[string]interface{}{}
+	for _, node := range nodes {
+		compositeID := h.nodeCompositeID(domainName, node.ID())
+		for _, attr := range attributesByNode[node.ID()] {
+			tuples = append(tuples, map[string]interface{}{
+				"composite_id":   compositeID,
+				"attribute_name": attr.Name(),
+				"value":          attr.Value(),
+				"order_index":    attr.OrderIndex(),
+			})
+		}
+	}
+
+	totalPages := (totalNodes + size - 1) / size
+
+	content := []map[string]interface{}{
+		createTextContent(fmt.Sprintf("Domain '%s': %d attribute value(s) across %d node(s) (page %d of %d)",
+			domainName, len(tuples), len(nodes), page, totalPages)),
+	}
+
+	structuredContent := map[string]interface{}{
+		"domain_name": domainName,
+		"attributes":  tuples,
+		"page":        page,
+		"size":        size,
+		"total_nodes": totalNodes,
+		"total_pages": totalPages,
+		"has_more":    page < totalPages,
+		"pagination":  newPagination(page, size, totalNodes),
+	}
+
+	return createMCPResponse(content, structuredContent), nil
+}
+
+// Domain Schema Management Tools
+
+// handleListDomainAttributes implements the list_domain_attributes tool
+func (h *MCPToolHandler) handleListDomainAttributes(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	// Parse domain_name argument
+	domainName, ok := args["domain_name"].(string)
+	if !ok || domainName == "" {
+		return nil, fmt.Errorf("missing or invalid 'domain_name' parameter")
+	}
+
+	// Get domain first to get domain ID
+	domain, err := h.dependencies.DomainRepo.GetByName(ctx, domainName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get domain: %w", err)
+	}
+
+	// Get attributes for this domain
+	attributes, err := h.dependencies.AttributeRepo.ListByDomainID(ctx, domain.ID())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list domain attributes: %w", err)
+	}
+
+	// Convert to MCP response format
+	content := []map[string]interface{}{}
+	for _, attr := range attributes {
+		content = append(content, map[string]interface{}{
+			"type": "text",
+			"text": fmt.Sprintf("Attribute: %s\nType: %s\nDescription: %s\nCreated: %s",
+				attr.Name(), attr.Type(), attr.Description(),
+				attr.CreatedAt().Format("2006-01-02 15:04:05")),
+		})
+	}
+
+	if len(content) == 0 {
+		content = append(content, map[string]interface{}{
+			"type": "text",
+			"text": fmt.Sprintf("No attributes defined for domain '%s'", domainName),
+		})
+	}
+
+	return map[string]interface{}{
+		"content": content,
+	}, nil
+}
+
+// handleGetDomainSchema implements the get_domain_schema tool
+func (h *MCPToolHandler) handleGetDomainSchema(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	// Parse domain_name argument
+	domainName, ok := args["domain_name"].(string)
+	if !ok || domainName == "" {
+		return nil, fmt.Errorf("missing or invalid 'domain_name' parameter")
+	}
+
+	// Get domain first to get domain ID
+	domain, err := h.dependencies.DomainRepo.GetByName(ctx, domainName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get domain: %w", err)
+	}
+	if domain == nil {
+		return nil, fmt.Errorf("domain '%s' not found", domainName)
+	}
+
+	// Get attributes for this domain
+	attributes, err := h.dependencies.AttributeRepo.ListByDomainID(ctx, domain.ID())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list domain attributes: %w", err)
+	}
+
+	// Build machine-readable attribute definitions. Only active templates constrain
+	// values, so allowed_values is reported when one lists them.
+	definitions := []map[string]interface{}{}
+	for _, attr := range attributes {
+		definition := map[string]interface{}{
+			"name":        attr.Name(),
+			"type":        attr.Type(),
+			"description": attr.Description(),
+		}
+		allowed, err := h.dependencies.TemplateService.AllowedAttributeValues(ctx, domainName, attr.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read allowed values of attribute '%s': %w", attr.Name(), err)
+		}
+		if allowed != nil {
+			definition["allowed_values"] = allowed
+		}
+		definitions = append(definitions, definition)
+	}
+
+	content := []map[string]interface{}{
+		createTextContent(fmt.Sprintf("Schema for domain '%s': %d attribute(s)", domainName, len(definitions))),
+	}
+
+	structuredContent := map[string]interface{}{
+		"domain_name": domainName,
+		"attributes":  definitions,
+	}
+
+	return createMCPResponse(content, structuredContent), nil
+}
+
+// handleDiffDomainSchemas implements the diff_domain_schemas tool
+func (h *MCPToolHandler) handleDiffDomainSchemas(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	domainA, ok := args["domain_a"].(string)
+	if !ok || domainA == "" {
+		return nil, fmt.Errorf("missing or invalid 'domain_a' parameter")
+	}
+	domainB, ok := args["domain_b"].(string)
+	if !ok || domainB == "" {
+		return nil, fmt.Errorf("missing or invalid 'domain_b' parameter")
+	}
+
+	attributesA, err := h.domainAttributesByName(ctx, domainA)
+	if err != nil {
+		return nil, err
+	}
+	attributesB, err := h.domainAttributesByName(ctx, domainB)
+	if err != nil {
+		return nil, err
+	}
+
+	definition := func(attr *entity.Attribute) map[string]interface{} {
+		return map[string]interface{}{
+			"name":        attr.Name(),
+			"type":        attr.Type(),
+			"description": attr.Description(),
+		}
+	}
+
+	names := make([]string, 0, len(attributesA)+len(attributesB))
+	for name := range attributesA {
+		names = append(names, name)
+	}
+	for name := range attributesB {
+		if _, ok := attributesA[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	onlyInA := []map[string]interface{}{}
+	onlyInB := []map[string]interface{}{}
+	changed := []map[string]interface{}{}
+	identical := []string{}
+	for _, name := range names {
+		attrA, inA := attributesA[name]
+		attrB, inB := attributesB[name]
+		switch {
+		case !inB:
+			onlyInA = append(onlyInA, definition(attrA))
+		case !inA:
+			onlyInB = append(onlyInB, definition(attrB))
+		default:
+			var differences []string
+			if attrA.Type() != attrB.Type() {
+				differences = append(differences, "type")
+			}
+			if attrA.Description() != attrB.Description() {
+				differences = append(differences, "description")
+			}
+			if len(differences) == 0 {
+				identical = append(identical, name)
+				continue
+			}
+			changed = append(changed, map[string]interface{}{
+				"name":        name,
+				"a":           definition(attrA),
+				"b":           definition(attrB),
+				"differences": differences,
+			})
+		}
+	}
+
+	lines := []string{fmt.Sprintf("Schema diff '%s' vs '%s': %d only in %s, %d only in %s, %d differing, %d identical",
+		domainA, domainB, len(onlyInA), domainA, len(onlyInB), domainB, len(changed), len(identical))}
+	for _, attr := range onlyInA {
+		lines = append(lines, fmt.Sprintf("- %s (%s): only in %s", attr["name"], attr["type"], domainA))
+	}
+	for _, attr := range onlyInB {
+		lines = append(lines, fmt.Sprintf("+ %s (%s): only in %s", attr["name"], attr["type"], domainB))
+	}
+	for _, diff := range changed {
+		lines = append(lines, fmt.Sprintf("~ %s: %s differ", diff["name"], strings.Join(diff["differences"].([]string), " and ")))
+	}
+
+	content := []map[string]interface{}{createTextContent(strings.Join(lines, "\n"))}
+	structuredContent := map[string]interface{}{
+		"domain_a":  domainA,
+		"domain_b":  domainB,
+		"only_in_a": onlyInA,
+		"only_in_b": onlyInB,
+		"changed":   changed,
+		"identical": identical,
+	}
+
+	return createMCPResponse(content, structuredContent), nil
+}
+
+// handleListAllAttributesAcrossDomains implements the list_all_attributes_across_domains
+// tool. Definitions are grouped by attribute name; a name defined with more than one
+// type is flagged as a type mismatch.
+func (h *MCPToolHandler) handleListAllAttributesAcrossDomains(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	mismatchesOnly, _ := args["mismatches_only"].(bool)
+
+	definitions, err := h.dependencies.AttributeRepo.ListAcrossDomains(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list attributes: %w", err)
+	}
+
+	// Definitions arrive ordered by name, so each group is a run of equal names
+	groups := []map[string]interface{}{}
+	mismatches := []string{}
+	domainNames := map[string]bool{}
+	var lines []string
+	for start := 0; start < len(definitions); {
+		end := start
+		for end < len(definitions) && definitions[end].Name == definitions[start].Name {
+			end++
+		}
+		run := definitions[start:end]
+		start = end
+
+		name := run[0].Name
+		domains := make([]map[string]interface{}, len(run))
+		domainList := make([]string, len(run))
+		typeSet := map[string]bool{}
+		for i, def := range run {
+			domainNames[def.DomainName] = true
+			typeSet[def.Type] = true
+			domains[i] = map[string]interface{}{
+				"domain_name": def.DomainName,
+				"type":        def.Type,
+				"description": def.Description,
+				"value_count": def.ValueCount,
+			}
+			domainList[i] = fmt.Sprintf("%s (%s)", def.DomainName, def.Type)
+		}
+		types := make([]string, 0, len(typeSet))
+		for attrType := range typeSet {
+			types = append(types, attrType)
+		}
+		sort.Strings(types)
+
+		typeMismatch := len(types) > 1
+		if typeMismatch {
+			mismatches = append(mismatches, name)
+		}
+		if mismatchesOnly && !typeMismatch {
+			continue
+		}
+
+		groups = append(groups, map[string]interface{}{
+			"name":          name,
+			"types":         types,
+			"type_mismatch": typeMismatch,
+			"domain_count":  len(run),
+			"domains":       domains,
+		})
+		line := fmt.Sprintf("- %s: %s", name, strings.Join(domainList, ", "))
+		if typeMismatch {
+			line += " [TYPE MISMATCH]"
+		}
+		lines = append(lines, line)
+	}
+
+	text := fmt.Sprintf("%d attribute name(s) across %d domain(s), %d with mismatched types",
+		len(groups), len(domainNames), len(mismatches))
+	if mismatchesOnly {
+		text = fmt.Sprintf("%d attribute name(s) defined with mismatched types across %d domain(s)", len(groups), len(domainNames))
+	}
+	if len(lines) > 0 {
+		text += "\n" + strings.Join(lines, "\n")
+	}
+
+	content := []map[string]interface{}{
+		createTextContent(text),
+	}
+
+	structuredContent := map[string]interface{}{
+		"attributes":      groups,
+		"type_mismatches": mismatches,
+		"domain_count":    len(domainNames),
+	}
+
+	return createMCPResponse(content, structuredContent), nil
+}
+
+// domainAttributesByName returns a domain's attribute definitions keyed by name
+func (h *MCPToolHandler) domainAttributesByName(ctx context.Context, domainName string) (map[string]*entity.Attribute, error) {
+	domain, err := h.dependencies.DomainRepo.GetByName(ctx, domainName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get domain: %w", err)
+	}
+	if domain == nil {
+		return nil, fmt.Errorf("domain '%s' not found", domainName)
+	}
+
+	attributes, err := h.dependencies.AttributeRepo.ListByDomainID(ctx, domain.ID())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list attributes of '%s': %w", domainName, err)
+	}
+
+	byName := make(map[string]*entity.Attribute, len(attributes))
+	for _, attr := range attributes {
+		byName[attr.Name()] = attr
+	}
+	return byName, nil
+}
+
+// handleCreateDomainAttribute implements the create_domain_attribute tool
+func (h *MCPToolHandler) handleCreateDomainAttribute(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	// Parse arguments
+	domainName, ok := args["domain_name"].(string)
+	if !ok || domainName == "" {
+		return nil, fmt.Errorf("missing or invalid 'domain_name' parameter")
+	}
+
+	name, ok := args["name"].(string)
+	if !ok || name == "" {
+		return nil, fmt.Errorf("missing or invalid 'name' parameter")
+	}
+
+	attrType, ok := args["type"].(string)
+	if !ok || attrType == "" {
+		return nil, fmt.Errorf("missing or invalid 'type' parameter")
+	}
+
+	description := ""
+	if d, ok := args["description"].(string); ok {
+		description = d
+	}
+
+	// Get domain first to get domain ID
+	domain, err := h.dependencies.DomainRepo.GetByName(ctx, domainName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get domain: %w", err)
+	}
+
+	// Create attribute request DTO
+	createReq := &request.CreateAttributeRequest{
+		DomainID:    domain.ID(),
+		Name:        name,
+		Type:        attrType,
+		Description: description,
+	}
+
+	// Execute use case
+	result, err := h.dependencies.CreateAttributeUC.Execute(ctx, createReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create domain attribute: %w", err)
+	}
+
+	// Convert to MCP response format
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": fmt.Sprintf("Successfully created domain attribute:\nDomain: %s\nName: %s\nType: %s\nDescription: %s\nCreated: %s",
+					domainName, result.Name, result.Type, result.Description,
+					result.CreatedAt.Format("2006-01-02 15:04:05")),
+			},
+		},
+	}, nil
+}
+
+// handleGetDomainAttribute implements the get_domain_attribute tool
+func (h *MCPToolHandler) handleGetDomainAttribute(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	// Parse arguments
+	domainName, ok := args["domain_name"].(string)
+	if !ok || domainName == "" {
+		return nil, fmt.Errorf("missing or invalid 'domain_name' parameter")
+	}
+
+	attributeName, ok := args["attribute_name"].(string)
+	if !ok || attributeName == "" {
+		return nil, fmt.Errorf("missing or invalid 'attribute_name' parameter")
+	}
+
+	// Get domain first to get domain ID
+	domain, err := h.dependencies.DomainRepo.GetByName(ctx, domainName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get domain: %w", err)
+	}
+
+	// Get all attributes for this domain and find the specific one
+	attributes, err := h.dependencies.AttributeRepo.ListByDomainID(ctx, domain.ID())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list domain attributes: %w", err)
+	}
+
+	// Find the specific attribute
+	var foundAttribute *entity.Attribute
+	for _, attr := range attributes {
+		if attr.Name() == attributeName {
+			foundAttribute = attr
+			break
+		}
+	}
+
+	if foundAttribute == nil {
+		return nil, fmt.Errorf("attribute '%s' not found in domain '%s'", attributeName, domainName)
+	}
+
+	// Convert to MCP response format
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": fmt.Sprintf("Domain Attribute Details:\nDomain: %s\nName: %s\nType: %s\nDescription: %s\nCreated: %s",
+					domainName, foundAttribute.Name(), foundAttribute.Type(), foundAttribute.Description(),
+					foundAttribute.CreatedAt().Format("2006-01-02 15:04:05")),
+			},
+		},
+	}, nil
+}
+
+// handleUpdateDomainAttribute implements the update_domain_attribute tool
+func (h *MCPToolHandler) handleUpdateDomainAttribute(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	// Parse arguments
+	domainName, ok := args["domain_name"].(string)
+	if !ok || domainName == "" {
+		return nil, fmt.Errorf("missing or invalid 'domain_name' parameter")
+	}
+
+	attributeName, ok := args["attribute_name"].(string)
+	if !ok || attributeName == "" {
+		return nil, fmt.Errorf("missing or invalid 'attribute_name' parameter")
+	}
+
+	// Get domain first to get domain ID
+	domain, err := h.dependencies.DomainRepo.GetByName(ctx, domainName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get domain: %w", err)
+	}
+
+	// Get all attributes for this domain and find the specific one
+	attributes, err := h.dependencies.AttributeRepo.ListByDomainID(ctx, domain.ID())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list domain attributes: %w", err)
+	}
+
+	// Find the specific attribute
+	var foundAttribute *entity.Attribute
+	for _, attr := range attributes {
+		if attr.Name() == attributeName {
+			foundAttribute = attr
+			break
+		}
+	}
+
+	if foundAttribute == nil {
+		return nil, fmt.Errorf("attribute '%s' not found in domain '%s'", attributeName, domainName)
+	}
+
+	// Update description if provided
+	updated := false
+	if description, ok := args["description"].(string); ok {
+		foundAttribute.UpdateDescription(description)
+		updated = true
+	}
+
+	if !updated {
+		return nil, fmt.Errorf("at least one field (description) must be provided for update")
+	}
+
+	// Save updated attribute
+	if err := h.dependencies.AttributeRepo.Update(ctx, foundAttribute); err != nil {
+		return nil, fmt.Errorf("failed to update domain attribute: %w", err)
+	}
+
+	// Convert to MCP response format
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": fmt.Sprintf("Successfully updated domain attribute:\nDomain: %s\nName: %s\nType: %s\nDescription: %s\nUpdated: %s",
+					domainName, foundAttribute.Name(), foundAttribute.Type(), foundAttribute.Description(),
+					foundAttribute.UpdatedAt().Format("2006-01-02 15:04:05")),
+			},
+		},
+	}, nil
+}
+
+// handleDeleteDomainAttribute implements the delete_domain_attribute tool
+func (h *MCPToolHandler) handleDeleteDomainAttribute(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	// Parse arguments
+	domainName, ok := args["domain_name"].(string)
+	if !ok || domainName == "" {
+		return nil, fmt.Errorf("missing or invalid 'domain_name' parameter")
+	}
+
+	attributeName, ok := args["attribute_name"].(string)
+	if !ok || attributeName == "" {
+		return nil, fmt.Errorf("missing or invalid 'attribute_name' parameter")
+	}
+
+	dryRun, _ := args["dry_run"].(bool)
+
+	// Get domain first to get domain ID
+	domain, err := h.dependencies.DomainRepo.GetByName(ctx, domainName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get domain: %w", err)
+	}
+	if domain == nil {
+		return nil, fmt.Errorf("domain '%s' not found", domainName)
+	}
+
+	// Get all attributes for this domain and find the specific one
+	attributes, err := h.dependencies.AttributeRepo.ListByDomainID(ctx, domain.ID())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list domain attributes: %w", err)
+	}
+
+	// Find the specific attribute
+	var foundAttribute *entity.Attribute
+	for _, attr := range attributes {
+		if attr.Name() == attributeName {
+			foundAttribute = attr
+			break
+		}
+	}
+
+	if foundAttribute == nil {
+		return nil, fmt.Errorf("attribute '%s' not found in domain '%s'", attributeName, domainName)
+	}
+
+	// Count the values deleted along with the attribute
+	nodeIDs, err := h.dependencies.NodeAttributeRepo.GetNodesWithAttribute(ctx, foundAttribute.ID(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes with attribute: %w", err)
+	}
+	valueCount, err := h.dependencies.NodeAttributeRepo.CountByAttributeID(ctx, foundAttribute.ID())
+	if err != nil {
+		return nil, fmt.Errorf("failed to count attribute values: %w", err)
+	}
+	templateValueCount, err := h.dependencies.TemplateAttributeRepo.CountByAttributeID(ctx, foundAttribute.ID())
+	if err != nil {
+		return nil, fmt.Errorf("failed to count template attribute values: %w", err)
+	}
+
+	header := "Successfully deleted domain attribute:"
+	if dryRun {
+		header = "Dry run, nothing was deleted. Deleting this attribute would remove:"
+	} else if err := h.dependencies.AttributeRepo.Delete(ctx, foundAttribute.ID()); err != nil {
+		return nil, fmt.Errorf("failed to delete domain attribute: %w", err)
+	}
+
+	content := []map[string]interface{}{
+		createTextContent(fmt.Sprintf("%s\nDomain: %s\nName: %s\nType: %s\nValues: %d on %d node(s)\nTemplate values: %d",
+			header, domainName, foundAttribute.Name(), foundAttribute.Type(), valueCount, len(nodeIDs), templateValueCount)),
+	}
+
+	structuredContent := map[string]interface{}{
+		"deleted":              !dryRun,
+		"dry_run":              dryRun,
+		"domain_name":          domainName,
+		"attribute_name":       foundAttribute.Name(),
+		"attribute_type":       string(foundAttribute.Type()),
+		"value_count":          valueCount,
+		"node_count":           len(nodeIDs),
+		"template_value_count": templateValueCount,
+	}
+
+	return createMCPResponse(content, structuredContent), nil
+}
+
+// Dependency Management Tools
+
+// parseCreateDependencyArguments parses and checks the arguments of one dependency
+// of create_dependency or create_dependencies
+func (h *MCPToolHandler) parseCreateDependencyArguments(args map[string]interface{}) (*request.CreateDependencyRequest, error) {
+	// Parse arguments
+	dependentNodeID, ok := args["dependent_node_id"].(string)
+	if !ok || dependentNodeID == "" {
+		return nil, fmt.Errorf("missing or invalid 'dependent_node_id' parameter")
+	}
+
+	dependencyNodeID, ok := args["dependency_node_id"].(string)
+	if !ok || dependencyNodeID == "" {
+		return nil, fmt.Errorf("missing or invalid 'dependency_node_id' parameter")
+	}
+
+	dependencyType, ok := args["dependency_type"].(string)
+	if !ok || dependencyType == "" {
+		return nil, fmt.Errorf("missing or invalid 'dependency_type' parameter")
+	}
+
+	// Validate dependency type against the configured taxonomy
+	isValid := false
+	for _, validType := range h.dependencyTypes {
+		if dependencyType == validType {
+			isValid = true
+			break
+		}
+	}
+	if !isValid {
+		return nil, fmt.Errorf("invalid dependency_type: %s. Must be one of: %s", dependencyType, strings.Join(h.dependencyTypes, ", "))
+	}
+
+	// Parse composite IDs
+	depNodeID, err := h.parseCompositeID(dependentNodeID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid dependent_node_id: %w", err)
+	}
+
+	depyNodeID, err := h.parseCompositeID(dependencyNodeID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid dependency_node_id: %w", err)
+	}
+
+	// Prevent self-dependency
+	if depNodeID == depyNodeID {
+		return nil, fmt.Errorf("a node cannot depend on itself")
+	}
+
+	// Optional parameters
+	cascadeDelete := false
+	if cd, ok := args["cascade_delete"].(bool); ok {
+		cascadeDelete = cd
+	}
+
+	cascadeUpdate := false
+	if cu, ok := args["cascade_update"].(bool); ok {
+		cascadeUpdate = cu
+	}
+
+	description := ""
+	if d, ok := args["description"].(string); ok {
+		description = d
+	}
+
+	// Cascading is reserved for hard dependencies; soft, reference and custom types never cascade
+	if (cascadeDelete || cascadeUpdate) && dependencyType != constants.DependencyTypeHard {
+		return nil, fmt.Errorf("cascade_delete and cascade_update are only supported for '%s' dependencies", constants.DependencyTypeHard)
+	}
+
+	return &request.CreateDependencyRequest{
+		DependentNodeID:  depNodeID,
+		DependencyNodeID: depyNodeID,
+		DependencyType:   dependencyType,
+		Description:      description,
+		CascadeDelete:    cascadeDelete,
+		CascadeUpdate:    cascadeUpdate,
+	}, nil
+}
+
+// createDependencyError describes a failed dependency creation, naming the nodes of
+// a cycle by composite ID
+func (h *MCPToolHandler) createDependencyError(ctx context.Context, err error) error {
+	var cycleErr *dependencyUseCase.CycleError
+	if errors.As(err, &cycleErr) {
+		path := make([]string, len(cycleErr.Path))
+		for i, nodeID := range cycleErr.Path {
+			var lookupErr error
+			if path[i], lookupErr = h.nodeCompositeIDByID(ctx, nodeID); lookupErr != nil {
+				path[i] = strconv.Itoa(nodeID)
+			}
+		}
+		return fmt.Errorf("dependency would create a cycle: %s", strings.Join(path, " -> "))
+	}
+	return fmt.Errorf("failed to create dependency: %w", err)
+}
+
+// handleCreateDependencies implements the create_dependencies tool
+func (h *MCPToolHandler) handleCreateDependencies(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	rawDependencies, ok := args["dependencies"].([]interface{})
+	if !ok || len(rawDependencies) == 0 {
+		return nil, fmt.Errorf("missing or invalid 'dependencies' parameter")
+	}
+	if len(rawDependencies) > constants.MaxBatchSize {
+		return nil, fmt.Errorf("too many dependencies: %d (max %d)", len(rawDependencies), constants.MaxBatchSize)
+	}
+
+	// Edges with invalid arguments are reported without reaching the use case
+	itemErrors := make([]error, len(rawDependencies))
+	var reqs []*request.CreateDependencyRequest
+	var reqIndexes []int
+	for i, raw := range rawDependencies {
+		item, ok := raw.(map[string]interface{})
+		if !ok {
+			itemErrors[i] = errors.New("expected an object")
+			continue
+		}
+		req, err := h.parseCreateDependencyArguments(item)
+		if err != nil {
+			itemErrors[i] = err
+			continue
+		}
+		reqs = append(reqs, req)
+		reqIndexes = append(reqIndexes, i)
+	}
+
+	batchResults, err := h.dependencies.CreateDependencyUC.ExecuteBatch(ctx, reqs)
+	if err != nil {
+		return nil, fmt.Errorf("batch aborted, nothing was created: %w", err)
+	}
+
+	createdDependencies := make(map[int]*entity.Dependency, len(batchResults))
+	for j, batchResult := range batchResults {
+		if batchResult.Err != nil {
+			itemErrors[reqIndexes[j]] = h.createDependencyError(ctx, batchResult.Err)
+			continue
+		}
+		createdDependencies[reqIndexes[j]] = batchResult.Dependency
+	}
+
+	// Report results in input order
+	results := make([]map[string]interface{}, len(rawDependencies))
+	lines := make([]string, len(rawDependencies))
+	created := 0
+	for i := range rawDependencies {
+		if itemErrors[i] != nil {
+			results[i] = map[string]interface{}{"index": i, "success": false, "error": itemErrors[i].Error()}
+			lines[i] = fmt.Sprintf("%d. failed (%v)", i, itemErrors[i])
+			continue
+		}
+
+		dependency, err := h.dependencyToMap(ctx, createdDependencies[i])
+		if err != nil {
+			return nil, err
+		}
+		created++
+		results[i] = map[string]interface{}{"index": i, "success": true, "dependency": dependency}
+		lines[i] = fmt.Sprintf("%d. %s -> %s (%s): dependency %d", i, dependency["dependent_node_id"], dependency["dependency_node_id"],
+			createdDependencies[i].DependencyType(), createdDependencies[i].ID())
+	}
+
+	content := []map[string]interface{}{
+		createTextContent(fmt.Sprintf("Created %d of %d dependencies\n%s", created, len(rawDependencies), strings.Join(lines, "\n"))),
+	}
+
+	structuredContent := map[string]interface{}{
+		"results":       results,
+		"created_count": created,
+		"failed_count":  len(rawDependencies) - created,
+	}
+
+	return createMCPResponse(content, structuredContent), nil
+}
+
+// handleCreateDependency implements the create_dependency tool
+func (h *MCPToolHandler) handleCreateDependency(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	req, err := h.parseCreateDependencyArguments(args)
+	if err != nil {
+		return nil, err
+	}
+
+	dependency, err := h.dependencies.CreateDependencyUC.Execute(ctx, req)
+	if err != nil {
+		return nil, h.createDependencyError(ctx, err)
+	}
+
+	content := []map[string]interface{}{
+		createTextContent(fmt.Sprintf("Successfully created dependency:\nDependency ID: %d\nDependent: %s\nDependency: %s\nType: %s\nCascade Delete: %t\nCascade Update: %t\nDescription: %s",
+			dependency.ID(), args["dependent_node_id"], args["dependency_node_id"], req.DependencyType, req.CascadeDelete, req.CascadeUpdate, req.Description)),
+	}
+
+	structuredContent, err := h.dependencyToMap(ctx, dependency)
+	if err != nil {
+		return nil, err
+	}
+
+	return createMCPResponse(content, structuredContent), nil
+}
+
+// dependencyToMap converts a dependency to its structured form, resolving both
+// endpoints to composite IDs (they may live in different domains)
+func (h *MCPToolHandler) dependencyToMap(ctx context.Context, dependency *entity.Dependency) (map[string]interface{}, error) {
+	dependent, err := h.nodeCompositeIDByID(ctx, dependency.DependentNodeID())
+	if err != nil {
+		return nil, err
+	}
+
+	dependencyNode, err := h.nodeCompositeIDByID(ctx, dependency.DependencyNodeID())
+	if err != nil {
+		return nil, err
+	}
+
+	return dependencyMap(dependency, dependent, dependencyNode), nil
+}
+
+// dependencyEdgeToMap converts a resolved dependency edge to its structured form,
+// with the related node's composite ID, title and URL
+func (h *MCPToolHandler) dependencyEdgeToMap(edge repository.DependencyEdge, dependents bool) map[string]interface{} {
+	dependency := edge.Dependency
+	dependent := h.nodeCompositeID(edge.DependentDomain, dependency.DependentNodeID())
+	dependencyNode := h.nodeCompositeID(edge.DependencyDomain, dependency.DependencyNodeID())
+
+	related := dependencyNode
+	if dependents {
+		related = dependent
+	}
+
+	result := dependencyMap(dependency, dependent, dependencyNode)
+	result["related_node"] = map[string]interface{}{
+		"composite_id": related,
+		"title":        edge.RelatedTitle,
+		"url":          edge.RelatedURL,
+	}
+	return result
+}
 
-	// Find node by URL
-	node, err := h.dependencies.NodeRepo.GetByURL(ctx, url, domainName)
+// dependencyMap is the structured form of a dependency whose endpoints are given as
+// composite IDs
+func dependencyMap(dependency *entity.Dependency, dependent, dependencyNode string) map[string]interface{} {
+	return map[string]interface{}{
+		"dependency_id":      dependency.ID(),
+		"dependent_node_id":  dependent,
+		"dependency_node_id": dependencyNode,
+		"dependency_type":    dependency.DependencyType(),
+		"cascade_delete":     dependency.CascadeDelete(),
+		"cascade_update":     dependency.CascadeUpdate(),
+		"description":        dependency.Description(),
+		"created_at":         dependency.CreatedAt().Format(time.RFC3339),
+	}
+}
+
+// nodeCompositeIDByID builds a node's composite ID by looking up its domain
+func (h *MCPToolHandler) nodeCompositeIDByID(ctx context.Context, nodeID int) (string, error) {
+	domain, err := h.dependencies.NodeRepo.GetDomainByNodeID(ctx, nodeID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to find node: %w", err)
+		return "", fmt.Errorf("failed to get node domain: %w", err)
+	}
+	if domain == nil {
+		return "", fmt.Errorf("node not found: %d", nodeID)
 	}
+	return h.nodeCompositeID(domain.Name(), nodeID), nil
+}
 
-	// Convert to MCP response format
-	return map[string]interface{}{
-		"content": []map[string]interface{}{
-			{
-				"type": "text",
-				"text": fmt.Sprintf("Found node:\nID: %d\nURL: %s\nTitle: %s\nDescription: %s\nCreated: %s",
-					node.ID(), node.URL(), node.Title(), node.Description(),
-					node.CreatedAt().Format("2006-01-02 15:04:05")),
-			},
-		},
-	}, nil
+// handleListNodeDependencies implements the list_node_dependencies tool
+func (h *MCPToolHandler) handleListNodeDependencies(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	return h.listNodeDependencyEdges(ctx, args, false)
 }
 
-// Attribute Management Tools
+// handleListNodeDependents implements the list_node_dependents tool
+func (h *MCPToolHandler) handleListNodeDependents(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	return h.listNodeDependencyEdges(ctx, args, true)
+}
 
-// handleGetNodeAttributes implements the get_node_attributes tool
-func (h *MCPToolHandler) handleGetNodeAttributes(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+// listNodeDependencyEdges lists what a node depends on, or what depends on it when
+// dependents is true
+func (h *MCPToolHandler) listNodeDependencyEdges(ctx context.Context, args map[string]interface{}, dependents bool) (interface{}, error) {
 	// Parse composite_id argument
 	compositeID, ok := args["composite_id"].(string)
 	if !ok || compositeID == "" {
@@ -469,378 +3887,699 @@ func (h *MCPToolHandler) handleGetNodeAttributes(ctx context.Context, args map[s
 	}
 
 	// Parse composite ID to extract node ID
-	parts := strings.Split(compositeID, ":")
-	if len(parts) != 3 {
-		return nil, fmt.Errorf("invalid composite_id format, expected 'tool-name:domain:id'")
-	}
-
-	nodeIDStr := parts[2]
-	nodeID, err := strconv.Atoi(nodeIDStr)
+	nodeID, err := h.parseCompositeID(compositeID)
 	if err != nil {
-		return nil, fmt.Errorf("invalid node ID in composite_id: %v", err)
+		return nil, fmt.Errorf("invalid composite_id: %w", err)
 	}
 
-	// Get node to ensure it exists
+	// Verify node exists
 	node, err := h.dependencies.NodeRepo.GetByID(ctx, nodeID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get node: %w", err)
 	}
+	if node == nil {
+		return nil, fmt.Errorf("node not found: %s", compositeID)
+	}
 
-	// Get node attributes from database
-	nodeAttributes, err := h.dependencies.NodeAttributeRepo.GetByNodeID(ctx, nodeID)
+	// Related nodes come back joined with each edge rather than looked up one by one
+	label := "Dependencies"
+	listFn := h.dependencies.DependencyRepo.ListEdgesByDependentNodeID
+	if dependents {
+		label = "Dependents"
+		listFn = h.dependencies.DependencyRepo.ListEdgesByDependencyNodeID
+	}
+
+	dependencyEdges, err := listFn(ctx, nodeID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get node attributes: %w", err)
+		return nil, fmt.Errorf("failed to list %s: %w", strings.ToLower(label), err)
 	}
 
-	if len(nodeAttributes) == 0 {
-		return map[string]interface{}{
-			"content": []map[string]interface{}{
-				{
-					"type": "text",
-					"text": fmt.Sprintf("No attributes found for node: %s\nURL: %s", node.Title(), node.URL()),
-				},
-			},
-			}, nil
+	edges := make([]map[string]interface{}, 0, len(dependencyEdges))
+	var lines []string
+	for _, dependencyEdge := range dependencyEdges {
+		dep := dependencyEdge.Dependency
+		edge := h.dependencyEdgeToMap(dependencyEdge, dependents)
+		edges = append(edges, edge)
+		lines = append(lines, fmt.Sprintf("• [%d] %s -> %s (%s): %s <%s>",
+			dep.ID(), edge["dependent_node_id"], edge["dependency_node_id"], dep.DependencyType(),
+			dependencyEdge.RelatedTitle, dependencyEdge.RelatedURL))
 	}
 
-	// Build attributes display
-	var attributeTexts []string
-	for _, nodeAttr := range nodeAttributes {
-		// Get attribute definition to show name and type
-		attr, err := h.dependencies.AttributeRepo.GetByID(ctx, nodeAttr.AttributeID())
+	text := fmt.Sprintf("%s for node: %s\nURL: %s\n\n", label, node.Title(), node.URL())
+	if len(lines) == 0 {
+		text += fmt.Sprintf("No %s found", strings.ToLower(label))
+	} else {
+		text += strings.Join(lines, "\n")
+	}
+
+	content := []map[string]interface{}{
+		createTextContent(text),
+	}
+
+	structuredContent := map[string]interface{}{
+		"composite_id": compositeID,
+		"dependencies": edges,
+		"total_count":  len(edges),
+	}
+
+	return createMCPResponse(content, structuredContent), nil
+}
+
+// handleDeleteDependency implements the delete_dependency tool
+func (h *MCPToolHandler) handleDeleteDependency(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	// Parse dependency_id argument
+	dependencyIDRaw, ok := args["dependency_id"]
+	if !ok {
+		return nil, fmt.Errorf("missing 'dependency_id' parameter")
+	}
+
+	var dependencyID int
+	switch v := dependencyIDRaw.(type) {
+	case float64:
+		dependencyID = int(v)
+	case int:
+		dependencyID = v
+	case string:
+		var err error
+		dependencyID, err = strconv.Atoi(v)
 		if err != nil {
-			continue // Skip if attribute definition not found
+			return nil, fmt.Errorf("invalid dependency_id format: %v", err)
 		}
+	default:
+		return nil, fmt.Errorf("invalid dependency_id type, expected number or string")
+	}
 
-		text := fmt.Sprintf("• %s (%s): %s", attr.Name(), attr.Type(), nodeAttr.Value())
-		if nodeAttr.OrderIndex() != nil {
-			text += fmt.Sprintf(" [order: %d]", *nodeAttr.OrderIndex())
-		}
-		attributeTexts = append(attributeTexts, text)
+	if dependencyID <= 0 {
+		return nil, fmt.Errorf("dependency_id must be positive")
+	}
+
+	if err := h.dependencies.DependencyRepo.Delete(ctx, dependencyID); err != nil {
+		return nil, fmt.Errorf("failed to delete dependency: %w", err)
 	}
 
 	content := []map[string]interface{}{
-		{
-			"type": "text",
-			"text": fmt.Sprintf("Attributes for node: %s\nURL: %s\n\n%s",
-				node.Title(), node.URL(), strings.Join(attributeTexts, "\n")),
-		},
+		createTextContent(fmt.Sprintf("Successfully deleted dependency with ID: %d", dependencyID)),
 	}
 
-	return map[string]interface{}{
-		"content": content,
-	}, nil
+	structuredContent := map[string]interface{}{
+		"dependency_id": dependencyID,
+		"deleted":       true,
+	}
+
+	return createMCPResponse(content, structuredContent), nil
 }
 
-// handleSetNodeAttributes implements the set_node_attributes tool
-func (h *MCPToolHandler) handleSetNodeAttributes(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+// handleListDomainDependencies implements the list_domain_dependencies tool
+func (h *MCPToolHandler) handleListDomainDependencies(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	// Parse domain_name argument
+	domainName, ok := args["domain_name"].(string)
+	if !ok || domainName == "" {
+		return nil, fmt.Errorf("missing or invalid 'domain_name' parameter")
+	}
+
+	// Optional pagination parameters
+	page := 1
+	if p, ok := args["page"].(float64); ok && p >= 1 {
+		page = int(p)
+	}
+
+	size := constants.DefaultPageSize
+	if s, ok := args["size"].(float64); ok && s >= 1 {
+		size = int(s)
+	}
+	if size > h.maxPageSize {
+		size = h.maxPageSize
+	}
+
+	domain, err := h.dependencies.DomainRepo.GetByName(ctx, domainName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get domain: %w", err)
+	}
+	if domain == nil {
+		return nil, fmt.Errorf("domain '%s' not found", domainName)
+	}
+
+	dependencies, totalCount, err := h.dependencies.DependencyRepo.ListByDomain(ctx, domain.ID(), page, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list domain dependencies: %w", err)
+	}
+
+	content := []map[string]interface{}{}
+	edges := []map[string]interface{}{}
+	for _, dep := range dependencies {
+		dependent := h.nodeCompositeID(domainName, dep.DependentNodeID())
+		dependency := h.nodeCompositeID(domainName, dep.DependencyNodeID())
+
+		content = append(content, createTextContent(fmt.Sprintf("Dependency ID: %d\n%s -> %s (%s)",
+			dep.ID(), dependent, dependency, dep.DependencyType())))
+
+		edges = append(edges, map[string]interface{}{
+			"id":                 dep.ID(),
+			"dependent_node_id":  dependent,
+			"dependency_node_id": dependency,
+			"dependency_type":    dep.DependencyType(),
+			"description":        dep.Description(),
+		})
+	}
+
+	if len(content) == 0 {
+		content = append(content, createTextContent(fmt.Sprintf("No dependencies found in domain '%s'", domainName)))
+	}
+
+	totalPages := (totalCount + size - 1) / size
+
+	structuredContent := map[string]interface{}{
+		"domain_name":  domainName,
+		"dependencies": edges,
+		"total_count":  totalCount,
+		"page":         page,
+		"total_pages":  totalPages,
+		"pagination":   newPagination(page, size, totalCount),
+	}
+
+	return createMCPResponse(content, structuredContent), nil
+}
+
+// handleGetNodeFull implements the get_node_full tool
+func (h *MCPToolHandler) handleGetNodeFull(ctx context.Context, args map[string]interface{}) (interface{}, error) {
 	// Parse composite_id argument
 	compositeID, ok := args["composite_id"].(string)
 	if !ok || compositeID == "" {
 		return nil, fmt.Errorf("missing or invalid 'composite_id' parameter")
 	}
 
-	// Parse attributes argument
-	attributesRaw, ok := args["attributes"]
-	if !ok {
-		return nil, fmt.Errorf("missing 'attributes' parameter")
+	nodeID, err := h.parseCompositeID(compositeID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid composite_id: %w", err)
 	}
 
-	attributes, ok := attributesRaw.([]interface{})
-	if !ok {
-		return nil, fmt.Errorf("invalid 'attributes' parameter, expected array")
+	// Every section is included unless explicitly turned off
+	include := func(name string) bool {
+		value, ok := args[name].(bool)
+		return !ok || value
 	}
 
-	// Parse composite ID to extract node ID
-	parts := strings.Split(compositeID, ":")
-	if len(parts) != 3 {
-		return nil, fmt.Errorf("invalid composite_id format, expected 'tool-name:domain:id'")
+	node, err := h.dependencies.NodeRepo.GetByID(ctx, nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node: %w", err)
+	}
+	if node == nil {
+		return nil, fmt.Errorf("node not found: %s", compositeID)
 	}
 
-	nodeIDStr := parts[2]
-	nodeID, err := strconv.Atoi(nodeIDStr)
-	if err != nil {
-		return nil, fmt.Errorf("invalid node ID in composite_id: %v", err)
+	structuredContent := map[string]interface{}{
+		"composite_id": compositeID,
+		"id":           node.ID(),
+		"url":          node.URL(),
+		"title":        node.Title(),
+		"description":  node.Description(),
+		"created_at":   node.CreatedAt().Format(time.RFC3339),
+		"updated_at":   node.UpdatedAt().Format(time.RFC3339),
 	}
+	summary := []string{fmt.Sprintf("Node: %s\nURL: %s\nTitle: %s", compositeID, node.URL(), node.Title())}
 
-	// Get node to ensure it exists
-	node, err := h.dependencies.NodeRepo.GetByID(ctx, nodeID)
+	if include("include_attributes") {
+		nodeAttributes, err := h.dependencies.NodeAttributeRepo.GetByNodeID(ctx, nodeID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get node attributes: %w", err)
+		}
+
+		attributes := make([]map[string]interface{}, 0, len(nodeAttributes))
+		for _, attr := range nodeAttributes {
+			attrType := ""
+			if attr.AttributeType() != nil {
+				attrType = *attr.AttributeType()
+			}
+			attributes = append(attributes, map[string]interface{}{
+				"name":        attr.Name(),
+				"type":        attrType,
+				"value":       attr.Value(),
+				"order_index": attr.OrderIndex(),
+			})
+		}
+		structuredContent["attributes"] = attributes
+		summary = append(summary, fmt.Sprintf("Attributes: %d", len(attributes)))
+	}
+
+	// Load the edge sections first, then resolve every neighbouring node in one batch
+	var dependencies, dependents []*entity.Dependency
+	var connections []*entity.NodeConnection
+	neighbourIDs := []int{nodeID}
+
+	if include("include_dependencies") {
+		if dependencies, err = h.dependencies.DependencyRepo.ListByDependentNodeID(ctx, nodeID); err != nil {
+			return nil, fmt.Errorf("failed to list dependencies: %w", err)
+		}
+		for _, dep := range dependencies {
+			neighbourIDs = append(neighbourIDs, dep.DependencyNodeID())
+		}
+	}
+
+	if include("include_dependents") {
+		if dependents, err = h.dependencies.DependencyRepo.ListByDependencyNodeID(ctx, nodeID); err != nil {
+			return nil, fmt.Errorf("failed to list dependents: %w", err)
+		}
+		for _, dep := range dependents {
+			neighbourIDs = append(neighbourIDs, dep.DependentNodeID())
+		}
+	}
+
+	if include("include_connections") {
+		if connections, err = h.dependencies.NodeConnectionRepo.ListByNodeID(ctx, nodeID); err != nil {
+			return nil, fmt.Errorf("failed to list connections: %w", err)
+		}
+		for _, conn := range connections {
+			neighbourIDs = append(neighbourIDs, conn.SourceNodeID(), conn.TargetNodeID())
+		}
+	}
+
+	compositeIDs, err := h.compositeIDsByNodeID(ctx, neighbourIDs)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get node: %w", err)
+		return nil, err
 	}
 
-	// Convert attributes to use case input
-	var attributeInputs []nodeUseCase.AttributeInput
-	for _, attr := range attributes {
-		attrMap, ok := attr.(map[string]interface{})
-		if !ok {
-			return nil, fmt.Errorf("invalid attribute format")
+	edgeMap := func(dep *entity.Dependency) map[string]interface{} {
+		return map[string]interface{}{
+			"dependency_id":      dep.ID(),
+			"dependent_node_id":  compositeIDs[dep.DependentNodeID()],
+			"dependency_node_id": compositeIDs[dep.DependencyNodeID()],
+			"dependency_type":    dep.DependencyType(),
+			"cascade_delete":     dep.CascadeDelete(),
+			"cascade_update":     dep.CascadeUpdate(),
+			"description":        dep.Description(),
+			"created_at":         dep.CreatedAt().Format(time.RFC3339),
+		}
+	}
+
+	if include("include_dependencies") {
+		edges := make([]map[string]interface{}, 0, len(dependencies))
+		for _, dep := range dependencies {
+			edges = append(edges, edgeMap(dep))
 		}
+		structuredContent["dependencies"] = edges
+		summary = append(summary, fmt.Sprintf("Dependencies: %d", len(edges)))
+	}
 
-		name, ok := attrMap["name"].(string)
-		if !ok || name == "" {
-			return nil, fmt.Errorf("attribute must have a valid 'name'")
+	if include("include_dependents") {
+		edges := make([]map[string]interface{}, 0, len(dependents))
+		for _, dep := range dependents {
+			edges = append(edges, edgeMap(dep))
+		}
+		structuredContent["dependents"] = edges
+		summary = append(summary, fmt.Sprintf("Dependents: %d", len(edges)))
+	}
+
+	if include("include_connections") {
+		links := make([]map[string]interface{}, 0, len(connections))
+		for _, conn := range connections {
+			links = append(links, map[string]interface{}{
+				"connection_id":     conn.ID(),
+				"source_node_id":    compositeIDs[conn.SourceNodeID()],
+				"target_node_id":    compositeIDs[conn.TargetNodeID()],
+				"relationship_type": conn.RelationshipType(),
+				"description":       conn.Description(),
+			})
 		}
+		structuredContent["connections"] = links
+		summary = append(summary, fmt.Sprintf("Connections: %d", len(links)))
+	}
 
-		value, ok := attrMap["value"].(string)
-		if !ok || value == "" {
-			return nil, fmt.Errorf("attribute must have a valid 'value'")
+	content := []map[string]interface{}{
+		createTextContent(strings.Join(summary, "\n")),
+	}
+
+	return createMCPResponse(content, structuredContent), nil
+}
+
+// compositeIDsByNodeID resolves composite IDs for several nodes with one node query
+// and one domain lookup per distinct domain
+func (h *MCPToolHandler) compositeIDsByNodeID(ctx context.Context, nodeIDs []int) (map[int]string, error) {
+	unique := make([]int, 0, len(nodeIDs))
+	seen := make(map[int]bool, len(nodeIDs))
+	for _, id := range nodeIDs {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
 		}
+	}
 
-		var orderIndex *int
-		if orderIndexRaw, exists := attrMap["order_index"]; exists && orderIndexRaw != nil {
-			if orderIndexFloat, ok := orderIndexRaw.(float64); ok {
-				orderIndexInt := int(orderIndexFloat)
-				orderIndex = &orderIndexInt
+	nodes, err := h.dependencies.NodeRepo.GetBatch(ctx, unique)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get nodes: %w", err)
+	}
+
+	domainNames := make(map[int]string)
+	compositeIDs := make(map[int]string, len(nodes))
+	for _, node := range nodes {
+		name, ok := domainNames[node.DomainID()]
+		if !ok {
+			domain, err := h.dependencies.DomainRepo.GetByID(ctx, node.DomainID())
+			if err != nil {
+				return nil, fmt.Errorf("failed to get domain: %w", err)
 			}
+			if domain != nil {
+				name = domain.Name()
+			}
+			domainNames[node.DomainID()] = name
 		}
+		compositeIDs[node.ID()] = h.nodeCompositeID(name, node.ID())
+	}
 
-		attributeInputs = append(attributeInputs, nodeUseCase.AttributeInput{
-			Name:       name,
-			Value:      value,
-			OrderIndex: orderIndex,
-		})
+	return compositeIDs, nil
+}
+
+// handleGlobalSearch implements the global_search tool
+func (h *MCPToolHandler) handleGlobalSearch(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	// Parse arguments
+	query, ok := args["query"].(string)
+	if !ok || strings.TrimSpace(query) == "" {
+		return nil, fmt.Errorf("missing or invalid 'query' parameter")
 	}
 
-	// Execute the use case
-	err = h.dependencies.SetNodeAttributesUC.Execute(ctx, nodeID, attributeInputs)
+	domainName, _ := args["domain_name"].(string)
+
+	// Optional pagination parameters
+	page := 1
+	if p, ok := args["page"].(float64); ok && p >= 1 {
+		page = int(p)
+	}
+
+	size := constants.DefaultPageSize
+	if s, ok := args["size"].(float64); ok && s >= 1 {
+		size = int(s)
+	}
+	if size > h.maxPageSize {
+		size = h.maxPageSize
+	}
+
+	if domainName != "" {
+		domain, err := h.dependencies.DomainRepo.GetByName(ctx, domainName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get domain: %w", err)
+		}
+		if domain == nil {
+			return nil, fmt.Errorf("domain '%s' not found", domainName)
+		}
+	}
+
+	hits, totalCount, err := h.dependencies.NodeRepo.GlobalSearch(ctx, domainName, query, page, size)
 	if err != nil {
-		return nil, fmt.Errorf("failed to set node attributes: %w", err)
+		return nil, fmt.Errorf("failed to search: %w", err)
+	}
+
+	nodeIDs := make([]int, len(hits))
+	for i, hit := range hits {
+		nodeIDs[i] = hit.Node.ID()
+	}
+	attributesByNode, err := h.dependencies.NodeAttributeRepo.GetByNodeIDs(ctx, nodeIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node attributes: %w", err)
+	}
+
+	words := strings.Fields(query)
+	containsWord := func(value string) bool {
+		for _, word := range words {
+			if _, ok := highlightMatch(value, word); ok {
+				return true
+			}
+		}
+		return false
 	}
 
-	return map[string]interface{}{
-		"content": []map[string]interface{}{
-			{
-				"type": "text",
-				"text": fmt.Sprintf("Successfully set %d attributes for node: %s\nURL: %s",
-					len(attributes), node.Title(), node.URL()),
-			},
-		},
-	}, nil
-}
+	results := make([]map[string]interface{}, len(hits))
+	lines := make([]string, len(hits))
+	for i, hit := range hits {
+		node := hit.Node
+		compositeID := h.nodeCompositeID(hit.DomainName, node.ID())
 
-// Domain Schema Management Tools
+		matchedFields := []string{}
+		for _, field := range []struct{ name, value string }{
+			{"title", node.Title()}, {"url", node.URL()}, {"description", node.Description()},
+		} {
+			if containsWord(field.value) {
+				matchedFields = append(matchedFields, field.name)
+			}
+		}
 
-// handleListDomainAttributes implements the list_domain_attributes tool
-func (h *MCPToolHandler) handleListDomainAttributes(ctx context.Context, args map[string]interface{}) (interface{}, error) {
-	// Parse domain_name argument
-	domainName, ok := args["domain_name"].(string)
-	if !ok || domainName == "" {
-		return nil, fmt.Errorf("missing or invalid 'domain_name' parameter")
-	}
+		attributeMatches := []map[string]interface{}{}
+		for _, attr := range attributesByNode[node.ID()] {
+			if containsWord(attr.Value()) {
+				attributeMatches = append(attributeMatches, map[string]interface{}{
+					"attribute_name": attr.Name(),
+					"value":          attr.Value(),
+				})
+			}
+		}
+		if len(attributeMatches) > 0 {
+			matchedFields = append(matchedFields, "attribute")
+		}
 
-	// Get domain first to get domain ID
-	domain, err := h.dependencies.DomainRepo.GetByName(ctx, domainName)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get domain: %w", err)
+		results[i] = map[string]interface{}{
+			"composite_id":      compositeID,
+			"domain_name":       hit.DomainName,
+			"url":               node.URL(),
+			"title":             node.Title(),
+			"description":       node.Description(),
+			"score":             hit.Score,
+			"matched_fields":    matchedFields,
+			"attribute_matches": attributeMatches,
+		}
+		lines[i] = fmt.Sprintf("• %s %s (matched: %s)", compositeID, node.URL(), strings.Join(matchedFields, ", "))
 	}
 
-	// Get attributes for this domain
-	attributes, err := h.dependencies.AttributeRepo.ListByDomainID(ctx, domain.ID())
-	if err != nil {
-		return nil, fmt.Errorf("failed to list domain attributes: %w", err)
+	scope := "any domain"
+	if domainName != "" {
+		scope = fmt.Sprintf("domain '%s'", domainName)
+	}
+	text := fmt.Sprintf("No nodes matching '%s' in %s", query, scope)
+	if totalCount > 0 {
+		text = fmt.Sprintf("Found %d node(s) matching '%s' in %s (page %d)\n%s",
+			totalCount, query, scope, page, strings.Join(lines, "\n"))
 	}
 
-	// Convert to MCP response format
-	content := []map[string]interface{}{}
-	for _, attr := range attributes {
-		content = append(content, map[string]interface{}{
-			"type": "text",
-			"text": fmt.Sprintf("Attribute: %s\nType: %s\nDescription: %s\nCreated: %s",
-				attr.Name(), attr.Type(), attr.Description(),
-				attr.CreatedAt().Format("2006-01-02 15:04:05")),
-		})
+	content := []map[string]interface{}{
+		createTextContent(text),
 	}
 
-	if len(content) == 0 {
-		content = append(content, map[string]interface{}{
-			"type": "text",
-			"text": fmt.Sprintf("No attributes defined for domain '%s'", domainName),
-		})
+	structuredContent := map[string]interface{}{
+		"query":       query,
+		"domain_name": domainName,
+		"results":     results,
+		"total_count": totalCount,
+		"pagination":  newPagination(page, size, totalCount),
 	}
 
-	return map[string]interface{}{
-		"content": content,
-	}, nil
+	return createMCPResponse(content, structuredContent), nil
 }
 
-// handleCreateDomainAttribute implements the create_domain_attribute tool
-func (h *MCPToolHandler) handleCreateDomainAttribute(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+// handleSearchAttributeValues implements the search_attribute_values tool
+func (h *MCPToolHandler) handleSearchAttributeValues(ctx context.Context, args map[string]interface{}) (interface{}, error) {
 	// Parse arguments
 	domainName, ok := args["domain_name"].(string)
 	if !ok || domainName == "" {
 		return nil, fmt.Errorf("missing or invalid 'domain_name' parameter")
 	}
 
-	name, ok := args["name"].(string)
-	if !ok || name == "" {
-		return nil, fmt.Errorf("missing or invalid 'name' parameter")
+	query, ok := args["query"].(string)
+	if !ok || strings.TrimSpace(query) == "" {
+		return nil, fmt.Errorf("missing or invalid 'query' parameter")
 	}
 
-	attrType, ok := args["type"].(string)
-	if !ok || attrType == "" {
-		return nil, fmt.Errorf("missing or invalid 'type' parameter")
+	// Optional pagination parameters
+	page := 1
+	if p, ok := args["page"].(float64); ok && p >= 1 {
+		page = int(p)
 	}
 
-	description := ""
-	if d, ok := args["description"].(string); ok {
-		description = d
+	size := constants.DefaultPageSize
+	if s, ok := args["size"].(float64); ok && s >= 1 {
+		size = int(s)
+	}
+	if size > h.maxPageSize {
+		size = h.maxPageSize
 	}
 
-	// Get domain first to get domain ID
 	domain, err := h.dependencies.DomainRepo.GetByName(ctx, domainName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get domain: %w", err)
 	}
-
-	// Create attribute request DTO
-	createReq := &request.CreateAttributeRequest{
-		DomainID:    domain.ID(),
-		Name:        name,
-		Type:        attrType,
-		Description: description,
+	if domain == nil {
+		return nil, fmt.Errorf("domain '%s' not found", domainName)
 	}
 
-	// Execute use case
-	result, err := h.dependencies.CreateAttributeUC.Execute(ctx, createReq)
+	nodeIDs, totalCount, err := h.dependencies.NodeAttributeRepo.SearchNodeIDsByValue(ctx, domain.ID(), query, page, size)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create domain attribute: %w", err)
+		return nil, fmt.Errorf("failed to search attribute values: %w", err)
 	}
 
-	// Convert to MCP response format
-	return map[string]interface{}{
-		"content": []map[string]interface{}{
-			{
-				"type": "text",
-				"text": fmt.Sprintf("Successfully created domain attribute:\nDomain: %s\nName: %s\nType: %s\nDescription: %s\nCreated: %s",
-					domainName, result.Name, result.Type, result.Description,
-					result.CreatedAt.Format("2006-01-02 15:04:05")),
-			},
-		},
-	}, nil
-}
-
-// handleGetDomainAttribute implements the get_domain_attribute tool
-func (h *MCPToolHandler) handleGetDomainAttribute(ctx context.Context, args map[string]interface{}) (interface{}, error) {
-	// Parse arguments
-	domainName, ok := args["domain_name"].(string)
-	if !ok || domainName == "" {
-		return nil, fmt.Errorf("missing or invalid 'domain_name' parameter")
+	nodes, err := h.dependencies.NodeRepo.GetBatch(ctx, nodeIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get nodes: %w", err)
 	}
-
-	attributeName, ok := args["attribute_name"].(string)
-	if !ok || attributeName == "" {
-		return nil, fmt.Errorf("missing or invalid 'attribute_name' parameter")
+	nodesByID := make(map[int]*entity.Node, len(nodes))
+	for _, node := range nodes {
+		nodesByID[node.ID()] = node
 	}
 
-	// Get domain first to get domain ID
-	domain, err := h.dependencies.DomainRepo.GetByName(ctx, domainName)
+	attributesByNode, err := h.dependencies.NodeAttributeRepo.GetByNodeIDs(ctx, nodeIDs)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get domain: %w", err)
+		return nil, fmt.Errorf("failed to get node attributes: %w", err)
 	}
 
-	// Get all attributes for this domain and find the specific one
-	attributes, err := h.dependencies.AttributeRepo.ListByDomainID(ctx, domain.ID())
-	if err != nil {
-		return nil, fmt.Errorf("failed to list domain attributes: %w", err)
-	}
+	results := make([]map[string]interface{}, 0, len(nodeIDs))
+	var lines []string
+	for _, nodeID := range nodeIDs {
+		node, ok := nodesByID[nodeID]
+		if !ok {
+			continue
+		}
+		compositeID := h.nodeCompositeID(domainName, nodeID)
 
-	// Find the specific attribute
-	var foundAttribute *entity.Attribute
-	for _, attr := range attributes {
-		if attr.Name() == attributeName {
-			foundAttribute = attr
-			break
+		matches := []map[string]interface{}{}
+		for _, attr := range attributesByNode[nodeID] {
+			highlighted, ok := highlightMatch(attr.Value(), query)
+			if !ok {
+				continue
+			}
+			matches = append(matches, map[string]interface{}{
+				"attribute_name": attr.Name(),
+				"value":          attr.Value(),
+				"highlighted":    highlighted,
+			})
+			lines = append(lines, fmt.Sprintf("• %s %s: %s", compositeID, attr.Name(), highlighted))
 		}
+
+		results = append(results, map[string]interface{}{
+			"composite_id": compositeID,
+			"url":          node.URL(),
+			"title":        node.Title(),
+			"matches":      matches,
+		})
 	}
 
-	if foundAttribute == nil {
-		return nil, fmt.Errorf("attribute '%s' not found in domain '%s'", attributeName, domainName)
+	text := fmt.Sprintf("No attribute values matching '%s' in domain '%s'", query, domainName)
+	if len(results) > 0 {
+		text = fmt.Sprintf("Found %d node(s) with attribute values matching '%s' in domain '%s'\n%s",
+			totalCount, query, domainName, strings.Join(lines, "\n"))
 	}
 
-	// Convert to MCP response format
-	return map[string]interface{}{
-		"content": []map[string]interface{}{
-			{
-				"type": "text",
-				"text": fmt.Sprintf("Domain Attribute Details:\nDomain: %s\nName: %s\nType: %s\nDescription: %s\nCreated: %s",
-					domainName, foundAttribute.Name(), foundAttribute.Type(), foundAttribute.Description(),
-					foundAttribute.CreatedAt().Format("2006-01-02 15:04:05")),
-			},
-		},
-	}, nil
+	content := []map[string]interface{}{
+		createTextContent(text),
+	}
+
+	structuredContent := map[string]interface{}{
+		"domain_name": domainName,
+		"query":       query,
+		"results":     results,
+		"total_count": totalCount,
+		"page":        page,
+		"total_pages": (totalCount + size - 1) / size,
+		"pagination":  newPagination(page, size, totalCount),
+	}
+
+	return createMCPResponse(content, structuredContent), nil
 }
 
-// handleUpdateDomainAttribute implements the update_domain_attribute tool
-func (h *MCPToolHandler) handleUpdateDomainAttribute(ctx context.Context, args map[string]interface{}) (interface{}, error) {
-	// Parse arguments
-	domainName, ok := args["domain_name"].(string)
-	if !ok || domainName == "" {
-		return nil, fmt.Errorf("missing or invalid 'domain_name' parameter")
+// highlightMatch wraps the first case-insensitive occurrence of term in value with
+// ** markers, reporting whether term occurs at all
+func highlightMatch(value, term string) (string, bool) {
+	for i := 0; i+len(term) <= len(value); i++ {
+		if strings.EqualFold(value[i:i+len(term)], term) {
+			return value[:i] + "**" + value[i:i+len(term)] + "**" + value[i+len(term):], true
+		}
 	}
+	return value, false
+}
 
+// handleFindNodesByAttributeValue implements the find_nodes_by_attribute_value tool
+func (h *MCPToolHandler) handleFindNodesByAttributeValue(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	// Parse arguments
 	attributeName, ok := args["attribute_name"].(string)
 	if !ok || attributeName == "" {
 		return nil, fmt.Errorf("missing or invalid 'attribute_name' parameter")
 	}
 
-	// Get domain first to get domain ID
-	domain, err := h.dependencies.DomainRepo.GetByName(ctx, domainName)
+	value, ok := args["value"].(string)
+	if !ok || value == "" {
+		return nil, fmt.Errorf("missing or invalid 'value' parameter")
+	}
+
+	// Optional pagination parameters
+	page := 1
+	if p, ok := args["page"].(float64); ok && p >= 1 {
+		page = int(p)
+	}
+
+	size := constants.DefaultPageSize
+	if s, ok := args["size"].(float64); ok && s >= 1 {
+		size = int(s)
+	}
+	if size > h.maxPageSize {
+		size = h.maxPageSize
+	}
+
+	counts, err := h.dependencies.NodeAttributeRepo.CountByNameAndValuePerDomain(ctx, attributeName, value)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get domain: %w", err)
+		return nil, fmt.Errorf("failed to count attribute value: %w", err)
 	}
 
-	// Get all attributes for this domain and find the specific one
-	attributes, err := h.dependencies.AttributeRepo.ListByDomainID(ctx, domain.ID())
+	matches, err := h.dependencies.NodeAttributeRepo.FindByNameAndValue(ctx, attributeName, value, page, size)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list domain attributes: %w", err)
+		return nil, fmt.Errorf("failed to find attribute value: %w", err)
 	}
 
-	// Find the specific attribute
-	var foundAttribute *entity.Attribute
-	for _, attr := range attributes {
-		if attr.Name() == attributeName {
-			foundAttribute = attr
-			break
-		}
+	domainNames := make([]string, 0, len(counts))
+	totalCount := 0
+	for domainName, count := range counts {
+		domainNames = append(domainNames, domainName)
+		totalCount += count
 	}
+	sort.Strings(domainNames)
 
-	if foundAttribute == nil {
-		return nil, fmt.Errorf("attribute '%s' not found in domain '%s'", attributeName, domainName)
+	domains := make([]map[string]interface{}, len(domainNames))
+	for i, domainName := range domainNames {
+		domains[i] = map[string]interface{}{"domain_name": domainName, "node_count": counts[domainName]}
 	}
 
-	// Update description if provided
-	updated := false
-	if description, ok := args["description"].(string); ok {
-		foundAttribute.UpdateDescription(description)
-		updated = true
+	nodes := make([]map[string]interface{}, len(matches))
+	lines := make([]string, len(matches))
+	for i, match := range matches {
+		compositeID := h.nodeCompositeID(match.DomainName, match.NodeID)
+		nodes[i] = map[string]interface{}{
+			"composite_id": compositeID,
+			"domain_name":  match.DomainName,
+			"url":          match.URL,
+			"title":        match.Title,
+		}
+		lines[i] = fmt.Sprintf("• %s %s", compositeID, match.URL)
 	}
 
-	if !updated {
-		return nil, fmt.Errorf("at least one field (description) must be provided for update")
+	text := fmt.Sprintf("No nodes have %s = '%s'", attributeName, value)
+	if totalCount > 0 {
+		text = fmt.Sprintf("Found %d node(s) with %s = '%s' in %d domain(s)\n%s",
+			totalCount, attributeName, value, len(domains), strings.Join(lines, "\n"))
 	}
 
-	// Save updated attribute
-	if err := h.dependencies.AttributeRepo.Update(ctx, foundAttribute); err != nil {
-		return nil, fmt.Errorf("failed to update domain attribute: %w", err)
+	content := []map[string]interface{}{
+		createTextContent(text),
 	}
 
-	// Convert to MCP response format
-	return map[string]interface{}{
-		"content": []map[string]interface{}{
-			{
-				"type": "text",
-				"text": fmt.Sprintf("Successfully updated domain attribute:\nDomain: %s\nName: %s\nType: %s\nDescription: %s\nUpdated: %s",
-					domainName, foundAttribute.Name(), foundAttribute.Type(), foundAttribute.Description(),
-					foundAttribute.UpdatedAt().Format("2006-01-02 15:04:05")),
-			},
-		},
-	}, nil
+	structuredContent := map[string]interface{}{
+		"attribute_name": attributeName,
+		"value":          value,
+		"domains":        domains,
+		"nodes":          nodes,
+		"total_count":    totalCount,
+		"page":           page,
+		"total_pages":    (totalCount + size - 1) / size,
+		"pagination":     newPagination(page, size, totalCount),
+	}
+
+	return createMCPResponse(content, structuredContent), nil
 }
 
-// handleDeleteDomainAttribute implements the delete_domain_attribute tool
-func (h *MCPToolHandler) handleDeleteDomainAttribute(ctx context.Context, args map[string]interface{}) (interface{}, error) {
-	// Parse arguments
+// handleSuggestAttributeValues implements the suggest_attribute_values tool
+func (h *MCPToolHandler) handleSuggestAttributeValues(ctx context.Context, args map[string]interface{}) (interface{}, error) {
 	domainName, ok := args["domain_name"].(string)
 	if !ok || domainName == "" {
 		return nil, fmt.Errorf("missing or invalid 'domain_name' parameter")
@@ -851,294 +4590,363 @@ func (h *MCPToolHandler) handleDeleteDomainAttribute(ctx context.Context, args m
 		return nil, fmt.Errorf("missing or invalid 'attribute_name' parameter")
 	}
 
-	// Get domain first to get domain ID
+	prefix, _ := args["prefix"].(string)
+
+	limit := constants.DefaultPageSize
+	if l, ok := args["limit"].(float64); ok && l >= 1 {
+		limit = int(l)
+	}
+	if limit > h.maxPageSize {
+		limit = h.maxPageSize
+	}
+
 	domain, err := h.dependencies.DomainRepo.GetByName(ctx, domainName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get domain: %w", err)
 	}
+	if domain == nil {
+		return nil, fmt.Errorf("domain '%s' not found", domainName)
+	}
 
-	// Get all attributes for this domain and find the specific one
-	attributes, err := h.dependencies.AttributeRepo.ListByDomainID(ctx, domain.ID())
+	attr, err := h.dependencies.AttributeRepo.GetByName(ctx, domain.ID(), attributeName)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list domain attributes: %w", err)
+		return nil, fmt.Errorf("failed to get attribute: %w", err)
+	}
+	if attr == nil {
+		return nil, fmt.Errorf("attribute '%s' not defined in domain '%s'", attributeName, domainName)
 	}
 
-	// Find the specific attribute
-	var foundAttribute *entity.Attribute
-	for _, attr := range attributes {
-		if attr.Name() == attributeName {
-			foundAttribute = attr
-			break
-		}
+	counts, err := h.dependencies.NodeAttributeRepo.CountValues(ctx, attr.ID(), prefix, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to suggest attribute values: %w", err)
 	}
 
-	if foundAttribute == nil {
-		return nil, fmt.Errorf("attribute '%s' not found in domain '%s'", attributeName, domainName)
+	suggestions := make([]map[string]interface{}, len(counts))
+	lines := make([]string, len(counts))
+	for i, count := range counts {
+		suggestions[i] = map[string]interface{}{"value": count.Value, "node_count": count.Count}
+		lines[i] = fmt.Sprintf("• %s (%d)", count.Value, count.Count)
 	}
 
-	// Delete the attribute
-	if err := h.dependencies.AttributeRepo.Delete(ctx, foundAttribute.ID()); err != nil {
-		return nil, fmt.Errorf("failed to delete domain attribute: %w", err)
+	text := fmt.Sprintf("No existing values for %s in domain %s", attributeName, domainName)
+	if prefix != "" {
+		text += fmt.Sprintf(" starting with '%s'", prefix)
+	}
+	if len(counts) > 0 {
+		text = fmt.Sprintf("Existing values for %s, most used first:\n%s", attributeName, strings.Join(lines, "\n"))
 	}
 
-	// Convert to MCP response format
-	return map[string]interface{}{
-		"content": []map[string]interface{}{
-			{
-				"type": "text",
-				"text": fmt.Sprintf("Successfully deleted domain attribute:\nDomain: %s\nName: %s\nType: %s",
-					domainName, foundAttribute.Name(), foundAttribute.Type()),
-			},
-		},
-	}, nil
+	content := []map[string]interface{}{
+		createTextContent(text),
+	}
+
+	structuredContent := map[string]interface{}{
+		"domain_name":    domainName,
+		"attribute_name": attributeName,
+		"attribute_type": attr.Type(),
+		"prefix":         prefix,
+		"values":         suggestions,
+	}
+
+	return createMCPResponse(content, structuredContent), nil
 }
 
-// Dependency Management Tools
+// handleGetAttributeStatistics implements the get_attribute_statistics tool
+func (h *MCPToolHandler) handleGetAttributeStatistics(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	domainName, ok := args["domain_name"].(string)
+	if !ok || domainName == "" {
+		return nil, fmt.Errorf("missing or invalid 'domain_name' parameter")
+	}
+
+	topValues := 5
+	if n, ok := args["top_values"].(float64); ok && n >= 0 {
+		topValues = int(n)
+	}
+	if topValues > h.maxPageSize {
+		topValues = h.maxPageSize
+	}
 
-// parseCompositeID is a helper function to parse composite IDs
-func parseCompositeID(compositeID string) (int, error) {
-	parts := strings.Split(compositeID, ":")
-	if len(parts) != 3 {
-		return 0, fmt.Errorf("invalid composite_id format, expected 'tool-name:domain:id'")
+	domain, err := h.dependencies.DomainRepo.GetByName(ctx, domainName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get domain: %w", err)
+	}
+	if domain == nil {
+		return nil, fmt.Errorf("domain '%s' not found", domainName)
 	}
 
-	nodeID, err := strconv.Atoi(parts[2])
+	nodeCount, err := h.dependencies.NodeRepo.CountByDomain(ctx, domain.ID())
 	if err != nil {
-		return 0, fmt.Errorf("invalid node ID in composite_id: %v", err)
+		return nil, fmt.Errorf("failed to count nodes: %w", err)
 	}
 
-	return nodeID, nil
-}
+	stats, err := h.dependencies.NodeAttributeRepo.GetUsageStats(ctx, domain.ID())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attribute statistics: %w", err)
+	}
+
+	attributes := make([]map[string]interface{}, len(stats))
+	lines := make([]string, len(stats))
+	for i, usage := range stats {
+		values := []map[string]interface{}{}
+		var valueTexts []string
+		if topValues > 0 && usage.DistinctValues > 0 {
+			counts, err := h.dependencies.NodeAttributeRepo.CountValues(ctx, usage.AttributeID, "", topValues)
+			if err != nil {
+				return nil, fmt.Errorf("failed to count values of attribute '%s': %w", usage.Name, err)
+			}
+			for _, count := range counts {
+				values = append(values, map[string]interface{}{"value": count.Value, "node_count": count.Count})
+				valueTexts = append(valueTexts, fmt.Sprintf("%s (%d)", count.Value, count.Count))
+			}
+		}
 
-// handleCreateDependency implements the create_dependency tool
-func (h *MCPToolHandler) handleCreateDependency(ctx context.Context, args map[string]interface{}) (interface{}, error) {
-	// Parse arguments
-	dependentNodeID, ok := args["dependent_node_id"].(string)
-	if !ok || dependentNodeID == "" {
-		return nil, fmt.Errorf("missing or invalid 'dependent_node_id' parameter")
+		attributes[i] = map[string]interface{}{
+			"name":            usage.Name,
+			"type":            usage.Type,
+			"node_count":      usage.NodeCount,
+			"distinct_values": usage.DistinctValues,
+			"top_values":      values,
+		}
+		lines[i] = fmt.Sprintf("• %s (%s): %d of %d nodes, %d distinct values", usage.Name, usage.Type, usage.NodeCount, nodeCount, usage.DistinctValues)
+		if len(valueTexts) > 0 {
+			lines[i] += "; top: " + strings.Join(valueTexts, ", ")
+		}
 	}
 
-	dependencyNodeID, ok := args["dependency_node_id"].(string)
-	if !ok || dependencyNodeID == "" {
-		return nil, fmt.Errorf("missing or invalid 'dependency_node_id' parameter")
+	text := fmt.Sprintf("No attributes defined in domain %s", domainName)
+	if len(stats) > 0 {
+		text = fmt.Sprintf("Attribute usage in domain %s (%d nodes):\n%s", domainName, nodeCount, strings.Join(lines, "\n"))
 	}
 
-	dependencyType, ok := args["dependency_type"].(string)
-	if !ok || dependencyType == "" {
-		return nil, fmt.Errorf("missing or invalid 'dependency_type' parameter")
+	content := []map[string]interface{}{
+		createTextContent(text),
 	}
 
-	// Validate dependency type
-	validTypes := []string{"hard", "soft", "reference"}
-	isValid := false
-	for _, validType := range validTypes {
-		if dependencyType == validType {
-			isValid = true
-			break
-		}
-	}
-	if !isValid {
-		return nil, fmt.Errorf("invalid dependency_type: %s. Must be one of: hard, soft, reference", dependencyType)
+	structuredContent := map[string]interface{}{
+		"domain_name": domainName,
+		"node_count":  nodeCount,
+		"attributes":  attributes,
 	}
 
-	// Parse composite IDs
-	depNodeID, err := parseCompositeID(dependentNodeID)
-	if err != nil {
-		return nil, fmt.Errorf("invalid dependent_node_id: %w", err)
+	return createMCPResponse(content, structuredContent), nil
+}
+
+// handleRenameAttributeValue implements the rename_attribute_value tool
+func (h *MCPToolHandler) handleRenameAttributeValue(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	domainName, ok := args["domain_name"].(string)
+	if !ok || domainName == "" {
+		return nil, fmt.Errorf("missing or invalid 'domain_name' parameter")
 	}
 
-	depyNodeID, err := parseCompositeID(dependencyNodeID)
-	if err != nil {
-		return nil, fmt.Errorf("invalid dependency_node_id: %w", err)
+	attributeName, ok := args["attribute_name"].(string)
+	if !ok || attributeName == "" {
+		return nil, fmt.Errorf("missing or invalid 'attribute_name' parameter")
 	}
 
-	// Prevent self-dependency
-	if depNodeID == depyNodeID {
-		return nil, fmt.Errorf("a node cannot depend on itself")
+	oldValue, ok := args["old_value"].(string)
+	if !ok || oldValue == "" {
+		return nil, fmt.Errorf("missing or invalid 'old_value' parameter")
 	}
 
-	// Optional parameters
-	cascadeDelete := false
-	if cd, ok := args["cascade_delete"].(bool); ok {
-		cascadeDelete = cd
+	newValue, ok := args["new_value"].(string)
+	if !ok || newValue == "" {
+		return nil, fmt.Errorf("missing or invalid 'new_value' parameter")
 	}
 
-	cascadeUpdate := false
-	if cu, ok := args["cascade_update"].(bool); ok {
-		cascadeUpdate = cu
+	dryRun, _ := args["dry_run"].(bool)
+
+	result, err := h.dependencies.RenameAttributeValueUC.Execute(ctx, domainName, attributeName, oldValue, newValue, dryRun)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rename attribute value: %w", err)
 	}
 
-	description := ""
-	if d, ok := args["description"].(string); ok {
-		description = d
+	verb := "Renamed"
+	if dryRun {
+		verb = "Would rename"
 	}
+	text := fmt.Sprintf("%s %s '%s' to '%s' in domain %s: %d values changed, %d merged into nodes already holding '%s'",
+		verb, attributeName, result.OldValue, result.NewValue, domainName, result.Renamed, result.Merged, result.NewValue)
 
-	// Verify both nodes exist
-	_, err = h.dependencies.NodeRepo.GetByID(ctx, depNodeID)
-	if err != nil {
-		return nil, fmt.Errorf("dependent node not found: %w", err)
+	content := []map[string]interface{}{
+		createTextContent(text),
 	}
 
-	_, err = h.dependencies.NodeRepo.GetByID(ctx, depyNodeID)
-	if err != nil {
-		return nil, fmt.Errorf("dependency node not found: %w", err)
+	structuredContent := map[string]interface{}{
+		"domain_name":    domainName,
+		"attribute_name": attributeName,
+		"old_value":      result.OldValue,
+		"new_value":      result.NewValue,
+		"renamed_count":  result.Renamed,
+		"merged_count":   result.Merged,
+		"changed_count":  result.Renamed + result.Merged,
+		"dry_run":        dryRun,
 	}
 
-	// TODO: Use a proper dependency repository when available
-	// For now, we'll use a direct database approach similar to other implementations
-	return map[string]interface{}{
-		"content": []map[string]interface{}{
-			{
-				"type": "text",
-				"text": fmt.Sprintf("Successfully created dependency:\nDependent: %s\nDependency: %s\nType: %s\nCascade Delete: %t\nCascade Update: %t\nDescription: %s\n\nNote: Full dependency creation will be implemented with proper repository",
-					dependentNodeID, dependencyNodeID, dependencyType, cascadeDelete, cascadeUpdate, description),
-			},
-		},
-	}, nil
+	return createMCPResponse(content, structuredContent), nil
 }
 
-// handleListNodeDependencies implements the list_node_dependencies tool
-func (h *MCPToolHandler) handleListNodeDependencies(ctx context.Context, args map[string]interface{}) (interface{}, error) {
-	// Parse composite_id argument
-	compositeID, ok := args["composite_id"].(string)
-	if !ok || compositeID == "" {
-		return nil, fmt.Errorf("missing or invalid 'composite_id' parameter")
+// handleGetFacets implements the get_facets tool
+func (h *MCPToolHandler) handleGetFacets(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	// Parse arguments
+	domainName, ok := args["domain_name"].(string)
+	if !ok || domainName == "" {
+		return nil, fmt.Errorf("missing or invalid 'domain_name' parameter")
 	}
 
-	// Parse composite ID to extract node ID
-	nodeID, err := parseCompositeID(compositeID)
-	if err != nil {
-		return nil, fmt.Errorf("invalid composite_id: %w", err)
+	attributesRaw, ok := args["attributes"].([]interface{})
+	if !ok || len(attributesRaw) == 0 {
+		return nil, fmt.Errorf("missing or invalid 'attributes' parameter, expected a non-empty array of attribute names")
+	}
+	attributeNames := make([]string, 0, len(attributesRaw))
+	seen := make(map[string]bool, len(attributesRaw))
+	for i, raw := range attributesRaw {
+		name, ok := raw.(string)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid attribute name at index %d", i)
+		}
+		if !seen[name] {
+			seen[name] = true
+			attributeNames = append(attributeNames, name)
+		}
 	}
 
-	// Verify node exists
-	node, err := h.dependencies.NodeRepo.GetByID(ctx, nodeID)
-	if err != nil {
-		return nil, fmt.Errorf("node not found: %w", err)
+	// The optional base filter restricts facets to the current result set
+	var filters []repository.AttributeFilter
+	if filtersRaw, ok := args["filters"]; ok {
+		var err error
+		filters, err = h.parseAttributeFilters(filtersRaw)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	// TODO: Query dependencies from database when repository is available
-	// For now, return placeholder response
-	return map[string]interface{}{
-		"content": []map[string]interface{}{
-			{
-				"type": "text",
-				"text": fmt.Sprintf("Dependencies for node: %s\nURL: %s\n\nNote: Dependency listing will be implemented with proper repository",
-					node.Title(), node.URL()),
-			},
-		},
-	}, nil
-}
+	limit := constants.DefaultPageSize
+	if l, ok := args["limit"].(float64); ok && l >= 1 {
+		limit = int(l)
+	}
+	if limit > h.maxPageSize {
+		limit = h.maxPageSize
+	}
 
-// handleListNodeDependents implements the list_node_dependents tool
-func (h *MCPToolHandler) handleListNodeDependents(ctx context.Context, args map[string]interface{}) (interface{}, error) {
-	// Parse composite_id argument
-	compositeID, ok := args["composite_id"].(string)
-	if !ok || compositeID == "" {
-		return nil, fmt.Errorf("missing or invalid 'composite_id' parameter")
+	domain, err := h.dependencies.DomainRepo.GetByName(ctx, domainName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get domain: %w", err)
+	}
+	if domain == nil {
+		return nil, fmt.Errorf("domain not found: %s", domainName)
 	}
 
-	// Parse composite ID to extract node ID
-	nodeID, err := parseCompositeID(compositeID)
+	filters, err = h.dependencies.FilterNodesUC.PrepareFilters(ctx, domainName, filters)
 	if err != nil {
-		return nil, fmt.Errorf("invalid composite_id: %w", err)
+		return nil, err
 	}
 
-	// Verify node exists
-	node, err := h.dependencies.NodeRepo.GetByID(ctx, nodeID)
+	counts, err := h.dependencies.NodeRepo.CountAttributeValues(ctx, domainName, attributeNames, filters)
 	if err != nil {
-		return nil, fmt.Errorf("node not found: %w", err)
+		return nil, fmt.Errorf("failed to count attribute values: %w", err)
 	}
 
-	// TODO: Query dependents from database when repository is available
-	// For now, return placeholder response
-	return map[string]interface{}{
-		"content": []map[string]interface{}{
-			{
-				"type": "text",
-				"text": fmt.Sprintf("Dependents for node: %s\nURL: %s\n\nNote: Dependent listing will be implemented with proper repository",
-					node.Title(), node.URL()),
-			},
-		},
-	}, nil
-}
+	facets := make([]map[string]interface{}, len(attributeNames))
+	lines := []string{fmt.Sprintf("Facets for domain '%s' (%d filter(s) applied)", domainName, len(filters))}
+	for i, name := range attributeNames {
+		valueCounts := counts[name]
+		totalValues := len(valueCounts)
+		if len(valueCounts) > limit {
+			valueCounts = valueCounts[:limit]
+		}
 
-// handleDeleteDependency implements the delete_dependency tool
-func (h *MCPToolHandler) handleDeleteDependency(ctx context.Context, args map[string]interface{}) (interface{}, error) {
-	// Parse dependency_id argument
-	dependencyIDRaw, ok := args["dependency_id"]
-	if !ok {
-		return nil, fmt.Errorf("missing 'dependency_id' parameter")
-	}
+		values := make([]map[string]interface{}, len(valueCounts))
+		parts := make([]string, len(valueCounts))
+		for j, valueCount := range valueCounts {
+			values[j] = map[string]interface{}{"value": valueCount.Value, "count": valueCount.Count}
+			parts[j] = fmt.Sprintf("%s (%d)", valueCount.Value, valueCount.Count)
+		}
 
-	var dependencyID int
-	switch v := dependencyIDRaw.(type) {
-	case float64:
-		dependencyID = int(v)
-	case int:
-		dependencyID = v
-	case string:
-		var err error
-		dependencyID, err = strconv.Atoi(v)
-		if err != nil {
-			return nil, fmt.Errorf("invalid dependency_id format: %v", err)
+		facets[i] = map[string]interface{}{
+			"attribute":    name,
+			"values":       values,
+			"total_values": totalValues,
+		}
+		if len(parts) == 0 {
+			lines = append(lines, fmt.Sprintf("%s: no values", name))
+		} else {
+			lines = append(lines, fmt.Sprintf("%s: %s", name, strings.Join(parts, ", ")))
 		}
-	default:
-		return nil, fmt.Errorf("invalid dependency_id type, expected number or string")
 	}
 
-	if dependencyID <= 0 {
-		return nil, fmt.Errorf("dependency_id must be positive")
+	content := []map[string]interface{}{
+		createTextContent(strings.Join(lines, "\n")),
 	}
 
-	// TODO: Delete dependency from database when repository is available
-	// For now, return placeholder response
-	return map[string]interface{}{
-		"content": []map[string]interface{}{
-			{
-				"type": "text",
-				"text": fmt.Sprintf("Would delete dependency with ID: %d\n\nNote: Dependency deletion will be implemented with proper repository",
-					dependencyID),
-			},
-		},
-	}, nil
+	structuredContent := map[string]interface{}{
+		"domain_name":     domainName,
+		"facets":          facets,
+		"filters_applied": len(filters),
+	}
+
+	return createMCPResponse(content, structuredContent), nil
 }
 
-// handleFilterNodesByAttributes implements the filter_nodes_by_attributes tool
-func (h *MCPToolHandler) handleFilterNodesByAttributes(ctx context.Context, args map[string]interface{}) (interface{}, error) {
-	// Parse domain_name argument
-	domainName, ok := args["domain_name"].(string)
-	if !ok || domainName == "" {
-		return nil, fmt.Errorf("missing or invalid 'domain_name' parameter")
+// parseAttributeFilters converts a filters argument into repository filters.
+// Each filter adds a subquery to the query, so the number per call, counting
+// groups and their nested filters, is capped.
+func (h *MCPToolHandler) parseAttributeFilters(filtersRaw interface{}) ([]repository.AttributeFilter, error) {
+	count := 0
+	filters, err := h.parseAttributeFilterList(filtersRaw, "filters", &count)
+	if err != nil {
+		return nil, err
 	}
 
-	// Parse filters argument
-	filtersRaw, ok := args["filters"]
-	if !ok {
-		return nil, fmt.Errorf("missing 'filters' parameter")
+	if h.maxFilters > 0 && count > h.maxFilters {
+		return nil, fmt.Errorf("too many filters: got %d, at most %d are allowed per request", count, h.maxFilters)
 	}
 
+	return filters, nil
+}
+
+// parseAttributeFilterList parses the filters at path, adding their number to count.
+// An object with a "filters" array is a group combined by its "logic".
+func (h *MCPToolHandler) parseAttributeFilterList(filtersRaw interface{}, path string, count *int) ([]repository.AttributeFilter, error) {
 	filtersArray, ok := filtersRaw.([]interface{})
 	if !ok {
-		return nil, fmt.Errorf("invalid 'filters' parameter, expected array")
+		return nil, fmt.Errorf("invalid '%s' parameter, expected array", path)
 	}
 
-	// Convert filters to repository format
+	*count += len(filtersArray)
+
 	var filters []repository.AttributeFilter
 	for i, filterRaw := range filtersArray {
 		filterMap, ok := filterRaw.(map[string]interface{})
 		if !ok {
-			return nil, fmt.Errorf("invalid filter at index %d, expected object", i)
+			return nil, fmt.Errorf("invalid filter at %s[%d], expected object", path, i)
+		}
+
+		if nestedRaw, ok := filterMap["filters"]; ok {
+			groupPath := fmt.Sprintf("%s[%d].filters", path, i)
+			nested, err := h.parseAttributeFilterList(nestedRaw, groupPath, count)
+			if err != nil {
+				return nil, err
+			}
+			if len(nested) == 0 {
+				return nil, fmt.Errorf("filter group at %s[%d] has no filters", path, i)
+			}
+
+			logic, _ := filterMap["logic"].(string)
+			filters = append(filters, repository.AttributeFilter{
+				Logic:   logic,
+				Filters: nested,
+			})
+			continue
 		}
 
 		name, ok := filterMap["name"].(string)
 		if !ok || name == "" {
-			return nil, fmt.Errorf("missing or invalid 'name' in filter at index %d", i)
+			return nil, fmt.Errorf("missing or invalid 'name' in filter at %s[%d]", path, i)
 		}
 
 		value, ok := filterMap["value"].(string)
 		if !ok || value == "" {
-			return nil, fmt.Errorf("missing or invalid 'value' in filter at index %d", i)
+			return nil, fmt.Errorf("missing or invalid 'value' in filter at %s[%d]", path, i)
 		}
 
 		operator := "equals" // default operator
@@ -1146,24 +4954,66 @@ func (h *MCPToolHandler) handleFilterNodesByAttributes(ctx context.Context, args
 			operator = op
 		}
 
+		// Upper bound of the "between" operator
+		valueTo, _ := filterMap["value_to"].(string)
+
 		filters = append(filters, repository.AttributeFilter{
 			Name:     name,
 			Value:    value,
+			ValueTo:  valueTo,
 			Operator: operator,
 		})
 	}
 
+	return filters, nil
+}
+
+// parseFilterNodesArguments parses the domain_name, filters, logic, page and size
+// arguments of filter_nodes_by_attributes and explain_filter
+func (h *MCPToolHandler) parseFilterNodesArguments(args map[string]interface{}) (string, []repository.AttributeFilter, int, int, error) {
+	// Parse domain_name argument
+	domainName, ok := args["domain_name"].(string)
+	if !ok || domainName == "" {
+		return "", nil, 0, 0, fmt.Errorf("missing or invalid 'domain_name' parameter")
+	}
+
+	// Parse filters argument
+	filtersRaw, ok := args["filters"]
+	if !ok {
+		return "", nil, 0, 0, fmt.Errorf("missing 'filters' parameter")
+	}
+
+	filters, err := h.parseAttributeFilters(filtersRaw)
+	if err != nil {
+		return "", nil, 0, 0, err
+	}
+
+	// The top-level filters are ANDed unless logic says otherwise
+	if logic, ok := args["logic"].(string); ok && logic != "" && len(filters) > 0 {
+		filters = []repository.AttributeFilter{{Logic: logic, Filters: filters}}
+	}
+
 	// Optional pagination parameters
 	page := 1
 	if p, ok := args["page"].(float64); ok {
 		page = int(p)
 	}
 
-	size := 20
+	size := constants.DefaultPageSize
 	if s, ok := args["size"].(float64); ok {
 		size = int(s)
 	}
 
+	return domainName, filters, page, size, nil
+}
+
+// handleFilterNodesByAttributes implements the filter_nodes_by_attributes tool
+func (h *MCPToolHandler) handleFilterNodesByAttributes(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	domainName, filters, page, size, err := h.parseFilterNodesArguments(args)
+	if err != nil {
+		return nil, err
+	}
+
 	// Execute filter use case
 	result, err := h.dependencies.FilterNodesUC.Execute(ctx, domainName, filters, page, size)
 	if err != nil {
@@ -1195,10 +5045,28 @@ func (h *MCPToolHandler) handleFilterNodesByAttributes(ctx context.Context, args
 			})
 		}
 	}
+	if note := pageSizeCappedText(size, result.Size); note != "" {
+		content = append(content, createTextContent(note))
+	}
 
-	return map[string]interface{}{
-		"content": content,
-	}, nil
+	structuredNodes := make([]map[string]interface{}, 0, len(result.Nodes))
+	for _, node := range result.Nodes {
+		structuredNodes = append(structuredNodes, map[string]interface{}{
+			"composite_id": h.nodeCompositeID(domainName, node.ID),
+			"url":          node.URL,
+			"title":        node.Title,
+			"description":  node.Description,
+			"created_at":   node.CreatedAt.Format(time.RFC3339),
+		})
+	}
+
+	structuredContent := map[string]interface{}{
+		"domain_name": domainName,
+		"nodes":       structuredNodes,
+		"pagination":  newPagination(result.Page, result.Size, result.TotalCount),
+	}
+
+	return createMCPResponse(content, structuredContent), nil
 }
 
 // handleGetNodeWithAttributes implements the get_node_with_attributes tool
@@ -1210,15 +5078,14 @@ func (h *MCPToolHandler) handleGetNodeWithAttributes(ctx context.Context, args m
 	}
 
 	// Parse composite ID to extract node ID
-	parts := strings.Split(compositeID, ":")
-	if len(parts) != 3 {
-		return nil, fmt.Errorf("invalid composite_id format, expected 'tool-name:domain:id'")
+	nodeID, err := h.parseCompositeID(compositeID)
+	if err != nil {
+		return nil, err
 	}
 
-	nodeIDStr := parts[2]
-	nodeID, err := strconv.Atoi(nodeIDStr)
+	format, err := parseAttributeFormat(args)
 	if err != nil {
-		return nil, fmt.Errorf("invalid node ID in composite_id: %v", err)
+		return nil, err
 	}
 
 	// Execute use case
@@ -1252,14 +5119,32 @@ func (h *MCPToolHandler) handleGetNodeWithAttributes(ctx context.Context, args m
 		responseText.WriteString("\nNo attributes found for this node.\n")
 	}
 
-	return map[string]interface{}{
-		"content": []map[string]interface{}{
-			{
-				"type": "text",
-				"text": responseText.String(),
-			},
-		},
-	}, nil
+	attributes := make([]map[string]interface{}, 0, len(result.Attributes))
+	for _, attr := range result.Attributes {
+		attributes = append(attributes, map[string]interface{}{
+			"name":        attr.AttributeName,
+			"type":        attr.AttributeType,
+			"value":       attr.Value,
+			"order_index": attr.OrderIndex,
+		})
+	}
+
+	content := []map[string]interface{}{
+		createTextContent(responseText.String()),
+	}
+
+	structuredContent := map[string]interface{}{
+		"composite_id": compositeID,
+		"url":          result.Node.URL,
+		"title":        result.Node.Title,
+		"description":  result.Node.Description,
+		"domain_name":  result.Node.DomainName,
+		"created_at":   result.Node.CreatedAt,
+		"updated_at":   result.Node.UpdatedAt,
+		"attributes":   formatAttributes(attributes, format),
+	}
+
+	return createMCPResponse(content, structuredContent), nil
 }
 
 // Template Management Tools
@@ -1277,7 +5162,7 @@ func (h *MCPToolHandler) handleListTemplates(ctx context.Context, args map[strin
 		page = int(p)
 	}
 
-	size := 20
+	size := constants.DefaultPageSize
 	if s, ok := args["size"].(float64); ok {
 		size = int(s)
 	}
@@ -1315,7 +5200,7 @@ func (h *MCPToolHandler) handleListTemplates(ctx context.Context, args map[strin
 		templateVersion, _ := template.GetTemplateVersion()
 
 		content = append(content, map[string]interface{}{
-			"composite_id": fmt.Sprintf("url-db:%s:template:%d", domainName, template.ID()),
+			"composite_id": h.templateCompositeID(domainName, template.ID()),
 			"name":         template.Name(),
 			"type":         templateType,
 			"version":      templateVersion,
@@ -1327,15 +5212,18 @@ func (h *MCPToolHandler) handleListTemplates(ctx context.Context, args map[strin
 		})
 	}
 
-	return map[string]interface{}{
-		"content": []map[string]interface{}{
-			{
-				"type": "text",
-				"text": fmt.Sprintf("Found %d templates (page %d, total: %d):\n\n%s",
-					len(templates), page, total, formatTemplateList(content)),
-			},
-		},
-	}, nil
+	text := []map[string]interface{}{
+		createTextContent(fmt.Sprintf("Found %d templates (page %d, total: %d):\n\n%s",
+			len(templates), page, total, formatTemplateList(content))),
+	}
+
+	structuredContent := map[string]interface{}{
+		"domain_name": domainName,
+		"templates":   content,
+		"pagination":  newPagination(page, size, total),
+	}
+
+	return createMCPResponse(text, structuredContent), nil
 }
 
 // handleCreateTemplate implements the create_template tool
@@ -1385,9 +5273,8 @@ func (h *MCPToolHandler) handleCreateTemplate(ctx context.Context, args map[stri
 		"content": []map[string]interface{}{
 			{
 				"type": "text",
-				"text": fmt.Sprintf("Template created successfully!\n\nComposite ID: url-db:%s:template:%d\nName: %s\nType: %s\nVersion: %s\nTitle: %s\nDescription: %s\nStatus: %s\nCreated: %s",
-					domainName,
-					template.ID(),
+				"text": fmt.Sprintf("Template created successfully!\n\nComposite ID: %s\nName: %s\nType: %s\nVersion: %s\nTitle: %s\nDescription: %s\nStatus: %s\nCreated: %s",
+					h.templateCompositeID(domainName, template.ID()),
 					template.Name(),
 					templateType,
 					templateVersion,
@@ -1407,16 +5294,12 @@ func (h *MCPToolHandler) handleGetTemplate(ctx context.Context, args map[string]
 		return nil, fmt.Errorf("composite_id is required")
 	}
 
-	// Parse composite ID: url-db:domain:template:id
-	parts := strings.Split(compositeID, ":")
-	if len(parts) != 4 || parts[2] != "template" {
-		return nil, fmt.Errorf("invalid template composite_id format, expected: tool:domain:template:id")
-	}
-
-	id, err := strconv.Atoi(parts[3])
+	// Parse composite ID: tool-name:domain:template:id
+	parsed, err := h.parseTemplateCompositeID(compositeID)
 	if err != nil {
-		return nil, fmt.Errorf("invalid template ID in composite_id: %w", err)
+		return nil, err
 	}
+	id := parsed.ID
 
 	template, err := h.dependencies.TemplateService.GetTemplate(ctx, id)
 	if err != nil {
@@ -1443,7 +5326,7 @@ func (h *MCPToolHandler) handleGetTemplate(ctx context.Context, args map[string]
 					getTemplateStatus(template.IsActive()),
 					template.CreatedAt().Format("2006-01-02 15:04:05"),
 					template.UpdatedAt().Format("2006-01-02 15:04:05"),
-					template.TemplateData()),
+					h.formatJSONText(template.TemplateData())),
 			},
 		},
 	}, nil
@@ -1456,16 +5339,12 @@ func (h *MCPToolHandler) handleUpdateTemplate(ctx context.Context, args map[stri
 		return nil, fmt.Errorf("composite_id is required")
 	}
 
-	// Parse composite ID
-	parts := strings.Split(compositeID, ":")
-	if len(parts) != 4 || parts[2] != "template" {
-		return nil, fmt.Errorf("invalid template composite_id format")
-	}
-
-	id, err := strconv.Atoi(parts[3])
+	// Parse composite ID: tool-name:domain:template:id
+	parsed, err := h.parseTemplateCompositeID(compositeID)
 	if err != nil {
-		return nil, fmt.Errorf("invalid template ID in composite_id: %w", err)
+		return nil, err
 	}
+	id := parsed.ID
 
 	req := &service.UpdateTemplateRequest{}
 
@@ -1521,16 +5400,12 @@ func (h *MCPToolHandler) handleDeleteTemplate(ctx context.Context, args map[stri
 		return nil, fmt.Errorf("composite_id is required")
 	}
 
-	// Parse composite ID
-	parts := strings.Split(compositeID, ":")
-	if len(parts) != 4 || parts[2] != "template" {
-		return nil, fmt.Errorf("invalid template composite_id format")
-	}
-
-	id, err := strconv.Atoi(parts[3])
+	// Parse composite ID: tool-name:domain:template:id
+	parsed, err := h.parseTemplateCompositeID(compositeID)
 	if err != nil {
-		return nil, fmt.Errorf("invalid template ID in composite_id: %w", err)
+		return nil, err
 	}
+	id := parsed.ID
 
 	// Get template name before deletion for response
 	template, err := h.dependencies.TemplateService.GetTemplate(ctx, id)
@@ -1571,16 +5446,12 @@ func (h *MCPToolHandler) handleCloneTemplate(ctx context.Context, args map[strin
 		return nil, fmt.Errorf("new_name is required")
 	}
 
-	// Parse source composite ID
-	parts := strings.Split(sourceCompositeID, ":")
-	if len(parts) != 4 || parts[2] != "template" {
-		return nil, fmt.Errorf("invalid source template composite_id format")
-	}
-
-	sourceID, err := strconv.Atoi(parts[3])
+	// Parse source composite ID: tool-name:domain:template:id
+	parsed, err := h.parseTemplateCompositeID(sourceCompositeID)
 	if err != nil {
-		return nil, fmt.Errorf("invalid template ID in source_composite_id: %w", err)
+		return nil, err
 	}
+	sourceID := parsed.ID
 
 	newTitle := ""
 	if t, ok := args["new_title"].(string); ok {
@@ -1592,139 +5463,462 @@ func (h *MCPToolHandler) handleCloneTemplate(ctx context.Context, args map[strin
 		newDescription = d
 	}
 
-	clonedTemplate, err := h.dependencies.TemplateService.CloneTemplate(ctx, sourceID, newName, newTitle, newDescription)
+	clonedTemplate, err := h.dependencies.TemplateService.CloneTemplate(ctx, sourceID, newName, newTitle, newDescription)
+	if err != nil {
+		return nil, fmt.Errorf("failed to clone template: %w", err)
+	}
+
+	domainName := parsed.DomainName
+	templateType, _ := clonedTemplate.GetTemplateType()
+	templateVersion, _ := clonedTemplate.GetTemplateVersion()
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": fmt.Sprintf("Template cloned successfully!\n\nSource: %s\nNew Composite ID: %s\nNew Name: %s\nType: %s\nVersion: %s\nTitle: %s\nDescription: %s\nCreated: %s",
+					sourceCompositeID,
+					h.templateCompositeID(domainName, clonedTemplate.ID()),
+					clonedTemplate.Name(),
+					templateType,
+					templateVersion,
+					clonedTemplate.Title(),
+					clonedTemplate.Description(),
+					clonedTemplate.CreatedAt().Format("2006-01-02 15:04:05")),
+			},
+		},
+	}, nil
+}
+
+// handleGenerateTemplateScaffold implements the generate_template_scaffold tool
+func (h *MCPToolHandler) handleGenerateTemplateScaffold(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	templateType, ok := args["template_type"].(string)
+	if !ok || templateType == "" {
+		return nil, fmt.Errorf("template_type is required")
+	}
+
+	scaffold, err := h.dependencies.TemplateService.GenerateTemplateScaffold(templateType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate template scaffold: %w", err)
+	}
+
+	content := []map[string]interface{}{
+		createTextContent(fmt.Sprintf("Template scaffold for type '%s':\n\n%s\n\nYou can use this as a starting point for creating a new template. Copy the JSON data and use it with the create_template tool.",
+			templateType,
+			h.formatJSONText(scaffold))),
+	}
+
+	// Structured content always carries the scaffold as a JSON object
+	var scaffoldData map[string]interface{}
+	if err := json.Unmarshal([]byte(scaffold), &scaffoldData); err != nil {
+		return nil, fmt.Errorf("failed to decode template scaffold: %w", err)
+	}
+
+	structuredContent := map[string]interface{}{
+		"template_type": templateType,
+		"scaffold":      scaffoldData,
+	}
+
+	return createMCPResponse(content, structuredContent), nil
+}
+
+// handleValidateTemplate implements the validate_template tool
+func (h *MCPToolHandler) handleValidateTemplate(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	templateData, ok := args["template_data"].(string)
+	if !ok || templateData == "" {
+		return nil, fmt.Errorf("template_data is required")
+	}
+
+	result, err := h.dependencies.TemplateService.ValidateTemplateData(templateData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate template: %w", err)
+	}
+
+	if result.Valid {
+		// Extract type and version for additional info
+		templateType, _ := h.dependencies.TemplateService.ExtractTemplateType(templateData)
+		templateVersion, _ := h.dependencies.TemplateService.ExtractTemplateVersion(templateData)
+
+		return map[string]interface{}{
+			"content": []map[string]interface{}{
+				{
+					"type": "text",
+					"text": fmt.Sprintf("✅ Template validation successful!\n\nType: %s\nVersion: %s\n\nThe template data is valid and can be used to create a new template.",
+						templateType,
+						templateVersion),
+				},
+			},
+			}, nil
+	} else {
+		var errorText strings.Builder
+		errorText.WriteString("❌ Template validation failed!\n\nErrors:\n")
+		for i, validationError := range result.Errors {
+			errorText.WriteString(fmt.Sprintf("%d. Path: %s - %s", i+1, validationError.Path, validationError.Message))
+			if validationError.Value != nil {
+				errorText.WriteString(fmt.Sprintf(" (value: %v)", validationError.Value))
+			}
+			errorText.WriteString("\n")
+		}
+
+		return map[string]interface{}{
+			"content": []map[string]interface{}{
+				{
+					"type": "text",
+					"text": errorText.String(),
+				},
+			},
+			"isError": true,
+		}, nil
+	}
+}
+
+// Helper functions for template tools
+
+func formatTemplateList(templates []map[string]interface{}) string {
+	if len(templates) == 0 {
+		return "No templates found."
+	}
+
+	var result strings.Builder
+	for i, template := range templates {
+		if i > 0 {
+			result.WriteString("\n")
+		}
+		result.WriteString(fmt.Sprintf("%d. %s (%s)\n", i+1, template["name"], template["composite_id"]))
+		result.WriteString(fmt.Sprintf("   Type: %s | Version: %s | Status: %s\n",
+			template["type"], template["version"], getTemplateStatus(template["is_active"].(bool))))
+		if title, ok := template["title"].(string); ok && title != "" {
+			result.WriteString(fmt.Sprintf("   Title: %s\n", title))
+		}
+		if description, ok := template["description"].(string); ok && description != "" {
+			result.WriteString(fmt.Sprintf("   Description: %s\n", description))
+		}
+		result.WriteString(fmt.Sprintf("   Updated: %s", template["updated_at"]))
+	}
+	return result.String()
+}
+
+func getTemplateStatus(isActive bool) string {
+	if isActive {
+		return "Active"
+	}
+	return "Inactive"
+}
+
+// nodeEventStructured converts a node event to its structured form; event data is
+// decoded JSON when present
+func nodeEventStructured(event *entity.NodeEvent) map[string]interface{} {
+	structured := map[string]interface{}{
+		"id":           event.ID(),
+		"node_id":      event.NodeID(),
+		"event_type":   event.EventType(),
+		"occurred_at":  event.OccurredAt().Format(time.RFC3339),
+		"processed_at": nil,
+		"data":         nil,
+	}
+	if processedAt := event.ProcessedAt(); processedAt != nil {
+		structured["processed_at"] = processedAt.Format(time.RFC3339)
+	}
+	if event.EventData() != "" {
+		var data interface{}
+		if err := json.Unmarshal([]byte(event.EventData()), &data); err == nil {
+			structured["data"] = data
+		}
+	}
+	return structured
+}
+
+// nodeEventText formats a node event for text content
+func nodeEventText(event *entity.NodeEvent) string {
+	status := "pending"
+	if processedAt := event.ProcessedAt(); processedAt != nil {
+		status = "processed " + processedAt.Format(time.RFC3339)
+	}
+	return fmt.Sprintf("Event %d: %s node %d at %s (%s)",
+		event.ID(), event.EventType(), event.NodeID(), event.OccurredAt().Format(time.RFC3339), status)
+}
+
+// handleGetNodeEvents implements the get_node_events tool
+func (h *MCPToolHandler) handleGetNodeEvents(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	compositeID, ok := args["composite_id"].(string)
+	if !ok || compositeID == "" {
+		return nil, fmt.Errorf("missing or invalid 'composite_id' parameter")
+	}
+
+	// The node may already be deleted, so its ID is taken from the composite ID alone
+	nodeID, err := h.parseCompositeID(compositeID)
+	if err != nil {
+		return nil, err
+	}
+
+	page := 1
+	if p, ok := args["page"].(float64); ok && p >= 1 {
+		page = int(p)
+	}
+
+	size := constants.DefaultPageSize
+	if s, ok := args["size"].(float64); ok && s >= 1 {
+		size = int(s)
+	}
+	if size > h.maxPageSize {
+		size = h.maxPageSize
+	}
+
+	if err := h.dependencies.EventRecorder.Flush(ctx); err != nil {
+		return nil, fmt.Errorf("failed to flush node events: %w", err)
+	}
+
+	nodeEvents, totalCount, err := h.dependencies.NodeEventRepo.ListByNode(ctx, nodeID, page, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list node events: %w", err)
+	}
+
+	content := []map[string]interface{}{}
+	structuredEvents := []map[string]interface{}{}
+	for _, event := range nodeEvents {
+		content = append(content, createTextContent(nodeEventText(event)))
+		structuredEvents = append(structuredEvents, nodeEventStructured(event))
+	}
+
+	if len(content) == 0 {
+		content = append(content, createTextContent(fmt.Sprintf("No events found for node %s", compositeID)))
+	}
+
+	structuredContent := map[string]interface{}{
+		"composite_id": compositeID,
+		"events":       structuredEvents,
+		"total_count":  totalCount,
+		"pagination":   newPagination(page, size, totalCount),
+	}
+
+	return createMCPResponse(content, structuredContent), nil
+}
+
+// handleGetPendingEvents implements the get_pending_events tool
+func (h *MCPToolHandler) handleGetPendingEvents(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	limit := constants.DefaultPageSize
+	if l, ok := args["limit"].(float64); ok && l >= 1 {
+		limit = int(l)
+	}
+	if limit > h.maxPageSize {
+		limit = h.maxPageSize
+	}
+
+	if err := h.dependencies.EventRecorder.Flush(ctx); err != nil {
+		return nil, fmt.Errorf("failed to flush node events: %w", err)
+	}
+
+	pending, err := h.dependencies.NodeEventRepo.ListPending(ctx, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending events: %w", err)
+	}
+
+	content := []map[string]interface{}{}
+	structuredEvents := []map[string]interface{}{}
+	for _, event := range pending {
+		content = append(content, createTextContent(nodeEventText(event)))
+		structuredEvents = append(structuredEvents, nodeEventStructured(event))
+	}
+
+	if len(content) == 0 {
+		content = append(content, createTextContent("No pending events"))
+	}
+
+	structuredContent := map[string]interface{}{
+		"events": structuredEvents,
+		"count":  len(structuredEvents),
+	}
+
+	return createMCPResponse(content, structuredContent), nil
+}
+
+// handleProcessEvent implements the process_event tool
+func (h *MCPToolHandler) handleProcessEvent(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	eventIDFloat, ok := args["event_id"].(float64)
+	if !ok || eventIDFloat < 1 {
+		return nil, fmt.Errorf("missing or invalid 'event_id' parameter")
+	}
+	eventID := int(eventIDFloat)
+
+	event, err := h.dependencies.NodeEventRepo.MarkProcessed(ctx, eventID, time.Now())
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("event not found: %d", eventID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to process event: %w", err)
+	}
+
+	content := []map[string]interface{}{
+		createTextContent(fmt.Sprintf("Processed %s", nodeEventText(event))),
+	}
+
+	return createMCPResponse(content, nodeEventStructured(event)), nil
+}
+
+// handleGetEventStats implements the get_event_stats tool
+func (h *MCPToolHandler) handleGetEventStats(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	if err := h.dependencies.EventRecorder.Flush(ctx); err != nil {
+		return nil, fmt.Errorf("failed to flush node events: %w", err)
+	}
+
+	stats, err := h.dependencies.NodeEventRepo.Stats(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event stats: %w", err)
+	}
+
+	content := []map[string]interface{}{
+		createTextContent(fmt.Sprintf("Events: %d total, %d pending, %d processed",
+			stats.Total, stats.Pending, stats.Processed)),
+	}
+
+	structuredContent := map[string]interface{}{
+		"total_count":     stats.Total,
+		"pending_count":   stats.Pending,
+		"processed_count": stats.Processed,
+		"by_type":         stats.ByType,
+	}
+
+	return createMCPResponse(content, structuredContent), nil
+}
+
+// subscriptionEventTypes are the node event types a subscription can select
+var subscriptionEventTypes = []string{entity.NodeEventCreated, entity.NodeEventUpdated, entity.NodeEventDeleted}
+
+// nodeSubscriptionStructured converts a subscription to structured content
+func nodeSubscriptionStructured(subscription *entity.NodeSubscription, compositeID string) map[string]interface{} {
+	return map[string]interface{}{
+		"id":                  subscription.ID(),
+		"composite_id":        compositeID,
+		"subscriber_service":  subscription.SubscriberService(),
+		"subscriber_endpoint": subscription.SubscriberEndpoint(),
+		"event_types":         subscription.EventTypes(),
+		"is_active":           subscription.IsActive(),
+		"created_at":          subscription.CreatedAt().Format(time.RFC3339),
+	}
+}
+
+// handleCreateSubscription implements the create_subscription tool
+func (h *MCPToolHandler) handleCreateSubscription(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	compositeID, ok := args["composite_id"].(string)
+	if !ok || compositeID == "" {
+		return nil, fmt.Errorf("missing or invalid 'composite_id' parameter")
+	}
+
+	service, ok := args["subscriber_service"].(string)
+	if !ok || strings.TrimSpace(service) == "" {
+		return nil, fmt.Errorf("missing or invalid 'subscriber_service' parameter")
+	}
+
+	endpoint, ok := args["subscriber_endpoint"].(string)
+	if !ok || endpoint == "" {
+		return nil, fmt.Errorf("missing or invalid 'subscriber_endpoint' parameter")
+	}
+	parsed, err := url.Parse(endpoint)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid 'subscriber_endpoint': must be an http or https URL")
+	}
+
+	eventTypes := []string{entity.NodeEventUpdated}
+	if raw, ok := args["event_types"].([]interface{}); ok && len(raw) > 0 {
+		eventTypes = []string{}
+		for _, item := range raw {
+			eventType, ok := item.(string)
+			if !ok || !slices.Contains(subscriptionEventTypes, eventType) {
+				return nil, fmt.Errorf("invalid event type %v, must be one of: %s", item, strings.Join(subscriptionEventTypes, ", "))
+			}
+			if !slices.Contains(eventTypes, eventType) {
+				eventTypes = append(eventTypes, eventType)
+			}
+		}
+	}
+
+	nodeID, err := h.parseCompositeID(compositeID)
+	if err != nil {
+		return nil, err
+	}
+	node, err := h.dependencies.NodeRepo.GetByID(ctx, nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node: %w", err)
+	}
+	if node == nil {
+		return nil, fmt.Errorf("node not found: %s", compositeID)
+	}
+
+	subscription, err := entity.NewNodeSubscription(strings.TrimSpace(service), endpoint, nodeID, eventTypes)
 	if err != nil {
-		return nil, fmt.Errorf("failed to clone template: %w", err)
+		return nil, err
+	}
+	if err := h.dependencies.NodeSubscriptionRepo.Create(ctx, subscription); err != nil {
+		return nil, fmt.Errorf("failed to create subscription: %w", err)
 	}
 
-	domainName := parts[1] // Extract domain name from source composite ID
-	templateType, _ := clonedTemplate.GetTemplateType()
-	templateVersion, _ := clonedTemplate.GetTemplateVersion()
+	content := []map[string]interface{}{
+		createTextContent(fmt.Sprintf("Created subscription %d: %s receives %s events of %s at %s",
+			subscription.ID(), subscription.SubscriberService(), strings.Join(eventTypes, ", "), compositeID, endpoint)),
+	}
 
-	return map[string]interface{}{
-		"content": []map[string]interface{}{
-			{
-				"type": "text",
-				"text": fmt.Sprintf("Template cloned successfully!\n\nSource: %s\nNew Composite ID: url-db:%s:template:%d\nNew Name: %s\nType: %s\nVersion: %s\nTitle: %s\nDescription: %s\nCreated: %s",
-					sourceCompositeID,
-					domainName,
-					clonedTemplate.ID(),
-					clonedTemplate.Name(),
-					templateType,
-					templateVersion,
-					clonedTemplate.Title(),
-					clonedTemplate.Description(),
-					clonedTemplate.CreatedAt().Format("2006-01-02 15:04:05")),
-			},
-		},
-	}, nil
+	return createMCPResponse(content, nodeSubscriptionStructured(subscription, compositeID)), nil
 }
 
-// handleGenerateTemplateScaffold implements the generate_template_scaffold tool
-func (h *MCPToolHandler) handleGenerateTemplateScaffold(ctx context.Context, args map[string]interface{}) (interface{}, error) {
-	templateType, ok := args["template_type"].(string)
-	if !ok || templateType == "" {
-		return nil, fmt.Errorf("template_type is required")
+// handleListSubscriptions implements the list_subscriptions tool
+func (h *MCPToolHandler) handleListSubscriptions(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	compositeID, ok := args["composite_id"].(string)
+	if !ok || compositeID == "" {
+		return nil, fmt.Errorf("missing or invalid 'composite_id' parameter")
 	}
 
-	scaffold, err := h.dependencies.TemplateService.GenerateTemplateScaffold(templateType)
+	nodeID, err := h.parseCompositeID(compositeID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate template scaffold: %w", err)
-	}
-
-	return map[string]interface{}{
-		"content": []map[string]interface{}{
-			{
-				"type": "text",
-				"text": fmt.Sprintf("Template scaffold for type '%s':\n\n%s\n\nYou can use this as a starting point for creating a new template. Copy the JSON data and use it with the create_template tool.",
-					templateType,
-					scaffold),
-			},
-		},
-	}, nil
-}
-
-// handleValidateTemplate implements the validate_template tool
-func (h *MCPToolHandler) handleValidateTemplate(ctx context.Context, args map[string]interface{}) (interface{}, error) {
-	templateData, ok := args["template_data"].(string)
-	if !ok || templateData == "" {
-		return nil, fmt.Errorf("template_data is required")
+		return nil, err
 	}
 
-	result, err := h.dependencies.TemplateService.ValidateTemplateData(templateData)
+	subscriptions, err := h.dependencies.NodeSubscriptionRepo.ListByNode(ctx, nodeID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to validate template: %w", err)
+		return nil, fmt.Errorf("failed to list subscriptions: %w", err)
 	}
 
-	if result.Valid {
-		// Extract type and version for additional info
-		templateType, _ := h.dependencies.TemplateService.ExtractTemplateType(templateData)
-		templateVersion, _ := h.dependencies.TemplateService.ExtractTemplateVersion(templateData)
+	content := []map[string]interface{}{}
+	structuredSubscriptions := []map[string]interface{}{}
+	for _, subscription := range subscriptions {
+		content = append(content, createTextContent(fmt.Sprintf("Subscription %d: %s (%s) at %s",
+			subscription.ID(), subscription.SubscriberService(), strings.Join(subscription.EventTypes(), ", "), subscription.SubscriberEndpoint())))
+		structuredSubscriptions = append(structuredSubscriptions, nodeSubscriptionStructured(subscription, compositeID))
+	}
 
-		return map[string]interface{}{
-			"content": []map[string]interface{}{
-				{
-					"type": "text",
-					"text": fmt.Sprintf("✅ Template validation successful!\n\nType: %s\nVersion: %s\n\nThe template data is valid and can be used to create a new template.",
-						templateType,
-						templateVersion),
-				},
-			},
-			}, nil
-	} else {
-		var errorText strings.Builder
-		errorText.WriteString("❌ Template validation failed!\n\nErrors:\n")
-		for i, validationError := range result.Errors {
-			errorText.WriteString(fmt.Sprintf("%d. Path: %s - %s", i+1, validationError.Path, validationError.Message))
-			if validationError.Value != nil {
-				errorText.WriteString(fmt.Sprintf(" (value: %v)", validationError.Value))
-			}
-			errorText.WriteString("\n")
-		}
+	if len(content) == 0 {
+		content = append(content, createTextContent(fmt.Sprintf("No subscriptions found for node %s", compositeID)))
+	}
 
-		return map[string]interface{}{
-			"content": []map[string]interface{}{
-				{
-					"type": "text",
-					"text": errorText.String(),
-				},
-			},
-			"isError": true,
-		}, nil
+	structuredContent := map[string]interface{}{
+		"composite_id":  compositeID,
+		"subscriptions": structuredSubscriptions,
+		"count":         len(structuredSubscriptions),
 	}
-}
 
-// Helper functions for template tools
+	return createMCPResponse(content, structuredContent), nil
+}
 
-func formatTemplateList(templates []map[string]interface{}) string {
-	if len(templates) == 0 {
-		return "No templates found."
+// handleDeleteSubscription implements the delete_subscription tool
+func (h *MCPToolHandler) handleDeleteSubscription(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	idFloat, ok := args["subscription_id"].(float64)
+	if !ok || idFloat < 1 {
+		return nil, fmt.Errorf("missing or invalid 'subscription_id' parameter")
 	}
+	subscriptionID := int(idFloat)
 
-	var result strings.Builder
-	for i, template := range templates {
-		if i > 0 {
-			result.WriteString("\n")
-		}
-		result.WriteString(fmt.Sprintf("%d. %s (%s)\n", i+1, template["name"], template["composite_id"]))
-		result.WriteString(fmt.Sprintf("   Type: %s | Version: %s | Status: %s\n",
-			template["type"], template["version"], getTemplateStatus(template["is_active"].(bool))))
-		if title, ok := template["title"].(string); ok && title != "" {
-			result.WriteString(fmt.Sprintf("   Title: %s\n", title))
-		}
-		if description, ok := template["description"].(string); ok && description != "" {
-			result.WriteString(fmt.Sprintf("   Description: %s\n", description))
-		}
-		result.WriteString(fmt.Sprintf("   Updated: %s", template["updated_at"]))
+	err := h.dependencies.NodeSubscriptionRepo.Delete(ctx, subscriptionID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("subscription not found: %d", subscriptionID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete subscription: %w", err)
 	}
-	return result.String()
-}
 
-func getTemplateStatus(isActive bool) string {
-	if isActive {
-		return "Active"
+	content := []map[string]interface{}{
+		createTextContent(fmt.Sprintf("Deleted subscription %d", subscriptionID)),
 	}
-	return "Inactive"
+
+	return createMCPResponse(content, map[string]interface{}{"subscription_id": subscriptionID, "deleted": true}), nil
 }
 
 // handleScanAllContent scans all content in a domain with token-based pagination
@@ -1755,11 +5949,30 @@ func (h *MCPToolHandler) handleScanAllContent(ctx context.Context, args map[stri
 		compressAttributes = compress
 	}
 
+	includeContentHash, _ := args["include_content_hash"].(bool)
+
+	order := h.scanDefaultOrder
+	if o, ok := args["order"].(string); ok && o != "" {
+		parsed, err := repository.ParseSortOrder(o)
+		if err != nil {
+			return nil, err
+		}
+		order = parsed
+	}
+
+	// Streaming needs a transport that can send more than one message per request;
+	// elsewhere the page is returned in one response as usual
+	var stream StreamWriter
+	if streamRequested, _ := args["stream"].(bool); streamRequested {
+		stream, _ = streamWriterFrom(ctx)
+	}
+
 	// Create content scanner service
 	contentScanner := service.NewContentScanner(
 		h.dependencies.NodeRepo,
 		h.dependencies.NodeAttributeRepo,
 		h.dependencies.DomainRepo,
+		h.dependencies.AttributeRepo,
 	)
 
 	// Execute scan
@@ -1769,6 +5982,12 @@ func (h *MCPToolHandler) handleScanAllContent(ctx context.Context, args map[stri
 		Page:               page,
 		IncludeAttributes:  includeAttributes,
 		CompressAttributes: compressAttributes,
+		IncludeContentHash: includeContentHash,
+		Order:              order,
+	}
+
+	if stream != nil {
+		return h.streamScanAllContent(ctx, contentScanner, req, stream)
 	}
 
 	result, err := contentScanner.ScanAllContent(ctx, req)
@@ -1790,10 +6009,121 @@ func (h *MCPToolHandler) handleScanAllContent(ctx context.Context, args map[stri
 				"text": formatScanResult(result),
 			},
 		},
-		"result": response,
+		"result":            response,
+		"structuredContent": scanStructuredContent(domainName, result),
+	}, nil
+}
+
+// scanStructuredContent reports a scan's pagination in the shared shape. Items stay in
+// "result" only, so a page is not sent twice.
+func scanStructuredContent(domainName string, result *service.ScanResponse) map[string]interface{} {
+	return map[string]interface{}{
+		"domain_name": domainName,
+		"pagination": Pagination{
+			Page:        result.Pagination.CurrentPage,
+			Size:        result.Pagination.NodesPerPage,
+			TotalCount:  result.Metadata.TotalNodes,
+			TotalPages:  result.Pagination.TotalPages,
+			HasMore:     result.Pagination.HasMore,
+			HasPrevious: result.Pagination.HasPrevious,
+		},
+	}
+}
+
+// streamScanAllContent sends each scanned item as a notification, then returns a
+// response holding only the pagination and metadata
+func (h *MCPToolHandler) streamScanAllContent(ctx context.Context, contentScanner *service.ContentScanner, req service.ScanRequest, stream StreamWriter) (interface{}, error) {
+	index := 0
+	result, err := contentScanner.ScanAllContentStream(ctx, req, func(item response.NodeWithAttributes) error {
+		notification := ScanItemNotification{
+			JSONRPCVersion: constants.JSONRPCVersion,
+			Method:         constants.MCPScanItemNotificationMethod,
+			Params: ScanItem{
+				DomainName: req.DomainName,
+				Index:      index,
+				Item:       item,
+			},
+		}
+		index++
+		return stream.WriteMessage(notification)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan content: %w", err)
+	}
+
+	text := fmt.Sprintf("📊 **Content Scan Results**\n\nStreamed %d/%d nodes (page %d/%d, %d tokens)",
+		len(result.Items), result.Metadata.TotalNodes,
+		result.Pagination.CurrentPage, result.Pagination.TotalPages, result.Pagination.CurrentTokens)
+
+	return map[string]interface{}{
+		"content": []map[string]interface{}{
+			{
+				"type": "text",
+				"text": text,
+			},
+		},
+		"result": map[string]interface{}{
+			"streamed_items": len(result.Items),
+			"pagination":     result.Pagination,
+			"metadata":       result.Metadata,
+		},
+		"structuredContent": scanStructuredContent(req.DomainName, result),
 	}, nil
 }
 
+// handleEstimateScanSize implements the estimate_scan_size tool
+func (h *MCPToolHandler) handleEstimateScanSize(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	domainName, ok := args["domain_name"].(string)
+	if !ok || domainName == "" {
+		return nil, fmt.Errorf("domain_name is required")
+	}
+
+	maxTokensPerPage := constants.DefaultMaxTokensPerPage
+	if tokens, ok := args["max_tokens_per_page"].(float64); ok {
+		maxTokensPerPage = int(tokens)
+	}
+
+	includeAttributes := true
+	if include, ok := args["include_attributes"].(bool); ok {
+		includeAttributes = include
+	}
+
+	compressAttributes, _ := args["compress_attributes"].(bool)
+
+	contentScanner := service.NewContentScanner(
+		h.dependencies.NodeRepo,
+		h.dependencies.NodeAttributeRepo,
+		h.dependencies.DomainRepo,
+		h.dependencies.AttributeRepo,
+	)
+
+	estimate, err := contentScanner.EstimateScanSize(ctx, service.ScanRequest{
+		DomainName:         domainName,
+		MaxTokensPerPage:   maxTokensPerPage,
+		IncludeAttributes:  includeAttributes,
+		CompressAttributes: compressAttributes,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to estimate scan size: %w", err)
+	}
+
+	content := []map[string]interface{}{
+		createTextContent(fmt.Sprintf("Scanning domain '%s' covers %d nodes, about %d tokens, in %d pages of up to %d nodes (max_tokens_per_page: %d)",
+			domainName, estimate.TotalNodes, estimate.EstimatedTokens, estimate.TotalPages, estimate.NodesPerPage, estimate.MaxTokensPerPage)),
+	}
+
+	structuredContent := map[string]interface{}{
+		"domain_name":         domainName,
+		"total_nodes":         estimate.TotalNodes,
+		"estimated_tokens":    estimate.EstimatedTokens,
+		"total_pages":         estimate.TotalPages,
+		"nodes_per_page":      estimate.NodesPerPage,
+		"max_tokens_per_page": estimate.MaxTokensPerPage,
+	}
+
+	return createMCPResponse(content, structuredContent), nil
+}
+
 // formatScanResult formats the scan result for display
 func formatScanResult(result *service.ScanResponse) string {
 	var text strings.Builder
@@ -1816,6 +6146,14 @@ func formatScanResult(result *service.ScanResponse) string {
 		text.WriteString(fmt.Sprintf("**Navigation**: %s\n", strings.Join(navInfo, " | ")))
 	}
 	
+	if len(result.Metadata.Attributes) > 0 {
+		definitions := make([]string, len(result.Metadata.Attributes))
+		for i, attr := range result.Metadata.Attributes {
+			definitions[i] = fmt.Sprintf("%s (%s)", attr.Name, attr.Type)
+		}
+		text.WriteString(fmt.Sprintf("**Attributes**: %s\n", strings.Join(definitions, ", ")))
+	}
+	
 	// Compression info
 	if result.Metadata.CompressedOutput && result.Metadata.AttributeSummary != nil {
 		summary := result.Metadata.AttributeSummary
@@ -1862,3 +6200,157 @@ func formatScanResult(result *service.ScanResponse) string {
 	
 	return text.String()
 }
+
+// storageStatsNames maps the tables get_storage_stats counts to the names it reports them under
+var storageStatsNames = map[string]string{
+	"domains":            "domains",
+	"nodes":              "nodes",
+	"attributes":         "attributes",
+	"node_attributes":    "node_attributes",
+	"templates":          "templates",
+	"node_dependencies":  "dependencies",
+	"node_subscriptions": "subscriptions",
+	"node_events":        "events",
+}
+
+// handleGetStorageStats implements the get_storage_stats tool
+func (h *MCPToolHandler) handleGetStorageStats(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	stats, err := h.dependencies.StorageRepo.GetStats(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get storage stats: %w", err)
+	}
+
+	rowCounts := make(map[string]interface{}, len(stats.RowCounts))
+	for table, count := range stats.RowCounts {
+		name, ok := storageStatsNames[table]
+		if !ok {
+			name = table
+		}
+		rowCounts[name] = count
+	}
+	freeBytes := int64(stats.FreePages) * int64(stats.PageSize)
+
+	text := fmt.Sprintf("Database size: %d bytes (%d pages of %d bytes, %d free)\n"+
+		"Rows: %d domains, %d nodes, %d attributes, %d node attributes, %d templates, %d dependencies, %d subscriptions, %d events",
+		stats.SizeBytes(), stats.PageCount, stats.PageSize, stats.FreePages,
+		rowCounts["domains"], rowCounts["nodes"], rowCounts["attributes"], rowCounts["node_attributes"],
+		rowCounts["templates"], rowCounts["dependencies"], rowCounts["subscriptions"], rowCounts["events"])
+
+	structuredContent := map[string]interface{}{
+		"row_counts": rowCounts,
+		"size_bytes": stats.SizeBytes(),
+		"page_size":  stats.PageSize,
+		"page_count": stats.PageCount,
+		"free_pages": stats.FreePages,
+		"free_bytes": freeBytes,
+	}
+
+	return createMCPResponse([]map[string]interface{}{createTextContent(text)}, structuredContent), nil
+}
+
+// handleGetHealth implements the get_health tool
+func (h *MCPToolHandler) handleGetHealth(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	report := h.health(ctx)
+
+	text := fmt.Sprintf("Status: %s\nServer: %s\nTool name: %s\nUptime: %ds\nDatabase: %s",
+		report.Status, report.Server, report.ToolName, report.UptimeSeconds, report.Database)
+	if report.Error != "" {
+		text += fmt.Sprintf(" (%s)", report.Error)
+	}
+
+	structuredContent := map[string]interface{}{
+		"status":         report.Status,
+		"server":         report.Server,
+		"tool_name":      report.ToolName,
+		"uptime_seconds": report.UptimeSeconds,
+		"database":       report.Database,
+	}
+	if report.Error != "" {
+		structuredContent["error"] = report.Error
+	}
+
+	return createMCPResponse([]map[string]interface{}{createTextContent(text)}, structuredContent), nil
+}
+
+// handleRebuildSearchIndex implements the rebuild_search_index tool
+func (h *MCPToolHandler) handleRebuildSearchIndex(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	domainName, _ := args["domain_name"].(string)
+
+	domainID := 0
+	if domainName != "" {
+		domain, err := h.dependencies.DomainRepo.GetByName(ctx, domainName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get domain: %w", err)
+		}
+		if domain == nil {
+			return nil, fmt.Errorf("domain '%s' not found", domainName)
+		}
+		domainID = domain.ID()
+	}
+
+	indexed, err := h.dependencies.SearchIndexRepo.Rebuild(ctx, domainID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rebuild search index: %w", err)
+	}
+
+	structuredContent := map[string]interface{}{
+		"indexed_count": indexed,
+	}
+	scope := "all domains"
+	if domainName != "" {
+		structuredContent["domain_name"] = domainName
+		scope = fmt.Sprintf("domain '%s'", domainName)
+	}
+
+	content := []map[string]interface{}{
+		createTextContent(fmt.Sprintf("Rebuilt the search index of %s: %d node(s) indexed", scope, indexed)),
+	}
+
+	return createMCPResponse(content, structuredContent), nil
+}
+
+// handleExplainFilter implements the explain_filter tool
+func (h *MCPToolHandler) handleExplainFilter(ctx context.Context, args map[string]interface{}) (interface{}, error) {
+	domainName, filters, page, size, err := h.parseFilterNodesArguments(args)
+	if err != nil {
+		return nil, err
+	}
+
+	plans, err := h.dependencies.FilterNodesUC.Explain(ctx, domainName, filters, page, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to explain filter: %w", err)
+	}
+
+	content := []map[string]interface{}{}
+	structuredPlans := make([]map[string]interface{}, 0, len(plans))
+	for _, plan := range plans {
+		var text strings.Builder
+		fmt.Fprintf(&text, "%s query plan:", plan.Name)
+
+		structuredRows := make([]map[string]interface{}, 0, len(plan.Rows))
+		depths := map[int]int{}
+		for _, row := range plan.Rows {
+			depths[row.ID] = depths[row.Parent] + 1
+			fmt.Fprintf(&text, "\n%s%s", strings.Repeat("  ", depths[row.ID]), row.Detail)
+			structuredRows = append(structuredRows, map[string]interface{}{
+				"id":     row.ID,
+				"parent": row.Parent,
+				"detail": row.Detail,
+			})
+		}
+
+		content = append(content, createTextContent(text.String()))
+		structuredPlans = append(structuredPlans, map[string]interface{}{
+			"name":  plan.Name,
+			"query": plan.Query,
+			"plan":  structuredRows,
+		})
+	}
+
+	structuredContent := map[string]interface{}{
+		"domain_name": domainName,
+		"plans":       structuredPlans,
+	}
+
+	return createMCPResponse(content, structuredContent), nil
+}
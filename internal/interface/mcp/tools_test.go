@@ -0,0 +1,3899 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"url-db/internal/application/dto/request"
+	"url-db/internal/application/dto/response"
+	"url-db/internal/application/usecase/dependency"
+	"url-db/internal/constants"
+	"url-db/internal/database"
+	"url-db/internal/domain/entity"
+	"url-db/internal/domain/repository"
+	"url-db/internal/domain/service"
+	"url-db/internal/infrastructure/events"
+	"url-db/internal/infrastructure/fetcher"
+	"url-db/internal/interface/setup"
+)
+
+func TestListNodesFieldProjection(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	structuredContent(t, callTool(t, h, "create_node", map[string]interface{}{
+		"domain_name": "docs",
+		"url":         "https://example.com/a",
+		"title":       "A",
+	}))
+
+	tests := []struct {
+		name     string
+		fields   interface{}
+		expected []string
+	}{
+		{"all fields by default", nil, []string{"composite_id", "attribute_count", "id", "url", "title", "description", "created_at"}},
+		{"composite_id only", []interface{}{}, []string{"composite_id", "attribute_count"}},
+		{"selected fields", []interface{}{"url", "title"}, []string{"composite_id", "attribute_count", "url", "title"}},
+		{"explicit composite_id", []interface{}{"composite_id", "id"}, []string{"composite_id", "attribute_count", "id"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := map[string]interface{}{"domain_name": "docs"}
+			if tt.fields != nil {
+				args["fields"] = tt.fields
+			}
+
+			structured := structuredContent(t, callTool(t, h, "list_nodes", args))
+			nodes := structured["nodes"].([]map[string]interface{})
+			if len(nodes) != 1 {
+				t.Fatalf("expected 1 node, got %d", len(nodes))
+			}
+			if len(nodes[0]) != len(tt.expected) {
+				t.Errorf("got fields %v, want %v", nodes[0], tt.expected)
+			}
+			for _, field := range tt.expected {
+				if _, ok := nodes[0][field]; !ok {
+					t.Errorf("field %s missing from %v", field, nodes[0])
+				}
+			}
+		})
+	}
+
+	resp := callTool(t, h, "list_nodes", map[string]interface{}{
+		"domain_name": "docs",
+		"fields":      []interface{}{"content"},
+	})
+	if resp.Error == nil {
+		t.Errorf("expected error for unknown field")
+	}
+}
+
+func TestListNodesAttributeCount(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": "tag", "type": "tag"})
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": "https://example.com/a"})
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": "https://example.com/b"})
+
+	resp := callTool(t, h, "set_node_attributes", map[string]interface{}{
+		"composite_id": "test-tool:docs:1",
+		"attributes": []interface{}{
+			map[string]interface{}{"name": "tag", "value": "go"},
+			map[string]interface{}{"name": "tag", "value": "sqlite"},
+		},
+	})
+	if resp.Error != nil {
+		t.Fatalf("failed to set attributes: %v", resp.Error.Data)
+	}
+
+	structured := structuredContent(t, callTool(t, h, "list_nodes", map[string]interface{}{"domain_name": "docs"}))
+	want := map[string]int{"test-tool:docs:1": 2, "test-tool:docs:2": 0}
+	for _, node := range structured["nodes"].([]map[string]interface{}) {
+		id := node["composite_id"].(string)
+		if node["attribute_count"] != want[id] {
+			t.Errorf("%s attribute_count = %v, want %d", id, node["attribute_count"], want[id])
+		}
+	}
+}
+
+func TestListNodesTimeRange(t *testing.T) {
+	h, db := newTestProtocolHandlerWithDB(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	// The trigger would overwrite the seeded updated_at with the current time
+	if _, err := db.DB().Exec(`DROP TRIGGER nodes_updated_at`); err != nil {
+		t.Fatalf("failed to drop trigger: %v", err)
+	}
+	stamps := []struct{ created, updated string }{
+		{"2026-01-01 00:00:00+00:00", "2026-01-01 00:00:00+00:00"},
+		{"2026-01-02 09:00:00+09:00", "2026-03-01 00:00:00+00:00"}, // 2026-01-02 00:00 UTC
+		{"2026-01-03 00:00:00+00:00", "2026-01-03 00:00:00+00:00"},
+	}
+	for i, stamp := range stamps {
+		callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": fmt.Sprintf("https://example.com/%d", i+1)})
+		if _, err := db.DB().Exec(`UPDATE nodes SET created_at = ?, updated_at = ? WHERE id = ?`, stamp.created, stamp.updated, i+1); err != nil {
+			t.Fatalf("failed to set timestamps: %v", err)
+		}
+	}
+
+	listed := func(args map[string]interface{}) []string {
+		t.Helper()
+		args["domain_name"] = "docs"
+		resp := callTool(t, h, "list_nodes", args)
+		if resp.Error != nil {
+			t.Fatalf("list_nodes %v failed: %v", args, resp.Error.Data)
+		}
+		var urls []string
+		for _, node := range structuredContent(t, resp)["nodes"].([]map[string]interface{}) {
+			urls = append(urls, node["url"].(string))
+		}
+		sort.Strings(urls)
+		return urls
+	}
+
+	cases := []struct {
+		args map[string]interface{}
+		want string
+	}{
+		{map[string]interface{}{"created_after": "2026-01-02T00:00:00Z"}, "[https://example.com/2 https://example.com/3]"},
+		{map[string]interface{}{"created_before": "2026-01-02T00:00:00Z"}, "[https://example.com/1]"},
+		{map[string]interface{}{"created_after": "2026-01-01T12:00:00+02:00", "created_before": "2026-01-03T00:00:00Z"}, "[https://example.com/2]"},
+		{map[string]interface{}{"updated_after": "2026-02-01T00:00:00Z"}, "[https://example.com/2]"},
+	}
+	for _, c := range cases {
+		if got := fmt.Sprint(listed(c.args)); got != c.want {
+			t.Errorf("list_nodes %v = %s, want %s", c.args, got, c.want)
+		}
+	}
+
+	for _, args := range []map[string]interface{}{
+		{"domain_name": "docs", "created_after": "yesterday"},
+		{"domain_name": "docs", "updated_after": "2026-02-01T00:00:00Z", "updated_before": "2026-01-01T00:00:00Z"},
+	} {
+		if resp := callTool(t, h, "list_nodes", args); resp.Error == nil {
+			t.Errorf("list_nodes %v succeeded, want a validation error", args)
+		}
+	}
+}
+
+func TestUpdateNodeExpectedVersion(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": "https://example.com/a", "title": "First"})
+	versionOf := func() int {
+		return structuredContent(t, callTool(t, h, "get_node", map[string]interface{}{"composite_id": "test-tool:docs:1"}))["version"].(int)
+	}
+	update := func(title string, expected interface{}) *JSONRPCResponse {
+		args := map[string]interface{}{"composite_id": "test-tool:docs:1", "title": title}
+		if expected != nil {
+			args["expected_version"] = expected
+		}
+		return callTool(t, h, "update_node", args)
+	}
+
+	if v := versionOf(); v != 1 {
+		t.Fatalf("new node version = %d, want 1", v)
+	}
+	result := structuredContent(t, update("Second", float64(1)))
+	if result["version"] != 2 || result["title"] != "Second" {
+		t.Errorf("unexpected update result: %v", result)
+	}
+
+	// A stale version is refused without writing
+	resp := update("Stale", float64(1))
+	if resp.Error == nil || resp.Error.Code != VersionConflict {
+		t.Fatalf("expected a version conflict, got %v", resp.Error)
+	}
+	if node := structuredContent(t, callTool(t, h, "get_node", map[string]interface{}{"composite_id": "test-tool:docs:1"})); node["title"] != "Second" {
+		t.Errorf("stale update overwrote the title: %v", node["title"])
+	}
+	if resp := update("Bad", "2"); resp.Error == nil || resp.Error.Code == VersionConflict {
+		t.Errorf("expected a non-integer expected_version to be invalid, got %v", resp.Error)
+	}
+
+	// Other writers bump the version too; updates without expected_version always apply
+	callTool(t, h, "archive_node", map[string]interface{}{"composite_id": "test-tool:docs:1"})
+	if v := versionOf(); v != 3 {
+		t.Errorf("version after archive = %d, want 3", v)
+	}
+	if resp := update("Third", nil); resp.Error != nil {
+		t.Errorf("unconditional update failed: %v", resp.Error)
+	}
+
+	// A change between reading and saving the node is caught by the repository
+	repo := h.toolHandler.dependencies.NodeRepo
+	first, _ := repo.GetByID(context.Background(), 1)
+	second, _ := repo.GetByID(context.Background(), 1)
+	if err := repo.Update(context.Background(), first); err != nil {
+		t.Fatalf("first update failed: %v", err)
+	}
+	if err := repo.Update(context.Background(), second); !errors.Is(err, repository.ErrConcurrencyConflict) {
+		t.Errorf("expected ErrConcurrencyConflict for a stale node, got %v", err)
+	}
+}
+
+func TestCreateNodeFetchTitleRefusesPrivateAddress(t *testing.T) {
+	hits := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		fmt.Fprint(w, "<title>Internal</title>")
+	}))
+	defer server.Close()
+
+	// Allowlisting the host does not lift the private address check
+	h := newTestProtocolHandler(t)
+	h.SetTitleFetcher(fetcher.NewTitleFetcher(fetcher.TitleFetcherConfig{Allowlist: []string{"127.0.0.1"}}))
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	node := structuredContent(t, callTool(t, h, "create_node", map[string]interface{}{
+		"domain_name": "docs", "url": server.URL, "fetch_title": true,
+	}))
+
+	fetchError, _ := node["title_fetch_error"].(string)
+	if !strings.Contains(fetchError, "not a public address") {
+		t.Errorf("expected a private address error, got %v", node)
+	}
+	if node["title"] != "" || hits != 0 {
+		t.Errorf("expected no fetch, got title %v after %d requests", node["title"], hits)
+	}
+}
+
+func TestArchiveNode(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": "https://example.com/a"})
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": "https://example.com/b"})
+
+	listed := func(args map[string]interface{}) []map[string]interface{} {
+		t.Helper()
+		args["domain_name"] = "docs"
+		return structuredContent(t, callTool(t, h, "list_nodes", args))["nodes"].([]map[string]interface{})
+	}
+
+	archived := structuredContent(t, callTool(t, h, "archive_node", map[string]interface{}{"composite_id": "test-tool:docs:1"}))
+	archivedAt, _ := archived["archived_at"].(string)
+	if archived["archived"] != true || archivedAt == "" {
+		t.Fatalf("unexpected archive result: %v", archived)
+	}
+
+	// Archiving again keeps the original time
+	again := structuredContent(t, callTool(t, h, "archive_node", map[string]interface{}{"composite_id": "test-tool:docs:1"}))
+	if again["archived_at"] != archivedAt {
+		t.Errorf("archived_at changed on re-archive: %v, want %s", again["archived_at"], archivedAt)
+	}
+
+	nodes := listed(map[string]interface{}{})
+	if len(nodes) != 1 || nodes[0]["composite_id"] != "test-tool:docs:2" {
+		t.Errorf("list_nodes = %v, want only the unarchived node", nodes)
+	}
+	nodes = listed(map[string]interface{}{"include_archived": true})
+	if len(nodes) != 2 {
+		t.Fatalf("list_nodes with include_archived = %v, want both nodes", nodes)
+	}
+	for _, node := range nodes {
+		if _, has := node["archived_at"]; has != (node["composite_id"] == "test-tool:docs:1") {
+			t.Errorf("node %v: archived_at should be set only on the archived node", node)
+		}
+	}
+
+	// The archived node can still be read directly
+	node := structuredContent(t, callTool(t, h, "get_node", map[string]interface{}{"composite_id": "test-tool:docs:1"}))
+	if node["archived_at"] != archivedAt {
+		t.Errorf("get_node archived_at = %v, want %s", node["archived_at"], archivedAt)
+	}
+
+	restored := structuredContent(t, callTool(t, h, "restore_node", map[string]interface{}{"composite_id": "test-tool:docs:1"}))
+	if restored["archived"] != false {
+		t.Errorf("unexpected restore result: %v", restored)
+	}
+	if nodes := listed(map[string]interface{}{}); len(nodes) != 2 {
+		t.Errorf("list_nodes after restore = %v, want both nodes", nodes)
+	}
+
+	if resp := callTool(t, h, "archive_node", map[string]interface{}{"composite_id": "test-tool:docs:99"}); resp.Error == nil {
+		t.Errorf("expected error archiving a missing node")
+	}
+}
+
+func TestFindNodeByURLArchived(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": "https://example.com/a"})
+	find := func(includeArchived bool) *JSONRPCResponse {
+		return callTool(t, h, "find_node_by_url", map[string]interface{}{
+			"domain_name": "docs", "url": "https://example.com/a", "include_archived": includeArchived,
+		})
+	}
+
+	found := structuredContent(t, find(false))
+	if found["composite_id"] != "test-tool:docs:1" || found["archived_at"] != nil {
+		t.Errorf("unexpected active node: %v", found)
+	}
+
+	// Archived nodes are skipped by default, with a hint that one holds the URL
+	structuredContent(t, callTool(t, h, "archive_node", map[string]interface{}{"composite_id": "test-tool:docs:1"}))
+	resp := find(false)
+	if resp.Error == nil || !strings.Contains(fmt.Sprint(resp.Error.Data), "include_archived") {
+		t.Errorf("expected an archived node to be skipped with a hint, got %+v", resp)
+	}
+
+	found = structuredContent(t, find(true))
+	if found["composite_id"] != "test-tool:docs:1" || found["archived_at"] == nil {
+		t.Errorf("expected the archived node with include_archived, got %v", found)
+	}
+
+	if resp := callTool(t, h, "find_node_by_url", map[string]interface{}{"domain_name": "docs", "url": "https://example.com/missing"}); resp.Error == nil {
+		t.Error("expected error finding a missing URL")
+	}
+}
+
+func TestCreateDependencyTypes(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	h.SetDependencyTypes([]string{"hard", "blocks", "relates-to"})
+
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": "https://example.com/a"})
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": "https://example.com/b"})
+
+	tests := []struct {
+		name    string
+		args    map[string]interface{}
+		wantErr bool
+	}{
+		{"custom type accepted", map[string]interface{}{"dependency_type": "blocks"}, false},
+		{"unknown type rejected", map[string]interface{}{"dependency_type": "reference"}, true},
+		{"hard type may cascade", map[string]interface{}{"dependency_type": "hard", "cascade_delete": true}, false},
+		{"custom type may not cascade", map[string]interface{}{"dependency_type": "relates-to", "cascade_delete": true}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := map[string]interface{}{
+				"dependent_node_id":  "test-tool:docs:1",
+				"dependency_node_id": "test-tool:docs:2",
+			}
+			for k, v := range tt.args {
+				args[k] = v
+			}
+
+			resp := callTool(t, h, "create_dependency", args)
+			if (resp.Error != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", resp.Error, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestListDomainDependencies(t *testing.T) {
+	h, db := newTestProtocolHandlerWithDB(t)
+
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "other", "description": "Other"})
+	for _, u := range []string{"https://example.com/1", "https://example.com/2", "https://example.com/3"} {
+		callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": u})
+	}
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "other", "url": "https://example.com/4"})
+
+	seed := []struct {
+		dependent, dependency int
+		typeName              string
+		active                bool
+	}{
+		{1, 2, "hard", true},
+		{2, 3, "soft", true},
+		{1, 4, "hard", true},  // crosses into another domain
+		{3, 1, "soft", false}, // inactive
+	}
+	for _, s := range seed {
+		_, err := db.DB().Exec(`
+			INSERT INTO node_dependencies (dependent_node_id, dependency_node_id, dependency_type_id, is_active)
+			SELECT ?, ?, id, ? FROM dependency_types WHERE type_name = ?`,
+			s.dependent, s.dependency, s.active, s.typeName)
+		if err != nil {
+			t.Fatalf("failed to seed dependency: %v", err)
+		}
+	}
+
+	structured := structuredContent(t, callTool(t, h, "list_domain_dependencies", map[string]interface{}{
+		"domain_name": "docs",
+	}))
+	if structured["total_count"] != 2 {
+		t.Fatalf("total_count = %v, want 2", structured["total_count"])
+	}
+
+	edges := structured["dependencies"].([]map[string]interface{})
+	if edges[0]["dependent_node_id"] != "test-tool:docs:1" || edges[0]["dependency_node_id"] != "test-tool:docs:2" {
+		t.Errorf("unexpected first edge: %v", edges[0])
+	}
+	if edges[1]["dependency_type"] != "soft" {
+		t.Errorf("dependency_type = %v, want soft", edges[1]["dependency_type"])
+	}
+
+	structured = structuredContent(t, callTool(t, h, "list_domain_dependencies", map[string]interface{}{
+		"domain_name": "docs",
+		"size":        1.0,
+		"page":        2.0,
+	}))
+	edges = structured["dependencies"].([]map[string]interface{})
+	if len(edges) != 1 || structured["total_pages"] != 2 {
+		t.Errorf("unexpected second page: %v", structured)
+	}
+}
+
+func TestNodeSoftWarnings(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+
+	tests := []struct {
+		url          string
+		wantWarnings bool
+	}{
+		{"https://example.com/page", false},
+		{"http://localhost:3000/admin", true},
+		{"http://192.168.1.10/status", true},
+		{"http://intranet/wiki", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			structured := structuredContent(t, callTool(t, h, "create_node", map[string]interface{}{
+				"domain_name": "docs",
+				"url":         tt.url,
+			}))
+			if _, ok := structured["warnings"]; ok != tt.wantWarnings {
+				t.Errorf("warnings present = %v, want %v (%v)", ok, tt.wantWarnings, structured["warnings"])
+			}
+		})
+	}
+
+	// Long titles are flagged on update while the update still succeeds
+	structured := structuredContent(t, callTool(t, h, "update_node", map[string]interface{}{
+		"composite_id": "test-tool:docs:1",
+		"title":        strings.Repeat("a", 220),
+	}))
+	if _, ok := structured["warnings"]; !ok {
+		t.Errorf("expected a warning for a long title")
+	}
+	if structured["title"] != strings.Repeat("a", 220) {
+		t.Errorf("title was not updated")
+	}
+
+	h.SetSoftWarnings(false)
+	structured = structuredContent(t, callTool(t, h, "create_node", map[string]interface{}{
+		"domain_name": "docs",
+		"url":         "http://localhost:8080/other",
+	}))
+	if _, ok := structured["warnings"]; ok {
+		t.Errorf("warnings should be omitted when disabled")
+	}
+}
+
+func TestFindNodesByURLs(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "other", "description": "Other"})
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": "https://example.com/a"})
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": "https://example.com/b"})
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "other", "url": "https://example.com/c"})
+
+	structured := structuredContent(t, callTool(t, h, "find_nodes_by_urls", map[string]interface{}{
+		"domain_name": "docs",
+		"urls": []interface{}{
+			"https://example.com/b",
+			"https://example.com/missing",
+			"https://example.com/a",
+			"https://example.com/c", // exists, but in another domain
+		},
+	}))
+
+	expected := []struct {
+		url         string
+		compositeID string
+	}{
+		{"https://example.com/b", "test-tool:docs:2"},
+		{"https://example.com/missing", ""},
+		{"https://example.com/a", "test-tool:docs:1"},
+		{"https://example.com/c", ""},
+	}
+
+	results := structured["results"].([]map[string]interface{})
+	if len(results) != len(expected) {
+		t.Fatalf("got %d results, want %d", len(results), len(expected))
+	}
+	for i, want := range expected {
+		got := results[i]
+		if got["url"] != want.url {
+			t.Errorf("result %d url = %v, want %s", i, got["url"], want.url)
+		}
+		if got["found"] != (want.compositeID != "") {
+			t.Errorf("result %d found = %v", i, got["found"])
+		}
+		if want.compositeID != "" && got["composite_id"] != want.compositeID {
+			t.Errorf("result %d composite_id = %v, want %s", i, got["composite_id"], want.compositeID)
+		}
+	}
+	if structured["found_count"] != 2 {
+		t.Errorf("found_count = %v, want 2", structured["found_count"])
+	}
+}
+
+func TestClearNodeAttributes(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": "tag", "type": "tag"})
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": "https://example.com"})
+
+	resp := callTool(t, h, "set_node_attributes", map[string]interface{}{
+		"composite_id": "test-tool:docs:1",
+		"attributes": []interface{}{
+			map[string]interface{}{"name": "tag", "value": "go"},
+			map[string]interface{}{"name": "tag", "value": "sqlite"},
+		},
+	})
+	if resp.Error != nil {
+		t.Fatalf("failed to set attributes: %v", resp.Error.Data)
+	}
+
+	structured := structuredContent(t, callTool(t, h, "clear_node_attributes", map[string]interface{}{
+		"composite_id": "test-tool:docs:1",
+	}))
+	if structured["removed_count"] != 2 {
+		t.Errorf("removed_count = %v, want 2", structured["removed_count"])
+	}
+
+	// The node itself survives with no attributes left
+	structuredContent(t, callTool(t, h, "get_node", map[string]interface{}{"composite_id": "test-tool:docs:1"}))
+	all := structuredContent(t, callTool(t, h, "get_all_attributes", map[string]interface{}{"domain_name": "docs"}))
+	if attrs := all["attributes"].([]map[string]interface{}); len(attrs) != 0 {
+		t.Errorf("expected no attributes after clearing, got %v", attrs)
+	}
+}
+
+func TestDedupeNodeAttributes(t *testing.T) {
+	h, db := newTestProtocolHandlerWithDB(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": "tag", "type": "tag"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": "step", "type": "ordered_tag"})
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": "https://example.com"})
+
+	// Duplicates left behind by older writes that did not dedupe
+	_, err := db.DB().Exec(`
+		INSERT INTO node_attributes (node_id, attribute_id, value, order_index) VALUES
+			(1, 1, 'go', NULL), (1, 1, 'go', NULL), (1, 1, 'sqlite', NULL),
+			(1, 2, 'build', 3), (1, 2, 'build', 1), (1, 2, 'build', 2), (1, 2, 'test', 4)
+	`)
+	if err != nil {
+		t.Fatalf("failed to seed duplicate attributes: %v", err)
+	}
+
+	structured := structuredContent(t, callTool(t, h, "dedupe_node_attributes", map[string]interface{}{
+		"composite_id": "test-tool:docs:1",
+	}))
+	if structured["removed_count"] != 3 {
+		t.Errorf("removed_count = %v, want 3", structured["removed_count"])
+	}
+
+	var orderIndex int
+	if err := db.DB().QueryRow("SELECT order_index FROM node_attributes WHERE attribute_id = 2 AND value = 'build'").Scan(&orderIndex); err != nil {
+		t.Fatalf("expected one 'build' step to remain: %v", err)
+	}
+	if orderIndex != 1 {
+		t.Errorf("kept order_index = %d, want the lowest (1)", orderIndex)
+	}
+
+	// Running it again finds nothing to remove
+	structured = structuredContent(t, callTool(t, h, "dedupe_node_attributes", map[string]interface{}{
+		"composite_id": "test-tool:docs:1",
+	}))
+	if structured["removed_count"] != 0 {
+		t.Errorf("second run removed_count = %v, want 0", structured["removed_count"])
+	}
+
+	// set_node_attributes collapses repeated input the same way
+	resp := callTool(t, h, "set_node_attributes", map[string]interface{}{
+		"composite_id": "test-tool:docs:1",
+		"attributes": []interface{}{
+			map[string]interface{}{"name": "tag", "value": "go"},
+			map[string]interface{}{"name": "tag", "value": "go"},
+			map[string]interface{}{"name": "step", "value": "build", "order_index": float64(5)},
+			map[string]interface{}{"name": "step", "value": "build", "order_index": float64(2)},
+		},
+	})
+	if resp.Error != nil {
+		t.Fatalf("failed to set attributes: %v", resp.Error.Data)
+	}
+
+	var count int
+	if err := db.DB().QueryRow("SELECT COUNT(*) FROM node_attributes WHERE node_id = 1").Scan(&count); err != nil || count != 2 {
+		t.Errorf("expected 2 attribute rows after set_node_attributes, got %d (err: %v)", count, err)
+	}
+	if err := db.DB().QueryRow("SELECT order_index FROM node_attributes WHERE attribute_id = 2").Scan(&orderIndex); err != nil || orderIndex != 2 {
+		t.Errorf("expected the lowest order_index (2) to be kept, got %d (err: %v)", orderIndex, err)
+	}
+}
+
+func TestCopyNodeAttributes(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "other", "description": "Other"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": "tag", "type": "tag"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": "owner", "type": "string"})
+	for i := 1; i <= 3; i++ {
+		callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": fmt.Sprintf("https://example.com/%d", i)})
+	}
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "other", "url": "https://example.com/other"})
+
+	set := func(id string, attrs ...string) {
+		var items []interface{}
+		for i := 0; i < len(attrs); i += 2 {
+			items = append(items, map[string]interface{}{"name": attrs[i], "value": attrs[i+1]})
+		}
+		if resp := callTool(t, h, "set_node_attributes", map[string]interface{}{"composite_id": id, "attributes": items}); resp.Error != nil {
+			t.Fatalf("failed to set attributes on %s: %v", id, resp.Error.Data)
+		}
+	}
+	attributesOf := func(id string) string {
+		result := structuredContent(t, callTool(t, h, "get_node_attributes", map[string]interface{}{"composite_id": id, "format": "map"}))
+		return fmt.Sprint(result["attributes"])
+	}
+	set("test-tool:docs:1", "tag", "go", "tag", "sqlite", "owner", "alice")
+	set("test-tool:docs:2", "tag", "rust", "owner", "bob")
+	set("test-tool:docs:3", "tag", "draft")
+
+	// Merging keeps the target's attributes and its own single value
+	result := structuredContent(t, callTool(t, h, "copy_node_attributes", map[string]interface{}{
+		"source_composite_id": "test-tool:docs:1",
+		"target_composite_id": "test-tool:docs:2",
+	}))
+	if result["mode"] != "merge" || result["copied_count"] != 2 || fmt.Sprint(result["skipped"]) != "[owner]" {
+		t.Errorf("unexpected merge result: %v", result)
+	}
+	if got, want := attributesOf("test-tool:docs:2"), "map[owner:bob tag:[rust go sqlite]]"; got != want {
+		t.Errorf("merged attributes = %s, want %s", got, want)
+	}
+
+	// Replacing makes the target a copy of the source
+	result = structuredContent(t, callTool(t, h, "copy_node_attributes", map[string]interface{}{
+		"source_composite_id": "test-tool:docs:1",
+		"target_composite_id": "test-tool:docs:3",
+		"mode":                "replace",
+	}))
+	if result["copied_count"] != 3 {
+		t.Errorf("unexpected replace result: %v", result)
+	}
+	if got, want := attributesOf("test-tool:docs:3"), attributesOf("test-tool:docs:1"); got != want {
+		t.Errorf("replaced attributes = %s, want the source's %s", got, want)
+	}
+
+	for _, args := range []map[string]interface{}{
+		{"source_composite_id": "test-tool:docs:1", "target_composite_id": "test-tool:other:4"}, // another domain
+		{"source_composite_id": "test-tool:docs:1", "target_composite_id": "test-tool:docs:1"},  // same node
+		{"source_composite_id": "test-tool:docs:1", "target_composite_id": "test-tool:docs:9"},  // missing node
+		{"source_composite_id": "test-tool:docs:1", "target_composite_id": "test-tool:docs:2", "mode": "append"},
+	} {
+		if resp := callTool(t, h, "copy_node_attributes", args); resp.Error == nil {
+			t.Errorf("expected copy_node_attributes(%v) to fail", args)
+		}
+	}
+	if got, want := attributesOf("test-tool:docs:2"), "map[owner:bob tag:[rust go sqlite]]"; got != want {
+		t.Errorf("failed copies changed the target: %s", got)
+	}
+}
+
+func TestNodeTags(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": "tag", "type": "tag"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": "owner", "type": "string"})
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": "https://example.com/1"})
+	callTool(t, h, "set_node_attributes", map[string]interface{}{"composite_id": "test-tool:docs:1", "attributes": []interface{}{
+		map[string]interface{}{"name": "tag", "value": "go"},
+		map[string]interface{}{"name": "owner", "value": "alice"},
+	}})
+	attributesOf := func() string {
+		result := structuredContent(t, callTool(t, h, "get_node_attributes", map[string]interface{}{"composite_id": "test-tool:docs:1", "format": "map"}))
+		return fmt.Sprint(result["attributes"])
+	}
+	tagArgs := func(name, value string) map[string]interface{} {
+		return map[string]interface{}{"composite_id": "test-tool:docs:1", "attribute_name": name, "value": value}
+	}
+
+	// Adding keeps the node's other attributes; a repeated add changes nothing
+	result := structuredContent(t, callTool(t, h, "add_node_tag", tagArgs("tag", "sqlite")))
+	if result["added"] != true || fmt.Sprint(result["values"]) != "[go sqlite]" {
+		t.Errorf("unexpected add result: %v", result)
+	}
+	result = structuredContent(t, callTool(t, h, "add_node_tag", tagArgs("tag", "SQLite")))
+	if result["added"] != false {
+		t.Errorf("expected adding an existing value to report added=false: %v", result)
+	}
+	if got, want := attributesOf(), "map[owner:alice tag:[go sqlite]]"; got != want {
+		t.Errorf("attributes after add = %s, want %s", got, want)
+	}
+
+	// An undefined attribute is created when a type is given
+	args := tagArgs("reading", "first")
+	args["type"] = "ordered_tag"
+	result = structuredContent(t, callTool(t, h, "add_node_tag", args))
+	if result["added"] != true || result["attribute_created"] != true {
+		t.Errorf("unexpected auto-create result: %v", result)
+	}
+	result = structuredContent(t, callTool(t, h, "add_node_tag", tagArgs("reading", "second")))
+	if result["attribute_created"] != false || fmt.Sprint(result["values"]) != "[first second]" {
+		t.Errorf("unexpected ordered add result: %v", result)
+	}
+
+	for _, args := range []map[string]interface{}{
+		tagArgs("missing", "x"), // undefined attribute without a type
+		tagArgs("owner", "bob"), // single-valued attribute
+		{"composite_id": "test-tool:docs:1", "attribute_name": "topic", "value": "x", "type": "string"},
+		{"composite_id": "test-tool:docs:1", "attribute_name": "tag", "value": "x", "type": "ordered_tag"},
+		{"composite_id": "test-tool:docs:9", "attribute_name": "tag", "value": "x"},
+	} {
+		if resp := callTool(t, h, "add_node_tag", args); resp.Error == nil {
+			t.Errorf("expected add_node_tag(%v) to fail", args)
+		}
+	}
+
+	// Removing matches the normalised value and keeps the other attributes
+	result = structuredContent(t, callTool(t, h, "remove_node_tag", tagArgs("tag", "GO")))
+	if result["removed"] != true || fmt.Sprint(result["values"]) != "[sqlite]" {
+		t.Errorf("unexpected remove result: %v", result)
+	}
+	result = structuredContent(t, callTool(t, h, "remove_node_tag", tagArgs("tag", "go")))
+	if result["removed"] != false {
+		t.Errorf("expected removing an absent value to report removed=false: %v", result)
+	}
+	if got, want := attributesOf(), "map[owner:alice reading:[first second] tag:[sqlite]]"; got != want {
+		t.Errorf("attributes after remove = %s, want %s", got, want)
+	}
+	if resp := callTool(t, h, "remove_node_tag", tagArgs("owner", "alice")); resp.Error == nil {
+		t.Error("expected remove_node_tag on a single-valued attribute to fail")
+	}
+}
+
+func TestNodeTagsLeaveOtherRows(t *testing.T) {
+	h, db := newTestProtocolHandlerWithDB(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": "tag", "type": "tag"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": "stars", "type": "number"})
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": "https://example.com/1"})
+
+	// A stale value that no longer validates, written before the rules tightened
+	_, err := db.DB().Exec(`INSERT INTO node_attributes (node_id, attribute_id, value, created_at) VALUES (1, 1, 'go', '2020-01-01 00:00:00'), (1, 2, 'many', '2020-01-01 00:00:00')`)
+	if err != nil {
+		t.Fatalf("failed to seed attributes: %v", err)
+	}
+
+	// Concurrent adds each insert their own row
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			args := map[string]interface{}{"composite_id": "test-tool:docs:1", "attribute_name": "tag", "value": fmt.Sprintf("t%d", i)}
+			if resp := callTool(t, h, "add_node_tag", args); resp.Error != nil {
+				t.Errorf("add_node_tag %v failed: %v", args, resp.Error.Data)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	callTool(t, h, "remove_node_tag", map[string]interface{}{"composite_id": "test-tool:docs:1", "attribute_name": "tag", "value": "t0"})
+
+	var tags, untouched int
+	err = db.DB().QueryRow(`SELECT
+		(SELECT COUNT(*) FROM node_attributes WHERE attribute_id = 1),
+		(SELECT COUNT(*) FROM node_attributes WHERE created_at = '2020-01-01 00:00:00')`).Scan(&tags, &untouched)
+	if err != nil {
+		t.Fatalf("failed to count attributes: %v", err)
+	}
+	if tags != 10 || untouched != 2 {
+		t.Errorf("expected 10 tags and both seeded rows untouched, got %d tags and %d untouched", tags, untouched)
+	}
+}
+
+func TestRenderNodeAttribute(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": "notes", "type": "markdown"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": "cover", "type": "image"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": "thumb", "type": "image"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": "tag", "type": "tag"})
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": "https://example.com/1"})
+	setAttributes := func(attrs ...map[string]interface{}) *JSONRPCResponse {
+		list := make([]interface{}, len(attrs))
+		for i, attr := range attrs {
+			list[i] = attr
+		}
+		return callTool(t, h, "set_node_attributes", map[string]interface{}{"composite_id": "test-tool:docs:1", "attributes": list})
+	}
+	renderArgs := func(name string) map[string]interface{} {
+		return map[string]interface{}{"composite_id": "test-tool:docs:1", "attribute_name": name}
+	}
+
+	// Malformed links are rejected when the value is set
+	for _, notes := range []string{"see [docs]()", "[click](javascript:alert(1))", "[x](data:text/html,hi)", "[bad](http://%zz)"} {
+		if resp := setAttributes(map[string]interface{}{"name": "notes", "value": notes}); resp.Error == nil {
+			t.Errorf("expected markdown %q to be rejected", notes)
+		}
+	}
+
+	if resp := setAttributes(
+		map[string]interface{}{"name": "notes", "value": "# Title\n\nSee [docs](https://example.com \"Docs\") <script>alert(1)</script>"},
+		map[string]interface{}{"name": "cover", "value": "https://example.com/img/cover.PNG"},
+		map[string]interface{}{"name": "thumb", "value": "data:image/gif;base64,R0lGODlhAQABAAAAACw="},
+		map[string]interface{}{"name": "tag", "value": "go"},
+	); resp.Error != nil {
+		t.Fatalf("set_node_attributes failed: %v", resp.Error)
+	}
+
+	result := structuredContent(t, callTool(t, h, "render_node_attribute", renderArgs("notes")))
+	html, _ := result["html"].(string)
+	if !strings.Contains(html, "<h1>Title</h1>") || !strings.Contains(html, `<a href="https://example.com" title="Docs">docs</a>`) {
+		t.Errorf("unexpected markdown rendering: %s", html)
+	}
+	if strings.Contains(html, "<script>") {
+		t.Errorf("expected raw HTML to be left out: %s", html)
+	}
+
+	result = structuredContent(t, callTool(t, h, "render_node_attribute", renderArgs("cover")))
+	if got := fmt.Sprint(result["image"]); got != "map[mime_type:image/png size_bytes:0 source:url url:https://example.com/img/cover.PNG]" {
+		t.Errorf("unexpected image info: %s", got)
+	}
+	result = structuredContent(t, callTool(t, h, "render_node_attribute", renderArgs("thumb")))
+	if got := fmt.Sprint(result["image"]); got != "map[mime_type:image/gif size_bytes:14 source:data url:]" {
+		t.Errorf("unexpected data URL info: %s", got)
+	}
+	result = structuredContent(t, callTool(t, h, "render_node_attribute", renderArgs("tag")))
+	if fmt.Sprint(result["values"]) != "[go]" || result["html"] != nil {
+		t.Errorf("unexpected plain rendering: %v", result)
+	}
+
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": "summary", "type": "markdown"})
+	for _, name := range []string{"summary", "missing"} {
+		if resp := callTool(t, h, "render_node_attribute", renderArgs(name)); resp.Error == nil {
+			t.Errorf("expected rendering %s to fail", name)
+		}
+	}
+}
+
+func TestGetNodeAttributesInherited(t *testing.T) {
+	h, db := newTestProtocolHandlerWithDB(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": "category", "type": "tag"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": "owner", "type": "string"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": "status", "type": "string"})
+	for _, u := range []string{"https://example.com/child", "https://example.com/parent", "https://example.com/grandparent"} {
+		callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": u})
+	}
+
+	set := func(id string, attrs ...map[string]interface{}) {
+		items := make([]interface{}, len(attrs))
+		for i, a := range attrs {
+			items[i] = a
+		}
+		resp := callTool(t, h, "set_node_attributes", map[string]interface{}{"composite_id": id, "attributes": items})
+		if resp.Error != nil {
+			t.Fatalf("failed to set attributes on %s: %v", id, resp.Error.Data)
+		}
+	}
+	set("test-tool:docs:1", map[string]interface{}{"name": "status", "value": "draft"})
+	set("test-tool:docs:2",
+		map[string]interface{}{"name": "status", "value": "published"},
+		map[string]interface{}{"name": "owner", "value": "parent"})
+	set("test-tool:docs:3",
+		map[string]interface{}{"name": "owner", "value": "grandparent"},
+		map[string]interface{}{"name": "category", "value": "go"},
+		map[string]interface{}{"name": "category", "value": "sqlite"})
+
+	// child -> parent via 'parent', grandparent -> parent via 'child', and a cycle back to the child
+	for _, c := range []struct {
+		source, target int
+		relationship   string
+	}{
+		{1, 2, "parent"},
+		{3, 2, "child"},
+		{3, 1, "parent"},
+	} {
+		if _, err := db.DB().Exec(`INSERT INTO node_connections (source_node_id, target_node_id, relationship_type) VALUES (?, ?, ?)`,
+			c.source, c.target, c.relationship); err != nil {
+			t.Fatalf("failed to seed connection: %v", err)
+		}
+	}
+
+	// Inheritance is opt-in
+	structured := structuredContent(t, callTool(t, h, "get_node_attributes", map[string]interface{}{
+		"composite_id": "test-tool:docs:1",
+	}))
+	if attrs := structured["attributes"].([]map[string]interface{}); len(attrs) != 1 {
+		t.Fatalf("expected only own attributes, got %v", attrs)
+	}
+
+	structured = structuredContent(t, callTool(t, h, "get_node_attributes", map[string]interface{}{
+		"composite_id":      "test-tool:docs:1",
+		"include_inherited": true,
+	}))
+
+	got := make(map[string][]map[string]interface{})
+	for _, attr := range structured["attributes"].([]map[string]interface{}) {
+		got[attr["name"].(string)] = append(got[attr["name"].(string)], attr)
+	}
+
+	// The child's own value overrides its parent
+	if len(got["status"]) != 1 || got["status"][0]["value"] != "draft" || got["status"][0]["inherited"] != false {
+		t.Errorf("status = %v, want own value draft", got["status"])
+	}
+	// The nearest ancestor wins
+	if len(got["owner"]) != 1 || got["owner"][0]["value"] != "parent" || got["owner"][0]["inherited_from"] != "test-tool:docs:2" {
+		t.Errorf("owner = %v, want parent's value", got["owner"])
+	}
+	// All tag values come from the grandparent
+	if len(got["category"]) != 2 || got["category"][0]["inherited_from"] != "test-tool:docs:3" {
+		t.Errorf("category = %v, want both grandparent tags", got["category"])
+	}
+}
+
+func TestGetNodeAttributesPagination(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": "step", "type": "ordered_tag"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": "note", "type": "string"})
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": "https://example.com"})
+	attributes := []interface{}{map[string]interface{}{"name": "note", "value": "pipeline"}}
+	for i, step := range []string{"lint", "test", "build", "package", "deploy"} {
+		attributes = append([]interface{}{map[string]interface{}{"name": "step", "value": step, "order_index": float64(5 - i)}}, attributes...)
+	}
+	if resp := callTool(t, h, "set_node_attributes", map[string]interface{}{"composite_id": "test-tool:docs:1", "attributes": attributes}); resp.Error != nil {
+		t.Fatalf("failed to set attributes: %v", resp.Error.Data)
+	}
+
+	getPage := func(args map[string]interface{}) (map[string]interface{}, string) {
+		t.Helper()
+		args["composite_id"] = "test-tool:docs:1"
+		result := structuredContent(t, callTool(t, h, "get_node_attributes", args))
+		var values []string
+		for _, attr := range result["attributes"].([]map[string]interface{}) {
+			values = append(values, attr["value"].(string))
+		}
+		return result, strings.Join(values, " ")
+	}
+
+	// Without page or size every attribute comes back, as before
+	result, _ := getPage(map[string]interface{}{})
+	if len(result["attributes"].([]map[string]interface{})) != 6 || result["total_count"] != 6 || result["pagination"] != nil {
+		t.Errorf("unexpected unpaginated result: %v", result)
+	}
+
+	// Pages follow attribute name, then order_index
+	expected := []string{"pipeline deploy package", "build test lint", ""}
+	for i, want := range expected {
+		result, got := getPage(map[string]interface{}{"page": float64(i + 1), "size": float64(3)})
+		if got != want {
+			t.Errorf("page %d = %q, want %q", i+1, got, want)
+		}
+		pagination := result["pagination"].(Pagination)
+		if result["total_count"] != 6 || pagination.TotalPages != 2 || pagination.HasMore != (i == 0) {
+			t.Errorf("page %d: unexpected total_count %v or pagination %+v", i+1, result["total_count"], pagination)
+		}
+	}
+
+	// size alone paginates from the first page
+	if _, got := getPage(map[string]interface{}{"size": float64(2)}); got != "pipeline deploy" {
+		t.Errorf("first page of 2 = %q", got)
+	}
+}
+
+func TestGetAllAttributes(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	for _, name := range []string{"docs", "other", "empty"} {
+		callTool(t, h, "create_domain", map[string]interface{}{"name": name, "description": name})
+		callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": name, "name": "tag", "type": "tag"})
+	}
+	nodes := []struct {
+		domain, url string
+		tags        []string
+	}{
+		{"docs", "https://example.com/a", []string{"go", "sqlite"}},
+		{"other", "https://example.com/b", []string{"web"}},
+		{"docs", "https://example.com/c", []string{"mcp"}},
+		{"docs", "https://example.com/d", nil},
+	}
+	for i, node := range nodes {
+		callTool(t, h, "create_node", map[string]interface{}{"domain_name": node.domain, "url": node.url})
+		var attributes []interface{}
+		for _, tag := range node.tags {
+			attributes = append(attributes, map[string]interface{}{"name": "tag", "value": tag})
+		}
+		if attributes == nil {
+			continue
+		}
+		compositeID := fmt.Sprintf("test-tool:%s:%d", node.domain, i+1)
+		if resp := callTool(t, h, "set_node_attributes", map[string]interface{}{"composite_id": compositeID, "attributes": attributes}); resp.Error != nil {
+			t.Fatalf("failed to set attributes on %s: %v", compositeID, resp.Error.Data)
+		}
+	}
+
+	allAttributes := func(args map[string]interface{}) (map[string]interface{}, string) {
+		t.Helper()
+		resp := callTool(t, h, "get_all_attributes", args)
+		if resp.Error != nil {
+			t.Fatalf("get_all_attributes %v failed: %v", args, resp.Error.Data)
+		}
+		result := structuredContent(t, resp)
+		var tuples []string
+		for _, attr := range result["attributes"].([]map[string]interface{}) {
+			tuples = append(tuples, fmt.Sprintf("%s=%s", attr["composite_id"], attr["value"]))
+		}
+		sort.Strings(tuples)
+		return result, strings.Join(tuples, " ")
+	}
+
+	// Only the requested domain's values come back; nodes without attributes count but add no tuples
+	cases := []struct {
+		domain     string
+		want       string
+		totalNodes int
+	}{
+		{"docs", "test-tool:docs:1=go test-tool:docs:1=sqlite test-tool:docs:3=mcp", 3},
+		{"other", "test-tool:other:2=web", 1},
+		{"empty", "", 0},
+	}
+	for _, c := range cases {
+		result, got := allAttributes(map[string]interface{}{"domain_name": c.domain})
+		if got != c.want {
+			t.Errorf("%s attributes = %q, want %q", c.domain, got, c.want)
+		}
+		if result["total_nodes"] != c.totalNodes || result["has_more"] != false {
+			t.Errorf("%s: unexpected total_nodes %v or has_more %v", c.domain, result["total_nodes"], result["has_more"])
+		}
+	}
+
+	// Pages cover whole nodes, so a node's values never split across pages
+	var paged []string
+	for page := 1; page <= 3; page++ {
+		result, got := allAttributes(map[string]interface{}{"domain_name": "docs", "page": float64(page), "size": float64(1)})
+		if result["total_pages"] != 3 || result["has_more"] != (page < 3) {
+			t.Errorf("page %d: unexpected total_pages %v or has_more %v", page, result["total_pages"], result["has_more"])
+		}
+		if got == "" {
+			continue
+		}
+		if owners := strings.Count(got, "test-tool:docs:1="); owners != 0 && owners != strings.Count(got, "=") {
+			t.Errorf("page %d mixes nodes: %q", page, got)
+		}
+		paged = append(paged, strings.Fields(got)...)
+	}
+	sort.Strings(paged)
+	if got := strings.Join(paged, " "); got != cases[0].want {
+		t.Errorf("paged docs attributes = %q, want %q", got, cases[0].want)
+	}
+
+	if resp := callTool(t, h, "get_all_attributes", map[string]interface{}{"domain_name": "missing"}); resp.Error == nil {
+		t.Errorf("expected error for unknown domain")
+	}
+}
+
+func TestDependencyPersistence(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "other", "description": "Other"})
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": "https://example.com/a"})
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "other", "url": "https://example.com/b", "title": "B"})
+
+	created := structuredContent(t, callTool(t, h, "create_dependency", map[string]interface{}{
+		"dependent_node_id":  "test-tool:docs:1",
+		"dependency_node_id": "test-tool:other:2",
+		"dependency_type":    "hard",
+		"cascade_delete":     true,
+		"description":        "needs b",
+	}))
+	dependencyID, ok := created["dependency_id"].(int)
+	if !ok || dependencyID <= 0 {
+		t.Fatalf("dependency_id = %v, want a positive ID", created["dependency_id"])
+	}
+
+	// Listing returns the stored row from both ends
+	listed := structuredContent(t, callTool(t, h, "list_node_dependencies", map[string]interface{}{
+		"composite_id": "test-tool:docs:1",
+	}))
+	edges := listed["dependencies"].([]map[string]interface{})
+	if len(edges) != 1 {
+		t.Fatalf("expected 1 dependency, got %v", edges)
+	}
+	edge := edges[0]
+	if edge["dependency_id"] != dependencyID || edge["dependency_node_id"] != "test-tool:other:2" ||
+		edge["cascade_delete"] != true || edge["description"] != "needs b" {
+		t.Errorf("unexpected stored dependency: %v", edge)
+	}
+	related := edge["related_node"].(map[string]interface{})
+	if related["composite_id"] != "test-tool:other:2" || related["title"] != "B" || related["url"] != "https://example.com/b" {
+		t.Errorf("related_node = %v, want node b", related)
+	}
+
+	// From the other end the related node is the dependent
+	dependents := structuredContent(t, callTool(t, h, "list_node_dependents", map[string]interface{}{
+		"composite_id": "test-tool:other:2",
+	}))
+	if dependents["total_count"] != 1 {
+		t.Fatalf("dependents total_count = %v, want 1", dependents["total_count"])
+	}
+	related = dependents["dependencies"].([]map[string]interface{})[0]["related_node"].(map[string]interface{})
+	if related["composite_id"] != "test-tool:docs:1" || related["url"] != "https://example.com/a" {
+		t.Errorf("related_node = %v, want node a", related)
+	}
+
+	rejected := []map[string]interface{}{
+		{"dependent_node_id": "test-tool:docs:1", "dependency_node_id": "test-tool:other:2", "dependency_type": "hard"},
+		{"dependent_node_id": "test-tool:docs:1", "dependency_node_id": "test-tool:docs:1", "dependency_type": "soft"},
+		{"dependent_node_id": "test-tool:docs:1", "dependency_node_id": "test-tool:docs:99", "dependency_type": "soft"},
+	}
+	for _, args := range rejected {
+		if resp := callTool(t, h, "create_dependency", args); resp.Error == nil {
+			t.Errorf("expected error creating %v", args)
+		}
+	}
+
+	structuredContent(t, callTool(t, h, "delete_dependency", map[string]interface{}{"dependency_id": float64(dependencyID)}))
+	listed = structuredContent(t, callTool(t, h, "list_node_dependencies", map[string]interface{}{
+		"composite_id": "test-tool:docs:1",
+	}))
+	if listed["total_count"] != 0 {
+		t.Errorf("total_count after delete = %v, want 0", listed["total_count"])
+	}
+	if resp := callTool(t, h, "delete_dependency", map[string]interface{}{"dependency_id": float64(dependencyID)}); resp.Error == nil {
+		t.Errorf("expected error deleting a missing dependency")
+	}
+}
+
+func BenchmarkListNodeDependents(b *testing.B) {
+	h := newTestProtocolHandler(b)
+	callTool(b, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	callTool(b, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": "https://example.com/hub"})
+
+	// 500 nodes depend on the hub
+	const edges = 500
+	batch := make([]map[string]interface{}, 0, 100)
+	for i := 0; i < edges; i++ {
+		callTool(b, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": fmt.Sprintf("https://example.com/%d", i)})
+		batch = append(batch, map[string]interface{}{
+			"dependent_node_id": fmt.Sprintf("test-tool:docs:%d", i+2), "dependency_node_id": "test-tool:docs:1", "dependency_type": "soft",
+		})
+		if len(batch) == cap(batch) {
+			structuredContent(b, callTool(b, h, "create_dependencies", map[string]interface{}{"dependencies": batch}))
+			batch = batch[:0]
+		}
+	}
+
+	args := map[string]interface{}{"composite_id": "test-tool:docs:1"}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if result := structuredContent(b, callTool(b, h, "list_node_dependents", args)); result["total_count"] != edges {
+			b.Fatalf("total_count = %v, want %d", result["total_count"], edges)
+		}
+	}
+}
+
+func TestCreateDependencies(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	for _, url := range []string{"https://example.com/a", "https://example.com/b", "https://example.com/c"} {
+		callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": url})
+	}
+	structuredContent(t, callTool(t, h, "create_dependency", map[string]interface{}{
+		"dependent_node_id": "test-tool:docs:3", "dependency_node_id": "test-tool:docs:1", "dependency_type": "soft",
+	}))
+
+	result := structuredContent(t, callTool(t, h, "create_dependencies", map[string]interface{}{
+		"dependencies": []map[string]interface{}{
+			{"dependent_node_id": "test-tool:docs:1", "dependency_node_id": "test-tool:docs:2", "dependency_type": "hard", "cascade_delete": true},
+			{"dependent_node_id": "test-tool:docs:1", "dependency_node_id": "test-tool:docs:1", "dependency_type": "soft"},
+			{"dependent_node_id": "test-tool:docs:3", "dependency_node_id": "test-tool:docs:1", "dependency_type": "soft"},
+			{"dependent_node_id": "test-tool:docs:2", "dependency_node_id": "test-tool:docs:3", "dependency_type": "soft"},
+			{"dependent_node_id": "test-tool:docs:2", "dependency_node_id": "test-tool:docs:99", "dependency_type": "soft"},
+			{"dependent_node_id": "test-tool:docs:2", "dependency_node_id": "test-tool:docs:3", "dependency_type": "bogus"},
+			{"dependent_node_id": "test-tool:docs:3", "dependency_node_id": "test-tool:docs:2", "dependency_type": "reference"},
+			{"dependent_node_id": "test-tool:docs:1", "dependency_node_id": "test-tool:docs:2", "dependency_type": "hard"},
+		},
+	}))
+
+	// Only the first and the seventh edge are valid: the others are a self edge, a
+	// duplicate of an existing edge, a cycle through the batch's first edge, a missing
+	// node, an unknown type and a duplicate within the batch
+	results := result["results"].([]map[string]interface{})
+	wantSuccess := []bool{true, false, false, false, false, false, true, false}
+	if len(results) != len(wantSuccess) {
+		t.Fatalf("expected %d results, got %v", len(wantSuccess), results)
+	}
+	for i, want := range wantSuccess {
+		if results[i]["index"] != i || results[i]["success"] != want {
+			t.Errorf("result %d = %v, want success %t", i, results[i], want)
+		}
+	}
+	if err, _ := results[3]["error"].(string); !strings.Contains(err, "cycle") {
+		t.Errorf("result 3 error = %q, want a cycle error", err)
+	}
+	if result["created_count"] != 2 || result["failed_count"] != 6 {
+		t.Errorf("counts = %v/%v, want 2/6", result["created_count"], result["failed_count"])
+	}
+
+	dependency := results[0]["dependency"].(map[string]interface{})
+	if dependency["dependency_node_id"] != "test-tool:docs:2" || dependency["cascade_delete"] != true {
+		t.Errorf("unexpected created dependency: %v", dependency)
+	}
+
+	// The valid edges were stored, the invalid ones were not
+	listed := structuredContent(t, callTool(t, h, "list_node_dependencies", map[string]interface{}{
+		"composite_id": "test-tool:docs:2",
+	}))
+	if listed["total_count"] != 0 {
+		t.Errorf("node 2 dependencies = %v, want none", listed["total_count"])
+	}
+	listed = structuredContent(t, callTool(t, h, "list_node_dependencies", map[string]interface{}{
+		"composite_id": "test-tool:docs:3",
+	}))
+	if listed["total_count"] != 2 {
+		t.Errorf("node 3 dependencies = %v, want 2", listed["total_count"])
+	}
+}
+
+func TestCreateDependencyConcurrentCycle(t *testing.T) {
+	// A file database with several connections, so the two inserts really overlap
+	config := database.DefaultConfig()
+	config.URL = "file:" + filepath.Join(t.TempDir(), "deps.sqlite")
+	config.MaxOpenConns = 4
+	db, err := database.New(config)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+	h := NewMCPProtocolHandler(setup.NewApplicationFactory(db.DB(), db.SQLXDB(), "test-tool"), constants.MCPModeStdio)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": "https://example.com/a"})
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": "https://example.com/b"})
+	deps := h.toolHandler.dependencies
+
+	// Hold the 1 -> 2 insert open between its check and its insert
+	first, err := entity.NewDependency(1, 2, "soft", "")
+	if err != nil {
+		t.Fatalf("failed to build dependency: %v", err)
+	}
+	checking := make(chan struct{})
+	created := make(chan error, 1)
+	go func() {
+		created <- deps.DependencyRepo.Create(context.Background(), first, func(ctx context.Context, graph repository.DependencyGraph, dependency *entity.Dependency) error {
+			close(checking)
+			time.Sleep(100 * time.Millisecond)
+			return nil
+		})
+	}()
+	<-checking
+
+	// 2 -> 1 waits for that transaction and then sees its edge
+	_, err = deps.CreateDependencyUC.Execute(context.Background(), &request.CreateDependencyRequest{
+		DependentNodeID: 2, DependencyNodeID: 1, DependencyType: "soft",
+	})
+	var cycleErr *dependency.CycleError
+	if !errors.As(err, &cycleErr) {
+		t.Errorf("expected a cycle error for the concurrent reverse edge, got %v", err)
+	}
+	if err := <-created; err != nil {
+		t.Fatalf("failed to create the first dependency: %v", err)
+	}
+}
+
+func TestDomainNodeLimit(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	h.SetNodeLimits(2, map[string]int{"unlimited": 0})
+
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "unlimited", "description": "No cap"})
+
+	for _, u := range []string{"https://example.com/1", "https://example.com/2"} {
+		structuredContent(t, callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": u}))
+	}
+
+	stats := structuredContent(t, callTool(t, h, "get_domain_stats", map[string]interface{}{"domain_name": "docs"}))
+	if stats["node_count"] != 2 || stats["max_nodes"] != 2 || stats["remaining"] != 0 {
+		t.Errorf("unexpected stats at the cap: %v", stats)
+	}
+
+	resp := callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": "https://example.com/3"})
+	if resp.Error == nil {
+		t.Fatalf("expected node cap error")
+	}
+	if msg := resp.Error.Data.(string); !strings.Contains(msg, "node limit of 2") || !strings.Contains(msg, "current count: 2") {
+		t.Errorf("error should name the cap and count, got %q", msg)
+	}
+
+	// The override lifts the cap for its domain
+	for _, u := range []string{"https://example.com/1", "https://example.com/2", "https://example.com/3"} {
+		structuredContent(t, callTool(t, h, "create_node", map[string]interface{}{"domain_name": "unlimited", "url": u}))
+	}
+	stats = structuredContent(t, callTool(t, h, "get_domain_stats", map[string]interface{}{"domain_name": "unlimited"}))
+	if stats["max_nodes"] != 0 || stats["remaining"] != nil {
+		t.Errorf("unexpected stats without a cap: %v", stats)
+	}
+}
+
+func TestCreateDependencyRejectsCycles(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	for _, u := range []string{"https://example.com/a", "https://example.com/b", "https://example.com/c"} {
+		callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": u})
+	}
+
+	link := func(dependent, dependency, dependencyType string) *JSONRPCResponse {
+		return callTool(t, h, "create_dependency", map[string]interface{}{
+			"dependent_node_id":  dependent,
+			"dependency_node_id": dependency,
+			"dependency_type":    dependencyType,
+		})
+	}
+
+	// A depends on B, B depends on C
+	structuredContent(t, link("test-tool:docs:1", "test-tool:docs:2", "hard"))
+	structuredContent(t, link("test-tool:docs:2", "test-tool:docs:3", "soft"))
+
+	// C depending on A closes the loop, whatever its type
+	resp := link("test-tool:docs:3", "test-tool:docs:1", "reference")
+	if resp.Error == nil {
+		t.Fatalf("expected cycle to be rejected")
+	}
+	want := "test-tool:docs:3 -> test-tool:docs:1 -> test-tool:docs:2 -> test-tool:docs:3"
+	if msg := resp.Error.Data.(string); !strings.Contains(msg, want) {
+		t.Errorf("error should name the cycle %q, got %q", want, msg)
+	}
+
+	// A shortcut in the same direction is not a cycle
+	structuredContent(t, link("test-tool:docs:1", "test-tool:docs:3", "reference"))
+}
+
+func TestSearchAttributeValues(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "other", "description": "Other"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": "tag", "type": "tag"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": "note", "type": "string"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "other", "name": "tag", "type": "tag"})
+	for _, u := range []string{"https://example.com/1", "https://example.com/2", "https://example.com/3"} {
+		callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": u})
+	}
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "other", "url": "https://example.com/4"})
+
+	set := func(id string, attrs ...interface{}) {
+		if resp := callTool(t, h, "set_node_attributes", map[string]interface{}{"composite_id": id, "attributes": attrs}); resp.Error != nil {
+			t.Fatalf("failed to set attributes on %s: %v", id, resp.Error.Data)
+		}
+	}
+	set("test-tool:docs:1", map[string]interface{}{"name": "tag", "value": "golang"})
+	set("test-tool:docs:2", map[string]interface{}{"name": "note", "value": "Written in GoLang 1.24"})
+	set("test-tool:docs:3", map[string]interface{}{"name": "note", "value": "100% rust"})
+	set("test-tool:other:4", map[string]interface{}{"name": "tag", "value": "golang"})
+
+	structured := structuredContent(t, callTool(t, h, "search_attribute_values", map[string]interface{}{
+		"domain_name": "docs",
+		"query":       "golang",
+	}))
+	if structured["total_count"] != 2 {
+		t.Fatalf("total_count = %v, want 2", structured["total_count"])
+	}
+
+	results := structured["results"].([]map[string]interface{})
+	if results[0]["composite_id"] != "test-tool:docs:1" || results[1]["composite_id"] != "test-tool:docs:2" {
+		t.Errorf("unexpected results: %v", results)
+	}
+	match := results[1]["matches"].([]map[string]interface{})[0]
+	if match["attribute_name"] != "note" || match["highlighted"] != "Written in **GoLang** 1.24" {
+		t.Errorf("unexpected match: %v", match)
+	}
+
+	// LIKE wildcards in the query are matched literally
+	structured = structuredContent(t, callTool(t, h, "search_attribute_values", map[string]interface{}{
+		"domain_name": "docs",
+		"query":       "0%",
+	}))
+	if structured["total_count"] != 1 {
+		t.Errorf("total_count for literal %% = %v, want 1", structured["total_count"])
+	}
+
+	structured = structuredContent(t, callTool(t, h, "search_attribute_values", map[string]interface{}{
+		"domain_name": "docs",
+		"query":       "golang",
+		"size":        1.0,
+		"page":        2.0,
+	}))
+	if results := structured["results"].([]map[string]interface{}); len(results) != 1 || structured["total_pages"] != 2 {
+		t.Errorf("unexpected second page: %v", structured)
+	}
+}
+
+func TestGlobalSearch(t *testing.T) {
+	h, db := newTestProtocolHandlerWithDB(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "other", "description": "Other"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": "tag", "type": "tag"})
+	nodes := []map[string]interface{}{
+		{"domain_name": "docs", "url": "https://example.com/a", "title": "Kubernetes basics"},
+		{"domain_name": "docs", "url": "https://kubernetes.io/docs", "title": "Docs"},
+		{"domain_name": "docs", "url": "https://example.com/c", "title": "Cluster notes", "description": "Running kubernetes at home"},
+		{"domain_name": "docs", "url": "https://example.com/d", "title": "Misc"},
+		{"domain_name": "other", "url": "https://example.com/e", "title": "Kubernetes elsewhere"},
+		{"domain_name": "docs", "url": "https://example.com/f", "title": "Unrelated"},
+	}
+	for _, node := range nodes {
+		callTool(t, h, "create_node", node)
+	}
+	callTool(t, h, "set_node_attributes", map[string]interface{}{
+		"composite_id": "test-tool:docs:4",
+		"attributes":   []interface{}{map[string]interface{}{"name": "tag", "value": "kubernetes"}},
+	})
+
+	search := func(args map[string]interface{}) (map[string]interface{}, string) {
+		t.Helper()
+		result := structuredContent(t, callTool(t, h, "global_search", args))
+		var got []string
+		for _, hit := range result["results"].([]map[string]interface{}) {
+			got = append(got, fmt.Sprintf("%v:%v", hit["composite_id"], strings.Join(hit["matched_fields"].([]string), "+")))
+		}
+		return result, strings.Join(got, " ")
+	}
+
+	// Title matches rank first, then URL, then description and attribute values
+	want := "test-tool:other:5:title test-tool:docs:1:title test-tool:docs:2:url test-tool:docs:4:attribute test-tool:docs:3:description"
+	result, got := search(map[string]interface{}{"query": "kubernetes"})
+	if got != want || result["total_count"] != 5 {
+		t.Errorf("global_search(kubernetes) = %s (total %v), want %s", got, result["total_count"], want)
+	}
+	hits := result["results"].([]map[string]interface{})
+	if matches := hits[3]["attribute_matches"].([]map[string]interface{}); len(matches) != 1 || matches[0]["attribute_name"] != "tag" {
+		t.Errorf("unexpected attribute matches: %v", hits[3]["attribute_matches"])
+	}
+
+	// Scoped to a domain and paginated
+	result, got = search(map[string]interface{}{"query": "kubernetes", "domain_name": "docs", "page": float64(2), "size": float64(2)})
+	if got != "test-tool:docs:4:attribute test-tool:docs:3:description" || result["total_count"] != 4 {
+		t.Errorf("second page of docs = %s (total %v)", got, result["total_count"])
+	}
+
+	// Every word has to match, in any field
+	if _, got := search(map[string]interface{}{"query": "kubernetes home"}); got != "test-tool:docs:3:description" {
+		t.Errorf("global_search(kubernetes home) = %s", got)
+	}
+
+	// Without the full-text index the LIKE fallback finds the same nodes
+	if _, err := db.DB().Exec(`DROP TABLE nodes_fts`); err != nil {
+		t.Fatalf("failed to drop search index: %v", err)
+	}
+	if _, got := search(map[string]interface{}{"query": "kubernetes"}); got != want {
+		t.Errorf("global_search(kubernetes) without FTS = %s, want %s", got, want)
+	}
+
+	if resp := callTool(t, h, "global_search", map[string]interface{}{"query": "kubernetes", "domain_name": "missing"}); resp.Error == nil {
+		t.Error("expected an error for a missing domain")
+	}
+	if resp := callTool(t, h, "global_search", map[string]interface{}{"query": "  "}); resp.Error == nil {
+		t.Error("expected an error for an empty query")
+	}
+}
+
+func TestCreateNodesBatch(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": "https://example.com/existing"})
+
+	nodes := []interface{}{
+		map[string]interface{}{"url": "https://example.com/a", "title": "A"},
+		map[string]interface{}{"url": "https://example.com/existing"},
+		map[string]interface{}{"url": "https://example.com/a"},
+		map[string]interface{}{"title": "no url"},
+		map[string]interface{}{"url": "https://example.com/b", "description": "B"},
+	}
+
+	// Atomic batches create nothing when any item fails
+	resp := callTool(t, h, "create_nodes_batch", map[string]interface{}{"domain_name": "docs", "nodes": nodes, "atomic": true})
+	if resp.Error == nil {
+		t.Fatalf("expected atomic batch to fail")
+	}
+	if stats := structuredContent(t, callTool(t, h, "get_domain_stats", map[string]interface{}{"domain_name": "docs"})); stats["node_count"] != 1 {
+		t.Fatalf("node_count after aborted batch = %v, want 1", stats["node_count"])
+	}
+
+	structured := structuredContent(t, callTool(t, h, "create_nodes_batch", map[string]interface{}{"domain_name": "docs", "nodes": nodes}))
+	if structured["created_count"] != 2 || structured["failed_count"] != 3 {
+		t.Fatalf("unexpected counts: %v", structured)
+	}
+
+	results := structured["results"].([]map[string]interface{})
+	wantSuccess := []bool{true, false, false, false, true}
+	for i, want := range wantSuccess {
+		if results[i]["success"] != want {
+			t.Errorf("result %d success = %v, want %v (%v)", i, results[i]["success"], want, results[i])
+		}
+	}
+	if results[0]["composite_id"] != "test-tool:docs:2" || results[4]["composite_id"] != "test-tool:docs:3" {
+		t.Errorf("unexpected composite IDs: %v, %v", results[0]["composite_id"], results[4]["composite_id"])
+	}
+
+	node := structuredContent(t, callTool(t, h, "get_node", map[string]interface{}{"composite_id": "test-tool:docs:3"}))
+	if node["description"] != "B" {
+		t.Errorf("description = %v, want B", node["description"])
+	}
+}
+
+func TestDeleteDomain(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": "https://example.com"})
+
+	resp := callTool(t, h, "delete_domain", map[string]interface{}{"domain_name": "docs"})
+	if resp.Error == nil {
+		t.Fatalf("expected deleting a non-empty domain to fail")
+	}
+	if msg := resp.Error.Data.(string); !strings.Contains(msg, "1 node(s)") {
+		t.Errorf("error should report the node count, got %q", msg)
+	}
+
+	callTool(t, h, "delete_node", map[string]interface{}{"composite_id": "test-tool:docs:1"})
+	structured := structuredContent(t, callTool(t, h, "delete_domain", map[string]interface{}{"domain_name": "docs"}))
+	if structured["deleted"] != true {
+		t.Errorf("unexpected result: %v", structured)
+	}
+
+	if resp := callTool(t, h, "delete_domain", map[string]interface{}{"domain_name": "docs"}); resp.Error == nil {
+		t.Errorf("expected deleting a missing domain to fail")
+	}
+}
+
+func TestDeleteDryRun(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": "tag", "type": "tag"})
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": "https://example.com/a", "title": "A"})
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": "https://example.com/b"})
+	callTool(t, h, "set_node_attributes", map[string]interface{}{
+		"composite_id": "test-tool:docs:1",
+		"attributes": []interface{}{
+			map[string]interface{}{"name": "tag", "value": "go"},
+			map[string]interface{}{"name": "tag", "value": "sqlite"},
+		},
+	})
+	structuredContent(t, callTool(t, h, "create_dependency", map[string]interface{}{
+		"dependent_node_id": "test-tool:docs:2", "dependency_node_id": "test-tool:docs:1", "dependency_type": "hard",
+	}))
+
+	node := structuredContent(t, callTool(t, h, "delete_node", map[string]interface{}{"composite_id": "test-tool:docs:1", "dry_run": true}))
+	if node["deleted"] != false || node["title"] != "A" || node["attribute_count"] != 2 || node["dependent_count"] != 1 || node["dependency_count"] != 0 {
+		t.Errorf("delete_node dry run = %v", node)
+	}
+	attr := structuredContent(t, callTool(t, h, "delete_domain_attribute", map[string]interface{}{"domain_name": "docs", "attribute_name": "tag", "dry_run": true}))
+	if attr["deleted"] != false || attr["value_count"] != 2 || attr["node_count"] != 1 {
+		t.Errorf("delete_domain_attribute dry run = %v", attr)
+	}
+	// A dry run fails exactly where the deletion would
+	if resp := callTool(t, h, "delete_domain", map[string]interface{}{"domain_name": "docs", "dry_run": true}); resp.Error == nil {
+		t.Error("expected a dry run of deleting a non-empty domain to fail")
+	}
+
+	// Nothing was deleted
+	if got := structuredContent(t, callTool(t, h, "get_node", map[string]interface{}{"composite_id": "test-tool:docs:1"}))["title"]; got != "A" {
+		t.Errorf("node should survive the dry run, got title %v", got)
+	}
+	if resp := callTool(t, h, "get_domain_attribute", map[string]interface{}{"domain_name": "docs", "attribute_name": "tag"}); resp.Error != nil {
+		t.Errorf("attribute should survive the dry run: %v", resp.Error.Data)
+	}
+
+	callTool(t, h, "delete_node", map[string]interface{}{"composite_id": "test-tool:docs:1"})
+	callTool(t, h, "delete_node", map[string]interface{}{"composite_id": "test-tool:docs:2"})
+	domain := structuredContent(t, callTool(t, h, "delete_domain", map[string]interface{}{"domain_name": "docs", "dry_run": true}))
+	if domain["deleted"] != false || domain["attribute_count"] != 1 || domain["template_count"] != 0 {
+		t.Errorf("delete_domain dry run = %v", domain)
+	}
+	if resp := callTool(t, h, "get_domain", map[string]interface{}{"domain_name": "docs"}); resp.Error != nil {
+		t.Errorf("domain should survive the dry run: %v", resp.Error.Data)
+	}
+}
+
+func TestGetNodeFull(t *testing.T) {
+	h, db := newTestProtocolHandlerWithDB(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "other", "description": "Other"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": "tag", "type": "tag"})
+	for _, u := range []string{"https://example.com/hub", "https://example.com/lib", "https://example.com/app"} {
+		callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": u})
+	}
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "other", "url": "https://example.com/guide"})
+
+	callTool(t, h, "set_node_attributes", map[string]interface{}{
+		"composite_id": "test-tool:docs:1",
+		"attributes": []interface{}{
+			map[string]interface{}{"name": "tag", "value": "go"},
+			map[string]interface{}{"name": "tag", "value": "sqlite"},
+		},
+	})
+	structuredContent(t, callTool(t, h, "create_dependency", map[string]interface{}{
+		"dependent_node_id": "test-tool:docs:1", "dependency_node_id": "test-tool:docs:2", "dependency_type": "hard",
+	}))
+	structuredContent(t, callTool(t, h, "create_dependency", map[string]interface{}{
+		"dependent_node_id": "test-tool:docs:3", "dependency_node_id": "test-tool:docs:1", "dependency_type": "soft",
+	}))
+	if _, err := db.DB().Exec(`INSERT INTO node_connections (source_node_id, target_node_id, relationship_type) VALUES (1, 4, 'related'), (3, 1, 'parent')`); err != nil {
+		t.Fatalf("failed to seed connections: %v", err)
+	}
+
+	structured := structuredContent(t, callTool(t, h, "get_node_full", map[string]interface{}{"composite_id": "test-tool:docs:1"}))
+	if structured["url"] != "https://example.com/hub" {
+		t.Errorf("url = %v", structured["url"])
+	}
+	if attrs := structured["attributes"].([]map[string]interface{}); len(attrs) != 2 {
+		t.Errorf("expected 2 attributes, got %v", attrs)
+	}
+	if deps := structured["dependencies"].([]map[string]interface{}); len(deps) != 1 || deps[0]["dependency_node_id"] != "test-tool:docs:2" {
+		t.Errorf("unexpected dependencies: %v", deps)
+	}
+	if deps := structured["dependents"].([]map[string]interface{}); len(deps) != 1 || deps[0]["dependent_node_id"] != "test-tool:docs:3" {
+		t.Errorf("unexpected dependents: %v", deps)
+	}
+	connections := structured["connections"].([]map[string]interface{})
+	if len(connections) != 2 || connections[0]["target_node_id"] != "test-tool:other:4" || connections[1]["source_node_id"] != "test-tool:docs:3" {
+		t.Errorf("unexpected connections: %v", connections)
+	}
+
+	// Sections can be left out to keep the payload small
+	structured = structuredContent(t, callTool(t, h, "get_node_full", map[string]interface{}{
+		"composite_id":         "test-tool:docs:1",
+		"include_attributes":   false,
+		"include_dependents":   false,
+		"include_connections":  false,
+		"include_dependencies": true,
+	}))
+	for _, section := range []string{"attributes", "dependents", "connections"} {
+		if _, ok := structured[section]; ok {
+			t.Errorf("section %s should be excluded", section)
+		}
+	}
+	if _, ok := structured["dependencies"]; !ok {
+		t.Errorf("dependencies should be included")
+	}
+}
+
+func TestCompactJSONText(t *testing.T) {
+	h := newTestProtocolHandler(t)
+
+	scaffoldText := func() string {
+		resp := callTool(t, h, "generate_template_scaffold", map[string]interface{}{"template_type": "layout"})
+		structured := structuredContent(t, resp)
+		if _, ok := structured["scaffold"].(map[string]interface{}); !ok {
+			t.Fatalf("scaffold should be a JSON object in structured content, got %T", structured["scaffold"])
+		}
+		content := resp.Result.(map[string]interface{})["content"].([]map[string]interface{})
+		return content[0]["text"].(string)
+	}
+
+	// Compact by default
+	if text := scaffoldText(); strings.Contains(text, "\n  \"") || !strings.Contains(text, `{"`) {
+		t.Errorf("expected compact JSON, got %q", text)
+	}
+
+	h.SetCompactJSON(false)
+	if text := scaffoldText(); !strings.Contains(text, "\n  \"") {
+		t.Errorf("expected indented JSON, got %q", text)
+	}
+}
+
+func TestFindNodesByAttributeValue(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	for _, domain := range []string{"docs", "blog", "misc"} {
+		callTool(t, h, "create_domain", map[string]interface{}{"name": domain, "description": domain})
+		callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": domain, "name": "tag", "type": "tag"})
+	}
+
+	tag := func(domain, url, value string) {
+		node := structuredContent(t, callTool(t, h, "create_node", map[string]interface{}{"domain_name": domain, "url": url}))
+		resp := callTool(t, h, "set_node_attributes", map[string]interface{}{
+			"composite_id": node["composite_id"],
+			"attributes":   []interface{}{map[string]interface{}{"name": "tag", "value": value}},
+		})
+		if resp.Error != nil {
+			t.Fatalf("failed to tag %s: %v", url, resp.Error.Data)
+		}
+	}
+	tag("docs", "https://example.com/1", "golang")
+	tag("docs", "https://example.com/2", "golang")
+	tag("blog", "https://example.com/3", "golang")
+	tag("misc", "https://example.com/4", "rust")
+
+	structured := structuredContent(t, callTool(t, h, "find_nodes_by_attribute_value", map[string]interface{}{
+		"attribute_name": "tag",
+		"value":          "golang",
+	}))
+	if structured["total_count"] != 3 {
+		t.Fatalf("total_count = %v, want 3", structured["total_count"])
+	}
+
+	domains := structured["domains"].([]map[string]interface{})
+	if len(domains) != 2 || domains[0]["domain_name"] != "blog" || domains[1]["node_count"] != 2 {
+		t.Errorf("unexpected domain facets: %v", domains)
+	}
+
+	nodes := structured["nodes"].([]map[string]interface{})
+	if len(nodes) != 3 || nodes[0]["composite_id"] != "test-tool:blog:3" || nodes[1]["domain_name"] != "docs" {
+		t.Errorf("unexpected nodes: %v", nodes)
+	}
+
+	structured = structuredContent(t, callTool(t, h, "find_nodes_by_attribute_value", map[string]interface{}{
+		"attribute_name": "tag",
+		"value":          "golang",
+		"size":           2.0,
+		"page":           2.0,
+	}))
+	if nodes := structured["nodes"].([]map[string]interface{}); len(nodes) != 1 || structured["total_pages"] != 2 {
+		t.Errorf("unexpected second page: %v", structured)
+	}
+}
+
+func TestUpdateDomain(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+
+	structured := structuredContent(t, callTool(t, h, "update_domain", map[string]interface{}{
+		"domain_name": "docs",
+		"description": "Project documentation",
+	}))
+	if structured["name"] != "docs" || structured["description"] != "Project documentation" {
+		t.Errorf("unexpected result: %v", structured)
+	}
+
+	resp := callTool(t, h, "update_domain", map[string]interface{}{
+		"domain_name": "docs",
+		"name":        "documentation",
+		"description": "Renamed",
+	})
+	if resp.Error == nil {
+		t.Fatalf("expected renaming to be rejected")
+	}
+	if msg := resp.Error.Data.(string); !strings.Contains(msg, "create_domain") {
+		t.Errorf("error should point to create_domain, got %q", msg)
+	}
+
+	if resp := callTool(t, h, "update_domain", map[string]interface{}{"domain_name": "missing", "description": "x"}); resp.Error == nil {
+		t.Errorf("expected updating a missing domain to fail")
+	}
+}
+
+func TestBufferedEventsFlushedOnClose(t *testing.T) {
+	h, db := newTestProtocolHandlerWithDB(t)
+	h.SetEventBuffer(1000, time.Hour)
+
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "docs"})
+	node := structuredContent(t, callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": "https://example.com/a"}))
+	callTool(t, h, "update_node", map[string]interface{}{"composite_id": node["composite_id"], "title": "A"})
+
+	countEvents := func() int {
+		var count int
+		if err := db.DB().QueryRow("SELECT COUNT(*) FROM node_events").Scan(&count); err != nil {
+			t.Fatalf("failed to count events: %v", err)
+		}
+		return count
+	}
+
+	if count := countEvents(); count != 0 {
+		t.Fatalf("expected events to stay buffered, found %d written", count)
+	}
+	metrics := structuredContent(t, callTool(t, h, "get_server_info", nil))["metrics"].(map[string]interface{})
+	if depth := metrics["event_buffer_depth"]; depth != 2 {
+		t.Errorf("expected event buffer depth 2, got %v", depth)
+	}
+
+	if err := h.Close(context.Background()); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+	if count := countEvents(); count != 2 {
+		t.Errorf("expected 2 events flushed on close, found %d", count)
+	}
+
+	// Events recorded after close are written straight through
+	callTool(t, h, "update_node", map[string]interface{}{"composite_id": node["composite_id"], "title": "B"})
+	if count := countEvents(); count != 3 {
+		t.Errorf("expected 3 events after a post-close update, found %d", count)
+	}
+}
+
+func TestNodeEventTools(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	// Buffered events must be visible to the event tools without waiting for a flush
+	h.SetEventBuffer(1000, time.Hour)
+
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": "https://example.com/a"})
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": "https://example.com/b"})
+	callTool(t, h, "update_node", map[string]interface{}{"composite_id": "test-tool:docs:1", "title": "A"})
+	if resp := callTool(t, h, "delete_node", map[string]interface{}{"composite_id": "test-tool:docs:1"}); resp.Error != nil {
+		t.Fatalf("failed to delete node: %v", resp.Error.Data)
+	}
+
+	// The deleted node keeps its history, newest first
+	history := structuredContent(t, callTool(t, h, "get_node_events", map[string]interface{}{"composite_id": "test-tool:docs:1"}))
+	var types []string
+	for _, event := range history["events"].([]map[string]interface{}) {
+		types = append(types, event["event_type"].(string))
+	}
+	if fmt.Sprint(types) != "[deleted updated created]" {
+		t.Errorf("node events = %v, want [deleted updated created]", types)
+	}
+
+	pending := structuredContent(t, callTool(t, h, "get_pending_events", map[string]interface{}{"limit": float64(2)}))
+	events := pending["events"].([]map[string]interface{})
+	if len(events) != 2 || events[0]["node_id"] != 1 || events[1]["node_id"] != 2 {
+		t.Fatalf("expected the two oldest events, got %v", events)
+	}
+
+	eventID := float64(events[0]["id"].(int))
+	processed := structuredContent(t, callTool(t, h, "process_event", map[string]interface{}{"event_id": eventID}))
+	if processed["processed_at"] == nil {
+		t.Fatalf("expected processed_at to be set, got %v", processed)
+	}
+	again := structuredContent(t, callTool(t, h, "process_event", map[string]interface{}{"event_id": eventID}))
+	if again["processed_at"] != processed["processed_at"] {
+		t.Errorf("processing twice changed processed_at from %v to %v", processed["processed_at"], again["processed_at"])
+	}
+	if resp := callTool(t, h, "process_event", map[string]interface{}{"event_id": float64(999)}); resp.Error == nil {
+		t.Error("expected an error for an unknown event")
+	}
+
+	stats := structuredContent(t, callTool(t, h, "get_event_stats", nil))
+	if stats["total_count"] != 4 || stats["pending_count"] != 3 || stats["processed_count"] != 1 {
+		t.Errorf("unexpected stats: %v", stats)
+	}
+	byType := stats["by_type"].(map[string]int)
+	if byType["created"] != 2 || byType["updated"] != 1 || byType["deleted"] != 1 {
+		t.Errorf("unexpected counts by type: %v", byType)
+	}
+}
+
+func TestGetDomain(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Documentation"})
+	for _, url := range []string{"https://example.com/a", "https://example.com/b"} {
+		callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": url})
+	}
+
+	domain := structuredContent(t, callTool(t, h, "get_domain", map[string]interface{}{"domain_name": "docs"}))
+	if domain["name"] != "docs" || domain["description"] != "Documentation" {
+		t.Errorf("unexpected domain details: %v", domain)
+	}
+	if domain["node_count"] != 2 {
+		t.Errorf("expected node_count 2, got %v", domain["node_count"])
+	}
+	if domain["created_at"] == "" || domain["updated_at"] == "" {
+		t.Errorf("expected timestamps, got %v", domain)
+	}
+
+	if resp := callTool(t, h, "get_domain", map[string]interface{}{"domain_name": "missing"}); resp.Error == nil {
+		t.Error("expected an error for a missing domain")
+	}
+}
+
+func TestNodeExpiry(t *testing.T) {
+	h, db := newTestProtocolHandlerWithDB(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "cache", "description": "Transient links"})
+
+	kept := structuredContent(t, callTool(t, h, "create_node", map[string]interface{}{"domain_name": "cache", "url": "https://example.com/kept"}))
+	expiring := structuredContent(t, callTool(t, h, "create_node", map[string]interface{}{
+		"domain_name": "cache", "url": "https://example.com/expiring", "expires_in": float64(3600),
+	}))
+	if expiring["expires_at"] == nil {
+		t.Fatalf("expected expires_at on create_node result: %v", expiring)
+	}
+
+	node := structuredContent(t, callTool(t, h, "get_node", map[string]interface{}{"composite_id": expiring["composite_id"]}))
+	if ttl, ok := node["ttl_seconds"].(int); !ok || ttl < 3590 || ttl > 3600 {
+		t.Errorf("expected ttl_seconds close to 3600, got %v", node["ttl_seconds"])
+	}
+	if _, ok := structuredContent(t, callTool(t, h, "get_node", map[string]interface{}{"composite_id": kept["composite_id"]}))["ttl_seconds"]; ok {
+		t.Error("nodes without expiry should not report ttl_seconds")
+	}
+
+	for _, args := range []map[string]interface{}{
+		{"domain_name": "cache", "url": "https://example.com/past", "expires_at": "2000-01-01T00:00:00Z"},
+		{"domain_name": "cache", "url": "https://example.com/both", "expires_in": float64(60), "expires_at": "2999-01-01T00:00:00Z"},
+	} {
+		if resp := callTool(t, h, "create_node", args); resp.Error == nil {
+			t.Errorf("expected create_node to reject %v", args)
+		}
+	}
+
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "cache", "name": "topic", "type": "string"})
+	for _, compositeID := range []interface{}{kept["composite_id"], expiring["composite_id"]} {
+		if resp := callTool(t, h, "set_node_attributes", map[string]interface{}{
+			"composite_id": compositeID,
+			"attributes":   []interface{}{map[string]interface{}{"name": "topic", "value": "golang"}},
+		}); resp.Error != nil {
+			t.Fatalf("failed to set attributes: %v", resp.Error.Data)
+		}
+	}
+
+	// Expire the node without waiting; it disappears before any sweep
+	if _, err := db.DB().Exec("UPDATE nodes SET expires_at = ? WHERE id = ?", time.Now().UTC().Add(-time.Minute), expiring["id"]); err != nil {
+		t.Fatalf("failed to expire node: %v", err)
+	}
+
+	list := structuredContent(t, callTool(t, h, "list_nodes", map[string]interface{}{"domain_name": "cache"}))
+	if nodes := list["nodes"].([]map[string]interface{}); len(nodes) != 1 || nodes[0]["composite_id"] != kept["composite_id"] {
+		t.Errorf("expected only the unexpired node in list_nodes, got %v", nodes)
+	}
+	if resp := callTool(t, h, "get_node", map[string]interface{}{"composite_id": expiring["composite_id"]}); resp.Error == nil {
+		t.Error("expected get_node to treat an expired node as missing")
+	}
+	if count := structuredContent(t, callTool(t, h, "get_domain", map[string]interface{}{"domain_name": "cache"}))["node_count"]; count != 1 {
+		t.Errorf("expected node_count 1, got %v", count)
+	}
+
+	found := structuredContent(t, callTool(t, h, "find_nodes_by_attribute_value", map[string]interface{}{"attribute_name": "topic", "value": "golang"}))
+	if nodes := found["nodes"].([]map[string]interface{}); found["total_count"] != 1 || len(nodes) != 1 || nodes[0]["composite_id"] != kept["composite_id"] {
+		t.Errorf("expected only the unexpired node in find_nodes_by_attribute_value, got %v", found)
+	}
+	if domains := found["domains"].([]map[string]interface{}); len(domains) != 1 || domains[0]["node_count"] != 1 {
+		t.Errorf("expected a domain count of 1, got %v", found["domains"])
+	}
+	searched := structuredContent(t, callTool(t, h, "search_attribute_values", map[string]interface{}{"domain_name": "cache", "query": "go"}))
+	if results := searched["results"].([]map[string]interface{}); searched["total_count"] != 1 || len(results) != 1 || results[0]["composite_id"] != kept["composite_id"] {
+		t.Errorf("expected only the unexpired node in search_attribute_values, got %v", searched)
+	}
+
+	// The sweeper reclaims the row
+	deleted, err := h.toolHandler.dependencies.SweepExpiredNodesUC.Execute(context.Background())
+	if err != nil || deleted != 1 {
+		t.Fatalf("expected the sweep to delete 1 node, got %d (%v)", deleted, err)
+	}
+	var rows int
+	if err := db.DB().QueryRow("SELECT COUNT(*) FROM nodes").Scan(&rows); err != nil || rows != 1 {
+		t.Errorf("expected 1 node row after the sweep, got %d (%v)", rows, err)
+	}
+}
+
+func TestCreateNodeReplacesExpiredURL(t *testing.T) {
+	h, db := newTestProtocolHandlerWithDB(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "cache", "description": "Transient links"})
+
+	args := map[string]interface{}{"domain_name": "cache", "url": "https://example.com/a", "expires_in": float64(60)}
+	first := structuredContent(t, callTool(t, h, "create_node", args))
+	if _, err := db.DB().Exec("UPDATE nodes SET expires_at = ? WHERE id = ?", time.Now().UTC().Add(-time.Second), first["id"]); err != nil {
+		t.Fatalf("failed to expire node: %v", err)
+	}
+
+	// The expired node still holds the URL until swept, but must not block re-adding it
+	second := structuredContent(t, callTool(t, h, "create_node", map[string]interface{}{"domain_name": "cache", "url": "https://example.com/a"}))
+	if second["composite_id"] == first["composite_id"] {
+		t.Errorf("expected a new node, got the expired one back: %v", second)
+	}
+}
+
+func TestCreateNodeValidatesURL(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+
+	tests := []struct {
+		url           string
+		allowRelative bool
+		valid         bool
+	}{
+		{"https://example.com/page", false, true},
+		{"not a url", false, false},
+		{"/docs/page", false, false},
+		{"example.com", false, false},
+		{"mailto:someone@example.com", false, false},
+		{"#introduction", true, true},
+		{"/docs/page", true, true},
+		{"not a url", true, false},
+	}
+
+	for _, tt := range tests {
+		resp := callTool(t, h, "create_node", map[string]interface{}{
+			"domain_name":    "docs",
+			"url":            tt.url,
+			"allow_relative": tt.allowRelative,
+		})
+		if valid := resp.Error == nil; valid != tt.valid {
+			t.Errorf("create_node(%q, allow_relative=%v): valid = %v, want %v (%v)", tt.url, tt.allowRelative, valid, tt.valid, resp.Error)
+		}
+	}
+
+	// Batch items are checked the same way
+	structured := structuredContent(t, callTool(t, h, "create_nodes_batch", map[string]interface{}{
+		"domain_name": "docs",
+		"nodes": []interface{}{
+			map[string]interface{}{"url": "https://example.com/batch"},
+			map[string]interface{}{"url": "relative/path"},
+		},
+	}))
+	if structured["created_count"] != 1 || structured["failed_count"] != 1 {
+		t.Errorf("expected 1 created and 1 failed, got %v", structured)
+	}
+}
+
+func TestCreateNodeURLSchemeAllowlist(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+
+	create := func(url string) *JSONRPCResponse {
+		return callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": url})
+	}
+
+	// Only http and https by default
+	for _, url := range []string{"http://example.com/a", "HTTPS://example.com/b"} {
+		if resp := create(url); resp.Error != nil {
+			t.Errorf("create_node(%q) failed: %v", url, resp.Error.Data)
+		}
+	}
+	for _, url := range []string{"javascript:alert(1)", "file:///etc/passwd", "data:text/html,<script>x</script>", "ftp://example.com/file"} {
+		resp := create(url)
+		if resp.Error == nil {
+			t.Errorf("create_node(%q) succeeded, want a scheme error", url)
+			continue
+		}
+		if data, _ := resp.Error.Data.(string); !strings.Contains(data, "is not allowed") {
+			t.Errorf("create_node(%q) error = %v, want a scheme error", url, resp.Error.Data)
+		}
+	}
+
+	// Operators can extend the list, including schemes without a host
+	h.SetAllowedURLSchemes([]string{"http", "https", "ftp", "mailto"})
+	for _, url := range []string{"ftp://example.com/file", "mailto:someone@example.com"} {
+		if resp := create(url); resp.Error != nil {
+			t.Errorf("create_node(%q) with ftp and mailto allowed failed: %v", url, resp.Error.Data)
+		}
+	}
+	if resp := create("javascript:alert(1)"); resp.Error == nil {
+		t.Errorf("javascript: URL accepted after extending the allowlist")
+	}
+
+	// Batches and imports use the same list
+	structured := structuredContent(t, callTool(t, h, "create_nodes_batch", map[string]interface{}{
+		"domain_name": "docs",
+		"nodes": []interface{}{
+			map[string]interface{}{"url": "https://example.com/batch"},
+			map[string]interface{}{"url": "data:text/plain,hi"},
+		},
+	}))
+	if structured["created_count"] != 1 || structured["failed_count"] != 1 {
+		t.Errorf("expected 1 created and 1 failed, got %v", structured)
+	}
+
+	resp := callTool(t, h, "import_domain", map[string]interface{}{
+		"data": `{"domain": {"name": "imported"}, "nodes": [{"content": "javascript:alert(1)"}]}`,
+	})
+	if resp.Error == nil {
+		t.Fatalf("import_domain accepted a javascript: URL")
+	}
+	if data, _ := resp.Error.Data.(string); !strings.Contains(data, "is not allowed") {
+		t.Errorf("import_domain error = %v, want a scheme error", resp.Error.Data)
+	}
+}
+
+func TestAttributeNameValidation(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	create := func(name string) *JSONRPCResponse {
+		return callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": name, "type": "tag"})
+	}
+
+	for _, name := range []string{"stars", "release_year", "x-ref", "A1"} {
+		if resp := create(name); resp.Error != nil {
+			t.Errorf("expected %q to be accepted, got %v", name, resp.Error.Data)
+		}
+	}
+	for _, name := range []string{"a b c", " stars", "bad:name", "café", strings.Repeat("a", 101)} {
+		if resp := create(name); resp.Error == nil {
+			t.Errorf("expected %q to be refused", name)
+		}
+	}
+	if resp := create("a b c"); resp.Error == nil || !strings.Contains(fmt.Sprint(resp.Error.Data), "letters, digits, hyphens and underscores") {
+		t.Errorf("expected a charset error, got %+v", resp.Error)
+	}
+
+	// Imports follow the same rules
+	resp := callTool(t, h, "import_domain", map[string]interface{}{
+		"data": `{"domain": {"name": "imported"}, "attributes": [{"name": "two words", "type": "tag"}]}`,
+	})
+	if resp.Error == nil || !strings.Contains(fmt.Sprint(resp.Error.Data), "attribute name 'two words'") {
+		t.Errorf("expected import_domain to refuse an attribute name with spaces, got %+v", resp.Error)
+	}
+
+	// Configured rules can allow spaces and require longer names
+	if err := h.SetAttributeNameRules(3, `^[a-z ]+$`); err != nil {
+		t.Fatalf("failed to set attribute name rules: %v", err)
+	}
+	if resp := create("two words"); resp.Error != nil {
+		t.Errorf("expected spaces to be accepted by the configured pattern, got %v", resp.Error.Data)
+	}
+	if resp := create("ab"); resp.Error == nil || !strings.Contains(fmt.Sprint(resp.Error.Data), "at least 3 characters") {
+		t.Errorf("expected a minimum length error, got %+v", resp.Error)
+	}
+
+	if err := h.SetAttributeNameRules(1, `[`); err == nil {
+		t.Error("expected an invalid pattern to be refused")
+	}
+}
+
+func TestGetFacets(t *testing.T) {
+	h, db := newTestProtocolHandlerWithDB(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": "tag", "type": "tag"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": "status", "type": "string"})
+	for _, url := range []string{"https://example.com/1", "https://example.com/2", "https://example.com/3"} {
+		callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": url})
+	}
+	_, err := db.DB().Exec(`
+		INSERT INTO node_attributes (node_id, attribute_id, value) VALUES
+			(1, 1, 'go'), (1, 1, 'sqlite'), (1, 2, 'draft'),
+			(2, 1, 'go'), (2, 2, 'published'),
+			(3, 1, 'rust'), (3, 2, 'published')
+	`)
+	if err != nil {
+		t.Fatalf("failed to seed attributes: %v", err)
+	}
+
+	// facetSummary renders each facet as "attribute=value:count,..."
+	facetSummary := func(structured map[string]interface{}) []string {
+		var summary []string
+		for _, facet := range structured["facets"].([]map[string]interface{}) {
+			var parts []string
+			for _, value := range facet["values"].([]map[string]interface{}) {
+				parts = append(parts, fmt.Sprintf("%v:%v", value["value"], value["count"]))
+			}
+			summary = append(summary, fmt.Sprintf("%v=%s", facet["attribute"], strings.Join(parts, ",")))
+		}
+		return summary
+	}
+
+	structured := structuredContent(t, callTool(t, h, "get_facets", map[string]interface{}{
+		"domain_name": "docs",
+		"attributes":  []interface{}{"tag", "status", "missing"},
+	}))
+	expected := []string{"tag=go:2,rust:1,sqlite:1", "status=published:2,draft:1", "missing="}
+	if got := facetSummary(structured); strings.Join(got, " ") != strings.Join(expected, " ") {
+		t.Errorf("facets = %v, want %v", got, expected)
+	}
+
+	// A base filter limits the counts to matching nodes
+	structured = structuredContent(t, callTool(t, h, "get_facets", map[string]interface{}{
+		"domain_name": "docs",
+		"attributes":  []interface{}{"tag", "status"},
+		"filters":     []interface{}{map[string]interface{}{"name": "status", "value": "published"}},
+		"limit":       float64(1),
+	}))
+	expected = []string{"tag=go:1", "status=published:2"}
+	if got := facetSummary(structured); strings.Join(got, " ") != strings.Join(expected, " ") {
+		t.Errorf("filtered facets = %v, want %v", got, expected)
+	}
+	if total := structured["facets"].([]map[string]interface{})[0]["total_values"]; total != 2 {
+		t.Errorf("total_values = %v, want 2 before the limit", total)
+	}
+
+	if resp := callTool(t, h, "get_facets", map[string]interface{}{"domain_name": "nope", "attributes": []interface{}{"tag"}}); resp.Error == nil {
+		t.Error("expected an error for an unknown domain")
+	}
+}
+
+func TestCreateDomainIfNotExists(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	structured := structuredContent(t, callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"}))
+	if structured["created"] != true {
+		t.Errorf("expected a new domain, got %v", structured)
+	}
+
+	// Creating it again is still an error by default
+	if resp := callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Other"}); resp.Error == nil {
+		t.Error("expected an error for an existing domain")
+	}
+
+	// With if_not_exists the existing domain comes back unchanged
+	structured = structuredContent(t, callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Other", "if_not_exists": true}))
+	if structured["created"] != false || structured["description"] != "Docs" {
+		t.Errorf("expected the existing domain, got %v", structured)
+	}
+
+	structured = structuredContent(t, callTool(t, h, "create_domain", map[string]interface{}{"name": "blog", "description": "Blog", "if_not_exists": true}))
+	if structured["created"] != true || structured["name"] != "blog" {
+		t.Errorf("expected if_not_exists to create a missing domain, got %v", structured)
+	}
+}
+
+func TestImportDomainRestoresExport(t *testing.T) {
+	source := newTestProtocolHandler(t)
+	callTool(t, source, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	callTool(t, source, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": "tag", "type": "tag"})
+	for i := 1; i <= 2; i++ {
+		callTool(t, source, "create_node", map[string]interface{}{"domain_name": "docs", "url": fmt.Sprintf("https://example.com/%d", i), "title": fmt.Sprintf("Page %d", i)})
+		callTool(t, source, "set_node_attributes", map[string]interface{}{
+			"composite_id": fmt.Sprintf("test-tool:docs:%d", i),
+			"attributes":   []interface{}{map[string]interface{}{"name": "tag", "value": "go"}},
+		})
+	}
+
+	exportText := func(format string) string {
+		resp := callTool(t, source, "export_domain", map[string]interface{}{"domain_name": "docs", "format": format})
+		structuredContent(t, resp)
+		return resp.Result.(map[string]interface{})["content"].([]map[string]interface{})[0]["text"].(string)
+	}
+	jsonDump, ndjsonDump := exportText("json"), exportText("ndjson")
+
+	target, db := newTestProtocolHandlerWithDB(t)
+	structured := structuredContent(t, callTool(t, target, "import_domain", map[string]interface{}{"data": jsonDump}))
+	if structured["domain_created"] != true || structured["attribute_values_created"] != 2 {
+		t.Errorf("unexpected import result %v", structured)
+	}
+	if nodes := structured["nodes"].(map[string]interface{}); nodes["created"] != 2 {
+		t.Errorf("expected 2 created nodes, got %v", nodes)
+	}
+	attributes := structuredContent(t, callTool(t, target, "get_node_attributes", map[string]interface{}{"composite_id": "test-tool:docs:2", "format": "map"}))
+	if got := fmt.Sprint(attributes["attributes"].(map[string]interface{})["tag"]); got != "[go]" {
+		t.Errorf("imported node tag = %s, want [go]", got)
+	}
+
+	// Existing URLs are skipped or overwritten on request
+	structured = structuredContent(t, callTool(t, target, "import_domain", map[string]interface{}{"data": ndjsonDump, "format": "ndjson", "conflict": "skip"}))
+	if nodes := structured["nodes"].(map[string]interface{}); nodes["skipped"] != 2 || structured["domain_created"] != false {
+		t.Errorf("expected both nodes skipped, got %v", structured)
+	}
+	callTool(t, target, "update_node", map[string]interface{}{"composite_id": "test-tool:docs:1", "title": "Changed"})
+	structured = structuredContent(t, callTool(t, target, "import_domain", map[string]interface{}{"data": ndjsonDump, "format": "ndjson", "conflict": "overwrite"}))
+	if nodes := structured["nodes"].(map[string]interface{}); nodes["updated"] != 2 {
+		t.Errorf("expected both nodes updated, got %v", structured)
+	}
+	var title string
+	var values int
+	if err := db.DB().QueryRow("SELECT title, (SELECT COUNT(*) FROM node_attributes) FROM nodes WHERE id = 1").Scan(&title, &values); err != nil || title != "Page 1" || values != 2 {
+		t.Errorf("expected overwrite to restore the title without duplicating attributes, got %q and %d values (err: %v)", title, values, err)
+	}
+
+	// A conflict under the default fail mode rolls back the whole import
+	callTool(t, source, "create_node", map[string]interface{}{"domain_name": "docs", "url": "https://example.com/3"})
+	if resp := callTool(t, target, "import_domain", map[string]interface{}{"data": exportText("json")}); resp.Error == nil {
+		t.Fatal("expected existing URLs to fail the import")
+	}
+	var count int
+	if err := db.DB().QueryRow("SELECT COUNT(*) FROM nodes").Scan(&count); err != nil || count != 2 {
+		t.Errorf("expected the failed import to add nothing, got %d nodes (err: %v)", count, err)
+	}
+}
+
+func TestImportDomainValidatesValues(t *testing.T) {
+	h, db := newTestProtocolHandlerWithDB(t)
+	dump := func(values string) string {
+		return `{"domain": {"name": "imported"}, "attributes": [{"name": "stars", "type": "number"}, {"name": "notes", "type": "markdown"}, {"name": "tag", "type": "tag"}],
+			"nodes": [{"content": "https://example.com/1", "attributes": [` + values + `]}, {"content": "https://example.com/2", "attributes": [{"name": "stars", "value": "5"}]}]}`
+	}
+
+	// Values set_node_attributes refuses fail the whole import, each one reported
+	resp := callTool(t, h, "import_domain", map[string]interface{}{
+		"data": dump(`{"name": "stars", "value": "not-a-number"}, {"name": "notes", "value": "[x](javascript:alert(1))"}, {"name": "missing", "value": "a"}`),
+	})
+	if resp.Error == nil {
+		t.Fatal("import_domain accepted invalid attribute values")
+	}
+	data := fmt.Sprint(resp.Error.Data)
+	for _, want := range []string{"3 invalid", "node 'https://example.com/1'", "attribute 'stars'", "attribute 'notes'", "attribute 'missing' not defined"} {
+		if !strings.Contains(data, want) {
+			t.Errorf("import_domain error %q does not mention %q", data, want)
+		}
+	}
+	var domains int
+	if err := db.DB().QueryRow("SELECT COUNT(*) FROM domains").Scan(&domains); err != nil || domains != 0 {
+		t.Errorf("expected the failed import to create nothing, got %d domains (err: %v)", domains, err)
+	}
+
+	// Valid values are transformed and deduplicated as set_node_attributes would
+	structured := structuredContent(t, callTool(t, h, "import_domain", map[string]interface{}{
+		"data": dump(`{"name": "stars", "value": " 42 "}, {"name": "tag", "value": " go"}, {"name": "tag", "value": "go "}`),
+	}))
+	if structured["attribute_values_created"] != 3 {
+		t.Errorf("expected 3 attribute values, got %v", structured["attribute_values_created"])
+	}
+	attributes := structuredContent(t, callTool(t, h, "get_node_attributes", map[string]interface{}{"composite_id": "test-tool:imported:1", "format": "map"}))
+	if got := fmt.Sprint(attributes["attributes"]); got != "map[stars:42 tag:[go]]" {
+		t.Errorf("imported attributes = %s, want map[stars:42 tag:[go]]", got)
+	}
+}
+
+func TestListToolsSharePaginationShape(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "blog", "description": "Blog"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": "tag", "type": "tag"})
+	for i := 1; i <= 3; i++ {
+		id := fmt.Sprintf("test-tool:docs:%d", i)
+		callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": fmt.Sprintf("https://example.com/%d", i)})
+		callTool(t, h, "set_node_attributes", map[string]interface{}{
+			"composite_id": id,
+			"attributes":   []interface{}{map[string]interface{}{"name": "tag", "value": "go"}},
+		})
+	}
+
+	calls := []struct {
+		tool string
+		args map[string]interface{}
+		want string
+	}{
+		{"list_nodes", map[string]interface{}{"domain_name": "docs", "page": float64(2), "size": float64(2)},
+			`{"page":2,"size":2,"total_count":3,"total_pages":2,"has_more":false,"has_previous":true}`},
+		{"list_domains", map[string]interface{}{"page": float64(1), "size": float64(1)},
+			`{"page":1,"size":1,"total_count":2,"total_pages":2,"has_more":true,"has_previous":false}`},
+		{"filter_nodes_by_attributes", map[string]interface{}{
+			"domain_name": "docs",
+			"filters":     []interface{}{map[string]interface{}{"name": "tag", "value": "go"}},
+			"page":        float64(1),
+			"size":        float64(2),
+		}, `{"page":1,"size":2,"total_count":3,"total_pages":2,"has_more":true,"has_previous":false}`},
+	}
+
+	for _, call := range calls {
+		structured := structuredContent(t, callTool(t, h, call.tool, call.args))
+		pagination, ok := structured["pagination"].(Pagination)
+		if !ok {
+			t.Errorf("%s: expected a Pagination, got %T", call.tool, structured["pagination"])
+			continue
+		}
+		data, err := json.Marshal(pagination)
+		if err != nil {
+			t.Fatalf("%s: failed to encode pagination: %v", call.tool, err)
+		}
+		if string(data) != call.want {
+			t.Errorf("%s: pagination = %s, want %s", call.tool, data, call.want)
+		}
+	}
+}
+
+func TestMaxPageSize(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": "tag", "type": "tag"})
+	for i := 1; i <= 4; i++ {
+		callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": fmt.Sprintf("https://example.com/%d", i)})
+		callTool(t, h, "set_node_attributes", map[string]interface{}{
+			"composite_id": fmt.Sprintf("test-tool:docs:%d", i),
+			"attributes":   []interface{}{map[string]interface{}{"name": "tag", "value": "go"}},
+		})
+	}
+
+	calls := map[string]map[string]interface{}{
+		"list_nodes":   {"domain_name": "docs", "size": float64(1000000)},
+		"list_domains": {"size": float64(1000000)},
+		"filter_nodes_by_attributes": {
+			"domain_name": "docs",
+			"filters":     []interface{}{map[string]interface{}{"name": "tag", "value": "go"}},
+			"size":        float64(1000000),
+		},
+	}
+	check := func(want int) {
+		t.Helper()
+		for tool, args := range calls {
+			resp := callTool(t, h, tool, args)
+			if size := structuredContent(t, resp)["pagination"].(Pagination).Size; size != want {
+				t.Errorf("%s: pagination size = %d, want %d", tool, size, want)
+			}
+			if text := fmt.Sprint(resp.Result); !strings.Contains(text, fmt.Sprintf("Page size capped at %d", want)) {
+				t.Errorf("%s: expected the text content to mention the cap", tool)
+			}
+		}
+	}
+
+	check(constants.MaxPageSize)
+
+	h.SetMaxPageSize(2)
+	check(2)
+	nodes := structuredContent(t, callTool(t, h, "list_nodes", calls["list_nodes"]))["nodes"].([]map[string]interface{})
+	if len(nodes) != 2 {
+		t.Errorf("expected a capped list_nodes page of 2 nodes, got %d", len(nodes))
+	}
+}
+
+func TestNodeAttributesMapFormat(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": "tag", "type": "tag"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": "step", "type": "ordered_tag"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": "status", "type": "string"})
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": "https://example.com"})
+	resp := callTool(t, h, "set_node_attributes", map[string]interface{}{
+		"composite_id": "test-tool:docs:1",
+		"attributes": []interface{}{
+			map[string]interface{}{"name": "step", "value": "deploy", "order_index": float64(2)},
+			map[string]interface{}{"name": "tag", "value": "go"},
+			map[string]interface{}{"name": "status", "value": "draft"},
+			map[string]interface{}{"name": "step", "value": "build", "order_index": float64(1)},
+			map[string]interface{}{"name": "tag", "value": "sqlite"},
+		},
+	})
+	if resp.Error != nil {
+		t.Fatalf("failed to set attributes: %v", resp.Error.Data)
+	}
+
+	for _, tool := range []string{"get_node_attributes", "get_node_with_attributes"} {
+		// The list format stays the default
+		structured := structuredContent(t, callTool(t, h, tool, map[string]interface{}{"composite_id": "test-tool:docs:1"}))
+		if list, ok := structured["attributes"].([]map[string]interface{}); !ok || len(list) != 5 {
+			t.Errorf("%s: expected a list of 5 attributes, got %v", tool, structured["attributes"])
+		}
+
+		structured = structuredContent(t, callTool(t, h, tool, map[string]interface{}{"composite_id": "test-tool:docs:1", "format": "map"}))
+		attributes, ok := structured["attributes"].(map[string]interface{})
+		if !ok {
+			t.Fatalf("%s: expected a map of attributes, got %T", tool, structured["attributes"])
+		}
+		if got := fmt.Sprint(attributes["tag"]); got != "[go sqlite]" {
+			t.Errorf("%s: tag = %s, want [go sqlite]", tool, got)
+		}
+		if got := fmt.Sprint(attributes["step"]); got != "[build deploy]" {
+			t.Errorf("%s: step = %s, want [build deploy] in order_index order", tool, got)
+		}
+		if attributes["status"] != "draft" {
+			t.Errorf("%s: status = %v, want a single value", tool, attributes["status"])
+		}
+
+		if resp := callTool(t, h, tool, map[string]interface{}{"composite_id": "test-tool:docs:1", "format": "table"}); resp.Error == nil {
+			t.Errorf("%s: expected an error for an unsupported format", tool)
+		}
+	}
+}
+
+func TestExportDomain(t *testing.T) {
+	h, db := newTestProtocolHandlerWithDB(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": "tag", "type": "tag"})
+
+	// More nodes than one scanner batch, so the export has to page through them
+	_, err := db.DB().Exec(`
+		WITH RECURSIVE seq(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM seq WHERE i < 150)
+		INSERT INTO nodes (content, domain_id, title, description) SELECT 'https://example.com/' || i, 1, 'Page ' || i, '' FROM seq;
+		INSERT INTO node_attributes (node_id, attribute_id, value) VALUES (1, 1, 'go'), (150, 1, 'sqlite');
+	`)
+	if err != nil {
+		t.Fatalf("failed to seed nodes: %v", err)
+	}
+
+	structured := structuredContent(t, callTool(t, h, "export_domain", map[string]interface{}{"domain_name": "docs"}))
+	if structured["node_count"] != 150 {
+		t.Fatalf("node_count = %v, want 150", structured["node_count"])
+	}
+	if name := structured["domain"].(map[string]interface{})["name"]; name != "docs" {
+		t.Errorf("domain name = %v, want docs", name)
+	}
+	if attributes := structured["attributes"].([]map[string]interface{}); len(attributes) != 1 || attributes[0]["name"] != "tag" {
+		t.Errorf("unexpected attribute definitions %v", attributes)
+	}
+	nodes := structured["nodes"].([]response.NodeWithAttributes)
+	if len(nodes) != 150 || nodes[149].Content != "https://example.com/150" {
+		t.Fatalf("expected all 150 nodes in id order, got %d", len(nodes))
+	}
+	if len(nodes[149].Attributes) != 1 || nodes[149].Attributes[0].Value != "sqlite" {
+		t.Errorf("expected the last node's attributes, got %+v", nodes[149].Attributes)
+	}
+
+	resp := callTool(t, h, "export_domain", map[string]interface{}{"domain_name": "docs", "format": "ndjson"})
+	if structured := structuredContent(t, resp); structured["node_count"] != 150 {
+		t.Errorf("ndjson node_count = %v, want 150", structured["node_count"])
+	}
+	text := resp.Result.(map[string]interface{})["content"].([]map[string]interface{})[0]["text"].(string)
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	if len(lines) != 152 {
+		t.Fatalf("expected 152 ndjson lines (domain, attribute, nodes), got %d", len(lines))
+	}
+	types := map[string]int{}
+	for _, line := range lines {
+		var record struct {
+			Record string `json:"record"`
+		}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("invalid ndjson line %q: %v", line, err)
+		}
+		types[record.Record]++
+	}
+	if types["domain"] != 1 || types["attribute"] != 1 || types["node"] != 150 {
+		t.Errorf("unexpected record types %v", types)
+	}
+
+	if resp := callTool(t, h, "export_domain", map[string]interface{}{"domain_name": "docs", "format": "xml"}); resp.Error == nil {
+		t.Error("expected an error for an unsupported format")
+	}
+}
+
+func TestScanToolsUnknownDomain(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	for _, tool := range []string{"scan_all_content", "estimate_scan_size", "export_domain"} {
+		resp := callTool(t, h, tool, map[string]interface{}{"domain_name": "nope"})
+		if resp.Error == nil || !strings.Contains(fmt.Sprint(resp.Error.Data), "not found") {
+			t.Errorf("%s on an unknown domain: got %+v, want a not found error", tool, resp)
+		}
+	}
+}
+
+func TestListDomainsCursor(t *testing.T) {
+	h, db := newTestProtocolHandlerWithDB(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "zeta", "description": "Created through the tool"})
+	// Older rows in the other created_at format, several sharing a timestamp
+	_, err := db.DB().Exec(`
+		INSERT INTO domains (name, description, created_at) VALUES
+			('beta', '', '2024-01-01 10:00:00'), ('alpha', '', '2024-01-01 10:00:00'),
+			('gamma', '', '2024-01-01 10:00:00'), ('delta', '', '2023-06-01 08:00:00')
+	`)
+	if err != nil {
+		t.Fatalf("failed to seed domains: %v", err)
+	}
+
+	var names []string
+	cursor := ""
+	for pages := 0; pages < 10; pages++ {
+		structured := structuredContent(t, callTool(t, h, "list_domains", map[string]interface{}{"cursor": cursor, "size": float64(2)}))
+		for _, domain := range structured["domains"].([]map[string]interface{}) {
+			names = append(names, domain["name"].(string))
+		}
+		next, ok := structured["next_cursor"].(string)
+		if !ok {
+			break
+		}
+		cursor = next
+	}
+
+	// Oldest first, ties broken by id, every domain exactly once
+	if got := strings.Join(names, ","); got != "delta,beta,alpha,gamma,zeta" {
+		t.Errorf("cursor listing = %s", got)
+	}
+
+	if resp := callTool(t, h, "list_domains", map[string]interface{}{"cursor": "not-a-cursor"}); resp.Error == nil {
+		t.Error("expected an error for an invalid cursor")
+	}
+}
+
+func TestAttributeValueTransforms(t *testing.T) {
+	h, db := newTestProtocolHandlerWithDB(t)
+	if err := h.SetAttributeTransforms(map[string][]string{"string": {"trim", "lowercase"}}); err != nil {
+		t.Fatalf("failed to set transforms: %v", err)
+	}
+	if err := h.SetAttributeTransforms(map[string][]string{"string": {"shout"}}); err == nil {
+		t.Error("expected an error for an unknown transform")
+	}
+
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": "lang", "type": "string"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": "cover", "type": "image"})
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": "https://example.com/a"})
+
+	resp := callTool(t, h, "set_node_attributes", map[string]interface{}{
+		"composite_id": "test-tool:docs:1",
+		"attributes": []interface{}{
+			map[string]interface{}{"name": "lang", "value": "  Go "},
+			map[string]interface{}{"name": "lang", "value": "GO"},
+			map[string]interface{}{"name": "cover", "value": " HTTPS://Example.COM:443/a.png"},
+		},
+	})
+	if resp.Error != nil {
+		t.Fatalf("failed to set attributes: %v", resp.Error.Data)
+	}
+
+	rows, err := db.DB().Query(`SELECT value FROM node_attributes WHERE node_id = 1 ORDER BY attribute_id`)
+	if err != nil {
+		t.Fatalf("failed to read attributes: %v", err)
+	}
+	defer rows.Close()
+	var stored []string
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			t.Fatalf("failed to scan value: %v", err)
+		}
+		stored = append(stored, value)
+	}
+	// The two spellings of "go" collapse into one value after the transforms
+	if got := strings.Join(stored, " "); got != "go https://example.com/a.png" {
+		t.Errorf("stored values = %q, want transformed and deduplicated", got)
+	}
+
+	// Equals filters go through the same transforms as stored values
+	for _, filter := range []map[string]interface{}{
+		{"name": "lang", "value": " GO"},
+		{"name": "cover", "value": "https://EXAMPLE.com/a.png"},
+	} {
+		structured := structuredContent(t, callTool(t, h, "filter_nodes_by_attributes", map[string]interface{}{
+			"domain_name": "docs",
+			"filters":     []interface{}{filter},
+		}))
+		if nodes := structured["nodes"].([]map[string]interface{}); len(nodes) != 1 {
+			t.Errorf("filter %v matched %d nodes, want 1", filter, len(nodes))
+		}
+	}
+}
+
+func TestFilterNodesByNumericAttribute(t *testing.T) {
+	h, db := newTestProtocolHandlerWithDB(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": "stars", "type": "number"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": "tag", "type": "tag"})
+	for _, url := range []string{"https://example.com/1", "https://example.com/2", "https://example.com/3"} {
+		callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": url})
+	}
+	// As text '5' sorts after '10'; the numeric operators must not
+	_, err := db.DB().Exec(`
+		INSERT INTO node_attributes (node_id, attribute_id, value) VALUES
+			(1, 1, '5'), (2, 1, '10'), (3, 1, '15.5'), (1, 2, 'go')
+	`)
+	if err != nil {
+		t.Fatalf("failed to seed attributes: %v", err)
+	}
+
+	filteredURLs := func(filter map[string]interface{}) []string {
+		structured := structuredContent(t, callTool(t, h, "filter_nodes_by_attributes", map[string]interface{}{
+			"domain_name": "docs",
+			"filters":     []interface{}{filter},
+		}))
+		var urls []string
+		for _, node := range structured["nodes"].([]map[string]interface{}) {
+			urls = append(urls, node["url"].(string))
+		}
+		sort.Strings(urls)
+		return urls
+	}
+
+	if got := filteredURLs(map[string]interface{}{"name": "stars", "operator": "gt", "value": "5"}); strings.Join(got, " ") != "https://example.com/2 https://example.com/3" {
+		t.Errorf("gt 5 = %v", got)
+	}
+	if got := filteredURLs(map[string]interface{}{"name": "stars", "operator": "lte", "value": "10"}); strings.Join(got, " ") != "https://example.com/1 https://example.com/2" {
+		t.Errorf("lte 10 = %v", got)
+	}
+	if got := filteredURLs(map[string]interface{}{"name": "stars", "operator": "between", "value": "6", "value_to": "16"}); strings.Join(got, " ") != "https://example.com/2 https://example.com/3" {
+		t.Errorf("between 6 and 16 = %v", got)
+	}
+
+	invalid := []map[string]interface{}{
+		{"name": "tag", "operator": "gt", "value": "1"},
+		{"name": "stars", "operator": "gt", "value": "many"},
+		{"name": "stars", "operator": "between", "value": "1"},
+	}
+	for _, filter := range invalid {
+		resp := callTool(t, h, "filter_nodes_by_attributes", map[string]interface{}{"domain_name": "docs", "filters": []interface{}{filter}})
+		if resp.Error == nil {
+			t.Errorf("expected a validation error for %v", filter)
+		}
+	}
+}
+
+func TestFilterNodesByAttributeGroups(t *testing.T) {
+	h, db := newTestProtocolHandlerWithDB(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": "lang", "type": "string"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": "status", "type": "string"})
+	for _, url := range []string{"https://example.com/1", "https://example.com/2", "https://example.com/3", "https://example.com/4"} {
+		callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": url})
+	}
+	_, err := db.DB().Exec(`
+		INSERT INTO node_attributes (node_id, attribute_id, value) VALUES
+			(1, 1, 'go'), (1, 2, 'active'),
+			(2, 1, 'rust'), (2, 2, 'active'),
+			(3, 1, 'go'), (3, 2, 'archived'),
+			(4, 1, 'python'), (4, 2, 'active')
+	`)
+	if err != nil {
+		t.Fatalf("failed to seed attributes: %v", err)
+	}
+
+	filteredURLs := func(args map[string]interface{}) string {
+		args["domain_name"] = "docs"
+		structured := structuredContent(t, callTool(t, h, "filter_nodes_by_attributes", args))
+		var urls []string
+		for _, node := range structured["nodes"].([]map[string]interface{}) {
+			urls = append(urls, node["url"].(string))
+		}
+		sort.Strings(urls)
+		return strings.Join(urls, " ")
+	}
+
+	// (lang=go OR lang=rust) AND status=active
+	got := filteredURLs(map[string]interface{}{"filters": []interface{}{
+		map[string]interface{}{"logic": "or", "filters": []interface{}{
+			map[string]interface{}{"name": "lang", "value": "go"},
+			map[string]interface{}{"name": "lang", "value": "rust"},
+		}},
+		map[string]interface{}{"name": "status", "value": "active"},
+	}})
+	if got != "https://example.com/1 https://example.com/2" {
+		t.Errorf("grouped filter = %v", got)
+	}
+
+	// Top-level logic combines the filters with OR
+	got = filteredURLs(map[string]interface{}{"logic": "or", "filters": []interface{}{
+		map[string]interface{}{"name": "lang", "value": "python"},
+		map[string]interface{}{"name": "status", "value": "archived"},
+	}})
+	if got != "https://example.com/3 https://example.com/4" {
+		t.Errorf("or filter = %v", got)
+	}
+
+	// Without logic the filters stay ANDed
+	got = filteredURLs(map[string]interface{}{"filters": []interface{}{
+		map[string]interface{}{"name": "lang", "value": "go"},
+		map[string]interface{}{"name": "status", "value": "active"},
+	}})
+	if got != "https://example.com/1" {
+		t.Errorf("and filter = %v", got)
+	}
+
+	resp := callTool(t, h, "filter_nodes_by_attributes", map[string]interface{}{"domain_name": "docs", "filters": []interface{}{
+		map[string]interface{}{"logic": "xor", "filters": []interface{}{map[string]interface{}{"name": "lang", "value": "go"}}},
+	}})
+	if resp.Error == nil {
+		t.Error("expected an error for unknown group logic")
+	}
+}
+
+func TestMoveNodes(t *testing.T) {
+	h, db := newTestProtocolHandlerWithDB(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "blog", "description": "Blog"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": "tag", "type": "tag"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": "stars", "type": "number"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "blog", "name": "tag", "type": "tag"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "blog", "name": "stars", "type": "string"})
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": "https://example.com/a"})
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": "https://example.com/b"})
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "blog", "url": "https://example.com/b"})
+	_, err := db.DB().Exec(`INSERT INTO node_attributes (node_id, attribute_id, value) VALUES (1, 1, 'go'), (1, 2, '5'), (2, 1, 'rust')`)
+	if err != nil {
+		t.Fatalf("failed to seed attributes: %v", err)
+	}
+
+	structured := structuredContent(t, callTool(t, h, "move_nodes", map[string]interface{}{
+		"composite_ids":      []interface{}{"test-tool:docs:1", "test-tool:docs:2", "bad"},
+		"target_domain_name": "blog",
+	}))
+	if structured["moved_count"] != 1 || structured["failed_count"] != 2 {
+		t.Fatalf("expected 1 moved and 2 failed, got %v", structured)
+	}
+
+	results := structured["results"].([]map[string]interface{})
+	if results[0]["success"] != true || results[0]["new_composite_id"] != "test-tool:blog:1" {
+		t.Errorf("expected node 1 to move to blog, got %v", results[0])
+	}
+	// stars is a number in docs but a string in blog, so its value is dropped
+	if dropped := results[0]["dropped_attributes"].([]string); len(dropped) != 1 || dropped[0] != "stars" {
+		t.Errorf("expected stars to be dropped, got %v", dropped)
+	}
+	if results[1]["success"] != false || !strings.Contains(results[1]["error"].(string), "already exists") {
+		t.Errorf("expected a URL collision for node 2, got %v", results[1])
+	}
+	if results[2]["success"] != false {
+		t.Errorf("expected a malformed ID to fail, got %v", results[2])
+	}
+
+	// The tag value now points at blog's tag attribute; node 2 is untouched
+	var attributeID int
+	if err := db.DB().QueryRow(`SELECT attribute_id FROM node_attributes WHERE node_id = 1`).Scan(&attributeID); err != nil {
+		t.Fatalf("failed to read moved attributes: %v", err)
+	}
+	if attributeID != 3 {
+		t.Errorf("expected the tag value to point at attribute 3, got %d", attributeID)
+	}
+	var domainID int
+	if err := db.DB().QueryRow(`SELECT domain_id FROM nodes WHERE id = 2`).Scan(&domainID); err != nil || domainID != 1 {
+		t.Errorf("expected node 2 to stay in docs, got domain %d (%v)", domainID, err)
+	}
+}
+
+func TestMoveNode(t *testing.T) {
+	h, db := newTestProtocolHandlerWithDB(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "blog", "description": "Blog"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": "tag", "type": "tag"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": "note", "type": "string"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "blog", "name": "tag", "type": "tag"})
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": "https://example.com/a"})
+	_, err := db.DB().Exec(`INSERT INTO node_attributes (node_id, attribute_id, value) VALUES (1, 1, 'go'), (1, 2, 'draft')`)
+	if err != nil {
+		t.Fatalf("failed to seed attributes: %v", err)
+	}
+
+	// blog has no note attribute, so the move fails unless told to drop it
+	args := map[string]interface{}{"composite_id": "test-tool:docs:1", "target_domain_name": "blog"}
+	if resp := callTool(t, h, "move_node", args); resp.Error == nil {
+		t.Fatal("expected an error for an attribute missing in the target")
+	}
+
+	args["drop_incompatible_attributes"] = true
+	structured := structuredContent(t, callTool(t, h, "move_node", args))
+	if structured["composite_id"] != "test-tool:blog:1" || structured["domain_name"] != "blog" {
+		t.Errorf("expected the new composite ID in blog, got %v", structured)
+	}
+	if dropped := structured["dropped_attributes"].([]string); len(dropped) != 1 || dropped[0] != "note" {
+		t.Errorf("expected note to be dropped, got %v", dropped)
+	}
+
+	structured = structuredContent(t, callTool(t, h, "get_node_with_attributes", map[string]interface{}{"composite_id": "test-tool:blog:1"}))
+	attributes := structured["attributes"].([]map[string]interface{})
+	if len(attributes) != 1 || attributes[0]["name"] != "tag" || attributes[0]["value"] != "go" {
+		t.Errorf("expected the tag to follow the node, got %v", attributes)
+	}
+}
+
+func TestMergeDomains(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "blog", "description": "Blog"})
+	for _, attr := range []struct{ domain, name, attrType string }{
+		{"docs", "tag", "tag"}, {"docs", "stars", "number"}, {"docs", "note", "string"},
+		{"blog", "tag", "tag"}, {"blog", "stars", "string"},
+	} {
+		callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": attr.domain, "name": attr.name, "type": attr.attrType})
+	}
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": "https://example.com/a"})
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": "https://example.com/b", "title": "Docs B"})
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "blog", "url": "https://example.com/b", "title": "Blog B"})
+	callTool(t, h, "set_node_attributes", map[string]interface{}{"composite_id": "test-tool:docs:1", "attributes": []interface{}{
+		map[string]interface{}{"name": "tag", "value": "go"},
+		map[string]interface{}{"name": "stars", "value": "5"},
+		map[string]interface{}{"name": "note", "value": "draft"},
+	}})
+	callTool(t, h, "set_node_attributes", map[string]interface{}{"composite_id": "test-tool:docs:2", "attributes": []interface{}{
+		map[string]interface{}{"name": "tag", "value": "rust"},
+	}})
+	callTool(t, h, "set_node_attributes", map[string]interface{}{"composite_id": "test-tool:blog:3", "attributes": []interface{}{
+		map[string]interface{}{"name": "tag", "value": "blog"},
+	}})
+	callTool(t, h, "create_template", map[string]interface{}{"name": "landing", "domain_name": "docs", "template_data": `{"type":"layout","version":"1.0"}`})
+	mergeArgs := func(extra map[string]interface{}) map[string]interface{} {
+		args := map[string]interface{}{"source_domain_name": "docs", "target_domain_name": "blog"}
+		for k, v := range extra {
+			args[k] = v
+		}
+		return args
+	}
+
+	// stars is a number in docs and a string in blog; /b is in both domains
+	resp := callTool(t, h, "merge_domains", mergeArgs(nil))
+	if resp.Error == nil || !strings.Contains(fmt.Sprint(resp.Error.Data), "stars (number in source, string in target)") {
+		t.Fatalf("expected an attribute type conflict, got %v", resp.Error)
+	}
+	resp = callTool(t, h, "merge_domains", mergeArgs(map[string]interface{}{"drop_conflicting_attributes": true}))
+	if resp.Error == nil || !strings.Contains(fmt.Sprint(resp.Error.Data), "https://example.com/b") {
+		t.Fatalf("expected a URL collision, got %v", resp.Error)
+	}
+
+	args := mergeArgs(map[string]interface{}{"drop_conflicting_attributes": true, "conflict": "overwrite", "dry_run": true})
+	dryRun := structuredContent(t, callTool(t, h, "merge_domains", args))
+	if resp := callTool(t, h, "get_domain", map[string]interface{}{"domain_name": "docs"}); resp.Error != nil {
+		t.Fatalf("expected the failed merges and the dry run to keep docs: %v", resp.Error)
+	}
+
+	delete(args, "dry_run")
+	result := structuredContent(t, callTool(t, h, "merge_domains", args))
+	for _, key := range []string{"nodes", "attributes", "templates", "attribute_conflicts"} {
+		if fmt.Sprint(result[key]) != fmt.Sprint(dryRun[key]) {
+			t.Errorf("%s = %v, but the dry run reported %v", key, result[key], dryRun[key])
+		}
+	}
+	if got := fmt.Sprint(result["nodes"]); got != "map[moved:1 overwritten:1 skipped:0]" {
+		t.Errorf("unexpected node counts: %s", got)
+	}
+	if got := fmt.Sprint(result["attributes"]); got != "map[merged:1 moved:1]" {
+		t.Errorf("unexpected attribute counts: %s", got)
+	}
+	if got := fmt.Sprint(result["templates"]); got != "map[dropped:0 moved:1]" {
+		t.Errorf("unexpected template counts: %s", got)
+	}
+	if got := fmt.Sprint(result["attribute_conflicts"]); got != "[map[dropped_values:1 name:stars source_type:number target_type:string]]" {
+		t.Errorf("unexpected conflicts: %s", got)
+	}
+
+	if resp := callTool(t, h, "get_domain", map[string]interface{}{"domain_name": "docs"}); resp.Error == nil {
+		t.Error("expected docs to be deleted")
+	}
+	attributesOf := func(compositeID string) string {
+		result := structuredContent(t, callTool(t, h, "get_node_attributes", map[string]interface{}{"composite_id": compositeID, "format": "map"}))
+		return fmt.Sprint(result["attributes"])
+	}
+	if got, want := attributesOf("test-tool:blog:1"), "map[note:draft tag:[go]]"; got != want {
+		t.Errorf("moved node attributes = %s, want %s", got, want)
+	}
+	// The blog node keeps its ID and takes the docs node's title and attributes
+	if got, want := attributesOf("test-tool:blog:3"), "map[tag:[rust]]"; got != want {
+		t.Errorf("overwritten node attributes = %s, want %s", got, want)
+	}
+	node := structuredContent(t, callTool(t, h, "get_node", map[string]interface{}{"composite_id": "test-tool:blog:3"}))
+	if node["title"] != "Docs B" {
+		t.Errorf("expected the overwritten node to take the source title, got %v", node["title"])
+	}
+	if resp := callTool(t, h, "get_template", map[string]interface{}{"composite_id": "test-tool:blog:template:1"}); resp.Error != nil {
+		t.Errorf("expected the template to move to blog: %v", resp.Error)
+	}
+}
+
+func TestMergeDomainsKeepsLinks(t *testing.T) {
+	h, db := newTestProtocolHandlerWithDB(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "blog", "description": "Blog"})
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": "https://example.com/a"})
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": "https://example.com/b"})
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "blog", "url": "https://example.com/b"})
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "blog", "url": "https://example.com/c"})
+	// Node 2 is skipped in favour of node 3: 2->1 moves to 3->1, 2->4 duplicates 3->4 and 2->3 joins the pair
+	_, err := db.DB().Exec(`INSERT INTO node_connections (source_node_id, target_node_id, relationship_type) VALUES
+		(2, 1, 'related'), (2, 4, 'related'), (3, 4, 'related'), (2, 3, 'related')`)
+	if err != nil {
+		t.Fatalf("failed to seed connections: %v", err)
+	}
+	_, err = db.DB().Exec(`INSERT INTO node_dependencies (dependent_node_id, dependency_node_id, dependency_type_id)
+		SELECT 1, 2, id FROM dependency_types WHERE type_name = 'hard'`)
+	if err != nil {
+		t.Fatalf("failed to seed dependency: %v", err)
+	}
+
+	result := structuredContent(t, callTool(t, h, "merge_domains", map[string]interface{}{
+		"source_domain_name": "docs", "target_domain_name": "blog", "conflict": "skip",
+	}))
+	if got := fmt.Sprint(result["links"]); got != "map[dropped:2 repointed:2]" {
+		t.Errorf("unexpected link counts: %s", got)
+	}
+
+	links := func(query string) string {
+		rows, err := db.DB().Query(query)
+		if err != nil {
+			t.Fatalf("failed to list links: %v", err)
+		}
+		defer rows.Close()
+		var pairs []string
+		for rows.Next() {
+			var from, to int
+			if err := rows.Scan(&from, &to); err != nil {
+				t.Fatalf("failed to scan link: %v", err)
+			}
+			pairs = append(pairs, fmt.Sprintf("%d->%d", from, to))
+		}
+		return strings.Join(pairs, " ")
+	}
+	if got := links(`SELECT source_node_id, target_node_id FROM node_connections ORDER BY source_node_id, target_node_id`); got != "3->1 3->4" {
+		t.Errorf("connections = %s, want 3->1 3->4", got)
+	}
+	if got := links(`SELECT dependent_node_id, dependency_node_id FROM node_dependencies`); got != "1->3" {
+		t.Errorf("dependencies = %s, want 1->3", got)
+	}
+
+	var eventData string
+	err = db.DB().QueryRow(`SELECT event_data FROM node_events WHERE node_id = 2 AND event_type = 'deleted'`).Scan(&eventData)
+	if err != nil || !strings.Contains(eventData, `"merged_into":3`) {
+		t.Errorf("expected a deleted event for the skipped node, got %q (%v)", eventData, err)
+	}
+}
+
+func TestScanAllContentStableOrder(t *testing.T) {
+	h, db := newTestProtocolHandlerWithDB(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	for i := 1; i <= 5; i++ {
+		callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": fmt.Sprintf("https://example.com/%d", i)})
+	}
+	// Identical timestamps leave only the id tiebreaker to order the nodes
+	if _, err := db.DB().Exec(`UPDATE nodes SET created_at = '2024-01-01 00:00:00'`); err != nil {
+		t.Fatalf("failed to reset timestamps: %v", err)
+	}
+
+	// scanIDs pages through the domain two nodes at a time
+	scanIDs := func(order string) []int {
+		var ids []int
+		for page := 1; ; page++ {
+			args := map[string]interface{}{
+				"domain_name":         "docs",
+				"page":                float64(page),
+				"max_tokens_per_page": float64(200),
+				"include_attributes":  false,
+			}
+			if order != "" {
+				args["order"] = order
+			}
+			resp := callTool(t, h, "scan_all_content", args)
+			if resp.Error != nil {
+				t.Fatalf("scan failed: %v", resp.Error)
+			}
+			result := resp.Result.(map[string]interface{})["result"].(map[string]interface{})
+			for _, item := range result["items"].([]response.NodeWithAttributes) {
+				ids = append(ids, item.ID)
+			}
+			if !result["pagination"].(service.PaginationInfo).HasMore {
+				return ids
+			}
+		}
+	}
+
+	first := fmt.Sprint(scanIDs(""))
+	if second := fmt.Sprint(scanIDs("")); first != second {
+		t.Errorf("scans of unchanged data differ: %s then %s", first, second)
+	}
+	if first != "[1 2 3 4 5]" {
+		t.Errorf("default order = %s, want ascending by id on equal created_at", first)
+	}
+	if got := fmt.Sprint(scanIDs("desc")); got != "[5 4 3 2 1]" {
+		t.Errorf("desc order = %s", got)
+	}
+	if resp := callTool(t, h, "scan_all_content", map[string]interface{}{"domain_name": "docs", "order": "sideways"}); resp.Error == nil {
+		t.Error("expected an error for an unknown order")
+	}
+}
+
+func TestScanAllContentContentHash(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": "tag", "type": "tag"})
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": "https://example.com/a", "title": "A"})
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": "https://example.com/b", "title": "B"})
+	callTool(t, h, "set_node_attributes", map[string]interface{}{
+		"composite_id": "test-tool:docs:1",
+		"attributes":   []interface{}{map[string]interface{}{"name": "tag", "value": "go"}},
+	})
+
+	// scanHashes returns each node's content hash, keyed by node ID
+	scanHashes := func(args map[string]interface{}) map[int]string {
+		args["domain_name"] = "docs"
+		resp := callTool(t, h, "scan_all_content", args)
+		if resp.Error != nil {
+			t.Fatalf("scan failed: %v", resp.Error)
+		}
+		result := resp.Result.(map[string]interface{})["result"].(map[string]interface{})
+		hashes := map[int]string{}
+		for _, item := range result["items"].([]response.NodeWithAttributes) {
+			hashes[item.ID] = item.ContentHash
+		}
+		return hashes
+	}
+
+	first := scanHashes(map[string]interface{}{"include_content_hash": true})
+	if len(first) != 2 || first[1] == "" || first[2] == "" {
+		t.Fatalf("expected a hash for every node, got %v", first)
+	}
+	if first[1] == first[2] {
+		t.Errorf("different nodes share hash %s", first[1])
+	}
+
+	// The hash covers attributes whether or not they are returned
+	second := scanHashes(map[string]interface{}{"include_content_hash": true, "include_attributes": false})
+	if second[1] != first[1] || second[2] != first[2] {
+		t.Errorf("hashes of unchanged nodes differ: %v then %v", first, second)
+	}
+
+	callTool(t, h, "update_node", map[string]interface{}{"composite_id": "test-tool:docs:2", "title": "B2"})
+	third := scanHashes(map[string]interface{}{"include_content_hash": true})
+	if third[1] != first[1] {
+		t.Errorf("hash of unchanged node 1 changed")
+	}
+	if third[2] == first[2] {
+		t.Errorf("hash of updated node 2 did not change")
+	}
+
+	if hashes := scanHashes(map[string]interface{}{}); hashes[1] != "" {
+		t.Errorf("content_hash should be omitted by default, got %s", hashes[1])
+	}
+}
+
+func TestFindDuplicateNodes(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	for _, url := range []string{
+		"https://Example.com/a/",
+		"https://example.com/a?utm_source=newsletter",
+		"https://example.com/a#top",
+		"https://example.com/b",
+		"https://example.com/b?page=2",
+	} {
+		if resp := callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": url}); resp.Error != nil {
+			t.Fatalf("failed to create %s: %v", url, resp.Error)
+		}
+	}
+
+	structured := structuredContent(t, callTool(t, h, "find_duplicate_nodes", map[string]interface{}{"domain_name": "docs"}))
+	if structured["cluster_count"] != 1 || structured["duplicate_node_count"] != 3 {
+		t.Fatalf("expected one cluster of 3, got %v", structured)
+	}
+
+	cluster := structured["clusters"].([]map[string]interface{})[0]
+	if cluster["normalized_url"] != "https://example.com/a" {
+		t.Errorf("normalized_url = %v", cluster["normalized_url"])
+	}
+	ids := cluster["composite_ids"].([]string)
+	if strings.Join(ids, " ") != "test-tool:docs:1 test-tool:docs:2 test-tool:docs:3" {
+		t.Errorf("composite_ids = %v, want the three /a nodes oldest first", ids)
+	}
+}
+
+func TestGetDomainSchema(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": "lang", "type": "string", "description": "Language"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": "stars", "type": "number", "description": "Stars"})
+	if resp := callTool(t, h, "create_template", map[string]interface{}{
+		"name": "langs", "domain_name": "docs", "template_data": `{"type":"form","version":"1.0","attributes":{"lang":["go","rust"]}}`,
+	}); resp.Error != nil {
+		t.Fatalf("failed to create template: %v", resp.Error)
+	}
+
+	structured := structuredContent(t, callTool(t, h, "get_domain_schema", map[string]interface{}{"domain_name": "docs"}))
+	got := map[string]string{}
+	for _, attr := range structured["attributes"].([]map[string]interface{}) {
+		got[attr["name"].(string)] = fmt.Sprint(attr)
+	}
+	// Only the template's list constrains values; nothing claims required or unique
+	if want := "map[allowed_values:[go rust] description:Language name:lang type:string]"; got["lang"] != want {
+		t.Errorf("lang = %s, want %s", got["lang"], want)
+	}
+	if want := "map[description:Stars name:stars type:number]"; got["stars"] != want {
+		t.Errorf("stars = %s, want %s", got["stars"], want)
+	}
+
+	if resp := callTool(t, h, "get_domain_schema", map[string]interface{}{"domain_name": "missing"}); resp.Error == nil {
+		t.Error("expected an error for an unknown domain")
+	}
+}
+
+func TestDiffDomainSchemas(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "blog", "description": "Blog"})
+	for _, attr := range []map[string]interface{}{
+		{"domain_name": "docs", "name": "tag", "type": "tag", "description": "Topics"},
+		{"domain_name": "docs", "name": "stars", "type": "number", "description": "Stars"},
+		{"domain_name": "docs", "name": "lang", "type": "string", "description": "Language"},
+		{"domain_name": "blog", "name": "tag", "type": "tag", "description": "Topics"},
+		{"domain_name": "blog", "name": "stars", "type": "string", "description": "Rating"},
+		{"domain_name": "blog", "name": "author", "type": "string", "description": "Author"},
+	} {
+		callTool(t, h, "create_domain_attribute", attr)
+	}
+
+	structured := structuredContent(t, callTool(t, h, "diff_domain_schemas", map[string]interface{}{"domain_a": "docs", "domain_b": "blog"}))
+
+	onlyInA := structured["only_in_a"].([]map[string]interface{})
+	if len(onlyInA) != 1 || onlyInA[0]["name"] != "lang" {
+		t.Errorf("only_in_a = %v, want lang", onlyInA)
+	}
+	onlyInB := structured["only_in_b"].([]map[string]interface{})
+	if len(onlyInB) != 1 || onlyInB[0]["name"] != "author" {
+		t.Errorf("only_in_b = %v, want author", onlyInB)
+	}
+	changed := structured["changed"].([]map[string]interface{})
+	if len(changed) != 1 || changed[0]["name"] != "stars" || fmt.Sprint(changed[0]["differences"]) != "[type description]" {
+		t.Errorf("changed = %v, want stars differing in type and description", changed)
+	}
+	if identical := structured["identical"].([]string); len(identical) != 1 || identical[0] != "tag" {
+		t.Errorf("identical = %v, want tag", identical)
+	}
+
+	if resp := callTool(t, h, "diff_domain_schemas", map[string]interface{}{"domain_a": "docs", "domain_b": "nope"}); resp.Error == nil {
+		t.Error("expected an error for an unknown domain")
+	}
+}
+
+func TestListAllAttributesAcrossDomains(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	for _, name := range []string{"docs", "blog", "wiki"} {
+		callTool(t, h, "create_domain", map[string]interface{}{"name": name, "description": name})
+	}
+	for _, attr := range []map[string]interface{}{
+		{"domain_name": "docs", "name": "tag", "type": "tag"},
+		{"domain_name": "docs", "name": "stars", "type": "number"},
+		{"domain_name": "blog", "name": "tag", "type": "tag"},
+		{"domain_name": "blog", "name": "stars", "type": "string"},
+		{"domain_name": "blog", "name": "author", "type": "string"},
+	} {
+		callTool(t, h, "create_domain_attribute", attr)
+	}
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "blog", "url": "https://example.com/a"})
+	callTool(t, h, "set_node_attributes", map[string]interface{}{"composite_id": "test-tool:blog:1", "attributes": []interface{}{
+		map[string]interface{}{"name": "tag", "value": "go"},
+		map[string]interface{}{"name": "tag", "value": "sqlite"},
+	}})
+
+	structured := structuredContent(t, callTool(t, h, "list_all_attributes_across_domains", map[string]interface{}{}))
+	groups := structured["attributes"].([]map[string]interface{})
+	var names []string
+	for _, group := range groups {
+		names = append(names, group["name"].(string))
+	}
+	if got := strings.Join(names, ","); got != "author,stars,tag" {
+		t.Fatalf("attribute names = %s, want author,stars,tag", got)
+	}
+	if structured["domain_count"] != 2 || fmt.Sprint(structured["type_mismatches"]) != "[stars]" {
+		t.Errorf("unexpected summary: domain_count=%v type_mismatches=%v", structured["domain_count"], structured["type_mismatches"])
+	}
+
+	stars, tag := groups[1], groups[2]
+	if stars["type_mismatch"] != true || fmt.Sprint(stars["types"]) != "[number string]" {
+		t.Errorf("expected stars to be flagged, got %v", stars)
+	}
+	if tag["type_mismatch"] != false || tag["domain_count"] != 2 {
+		t.Errorf("expected tag to agree across two domains, got %v", tag)
+	}
+	// Domains are listed by name, with how many values each holds
+	if got := fmt.Sprint(tag["domains"]); got != "[map[description: domain_name:blog type:tag value_count:2] map[description: domain_name:docs type:tag value_count:0]]" {
+		t.Errorf("unexpected tag domains: %s", got)
+	}
+
+	structured = structuredContent(t, callTool(t, h, "list_all_attributes_across_domains", map[string]interface{}{"mismatches_only": true}))
+	if groups := structured["attributes"].([]map[string]interface{}); len(groups) != 1 || groups[0]["name"] != "stars" {
+		t.Errorf("mismatches_only listed %v, want only stars", groups)
+	}
+}
+
+func TestSubscriptionDelivery(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": "https://example.com/a"})
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": "https://example.com/b"})
+
+	var mu sync.Mutex
+	var received []events.DeliveryPayload
+	okServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload events.DeliveryPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("failed to decode payload: %v", err)
+		}
+		mu.Lock()
+		received = append(received, payload)
+		mu.Unlock()
+	}))
+	defer okServer.Close()
+
+	failedAttempts := 0
+	failServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		failedAttempts++
+		mu.Unlock()
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failServer.Close()
+
+	if resp := callTool(t, h, "create_subscription", map[string]interface{}{
+		"composite_id": "test-tool:docs:1", "subscriber_service": "search", "subscriber_endpoint": "ftp://example.com",
+	}); resp.Error == nil {
+		t.Error("expected an error for a non-HTTP endpoint")
+	}
+	subscription := structuredContent(t, callTool(t, h, "create_subscription", map[string]interface{}{
+		"composite_id": "test-tool:docs:1", "subscriber_service": "search", "subscriber_endpoint": okServer.URL,
+	}))
+	callTool(t, h, "create_subscription", map[string]interface{}{
+		"composite_id": "test-tool:docs:2", "subscriber_service": "flaky", "subscriber_endpoint": failServer.URL,
+		"event_types": []interface{}{"updated", "deleted"},
+	})
+
+	// Only events after the subscription, of its node and types, are delivered
+	callTool(t, h, "update_node", map[string]interface{}{"composite_id": "test-tool:docs:1", "title": "A"})
+	callTool(t, h, "update_node", map[string]interface{}{"composite_id": "test-tool:docs:2", "title": "B"})
+
+	// The default client refuses the loopback test servers
+	worker := events.NewDeliveryWorker(h.toolHandler.dependencies.NodeSubscriptionRepo, events.DeliveryConfig{
+		RetryCount:  2,
+		BackoffBase: time.Millisecond,
+		Client:      &http.Client{Timeout: time.Second},
+	})
+	result, err := worker.DeliverPending(context.Background())
+	if err != nil {
+		t.Fatalf("failed to deliver events: %v", err)
+	}
+	if result.Delivered != 1 || result.Failed != 1 {
+		t.Errorf("delivered %d and failed %d, want 1 and 1", result.Delivered, result.Failed)
+	}
+	if len(received) != 1 || received[0].SubscriptionID != subscription["id"] || received[0].Event.EventType != "updated" || received[0].Event.NodeID != 1 {
+		t.Errorf("unexpected deliveries: %+v", received)
+	}
+	if failedAttempts != 3 {
+		t.Errorf("failing endpoint was tried %d times, want 3", failedAttempts)
+	}
+
+	// Delivered and given-up events are not sent again
+	result, err = worker.DeliverPending(context.Background())
+	if err != nil || result.Delivered != 0 || result.Failed != 0 {
+		t.Errorf("second run delivered %+v (err %v), want nothing", result, err)
+	}
+
+	listed := structuredContent(t, callTool(t, h, "list_subscriptions", map[string]interface{}{"composite_id": "test-tool:docs:1"}))
+	if listed["count"] != 1 {
+		t.Errorf("expected 1 subscription, got %v", listed)
+	}
+	if resp := callTool(t, h, "delete_subscription", map[string]interface{}{"subscription_id": float64(subscription["id"].(int))}); resp.Error != nil {
+		t.Fatalf("failed to delete subscription: %v", resp.Error.Data)
+	}
+	if resp := callTool(t, h, "delete_subscription", map[string]interface{}{"subscription_id": float64(subscription["id"].(int))}); resp.Error == nil {
+		t.Error("expected an error for a deleted subscription")
+	}
+}
+
+func TestSubscriptionDeliveryOfDeletedNode(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": "https://example.com/a"})
+
+	var received []events.DeliveryPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload events.DeliveryPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("failed to decode payload: %v", err)
+		}
+		received = append(received, payload)
+	}))
+	defer server.Close()
+
+	callTool(t, h, "create_subscription", map[string]interface{}{
+		"composite_id": "test-tool:docs:1", "subscriber_service": "search", "subscriber_endpoint": server.URL,
+		"event_types": []interface{}{"deleted"},
+	})
+	if resp := callTool(t, h, "delete_node", map[string]interface{}{"composite_id": "test-tool:docs:1"}); resp.Error != nil {
+		t.Fatalf("failed to delete node: %v", resp.Error.Data)
+	}
+
+	worker := events.NewDeliveryWorker(h.toolHandler.dependencies.NodeSubscriptionRepo, events.DeliveryConfig{
+		BackoffBase: time.Millisecond,
+		Client:      &http.Client{Timeout: time.Second},
+	})
+	result, err := worker.DeliverPending(context.Background())
+	if err != nil {
+		t.Fatalf("failed to deliver events: %v", err)
+	}
+	if result.Delivered != 1 || len(received) != 1 || received[0].Event.EventType != "deleted" || received[0].Event.NodeID != 1 {
+		t.Errorf("expected the deletion to be delivered, got %+v and %+v", result, received)
+	}
+}
+
+func TestSubscriptionDeliveryRefusesPrivateAddress(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": "https://example.com/a"})
+
+	hits := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+	}))
+	defer server.Close()
+
+	callTool(t, h, "create_subscription", map[string]interface{}{
+		"composite_id": "test-tool:docs:1", "subscriber_service": "internal", "subscriber_endpoint": server.URL,
+	})
+	callTool(t, h, "update_node", map[string]interface{}{"composite_id": "test-tool:docs:1", "title": "A"})
+
+	worker := events.NewDeliveryWorker(h.toolHandler.dependencies.NodeSubscriptionRepo, events.DeliveryConfig{
+		BackoffBase: time.Millisecond,
+	})
+	result, err := worker.DeliverPending(context.Background())
+	if err != nil {
+		t.Fatalf("failed to deliver events: %v", err)
+	}
+	if result.Failed != 1 || hits != 0 {
+		t.Errorf("expected the loopback endpoint to be refused, got %+v after %d requests", result, hits)
+	}
+}
+
+func TestSetNodeAttributesDryRun(t *testing.T) {
+	h, db := newTestProtocolHandlerWithDB(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	for name, attrType := range map[string]string{"lang": "string", "topic": "tag", "note": "string", "rating": "number"} {
+		callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": name, "type": attrType})
+	}
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": "https://example.com/a"})
+	if resp := callTool(t, h, "set_node_attributes", map[string]interface{}{
+		"composite_id": "test-tool:docs:1",
+		"attributes": []interface{}{
+			map[string]interface{}{"name": "lang", "value": "go"},
+			map[string]interface{}{"name": "topic", "value": "a"},
+			map[string]interface{}{"name": "note", "value": "x"},
+		},
+	}); resp.Error != nil {
+		t.Fatalf("failed to set attributes: %v", resp.Error.Data)
+	}
+
+	preview := structuredContent(t, callTool(t, h, "set_node_attributes", map[string]interface{}{
+		"composite_id": "test-tool:docs:1",
+		"dry_run":      true,
+		"attributes": []interface{}{
+			map[string]interface{}{"name": "lang", "value": "go"},
+			map[string]interface{}{"name": "topic", "value": "b"},
+			map[string]interface{}{"name": "rating", "value": "5"},
+			map[string]interface{}{"name": "rating", "value": "lots"},
+			map[string]interface{}{"name": "missing", "value": "y"},
+		},
+	}))
+
+	summarize := func(key string) string {
+		var parts []string
+		for _, change := range preview[key].([]map[string]interface{}) {
+			parts = append(parts, fmt.Sprintf("%v:%v>%v", change["name"], change["old_values"], change["new_values"]))
+		}
+		return strings.Join(parts, " ")
+	}
+	expected := map[string]string{
+		"added":     "rating:<nil>>[5]",
+		"changed":   "topic:[a]>[b]",
+		"unchanged": "lang:[go]><nil>",
+		"removed":   "note:[x]><nil>",
+	}
+	for key, want := range expected {
+		if got := summarize(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+
+	errs := preview["errors"].([]map[string]interface{})
+	if preview["valid"] != false || len(errs) != 2 || errs[0]["name"] != "missing" || errs[1]["name"] != "rating" || errs[1]["value"] != "lots" {
+		t.Errorf("unexpected validation errors: valid=%v errors=%v", preview["valid"], errs)
+	}
+
+	var count int
+	if err := db.DB().QueryRow(`SELECT COUNT(*) FROM node_attributes WHERE node_id = 1 AND value IN ('go', 'a', 'x')`).Scan(&count); err != nil {
+		t.Fatalf("failed to count attributes: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("dry run changed stored attributes: %d of the original 3 left", count)
+	}
+}
+
+func TestSetNodeAttributesAllOrNothing(t *testing.T) {
+	h, db := newTestProtocolHandlerWithDB(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	for name, attrType := range map[string]string{"lang": "string", "topic": "tag", "rating": "number"} {
+		callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": name, "type": attrType})
+	}
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": "https://example.com/a"})
+	callTool(t, h, "set_node_attributes", map[string]interface{}{"composite_id": "test-tool:docs:1", "attributes": []interface{}{
+		map[string]interface{}{"name": "lang", "value": "go"},
+		map[string]interface{}{"name": "topic", "value": "a"},
+	}})
+	attributesOf := func() string {
+		result := structuredContent(t, callTool(t, h, "get_node_attributes", map[string]interface{}{"composite_id": "test-tool:docs:1", "format": "map"}))
+		return fmt.Sprint(result["attributes"])
+	}
+	original := attributesOf()
+
+	// Every invalid input is named, and the valid ones are not applied either
+	resp := callTool(t, h, "set_node_attributes", map[string]interface{}{"composite_id": "test-tool:docs:1", "attributes": []interface{}{
+		map[string]interface{}{"name": "topic", "value": "b"},
+		map[string]interface{}{"name": "rating", "value": "lots"},
+		map[string]interface{}{"name": "missing", "value": "y"},
+	}})
+	if resp.Error == nil {
+		t.Fatal("expected invalid attributes to fail")
+	}
+	message := fmt.Sprint(resp.Error.Data)
+	for _, name := range []string{"'rating'", "'missing'", "no attributes were set"} {
+		if !strings.Contains(message, name) {
+			t.Errorf("error %q does not mention %s", message, name)
+		}
+	}
+	if got := attributesOf(); got != original {
+		t.Errorf("failed set changed attributes to %s, want %s", got, original)
+	}
+
+	// A write failing midway rolls back the values already written
+	if _, err := db.DB().Exec(`CREATE TRIGGER reject_boom BEFORE INSERT ON node_attributes
+		WHEN NEW.value = 'boom' BEGIN SELECT RAISE(ABORT, 'rejected'); END`); err != nil {
+		t.Fatalf("failed to create trigger: %v", err)
+	}
+	resp = callTool(t, h, "set_node_attributes", map[string]interface{}{"composite_id": "test-tool:docs:1", "attributes": []interface{}{
+		map[string]interface{}{"name": "lang", "value": "rust"},
+		map[string]interface{}{"name": "topic", "value": "boom"},
+	}})
+	if resp.Error == nil || !strings.Contains(fmt.Sprint(resp.Error.Data), "'boom'") {
+		t.Errorf("expected the failing value to be named, got %v", resp.Error)
+	}
+	if got := attributesOf(); got != original {
+		t.Errorf("failed write changed attributes to %s, want %s", got, original)
+	}
+}
+
+func TestSuggestAttributeValues(t *testing.T) {
+	h, db := newTestProtocolHandlerWithDB(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": "topic", "type": "tag"})
+	tags := [][]string{{"golang", "go_tips"}, {"golang", "gRPC"}, {"golang", "graphql"}, {"gRPC"}}
+	for i, values := range tags {
+		callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": fmt.Sprintf("https://example.com/%d", i)})
+		var attributes []interface{}
+		for _, value := range values {
+			attributes = append(attributes, map[string]interface{}{"name": "topic", "value": value})
+		}
+		callTool(t, h, "set_node_attributes", map[string]interface{}{"composite_id": fmt.Sprintf("test-tool:docs:%d", i+1), "attributes": attributes})
+	}
+
+	tests := []struct {
+		args     map[string]interface{}
+		expected string
+	}{
+		{map[string]interface{}{}, "[golang:3 grpc:2 go_tips:1 graphql:1]"},
+		{map[string]interface{}{"prefix": "GR"}, "[grpc:2 graphql:1]"},
+		{map[string]interface{}{"prefix": "go_"}, "[go_tips:1]"}, // _ is not a wildcard
+		{map[string]interface{}{"limit": float64(1)}, "[golang:3]"},
+	}
+	for _, tt := range tests {
+		tt.args["domain_name"] = "docs"
+		tt.args["attribute_name"] = "topic"
+		result := structuredContent(t, callTool(t, h, "suggest_attribute_values", tt.args))
+		var got []string
+		for _, value := range result["values"].([]map[string]interface{}) {
+			got = append(got, fmt.Sprintf("%v:%v", value["value"], value["node_count"]))
+		}
+		if fmt.Sprint(got) != tt.expected {
+			t.Errorf("suggest_attribute_values(%v) = %v, want %s", tt.args, got, tt.expected)
+		}
+	}
+
+	// Values of expired nodes are not counted
+	if _, err := db.DB().Exec("UPDATE nodes SET expires_at = ? WHERE id = 2", time.Now().UTC().Add(-time.Minute)); err != nil {
+		t.Fatalf("failed to expire node: %v", err)
+	}
+	result := structuredContent(t, callTool(t, h, "suggest_attribute_values", map[string]interface{}{"domain_name": "docs", "attribute_name": "topic"}))
+	var got []string
+	for _, value := range result["values"].([]map[string]interface{}) {
+		got = append(got, fmt.Sprintf("%v:%v", value["value"], value["node_count"]))
+	}
+	if expected := "[golang:2 go_tips:1 graphql:1 grpc:1]"; fmt.Sprint(got) != expected {
+		t.Errorf("suggest_attribute_values after expiry = %v, want %s", got, expected)
+	}
+
+	if resp := callTool(t, h, "suggest_attribute_values", map[string]interface{}{"domain_name": "docs", "attribute_name": "missing"}); resp.Error == nil {
+		t.Error("expected an error for an undefined attribute")
+	}
+}
+
+func TestGetAttributeStatistics(t *testing.T) {
+	h, db := newTestProtocolHandlerWithDB(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": "topic", "type": "tag"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": "note", "type": "string"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": "unused", "type": "string"})
+	tags := [][]string{{"golang", "grpc"}, {"golang"}, {"golang", "sqlite"}}
+	for i, values := range tags {
+		callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": fmt.Sprintf("https://example.com/%d", i)})
+		var attributes []interface{}
+		for _, value := range values {
+			attributes = append(attributes, map[string]interface{}{"name": "topic", "value": value})
+		}
+		if i == 0 {
+			attributes = append(attributes, map[string]interface{}{"name": "note", "value": "start here"})
+		}
+		callTool(t, h, "set_node_attributes", map[string]interface{}{"composite_id": fmt.Sprintf("test-tool:docs:%d", i+1), "attributes": attributes})
+	}
+
+	statistics := func() (interface{}, string) {
+		result := structuredContent(t, callTool(t, h, "get_attribute_statistics", map[string]interface{}{"domain_name": "docs", "top_values": float64(2)}))
+		var got []string
+		for _, attr := range result["attributes"].([]map[string]interface{}) {
+			var top []string
+			for _, value := range attr["top_values"].([]map[string]interface{}) {
+				top = append(top, fmt.Sprintf("%v:%v", value["value"], value["node_count"]))
+			}
+			got = append(got, fmt.Sprintf("%v/%v/%v%v", attr["name"], attr["node_count"], attr["distinct_values"], top))
+		}
+		return result["node_count"], fmt.Sprint(got)
+	}
+
+	nodeCount, got := statistics()
+	expected := "[topic/3/3[golang:3 grpc:1] note/1/1[start here:1] unused/0/0[]]"
+	if nodeCount != 3 || got != expected {
+		t.Errorf("node_count = %v and attributes = %v, want 3 and %s", nodeCount, got, expected)
+	}
+
+	// An expired node drops out of every count, agreeing with the domain node_count
+	if _, err := db.DB().Exec("UPDATE nodes SET expires_at = ? WHERE id = 1", time.Now().UTC().Add(-time.Minute)); err != nil {
+		t.Fatalf("failed to expire node: %v", err)
+	}
+	nodeCount, got = statistics()
+	expected = "[topic/2/2[golang:2 sqlite:1] note/0/0[] unused/0/0[]]"
+	if nodeCount != 2 || got != expected {
+		t.Errorf("after expiry node_count = %v and attributes = %v, want 2 and %s", nodeCount, got, expected)
+	}
+
+	if resp := callTool(t, h, "get_attribute_statistics", map[string]interface{}{"domain_name": "missing"}); resp.Error == nil {
+		t.Error("expected an error for an unknown domain")
+	}
+}
+
+func TestSplitCompositeID(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected parsedCompositeID
+	}{
+		{"url-db:docs:12", parsedCompositeID{ToolName: "url-db", DomainName: "docs", ID: 12}},
+		{"url-db:docs:template:3", parsedCompositeID{ToolName: "url-db", DomainName: "docs", ID: 3, Template: true}},
+		{"team:url-db:docs:12", parsedCompositeID{ToolName: "team:url-db", DomainName: "docs", ID: 12}},
+		{"team:url-db:docs:template:3", parsedCompositeID{ToolName: "team:url-db", DomainName: "docs", ID: 3, Template: true}},
+		{"url-db:template:7", parsedCompositeID{ToolName: "url-db", DomainName: "template", ID: 7}}, // a node of a domain named template
+	}
+	for _, tt := range tests {
+		got, err := splitCompositeID(tt.input)
+		if err != nil || got != tt.expected {
+			t.Errorf("splitCompositeID(%q) = %+v, %v, want %+v", tt.input, got, err, tt.expected)
+		}
+	}
+
+	for _, input := range []string{"", "12", "docs:12", ":docs:12", "url-db::12", "url-db:docs:", "url-db:docs:abc", "url-db:docs:0", "url-db:docs:template:x"} {
+		if got, err := splitCompositeID(input); err == nil {
+			t.Errorf("expected splitCompositeID(%q) to fail, got %+v", input, got)
+		}
+	}
+}
+
+func TestCompositeIDToolName(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": "https://example.com"})
+	callTool(t, h, "create_template", map[string]interface{}{"name": "landing", "domain_name": "docs", "template_data": `{"type":"layout","version":"1.0"}`})
+
+	if resp := callTool(t, h, "get_node", map[string]interface{}{"composite_id": "test-tool:docs:1"}); resp.Error != nil {
+		t.Fatalf("expected this server's composite ID to resolve, got %v", resp.Error.Data)
+	}
+
+	calls := []struct {
+		tool string
+		args map[string]interface{}
+	}{
+		{"get_node", map[string]interface{}{"composite_id": "other-tool:docs:1"}},
+		{"get_node_attributes", map[string]interface{}{"composite_id": "other-tool:docs:1"}},
+		{"move_nodes", map[string]interface{}{"composite_ids": []interface{}{"other-tool:docs:1"}, "target_domain_name": "docs"}},
+		{"create_dependency", map[string]interface{}{"dependent_node_id": "other-tool:docs:1", "dependency_node_id": "test-tool:docs:1", "dependency_type": "hard"}},
+		{"get_template", map[string]interface{}{"composite_id": "other-tool:docs:template:1"}},
+	}
+	for _, call := range calls {
+		resp := callTool(t, h, call.tool, call.args)
+		var message string
+		if resp.Error != nil {
+			message = fmt.Sprint(resp.Error.Data)
+		} else {
+			message = fmt.Sprint(resp.Result)
+		}
+		if !strings.Contains(message, "composite_id belongs to tool 'other-tool'") {
+			t.Errorf("expected %s to refuse another tool's composite ID, got %s", call.tool, message)
+		}
+	}
+
+	// list_templates hands out IDs with the configured tool name
+	if text := fmt.Sprint(callTool(t, h, "list_templates", map[string]interface{}{"domain_name": "docs"}).Result); !strings.Contains(text, "test-tool:docs:template:1") {
+		t.Errorf("expected list_templates to use the configured tool name, got %s", text)
+	}
+
+	// Node and template IDs are not interchangeable
+	if resp := callTool(t, h, "get_node", map[string]interface{}{"composite_id": "test-tool:docs:template:1"}); resp.Error == nil || !strings.Contains(fmt.Sprint(resp.Error.Data), "names a template") {
+		t.Errorf("expected get_node to refuse a template ID, got %+v", resp.Error)
+	}
+	if resp := callTool(t, h, "get_template", map[string]interface{}{"composite_id": "test-tool:docs:1"}); resp.Error == nil || !strings.Contains(fmt.Sprint(resp.Error.Data), "names a node") {
+		t.Errorf("expected get_template to refuse a node ID, got %+v", resp.Error)
+	}
+}
+
+func TestRenameAttributeValue(t *testing.T) {
+	h, db := newTestProtocolHandlerWithDB(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": "topic", "type": "tag"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": "stars", "type": "number"})
+	// Node 2 already has the new value, so its old row is merged away
+	tags := [][]string{{"golnag"}, {"golnag", "golang"}, {"golang"}, {"rust"}}
+	for i, values := range tags {
+		callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": fmt.Sprintf("https://example.com/%d", i)})
+		var attributes []interface{}
+		for _, value := range values {
+			attributes = append(attributes, map[string]interface{}{"name": "topic", "value": value})
+		}
+		callTool(t, h, "set_node_attributes", map[string]interface{}{"composite_id": fmt.Sprintf("test-tool:docs:%d", i+1), "attributes": attributes})
+	}
+	rename := func(args map[string]interface{}) *JSONRPCResponse {
+		args["domain_name"] = "docs"
+		if _, ok := args["attribute_name"]; !ok {
+			args["attribute_name"] = "topic"
+		}
+		return callTool(t, h, "rename_attribute_value", args)
+	}
+	topicValues := func() string {
+		rows, err := db.DB().Query(`SELECT na.node_id, na.value FROM node_attributes na
+			JOIN attributes a ON a.id = na.attribute_id WHERE a.name = 'topic' ORDER BY na.node_id, na.value`)
+		if err != nil {
+			t.Fatalf("failed to list topics: %v", err)
+		}
+		defer rows.Close()
+		var got []string
+		for rows.Next() {
+			var nodeID int
+			var value string
+			if err := rows.Scan(&nodeID, &value); err != nil {
+				t.Fatalf("failed to scan topic: %v", err)
+			}
+			got = append(got, fmt.Sprintf("%d:%s", nodeID, value))
+		}
+		return fmt.Sprint(got)
+	}
+	before := topicValues()
+
+	preview := structuredContent(t, rename(map[string]interface{}{"old_value": "GOLNAG", "new_value": "golang", "dry_run": true}))
+	if preview["renamed_count"] != 1 || preview["merged_count"] != 1 || preview["changed_count"] != 2 || preview["dry_run"] != true {
+		t.Errorf("unexpected dry run result: %v", preview)
+	}
+	if got := topicValues(); got != before {
+		t.Errorf("dry run changed stored values: %s, want %s", got, before)
+	}
+
+	result := structuredContent(t, rename(map[string]interface{}{"old_value": "golnag", "new_value": "golang"}))
+	if result["renamed_count"] != 1 || result["merged_count"] != 1 || result["dry_run"] != false {
+		t.Errorf("unexpected rename result: %v", result)
+	}
+	if got, want := topicValues(), "[1:golang 2:golang 3:golang 4:rust]"; got != want {
+		t.Errorf("topics after rename = %s, want %s", got, want)
+	}
+
+	// Renaming again finds nothing to change
+	result = structuredContent(t, rename(map[string]interface{}{"old_value": "golnag", "new_value": "golang"}))
+	if result["changed_count"] != 0 {
+		t.Errorf("expected nothing left to rename, got %v", result)
+	}
+
+	for _, args := range []map[string]interface{}{
+		{"old_value": "golang", "new_value": "Golang"},                    // same value after normalization
+		{"attribute_name": "missing", "old_value": "a", "new_value": "b"},  // undefined attribute
+		{"attribute_name": "stars", "old_value": "5", "new_value": "lots"}, // invalid for the attribute type
+	} {
+		if resp := rename(args); resp.Error == nil {
+			t.Errorf("expected rename_attribute_value(%v) to fail", args)
+		}
+	}
+}
+
+func TestGetStorageStats(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "blog", "description": "Blog"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": "topic", "type": "tag"})
+	for i := 1; i <= 3; i++ {
+		callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": fmt.Sprintf("https://example.com/%d", i)})
+	}
+	callTool(t, h, "set_node_attributes", map[string]interface{}{"composite_id": "test-tool:docs:1",
+		"attributes": []interface{}{map[string]interface{}{"name": "topic", "value": "go"}, map[string]interface{}{"name": "topic", "value": "sql"}}})
+	callTool(t, h, "create_dependency", map[string]interface{}{"dependent_node_id": "test-tool:docs:1", "dependency_node_id": "test-tool:docs:2", "dependency_type": "reference"})
+	callTool(t, h, "create_subscription", map[string]interface{}{"composite_id": "test-tool:docs:2", "subscriber_service": "search", "subscriber_endpoint": "https://example.com/hook"})
+
+	result := structuredContent(t, callTool(t, h, "get_storage_stats", map[string]interface{}{}))
+	expected := map[string]int{
+		"domains": 2, "nodes": 3, "attributes": 1, "node_attributes": 2,
+		"templates": 0, "dependencies": 1, "subscriptions": 1, "events": 3,
+	}
+	rowCounts := result["row_counts"].(map[string]interface{})
+	for name, count := range expected {
+		if rowCounts[name] != count {
+			t.Errorf("row_counts[%s] = %v, want %d", name, rowCounts[name], count)
+		}
+	}
+	if len(rowCounts) != len(expected) {
+		t.Errorf("unexpected row counts: %v", rowCounts)
+	}
+
+	pageSize, pageCount := result["page_size"].(int), result["page_count"].(int)
+	if pageSize <= 0 || pageCount <= 0 || result["size_bytes"] != int64(pageSize)*int64(pageCount) {
+		t.Errorf("unexpected database size: %v", result)
+	}
+}
+
+func TestExplainFilter(t *testing.T) {
+	h := newTestProtocolHandler(t)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	callTool(t, h, "create_domain_attribute", map[string]interface{}{"domain_name": "docs", "name": "stars", "type": "number"})
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": "https://example.com/a"})
+	args := map[string]interface{}{
+		"domain_name": "docs",
+		"filters":     []interface{}{map[string]interface{}{"name": "stars", "operator": "gt", "value": "10"}},
+	}
+
+	// Admin tools are hidden and refused by default
+	if resp := callTool(t, h, "explain_filter", args); resp.Error == nil || resp.Error.Code != MethodNotFound {
+		t.Errorf("expected explain_filter to be refused without admin tools, got %+v", resp)
+	}
+	listed := func() bool {
+		resp := h.HandleRequest(context.Background(), &JSONRPCRequest{JSONRPC: "2.0", ID: 1, Method: "tools/list"})
+		for _, tool := range resp.Result.(map[string]interface{})["tools"].([]map[string]interface{}) {
+			if tool["name"] == "explain_filter" {
+				return true
+			}
+		}
+		return false
+	}
+	if listed() {
+		t.Error("explain_filter should not be listed without admin tools")
+	}
+
+	h.SetAdminTools(true)
+	if !listed() {
+		t.Error("explain_filter should be listed with admin tools")
+	}
+
+	result := structuredContent(t, callTool(t, h, "explain_filter", args))
+	if _, ok := result["nodes"]; ok {
+		t.Error("explain_filter must not return filter results")
+	}
+	plans := result["plans"].([]map[string]interface{})
+	if len(plans) != 2 || plans[0]["name"] != "page" || plans[1]["name"] != "count" {
+		t.Fatalf("expected page and count plans, got %v", plans)
+	}
+	for _, plan := range plans {
+		rows := plan["plan"].([]map[string]interface{})
+		if len(rows) == 0 || !strings.Contains(fmt.Sprint(rows), "nodes") {
+			t.Errorf("expected plan rows over nodes for the %s query, got %v", plan["name"], rows)
+		}
+	}
+
+	// Filters are validated as for filter_nodes_by_attributes
+	args["filters"] = []interface{}{map[string]interface{}{"name": "stars", "operator": "gt", "value": "many"}}
+	if resp := callTool(t, h, "explain_filter", args); resp.Error == nil {
+		t.Error("expected an error for a non-numeric value")
+	}
+}
+
+func TestRebuildSearchIndex(t *testing.T) {
+	h, db := newTestProtocolHandlerWithDB(t)
+	h.SetAdminTools(true)
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "docs", "description": "Docs"})
+	callTool(t, h, "create_domain", map[string]interface{}{"name": "other", "description": "Other"})
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": "https://example.com/go", "title": "Go concurrency patterns"})
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "docs", "url": "https://example.com/rust", "title": "Rust ownership", "description": "Borrowing and concurrency"})
+	callTool(t, h, "create_node", map[string]interface{}{"domain_name": "other", "url": "https://example.com/other", "title": "SQLite elsewhere"})
+
+	search := func(query string) []string {
+		t.Helper()
+		result := structuredContent(t, callTool(t, h, "list_nodes", map[string]interface{}{"domain_name": "docs", "search": query}))
+		var urls []string
+		for _, node := range result["nodes"].([]map[string]interface{}) {
+			urls = append(urls, node["url"].(string))
+		}
+		sort.Strings(urls)
+		return urls
+	}
+
+	// Nodes created through the tools are indexed by the triggers
+	if got := search("concurrency"); len(got) != 2 {
+		t.Errorf("search(concurrency) = %v, want both docs nodes", got)
+	}
+	if got := search("concurrency go"); len(got) != 1 || got[0] != "https://example.com/go" {
+		t.Errorf("search(concurrency go) = %v, want only the go node", got)
+	}
+
+	// A bulk load that bypasses the triggers leaves the index behind
+	_, err := db.DB().Exec(`
+		DROP TRIGGER nodes_fts_insert;
+		INSERT INTO nodes (content, domain_id, title, description) VALUES ('https://example.com/bulk', 1, 'Bulk loaded SQLite guide', '');
+	`)
+	if err != nil {
+		t.Fatalf("failed to insert node directly: %v", err)
+	}
+	if got := search("sqlite"); len(got) != 0 {
+		t.Fatalf("search(sqlite) before rebuild = %v, want no results", got)
+	}
+
+	result := structuredContent(t, callTool(t, h, "rebuild_search_index", map[string]interface{}{"domain_name": "docs"}))
+	if result["indexed_count"] != 3 || result["domain_name"] != "docs" {
+		t.Errorf("unexpected rebuild result: %v", result)
+	}
+	if got := search("sqlite"); len(got) != 1 || got[0] != "https://example.com/bulk" {
+		t.Errorf("search(sqlite) after rebuild = %v, want the bulk node", got)
+	}
+	if got := search("concurrency"); len(got) != 2 {
+		t.Errorf("search(concurrency) after rebuild = %v, want both docs nodes", got)
+	}
+
+	// Punctuation is searched literally rather than read as FTS syntax
+	if got := search("example.com/bulk"); len(got) != 1 {
+		t.Errorf("search(example.com/bulk) = %v, want the bulk node", got)
+	}
+
+	// Without a domain every node is reindexed
+	result = structuredContent(t, callTool(t, h, "rebuild_search_index", map[string]interface{}{}))
+	if result["indexed_count"] != 4 {
+		t.Errorf("indexed_count of a full rebuild = %v, want 4", result["indexed_count"])
+	}
+	if resp := callTool(t, h, "rebuild_search_index", map[string]interface{}{"domain_name": "missing"}); resp.Error == nil {
+		t.Error("expected an error rebuilding a missing domain")
+	}
+}
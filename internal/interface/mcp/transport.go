@@ -1,8 +1,15 @@
 package mcp
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"net/http"
+
+	"url-db/internal/constants"
 )
 
 // Transport represents different communication transports for MCP server
@@ -11,6 +18,8 @@ type Transport interface {
 	Start(ctx context.Context) error
 	// Stop gracefully shuts down the transport
 	Stop() error
+	// Shutdown stops accepting new requests and waits for in-flight ones until ctx is done
+	Shutdown(ctx context.Context) error
 	// SetRequestHandler sets the request handler for processing incoming requests
 	SetRequestHandler(handler RequestHandler)
 	// SetPort configures the port for network-based transports
@@ -32,10 +41,117 @@ type ResponseWriter interface {
 	GetWriter() io.Writer
 }
 
+// StreamWriter sends messages for a request ahead of its response. Only
+// transports that can deliver more than one message per request provide one.
+type StreamWriter interface {
+	// WriteMessage sends a JSON message as its own frame
+	WriteMessage(message interface{}) error
+}
+
+type streamWriterKey struct{}
+
+// withStreamWriter attaches a StreamWriter to a request context
+func withStreamWriter(ctx context.Context, w StreamWriter) context.Context {
+	return context.WithValue(ctx, streamWriterKey{}, w)
+}
+
+// streamWriterFrom returns the request's StreamWriter, if its transport supports streaming
+func streamWriterFrom(ctx context.Context) (StreamWriter, bool) {
+	w, ok := ctx.Value(streamWriterKey{}).(StreamWriter)
+	return w, ok
+}
+
 // TransportConfig holds configuration for transport initialization
 type TransportConfig struct {
-	Mode   string
-	Port   string
-	Reader io.Reader
-	Writer io.Writer
+	Mode         string
+	Port         string
+	Reader       io.Reader
+	Writer       io.Writer
+	DoneEvent    string       // SSE event sent after each response ("" = none)
+	MaxBodyBytes int64        // HTTP and SSE request body limit (0 = unlimited)
+	RateLimiter  *RateLimiter // HTTP and SSE per-IP rate limit (nil = unlimited)
+	Auth         *APIKeyAuth  // HTTP and SSE API key check (nil = open)
+	CORS         *CORSPolicy  // HTTP and SSE allowed browser origins (nil = any)
+	Health       HealthFunc   // HTTP and SSE /health check (nil = static ok)
+}
+
+// errBodyTooLarge reports a request body over the transport's limit
+var errBodyTooLarge = errors.New("request body too large")
+
+// decodeRequestBody reads a JSON-RPC message, a request or a batch of them, from
+// r's body. Reading stops at maxBytes (0 = unlimited), so an oversized body is
+// refused without being read in full; a body within the limit is held whole.
+func decodeRequestBody(w http.ResponseWriter, r *http.Request, maxBytes int64, message *json.RawMessage) error {
+	body := r.Body
+	if maxBytes > 0 {
+		body = http.MaxBytesReader(w, r.Body, maxBytes)
+	}
+
+	if err := json.NewDecoder(body).Decode(message); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return fmt.Errorf("%w: limit is %d bytes", errBodyTooLarge, maxBytesErr.Limit)
+		}
+		return err
+	}
+	return nil
+}
+
+// handleMessage runs the request in message through handler, or each request in turn
+// when message is a JSON-RPC batch array. It returns the responses to send, of which
+// notifications have none, and whether message was a batch; a batch is answered with
+// one array of its responses, or nothing when it held only notifications. A batch
+// above MaxRequestBatchSize is refused whole with a single error.
+func handleMessage(ctx context.Context, handler RequestHandler, message json.RawMessage) ([]*JSONRPCResponse, bool) {
+	trimmed := bytes.TrimSpace(message)
+	if len(trimmed) == 0 || trimmed[0] != '[' {
+		response := handleRawRequest(ctx, handler, message)
+		if response == nil {
+			return nil, false
+		}
+		return []*JSONRPCResponse{response}, false
+	}
+
+	var batch []json.RawMessage
+	if err := json.Unmarshal(trimmed, &batch); err != nil {
+		return []*JSONRPCResponse{newErrorResponse(nil, ParseError, "Parse error", err.Error())}, false
+	}
+	if len(batch) == 0 {
+		return []*JSONRPCResponse{newErrorResponse(nil, InvalidRequest, "Invalid Request", "empty batch")}, false
+	}
+	// The rate limit charges a batch once, so its size is capped
+	if len(batch) > constants.MaxRequestBatchSize {
+		return []*JSONRPCResponse{newErrorResponse(nil, InvalidRequest, "Invalid Request",
+			fmt.Sprintf("batch of %d requests exceeds the limit of %d", len(batch), constants.MaxRequestBatchSize))}, false
+	}
+
+	responses := []*JSONRPCResponse{}
+	for _, raw := range batch {
+		if response := handleRawRequest(ctx, handler, raw); response != nil {
+			responses = append(responses, response)
+		}
+	}
+	return responses, true
+}
+
+// handleRawRequest decodes one request and runs it through handler
+func handleRawRequest(ctx context.Context, handler RequestHandler, raw json.RawMessage) *JSONRPCResponse {
+	var req JSONRPCRequest
+	if err := json.Unmarshal(raw, &req); err != nil {
+		return newErrorResponse(nil, InvalidRequest, "Invalid Request", err.Error())
+	}
+	return handler(ctx, &req)
+}
+
+// newErrorResponse creates a JSON-RPC error response
+func newErrorResponse(id interface{}, code int, message string, data interface{}) *JSONRPCResponse {
+	return &JSONRPCResponse{
+		JSONRPC: constants.JSONRPCVersion,
+		ID:      id,
+		Error: &RPCError{
+			Code:    code,
+			Message: message,
+			Data:    data,
+		},
+	}
 }
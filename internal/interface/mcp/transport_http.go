@@ -3,10 +3,12 @@ package mcp
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strconv"
+	"sync"
 
 	"url-db/internal/constants"
 )
@@ -16,6 +18,13 @@ type HTTPTransport struct {
 	port           string
 	server         *http.Server
 	requestHandler RequestHandler
+	maxBodyBytes   int64        // Request body limit (0 = unlimited)
+	rateLimiter    *RateLimiter // Per-IP limit on /mcp (nil = unlimited)
+	auth           *APIKeyAuth  // API key required on /mcp (nil = open)
+	cors           *CORSPolicy  // Origins allowed to call /mcp (nil = any)
+	health         HealthFunc   // /health check (nil = static ok)
+	mu             sync.Mutex   // Guards server and shutdown
+	shutdown       bool
 }
 
 // NewHTTPTransport creates a new HTTP transport
@@ -26,7 +35,12 @@ func NewHTTPTransport(config *TransportConfig) *HTTPTransport {
 	}
 
 	return &HTTPTransport{
-		port: port,
+		port:         port,
+		maxBodyBytes: config.MaxBodyBytes,
+		rateLimiter:  config.RateLimiter,
+		auth:         config.Auth,
+		cors:         config.CORS,
+		health:       config.Health,
 	}
 }
 
@@ -39,27 +53,49 @@ func (t *HTTPTransport) Start(ctx context.Context) error {
 	mux := http.NewServeMux()
 
 	// MCP endpoint for JSON-RPC communication
-	mux.HandleFunc("/mcp", t.handleHTTPEndpoint)
+	mux.Handle("/mcp", t.rateLimiter.Wrap(t.auth.Wrap(http.HandlerFunc(t.handleHTTPEndpoint))))
 
-	// Health check endpoint
+	// Health check endpoint (not rate limited or authenticated)
 	mux.HandleFunc("/health", t.handleHealthCheck)
 
+	t.mu.Lock()
+	if t.shutdown {
+		t.mu.Unlock()
+		return nil
+	}
 	t.server = &http.Server{
 		Addr:    ":" + t.port,
 		Handler: mux,
 	}
+	server := t.server
+	t.mu.Unlock()
 
 	fmt.Printf("Starting MCP HTTP server on port %s\n", t.port)
 	fmt.Printf("MCP endpoint: http://localhost:%s/mcp\n", t.port)
 	fmt.Printf("Health check: http://localhost:%s/health\n", t.port)
 
-	return t.server.ListenAndServe()
+	// Shutdown makes ListenAndServe return ErrServerClosed; that is a clean stop
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
 }
 
 // Stop gracefully shuts down the transport
 func (t *HTTPTransport) Stop() error {
-	if t.server != nil {
-		return t.server.Shutdown(context.Background())
+	return t.Shutdown(context.Background())
+}
+
+// Shutdown stops accepting connections and waits for in-flight requests to
+// finish or for ctx to expire
+func (t *HTTPTransport) Shutdown(ctx context.Context) error {
+	t.mu.Lock()
+	t.shutdown = true
+	server := t.server
+	t.mu.Unlock()
+
+	if server != nil {
+		return server.Shutdown(ctx)
 	}
 	return nil
 }
@@ -74,6 +110,27 @@ func (t *HTTPTransport) SetPort(port string) {
 	t.port = port
 }
 
+// SetMaxBodyBytes limits the size of request bodies (0 = unlimited)
+func (t *HTTPTransport) SetMaxBodyBytes(maxBytes int64) {
+	t.maxBodyBytes = maxBytes
+}
+
+// SetRateLimiter limits /mcp requests per client IP (nil = unlimited); it applies
+// from the next Start
+func (t *HTTPTransport) SetRateLimiter(limiter *RateLimiter) {
+	t.rateLimiter = limiter
+}
+
+// SetAuth requires an API key on /mcp (nil = open); it applies from the next Start
+func (t *HTTPTransport) SetAuth(auth *APIKeyAuth) {
+	t.auth = auth
+}
+
+// SetCORSPolicy limits the browser origins allowed to call /mcp (nil = any)
+func (t *HTTPTransport) SetCORSPolicy(policy *CORSPolicy) {
+	t.cors = policy
+}
+
 // GetName returns the transport name
 func (t *HTTPTransport) GetName() string {
 	return constants.MCPModeHTTP
@@ -83,7 +140,10 @@ func (t *HTTPTransport) GetName() string {
 func (t *HTTPTransport) handleHTTPEndpoint(w http.ResponseWriter, r *http.Request) {
 	// Handle preflight requests
 	if r.Method == "OPTIONS" {
-		t.setCORSHeaders(w)
+		if !t.setCORSHeaders(w, r) {
+			http.Error(w, "Origin not allowed", http.StatusForbidden)
+			return
+		}
 		w.WriteHeader(http.StatusOK)
 		return
 	}
@@ -95,44 +155,48 @@ func (t *HTTPTransport) handleHTTPEndpoint(w http.ResponseWriter, r *http.Reques
 	}
 
 	// Set response headers
-	t.setCORSHeaders(w)
+	t.setCORSHeaders(w, r)
 	w.Header().Set("Content-Type", "application/json")
 
-	// Read and parse the JSON-RPC request
-	var req JSONRPCRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	// Read and parse the JSON-RPC request or batch
+	var message json.RawMessage
+	if err := decodeRequestBody(w, r, t.maxBodyBytes, &message); err != nil {
 		responseWriter := NewHTTPResponseWriter(w)
+		if errors.Is(err, errBodyTooLarge) {
+			// WriteHeader freezes the headers, so WriteError's content type would come too late
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			responseWriter.WriteError(nil, InvalidRequest, "Request body too large", err.Error())
+			return
+		}
 		responseWriter.WriteError(nil, ParseError, "Parse error", err.Error())
 		return
 	}
 
-	// Create response writer and handle the request
+	// Create response writer and handle the request or batch
 	responseWriter := NewHTTPResponseWriter(w)
-	response := t.requestHandler(r.Context(), &req)
+	responses, batch := handleMessage(r.Context(), t.requestHandler, message)
 
-	if response != nil {
-		if err := responseWriter.WriteResponse(response); err != nil {
-			http.Error(w, "Failed to write response", http.StatusInternalServerError)
-		}
+	var err error
+	if batch && len(responses) > 0 {
+		err = responseWriter.WriteBatch(responses)
+	} else if !batch && len(responses) == 1 {
+		err = responseWriter.WriteResponse(responses[0])
+	}
+	if err != nil {
+		http.Error(w, "Failed to write response", http.StatusInternalServerError)
 	}
 }
 
-// handleHealthCheck handles health check requests
+// handleHealthCheck reports whether the server and its database are healthy
 func (t *HTTPTransport) handleHealthCheck(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"status": "ok",
-		"mode":   "http",
-		"server": constants.MCPServerName,
-	})
+	writeHealth(w, r, t.health, "http")
 }
 
-// setCORSHeaders sets Cross-Origin Resource Sharing headers
-func (t *HTTPTransport) setCORSHeaders(w http.ResponseWriter) {
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+// setCORSHeaders sets Cross-Origin Resource Sharing headers and reports whether
+// the request's origin is allowed
+func (t *HTTPTransport) setCORSHeaders(w http.ResponseWriter, r *http.Request) bool {
+	return t.cors.SetHeaders(w, r, "Content-Type, Authorization")
 }
 
 // HTTPResponseWriter implements ResponseWriter for HTTP
@@ -153,6 +217,12 @@ func (w *HTTPResponseWriter) WriteResponse(response *JSONRPCResponse) error {
 	return json.NewEncoder(w.responseWriter).Encode(response)
 }
 
+// WriteBatch writes the responses to a JSON-RPC batch as one array
+func (w *HTTPResponseWriter) WriteBatch(responses []*JSONRPCResponse) error {
+	w.responseWriter.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w.responseWriter).Encode(responses)
+}
+
 // WriteError writes an error response to HTTP response
 func (w *HTTPResponseWriter) WriteError(id interface{}, code int, message string, data interface{}) error {
 	response := &JSONRPCResponse{
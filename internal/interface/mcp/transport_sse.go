@@ -3,10 +3,13 @@ package mcp
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"os"
 	"strconv"
+	"sync"
 
 	"url-db/internal/constants"
 )
@@ -16,6 +19,14 @@ type SSETransport struct {
 	port           string
 	server         *http.Server
 	requestHandler RequestHandler
+	doneEvent      string       // Event sent after each response ("" = none)
+	maxBodyBytes   int64        // Request body limit (0 = unlimited)
+	rateLimiter    *RateLimiter // Per-IP limit on /mcp (nil = unlimited)
+	auth           *APIKeyAuth  // API key required on /mcp (nil = open)
+	cors           *CORSPolicy  // Origins allowed to call /mcp (nil = any)
+	health         HealthFunc   // /health check (nil = static ok)
+	mu             sync.Mutex   // Guards server and shutdown
+	shutdown       bool
 }
 
 // NewSSETransport creates a new SSE transport
@@ -26,7 +37,13 @@ func NewSSETransport(config *TransportConfig) *SSETransport {
 	}
 
 	return &SSETransport{
-		port: port,
+		port:         port,
+		doneEvent:    config.DoneEvent,
+		maxBodyBytes: config.MaxBodyBytes,
+		rateLimiter:  config.RateLimiter,
+		auth:         config.Auth,
+		cors:         config.CORS,
+		health:       config.Health,
 	}
 }
 
@@ -39,27 +56,49 @@ func (t *SSETransport) Start(ctx context.Context) error {
 	mux := http.NewServeMux()
 
 	// SSE endpoint for MCP communication
-	mux.HandleFunc("/mcp", t.handleSSEEndpoint)
+	mux.Handle("/mcp", t.rateLimiter.Wrap(t.auth.Wrap(http.HandlerFunc(t.handleSSEEndpoint))))
 
-	// Health check endpoint
+	// Health check endpoint (not rate limited or authenticated)
 	mux.HandleFunc("/health", t.handleHealthCheck)
 
+	t.mu.Lock()
+	if t.shutdown {
+		t.mu.Unlock()
+		return nil
+	}
 	t.server = &http.Server{
 		Addr:    ":" + t.port,
 		Handler: mux,
 	}
+	server := t.server
+	t.mu.Unlock()
 
 	fmt.Printf("Starting MCP SSE server on port %s\n", t.port)
 	fmt.Printf("SSE endpoint: http://localhost:%s/mcp\n", t.port)
 	fmt.Printf("Health check: http://localhost:%s/health\n", t.port)
 
-	return t.server.ListenAndServe()
+	// Shutdown makes ListenAndServe return ErrServerClosed; that is a clean stop
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
 }
 
 // Stop gracefully shuts down the transport
 func (t *SSETransport) Stop() error {
-	if t.server != nil {
-		return t.server.Shutdown(context.Background())
+	return t.Shutdown(context.Background())
+}
+
+// Shutdown stops accepting connections and waits for in-flight requests to
+// finish or for ctx to expire
+func (t *SSETransport) Shutdown(ctx context.Context) error {
+	t.mu.Lock()
+	t.shutdown = true
+	server := t.server
+	t.mu.Unlock()
+
+	if server != nil {
+		return server.Shutdown(ctx)
 	}
 	return nil
 }
@@ -74,6 +113,32 @@ func (t *SSETransport) SetPort(port string) {
 	t.port = port
 }
 
+// SetDoneEvent sets the event sent after each response ("" = none)
+func (t *SSETransport) SetDoneEvent(name string) {
+	t.doneEvent = name
+}
+
+// SetMaxBodyBytes limits the size of request bodies (0 = unlimited)
+func (t *SSETransport) SetMaxBodyBytes(maxBytes int64) {
+	t.maxBodyBytes = maxBytes
+}
+
+// SetRateLimiter limits /mcp requests per client IP (nil = unlimited); it applies
+// from the next Start
+func (t *SSETransport) SetRateLimiter(limiter *RateLimiter) {
+	t.rateLimiter = limiter
+}
+
+// SetAuth requires an API key on /mcp (nil = open); it applies from the next Start
+func (t *SSETransport) SetAuth(auth *APIKeyAuth) {
+	t.auth = auth
+}
+
+// SetCORSPolicy limits the browser origins allowed to call /mcp (nil = any)
+func (t *SSETransport) SetCORSPolicy(policy *CORSPolicy) {
+	t.cors = policy
+}
+
 // GetName returns the transport name
 func (t *SSETransport) GetName() string {
 	return constants.MCPModeSSE
@@ -83,7 +148,10 @@ func (t *SSETransport) GetName() string {
 func (t *SSETransport) handleSSEEndpoint(w http.ResponseWriter, r *http.Request) {
 	// Handle preflight requests
 	if r.Method == "OPTIONS" {
-		t.setSSEHeaders(w)
+		if !t.setSSEHeaders(w, r) {
+			http.Error(w, "Origin not allowed", http.StatusForbidden)
+			return
+		}
 		w.WriteHeader(http.StatusOK)
 		return
 	}
@@ -95,44 +163,77 @@ func (t *SSETransport) handleSSEEndpoint(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Set SSE headers
-	t.setSSEHeaders(w)
-
-	// Read the initial JSON-RPC request
-	var req JSONRPCRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	t.setSSEHeaders(w, r)
+
+	// Read the initial JSON-RPC request or batch
+	var message json.RawMessage
+	if err := decodeRequestBody(w, r, t.maxBodyBytes, &message); err != nil {
+		if errors.Is(err, errBodyTooLarge) {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
 		http.Error(w, "Invalid JSON", http.StatusBadRequest)
 		return
 	}
 
-	// Create SSE response writer and handle the request
+	// Create SSE response writer and handle the request or batch; tools may
+	// stream intermediate messages through it before the response
 	responseWriter := NewSSEResponseWriter(w)
-	response := t.requestHandler(r.Context(), &req)
+	responses, batch := handleMessage(withStreamWriter(r.Context(), responseWriter), t.requestHandler, message)
+
+	// A batch is answered with one frame holding the array of its responses
+	var err error
+	if batch && len(responses) > 0 {
+		err = responseWriter.WriteMessage(responses)
+	} else if !batch && len(responses) == 1 {
+		err = responseWriter.WriteResponse(responses[0])
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to send SSE response: %v\n", err)
+		return
+	}
 
-	if response != nil {
-		if err := responseWriter.WriteResponse(response); err != nil {
-			fmt.Printf("Failed to send SSE response: %v\n", err)
+	// Tell the client nothing more is coming for this request or batch
+	if t.doneEvent != "" {
+		if err := responseWriter.WriteEvent(t.doneEvent, doneEventPayload(message, batch)); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to send SSE %s event: %v\n", t.doneEvent, err)
 		}
 	}
 }
 
-// handleHealthCheck handles health check requests
+// doneEventPayload identifies what the done event ends: {"id": ...} for a request,
+// {"ids": [...]} with the id of each request in a batch
+func doneEventPayload(message json.RawMessage, batch bool) map[string]interface{} {
+	if !batch {
+		var req JSONRPCRequest
+		json.Unmarshal(message, &req)
+		return map[string]interface{}{"id": req.ID}
+	}
+
+	var batchMessages []json.RawMessage
+	json.Unmarshal(message, &batchMessages)
+	ids := []interface{}{}
+	for _, raw := range batchMessages {
+		var req JSONRPCRequest
+		if json.Unmarshal(raw, &req) == nil && req.ID != nil {
+			ids = append(ids, req.ID)
+		}
+	}
+	return map[string]interface{}{"ids": ids}
+}
+
+// handleHealthCheck reports whether the server and its database are healthy
 func (t *SSETransport) handleHealthCheck(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"status": "ok",
-		"mode":   "sse",
-		"server": constants.MCPServerName,
-	})
+	writeHealth(w, r, t.health, "sse")
 }
 
-// setSSEHeaders sets Server-Sent Events headers
-func (t *SSETransport) setSSEHeaders(w http.ResponseWriter) {
+// setSSEHeaders sets Server-Sent Events and CORS headers and reports whether the
+// request's origin is allowed
+func (t *SSETransport) setSSEHeaders(w http.ResponseWriter, r *http.Request) bool {
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Headers", "Cache-Control")
+	return t.cors.SetHeaders(w, r, "Cache-Control, Authorization")
 }
 
 // SSEResponseWriter implements ResponseWriter for Server-Sent Events
@@ -149,7 +250,12 @@ func NewSSEResponseWriter(w http.ResponseWriter) *SSEResponseWriter {
 
 // WriteResponse writes a JSON-RPC response via SSE
 func (w *SSEResponseWriter) WriteResponse(response *JSONRPCResponse) error {
-	data, err := json.Marshal(response)
+	return w.WriteMessage(response)
+}
+
+// WriteMessage writes any JSON message as an SSE data frame
+func (w *SSEResponseWriter) WriteMessage(message interface{}) error {
+	data, err := json.Marshal(message)
 	if err != nil {
 		return err
 	}
@@ -162,6 +268,20 @@ func (w *SSEResponseWriter) WriteResponse(response *JSONRPCResponse) error {
 	return nil
 }
 
+// WriteEvent writes a named SSE event with a JSON payload
+func (w *SSEResponseWriter) WriteEvent(event string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w.responseWriter, "event: %s\ndata: %s\n\n", event, data)
+	if f, ok := w.responseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
+
 // WriteError writes an error response via SSE
 func (w *SSEResponseWriter) WriteError(id interface{}, code int, message string, data interface{}) error {
 	response := &JSONRPCResponse{
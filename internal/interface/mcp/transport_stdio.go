@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"sync"
 
 	"url-db/internal/constants"
 )
@@ -13,8 +14,11 @@ import (
 // StdioTransport implements Transport for stdin/stdout communication
 type StdioTransport struct {
 	reader         io.Reader
-	writer         ResponseWriter
+	writer         *StdioResponseWriter
 	requestHandler RequestHandler
+	mu             sync.Mutex // Guards shutdown and inFlight registration
+	shutdown       bool
+	inFlight       sync.WaitGroup
 }
 
 // NewStdioTransport creates a new stdio transport
@@ -48,8 +52,8 @@ func (t *StdioTransport) Start(ctx context.Context) error {
 		case <-ctx.Done():
 			return ctx.Err()
 		default:
-			var req JSONRPCRequest
-			if err := decoder.Decode(&req); err != nil {
+			var message json.RawMessage
+			if err := decoder.Decode(&message); err != nil {
 				if err == io.EOF {
 					return nil
 				}
@@ -57,20 +61,53 @@ func (t *StdioTransport) Start(ctx context.Context) error {
 				continue
 			}
 
-			response := t.requestHandler(ctx, &req)
-			if response != nil {
-				if err := t.writer.WriteResponse(response); err != nil {
-					fmt.Fprintf(os.Stderr, "Failed to send response: %v\n", err)
-				}
+			t.mu.Lock()
+			if t.shutdown {
+				t.mu.Unlock()
+				return nil
+			}
+			t.inFlight.Add(1)
+			t.mu.Unlock()
+
+			responses, batch := handleMessage(ctx, t.requestHandler, message)
+			var err error
+			if batch && len(responses) > 0 {
+				err = t.writer.WriteBatch(responses)
+			} else if !batch && len(responses) == 1 {
+				err = t.writer.WriteResponse(responses[0])
 			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to send response: %v\n", err)
+			}
+			t.inFlight.Done()
 		}
 	}
 }
 
 // Stop gracefully shuts down the transport
 func (t *StdioTransport) Stop() error {
-	// No cleanup needed for stdio
-	return nil
+	return t.Shutdown(context.Background())
+}
+
+// Shutdown stops handling new requests and waits for the one in progress, if
+// any. A read already blocked on stdin is not interrupted.
+func (t *StdioTransport) Shutdown(ctx context.Context) error {
+	t.mu.Lock()
+	t.shutdown = true
+	t.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		t.inFlight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 // SetRequestHandler sets the request handler
@@ -106,6 +143,11 @@ func (w *StdioResponseWriter) WriteResponse(response *JSONRPCResponse) error {
 	return encoder.Encode(response)
 }
 
+// WriteBatch writes the responses to a JSON-RPC batch as one array
+func (w *StdioResponseWriter) WriteBatch(responses []*JSONRPCResponse) error {
+	return json.NewEncoder(w.writer).Encode(responses)
+}
+
 // WriteError writes an error response to stdout
 func (w *StdioResponseWriter) WriteError(id interface{}, code int, message string, data interface{}) error {
 	response := &JSONRPCResponse{
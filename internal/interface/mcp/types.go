@@ -28,6 +28,20 @@ type LogNotification struct {
 	Params         LogMessage `json:"params"`
 }
 
+// ScanItem is one node streamed by scan_all_content
+type ScanItem struct {
+	DomainName string      `json:"domain_name"`
+	Index      int         `json:"index"` // Position within the scanned page (0-based)
+	Item       interface{} `json:"item"`
+}
+
+// ScanItemNotification streams a scan_all_content item to the client ahead of the response
+type ScanItemNotification struct {
+	JSONRPCVersion string   `json:"jsonrpc"`
+	Method         string   `json:"method"`
+	Params         ScanItem `json:"params"`
+}
+
 // JSONRPCRequest represents a JSON-RPC 2.0 request
 type JSONRPCRequest struct {
 	JSONRPC string          `json:"jsonrpc"`
@@ -59,3 +73,13 @@ const (
 	InvalidParams  = -32602
 	InternalError  = -32603
 )
+
+// Server-defined error codes (JSON-RPC reserves -32000 to -32099)
+const (
+	RateLimited      = -32000 // The client exceeded the HTTP rate limit
+	ToolTimeout      = -32001 // A tool call exceeded its time limit
+	ResourceNotFound = -32002 // resources/read named a domain or node that does not exist
+	Unauthorized     = -32003 // An HTTP or SSE request lacked the configured API key
+	ReadOnlyMode     = -32004 // A mutating tool was called while the server is read-only
+	VersionConflict  = -32005 // update_node's expected_version no longer matched the node
+)
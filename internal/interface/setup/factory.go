@@ -6,12 +6,14 @@ import (
 
 	"github.com/jmoiron/sqlx"
 	"url-db/internal/application/usecase/attribute"
+	"url-db/internal/application/usecase/dependency"
 	"url-db/internal/application/usecase/domain"
 	"url-db/internal/application/usecase/node"
 	domainAttribute "url-db/internal/domain/attribute"
 	"url-db/internal/domain/entity"
 	"url-db/internal/domain/repository"
 	"url-db/internal/domain/service"
+	"url-db/internal/infrastructure/events"
 	sqliteRepo "url-db/internal/infrastructure/persistence/sqlite/repository"
 )
 
@@ -23,6 +25,15 @@ type RepositoryFactory interface {
 	CreateNodeAttributeRepository() repository.NodeAttributeRepository
 	CreateTemplateRepository() repository.TemplateRepository
 	CreateTemplateAttributeRepository() repository.TemplateAttributeRepository
+	CreateDependencyRepository() repository.DependencyRepository
+	CreateNodeConnectionRepository() repository.NodeConnectionRepository
+	CreateNodeEventRepository() repository.NodeEventRepository
+	CreateDomainImportRepository() repository.DomainImportRepository
+	CreateNodeMoveRepository() repository.NodeMoveRepository
+	CreateDomainMergeRepository() repository.DomainMergeRepository
+	CreateNodeSubscriptionRepository() repository.NodeSubscriptionRepository
+	CreateStorageRepository() repository.StorageRepository
+	CreateSearchIndexRepository() repository.SearchIndexRepository
 }
 
 // UseCaseFactory creates use case instances
@@ -48,6 +59,11 @@ func NewApplicationFactory(db *sql.DB, sqlxDB *sqlx.DB, toolName string) *Applic
 	}
 }
 
+// ToolName returns the tool name used when building composite keys
+func (f *ApplicationFactory) ToolName() string {
+	return f.toolName
+}
+
 // Repository Factory Implementation
 func (f *ApplicationFactory) CreateDomainRepository() repository.DomainRepository {
 	return sqliteRepo.NewDomainRepository(f.db)
@@ -73,6 +89,42 @@ func (f *ApplicationFactory) CreateTemplateAttributeRepository() repository.Temp
 	return sqliteRepo.NewSQLiteTemplateAttributeRepository(f.db)
 }
 
+func (f *ApplicationFactory) CreateDependencyRepository() repository.DependencyRepository {
+	return sqliteRepo.NewDependencyRepository(f.db)
+}
+
+func (f *ApplicationFactory) CreateNodeConnectionRepository() repository.NodeConnectionRepository {
+	return sqliteRepo.NewNodeConnectionRepository(f.db)
+}
+
+func (f *ApplicationFactory) CreateNodeEventRepository() repository.NodeEventRepository {
+	return sqliteRepo.NewNodeEventRepository(f.db)
+}
+
+func (f *ApplicationFactory) CreateDomainImportRepository() repository.DomainImportRepository {
+	return sqliteRepo.NewDomainImportRepository(f.db)
+}
+
+func (f *ApplicationFactory) CreateNodeMoveRepository() repository.NodeMoveRepository {
+	return sqliteRepo.NewNodeMoveRepository(f.db)
+}
+
+func (f *ApplicationFactory) CreateDomainMergeRepository() repository.DomainMergeRepository {
+	return sqliteRepo.NewDomainMergeRepository(f.db)
+}
+
+func (f *ApplicationFactory) CreateNodeSubscriptionRepository() repository.NodeSubscriptionRepository {
+	return sqliteRepo.NewNodeSubscriptionRepository(f.db)
+}
+
+func (f *ApplicationFactory) CreateStorageRepository() repository.StorageRepository {
+	return sqliteRepo.NewStorageRepository(f.db)
+}
+
+func (f *ApplicationFactory) CreateSearchIndexRepository() repository.SearchIndexRepository {
+	return sqliteRepo.NewSearchIndexRepository(f.db)
+}
+
 // Use Case Factory Implementation
 func (f *ApplicationFactory) CreateDomainUseCases(domainRepo repository.DomainRepository) (*domain.CreateDomainUseCase, *domain.ListDomainsUseCase) {
 	createUC := domain.NewCreateDomainUseCase(domainRepo)
@@ -101,6 +153,15 @@ func (f *ApplicationFactory) CreateCleanArchitectureDependencies() *CleanDepende
 	nodeAttributeRepo := f.CreateNodeAttributeRepository()
 	templateRepo := f.CreateTemplateRepository()
 	templateAttributeRepo := f.CreateTemplateAttributeRepository()
+	dependencyRepo := f.CreateDependencyRepository()
+	nodeConnectionRepo := f.CreateNodeConnectionRepository()
+	nodeEventRepo := f.CreateNodeEventRepository()
+	domainImportRepo := f.CreateDomainImportRepository()
+	nodeMoveRepo := f.CreateNodeMoveRepository()
+	domainMergeRepo := f.CreateDomainMergeRepository()
+	nodeSubscriptionRepo := f.CreateNodeSubscriptionRepository()
+	storageRepo := f.CreateStorageRepository()
+	searchIndexRepo := f.CreateSearchIndexRepository()
 
 	// Create validation registry
 	validatorRegistry := domainAttribute.NewValidatorRegistry()
@@ -116,8 +177,13 @@ func (f *ApplicationFactory) CreateCleanArchitectureDependencies() *CleanDepende
 	createNodeUC, listNodesUC := f.CreateNodeUseCases(nodeRepo, domainRepo)
 	createAttributeUC, listAttributesUC := f.CreateAttributeUseCases(attributeRepo, domainRepo)
 	setNodeAttributesUC := node.NewSetNodeAttributesUseCase(nodeRepo, attributeRepo, nodeAttributeRepo, templateService)
-	filterNodesUC := node.NewFilterNodesByAttributesUseCase(nodeRepo)
+	renameAttributeValueUC := node.NewRenameAttributeValueUseCase(domainRepo, attributeRepo, nodeAttributeRepo, templateService)
+	filterNodesUC := node.NewFilterNodesByAttributesUseCase(nodeRepo, domainRepo, attributeRepo)
 	getNodeWithAttributesUC := node.NewGetNodeWithAttributesUseCase(nodeRepo, nodeAttributeRepo, attributeRepo)
+	sweepExpiredNodesUC := node.NewSweepExpiredNodesUseCase(nodeRepo)
+	createDependencyUC := dependency.NewCreateDependencyUseCase(dependencyRepo, nodeRepo)
+	deleteDomainUC := domain.NewDeleteDomainUseCase(domainRepo, nodeRepo)
+	updateDomainUC := domain.NewUpdateDomainUseCase(domainRepo)
 
 	return &CleanDependencies{
 		// Repositories
@@ -127,9 +193,19 @@ func (f *ApplicationFactory) CreateCleanArchitectureDependencies() *CleanDepende
 		NodeAttributeRepo:     nodeAttributeRepo,
 		TemplateRepo:          templateRepo,
 		TemplateAttributeRepo: templateAttributeRepo,
+		DependencyRepo:        dependencyRepo,
+		NodeConnectionRepo:    nodeConnectionRepo,
+		NodeEventRepo:         nodeEventRepo,
+		DomainImportRepo:      domainImportRepo,
+		NodeMoveRepo:          nodeMoveRepo,
+		DomainMergeRepo:       domainMergeRepo,
+		NodeSubscriptionRepo:  nodeSubscriptionRepo,
+		StorageRepo:           storageRepo,
+		SearchIndexRepo:       searchIndexRepo,
 
 		// Services
 		TemplateService: templateService,
+		EventRecorder:   events.NewDirectRecorder(nodeEventRepo),
 
 		// Validators
 		ValidatorRegistry: validatorRegistry,
@@ -137,13 +213,18 @@ func (f *ApplicationFactory) CreateCleanArchitectureDependencies() *CleanDepende
 		// Use Cases
 		CreateDomainUC:          createDomainUC,
 		ListDomainsUC:           listDomainsUC,
+		UpdateDomainUC:          updateDomainUC,
+		DeleteDomainUC:          deleteDomainUC,
 		CreateNodeUC:            createNodeUC,
 		ListNodesUC:             listNodesUC,
 		CreateAttributeUC:       createAttributeUC,
 		ListAttributesUC:        listAttributesUC,
 		SetNodeAttributesUC:     setNodeAttributesUC,
+		RenameAttributeValueUC:  renameAttributeValueUC,
 		FilterNodesUC:           filterNodesUC,
 		GetNodeWithAttributesUC: getNodeWithAttributesUC,
+		SweepExpiredNodesUC:     sweepExpiredNodesUC,
+		CreateDependencyUC:      createDependencyUC,
 	}
 }
 
@@ -156,9 +237,19 @@ type CleanDependencies struct {
 	NodeAttributeRepo     repository.NodeAttributeRepository
 	TemplateRepo          repository.TemplateRepository
 	TemplateAttributeRepo repository.TemplateAttributeRepository
+	DependencyRepo        repository.DependencyRepository
+	NodeConnectionRepo    repository.NodeConnectionRepository
+	NodeEventRepo         repository.NodeEventRepository
+	DomainImportRepo      repository.DomainImportRepository
+	NodeMoveRepo          repository.NodeMoveRepository
+	DomainMergeRepo       repository.DomainMergeRepository
+	NodeSubscriptionRepo  repository.NodeSubscriptionRepository
+	StorageRepo           repository.StorageRepository
+	SearchIndexRepo       repository.SearchIndexRepository
 
 	// Services
 	TemplateService service.TemplateService
+	EventRecorder   events.Recorder
 
 	// Validators
 	ValidatorRegistry *domainAttribute.ValidatorRegistry
@@ -166,13 +257,18 @@ type CleanDependencies struct {
 	// Use Cases
 	CreateDomainUC          *domain.CreateDomainUseCase
 	ListDomainsUC           *domain.ListDomainsUseCase
+	UpdateDomainUC          *domain.UpdateDomainUseCase
+	DeleteDomainUC          *domain.DeleteDomainUseCase
 	CreateNodeUC            *node.CreateNodeUseCase
 	ListNodesUC             *node.ListNodesUseCase
 	CreateAttributeUC       *attribute.CreateAttributeUseCase
 	ListAttributesUC        *attribute.ListAttributesUseCase
 	SetNodeAttributesUC     *node.SetNodeAttributesUseCase
+	RenameAttributeValueUC  *node.RenameAttributeValueUseCase
 	FilterNodesUC           *node.FilterNodesByAttributesUseCase
 	GetNodeWithAttributesUC *node.GetNodeWithAttributesUseCase
+	SweepExpiredNodesUC     *node.SweepExpiredNodesUseCase
+	CreateDependencyUC      *dependency.CreateDependencyUseCase
 }
 
 // Individual UseCase factory methods for MCP server
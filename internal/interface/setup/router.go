@@ -3,11 +3,14 @@ package setup
 import (
 	"net/http"
 
+	"url-db/internal/interface/http/handler"
+
 	"github.com/gin-gonic/gin"
 )
 
-// SetupCleanRouter creates a Gin router for the Clean Architecture implementation
-func SetupCleanRouter(factory *ApplicationFactory) *gin.Engine {
+// SetupCleanRouter creates a Gin router for the Clean Architecture implementation.
+// maxPageSize caps the page size of the listings (MAX_PAGE_SIZE; 0 = default).
+func SetupCleanRouter(factory *ApplicationFactory, maxPageSize int) *gin.Engine {
 	router := gin.Default()
 
 	// Add basic health check
@@ -19,6 +22,15 @@ func SetupCleanRouter(factory *ApplicationFactory) *gin.Engine {
 		})
 	})
 
+	// Listing endpoints are served by the handlers, which answer If-None-Match with 304
+	listDomainsUC := factory.CreateListDomainsUseCase()
+	listDomainsUC.SetMaxPageSize(maxPageSize)
+	listNodesUC := factory.CreateListNodesUseCase()
+	listNodesUC.SetMaxPageSize(maxPageSize)
+	domainHandler := handler.NewDomainHandler(factory.CreateCreateDomainUseCase(), listDomainsUC)
+	nodeHandler := handler.NewNodeHandler(factory.CreateCreateNodeUseCase(), listNodesUC)
+	attributeHandler := handler.NewAttributeHandler(factory.CreateCreateAttributeUseCase(), factory.CreateListAttributesUseCase())
+
 	// Create API group
 	api := router.Group("/api")
 
@@ -30,11 +42,8 @@ func SetupCleanRouter(factory *ApplicationFactory) *gin.Engine {
 				"message": "Domain creation endpoint - Clean Architecture implementation pending",
 			})
 		})
-		domainGroup.GET("", func(c *gin.Context) {
-			c.JSON(http.StatusNotImplemented, gin.H{
-				"message": "Domain listing endpoint - Clean Architecture implementation pending",
-			})
-		})
+		domainGroup.GET("", gin.WrapF(domainHandler.ListDomains))
+		domainGroup.GET("/:domain_id/attributes", attributeHandler.ListAttributes)
 	}
 
 	// Node routes
@@ -45,11 +54,7 @@ func SetupCleanRouter(factory *ApplicationFactory) *gin.Engine {
 				"message": "Node creation endpoint - Clean Architecture implementation pending",
 			})
 		})
-		nodeGroup.GET("", func(c *gin.Context) {
-			c.JSON(http.StatusNotImplemented, gin.H{
-				"message": "Node listing endpoint - Clean Architecture implementation pending",
-			})
-		})
+		nodeGroup.GET("", gin.WrapF(nodeHandler.ListNodes))
 	}
 
 	// Attribute routes
@@ -62,7 +67,7 @@ func SetupCleanRouter(factory *ApplicationFactory) *gin.Engine {
 		})
 		attributeGroup.GET("", func(c *gin.Context) {
 			c.JSON(http.StatusNotImplemented, gin.H{
-				"message": "Attribute listing endpoint - Clean Architecture implementation pending",
+				"message": "Attribute listing is served per domain at /api/domains/:domain_id/attributes",
 			})
 		})
 	}
@@ -0,0 +1,105 @@
+package setup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"url-db/internal/application/dto/request"
+	"url-db/internal/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCleanRouterConditionalListing(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, err := database.New(database.TestConfig())
+	if err != nil {
+		t.Fatalf("failed to create test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	factory := NewApplicationFactory(db.DB(), db.SQLXDB(), "test-tool")
+	router := SetupCleanRouter(factory, 0)
+	createDomain := func(name string) {
+		t.Helper()
+		if _, err := factory.CreateCreateDomainUseCase().Execute(context.Background(), &request.CreateDomainRequest{Name: name}); err != nil {
+			t.Fatalf("failed to create domain %s: %v", name, err)
+		}
+	}
+	get := func(path, ifNoneMatch string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		if ifNoneMatch != "" {
+			r.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		return w
+	}
+
+	createDomain("docs")
+
+	for _, path := range []string{"/api/domains", "/api/nodes?domain=docs", "/api/domains/1/attributes"} {
+		first := get(path, "")
+		etag := first.Header().Get("ETag")
+		if first.Code != http.StatusOK || etag == "" {
+			t.Fatalf("GET %s: got %d with ETag %q, want 200 with an ETag", path, first.Code, etag)
+		}
+		if w := get(path, etag); w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+			t.Errorf("GET %s with If-None-Match: got %d with %d byte body, want 304 and no body", path, w.Code, w.Body.Len())
+		}
+	}
+
+	// A new domain changes the listing, so the old ETag no longer matches
+	etag := get("/api/domains", "").Header().Get("ETag")
+	createDomain("other")
+	if w := get("/api/domains", etag); w.Code != http.StatusOK || w.Header().Get("ETag") == etag {
+		t.Errorf("changed listing: got %d with ETag %s", w.Code, w.Header().Get("ETag"))
+	}
+}
+
+func TestCleanRouterMaxPageSize(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db, err := database.New(database.TestConfig())
+	if err != nil {
+		t.Fatalf("failed to create test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	factory := NewApplicationFactory(db.DB(), db.SQLXDB(), "test-tool")
+	for i := 1; i <= 3; i++ {
+		if _, err := factory.CreateCreateDomainUseCase().Execute(context.Background(), &request.CreateDomainRequest{Name: fmt.Sprintf("d%d", i)}); err != nil {
+			t.Fatalf("failed to create domain: %v", err)
+		}
+	}
+
+	listed := func(maxPageSize int, path string) (size, items int) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		SetupCleanRouter(factory, maxPageSize).ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		var body struct {
+			Size    int               `json:"size"`
+			Domains []json.RawMessage `json:"domains"`
+		}
+		if w.Code != http.StatusOK || json.Unmarshal(w.Body.Bytes(), &body) != nil {
+			t.Fatalf("GET %s: got %d %q", path, w.Code, w.Body.String())
+		}
+		return body.Size, len(body.Domains)
+	}
+
+	// A size above the configured cap is cut to it, whether the cap is below or above the default
+	if size, items := listed(2, "/api/domains?size=500"); size != 2 || items != 2 {
+		t.Errorf("domains with cap 2: got size %d and %d items", size, items)
+	}
+	if size, _ := listed(150, "/api/domains?size=120"); size != 120 {
+		t.Errorf("domains with cap 150: got size %d, want 120", size)
+	}
+	if size, _ := listed(2, "/api/nodes?domain=d1&size=500"); size != 2 {
+		t.Errorf("nodes with cap 2: got size %d", size)
+	}
+}
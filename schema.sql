@@ -20,6 +20,9 @@ CREATE TABLE IF NOT EXISTS nodes (
 	description TEXT,
 	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	expires_at DATETIME,                  -- 만료 시각 (UTC), NULL이면 만료되지 않음
+	archived_at DATETIME,                 -- 보관 시각 (UTC), NULL이면 보관되지 않음
+	version INTEGER NOT NULL DEFAULT 1,   -- 낙관적 동시성 버전, 노드가 바뀔 때마다 1씩 증가
 	FOREIGN KEY (domain_id) REFERENCES domains(id) ON DELETE CASCADE,
 	UNIQUE(content, domain_id)
 );
@@ -89,7 +92,7 @@ CREATE TABLE IF NOT EXISTS template_attributes (
 	FOREIGN KEY (attribute_id) REFERENCES attributes(id) ON DELETE CASCADE
 );
 
--- 노드 구독 테이블 (외부 서비스 알림)
+-- 노드 구독 테이블 (외부 서비스 알림, 외래 키 없음: 노드 삭제 후에도 삭제 이벤트를 전달)
 CREATE TABLE IF NOT EXISTS node_subscriptions (
 	id INTEGER PRIMARY KEY AUTOINCREMENT,
 	subscriber_service TEXT NOT NULL,     -- 구독자 서비스 식별자
@@ -99,8 +102,7 @@ CREATE TABLE IF NOT EXISTS node_subscriptions (
 	filter_conditions TEXT,               -- JSON: 구독 필터 조건
 	is_active BOOLEAN DEFAULT TRUE,       -- 구독 활성화 상태
 	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-	FOREIGN KEY (subscribed_node_id) REFERENCES nodes(id) ON DELETE CASCADE
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 );
 
 -- 의존성 타입 레지스트리
@@ -190,18 +192,30 @@ CREATE TABLE IF NOT EXISTS dependency_impact_analysis (
 	FOREIGN KEY (source_node_id) REFERENCES nodes(id) ON DELETE CASCADE
 );
 
--- 노드 이벤트 로그 테이블
+-- 노드 이벤트 로그 테이블 (외래 키 없음: 노드 삭제 후에도 삭제 이벤트를 보존)
 CREATE TABLE IF NOT EXISTS node_events (
 	id INTEGER PRIMARY KEY AUTOINCREMENT,
 	node_id INTEGER NOT NULL,
 	event_type TEXT NOT NULL,             -- 'created', 'updated', 'deleted', 'attribute_changed'
 	event_data TEXT,                      -- JSON: 이벤트 상세 데이터
 	occurred_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-	processed_at DATETIME,                -- 처리 완료 시간
-	FOREIGN KEY (node_id) REFERENCES nodes(id) ON DELETE CASCADE
+	processed_at DATETIME                 -- 처리 완료 시간
+);
+
+-- 구독별 이벤트 전달 기록 테이블
+CREATE TABLE IF NOT EXISTS subscription_deliveries (
+	subscription_id INTEGER NOT NULL,
+	event_id INTEGER NOT NULL,
+	attempts INTEGER NOT NULL DEFAULT 0,  -- 시도 횟수
+	delivered_at DATETIME,                -- 전달 성공 시간 (NULL: 재시도 후 포기)
+	last_error TEXT,                      -- 마지막 실패 원인
+	recorded_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (subscription_id, event_id),
+	FOREIGN KEY (subscription_id) REFERENCES node_subscriptions(id) ON DELETE CASCADE
 );
 
 -- 인덱스 생성
+CREATE INDEX IF NOT EXISTS idx_domains_created_id ON domains(julianday(created_at), id); -- list_domains 커서 페이지네이션
 CREATE INDEX IF NOT EXISTS idx_nodes_domain ON nodes(domain_id);
 CREATE INDEX IF NOT EXISTS idx_nodes_content ON nodes(content);
 CREATE INDEX IF NOT EXISTS idx_attributes_domain ON attributes(domain_id);